@@ -132,6 +132,11 @@ const (
 	CliTxForceReset                     = "transaction-force-reset"
 	CliFlagMaxFiles                     = "maxFiles"
 	CliFlagMaxBytes                     = "maxBytes"
+	CliFlagSoftBytes                    = "softBytes"
+	CliFlagHardBytes                    = "hardBytes"
+	CliFlagSoftInodes                   = "softInodes"
+	CliFlagHardInodes                   = "hardInodes"
+	CliFlagGracePeriod                  = "gracePeriod"
 	CliFlagMaxConcurrencyInode          = "maxConcurrencyInode"
 	CliFlagForceInode                   = "forceInode"
 	CliFlagEnableQuota                  = "enableQuota"
@@ -153,6 +158,7 @@ const (
 	CliFlagTrashInterval                = "trashInterval"
 	CliFlagAccessTimeValidInterval      = "accessTimeValidInterval"
 	CliFlagEnablePersistAccessTime      = "enablePersistAccessTime"
+	CliFlagInlineDataThreshold          = "inlineDataThreshold"
 	CliFlagDecommissionRaftForce        = "raftForceDel"
 	CliFLagDecommissionWeight           = "decommissionWeight"
 	CliFlagDecommissionDstNodeSet       = "decommissionDstNodeSet"
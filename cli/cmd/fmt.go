@@ -288,6 +288,7 @@ func formatSimpleVolView(svv *proto.SimpleVolView) string {
 	sb.WriteString(fmt.Sprintf("  AccessTimeValidInterval         : %v\n", time.Duration(svv.AccessTimeInterval)*time.Second))
 	sb.WriteString(fmt.Sprintf("  MetaLeaderRetryTimeout          : %v\n", time.Duration(svv.LeaderRetryTimeOut)*time.Second))
 	sb.WriteString(fmt.Sprintf("  EnablePersistAccessTime         : %v\n", svv.EnablePersistAccessTime))
+	sb.WriteString(fmt.Sprintf("  InlineDataThreshold             : %v\n", strutil.FormatSize(uint64(svv.InlineDataThreshold))))
 	sb.WriteString(fmt.Sprintf("  ForbidWriteOpOfProtoVer0        : %v\n", svv.ForbidWriteOpOfProtoVer0))
 	if svv.Forbidden && svv.Status == 1 {
 		sb.WriteString(fmt.Sprintf("  DeleteDelayTime                 : %v\n", time.Until(svv.DeleteExecTime)))
@@ -1284,6 +1285,38 @@ func formatQuotaInfo(info *proto.QuotaInfo) string {
 	return ret
 }
 
+var userQuotaTableRowPattern = "%-6v %-10v %-2v    %-12v %-12v %-12v %-10v    %-12v %-12v %-12v %-10v"
+
+func formatUserQuotaTableHeader() string {
+	return fmt.Sprintf(userQuotaTableRowPattern, "TYPE", "ID", "", "USEDBYTES", "SOFTBYTES", "HARDBYTES", "GRACE",
+		"USEDINODES", "SOFTINODES", "HARDINODES", "GRACE")
+}
+
+// formatUserQuotaInfo formats the quota like repquota, the flags show if the bytes and inodes are
+// above the soft limits, the grace is the time left before the soft limit is enforced.
+func formatUserQuotaInfo(info *proto.UserQuotaInfo, now int64) string {
+	flags := []byte("--")
+	if info.SoftBytes != 0 && info.UsedInfo.UsedBytes > 0 && uint64(info.UsedInfo.UsedBytes) > info.SoftBytes {
+		flags[0] = '+'
+	}
+	if info.SoftInodes != 0 && info.UsedInfo.UsedInodes > 0 && uint64(info.UsedInfo.UsedInodes) > info.SoftInodes {
+		flags[1] = '+'
+	}
+	return fmt.Sprintf(userQuotaTableRowPattern, info.Type, info.Id, string(flags),
+		info.UsedInfo.UsedBytes, info.SoftBytes, info.HardBytes, formatUserQuotaGrace(info.BytesGraceEnd, now),
+		info.UsedInfo.UsedInodes, info.SoftInodes, info.HardInodes, formatUserQuotaGrace(info.InodesGraceEnd, now))
+}
+
+func formatUserQuotaGrace(graceEnd int64, now int64) string {
+	if graceEnd == 0 {
+		return ""
+	}
+	if now >= graceEnd {
+		return "none"
+	}
+	return (time.Duration(graceEnd-now) * time.Second).String()
+}
+
 var badDiskDetailTableRowPattern = "%-18v    %-18v    %-18v    %-18v    %-18v"
 
 func formatBadDiskTableHeader() string {
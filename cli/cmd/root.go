@@ -78,6 +78,7 @@ func NewRootCmd(client *master.MasterClient) *CubeFSCmd {
 		newAclCmd(client),
 		newUidCmd(client),
 		newQuotaCmd(client),
+		newUserQuotaCmd(client),
 		newDiskCmd(client),
 		newVersionCmd(client),
 		newFlashNodeCmd(client),
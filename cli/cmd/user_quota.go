@@ -0,0 +1,150 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/sdk/master"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cmdUserQuotaUse          = "userquota [COMMAND]"
+	cmdUserQuotaShort        = "Manage user and group quota"
+	cmdUserQuotaSetUse       = "set [volname] [user|group] [id]"
+	cmdUserQuotaSetShort     = "set quota of user or group, the limit of 0 means no limit"
+	cmdUserQuotaDeleteUse    = "delete [volname] [user|group] [id]"
+	cmdUserQuotaDeleteShort  = "delete quota of user or group"
+	cmdUserQuotaReportUse    = "report [volname]"
+	cmdUserQuotaReportShort  = "report usage and quota of users and groups"
+	cmdUserQuotaDefaultGrace = time.Duration(proto.DefaultUserQuotaGracePeriod) * time.Second
+)
+
+func newUserQuotaCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   cmdUserQuotaUse,
+		Short: cmdUserQuotaShort,
+		Args:  cobra.MinimumNArgs(0),
+	}
+	cmd.AddCommand(
+		newUserQuotaSetCmd(client),
+		newUserQuotaDeleteCmd(client),
+		newUserQuotaReportCmd(client),
+	)
+	return cmd
+}
+
+func parseUserQuotaArgs(args []string) (quotaType proto.UserQuotaType, id uint32, err error) {
+	if quotaType, err = proto.ParseUserQuotaType(args[1]); err != nil {
+		return
+	}
+	var tmp uint64
+	if tmp, err = strconv.ParseUint(args[2], 10, 32); err != nil {
+		return
+	}
+	id = uint32(tmp)
+	return
+}
+
+func newUserQuotaSetCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		softBytes   uint64
+		hardBytes   uint64
+		softInodes  uint64
+		hardInodes  uint64
+		gracePeriod time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   cmdUserQuotaSetUse,
+		Short: cmdUserQuotaSetShort,
+		Args:  cobra.MinimumNArgs(3),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			req := &proto.SetUserQuotaRequest{
+				VolName:     args[0],
+				SoftBytes:   softBytes,
+				HardBytes:   hardBytes,
+				SoftInodes:  softInodes,
+				HardInodes:  hardInodes,
+				GracePeriod: int64(gracePeriod / time.Second),
+			}
+			if req.Type, req.Id, err = parseUserQuotaArgs(args); err != nil {
+				stdout("set user quota failed, args %v error %v.\n", args, err)
+				return
+			}
+			if err = client.AdminAPI().SetUserQuota(req); err != nil {
+				stdout("volName %v %v %v user quota set failed(%v)\n", req.VolName, req.Type, req.Id, err)
+				return
+			}
+			stdout("setUserQuota: volName %v %v %v softBytes %v hardBytes %v softInodes %v hardInodes %v gracePeriod %v success.\n",
+				req.VolName, req.Type, req.Id, softBytes, hardBytes, softInodes, hardInodes, gracePeriod)
+		},
+	}
+	cmd.Flags().Uint64Var(&softBytes, CliFlagSoftBytes, 0, "Specify soft limit of bytes")
+	cmd.Flags().Uint64Var(&hardBytes, CliFlagHardBytes, 0, "Specify hard limit of bytes")
+	cmd.Flags().Uint64Var(&softInodes, CliFlagSoftInodes, 0, "Specify soft limit of inodes")
+	cmd.Flags().Uint64Var(&hardInodes, CliFlagHardInodes, 0, "Specify hard limit of inodes")
+	cmd.Flags().DurationVar(&gracePeriod, CliFlagGracePeriod, cmdUserQuotaDefaultGrace, "Specify how long the soft limits can be exceeded")
+	return cmd
+}
+
+func newUserQuotaDeleteCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   cmdUserQuotaDeleteUse,
+		Short: cmdUserQuotaDeleteShort,
+		Args:  cobra.MinimumNArgs(3),
+		Run: func(cmd *cobra.Command, args []string) {
+			volName := args[0]
+			quotaType, id, err := parseUserQuotaArgs(args)
+			if err != nil {
+				stdout("delete user quota failed, args %v error %v.\n", args, err)
+				return
+			}
+			if err = client.AdminAPI().DeleteUserQuota(volName, quotaType, id); err != nil {
+				stdout("volName %v %v %v user quota delete failed(%v)\n", volName, quotaType, id, err)
+				return
+			}
+			stdout("deleteUserQuota: volName %v %v %v success.\n", volName, quotaType, id)
+		},
+	}
+	return cmd
+}
+
+func newUserQuotaReportCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   cmdUserQuotaReportUse,
+		Short: cmdUserQuotaReportShort,
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			volName := args[0]
+			quotas, err := client.AdminAPI().ListUserQuota(volName)
+			if err != nil {
+				stdout("volName %v user quota report failed(%v)\n", volName, err)
+				return
+			}
+			now := time.Now().Unix()
+			stdout("[user quotas]\n")
+			stdout("%v\n", formatUserQuotaTableHeader())
+			for _, quotaInfo := range quotas {
+				stdout("%v\n", formatUserQuotaInfo(quotaInfo, now))
+			}
+		},
+	}
+	return cmd
+}
@@ -384,6 +384,7 @@ func newVolUpdateCmd(client *master.MasterClient) *cobra.Command {
 	var optTrashInterval int64
 	var optAccessTimeValidInterval int64
 	var optEnablePersistAccessTime string
+	var optInlineDataThreshold int64
 	var optVolStorageClass int
 	var optForbidWriteOpOfProtoVer0 string
 	var optVolQuotaClass int
@@ -698,6 +699,22 @@ func newVolUpdateCmd(client *master.MasterClient) *cobra.Command {
 			} else {
 				confirmString.WriteString(fmt.Sprintf("  EnablePersistAccessTime        : %v \n", vv.EnablePersistAccessTime))
 			}
+
+			if optInlineDataThreshold >= 0 {
+				if optInlineDataThreshold > proto.MaxInlineDataThreshold {
+					err = fmt.Errorf("InlineDataThreshold can't be greater than %v\n", proto.MaxInlineDataThreshold)
+					return
+				}
+				if optInlineDataThreshold != vv.InlineDataThreshold {
+					isChange = true
+					confirmString.WriteString(fmt.Sprintf("  InlineDataThreshold            : %v B -> %v B\n", vv.InlineDataThreshold, optInlineDataThreshold))
+					vv.InlineDataThreshold = optInlineDataThreshold
+				} else {
+					confirmString.WriteString(fmt.Sprintf("  InlineDataThreshold            : %v B\n", vv.InlineDataThreshold))
+				}
+			} else {
+				confirmString.WriteString(fmt.Sprintf("  InlineDataThreshold            : %v B\n", vv.InlineDataThreshold))
+			}
 			if optEnableDpAutoMetaRepair != "" {
 				enable := false
 				if enable, err = strconv.ParseBool(optEnableDpAutoMetaRepair); err != nil {
@@ -924,6 +941,8 @@ func newVolUpdateCmd(client *master.MasterClient) *cobra.Command {
 	cmd.Flags().Int64Var(&optTrashInterval, CliFlagTrashInterval, -1, "The retention period for files in trash")
 	cmd.Flags().Int64Var(&optAccessTimeValidInterval, CliFlagAccessTimeValidInterval, -1, fmt.Sprintf("Effective time interval for accesstime, at least %v [Unit: second]", proto.MinAccessTimeValidInterval))
 	cmd.Flags().StringVar(&optEnablePersistAccessTime, CliFlagEnablePersistAccessTime, "", "true/false to enable/disable persisting access time")
+	cmd.Flags().Int64Var(&optInlineDataThreshold, CliFlagInlineDataThreshold, -1,
+		fmt.Sprintf("Keep the files not larger than it inline in their inodes, at most %v, 0 to disable [Unit: byte]", proto.MaxInlineDataThreshold))
 	cmd.Flags().StringVar(&optForbidWriteOpOfProtoVer0, CliForbidWriteOpOfProtoVersion0, "",
 		"set volume forbid write operates of packet whose protocol version is version-0: [true | false]")
 
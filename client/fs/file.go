@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"math"
 	"path"
 	"strings"
 	"sync"
@@ -57,6 +58,7 @@ var (
 	_ fs.HandleReader      = (*File)(nil)
 	_ fs.HandleWriter      = (*File)(nil)
 	_ fs.HandleFlusher     = (*File)(nil)
+	_ fs.HandleLocker      = (*File)(nil)
 	_ fs.NodeFsyncer       = (*File)(nil)
 	_ fs.NodeSetattrer     = (*File)(nil)
 	_ fs.NodeReadlinker    = (*File)(nil)
@@ -390,6 +392,13 @@ func (f *File) Release(ctx context.Context, req *fuse.ReleaseRequest) (err error
 	//	f.fWriter.Close()
 	// }
 
+	if req.ReleaseFlags&fuse.ReleaseFlockUnlock != 0 {
+		lock := &proto.PosixLock{Type: proto.PosixLockUnlock, Flock: true, End: math.MaxUint64, Owner: uint64(req.LockOwner)}
+		if err = f.super.mw.SetLock(ctx, ino, lock, false); err != nil {
+			log.LogWarnf("Release: unlock flock failed, ino(%v) req(%v) err(%v)", ino, req, err)
+		}
+	}
+
 	err = f.super.ec.CloseStream(ino)
 	if err != nil {
 		log.LogErrorf("Release: close writer failed, ino(%v) req(%v) err(%v)", ino, req, err)
@@ -918,6 +927,88 @@ func (f *File) Removexattr(ctx context.Context, req *fuse.RemovexattrRequest) er
 	return nil
 }
 
+func newPosixLock(lock fuse.FileLock, owner fuse.LockOwner, flags fuse.LockFlags) *proto.PosixLock {
+	lk := &proto.PosixLock{
+		Flock: flags&fuse.LockFlock != 0,
+		Start: lock.Start,
+		End:   lock.End,
+		Owner: uint64(owner),
+		Pid:   uint32(lock.PID),
+	}
+	switch lock.Type {
+	case fuse.LockRead:
+		lk.Type = proto.PosixLockRead
+	case fuse.LockWrite:
+		lk.Type = proto.PosixLockWrite
+	default:
+		lk.Type = proto.PosixLockUnlock
+	}
+	if lk.Flock {
+		lk.Start, lk.End = 0, math.MaxUint64
+	}
+	return lk
+}
+
+func (f *File) setLock(ctx context.Context, op string, lk *proto.PosixLock, wait bool) (err error) {
+	ino := f.info.Inode
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat(op, err, bgTime, 1)
+	}()
+
+	start := time.Now()
+	err = f.super.mw.SetLock(ctx, ino, lk, wait)
+	if err != nil {
+		if err != syscall.EAGAIN && err != syscall.EINTR {
+			log.LogErrorf("%v: ino(%v) lock(%v) err(%v)", op, ino, lk, err)
+		}
+		return ParseError(err)
+	}
+	log.LogDebugf("TRACE %v: ino(%v) lock(%v) (%v)ns", op, ino, lk, time.Since(start).Nanoseconds())
+	return nil
+}
+
+// Setlk acquires or releases a posix lock or flock without waiting.
+func (f *File) Setlk(ctx context.Context, req *fuse.LockRequest) error {
+	return f.setLock(ctx, "Setlk", newPosixLock(req.Lock, req.LockOwner, req.LockFlags), false)
+}
+
+// Setlkw acquires or releases a posix lock or flock, it waits until the lock is released by others.
+func (f *File) Setlkw(ctx context.Context, req *fuse.LockWaitRequest) error {
+	return f.setLock(ctx, "Setlkw", newPosixLock(req.Lock, req.LockOwner, req.LockFlags), true)
+}
+
+// Getlk returns the lock conflicting with the requested one, if any.
+func (f *File) Getlk(ctx context.Context, req *fuse.QueryLockRequest, resp *fuse.QueryLockResponse) (err error) {
+	ino := f.info.Inode
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("Getlk", err, bgTime, 1)
+	}()
+
+	lk := newPosixLock(req.Lock, req.LockOwner, req.LockFlags)
+	conflict, err := f.super.mw.GetLock(ino, lk)
+	if err != nil {
+		log.LogErrorf("Getlk: ino(%v) lock(%v) err(%v)", ino, lk, err)
+		return ParseError(err)
+	}
+	if conflict == nil {
+		return nil
+	}
+
+	resp.Lock = fuse.FileLock{
+		Start: conflict.Start,
+		End:   conflict.End,
+		Type:  fuse.LockRead,
+		PID:   int32(conflict.Pid),
+	}
+	if conflict.Type == proto.PosixLockWrite {
+		resp.Lock.Type = fuse.LockWrite
+	}
+	log.LogDebugf("TRACE Getlk: ino(%v) lock(%v) conflict(%v)", ino, lk, conflict)
+	return nil
+}
+
 func (f *File) fileSize(ino uint64) (size int, gen uint64) {
 	size, gen, valid := f.super.ec.FileSize(ino)
 	if !valid {
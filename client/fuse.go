@@ -882,6 +882,10 @@ func mount(opt *proto.MountOptions) (fsConn *fuse.Conn, super *cfs.Super, err er
 		options = append(options, fuse.DefaultPermissions())
 	}
 
+	if opt.EnableFileLock {
+		options = append(options, fuse.LockingFlock(), fuse.LockingPOSIX())
+	}
+
 	fsConn, err = fuse.Mount(opt.MountPoint, opt.NeedRestoreFuse, options...)
 	return
 }
@@ -1054,6 +1058,7 @@ func parseMountOption(cfg *config.Config) (*proto.MountOptions, error) {
 	opt.MinimumNlinkReadDir = GlobalMountOptions[proto.MinimumNlinkReadDir].GetInt64()
 	opt.InodeLruLimit = GlobalMountOptions[proto.InodeLruLimit].GetInt64()
 	opt.FuseServeThreads = GlobalMountOptions[proto.FuseServeThreads].GetInt64()
+	opt.EnableFileLock = GlobalMountOptions[proto.EnableFileLock].GetBool()
 
 	if opt.MountPoint == "" || opt.Volname == "" || opt.Owner == "" || opt.Master == "" {
 		return nil, errors.New(fmt.Sprintf("invalid config file: lack of mandatory fields, mountPoint(%v), volName(%v), owner(%v), masterAddr(%v)", opt.MountPoint, opt.Volname, opt.Owner, opt.Master))
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	syslog "log"
+	"math"
 	"os"
 	gopath "path"
 	"strings"
@@ -159,6 +160,7 @@ type (
 		fileReader *blobstore.Reader
 
 		closed bool
+		locked bool
 		path   string
 	}
 
@@ -489,6 +491,7 @@ func (f *File) CloseFile() (err error) {
 	if f == nil {
 		return syscall.EBADFD
 	}
+	f.client.releaseLocks(f)
 
 	// Consistent with cfs open, do close and closeStream only if f is regular file
 	if proto.IsRegular(info.Mode) {
@@ -1305,6 +1308,136 @@ func (c *Client) Link(srcPath, dstPath string) error {
 	return nil
 }
 
+// Flock applies or removes an advisory lock on the open file like flock(2), how is one of
+// syscall.LOCK_SH, syscall.LOCK_EX and syscall.LOCK_UN, optionally ORed with syscall.LOCK_NB.
+// The lock is shared with the other clients through the metanode.
+func (f *File) Flock(how int) error {
+	if f.closed {
+		return syscall.EBADFD
+	}
+
+	lk := &proto.PosixLock{Flock: true, End: math.MaxUint64, Owner: uint64(f.fd)}
+	switch how &^ syscall.LOCK_NB {
+	case syscall.LOCK_SH:
+		lk.Type = proto.PosixLockRead
+	case syscall.LOCK_EX:
+		lk.Type = proto.PosixLockWrite
+	case syscall.LOCK_UN:
+		lk.Type = proto.PosixLockUnlock
+	default:
+		return syscall.EINVAL
+	}
+	if err := f.client.mw.SetLock(context.Background(), f.ino, lk, how&syscall.LOCK_NB == 0); err != nil {
+		if err == syscall.EAGAIN {
+			return syscall.EWOULDBLOCK
+		}
+		return err
+	}
+	f.locked = true
+	return nil
+}
+
+// SetLock acquires or releases a posix byte-range lock like fcntl(2) F_SETLK, or F_SETLKW if wait
+// is true. Only SEEK_SET is supported for lk.Whence. The locks are owned by the client, so the
+// locks set through different files of the same client do not conflict with each other.
+func (f *File) SetLock(lk *syscall.Flock_t, wait bool) error {
+	if f.closed {
+		return syscall.EBADFD
+	}
+
+	posixLock, err := newPosixLock(lk)
+	if err != nil {
+		return err
+	}
+	if err = f.client.mw.SetLock(context.Background(), f.ino, posixLock, wait); err != nil {
+		return err
+	}
+	f.locked = true
+	return nil
+}
+
+// GetLock tests the posix byte-range lock like fcntl(2) F_GETLK, lk is replaced with the first
+// conflicting lock, or its type is set to syscall.F_UNLCK if the lock can be acquired.
+func (f *File) GetLock(lk *syscall.Flock_t) error {
+	if f.closed {
+		return syscall.EBADFD
+	}
+
+	posixLock, err := newPosixLock(lk)
+	if err != nil {
+		return err
+	}
+	conflict, err := f.client.mw.GetLock(f.ino, posixLock)
+	if err != nil {
+		return err
+	}
+	if conflict == nil {
+		lk.Type = syscall.F_UNLCK
+		return nil
+	}
+
+	lk.Type = syscall.F_RDLCK
+	if conflict.Type == proto.PosixLockWrite {
+		lk.Type = syscall.F_WRLCK
+	}
+	lk.Whence = io.SeekStart
+	lk.Start = int64(conflict.Start)
+	lk.Len = 0
+	if conflict.End != math.MaxUint64 {
+		lk.Len = int64(conflict.End - conflict.Start + 1)
+	}
+	lk.Pid = int32(conflict.Pid)
+	return nil
+}
+
+func newPosixLock(lk *syscall.Flock_t) (*proto.PosixLock, error) {
+	if lk.Whence != io.SeekStart {
+		return nil, syscall.EINVAL
+	}
+	start, end := lk.Start, int64(-1)
+	if lk.Len > 0 {
+		end = start + lk.Len - 1
+	} else if lk.Len < 0 {
+		start, end = start+lk.Len, start-1
+	}
+	if start < 0 {
+		return nil, syscall.EINVAL
+	}
+
+	posixLock := &proto.PosixLock{Start: uint64(start), End: math.MaxUint64, Pid: uint32(os.Getpid())}
+	if end >= 0 {
+		posixLock.End = uint64(end)
+	}
+	switch lk.Type {
+	case syscall.F_RDLCK:
+		posixLock.Type = proto.PosixLockRead
+	case syscall.F_WRLCK:
+		posixLock.Type = proto.PosixLockWrite
+	case syscall.F_UNLCK:
+		posixLock.Type = proto.PosixLockUnlock
+	default:
+		return nil, syscall.EINVAL
+	}
+	return posixLock, nil
+}
+
+// releaseLocks releases the flock of the file and the posix locks of the client on the inode,
+// as closing a file descriptor does.
+func (c *Client) releaseLocks(f *File) {
+	if !f.locked {
+		return
+	}
+	unlocks := []*proto.PosixLock{
+		{Type: proto.PosixLockUnlock, Flock: true, End: math.MaxUint64, Owner: uint64(f.fd)},
+		{Type: proto.PosixLockUnlock, End: math.MaxUint64},
+	}
+	for _, lk := range unlocks {
+		if err := c.mw.SetLock(context.Background(), f.ino, lk, false); err != nil {
+			log.LogWarnf("releaseLocks: ino(%v) fd(%v) lock(%v) err(%v)", f.ino, f.fd, lk, err)
+		}
+	}
+}
+
 func (f *File) Fd() uint {
 	return f.fd
 }
@@ -172,6 +172,8 @@ extern int cfs_rmdir(int64_t id, char* path);
 extern int cfs_unlink(int64_t id, char* path);
 extern int cfs_rename(int64_t id, char* from, char* to, GoUint8 overwritten);
 extern int cfs_fchmod(int64_t id, int fd, mode_t mode);
+extern int cfs_flock(int64_t id, int fd, int how);
+extern int cfs_fcntl_lock(int64_t id, int fd, int cmd, struct flock* lk);
 extern int cfs_getsummary(int64_t id, char* path, struct cfs_summary_info* summary, char* useCache, int goroutine_num);
 extern int64_t cfs_lock_dir(int64_t id, char *path, int64_t lease, int64_t lock_id);
 extern int cfs_unlock_dir(int64_t id, char *path);
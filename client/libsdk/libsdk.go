@@ -101,6 +101,7 @@ import (
 	"fmt"
 	"io"
 	syslog "log"
+	"math"
 	"os"
 	"path"
 	gopath "path"
@@ -234,6 +235,7 @@ type file struct {
 	path         string
 	storageClass uint32
 	openForWrite bool
+	locked       bool
 }
 
 type dirStream struct {
@@ -855,6 +857,9 @@ func cfs_close(id C.int64_t, fd C.int) {
 	}
 
 	f = c.releaseFD(uint(fd))
+	if f != nil {
+		c.releaseLocks(f)
+	}
 	// Consistent with cfs open, do close and closeStream only if f is regular file
 	if f != nil && info != nil && proto.IsRegular(info.Mode) {
 		c.flush(f)
@@ -1396,6 +1401,136 @@ func cfs_fchmod(id C.int64_t, fd C.int, mode C.mode_t) C.int {
 	return statusOK
 }
 
+//export cfs_flock
+func cfs_flock(id C.int64_t, fd C.int, how C.int) C.int {
+	c, exist := getClient(int64(id))
+	if !exist {
+		return statusEINVAL
+	}
+
+	f := c.getFile(uint(fd))
+	if f == nil {
+		return statusEBADFD
+	}
+
+	lk := &proto.PosixLock{Flock: true, End: math.MaxUint64, Owner: uint64(fd)}
+	switch int(how) &^ syscall.LOCK_NB {
+	case syscall.LOCK_SH:
+		lk.Type = proto.PosixLockRead
+	case syscall.LOCK_EX:
+		lk.Type = proto.PosixLockWrite
+	case syscall.LOCK_UN:
+		lk.Type = proto.PosixLockUnlock
+	default:
+		return statusEINVAL
+	}
+	if err := c.mw.SetLock(context.Background(), f.ino, lk, int(how)&syscall.LOCK_NB == 0); err != nil {
+		if err == syscall.EAGAIN {
+			return errorToStatus(syscall.EWOULDBLOCK)
+		}
+		return errorToStatus(err)
+	}
+	f.locked = true
+	return statusOK
+}
+
+//export cfs_fcntl_lock
+func cfs_fcntl_lock(id C.int64_t, fd C.int, cmd C.int, lk *C.struct_flock) C.int {
+	c, exist := getClient(int64(id))
+	if !exist {
+		return statusEINVAL
+	}
+
+	f := c.getFile(uint(fd))
+	if f == nil {
+		return statusEBADFD
+	}
+
+	posixLock, err := newPosixLock(lk)
+	if err != nil {
+		return errorToStatus(err)
+	}
+
+	switch int(cmd) {
+	case syscall.F_SETLK, syscall.F_SETLKW:
+		if err = c.mw.SetLock(context.Background(), f.ino, posixLock, int(cmd) == syscall.F_SETLKW); err != nil {
+			return errorToStatus(err)
+		}
+		f.locked = true
+	case syscall.F_GETLK:
+		conflict, err := c.mw.GetLock(f.ino, posixLock)
+		if err != nil {
+			return errorToStatus(err)
+		}
+		if conflict == nil {
+			lk.l_type = C.F_UNLCK
+			return statusOK
+		}
+		lk.l_type = C.F_RDLCK
+		if conflict.Type == proto.PosixLockWrite {
+			lk.l_type = C.F_WRLCK
+		}
+		lk.l_whence = C.SEEK_SET
+		lk.l_start = C.off_t(conflict.Start)
+		lk.l_len = 0
+		if conflict.End != math.MaxUint64 {
+			lk.l_len = C.off_t(conflict.End - conflict.Start + 1)
+		}
+		lk.l_pid = C.pid_t(conflict.Pid)
+	default:
+		return statusEINVAL
+	}
+	return statusOK
+}
+
+func newPosixLock(lk *C.struct_flock) (*proto.PosixLock, error) {
+	if lk.l_whence != C.SEEK_SET {
+		return nil, syscall.EINVAL
+	}
+	start, end := int64(lk.l_start), int64(-1)
+	if lk.l_len > 0 {
+		end = start + int64(lk.l_len) - 1
+	} else if lk.l_len < 0 {
+		start, end = start+int64(lk.l_len), start-1
+	}
+	if start < 0 {
+		return nil, syscall.EINVAL
+	}
+
+	posixLock := &proto.PosixLock{Start: uint64(start), End: math.MaxUint64, Pid: uint32(os.Getpid())}
+	if end >= 0 {
+		posixLock.End = uint64(end)
+	}
+	switch lk.l_type {
+	case C.F_RDLCK:
+		posixLock.Type = proto.PosixLockRead
+	case C.F_WRLCK:
+		posixLock.Type = proto.PosixLockWrite
+	case C.F_UNLCK:
+		posixLock.Type = proto.PosixLockUnlock
+	default:
+		return nil, syscall.EINVAL
+	}
+	return posixLock, nil
+}
+
+// releaseLocks releases the flock of the file and the posix locks of the client on the inode,
+// as closing a file descriptor does.
+func (c *client) releaseLocks(f *file) {
+	if !f.locked {
+		return
+	}
+	unlocks := []*proto.PosixLock{
+		{Type: proto.PosixLockUnlock, Flock: true, End: math.MaxUint64, Owner: uint64(f.fd)},
+		{Type: proto.PosixLockUnlock, End: math.MaxUint64},
+	}
+	for _, lk := range unlocks {
+		if err := c.mw.SetLock(context.Background(), f.ino, lk, false); err != nil {
+			log.LogWarnf("releaseLocks: ino(%v) fd(%v) lock(%v) err(%v)", f.ino, f.fd, lk, err)
+		}
+	}
+}
+
 //export cfs_getsummary
 func cfs_getsummary(id C.int64_t, path *C.char, summary *C.struct_cfs_summary_info, useCache *C.char, goroutine_num C.int) C.int {
 	c, exist := getClient(int64(id))
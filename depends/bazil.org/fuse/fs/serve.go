@@ -294,8 +294,6 @@ func nodeAttr(ctx context.Context, n Node, attr *fuse.Attr) error {
 // Other FUSE requests can be handled by implementing methods from the
 // Handle* interfaces. The most common to implement are HandleReader,
 // HandleReadDirer, and HandleWriter.
-//
-// TODO implement methods: Getlk, Setlk, Setlkw
 type Handle interface {
 }
 
@@ -350,6 +348,32 @@ type HandleReleaser interface {
 	Release(ctx context.Context, req *fuse.ReleaseRequest) error
 }
 
+// HandleLocker handles the flock and POSIX byte-range locks of the
+// handle, which are enabled by the LockingFlock and LockingPOSIX mount
+// options. Without this, the requests fail with ENOTSUP.
+//
+// The methods are named after the FUSE opcodes, so that handles
+// embedding a sync.Mutex can implement them.
+type HandleLocker interface {
+	// Setlk tries to acquire or release a lock on a byte range of the
+	// node. If a conflicting lock is already held, returns
+	// syscall.EAGAIN.
+	Setlk(ctx context.Context, req *fuse.LockRequest) error
+
+	// Setlkw acquires or releases a lock on a byte range of the node,
+	// waiting until the lock can be obtained (or context is canceled).
+	Setlkw(ctx context.Context, req *fuse.LockWaitRequest) error
+
+	// Getlk returns the current state of locks held for the byte
+	// range of the node.
+	//
+	// See QueryLockRequest for details on how to respond.
+	//
+	// To simplify implementing this method, resp.Lock is prefilled to
+	// have Lock.Type LockUnlock, meaning no conflicting lock found.
+	Getlk(ctx context.Context, req *fuse.QueryLockRequest, resp *fuse.QueryLockResponse) error
+}
+
 type Config struct {
 	// Function to send debug log messages to. If nil, use fuse.Debug.
 	// Note that changing this or fuse.Debug may not affect existing
@@ -2028,6 +2052,59 @@ func (c *Server) handleRequest(ctx context.Context, node Node, snode *serveNode,
 		r.Respond()
 		return nil
 
+	case *fuse.LockRequest:
+		shandle := c.getHandle(r.Handle)
+		if shandle == nil {
+			return fuse.ESTALE
+		}
+		h, ok := shandle.handle.(HandleLocker)
+		if !ok {
+			return fuse.ENOTSUP
+		}
+		if err := h.Setlk(ctx, r); err != nil {
+			return err
+		}
+		done(nil)
+		r.Respond()
+		return nil
+
+	case *fuse.LockWaitRequest:
+		shandle := c.getHandle(r.Handle)
+		if shandle == nil {
+			return fuse.ESTALE
+		}
+		h, ok := shandle.handle.(HandleLocker)
+		if !ok {
+			return fuse.ENOTSUP
+		}
+		if err := h.Setlkw(ctx, r); err != nil {
+			return err
+		}
+		done(nil)
+		r.Respond()
+		return nil
+
+	case *fuse.QueryLockRequest:
+		shandle := c.getHandle(r.Handle)
+		if shandle == nil {
+			return fuse.ESTALE
+		}
+		h, ok := shandle.handle.(HandleLocker)
+		if !ok {
+			return fuse.ENOTSUP
+		}
+		s := &fuse.QueryLockResponse{
+			Lock: fuse.FileLock{
+				Type: fuse.LockUnlock,
+			},
+		}
+		if err := h.Getlk(ctx, r, s); err != nil {
+			return err
+		}
+		done(s)
+		r.Respond(s)
+		return nil
+
 	case *fuse.DestroyRequest:
 		if fs, ok := c.fs.(FSDestroyer); ok {
 			fs.Destroy()
@@ -2118,9 +2195,6 @@ func (c *Server) handleRequest(ctx context.Context, node Node, snode *serveNode,
 		/*	case *FsyncdirRequest:
 				return ENOSYS
 
-			case *GetlkRequest, *SetlkRequest, *SetlkwRequest:
-				return ENOSYS
-
 			case *BmapRequest:
 				return ENOSYS
 
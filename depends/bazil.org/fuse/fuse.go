@@ -877,7 +877,7 @@ loop:
 			Handle:       HandleID(in.Fh),
 			Flags:        openFlags(in.Flags),
 			ReleaseFlags: ReleaseFlags(in.ReleaseFlags),
-			LockOwner:    in.LockOwner,
+			LockOwner:    LockOwner(in.LockOwner),
 		}
 
 	case opFsync, opFsyncdir:
@@ -980,11 +980,45 @@ loop:
 		}
 
 	case opGetlk:
-		panic("opGetlk")
-	case opSetlk:
-		panic("opSetlk")
-	case opSetlkw:
-		panic("opSetlkw")
+		in := (*lkIn)(m.data())
+		if m.len() < lkInSize(c.proto) {
+			goto corrupt
+		}
+		req = &QueryLockRequest{
+			Header:    m.Header(),
+			Handle:    HandleID(in.Fh),
+			LockOwner: LockOwner(in.Owner),
+			Lock: FileLock{
+				Start: in.Lk.Start,
+				End:   in.Lk.End,
+				Type:  LockType(in.Lk.Type),
+				PID:   int32(in.Lk.Pid),
+			},
+			LockFlags: LockFlags(in.LkFlags),
+		}
+
+	case opSetlk, opSetlkw:
+		in := (*lkIn)(m.data())
+		if m.len() < lkInSize(c.proto) {
+			goto corrupt
+		}
+		tmp := LockRequest{
+			Header:    m.Header(),
+			Handle:    HandleID(in.Fh),
+			LockOwner: LockOwner(in.Owner),
+			Lock: FileLock{
+				Start: in.Lk.Start,
+				End:   in.Lk.End,
+				Type:  LockType(in.Lk.Type),
+				PID:   int32(in.Lk.Pid),
+			},
+			LockFlags: LockFlags(in.LkFlags),
+		}
+		if m.hdr.Opcode == opSetlkw {
+			req = (*LockWaitRequest)(&tmp)
+		} else {
+			req = &tmp
+		}
 
 	case opAccess:
 		in := (*accessIn)(m.data())
@@ -1824,13 +1858,13 @@ type ReleaseRequest struct {
 	Handle       HandleID
 	Flags        OpenFlags // flags from OpenRequest
 	ReleaseFlags ReleaseFlags
-	LockOwner    uint32
+	LockOwner    LockOwner
 }
 
 var _ = Request(&ReleaseRequest{})
 
 func (r *ReleaseRequest) String() string {
-	return fmt.Sprintf("Release [%s] %v fl=%v rfl=%v owner=%#x", &r.Header, r.Handle, r.Flags, r.ReleaseFlags, r.LockOwner)
+	return fmt.Sprintf("Release [%s] %v fl=%v rfl=%v owner=%v", &r.Header, r.Handle, r.Flags, r.ReleaseFlags, r.LockOwner)
 }
 
 // Respond replies to the request, indicating that the handle has been released.
@@ -1839,6 +1873,125 @@ func (r *ReleaseRequest) Respond() {
 	r.respond(buf)
 }
 
+// LockOwner is a file-local opaque identifier assigned by the kernel
+// to identify the owner of a particular lock.
+type LockOwner uint64
+
+func (o LockOwner) String() string {
+	if o == 0 {
+		return "0"
+	}
+	return fmt.Sprintf("%016x", uint64(o))
+}
+
+// FileLock describes a POSIX byte-range lock or a BSD flock, the End
+// is inclusive and math.MaxUint64 means the end of the file.
+type FileLock struct {
+	Start uint64
+	End   uint64
+	Type  LockType
+	PID   int32
+}
+
+func (l FileLock) String() string {
+	return fmt.Sprintf("%v %d-%d pid=%d", l.Type, l.Start, l.End, l.PID)
+}
+
+// LockRequest asks to try acquire or release a byte range lock on a
+// node. The response should be immediate, do not wait to obtain lock.
+//
+// Unlocking can be
+//
+//   - explicit with Lock.Type LockUnlock
+//   - for flock: implicit on final close (ReleaseRequest.ReleaseFlags
+//     has ReleaseFlockUnlock set)
+//
+// See LockFlags to know which kind of a lock is being requested. FUSE
+// always sees ranges, and treats flock whole-file locks as requests
+// for the maximum byte range.
+type LockRequest struct {
+	Header    `json:"-"`
+	Handle    HandleID
+	LockOwner LockOwner
+	Lock      FileLock
+	LockFlags LockFlags
+}
+
+var _ = Request(&LockRequest{})
+
+func (r *LockRequest) String() string {
+	return fmt.Sprintf("Lock [%s] %v owner=%v range=%d-%d type=%v pid=%v fl=%v", &r.Header, r.Handle, r.LockOwner, r.Lock.Start, r.Lock.End, r.Lock.Type, r.Lock.PID, r.LockFlags)
+}
+
+// Respond replies to the request, indicating that the lock was
+// granted.
+func (r *LockRequest) Respond() {
+	buf := newBuffer(0)
+	r.respond(buf)
+}
+
+// LockWaitRequest asks to acquire a byte range lock on a node,
+// delaying response until lock can be obtained (or the request is
+// interrupted).
+//
+// See LockRequest. LockWaitRequest can be converted to a LockRequest.
+type LockWaitRequest LockRequest
+
+var _ = Request(&LockWaitRequest{})
+
+func (r *LockWaitRequest) String() string {
+	return fmt.Sprintf("LockWait [%s] %v owner=%v range=%d-%d type=%v pid=%v fl=%v", &r.Header, r.Handle, r.LockOwner, r.Lock.Start, r.Lock.End, r.Lock.Type, r.Lock.PID, r.LockFlags)
+}
+
+// Respond replies to the request, indicating that the lock was
+// granted.
+func (r *LockWaitRequest) Respond() {
+	buf := newBuffer(0)
+	r.respond(buf)
+}
+
+// QueryLockRequest queries the lock status.
+//
+// If the lock could be placed, set response Lock.Type to
+// LockUnlock.
+//
+// If there are conflicting locks, the response should describe one
+// of them. For Open File Description locks, set PID to -1.
+type QueryLockRequest struct {
+	Header    `json:"-"`
+	Handle    HandleID
+	LockOwner LockOwner
+	Lock      FileLock
+	LockFlags LockFlags
+}
+
+var _ = Request(&QueryLockRequest{})
+
+func (r *QueryLockRequest) String() string {
+	return fmt.Sprintf("QueryLock [%s] %v owner=%v range=%d-%d type=%v pid=%v fl=%v", &r.Header, r.Handle, r.LockOwner, r.Lock.Start, r.Lock.End, r.Lock.Type, r.Lock.PID, r.LockFlags)
+}
+
+// Respond replies to the request.
+func (r *QueryLockRequest) Respond(resp *QueryLockResponse) {
+	buf := newBuffer(unsafe.Sizeof(lkOut{}))
+	out := (*lkOut)(buf.alloc(unsafe.Sizeof(lkOut{})))
+	out.Lk = fileLock{
+		Start: resp.Lock.Start,
+		End:   resp.Lock.End,
+		Type:  uint32(resp.Lock.Type),
+		Pid:   uint32(resp.Lock.PID),
+	}
+	r.respond(buf)
+}
+
+type QueryLockResponse struct {
+	Lock FileLock
+}
+
+func (r *QueryLockResponse) String() string {
+	return fmt.Sprintf("QueryLock range=%d-%d type=%v pid=%v", r.Lock.Start, r.Lock.End, r.Lock.Type, r.Lock.PID)
+}
+
 // A DestroyRequest is sent by the kernel when unmounting the file system.
 // No more requests will be received after this one, but it should still be
 // responded to.
@@ -324,6 +324,29 @@ func AllowNonEmptyMount() MountOption {
 	}
 }
 
+// LockingFlock enables flock-based (BSD) locking. This is mostly
+// useful for distributed filesystems with global locking. Without
+// this, kernel manages local locking automatically.
+func LockingFlock() MountOption {
+	return func(conf *mountConfig) error {
+		conf.initFlags |= InitFlockLocks
+		return nil
+	}
+}
+
+// LockingPOSIX enables POSIX byte-range (fcntl) locking. This is mostly
+// useful for distributed filesystems with global locking. Without
+// this, kernel manages local locking automatically.
+//
+// Beware POSIX locks are a broken API with unintuitive behavior for
+// callers.
+func LockingPOSIX() MountOption {
+	return func(conf *mountConfig) error {
+		conf.initFlags |= InitPosixLocks
+		return nil
+	}
+}
+
 // PosixACL enable posix ACL supported.
 func PosixACL() MountOption {
 	return func(conf *mountConfig) error {
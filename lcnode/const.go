@@ -68,6 +68,7 @@ var (
 	snapshotRoutineNumPerTask int
 	lcNodeTaskCountLimit      int
 	maxDirChanNum             = 1000000
+	inventoryMaxRowsPerFile   = 1000000
 	delayDelMinute            uint64
 	useCreateTime             bool
 )
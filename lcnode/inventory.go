@@ -0,0 +1,411 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lcnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/storage-inventory.html
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+	"github.com/google/uuid"
+)
+
+const (
+	inventoryManifestVersion = "2016-11-30"
+	inventoryTimeLayout      = "2006-01-02T15-04Z"
+	inventoryDateLayout      = "2006-01-02T15:04:05.000Z"
+	inventoryStorageStandard = "STANDARD"
+	inventoryLockCompliance  = "COMPLIANCE"
+	inventoryLegalHoldOn     = "ON"
+	inventoryLegalHoldOff    = "OFF"
+
+	inventoryColumnBucket = "Bucket"
+	inventoryColumnKey    = "Key"
+
+	inventoryMIMEGzip    = "application/gzip"
+	inventoryMIMEJSON    = "application/json"
+	inventoryMIMEParquet = "application/octet-stream"
+	inventoryMIMEText    = "text/plain"
+
+	// the xattrs of the objects written by objectnode
+	xattrKeyOSSETag           = "oss:etag"
+	xattrKeyOSSETagDeprecated = "oss:tag"
+	xattrKeyOSSLock           = "oss:lock"
+	xattrKeyOSSLockMode       = "oss:lock-mode"
+	xattrKeyOSSLegalHold      = "oss:legal-hold"
+)
+
+const (
+	inventoryKindString = iota
+	inventoryKindInt64
+	inventoryKindTimestamp
+)
+
+type inventoryColumn struct {
+	name        string // the name in the CSV schema, which is the name of the optional field
+	parquetName string
+	kind        int
+}
+
+// inventoryColumns are all the columns in the order of the inventory reports.
+var inventoryColumns = []*inventoryColumn{
+	{name: inventoryColumnBucket, parquetName: "bucket", kind: inventoryKindString},
+	{name: inventoryColumnKey, parquetName: "key", kind: inventoryKindString},
+	{name: proto.InventoryFieldSize, parquetName: "size", kind: inventoryKindInt64},
+	{name: proto.InventoryFieldLastModifiedDate, parquetName: "last_modified_date", kind: inventoryKindTimestamp},
+	{name: proto.InventoryFieldETag, parquetName: "e_tag", kind: inventoryKindString},
+	{name: proto.InventoryFieldStorageClass, parquetName: "storage_class", kind: inventoryKindString},
+	{name: proto.InventoryFieldObjectLockRetainUntilDate, parquetName: "object_lock_retain_until_date", kind: inventoryKindTimestamp},
+	{name: proto.InventoryFieldObjectLockMode, parquetName: "object_lock_mode", kind: inventoryKindString},
+	{name: proto.InventoryFieldObjectLockLegalHoldStatus, parquetName: "object_lock_legal_hold_status", kind: inventoryKindString},
+}
+
+type inventoryValue struct {
+	str  string
+	num  int64
+	null bool
+}
+
+// inventoryRecord is an object listed in the inventory report.
+type inventoryRecord struct {
+	Key             string
+	Size            uint64
+	LastModified    time.Time
+	ETag            string
+	StorageClass    string
+	RetainUntilDate time.Time
+	LockMode        string
+	LegalHold       bool
+}
+
+func (r *inventoryRecord) value(bucket string, column *inventoryColumn) inventoryValue {
+	switch column.name {
+	case inventoryColumnBucket:
+		return inventoryValue{str: bucket}
+	case inventoryColumnKey:
+		return inventoryValue{str: r.Key}
+	case proto.InventoryFieldSize:
+		return inventoryValue{num: int64(r.Size)}
+	case proto.InventoryFieldLastModifiedDate:
+		return inventoryValue{num: r.LastModified.UnixMilli()}
+	case proto.InventoryFieldETag:
+		return inventoryValue{str: r.ETag, null: r.ETag == ""}
+	case proto.InventoryFieldStorageClass:
+		return inventoryValue{str: r.StorageClass}
+	case proto.InventoryFieldObjectLockRetainUntilDate:
+		return inventoryValue{num: r.RetainUntilDate.UnixMilli(), null: r.RetainUntilDate.IsZero()}
+	case proto.InventoryFieldObjectLockMode:
+		return inventoryValue{str: r.LockMode, null: r.LockMode == ""}
+	case proto.InventoryFieldObjectLockLegalHoldStatus:
+		if r.LegalHold {
+			return inventoryValue{str: inventoryLegalHoldOn}
+		}
+		return inventoryValue{str: inventoryLegalHoldOff}
+	}
+	return inventoryValue{null: true}
+}
+
+// newInventoryRecord loads the record of the object from its inode and xattrs.
+func newInventoryRecord(key string, info *proto.InodeInfo, xattr *proto.XAttrInfo) *inventoryRecord {
+	r := &inventoryRecord{
+		Key:          key,
+		Size:         info.Size,
+		LastModified: info.ModifyTime,
+		StorageClass: inventoryStorageClass(info.StorageClass),
+	}
+	if xattr == nil {
+		return r
+	}
+	etag := string(xattr.Get(xattrKeyOSSETag))
+	if etag == "" {
+		etag = string(xattr.Get(xattrKeyOSSETagDeprecated))
+	}
+	// the etag is encoded with the timestamp in the form of "etag:ts"
+	if i := strings.IndexByte(etag, ':'); i >= 0 {
+		etag = etag[:i]
+	}
+	r.ETag = etag
+	if raw := xattr.Get(xattrKeyOSSLock); len(raw) > 0 {
+		if nanos, err := strconv.ParseInt(string(raw), 10, 64); err == nil {
+			r.RetainUntilDate = time.Unix(0, nanos).UTC()
+			// objects locked before GOVERNANCE mode is supported have no mode xattr
+			r.LockMode = inventoryLockCompliance
+			if mode := xattr.Get(xattrKeyOSSLockMode); len(mode) > 0 {
+				r.LockMode = string(mode)
+			}
+		}
+	}
+	r.LegalHold = string(xattr.Get(xattrKeyOSSLegalHold)) == inventoryLegalHoldOn
+	return r
+}
+
+func inventoryStorageClass(storageClass uint32) string {
+	switch storageClass {
+	case proto.StorageClass_Replica_HDD:
+		return proto.OpTypeStorageClassHDD
+	case proto.StorageClass_BlobStore:
+		return proto.OpTypeStorageClassEBS
+	default:
+		return inventoryStorageStandard
+	}
+}
+
+// inventoryXAttrKeys returns the keys of the xattrs required by the optional fields.
+func inventoryXAttrKeys(conf *proto.InventoryConfiguration) []string {
+	var keys []string
+	if conf.HasField(proto.InventoryFieldETag) {
+		keys = append(keys, xattrKeyOSSETag, xattrKeyOSSETagDeprecated)
+	}
+	if conf.HasField(proto.InventoryFieldObjectLockRetainUntilDate) || conf.HasField(proto.InventoryFieldObjectLockMode) {
+		keys = append(keys, xattrKeyOSSLock, xattrKeyOSSLockMode)
+	}
+	if conf.HasField(proto.InventoryFieldObjectLockLegalHoldStatus) {
+		keys = append(keys, xattrKeyOSSLegalHold)
+	}
+	return keys
+}
+
+// inventoryStore writes the files of the inventory report into the destination bucket.
+type inventoryStore interface {
+	Put(key string, data []byte, mimeType string) error
+	Close() error
+}
+
+type inventoryManifestFile struct {
+	Key         string `json:"key"`
+	Size        int64  `json:"size"`
+	MD5checksum string `json:"MD5checksum"`
+}
+
+type inventoryManifest struct {
+	SourceBucket      string                   `json:"sourceBucket"`
+	DestinationBucket string                   `json:"destinationBucket"`
+	Version           string                   `json:"version"`
+	CreationTimestamp string                   `json:"creationTimestamp"`
+	FileFormat        string                   `json:"fileFormat"`
+	FileSchema        string                   `json:"fileSchema"`
+	Files             []*inventoryManifestFile `json:"files"`
+}
+
+// inventoryReport collects the objects scanned by the LcScanner, the objects are written into the data files
+// of the destination bucket once the number of the rows reaches the limit, and the manifest listing all the
+// data files is written after the scan is done.
+type inventoryReport struct {
+	bucket  string
+	conf    *proto.InventoryConfiguration
+	store   inventoryStore
+	now     time.Time
+	columns []*inventoryColumn
+	maxRows int
+
+	lock    sync.Mutex
+	rows    int
+	csvBuf  bytes.Buffer
+	csv     *gzip.Writer
+	parquet *parquetWriter
+	files   []*inventoryManifestFile
+	err     error
+}
+
+func newInventoryReport(bucket string, conf *proto.InventoryConfiguration, store inventoryStore, now time.Time) *inventoryReport {
+	r := &inventoryReport{
+		bucket:  bucket,
+		conf:    conf,
+		store:   store,
+		now:     now,
+		maxRows: inventoryMaxRowsPerFile,
+	}
+	for _, column := range inventoryColumns {
+		if column.name == inventoryColumnBucket || column.name == inventoryColumnKey || conf.HasField(column.name) {
+			r.columns = append(r.columns, column)
+		}
+	}
+	return r
+}
+
+// prefix returns the prefix of the report in the form of "destination-prefix/source-bucket/config-ID/".
+func (r *inventoryReport) prefix() string {
+	prefix := r.conf.Destination.S3BucketDestination.Prefix
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return prefix + r.bucket + "/" + r.conf.ID + "/"
+}
+
+func (r *inventoryReport) format() string {
+	return r.conf.Destination.S3BucketDestination.Format
+}
+
+func (r *inventoryReport) add(record *inventoryRecord) error {
+	values := make([]inventoryValue, 0, len(r.columns))
+	for _, column := range r.columns {
+		values = append(values, record.value(r.bucket, column))
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	if r.format() == proto.InventoryFormatParquet {
+		if r.parquet == nil {
+			r.parquet = newParquetWriter(r.columns)
+		}
+		r.parquet.add(values)
+	} else {
+		if r.csv == nil {
+			r.csvBuf.Reset()
+			r.csv = gzip.NewWriter(&r.csvBuf)
+		}
+		if _, err := r.csv.Write([]byte(formatInventoryCSV(r.columns, values))); err != nil {
+			r.err = err
+			return err
+		}
+	}
+	r.rows++
+	if r.rows >= r.maxRows {
+		r.err = r.flush()
+	}
+	return r.err
+}
+
+// flush must be called with the lock held.
+func (r *inventoryReport) flush() (err error) {
+	if r.rows == 0 {
+		return nil
+	}
+	var data []byte
+	var key, mimeType string
+	if r.format() == proto.InventoryFormatParquet {
+		data = r.parquet.bytes()
+		r.parquet = nil
+		key = r.prefix() + "data/" + uuid.New().String() + ".parquet"
+		mimeType = inventoryMIMEParquet
+	} else {
+		if err = r.csv.Close(); err != nil {
+			return
+		}
+		data = r.csvBuf.Bytes()
+		r.csv = nil
+		key = r.prefix() + "data/" + uuid.New().String() + ".csv.gz"
+		mimeType = inventoryMIMEGzip
+	}
+	if err = r.store.Put(key, data, mimeType); err != nil {
+		log.LogErrorf("inventoryReport: put data file fail: bucket(%v) inventory(%v) key(%v) err(%v)",
+			r.bucket, r.conf.ID, key, err)
+		return
+	}
+	sum := md5.Sum(data)
+	r.files = append(r.files, &inventoryManifestFile{Key: key, Size: int64(len(data)), MD5checksum: hex.EncodeToString(sum[:])})
+	r.rows = 0
+	return
+}
+
+// finish writes the remaining rows and the manifest of the report.
+func (r *inventoryReport) finish() (err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	if err = r.flush(); err != nil {
+		return
+	}
+
+	manifest := &inventoryManifest{
+		SourceBucket:      r.bucket,
+		DestinationBucket: r.conf.Destination.S3BucketDestination.Bucket,
+		Version:           inventoryManifestVersion,
+		CreationTimestamp: strconv.FormatInt(r.now.UnixMilli(), 10),
+		FileFormat:        r.format(),
+		FileSchema:        r.schema(),
+		Files:             r.files,
+	}
+	if manifest.Files == nil {
+		manifest.Files = make([]*inventoryManifestFile, 0)
+	}
+	var data []byte
+	if data, err = json.Marshal(manifest); err != nil {
+		return
+	}
+	dir := r.prefix() + r.now.UTC().Format(inventoryTimeLayout) + "/"
+	if err = r.store.Put(dir+"manifest.json", data, inventoryMIMEJSON); err != nil {
+		return
+	}
+	sum := md5.Sum(data)
+	return r.store.Put(dir+"manifest.checksum", []byte(hex.EncodeToString(sum[:])), inventoryMIMEText)
+}
+
+func (r *inventoryReport) schema() string {
+	names := make([]string, 0, len(r.columns))
+	if r.format() != proto.InventoryFormatParquet {
+		for _, column := range r.columns {
+			names = append(names, column.name)
+		}
+		return strings.Join(names, ", ")
+	}
+	for _, column := range r.columns {
+		repetition := "optional"
+		if column.name == inventoryColumnBucket || column.name == inventoryColumnKey {
+			repetition = "required"
+		}
+		switch column.kind {
+		case inventoryKindString:
+			names = append(names, repetition+" binary "+column.parquetName+" (UTF8);")
+		case inventoryKindInt64:
+			names = append(names, repetition+" int64 "+column.parquetName+";")
+		case inventoryKindTimestamp:
+			names = append(names, repetition+" int64 "+column.parquetName+" (TIMESTAMP_MILLIS);")
+		}
+	}
+	return "message s3.inventory { " + strings.Join(names, " ") + " }"
+}
+
+// formatInventoryCSV formats the row with all the values quoted, the keys are URL-encoded.
+func formatInventoryCSV(columns []*inventoryColumn, values []inventoryValue) string {
+	var sb strings.Builder
+	for i, column := range columns {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		v := values[i]
+		var field string
+		switch {
+		case v.null:
+		case column.name == inventoryColumnKey:
+			field = url.QueryEscape(v.str)
+		case column.kind == inventoryKindInt64:
+			field = strconv.FormatInt(v.num, 10)
+		case column.kind == inventoryKindTimestamp:
+			field = time.UnixMilli(v.num).UTC().Format(inventoryDateLayout)
+		default:
+			field = v.str
+		}
+		sb.WriteString(`"` + strings.ReplaceAll(field, `"`, `""`) + `"`)
+	}
+	sb.WriteByte('\n')
+	return sb.String()
+}
@@ -0,0 +1,294 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lcnode
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/golang/snappy"
+)
+
+// The writer of the Parquet inventory files writes the flat schema of the inventory columns. Each file
+// has one row group, in which each column chunk has one data page with the PLAIN encoding compressed
+// by SNAPPY. The metadata of Parquet is serialized with the thrift compact protocol by the field ids
+// defined in parquet.thrift.
+
+const (
+	parquetMagic = "PAR1"
+
+	parquetInt64     = 2
+	parquetByteArray = 6
+
+	parquetRequired = 0
+	parquetOptional = 1
+
+	parquetConvertedUTF8            = 0
+	parquetConvertedTimestampMillis = 9
+
+	parquetEncodingPlain = 0
+	parquetEncodingRLE   = 3
+	parquetCodecSnappy   = 1
+	parquetDataPage      = 0
+
+	parquetCreatedBy = "cubefs lcnode"
+
+	thriftStop   = 0
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+type thriftField struct {
+	id    int16
+	typ   byte
+	value interface{}
+}
+
+type thriftListValue struct {
+	elemType byte
+	items    []interface{}
+}
+
+func writeThriftValue(buf *bytes.Buffer, typ byte, v interface{}) {
+	var tmp [binary.MaxVarintLen64]byte
+	switch typ {
+	case thriftI32, thriftI64:
+		n := v.(int64)
+		buf.Write(tmp[:binary.PutUvarint(tmp[:], uint64((n<<1)^(n>>63)))])
+	case thriftBinary:
+		b := []byte(v.(string))
+		buf.Write(tmp[:binary.PutUvarint(tmp[:], uint64(len(b)))])
+		buf.Write(b)
+	case thriftList:
+		l := v.(thriftListValue)
+		if len(l.items) < 15 {
+			buf.WriteByte(byte(len(l.items))<<4 | l.elemType)
+		} else {
+			buf.WriteByte(0xf0 | l.elemType)
+			buf.Write(tmp[:binary.PutUvarint(tmp[:], uint64(len(l.items)))])
+		}
+		for _, item := range l.items {
+			writeThriftValue(buf, l.elemType, item)
+		}
+	case thriftStruct:
+		writeThriftStruct(buf, v.([]thriftField))
+	}
+}
+
+func writeThriftStruct(buf *bytes.Buffer, fields []thriftField) {
+	var last int16
+	for _, f := range fields {
+		if delta := f.id - last; delta > 0 && delta <= 15 {
+			buf.WriteByte(byte(delta)<<4 | f.typ)
+		} else {
+			buf.WriteByte(f.typ)
+			writeThriftValue(buf, thriftI32, int64(f.id))
+		}
+		last = f.id
+		writeThriftValue(buf, f.typ, f.value)
+	}
+	buf.WriteByte(thriftStop)
+}
+
+func i32Field(id int16, v int64) thriftField {
+	return thriftField{id: id, typ: thriftI32, value: v}
+}
+
+func i64Field(id int16, v int64) thriftField {
+	return thriftField{id: id, typ: thriftI64, value: v}
+}
+
+func binaryField(id int16, v string) thriftField {
+	return thriftField{id: id, typ: thriftBinary, value: v}
+}
+
+func structField(id int16, fields []thriftField) thriftField {
+	return thriftField{id: id, typ: thriftStruct, value: fields}
+}
+
+func listField(id int16, elemType byte, items []interface{}) thriftField {
+	return thriftField{id: id, typ: thriftList, value: thriftListValue{elemType: elemType, items: items}}
+}
+
+// parquetColumnChunk buffers the values of a column of the file.
+type parquetColumnChunk struct {
+	column    *inventoryColumn
+	levels    []int
+	values    bytes.Buffer
+	numValues int64
+}
+
+func (c *parquetColumnChunk) optional() bool {
+	return c.column.name != inventoryColumnBucket && c.column.name != inventoryColumnKey
+}
+
+func (c *parquetColumnChunk) add(v inventoryValue) {
+	c.numValues++
+	if c.optional() {
+		if v.null {
+			c.levels = append(c.levels, 0)
+			return
+		}
+		c.levels = append(c.levels, 1)
+	}
+	switch c.column.kind {
+	case inventoryKindString:
+		_ = binary.Write(&c.values, binary.LittleEndian, uint32(len(v.str)))
+		c.values.WriteString(v.str)
+	default:
+		_ = binary.Write(&c.values, binary.LittleEndian, v.num)
+	}
+}
+
+func (c *parquetColumnChunk) schemaElement() []thriftField {
+	repetition := int64(parquetRequired)
+	if c.optional() {
+		repetition = parquetOptional
+	}
+	fields := []thriftField{
+		i32Field(1, c.physicalType()),
+		i32Field(3, repetition),
+		binaryField(4, c.column.parquetName),
+	}
+	switch c.column.kind {
+	case inventoryKindString:
+		fields = append(fields, i32Field(6, parquetConvertedUTF8))
+	case inventoryKindTimestamp:
+		fields = append(fields, i32Field(6, parquetConvertedTimestampMillis))
+	}
+	return fields
+}
+
+func (c *parquetColumnChunk) physicalType() int64 {
+	if c.column.kind == inventoryKindString {
+		return parquetByteArray
+	}
+	return parquetInt64
+}
+
+// writeTo writes the column chunk and returns the ColumnChunk metadata.
+func (c *parquetColumnChunk) writeTo(buf *bytes.Buffer) []thriftField {
+	var page bytes.Buffer
+	if c.optional() {
+		levels := encodeRLELevels(c.levels)
+		_ = binary.Write(&page, binary.LittleEndian, uint32(len(levels)))
+		page.Write(levels)
+	}
+	page.Write(c.values.Bytes())
+	compressed := snappy.Encode(nil, page.Bytes())
+
+	offset := int64(buf.Len())
+	writeThriftStruct(buf, []thriftField{
+		i32Field(1, parquetDataPage),
+		i32Field(2, int64(page.Len())),
+		i32Field(3, int64(len(compressed))),
+		structField(5, []thriftField{
+			i32Field(1, c.numValues),
+			i32Field(2, parquetEncodingPlain),
+			i32Field(3, parquetEncodingRLE),
+			i32Field(4, parquetEncodingRLE),
+		}),
+	})
+	headerSize := int64(buf.Len()) - offset
+	buf.Write(compressed)
+
+	return []thriftField{
+		i64Field(2, offset),
+		structField(3, []thriftField{
+			i32Field(1, c.physicalType()),
+			listField(2, thriftI32, []interface{}{int64(parquetEncodingPlain), int64(parquetEncodingRLE)}),
+			listField(3, thriftBinary, []interface{}{c.column.parquetName}),
+			i32Field(4, parquetCodecSnappy),
+			i64Field(5, c.numValues),
+			i64Field(6, headerSize+int64(page.Len())),
+			i64Field(7, headerSize+int64(len(compressed))),
+			i64Field(9, offset),
+		}),
+	}
+}
+
+// encodeRLELevels encodes the definition levels of bit width 1 with the RLE runs of the RLE/bit-packing
+// hybrid encoding.
+func encodeRLELevels(levels []int) []byte {
+	var buf bytes.Buffer
+	var tmp [binary.MaxVarintLen64]byte
+	for i := 0; i < len(levels); {
+		j := i + 1
+		for j < len(levels) && levels[j] == levels[i] {
+			j++
+		}
+		buf.Write(tmp[:binary.PutUvarint(tmp[:], uint64(j-i)<<1)])
+		buf.WriteByte(byte(levels[i]))
+		i = j
+	}
+	return buf.Bytes()
+}
+
+// parquetWriter buffers the rows of an inventory file in memory and encodes them at once.
+type parquetWriter struct {
+	chunks  []*parquetColumnChunk
+	numRows int64
+}
+
+func newParquetWriter(columns []*inventoryColumn) *parquetWriter {
+	w := &parquetWriter{}
+	for _, column := range columns {
+		w.chunks = append(w.chunks, &parquetColumnChunk{column: column})
+	}
+	return w
+}
+
+func (w *parquetWriter) add(values []inventoryValue) {
+	for i, chunk := range w.chunks {
+		chunk.add(values[i])
+	}
+	w.numRows++
+}
+
+func (w *parquetWriter) bytes() []byte {
+	var buf bytes.Buffer
+	buf.WriteString(parquetMagic)
+
+	schema := []interface{}{[]thriftField{
+		binaryField(4, "s3.inventory"),
+		i32Field(5, int64(len(w.chunks))),
+	}}
+	chunks := make([]interface{}, 0, len(w.chunks))
+	start := int64(buf.Len())
+	for _, chunk := range w.chunks {
+		schema = append(schema, chunk.schemaElement())
+		chunks = append(chunks, chunk.writeTo(&buf))
+	}
+	rowGroup := []thriftField{
+		listField(1, thriftStruct, chunks),
+		i64Field(2, int64(buf.Len())-start),
+		i64Field(3, w.numRows),
+	}
+
+	metaStart := buf.Len()
+	writeThriftStruct(&buf, []thriftField{
+		i32Field(1, 1),
+		listField(2, thriftStruct, schema),
+		i64Field(3, w.numRows),
+		listField(4, thriftStruct, []interface{}{rowGroup}),
+		binaryField(6, parquetCreatedBy),
+	})
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(buf.Len()-metaStart))
+	buf.WriteString(parquetMagic)
+	return buf.Bytes()
+}
@@ -0,0 +1,193 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lcnode
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/sdk/data/stream"
+	"github.com/cubefs/cubefs/sdk/meta"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	inventoryFileMode = 0o644
+	inventoryDirMode  = inventoryFileMode | os.ModeDir
+
+	xattrKeyOSSMIME = "oss:mime"
+)
+
+// volumeInventoryStore writes the inventory files into the destination volume as the objects of objectnode,
+// the file is written to an inode without dentry first, and linked to its key after the data is flushed.
+type volumeInventoryStore struct {
+	volume string
+	mw     *meta.MetaWrapper
+	ec     *stream.ExtentClient
+}
+
+func newVolumeInventoryStore(l *LcNode, volume string) (*volumeInventoryStore, error) {
+	metaConfig := &meta.MetaConfig{
+		Volume:               volume,
+		Masters:              l.masters,
+		Authenticate:         false,
+		ValidateOwner:        false,
+		InnerReq:             true,
+		MetaSendTimeout:      600,
+		DisableTrashByClient: true,
+	}
+	mw, err := meta.NewMetaWrapper(metaConfig)
+	if err != nil {
+		log.LogErrorf("newVolumeInventoryStore: NewMetaWrapper err: volume(%v) err(%v)", volume, err)
+		return nil, err
+	}
+	volumeInfo, err := l.mc.AdminAPI().GetVolumeSimpleInfo(volume)
+	if err != nil {
+		log.LogErrorf("newVolumeInventoryStore: get volume info from master failed: volume(%v) err(%v)", volume, err)
+		mw.Close()
+		return nil, err
+	}
+	if volumeInfo.Status == 1 {
+		mw.Close()
+		return nil, proto.ErrVolNotExists
+	}
+	extentConfig := &stream.ExtentConfig{
+		Volume:                 volume,
+		Masters:                l.masters,
+		FollowerRead:           false,
+		OnAppendExtentKey:      mw.AppendExtentKey,
+		OnSplitExtentKey:       mw.SplitExtentKey,
+		OnGetExtents:           mw.GetExtents,
+		OnTruncate:             mw.Truncate,
+		VolStorageClass:        volumeInfo.VolStorageClass,
+		VolAllowedStorageClass: volumeInfo.AllowedStorageClass,
+		InnerReq:               true,
+		MetaWrapper:            mw,
+	}
+	ec, err := stream.NewExtentClient(extentConfig)
+	if err != nil {
+		log.LogErrorf("newVolumeInventoryStore: NewExtentClient err: volume(%v) err(%v)", volume, err)
+		mw.Close()
+		return nil, err
+	}
+	return &volumeInventoryStore{volume: volume, mw: mw, ec: ec}, nil
+}
+
+func (s *volumeInventoryStore) Put(key string, data []byte, mimeType string) (err error) {
+	dirs := strings.Split(key, pathSep)
+	name := dirs[len(dirs)-1]
+	var parentId uint64
+	if parentId, err = s.makeDirs(dirs[:len(dirs)-1]); err != nil {
+		log.LogErrorf("inventoryStore: make dirs fail: volume(%v) key(%v) err(%v)", s.volume, key, err)
+		return
+	}
+
+	var info *proto.InodeInfo
+	if info, err = s.mw.InodeCreate_ll(parentId, inventoryFileMode, 0, 0, nil, make([]uint64, 0), key); err != nil {
+		log.LogErrorf("inventoryStore: inode create fail: volume(%v) key(%v) err(%v)", s.volume, key, err)
+		return
+	}
+	defer func() {
+		if err != nil {
+			_, _ = s.mw.InodeUnlink_ll(info.Inode, key)
+			_ = s.mw.Evict(info.Inode, key)
+		}
+	}()
+
+	if err = s.ec.OpenStream(info.Inode, true, false, key); err != nil {
+		log.LogErrorf("inventoryStore: open stream fail: volume(%v) key(%v) inode(%v) err(%v)", s.volume, key, info.Inode, err)
+		return
+	}
+	defer func() {
+		if closeErr := s.ec.CloseStream(info.Inode); closeErr != nil {
+			log.LogErrorf("inventoryStore: close stream fail: volume(%v) inode(%v) err(%v)", s.volume, info.Inode, closeErr)
+		}
+	}()
+	for offset := 0; offset < len(data); {
+		end := offset + int(MaxSizePutOnce)
+		if end > len(data) {
+			end = len(data)
+		}
+		var n int
+		if n, err = s.ec.Write(info.Inode, offset, data[offset:end], 0, nil, info.StorageClass, false, false); err != nil {
+			log.LogErrorf("inventoryStore: write fail: volume(%v) key(%v) inode(%v) offset(%v) err(%v)",
+				s.volume, key, info.Inode, offset, err)
+			return
+		}
+		offset += n
+	}
+	if err = s.ec.Flush(info.Inode); err != nil {
+		log.LogErrorf("inventoryStore: flush fail: volume(%v) key(%v) inode(%v) err(%v)", s.volume, key, info.Inode, err)
+		return
+	}
+
+	var final *proto.InodeInfo
+	if final, err = s.mw.InodeGet_ll(info.Inode); err != nil {
+		return
+	}
+	sum := md5.Sum(data)
+	attrs := map[string]string{
+		// the same etag encoding as objectnode
+		xattrKeyOSSETag: hex.EncodeToString(sum[:]) + ":" + strconv.FormatInt(final.ModifyTime.Unix(), 10),
+		xattrKeyOSSMIME: mimeType,
+	}
+	if err = s.mw.BatchSetXAttr_ll(info.Inode, attrs); err != nil {
+		log.LogErrorf("inventoryStore: set xattr fail: volume(%v) key(%v) inode(%v) err(%v)", s.volume, key, info.Inode, err)
+		return
+	}
+	if err = s.mw.DentryCreate_ll(parentId, name, info.Inode, inventoryFileMode, key); err != nil {
+		log.LogErrorf("inventoryStore: dentry create fail: volume(%v) key(%v) inode(%v) err(%v)", s.volume, key, info.Inode, err)
+		return
+	}
+	log.LogInfof("inventoryStore: put file: volume(%v) key(%v) inode(%v) size(%v)", s.volume, key, info.Inode, len(data))
+	return
+}
+
+func (s *volumeInventoryStore) makeDirs(dirs []string) (parentId uint64, err error) {
+	parentId = proto.RootIno
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		ino, mode, err := s.mw.Lookup_ll(parentId, dir)
+		if err == syscall.ENOENT {
+			var info *proto.InodeInfo
+			info, err = s.mw.Create_ll(parentId, dir, uint32(inventoryDirMode), 0, 0, nil, dir, false)
+			if err == syscall.EEXIST {
+				ino, mode, err = s.mw.Lookup_ll(parentId, dir)
+			} else if err == nil {
+				ino, mode = info.Inode, info.Mode
+			}
+		}
+		if err != nil {
+			return 0, err
+		}
+		if !os.FileMode(mode).IsDir() {
+			return 0, syscall.ENOTDIR
+		}
+		parentId = ino
+	}
+	return
+}
+
+func (s *volumeInventoryStore) Close() error {
+	_ = s.ec.Close()
+	return s.mw.Close()
+}
@@ -0,0 +1,215 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lcnode
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/routinepool"
+	"github.com/cubefs/cubefs/util/unboundedchan"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+type mockInventoryStore struct {
+	sync.Mutex
+	files map[string][]byte
+}
+
+func newMockInventoryStore() *mockInventoryStore {
+	return &mockInventoryStore{files: make(map[string][]byte)}
+}
+
+func (s *mockInventoryStore) Put(key string, data []byte, mimeType string) error {
+	s.Lock()
+	defer s.Unlock()
+	s.files[key] = append([]byte(nil), data...)
+	return nil
+}
+
+func (s *mockInventoryStore) Close() error {
+	return nil
+}
+
+func (s *mockInventoryStore) manifest(t *testing.T) *inventoryManifest {
+	for key, data := range s.files {
+		if strings.HasSuffix(key, "/manifest.json") {
+			sum := md5.Sum(data)
+			require.Equal(t, hex.EncodeToString(sum[:]), string(s.files[strings.TrimSuffix(key, ".json")+".checksum"]))
+			manifest := &inventoryManifest{}
+			require.NoError(t, json.Unmarshal(data, manifest))
+			return manifest
+		}
+	}
+	require.FailNow(t, "no manifest found")
+	return nil
+}
+
+func testInventoryConfiguration(format string, fields ...string) *proto.InventoryConfiguration {
+	return &proto.InventoryConfiguration{
+		ID:        "daily",
+		IsEnabled: true,
+		Destination: &proto.InventoryDestination{S3BucketDestination: &proto.InventoryS3BucketDestination{
+			Bucket: "arn:aws:s3:::reports",
+			Format: format,
+			Prefix: "inventory",
+		}},
+		IncludedObjectVersions: proto.InventoryVersionsCurrent,
+		OptionalFields:         fields,
+		Schedule:               &proto.InventorySchedule{Frequency: proto.InventoryFrequencyDaily},
+	}
+}
+
+func TestInventoryReportCSV(t *testing.T) {
+	conf := testInventoryConfiguration(proto.InventoryFormatCSV, proto.InventoryFieldETag, proto.InventoryFieldSize,
+		proto.InventoryFieldLastModifiedDate, proto.InventoryFieldObjectLockRetainUntilDate, proto.InventoryFieldObjectLockMode)
+	store := newMockInventoryStore()
+	now := time.Date(2024, 2, 6, 1, 2, 3, 0, time.UTC)
+	report := newInventoryReport("bucket", conf, store, now)
+	report.maxRows = 2
+
+	xattr := &proto.XAttrInfo{XAttrs: map[string]string{
+		xattrKeyOSSETag: "d41d8cd98f00b204e9800998ecf8427e-2:1700000000",
+		xattrKeyOSSLock: "1700000000000000000",
+	}}
+	modified := time.Date(2024, 2, 5, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, report.add(newInventoryRecord("a b/c\".txt", &proto.InodeInfo{Size: 10, ModifyTime: modified}, xattr)))
+	require.NoError(t, report.add(newInventoryRecord("d.txt", &proto.InodeInfo{Size: 20, ModifyTime: modified}, nil)))
+	require.NoError(t, report.add(newInventoryRecord("e.txt", &proto.InodeInfo{Size: 30, ModifyTime: modified}, nil)))
+	require.NoError(t, report.finish())
+
+	manifest := store.manifest(t)
+	require.Equal(t, "bucket", manifest.SourceBucket)
+	require.Equal(t, "arn:aws:s3:::reports", manifest.DestinationBucket)
+	require.Equal(t, proto.InventoryFormatCSV, manifest.FileFormat)
+	require.Equal(t, "Bucket, Key, Size, LastModifiedDate, ETag, ObjectLockRetainUntilDate, ObjectLockMode", manifest.FileSchema)
+	require.Contains(t, store.files, "inventory/bucket/daily/2024-02-06T01-02Z/manifest.json")
+	require.Len(t, manifest.Files, 2)
+
+	var lines []string
+	for _, file := range manifest.Files {
+		require.True(t, strings.HasPrefix(file.Key, "inventory/bucket/daily/data/"))
+		require.True(t, strings.HasSuffix(file.Key, ".csv.gz"))
+		data := store.files[file.Key]
+		require.Equal(t, int64(len(data)), file.Size)
+		sum := md5.Sum(data)
+		require.Equal(t, hex.EncodeToString(sum[:]), file.MD5checksum)
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		require.NoError(t, err)
+		content, err := io.ReadAll(r)
+		require.NoError(t, err)
+		lines = append(lines, strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")...)
+	}
+	sort.Strings(lines)
+	require.Equal(t, []string{
+		`"bucket","a+b%2Fc%22.txt","10","2024-02-05T00:00:00.000Z","d41d8cd98f00b204e9800998ecf8427e-2","2023-11-14T22:13:20.000Z","COMPLIANCE"`,
+		`"bucket","d.txt","20","2024-02-05T00:00:00.000Z","","",""`,
+		`"bucket","e.txt","30","2024-02-05T00:00:00.000Z","","",""`,
+	}, lines)
+}
+
+func TestInventoryReportParquet(t *testing.T) {
+	conf := testInventoryConfiguration(proto.InventoryFormatParquet, proto.InventoryFieldSize,
+		proto.InventoryFieldStorageClass, proto.InventoryFieldObjectLockLegalHoldStatus, proto.InventoryFieldObjectLockRetainUntilDate)
+	store := newMockInventoryStore()
+	report := newInventoryReport("bucket", conf, store, time.Now())
+
+	xattr := &proto.XAttrInfo{XAttrs: map[string]string{xattrKeyOSSLegalHold: inventoryLegalHoldOn, xattrKeyOSSLock: "1000000"}}
+	require.NoError(t, report.add(newInventoryRecord("a.txt", &proto.InodeInfo{Size: 10, StorageClass: proto.StorageClass_BlobStore}, xattr)))
+	require.NoError(t, report.add(newInventoryRecord("b.txt", &proto.InodeInfo{Size: 20}, nil)))
+	require.NoError(t, report.finish())
+
+	manifest := store.manifest(t)
+	require.Equal(t, "message s3.inventory { required binary bucket (UTF8); required binary key (UTF8); "+
+		"optional int64 size; optional binary storage_class (UTF8); optional int64 object_lock_retain_until_date (TIMESTAMP_MILLIS); "+
+		"optional binary object_lock_legal_hold_status (UTF8); }", manifest.FileSchema)
+	require.Len(t, manifest.Files, 1)
+	require.True(t, strings.HasSuffix(manifest.Files[0].Key, ".parquet"))
+
+	data := store.files[manifest.Files[0].Key]
+	require.Equal(t, parquetMagic, string(data[:4]))
+	require.Equal(t, parquetMagic, string(data[len(data)-4:]))
+	metaLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	require.Less(t, metaLen, len(data))
+	require.True(t, bytes.Contains(data[len(data)-8-metaLen:], []byte("object_lock_legal_hold_status")))
+}
+
+func TestRLELevels(t *testing.T) {
+	require.Equal(t, []byte{3 << 1, 1, 1 << 1, 0, 2 << 1, 1}, encodeRLELevels([]int{1, 1, 1, 0, 1, 1}))
+	require.Empty(t, encodeRLELevels(nil))
+}
+
+func TestLcScannerInventory(t *testing.T) {
+	lcScanRoutineNumPerTask = 1
+	maxDirChanNum = 0
+	scanCheckInterval = 1
+	conf := testInventoryConfiguration(proto.InventoryFormatCSV, proto.InventoryFieldETag, proto.InventoryFieldObjectLockLegalHoldStatus)
+	store := newMockInventoryStore()
+	scanner := &LcScanner{
+		ID:     proto.InventoryTaskID("test_vol", conf.ID),
+		Volume: "test_vol",
+		mw:     NewMockMetaWrapper(),
+		lcnode: &LcNode{},
+		transitionMgr: &TransitionMgr{
+			volume:    "test_vol",
+			ec:        NewMockExtentClient(),
+			ecForW:    NewMockExtentClient(),
+			ebsClient: NewMockEbsClient(),
+		},
+		adminTask: &proto.AdminTask{
+			Response: &proto.LcNodeRuleTaskResponse{},
+		},
+		rule:        &proto.Rule{ID: "inventory/daily", Status: proto.RuleEnabled, Filter: &proto.Filter{}},
+		inventory:   newInventoryReport("test_vol", conf, store, time.Now()),
+		dirChan:     unboundedchan.NewUnboundedChan(10),
+		fileChan:    make(chan interface{}),
+		dirRPool:    routinepool.NewRoutinePool(lcScanRoutineNumPerTask),
+		fileRPool:   routinepool.NewRoutinePool(lcScanRoutineNumPerTask),
+		currentStat: &proto.LcNodeRuleTaskStatistics{},
+		limiter:     rate.NewLimiter(defaultLcScanLimitPerSecond, defaultLcScanLimitBurst),
+		now:         time.Now(),
+		stopC:       make(chan bool),
+	}
+	require.NoError(t, scanner.Start())
+	time.Sleep(time.Second * 5)
+	require.True(t, scanner.DoneScanning())
+	require.Equal(t, int64(4), scanner.currentStat.TotalFileScannedNum)
+	require.Equal(t, int64(4), scanner.currentStat.InventoryObjectNum)
+	require.Equal(t, int64(0), scanner.currentStat.ErrorInventoryNum)
+	// the objects are only listed without being expired
+	require.Equal(t, int64(0), scanner.currentStat.TotalFileExpiredNum)
+
+	require.NoError(t, scanner.inventory.finish())
+	manifest := store.manifest(t)
+	require.Len(t, manifest.Files, 1)
+	r, err := gzip.NewReader(bytes.NewReader(store.files[manifest.Files[0].Key]))
+	require.NoError(t, err)
+	content, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, 4, strings.Count(string(content), "\n"))
+	require.Contains(t, string(content), `"d41d8cd98f00b204e9800998ecf8427e","ON"`)
+}
@@ -71,10 +71,14 @@ func (l *LcNode) opMasterHeartbeat(conn net.Conn, p *proto.Packet, remoteAddr st
 					ExpiredMToHddBytes:       atomic.LoadInt64(&scanner.currentStat.ExpiredMToHddBytes),
 					ExpiredMToBlobstoreBytes: atomic.LoadInt64(&scanner.currentStat.ExpiredMToBlobstoreBytes),
 					ExpiredSkipNum:           atomic.LoadInt64(&scanner.currentStat.ExpiredSkipNum),
+					ExpiredRestoreNum:        atomic.LoadInt64(&scanner.currentStat.ExpiredRestoreNum),
+					InventoryObjectNum:       atomic.LoadInt64(&scanner.currentStat.InventoryObjectNum),
 					ErrorDeleteNum:           atomic.LoadInt64(&scanner.currentStat.ErrorDeleteNum),
 					ErrorMToHddNum:           atomic.LoadInt64(&scanner.currentStat.ErrorMToHddNum),
 					ErrorMToBlobstoreNum:     atomic.LoadInt64(&scanner.currentStat.ErrorMToBlobstoreNum),
 					ErrorReadDirNum:          atomic.LoadInt64(&scanner.currentStat.ErrorReadDirNum),
+					ErrorRestoreNum:          atomic.LoadInt64(&scanner.currentStat.ErrorRestoreNum),
+					ErrorInventoryNum:        atomic.LoadInt64(&scanner.currentStat.ErrorInventoryNum),
 				},
 			}
 			resp.LcScanningTasks[scanner.ID] = result
@@ -48,6 +48,7 @@ type LcScanner struct {
 	transitionMgr *TransitionMgr
 	adminTask     *proto.AdminTask
 	rule          *proto.Rule
+	inventory     *inventoryReport
 	dirChan       *unboundedchan.UnboundedChan
 	fileChan      chan interface{}
 	dirRPool      *routinepool.RoutinePool
@@ -172,6 +173,19 @@ func NewS3Scanner(adminTask *proto.AdminTask, l *LcNode) (*LcScanner, error) {
 		meta:      metaWrapper,
 	}
 
+	// the inventory task lists the objects into the report instead of applying the rule
+	if scanTask.Inventory != nil {
+		var store *volumeInventoryStore
+		if store, err = newVolumeInventoryStore(l, scanTask.Inventory.DestinationBucket()); err != nil {
+			log.LogErrorf("newVolumeInventoryStore err: %v, inventory: %v", err, scanTask.Inventory.ID)
+			metaWrapper.Close()
+			extentClient.Close()
+			extentClientForW.Close()
+			return nil, err
+		}
+		scanner.inventory = newInventoryReport(scanner.Volume, scanTask.Inventory, store, scanner.now)
+	}
+
 	return scanner, nil
 }
 
@@ -400,6 +414,16 @@ func (s *LcScanner) handleFile(dentry *proto.ScanDentry) {
 		return
 	}
 
+	if s.inventory != nil {
+		s.handleInventory(dentry, info)
+		return
+	}
+
+	// the restored copy expires no matter whether the object matches the rule
+	if info != nil && proto.IsStorageClassBlobStore(info.StorageClass) {
+		s.handleRestored(dentry, false)
+	}
+
 	if info != nil && info.Size < s.rule.MinSize() {
 		log.LogInfof("handleFile: %+v, minSize(%d) size(%v) no need to process", dentry, s.rule.MinSize(), info.Size)
 		return
@@ -432,6 +456,9 @@ func (s *LcScanner) handleFile(dentry *proto.ScanDentry) {
 			log.LogWarnf("delete DeleteWithCond_ll err: %v, dentry: %+v", err, dentry)
 			return
 		}
+		if proto.IsStorageClassBlobStore(dentry.StorageClass) {
+			s.handleRestored(dentry, true)
+		}
 		if err = s.mw.Evict(dentry.Inode, dentry.Path); err != nil {
 			log.LogWarnf("delete Evict err: %v, dentry: %+v", err, dentry)
 		}
@@ -513,6 +540,75 @@ func (s *LcScanner) handleFile(dentry *proto.ScanDentry) {
 	}
 }
 
+// handleRestored releases the temporary copy restored from blobstore by RestoreObject of objectnode
+// once it expires, or the object itself is deleted if force is set.
+func (s *LcScanner) handleRestored(dentry *proto.ScanDentry, force bool) {
+	xattr, err := s.mw.XAttrGet_ll(dentry.Inode, proto.XAttrKeyOSSRestore)
+	if err != nil {
+		log.LogWarnf("handleRestored XAttrGet_ll err: %v, dentry: %+v", err, dentry)
+		return
+	}
+	value := xattr.Get(proto.XAttrKeyOSSRestore)
+	if len(value) == 0 {
+		return
+	}
+	restore, err := proto.ParseObjectRestore(value)
+	if err != nil {
+		log.LogWarnf("handleRestored parse restore(%v) err: %v, dentry: %+v", string(value), err, dentry)
+		return
+	}
+	if !force && !restore.Expired(time.Now()) {
+		return
+	}
+
+	start := time.Now()
+	defer func() {
+		auditlog.LogLcNodeOp(proto.OpTypeRestoreExpired, s.Volume, dentry.Name, dentry.Path, dentry.ParentId, restore.Inode, dentry.Size,
+			dentry.LeaseExpire, dentry.HasMek, proto.StorageClass_Unspecified, proto.StorageClass_Unspecified, time.Since(start).Milliseconds(), err)
+	}()
+
+	// the restored copy is released before the xattr, objectnode never reads an expired copy
+	if _, err = s.mw.InodeUnlink_ll(restore.Inode, dentry.Path); err != nil && err != syscall.ENOENT {
+		atomic.AddInt64(&s.currentStat.ErrorRestoreNum, 1)
+		log.LogWarnf("handleRestored InodeUnlink_ll err: %v, restore: %+v, dentry: %+v", err, restore, dentry)
+		return
+	}
+	if err = s.mw.Evict(restore.Inode, dentry.Path); err != nil {
+		log.LogWarnf("handleRestored Evict err: %v, restore: %+v, dentry: %+v", err, restore, dentry)
+	}
+	if err = s.mw.XAttrDel_ll(dentry.Inode, proto.XAttrKeyOSSRestore); err != nil {
+		atomic.AddInt64(&s.currentStat.ErrorRestoreNum, 1)
+		log.LogWarnf("handleRestored XAttrDel_ll err: %v, restore: %+v, dentry: %+v", err, restore, dentry)
+		return
+	}
+	atomic.AddInt64(&s.currentStat.ExpiredRestoreNum, 1)
+}
+
+// handleInventory adds the object to the inventory report.
+func (s *LcScanner) handleInventory(dentry *proto.ScanDentry, info *proto.InodeInfo) {
+	if info == nil {
+		return
+	}
+	var xattr *proto.XAttrInfo
+	if keys := inventoryXAttrKeys(s.inventory.conf); len(keys) > 0 {
+		xattrs, err := s.mw.BatchGetXAttr([]uint64{dentry.Inode}, keys)
+		if err != nil {
+			atomic.AddInt64(&s.currentStat.ErrorInventoryNum, 1)
+			log.LogWarnf("handleInventory BatchGetXAttr err: %v, dentry: %+v", err, dentry)
+			return
+		}
+		if len(xattrs) > 0 {
+			xattr = xattrs[0]
+		}
+	}
+	if err := s.inventory.add(newInventoryRecord(dentry.Path, info, xattr)); err != nil {
+		atomic.AddInt64(&s.currentStat.ErrorInventoryNum, 1)
+		log.LogWarnf("handleInventory add err: %v, dentry: %+v", err, dentry)
+		return
+	}
+	atomic.AddInt64(&s.currentStat.InventoryObjectNum, 1)
+}
+
 func isSkipErr(err error) bool {
 	if strings.Contains(err.Error(), "statusLeaseOccupiedByOthers") {
 		return true
@@ -773,6 +869,8 @@ func (s *LcScanner) checkScanning() {
 			response.ExpiredMToHddBytes = s.currentStat.ExpiredMToHddBytes
 			response.ExpiredMToBlobstoreBytes = s.currentStat.ExpiredMToBlobstoreBytes
 			response.ExpiredSkipNum = s.currentStat.ExpiredSkipNum
+			response.ExpiredRestoreNum = s.currentStat.ExpiredRestoreNum
+			response.InventoryObjectNum = s.currentStat.InventoryObjectNum
 			response.TotalFileScannedNum = s.currentStat.TotalFileScannedNum
 			response.TotalFileExpiredNum = s.currentStat.TotalFileExpiredNum
 			response.TotalDirScannedNum = s.currentStat.TotalDirScannedNum
@@ -780,6 +878,8 @@ func (s *LcScanner) checkScanning() {
 			response.ErrorMToHddNum = s.currentStat.ErrorMToHddNum
 			response.ErrorMToBlobstoreNum = s.currentStat.ErrorMToBlobstoreNum
 			response.ErrorReadDirNum = s.currentStat.ErrorReadDirNum
+			response.ErrorRestoreNum = s.currentStat.ErrorRestoreNum
+			response.ErrorInventoryNum = s.currentStat.ErrorInventoryNum
 			log.LogInfof("receive receiveStopC response(%+v)", response)
 
 			s.lcnode.scannerMutex.Lock()
@@ -793,10 +893,18 @@ func (s *LcScanner) checkScanning() {
 			if s.DoneScanning() {
 				log.LogInfof("checkScanning completed for task(%v)", s.adminTask)
 				taskCheckTimer.Stop()
-				t := time.Now()
 				response := s.adminTask.Response.(*proto.LcNodeRuleTaskResponse)
-				response.EndTime = &t
 				response.Status = proto.TaskSucceeds
+				// the manifest is written only if the whole report is delivered
+				if s.inventory != nil {
+					if err := s.inventory.finish(); err != nil {
+						log.LogErrorf("checkScanning finish inventory err(%v), task(%v)", err, s.ID)
+						response.Status = proto.TaskFailed
+						response.StartErr = err.Error()
+					}
+				}
+				t := time.Now()
+				response.EndTime = &t
 				response.Done = true
 				response.ID = s.ID
 				response.LcNode = s.lcnode.localServerAddr
@@ -808,6 +916,8 @@ func (s *LcScanner) checkScanning() {
 				response.ExpiredMToHddBytes = s.currentStat.ExpiredMToHddBytes
 				response.ExpiredMToBlobstoreBytes = s.currentStat.ExpiredMToBlobstoreBytes
 				response.ExpiredSkipNum = s.currentStat.ExpiredSkipNum
+				response.ExpiredRestoreNum = s.currentStat.ExpiredRestoreNum
+				response.InventoryObjectNum = s.currentStat.InventoryObjectNum
 				response.TotalFileScannedNum = s.currentStat.TotalFileScannedNum
 				response.TotalFileExpiredNum = s.currentStat.TotalFileExpiredNum
 				response.TotalDirScannedNum = s.currentStat.TotalDirScannedNum
@@ -815,6 +925,8 @@ func (s *LcScanner) checkScanning() {
 				response.ErrorMToHddNum = s.currentStat.ErrorMToHddNum
 				response.ErrorMToBlobstoreNum = s.currentStat.ErrorMToBlobstoreNum
 				response.ErrorReadDirNum = s.currentStat.ErrorReadDirNum
+				response.ErrorRestoreNum = s.currentStat.ErrorRestoreNum
+				response.ErrorInventoryNum = s.currentStat.ErrorInventoryNum
 				log.LogInfof("checkScanning completed response(%+v)", response)
 
 				s.lcnode.scannerMutex.Lock()
@@ -850,6 +962,9 @@ func (s *LcScanner) Stop() {
 	s.mw.Close()
 	s.transitionMgr.ec.Close()
 	s.transitionMgr.ecForW.Close()
+	if s.inventory != nil {
+		s.inventory.store.Close()
+	}
 	log.LogInfof("stop: scanner(%v) stopped", s.ID)
 	auditlog.LogMasterOp("LcScanStop ", fmt.Sprintf("ID(%v), receiveStop(%v), %v", s.ID, s.receiveStop, time.Since(start).String()), nil)
 }
@@ -86,6 +86,14 @@ func TestLcScanner(t *testing.T) {
 	require.Equal(t, int64(0), scanner.currentStat.ErrorMToHddNum)
 	require.Equal(t, int64(0), scanner.currentStat.ErrorMToBlobstoreNum)
 	require.Equal(t, int64(0), scanner.currentStat.ErrorReadDirNum)
+	// the restored copy is released with the deleted object
+	require.Equal(t, int64(1), scanner.currentStat.ExpiredRestoreNum)
+
+	scanner.handleRestored(&proto.ScanDentry{Inode: 3}, false)
+	require.Equal(t, int64(1), scanner.currentStat.ExpiredRestoreNum)
+	scanner.handleRestored(&proto.ScanDentry{Inode: 2}, false)
+	require.Equal(t, int64(2), scanner.currentStat.ExpiredRestoreNum)
+	require.Equal(t, int64(0), scanner.currentStat.ErrorRestoreNum)
 
 	dentry := &proto.ScanDentry{
 		Inode: 1,
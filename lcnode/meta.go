@@ -23,6 +23,10 @@ type MetaWrapper interface {
 	InodeGet_ll(inode uint64) (*proto.InodeInfo, error)
 	DeleteWithCond_ll(parentID, cond uint64, name string, isDir bool, fullPath string) (inode *proto.InodeInfo, err error)
 	Evict(inode uint64, fullPath string) error
+	InodeUnlink_ll(inode uint64, fullPath string) (*proto.InodeInfo, error)
+	XAttrGet_ll(inode uint64, name string) (*proto.XAttrInfo, error)
+	XAttrDel_ll(inode uint64, name string) error
+	BatchGetXAttr(inodes []uint64, keys []string) ([]*proto.XAttrInfo, error)
 	UpdateExtentKeyAfterMigration(inode uint64, storageType uint32, extentKeys []proto.ObjExtentKey, leaseExpireTime uint64, delayDelMinute uint64, fullPath string) error
 	DeleteMigrationExtentKey(inode uint64, fullPath string) error
 	ReadDirLimit_ll(parentID uint64, from string, limit uint64) ([]proto.Dentry, error)
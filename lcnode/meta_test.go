@@ -15,6 +15,7 @@
 package lcnode
 
 import (
+	"fmt"
 	"os"
 	"time"
 
@@ -55,8 +56,9 @@ func (*MockMetaWrapper) InodeGet_ll(inode uint64) (*proto.InodeInfo, error) {
 		}, nil
 	case 3:
 		return &proto.InodeInfo{
-			Inode:      3,
-			AccessTime: time.Now().AddDate(0, 0, -4),
+			Inode:        3,
+			AccessTime:   time.Now().AddDate(0, 0, -4),
+			StorageClass: proto.StorageClass_BlobStore,
 		}, nil
 	case 6:
 		return &proto.InodeInfo{
@@ -76,6 +78,43 @@ func (*MockMetaWrapper) Evict(inode uint64, fullPath string) error {
 	return nil
 }
 
+func (*MockMetaWrapper) InodeUnlink_ll(inode uint64, fullPath string) (*proto.InodeInfo, error) {
+	return nil, nil
+}
+
+func (*MockMetaWrapper) XAttrGet_ll(inode uint64, name string) (*proto.XAttrInfo, error) {
+	xattr := &proto.XAttrInfo{Inode: inode, XAttrs: make(map[string]string)}
+	if name != proto.XAttrKeyOSSRestore {
+		return xattr, nil
+	}
+	switch inode {
+	case 2:
+		// the copy restored from blobstore has expired
+		xattr.XAttrs[name] = fmt.Sprintf(`{"inode":7,"expiry_date":%d}`, time.Now().Unix()-1)
+	case 3:
+		xattr.XAttrs[name] = fmt.Sprintf(`{"inode":8,"expiry_date":%d}`, time.Now().Unix()+3600)
+	}
+	return xattr, nil
+}
+
+func (*MockMetaWrapper) XAttrDel_ll(inode uint64, name string) error {
+	return nil
+}
+
+func (*MockMetaWrapper) BatchGetXAttr(inodes []uint64, keys []string) ([]*proto.XAttrInfo, error) {
+	xattrs := make([]*proto.XAttrInfo, 0, len(inodes))
+	for _, inode := range inodes {
+		xattr := &proto.XAttrInfo{Inode: inode, XAttrs: make(map[string]string)}
+		if inode == 1 {
+			xattr.XAttrs[xattrKeyOSSETag] = "d41d8cd98f00b204e9800998ecf8427e:1700000000"
+			xattr.XAttrs[xattrKeyOSSLock] = "1700000000000000000"
+			xattr.XAttrs[xattrKeyOSSLegalHold] = inventoryLegalHoldOn
+		}
+		xattrs = append(xattrs, xattr)
+	}
+	return xattrs, nil
+}
+
 func (*MockMetaWrapper) UpdateExtentKeyAfterMigration(inode uint64, storageType uint32, extentKeys []proto.ObjExtentKey, writeGen uint64, delayDelMinute uint64, fullPath string) error {
 	return nil
 }
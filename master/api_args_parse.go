@@ -382,6 +382,17 @@ func extractInt64WithDefault(r *http.Request, key string, def int64) (val int64,
 	return val, nil
 }
 
+// parseInlineDataThreshold parses the max size of the files kept inline in their inodes, 0 disables it.
+func parseInlineDataThreshold(r *http.Request, def int64) (val int64, err error) {
+	if val, err = extractInt64WithDefault(r, inlineDataThresholdKey, def); err != nil {
+		return
+	}
+	if val > proto.MaxInlineDataThreshold {
+		return 0, fmt.Errorf("%s can't be greater than %d, now %d", inlineDataThresholdKey, proto.MaxInlineDataThreshold, val)
+	}
+	return
+}
+
 func extractStrWithDefault(r *http.Request, key string, def string) (val string) {
 	if val = r.FormValue(key); val == "" {
 		return def
@@ -434,6 +445,7 @@ type updateVolReq struct {
 	enableAutoDpMetaRepair   bool
 	accessTimeValidInterval  int64
 	enablePersistAccessTime  bool
+	inlineDataThreshold      int64
 	volStorageClass          uint32
 	forbidWriteOpOfProtoVer0 bool
 	quotaOfClass             uint64
@@ -559,6 +571,9 @@ func parseVolUpdateReq(r *http.Request, vol *Vol, req *updateVolReq) (err error)
 	if req.enablePersistAccessTime, err = extractBoolWithDefault(r, enablePersistAccessTimeKey, vol.EnablePersistAccessTime); err != nil {
 		return
 	}
+	if req.inlineDataThreshold, err = parseInlineDataThreshold(r, vol.InlineDataThreshold); err != nil {
+		return
+	}
 	if req.enableAutoDpMetaRepair, err = extractBoolWithDefault(r, autoDpMetaRepairKey, vol.EnableAutoMetaRepair.Load()); err != nil {
 		return
 	}
@@ -753,6 +768,7 @@ type createVolReq struct {
 	trashInterval           int64
 	accessTimeValidInterval int64
 	enablePersistAccessTime bool
+	inlineDataThreshold     int64
 	// cold vol args
 	coldArgs coldVolArgs
 
@@ -985,6 +1001,9 @@ func parseRequestToCreateVol(r *http.Request, req *createVolReq) (err error) {
 	if req.enablePersistAccessTime, err = extractBoolWithDefault(r, enablePersistAccessTimeKey, false); err != nil {
 		return
 	}
+	if req.inlineDataThreshold, err = parseInlineDataThreshold(r, 0); err != nil {
+		return
+	}
 
 	if req.allowedStorageClass, err = parseAllowedStorageClass(r); err != nil {
 		return
@@ -2332,6 +2351,89 @@ func extractQuotaId(r *http.Request) (quotaId uint32, err error) {
 	return
 }
 
+func parseSetUserQuotaParam(r *http.Request, req *proto.SetUserQuotaRequest) (err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+
+	if req.VolName, err = extractName(r); err != nil {
+		return
+	}
+
+	if req.Type, req.Id, err = extractUserQuotaKey(r); err != nil {
+		return
+	}
+
+	if req.SoftBytes, err = extractUint64(r, softBytesKey); err != nil {
+		return
+	}
+
+	if req.HardBytes, err = extractUint64(r, hardBytesKey); err != nil {
+		return
+	}
+
+	if req.SoftInodes, err = extractUint64(r, softInodesKey); err != nil {
+		return
+	}
+
+	if req.HardInodes, err = extractUint64(r, hardInodesKey); err != nil {
+		return
+	}
+
+	if req.GracePeriod, err = extractInt64WithDefault(r, gracePeriodKey, proto.DefaultUserQuotaGracePeriod); err != nil {
+		return
+	}
+
+	if req.HardBytes != 0 && req.SoftBytes > req.HardBytes {
+		err = fmt.Errorf("soft bytes [%v] is larger than hard bytes [%v]", req.SoftBytes, req.HardBytes)
+		return
+	}
+
+	if req.HardInodes != 0 && req.SoftInodes > req.HardInodes {
+		err = fmt.Errorf("soft inodes [%v] is larger than hard inodes [%v]", req.SoftInodes, req.HardInodes)
+		return
+	}
+	return
+}
+
+func parseDeleteUserQuotaParam(r *http.Request) (volName string, key proto.UserQuotaKey, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+
+	if volName, err = extractName(r); err != nil {
+		return
+	}
+
+	if key.Type, key.Id, err = extractUserQuotaKey(r); err != nil {
+		return
+	}
+	return
+}
+
+func extractUserQuotaKey(r *http.Request) (quotaType proto.UserQuotaType, id uint32, err error) {
+	var value string
+	if value = r.FormValue(userQuotaTypeKey); value == "" {
+		err = keyNotFound(userQuotaTypeKey)
+		return
+	}
+	if quotaType, err = proto.ParseUserQuotaType(value); err != nil {
+		return
+	}
+
+	if value = r.FormValue(idKey); value == "" {
+		err = keyNotFound(idKey)
+		return
+	}
+	tmp, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		err = fmt.Errorf("args [%s] is not legal, val %s", idKey, value)
+		return
+	}
+	id = uint32(tmp)
+	return
+}
+
 func parseRequestToSetTrashInterval(r *http.Request) (name, authKey string, interval int64, err error) {
 	if err = r.ParseForm(); err != nil {
 		return
@@ -2656,6 +2656,7 @@ func (m *Server) updateVol(w http.ResponseWriter, r *http.Request) {
 	newArgs.trashInterval = req.trashInterval
 	newArgs.accessTimeValidInterval = req.accessTimeValidInterval
 	newArgs.enablePersistAccessTime = req.enablePersistAccessTime
+	newArgs.inlineDataThreshold = req.inlineDataThreshold
 	if req.coldArgs != nil {
 		newArgs.coldArgs = req.coldArgs
 	}
@@ -3222,6 +3223,7 @@ func newSimpleView(vol *Vol) (view *proto.SimpleVolView) {
 		EnableAutoDpMetaRepair:  vol.EnableAutoMetaRepair.Load(),
 		AccessTimeInterval:      vol.AccessTimeValidInterval,
 		EnablePersistAccessTime: vol.EnablePersistAccessTime,
+		InlineDataThreshold:     vol.InlineDataThreshold,
 
 		VolStorageClass:          vol.volStorageClass,
 		ForbidWriteOpOfProtoVer0: vol.ForbidWriteOpOfProtoVer0.Load(),
@@ -7609,6 +7611,100 @@ func (m *Server) ListQuotaAll(w http.ResponseWriter, r *http.Request) {
 	sendOkReply(w, r, newSuccessHTTPReply(volsInfo))
 }
 
+func (m *Server) SetUserQuota(w http.ResponseWriter, r *http.Request) {
+	req := &proto.SetUserQuotaRequest{}
+	var (
+		err error
+		vol *Vol
+	)
+
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.UserQuotaSet))
+	defer func() {
+		doStatAndMetric(proto.UserQuotaSet, metric, err, map[string]string{exporter.Vol: req.VolName})
+		AuditLog(r, proto.UserQuotaSet, fmt.Sprintf("set vol(%v) %v(%v) quota", req.VolName, req.Type, req.Id), err)
+	}()
+
+	if err = parseSetUserQuotaParam(r, req); err != nil {
+		log.LogErrorf("[SetUserQuota] set user quota fail err [%v]", err)
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
+	if vol, err = m.cluster.getVol(req.VolName); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+
+	if err = vol.userQuotaManager.setUserQuota(req); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+
+	msg := fmt.Sprintf("set user quota successfully, vol [%v] %v [%v]", req.VolName, req.Type, req.Id)
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
+
+func (m *Server) DeleteUserQuota(w http.ResponseWriter, r *http.Request) {
+	var (
+		err  error
+		vol  *Vol
+		key  proto.UserQuotaKey
+		name string
+	)
+
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.UserQuotaDelete))
+	defer func() {
+		doStatAndMetric(proto.UserQuotaDelete, metric, err, map[string]string{exporter.Vol: name})
+		AuditLog(r, proto.UserQuotaDelete, fmt.Sprintf("delete vol(%v) %v(%v) quota", name, key.Type, key.Id), err)
+	}()
+
+	if name, key, err = parseDeleteUserQuotaParam(r); err != nil {
+		log.LogErrorf("[DeleteUserQuota] del user quota fail err [%v]", err)
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+
+	if err = vol.userQuotaManager.deleteUserQuota(key); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+
+	msg := fmt.Sprintf("delete user quota successfully, vol [%v] %v [%v]", name, key.Type, key.Id)
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
+
+func (m *Server) ListUserQuota(w http.ResponseWriter, r *http.Request) {
+	var (
+		err  error
+		vol  *Vol
+		resp *proto.ListUserQuotaResponse
+		name string
+	)
+
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.UserQuotaList))
+	defer func() {
+		doStatAndMetric(proto.UserQuotaList, metric, err, map[string]string{exporter.Vol: name})
+	}()
+
+	if name, err = parseAndExtractName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+
+	resp = vol.userQuotaManager.listUserQuota()
+	sendOkReply(w, r, newSuccessHTTPReply(resp))
+}
+
 func (m *Server) GetQuota(w http.ResponseWriter, r *http.Request) {
 	var (
 		err       error
@@ -8042,7 +8138,14 @@ func (m *Server) SetBucketLifecycle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err = proto.ValidRules(req.Rules); err != nil {
+	// the configuration may only have the inventories without any lifecycle rule
+	if len(req.Rules) > 0 || len(req.Inventories) == 0 {
+		if err = proto.ValidRules(req.Rules); err != nil {
+			sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+			return
+		}
+	}
+	if err = proto.ValidInventories(req.Inventories); err != nil {
 		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
@@ -212,6 +212,62 @@ func (m *Server) removeUserPolicy(w http.ResponseWriter, r *http.Request) {
 	sendOkReply(w, r, newSuccessHTTPReply(userInfo))
 }
 
+func (m *Server) updateUserPublicAccessBlock(w http.ResponseWriter, r *http.Request) {
+	var (
+		userInfo *proto.UserInfo
+		bytes    []byte
+		err      error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.UserUpdatePublicAccessBlock))
+	defer func() {
+		doStatAndMetric(proto.UserUpdatePublicAccessBlock, metric, err, nil)
+	}()
+
+	if bytes, err = io.ReadAll(r.Body); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	param := proto.UserPublicAccessBlockUpdateParam{}
+	if err = json.Unmarshal(bytes, &param); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if userInfo, err = m.user.updatePublicAccessBlock(&param); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	AuditLog(r, "updateUserPublicAccessBlock", fmt.Sprintf("update user public access block: %v %+v", userInfo, param.Config), nil)
+	sendOkReply(w, r, newSuccessHTTPReply(userInfo))
+}
+
+func (m *Server) updateUserAccountMetadata(w http.ResponseWriter, r *http.Request) {
+	var (
+		userInfo *proto.UserInfo
+		bytes    []byte
+		err      error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.UserUpdateAccountMetadata))
+	defer func() {
+		doStatAndMetric(proto.UserUpdateAccountMetadata, metric, err, nil)
+	}()
+
+	if bytes, err = io.ReadAll(r.Body); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	param := proto.UserAccountMetadataUpdateParam{}
+	if err = json.Unmarshal(bytes, &param); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if userInfo, err = m.user.updateAccountMetadata(&param); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	AuditLog(r, "updateUserAccountMetadata", fmt.Sprintf("update user account metadata: %v %v", userInfo, param.Metadata), nil)
+	sendOkReply(w, r, newSuccessHTTPReply(userInfo))
+}
+
 func (m *Server) deleteUserVolPolicy(w http.ResponseWriter, r *http.Request) {
 	var (
 		vol string
@@ -977,6 +977,9 @@ func (c *Cluster) checkMetaNodeHeartbeat() {
 					hbReq.QuotaHbInfos = append(hbReq.QuotaHbInfos, quotaHbInfos...)
 				}
 			}
+			if vol.userQuotaManager != nil {
+				hbReq.UserQuotaHbInfos = append(hbReq.UserQuotaHbInfos, vol.userQuotaManager.getUserQuotaHbInfos()...)
+			}
 
 			hbReq.TxInfo = append(hbReq.TxInfo, &proto.TxInfo{
 				Volume:     vol.Name,
@@ -4109,6 +4112,7 @@ func (c *Cluster) doCreateVol(req *createVolReq) (vol *Vol, err error) {
 		TrashInterval:           req.trashInterval,
 		AccessTimeInterval:      req.accessTimeValidInterval,
 		EnablePersistAccessTime: req.enablePersistAccessTime,
+		InlineDataThreshold:     req.inlineDataThreshold,
 
 		VolStorageClass:     req.volStorageClass,
 		AllowedStorageClass: req.allowedStorageClass,
@@ -6048,8 +6052,9 @@ func (c *Cluster) getSnapshotDelVer() {
 
 func (c *Cluster) SetBucketLifecycle(req *proto.LcConfiguration) error {
 	lcConf := &proto.LcConfiguration{
-		VolName: req.VolName,
-		Rules:   req.Rules,
+		VolName:     req.VolName,
+		Rules:       req.Rules,
+		Inventories: req.Inventories,
 	}
 	if c.lcMgr.GetS3BucketLifecycle(req.VolName) != nil {
 		if err := c.syncUpdateLcConf(lcConf); err != nil {
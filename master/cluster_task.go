@@ -1208,6 +1208,7 @@ func (c *Cluster) updateMetaNode(metaNode *MetaNode, metaPartitions []*proto.Met
 		mp.updateMetaPartition(mr, metaNode, c)
 		vol.uidSpaceManager.pushUidMsg(mr)
 		vol.quotaManager.quotaUpdate(mr)
+		vol.userQuotaManager.userQuotaUpdate(mr)
 		c.updateInodeIDUpperBound(mp, mr, threshold, metaNode)
 	}
 }
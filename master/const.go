@@ -122,6 +122,12 @@ const (
 	MaxFilesKey                            = "maxFiles"
 	MaxBytesKey                            = "maxBytes"
 	quotaKey                               = "quotaId"
+	userQuotaTypeKey                       = "type"
+	softBytesKey                           = "softBytes"
+	hardBytesKey                           = "hardBytes"
+	softInodesKey                          = "softInodes"
+	hardInodesKey                          = "hardInodes"
+	gracePeriodKey                         = "gracePeriod"
 	enableQuota                            = "enableQuota"
 	dpDiscardKey                           = "dpDiscard"
 	ignoreDiscardKey                       = "ignoreDiscard"
@@ -144,6 +150,7 @@ const (
 	trashIntervalKey                       = "trashInterval"
 	accessTimeIntervalKey                  = "accessTimeValidInterval"
 	enablePersistAccessTimeKey             = "enablePersistAccessTime"
+	inlineDataThresholdKey                 = "inlineDataThreshold"
 	mediaTypeKey                           = "mediaType"
 	allowedStorageClassKey                 = "allowedStorageClass"
 	volStorageClassKey                     = "volStorageClass"
@@ -343,6 +350,10 @@ const (
 	opSyncAllocQuotaID uint32 = 0x40
 	opSyncSetQuota     uint32 = 0x41
 	opSyncDeleteQuota  uint32 = 0x42
+
+	opSyncSetUserQuota    uint32 = 0x43
+	opSyncDeleteUserQuota uint32 = 0x44
+
 	opSyncMulitVersion uint32 = 0x53
 
 	opSyncS3QosSet    uint32 = 0x60
@@ -426,6 +437,8 @@ func init() {
 		opSyncAllocQuotaID,
 		opSyncSetQuota,
 		opSyncDeleteQuota,
+		opSyncSetUserQuota,
+		opSyncDeleteUserQuota,
 		opSyncMulitVersion,
 
 		opSyncS3QosSet,
@@ -485,6 +498,7 @@ const (
 	volUserPrefix    = keySeparator + volUserAcronym + keySeparator
 	volWarnUsedRatio = 0.9
 	quotaPrefix      = keySeparator + "quota" + keySeparator
+	userQuotaPrefix  = keySeparator + "userQuota" + keySeparator
 	lcNodePrefix     = keySeparator + lcNodeAcronym + keySeparator
 	lcConfPrefix     = keySeparator + lcConfigurationAcronym + keySeparator
 	lcTaskPrefix     = keySeparator + lcTaskAcronym + keySeparator
@@ -218,13 +218,15 @@ var AuthenticationUri2MsgTypeMap = map[string]proto.MsgType{
 	proto.RecommissionDisk:              proto.MsgMasterRecommissionDiskReq,
 
 	// Master API user management
-	proto.UserCreate:          proto.MsgMasterUserCreateReq,
-	proto.UserDelete:          proto.MsgMasterUserDeleteReq,
-	proto.UserUpdate:          proto.MsgMasterUserUpdateReq,
-	proto.UserUpdatePolicy:    proto.MsgMasterUserUpdatePolicyReq,
-	proto.UserRemovePolicy:    proto.MsgMasterUserRemovePolicyReq,
-	proto.UserDeleteVolPolicy: proto.MsgMasterUserDeleteVolPolicyReq,
-	proto.UserTransferVol:     proto.MsgMasterUserTransferVolReq,
+	proto.UserCreate:                  proto.MsgMasterUserCreateReq,
+	proto.UserDelete:                  proto.MsgMasterUserDeleteReq,
+	proto.UserUpdate:                  proto.MsgMasterUserUpdateReq,
+	proto.UserUpdatePolicy:            proto.MsgMasterUserUpdatePolicyReq,
+	proto.UserRemovePolicy:            proto.MsgMasterUserRemovePolicyReq,
+	proto.UserDeleteVolPolicy:         proto.MsgMasterUserDeleteVolPolicyReq,
+	proto.UserTransferVol:             proto.MsgMasterUserTransferVolReq,
+	proto.UserUpdatePublicAccessBlock: proto.MsgMasterUserUpdatePublicAccessBlockReq,
+	proto.UserUpdateAccountMetadata:   proto.MsgMasterUserUpdateAccountMetadataReq,
 
 	// Master API zone management
 	proto.UpdateZone: proto.MsgMasterUpdateZoneReq,
@@ -859,6 +861,12 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.UserDeleteVolPolicy).
 		HandlerFunc(m.deleteUserVolPolicy)
+	router.NewRoute().Methods(http.MethodPost).
+		Path(proto.UserUpdatePublicAccessBlock).
+		HandlerFunc(m.updateUserPublicAccessBlock)
+	router.NewRoute().Methods(http.MethodPost).
+		Path(proto.UserUpdateAccountMetadata).
+		HandlerFunc(m.updateUserAccountMetadata)
 	router.NewRoute().Methods(http.MethodGet).
 		Path(proto.UserGetAKInfo).
 		HandlerFunc(m.getUserAKInfo)
@@ -911,6 +919,17 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet).
 		Path(proto.QuotaListAll).
 		HandlerFunc(m.ListQuotaAll)
+
+	// User quota
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.UserQuotaSet).
+		HandlerFunc(m.SetUserQuota)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.UserQuotaDelete).
+		HandlerFunc(m.DeleteUserQuota)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.UserQuotaList).
+		HandlerFunc(m.ListUserQuota)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminSetTrashInterval).
 		HandlerFunc(m.volSetTrashInterval)
@@ -62,6 +62,10 @@ func exist(task *proto.RuleTask, doing []*proto.LcNodeRuleTaskResponse, todo []*
 			log.LogInfof("startLcScan: exist doing task: %v, skip this task: %v", d, task)
 			return true
 		}
+		// the inventory only reads the objects, it is not conflicting with the lifecycle rules
+		if proto.IsInventoryTask(task.Id) != proto.IsInventoryTask(d.ID) {
+			continue
+		}
 		if task.VolName == d.Volume && task.Rule.GetPrefix() == d.Rule.GetPrefix() {
 			log.LogInfof("startLcScan: exist doing task: %v, skip this task: %v", d, task)
 			return true
@@ -80,6 +84,9 @@ func exist(task *proto.RuleTask, doing []*proto.LcNodeRuleTaskResponse, todo []*
 			log.LogInfof("startLcScan: exist todo task: %v, skip this task: %v", t, task)
 			return true
 		}
+		if proto.IsInventoryTask(task.Id) != proto.IsInventoryTask(t.Id) {
+			continue
+		}
 		if task.VolName == t.VolName && task.Rule.GetPrefix() == t.Rule.GetPrefix() {
 			log.LogInfof("startLcScan: exist todo task: %v, skip this task: %v", t, task)
 			return true
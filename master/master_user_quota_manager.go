@@ -0,0 +1,240 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/errors"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// MasterUserQuotaManager keeps the user and group quotas of a volume, and aggregates the usage
+// reported by the leaders of the meta partitions to start the grace periods and limit the writes.
+type MasterUserQuotaManager struct {
+	MpUsedInfoMap map[uint64][]*proto.UserQuotaReportInfo
+	QuotaInfoMap  map[proto.UserQuotaKey]*proto.UserQuotaInfo
+	UsedInfoMap   map[proto.UserQuotaKey]proto.UserQuotaUsedInfo
+	vol           *Vol
+	c             *Cluster
+
+	sync.RWMutex
+}
+
+func newMasterUserQuotaManager(vol *Vol) *MasterUserQuotaManager {
+	return &MasterUserQuotaManager{
+		MpUsedInfoMap: make(map[uint64][]*proto.UserQuotaReportInfo),
+		QuotaInfoMap:  make(map[proto.UserQuotaKey]*proto.UserQuotaInfo),
+		UsedInfoMap:   make(map[proto.UserQuotaKey]proto.UserQuotaUsedInfo),
+		vol:           vol,
+	}
+}
+
+func (uqMgr *MasterUserQuotaManager) userQuotaKey(quotaInfo *proto.UserQuotaInfo) string {
+	return userQuotaPrefix + strconv.FormatUint(uqMgr.vol.ID, 10) + keySeparator +
+		quotaInfo.Type.String() + keySeparator + strconv.FormatUint(uint64(quotaInfo.Id), 10)
+}
+
+func (uqMgr *MasterUserQuotaManager) syncUserQuota(op uint32, quotaInfo *proto.UserQuotaInfo) (err error) {
+	var value []byte
+	if value, err = json.Marshal(quotaInfo); err != nil {
+		log.LogErrorf("sync user quota [%v] marsha1 fail [%v].", quotaInfo, err)
+		return
+	}
+
+	metadata := new(RaftCmd)
+	metadata.Op = op
+	metadata.K = uqMgr.userQuotaKey(quotaInfo)
+	metadata.V = value
+
+	if err = uqMgr.c.submit(metadata); err != nil {
+		log.LogErrorf("sync user quota [%v] submit fail [%v].", quotaInfo, err)
+		return
+	}
+	return
+}
+
+func (uqMgr *MasterUserQuotaManager) setUserQuota(req *proto.SetUserQuotaRequest) (err error) {
+	uqMgr.Lock()
+	defer uqMgr.Unlock()
+
+	key := proto.UserQuotaKey{Type: req.Type, Id: req.Id}
+	quotaInfo := &proto.UserQuotaInfo{
+		VolName:     req.VolName,
+		Type:        req.Type,
+		Id:          req.Id,
+		CTime:       time.Now().Unix(),
+		SoftBytes:   req.SoftBytes,
+		HardBytes:   req.HardBytes,
+		SoftInodes:  req.SoftInodes,
+		HardInodes:  req.HardInodes,
+		GracePeriod: req.GracePeriod,
+		UsedInfo:    uqMgr.UsedInfoMap[key],
+	}
+	if !quotaInfo.HasLimit() {
+		err = errors.New("user quota has no limit.")
+		return
+	}
+	if old, isFind := uqMgr.QuotaInfoMap[key]; isFind {
+		// keep the running grace periods
+		quotaInfo.CTime = old.CTime
+		quotaInfo.BytesGraceEnd = old.BytesGraceEnd
+		quotaInfo.InodesGraceEnd = old.InodesGraceEnd
+	}
+	quotaInfo.Update(quotaInfo.UsedInfo, time.Now().Unix())
+
+	if err = uqMgr.syncUserQuota(opSyncSetUserQuota, quotaInfo); err != nil {
+		return
+	}
+
+	uqMgr.QuotaInfoMap[key] = quotaInfo
+	log.LogInfof("set user quota [%v] success.", quotaInfo)
+	return
+}
+
+func (uqMgr *MasterUserQuotaManager) deleteUserQuota(key proto.UserQuotaKey) (err error) {
+	uqMgr.Lock()
+	defer uqMgr.Unlock()
+
+	quotaInfo, isFind := uqMgr.QuotaInfoMap[key]
+	if !isFind {
+		log.LogErrorf("vol [%v] user quota %v [%v] is not exist.", uqMgr.vol.Name, key.Type, key.Id)
+		err = errors.New("user quota is not exist.")
+		return
+	}
+
+	if err = uqMgr.syncUserQuota(opSyncDeleteUserQuota, quotaInfo); err != nil {
+		return
+	}
+
+	delete(uqMgr.QuotaInfoMap, key)
+	log.LogInfof("delete user quota [%v] success.", quotaInfo)
+	return
+}
+
+func (uqMgr *MasterUserQuotaManager) listUserQuota() (resp *proto.ListUserQuotaResponse) {
+	uqMgr.RLock()
+	defer uqMgr.RUnlock()
+	resp = &proto.ListUserQuotaResponse{}
+	resp.Quotas = make([]*proto.UserQuotaInfo, 0, len(uqMgr.UsedInfoMap))
+	for _, quotaInfo := range uqMgr.QuotaInfoMap {
+		info := *quotaInfo
+		resp.Quotas = append(resp.Quotas, &info)
+	}
+	for key, usedInfo := range uqMgr.UsedInfoMap {
+		if _, isFind := uqMgr.QuotaInfoMap[key]; isFind {
+			continue
+		}
+		resp.Quotas = append(resp.Quotas, &proto.UserQuotaInfo{
+			VolName:  uqMgr.vol.Name,
+			Type:     key.Type,
+			Id:       key.Id,
+			UsedInfo: usedInfo,
+		})
+	}
+	sort.Slice(resp.Quotas, func(i, j int) bool {
+		if resp.Quotas[i].Type != resp.Quotas[j].Type {
+			return resp.Quotas[i].Type < resp.Quotas[j].Type
+		}
+		return resp.Quotas[i].Id < resp.Quotas[j].Id
+	})
+	return
+}
+
+func (uqMgr *MasterUserQuotaManager) userQuotaUpdate(report *proto.MetaPartitionReport) {
+	if !report.IsLeader {
+		return
+	}
+
+	uqMgr.Lock()
+
+	if len(report.UserQuotaReportInfos) == 0 {
+		delete(uqMgr.MpUsedInfoMap, report.PartitionID)
+	} else {
+		uqMgr.MpUsedInfoMap[report.PartitionID] = report.UserQuotaReportInfos
+	}
+
+	usedInfoMap := make(map[proto.UserQuotaKey]proto.UserQuotaUsedInfo)
+	for _, reportInfos := range uqMgr.MpUsedInfoMap {
+		for _, info := range reportInfos {
+			key := proto.UserQuotaKey{Type: info.Type, Id: info.Id}
+			usedInfo := usedInfoMap[key]
+			usedInfo.Add(&info.UsedInfo)
+			usedInfoMap[key] = usedInfo
+		}
+	}
+	uqMgr.UsedInfoMap = usedInfoMap
+
+	now := time.Now().Unix()
+	updated := make(map[proto.UserQuotaKey]*proto.UserQuotaInfo)
+	infos := make(map[proto.UserQuotaKey]*proto.UserQuotaInfo)
+	for key, quotaInfo := range uqMgr.QuotaInfoMap {
+		if quotaInfo.Update(usedInfoMap[key], now) {
+			info := *quotaInfo
+			updated[key] = quotaInfo
+			infos[key] = &info
+		}
+		log.LogDebugf("[userQuotaUpdate] %v [%v] quotaInfo [%v]", key.Type, key.Id, quotaInfo)
+	}
+	uqMgr.Unlock()
+
+	// the raft submit is done out of the lock, which is held by the heartbeats of all the meta partitions
+	for key, info := range infos {
+		uqMgr.syncUpdatedUserQuota(key, updated[key], info)
+	}
+}
+
+// syncUpdatedUserQuota persists the quota updated by the heartbeat. The quota may be set or deleted
+// during the submit, the latest one is persisted again so that the stale one is not loaded by the next leader.
+func (uqMgr *MasterUserQuotaManager) syncUpdatedUserQuota(key proto.UserQuotaKey, origin, info *proto.UserQuotaInfo) {
+	if err := uqMgr.syncUserQuota(opSyncSetUserQuota, info); err != nil {
+		return
+	}
+
+	uqMgr.RLock()
+	current, isFind := uqMgr.QuotaInfoMap[key]
+	var latest proto.UserQuotaInfo
+	if isFind {
+		latest = *current
+	}
+	uqMgr.RUnlock()
+
+	if !isFind {
+		uqMgr.syncUserQuota(opSyncDeleteUserQuota, info)
+	} else if current != origin {
+		uqMgr.syncUserQuota(opSyncSetUserQuota, &latest)
+	}
+}
+
+func (uqMgr *MasterUserQuotaManager) getUserQuotaHbInfos() (infos []*proto.UserQuotaInfo) {
+	uqMgr.RLock()
+	defer uqMgr.RUnlock()
+	for _, quotaInfo := range uqMgr.QuotaInfoMap {
+		info := *quotaInfo
+		infos = append(infos, &info)
+	}
+	return
+}
+
+func (uqMgr *MasterUserQuotaManager) HasUserQuota() bool {
+	uqMgr.RLock()
+	defer uqMgr.RUnlock()
+	return len(uqMgr.QuotaInfoMap) > 0
+}
@@ -384,6 +384,7 @@ type volValue struct {
 	DisableAuditLog                                        bool
 	AccessTimeInterval                                     int64
 	EnablePersistAccessTime                                bool
+	InlineDataThreshold                                    int64
 
 	Forbidden            bool
 	DpRepairBlockSize    uint64
@@ -478,6 +479,7 @@ func newVolValue(vol *Vol) (vv *volValue) {
 		EnableAutoMetaRepair:    vol.EnableAutoMetaRepair.Load(),
 		AccessTimeInterval:      vol.AccessTimeValidInterval,
 		EnablePersistAccessTime: vol.EnablePersistAccessTime,
+		InlineDataThreshold:     vol.InlineDataThreshold,
 
 		VolStorageClass:          vol.volStorageClass,
 		ForbidWriteOpOfProtoVer0: vol.ForbidWriteOpOfProtoVer0.Load(),
@@ -1944,6 +1946,10 @@ func (c *Cluster) loadQuota() (err error) {
 			log.LogErrorf("loadQuota loadQuotaManager vol [%v] fail err [%v]", name, err.Error())
 			return err
 		}
+		if err = vol.loadUserQuotaManager(c); err != nil {
+			log.LogErrorf("loadQuota loadUserQuotaManager vol [%v] fail err [%v]", name, err.Error())
+			return err
+		}
 	}
 	return
 }
@@ -1192,12 +1192,16 @@ func (mm *monitorMetrics) deleteS3LcVolMetric(id string) {
 	mm.lcVolExpired.DeleteLabelValues(id, "hdd")
 	mm.lcVolExpired.DeleteLabelValues(id, "blobstore")
 	mm.lcVolExpired.DeleteLabelValues(id, "skip")
+	mm.lcVolExpired.DeleteLabelValues(id, "restore")
+	mm.lcVolExpired.DeleteLabelValues(id, "inventory")
 	mm.lcVolMigrateBytes.DeleteLabelValues(id, "hdd")
 	mm.lcVolMigrateBytes.DeleteLabelValues(id, "blobstore")
 	mm.lcVolError.DeleteLabelValues(id, "delete")
 	mm.lcVolError.DeleteLabelValues(id, "hdd")
 	mm.lcVolError.DeleteLabelValues(id, "blobstore")
 	mm.lcVolError.DeleteLabelValues(id, "readdir")
+	mm.lcVolError.DeleteLabelValues(id, "restore")
+	mm.lcVolError.DeleteLabelValues(id, "inventory")
 }
 
 func (mm *monitorMetrics) setLcMetrics() {
@@ -1222,12 +1226,16 @@ func (mm *monitorMetrics) setLcMetrics() {
 		mm.lcVolExpired.SetWithLabelValues(float64(stat.ExpiredMToHddNum), id, "hdd")
 		mm.lcVolExpired.SetWithLabelValues(float64(stat.ExpiredMToBlobstoreNum), id, "blobstore")
 		mm.lcVolExpired.SetWithLabelValues(float64(stat.ExpiredSkipNum), id, "skip")
+		mm.lcVolExpired.SetWithLabelValues(float64(stat.ExpiredRestoreNum), id, "restore")
+		mm.lcVolExpired.SetWithLabelValues(float64(stat.InventoryObjectNum), id, "inventory")
 		mm.lcVolMigrateBytes.SetWithLabelValues(float64(stat.ExpiredMToHddBytes), id, "hdd")
 		mm.lcVolMigrateBytes.SetWithLabelValues(float64(stat.ExpiredMToBlobstoreBytes), id, "blobstore")
 		mm.lcVolError.SetWithLabelValues(float64(stat.ErrorDeleteNum), id, "delete")
 		mm.lcVolError.SetWithLabelValues(float64(stat.ErrorMToHddNum), id, "hdd")
 		mm.lcVolError.SetWithLabelValues(float64(stat.ErrorMToBlobstoreNum), id, "blobstore")
 		mm.lcVolError.SetWithLabelValues(float64(stat.ErrorReadDirNum), id, "readdir")
+		mm.lcVolError.SetWithLabelValues(float64(stat.ErrorRestoreNum), id, "restore")
+		mm.lcVolError.SetWithLabelValues(float64(stat.ErrorInventoryNum), id, "inventory")
 	}
 }
 
@@ -301,6 +301,39 @@ func (u *User) updatePolicy(params *proto.UserPermUpdateParam) (userInfo *proto.
 	return
 }
 
+func (u *User) updatePublicAccessBlock(params *proto.UserPublicAccessBlockUpdateParam) (userInfo *proto.UserInfo, err error) {
+	if userInfo, err = u.getUserInfo(params.UserID); err != nil {
+		return
+	}
+	userInfo.Mu.Lock()
+	defer userInfo.Mu.Unlock()
+	userInfo.PublicAccessBlock = params.Config
+	if err = u.syncUpdateUserInfo(userInfo); err != nil {
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	log.LogInfof("action[updatePublicAccessBlock], userID: %v, config: %+v", params.UserID, params.Config)
+	return
+}
+
+func (u *User) updateAccountMetadata(params *proto.UserAccountMetadataUpdateParam) (userInfo *proto.UserInfo, err error) {
+	if userInfo, err = u.getUserInfo(params.UserID); err != nil {
+		return
+	}
+	userInfo.Mu.Lock()
+	defer userInfo.Mu.Unlock()
+	userInfo.AccountMetadata = params.Metadata
+	if len(params.Metadata) == 0 {
+		userInfo.AccountMetadata = nil
+	}
+	if err = u.syncUpdateUserInfo(userInfo); err != nil {
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	log.LogInfof("action[updateAccountMetadata], userID: %v, metadata: %v", params.UserID, params.Metadata)
+	return
+}
+
 func (u *User) removePolicy(params *proto.UserPermRemoveParam) (userInfo *proto.UserInfo, err error) {
 	if userInfo, err = u.getUserInfo(params.UserID); err != nil {
 		return
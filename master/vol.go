@@ -61,6 +61,7 @@ type VolVarargs struct {
 	enableAutoDpMetaRepair   bool
 	accessTimeValidInterval  int64
 	enablePersistAccessTime  bool
+	inlineDataThreshold      int64
 	leaderRetryTimeout       int64
 	volStorageClass          uint32
 	allowedStorageClass      []uint32
@@ -175,6 +176,7 @@ type Vol struct {
 	AccessTimeInterval       int64
 	EnablePersistAccessTime  bool
 	AccessTimeValidInterval  int64
+	InlineDataThreshold      int64
 	LeaderRetryTimeout       int64 // s
 	EnableAutoMetaRepair     atomicutil.Bool
 	ForbidWriteOpOfProtoVer0 atomicutil.Bool
@@ -185,11 +187,12 @@ type Vol struct {
 	AuthenticSubItem
 	VolDeletionSubItem
 
-	qosManager      *QosCtrlManager
-	aclMgr          AclManager
-	uidSpaceManager *UidSpaceManager
-	quotaManager    *MasterQuotaManager
-	VersionMgr      *VolVersionManager
+	qosManager       *QosCtrlManager
+	aclMgr           AclManager
+	uidSpaceManager  *UidSpaceManager
+	quotaManager     *MasterQuotaManager
+	userQuotaManager *MasterUserQuotaManager
+	VersionMgr       *VolVersionManager
 
 	mpsLock *mpsLockManager
 	volLock sync.RWMutex
@@ -278,6 +281,7 @@ func newVol(vv volValue) (vol *Vol) {
 	vol.TrashInterval = vv.TrashInterval
 	vol.AccessTimeValidInterval = vv.AccessTimeInterval
 	vol.EnablePersistAccessTime = vv.EnablePersistAccessTime
+	vol.InlineDataThreshold = vv.InlineDataThreshold
 
 	vol.allowedStorageClass = make([]uint32, len(vv.AllowedStorageClass))
 	copy(vol.allowedStorageClass, vv.AllowedStorageClass)
@@ -297,6 +301,7 @@ func newVol(vv volValue) (vol *Vol) {
 		IdQuotaInfoMap: make(map[uint32]*proto.QuotaInfo),
 		vol:            vol,
 	}
+	vol.userQuotaManager = newMasterUserQuotaManager(vol)
 
 	return
 }
@@ -334,6 +339,7 @@ func newVolFromVolValue(vv *volValue) (vol *Vol) {
 	if vol.AccessTimeValidInterval == 0 {
 		vol.AccessTimeValidInterval = proto.DefaultAccessTimeValidInterval
 	}
+	vol.InlineDataThreshold = vv.InlineDataThreshold
 	vol.ForbidWriteOpOfProtoVer0.Store(vv.ForbidWriteOpOfProtoVer0)
 
 	if vol.remoteCacheTTL == 0 {
@@ -1818,6 +1824,7 @@ func setVolFromArgs(args *VolVarargs, vol *Vol) {
 	vol.AccessTimeInterval = args.accessTimeInterval
 	vol.EnableAutoMetaRepair.Store(args.enableAutoDpMetaRepair)
 	vol.EnablePersistAccessTime = args.enablePersistAccessTime
+	vol.InlineDataThreshold = args.inlineDataThreshold
 	vol.volStorageClass = args.volStorageClass
 	vol.allowedStorageClass = append([]uint32{}, args.allowedStorageClass...)
 	vol.ForbidWriteOpOfProtoVer0.Store(args.forbidWriteOpOfProtoVer0)
@@ -1882,6 +1889,7 @@ func getVolVarargs(vol *Vol) *VolVarargs {
 		accessTimeValidInterval:  vol.AccessTimeValidInterval,
 		trashInterval:            vol.TrashInterval,
 		enablePersistAccessTime:  vol.EnablePersistAccessTime,
+		inlineDataThreshold:      vol.InlineDataThreshold,
 		enableAutoDpMetaRepair:   vol.EnableAutoMetaRepair.Load(),
 		volStorageClass:          vol.volStorageClass,
 		allowedStorageClass:      append([]uint32{}, vol.allowedStorageClass...),
@@ -1904,6 +1912,7 @@ func getVolVarargs(vol *Vol) *VolVarargs {
 
 func (vol *Vol) initQuotaManager(c *Cluster) {
 	vol.quotaManager.c = c
+	vol.userQuotaManager.c = c
 }
 
 func (vol *Vol) loadQuotaManager(c *Cluster) (err error) {
@@ -1932,6 +1941,32 @@ func (vol *Vol) loadQuotaManager(c *Cluster) (err error) {
 	return err
 }
 
+func (vol *Vol) loadUserQuotaManager(c *Cluster) (err error) {
+	vol.userQuotaManager.c = c
+
+	result, err := c.fsm.store.SeekForPrefix([]byte(userQuotaPrefix + strconv.FormatUint(vol.ID, 10) + keySeparator))
+	if err != nil {
+		err = fmt.Errorf("loadUserQuotaManager get user quota failed, err [%v]", err)
+		return err
+	}
+
+	for _, value := range result {
+		quotaInfo := &proto.UserQuotaInfo{}
+
+		if err = json.Unmarshal(value, quotaInfo); err != nil {
+			log.LogErrorf("loadUserQuotaManager Unmarshal fail err [%v]", err)
+			return err
+		}
+		log.LogDebugf("loadUserQuotaManager info [%v]", quotaInfo)
+		if vol.Name != quotaInfo.VolName {
+			panic(fmt.Sprintf("vol name do not match vol name [%v], quotaInfo vol name [%v]", vol.Name, quotaInfo.VolName))
+		}
+		vol.userQuotaManager.QuotaInfoMap[quotaInfo.Key()] = quotaInfo
+	}
+
+	return err
+}
+
 func (vol *Vol) checkDataReplicaMeta(c *Cluster) (cnt int) {
 	partitions := vol.dataPartitions.clonePartitions()
 	checkMetaDp := make(map[uint64]*DataPartition)
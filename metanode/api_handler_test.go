@@ -82,6 +82,7 @@ func createMetaPartition(rootDir string, t *testing.T) (mp *metaPartition) {
 	}
 	mp.uidManager = NewUidMgr(mpC.VolName, mpC.PartitionId)
 	mp.mqMgr = NewQuotaManager(mpC.VolName, mpC.PartitionId)
+	mp.uqMgr = NewUserQuotaManager(mpC.VolName, mpC.PartitionId)
 
 	ino := NewInode(1, 0)
 	ino.StorageClass = proto.StorageClass_Replica_SSD
@@ -57,6 +57,8 @@ type (
 	UpdateDentryReq = proto.UpdateDentryRequest
 	// MetaNode -> Client updateDentry response
 	UpdateDentryResp = proto.UpdateDentryResponse
+	// Client -> MetaNode conditional updateDentry request
+	UpdateDentryIfReq = proto.UpdateDentryIfRequest
 	// Client -> MetaNode read dir request
 	ReadDirReq      = proto.ReadDirRequest
 	ReadDirOnlyReq  = proto.ReadDirOnlyRequest
@@ -209,6 +211,22 @@ const (
 
 	// freeze meta partition
 	opFSMSetFreeze = 92
+
+	// update dentry only if it points to the expected inode
+	opFSMUpdateDentryIf = 93
+
+	// posix lock and flock
+	opFSMSetLock   = 94
+	opFSMRenewLock = 95
+
+	// inline data of small files
+	opFSMWriteInline = 96
+
+	// share the extents of a file
+	opFSMShareExtents = 97
+
+	// append the extents only if the inode is unchanged
+	opFSMExtentsAddIf = 98
 )
 
 // new inode opCode
@@ -252,6 +270,9 @@ const (
 	cfsQosEnable                 = "qosEnable"   // bool
 	cfgReadDirIops               = "readDirIops" // int
 
+	// int, change events retained by each partition, 4096 by default, negative disables change feed
+	cfgChangeFeedCapacity = "changeFeedCapacity"
+
 	metaNodeDeleteBatchCountKey = "batchCount"
 	configNameResolveInterval   = "nameResolveInterval" // int
 )
@@ -558,6 +558,42 @@ func (td *TxUpdateDentry) Unmarshal(raw []byte) (err error) {
 	return
 }
 
+// UpdateDentryIf is the conditional update of the dentry, which is applied only
+// if the dentry still points to OldIno.
+type UpdateDentryIf struct {
+	Dentry *Dentry
+	OldIno uint64
+}
+
+func (ud *UpdateDentryIf) Marshal() (result []byte, err error) {
+	buff := bytes.NewBuffer(make([]byte, 0))
+	if err = binary.Write(buff, binary.BigEndian, ud.OldIno); err != nil {
+		return nil, err
+	}
+	bs, err := ud.Dentry.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = buff.Write(bs); err != nil {
+		return nil, err
+	}
+	result = buff.Bytes()
+	return
+}
+
+func (ud *UpdateDentryIf) Unmarshal(raw []byte) (err error) {
+	buff := bytes.NewBuffer(raw)
+	if err = binary.Read(buff, binary.BigEndian, &ud.OldIno); err != nil {
+		return
+	}
+	dentry := &Dentry{}
+	if err = dentry.Unmarshal(buff.Bytes()); err != nil {
+		return
+	}
+	ud.Dentry = dentry
+	return
+}
+
 type DentryBatch []*Dentry
 
 // Marshal marshals a dentry into a byte array. which will alloc mem in runtime
@@ -4,6 +4,9 @@ import (
 	"bytes"
 	"io/fs"
 	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
 )
 
 func dentryDqual(d1, d2 *Dentry) bool {
@@ -143,6 +146,65 @@ func TestDentryMarshalValue(t *testing.T) {
 	}
 }
 
+func TestUpdateDentryIfMarshal(t *testing.T) {
+	upd := &UpdateDentryIf{
+		Dentry: &Dentry{ParentId: 1, Name: "test", Inode: 1024, Type: uint32(fs.ModeType)},
+		OldIno: 1000,
+	}
+	data, err := upd.Marshal()
+	require.NoError(t, err)
+
+	upd2 := &UpdateDentryIf{}
+	require.NoError(t, upd2.Unmarshal(data))
+	require.Equal(t, upd.OldIno, upd2.OldIno)
+	require.True(t, dentryDqual(upd.Dentry, upd2.Dentry))
+}
+
+func TestFsmUpdateDentryIf(t *testing.T) {
+	initMp(t)
+	dir := testCreateInode(t, DirModeType)
+	file := testCreateInode(t, FileModeType)
+	testCreateDentry(t, dir.Inode, file.Inode, "file", FileModeType)
+
+	resp := mp.fsmUpdateDentryIf(&Dentry{ParentId: dir.Inode, Name: "file", Inode: file.Inode + 1}, file.Inode+2)
+	require.Equal(t, proto.OpExistErr, resp.Status)
+	resp = mp.fsmUpdateDentryIf(&Dentry{ParentId: dir.Inode, Name: "none", Inode: file.Inode + 1}, file.Inode)
+	require.Equal(t, proto.OpNotExistErr, resp.Status)
+
+	resp = mp.fsmUpdateDentryIf(&Dentry{ParentId: dir.Inode, Name: "file", Inode: file.Inode + 1}, file.Inode)
+	require.Equal(t, proto.OpOk, resp.Status)
+	require.Equal(t, file.Inode, resp.Msg.Inode)
+	resp = mp.fsmUpdateDentryIf(&Dentry{ParentId: dir.Inode, Name: "file", Inode: file.Inode + 2}, file.Inode)
+	require.Equal(t, proto.OpExistErr, resp.Status)
+}
+
+func TestUpdateDentryIf(t *testing.T) {
+	newMpWithMock(t)
+	dir := testCreateInode(t, DirModeType)
+	file := testCreateInode(t, FileModeType)
+	testCreateDentry(t, dir.Inode, file.Inode, "file", FileModeType)
+
+	tests := []struct {
+		oldIno uint64
+		status uint8
+	}{
+		// the update without the expected inode is sent by OpMetaUpdateDentry
+		{oldIno: 0, status: proto.OpArgMismatchErr},
+		{oldIno: file.Inode + 2, status: proto.OpExistErr},
+		{oldIno: file.Inode, status: proto.OpOk},
+		{oldIno: file.Inode, status: proto.OpExistErr},
+	}
+	for i, tc := range tests {
+		p := &Packet{}
+		req := &UpdateDentryIfReq{ParentID: dir.Inode, Name: "file", Inode: file.Inode + 1, OldIno: tc.oldIno}
+		mp.UpdateDentryIf(req, p, localAddrForAudit)
+		require.Equal(t, tc.status, p.ResultCode, "case %d", i)
+	}
+	den, status := mp.getDentry(&Dentry{ParentId: dir.Inode, Name: "file"})
+	require.Equal(t, proto.OpOk, status)
+	require.Equal(t, file.Inode+1, den.Inode)
+}
+
 func BenchmarkDentryMarshal(b *testing.B) {
 	d := &Dentry{
 		ParentId: 1,
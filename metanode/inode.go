@@ -38,6 +38,7 @@ const (
 	DeleteMarkFlag               = 1 << 0
 	InodeDelTop                  = 1 << 1
 	DeleteMigrationExtentKeyFlag = 1 << 2 // only delete migration ek by delay
+	SharedExtentsFlag            = 1 << 3 // some extents are shared with other inodes
 )
 
 const (
@@ -47,6 +48,7 @@ const (
 	V4EnableHybridCloud   uint64 = 0x08
 	// V4EBSExtentsFlag       uint64 = 0x20
 	V4MigrationExtentsFlag uint64 = 0x40
+	V5InlineDataFlag       uint64 = 0x80
 )
 
 // Inode wraps necessary properties of `Inode` information in the file system.
@@ -98,6 +100,9 @@ type Inode struct {
 
 	// pointer
 	LinkTarget []byte // SymLink target name
+	// InlineData keeps the data of a small file instead of extents, the file never has both.
+	// The range between the end of the inline data and the size of the file reads zeros.
+	InlineData []byte
 	// Snapshot
 	multiSnap                   *InodeMultiSnap
 	HybridCloudExtents          *SortedHybridCloudExtents
@@ -498,6 +503,7 @@ func (i *Inode) String() string {
 	}
 	buff.WriteString(fmt.Sprintf("ClientID[%v]", i.ClientID))
 	buff.WriteString(fmt.Sprintf("LeaseExpireTime[%v]", i.LeaseExpireTime))
+	buff.WriteString(fmt.Sprintf("InlineData[%d]", len(i.InlineData)))
 	buff.WriteString("}")
 	return buff.String()
 }
@@ -556,6 +562,10 @@ func (i *Inode) Copy() BtreeItem {
 		newIno.LinkTarget = make([]byte, size)
 		copy(newIno.LinkTarget, i.LinkTarget)
 	}
+	if size := len(i.InlineData); size > 0 {
+		newIno.InlineData = make([]byte, size)
+		copy(newIno.InlineData, i.InlineData)
+	}
 	newIno.NLink = i.NLink
 	newIno.Flag = i.Flag
 	newIno.Reserved = i.Reserved
@@ -611,6 +621,10 @@ func (i *Inode) CopyDirectly() BtreeItem {
 		newIno.LinkTarget = make([]byte, size)
 		copy(newIno.LinkTarget, i.LinkTarget)
 	}
+	if size := len(i.InlineData); size > 0 {
+		newIno.InlineData = make([]byte, size)
+		copy(newIno.InlineData, i.InlineData)
+	}
 	newIno.NLink = i.NLink
 	newIno.Flag = i.Flag
 	newIno.Reserved = i.Reserved
@@ -892,6 +906,10 @@ func (i *Inode) MarshalInodeValue(buff *buf.ByteBufExt) {
 		}
 	}
 
+	if len(i.InlineData) > 0 {
+		reserved |= V5InlineDataFlag
+	}
+
 	if i.HybridCloudExtentsMigration != nil && i.HybridCloudExtentsMigration.storageClass != proto.MediaType_Unspecified {
 		reserved |= V4MigrationExtentsFlag
 		if log.EnableDebug() {
@@ -973,6 +991,15 @@ func (i *Inode) MarshalInodeValue(buff *buf.ByteBufExt) {
 		panic(err)
 	}
 
+	if reserved&V5InlineDataFlag > 0 {
+		if err = buff.PutUint32(uint32(len(i.InlineData))); err != nil {
+			panic(err)
+		}
+		if _, err = buff.Write(i.InlineData); err != nil {
+			panic(err)
+		}
+	}
+
 	if reserved&V4MigrationExtentsFlag > 0 {
 		sem := i.HybridCloudExtentsMigration
 
@@ -1313,6 +1340,21 @@ func (i *Inode) UnmarshalInodeValueV2(buff *buf.ReadByteBuff) (err error) {
 			return
 		}
 
+		if i.Reserved&V5InlineDataFlag > 0 {
+			inlineSize := uint32(0)
+			if inlineSize, err = buff.ReadUint32(); err != nil {
+				err = UnmarshalInodeFiledError("InlineDataSize(v5)", err)
+				return
+			}
+			inlineData, err1 := buff.Next(int(inlineSize))
+			if err1 != nil {
+				err = UnmarshalInodeFiledError("InlineData(v5)", err1)
+				return
+			}
+			i.InlineData = make([]byte, inlineSize)
+			copy(i.InlineData, inlineData)
+		}
+
 		if i.StorageClass == proto.StorageClass_Unspecified && isFile {
 			i.StorageClass = proto.StorageClass_BlobStore
 		}
@@ -1388,6 +1430,7 @@ func (i *Inode) GetSpaceSize() (extSize uint64) {
 	if i.IsTempFile() || !i.IsFile() {
 		return
 	}
+	extSize += uint64(len(i.InlineData))
 	if i.HybridCloudExtents.sortedEks == nil {
 		return
 	}
@@ -1480,6 +1523,8 @@ func (i *Inode) AppendExtents(eks []proto.ExtentKey, ct int64, volType int) (del
 		}
 		delExtents = append(delExtents, delItems...)
 	}
+	// the client has written the inline data into the extents before appending them
+	i.InlineData = nil
 	i.Generation++
 	i.ModifyTime = ct
 
@@ -1503,6 +1548,7 @@ func (i *Inode) AppendObjExtents(eks []proto.ObjExtentKey, ct int64) (err error)
 			i.Size = size
 		}
 	}
+	i.InlineData = nil
 	i.Generation++
 	i.ModifyTime = ct
 	return
@@ -2242,6 +2288,7 @@ func (i *Inode) AppendExtentWithCheck(param *AppendExtParam) (delExtents []proto
 		if i.Size < size {
 			i.Size = size
 		}
+		i.InlineData = nil
 		i.Generation++
 		i.ModifyTime = param.ct
 	}
@@ -2249,6 +2296,15 @@ func (i *Inode) AppendExtentWithCheck(param *AppendExtParam) (delExtents []proto
 }
 
 func (i *Inode) ExtentsTruncate(length uint64, ct int64, insertRefMap func(ek *proto.ExtentKey)) (delExtents []proto.ExtentKey) {
+	if len(i.InlineData) > 0 {
+		if length < uint64(len(i.InlineData)) {
+			i.InlineData = i.InlineData[:length]
+		}
+		i.Size = length
+		i.ModifyTime = ct
+		i.Generation++
+		return
+	}
 	if i.HybridCloudExtents.sortedEks != nil {
 		extents := i.HybridCloudExtents.sortedEks.(*SortedExtents)
 		delExtents = extents.Truncate(length, insertRefMap)
@@ -2415,6 +2471,21 @@ func (i *Inode) NeedDeleteMigrationExtentKey() bool {
 	return i.Flag&DeleteMigrationExtentKeyFlag == DeleteMigrationExtentKeyFlag
 }
 
+// SetSharedExtents marks the inode as sharing some extents with other inodes.
+func (i *Inode) SetSharedExtents() {
+	i.Lock()
+	i.Flag |= SharedExtentsFlag
+	i.Unlock()
+}
+
+// HasSharedExtents returns if some extents of the inode may be shared with other inodes.
+func (i *Inode) HasSharedExtents() (ok bool) {
+	i.RLock()
+	ok = i.Flag&SharedExtentsFlag == SharedExtentsFlag
+	i.RUnlock()
+	return
+}
+
 // ShouldDelete returns if the inode has been marked as deleted.
 func (i *Inode) ShouldDelete() (ok bool) {
 	i.RLock()
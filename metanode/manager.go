@@ -80,6 +80,8 @@ type MetadataManagerConfig struct {
 	EnableGcTimer    bool
 	GcRecyclePercent float64
 	RaftStore        raftstore.RaftStore
+	// change events retained by each partition
+	ChangeFeedCapacity int
 }
 
 type verOp2Phase struct {
@@ -114,6 +116,7 @@ type metadataManager struct {
 	gcRecyclePercent     float64
 	gcTimer              *util.RecycleTimer
 	limitFactor          map[uint32]*rate.Limiter
+	changeFeedCapacity   int
 }
 
 func (m *metadataManager) GetAllVolumes() (volumes *util.Set) {
@@ -278,6 +281,8 @@ func (m *metadataManager) HandleMetadataOperation(conn net.Conn, p *Packet, remo
 		err = m.opBatchDeleteDentry(conn, p, remoteAddr)
 	case proto.OpMetaUpdateDentry:
 		err = m.opUpdateDentry(conn, p, remoteAddr)
+	case proto.OpMetaUpdateDentryIf:
+		err = m.opUpdateDentryIf(conn, p, remoteAddr)
 	case proto.OpMetaReadDir:
 		err = m.opReadDir(conn, p, remoteAddr)
 	case proto.OpMetaReadDirOnly:
@@ -356,6 +361,26 @@ func (m *metadataManager) HandleMetadataOperation(conn net.Conn, p *Packet, remo
 	// operation for dir lock
 	case proto.OpMetaLockDir:
 		err = m.opMetaLockDir(conn, p, remoteAddr)
+	// operations for posix lock and flock
+	case proto.OpMetaSetLock:
+		err = m.opMetaSetLock(conn, p, remoteAddr)
+	case proto.OpMetaGetLock:
+		err = m.opMetaGetLock(conn, p, remoteAddr)
+	case proto.OpMetaRenewLock:
+		err = m.opMetaRenewLock(conn, p, remoteAddr)
+	// operation for change feed
+	case proto.OpMetaGetChangeEvents:
+		err = m.opMetaGetChangeEvents(conn, p, remoteAddr)
+	// operations for inline data of small files
+	case proto.OpMetaWriteInline:
+		err = m.opMetaWriteInline(conn, p, remoteAddr)
+	case proto.OpMetaReadInline:
+		err = m.opMetaReadInline(conn, p, remoteAddr)
+	// operation for sharing extents
+	case proto.OpMetaShareExtents:
+		err = m.opMetaShareExtents(conn, p, remoteAddr)
+	case proto.OpMetaAppendExtentKeysIf:
+		err = m.opMetaAppendExtentKeysIf(conn, p, remoteAddr)
 	// operations for multipart session
 	case proto.OpCreateMultipart:
 		err = m.opCreateMultipart(conn, p, remoteAddr)
@@ -923,6 +948,7 @@ func NewMetadataManager(conf MetadataManagerConfig, metaNode *MetaNode) Metadata
 		enableGcTimer:        conf.EnableGcTimer,
 		gcRecyclePercent:     conf.GcRecyclePercent,
 		limitFactor:          make(map[uint32]*rate.Limiter),
+		changeFeedCapacity:   conf.ChangeFeedCapacity,
 	}
 	m.limitFactor[readDirIops] = rate.NewLimiter(rate.Limit(metaNode.readDirIops), metaNode.readDirIops/2)
 
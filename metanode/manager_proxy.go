@@ -43,6 +43,7 @@ func (m *metadataManager) IsForbiddenOp(mp MetaPartition, reqOp uint8) bool {
 		proto.OpMetaTxDeleteDentry,
 		proto.OpMetaBatchDeleteDentry,
 		proto.OpMetaUpdateDentry,
+		proto.OpMetaUpdateDentryIf,
 		proto.OpMetaTxUpdateDentry,
 		// extend
 		proto.OpMetaUpdateXAttr,
@@ -56,6 +57,7 @@ func (m *metadataManager) IsForbiddenOp(mp MetaPartition, reqOp uint8) bool {
 		proto.OpMetaObjExtentAdd,
 		proto.OpMetaBatchObjExtentsAdd,
 		proto.OpMetaBatchExtentsAdd,
+		proto.OpMetaAppendExtentKeysIf,
 		proto.OpMetaExtentsDel,
 		// inode
 		proto.OpMetaCreateInode,
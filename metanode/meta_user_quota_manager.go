@@ -0,0 +1,238 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// MetaUserQuotaManager counts the bytes and inodes used by every user and group in the meta partition,
+// and checks the user quotas sent by master before the inodes are created, written or chowned.
+// The usage is updated by the fsm operations and rebuilt from every snapshot of the inode tree, the
+// changes applied while the snapshot is scanned are added to the rebuilt usage when it is finished.
+type MetaUserQuotaManager struct {
+	usedInfos        map[proto.UserQuotaKey]*proto.UserQuotaUsedInfo
+	reportedInfos    map[proto.UserQuotaKey]proto.UserQuotaUsedInfo
+	rebuildInfos     map[proto.UserQuotaKey]*proto.UserQuotaUsedInfo
+	rebuildDeltas    map[proto.UserQuotaKey]*proto.UserQuotaUsedInfo
+	quotaInfos       map[proto.UserQuotaKey]*proto.UserQuotaInfo
+	rbuildbySnapshot bool
+	volName          string
+	rwlock           sync.RWMutex
+	mpID             uint64
+}
+
+func NewUserQuotaManager(volName string, mpId uint64) (uqMgr *MetaUserQuotaManager) {
+	uqMgr = &MetaUserQuotaManager{
+		usedInfos:     make(map[proto.UserQuotaKey]*proto.UserQuotaUsedInfo),
+		reportedInfos: make(map[proto.UserQuotaKey]proto.UserQuotaUsedInfo),
+		quotaInfos:    make(map[proto.UserQuotaKey]*proto.UserQuotaInfo),
+		volName:       volName,
+		mpID:          mpId,
+	}
+	return
+}
+
+func userQuotaKeys(uid, gid uint32) []proto.UserQuotaKey {
+	return []proto.UserQuotaKey{
+		{Type: proto.UserQuotaTypeUser, Id: uid},
+		{Type: proto.UserQuotaTypeGroup, Id: gid},
+	}
+}
+
+func userQuotaUsedBytes(ino *Inode) int64 {
+	if !proto.IsRegular(ino.Type) {
+		return 0
+	}
+	return int64(ino.Size)
+}
+
+func addUserQuotaUsedInfo(infos map[proto.UserQuotaKey]*proto.UserQuotaUsedInfo, uid, gid uint32, delta *proto.UserQuotaUsedInfo) {
+	for _, key := range userQuotaKeys(uid, gid) {
+		usedInfo, ok := infos[key]
+		if !ok {
+			usedInfo = &proto.UserQuotaUsedInfo{}
+			infos[key] = usedInfo
+		}
+		usedInfo.Add(delta)
+	}
+}
+
+func (uqMgr *MetaUserQuotaManager) setUserQuotaHbInfo(infos []*proto.UserQuotaInfo) {
+	uqMgr.rwlock.Lock()
+	defer uqMgr.rwlock.Unlock()
+
+	quotaInfos := make(map[proto.UserQuotaKey]*proto.UserQuotaInfo)
+	for _, info := range infos {
+		if uqMgr.volName != info.VolName {
+			continue
+		}
+		quotaInfos[info.Key()] = info
+	}
+	uqMgr.quotaInfos = quotaInfos
+}
+
+// getUserQuotaReportInfos returns the usage of all the users and groups if the volume has any user quota,
+// which is reported to master to be aggregated.
+func (uqMgr *MetaUserQuotaManager) getUserQuotaReportInfos() (infos []*proto.UserQuotaReportInfo) {
+	uqMgr.rwlock.Lock()
+	defer uqMgr.rwlock.Unlock()
+
+	if len(uqMgr.quotaInfos) == 0 {
+		return
+	}
+	reportedInfos := make(map[proto.UserQuotaKey]proto.UserQuotaUsedInfo, len(uqMgr.usedInfos))
+	for key, usedInfo := range uqMgr.usedInfos {
+		if usedInfo.UsedBytes == 0 && usedInfo.UsedInodes == 0 {
+			continue
+		}
+		infos = append(infos, &proto.UserQuotaReportInfo{
+			Type:     key.Type,
+			Id:       key.Id,
+			UsedInfo: *usedInfo,
+		})
+		reportedInfos[key] = *usedInfo
+	}
+	uqMgr.reportedInfos = reportedInfos
+	return
+}
+
+func (uqMgr *MetaUserQuotaManager) updateUsedInfo(uid, gid uint32, delta *proto.UserQuotaUsedInfo) {
+	if delta.UsedBytes == 0 && delta.UsedInodes == 0 {
+		return
+	}
+	uqMgr.rwlock.Lock()
+	defer uqMgr.rwlock.Unlock()
+
+	addUserQuotaUsedInfo(uqMgr.usedInfos, uid, gid, delta)
+	if uqMgr.rbuildbySnapshot {
+		addUserQuotaUsedInfo(uqMgr.rebuildDeltas, uid, gid, delta)
+	}
+}
+
+func (uqMgr *MetaUserQuotaManager) addInode(ino *Inode) {
+	uqMgr.updateUsedInfo(ino.Uid, ino.Gid, &proto.UserQuotaUsedInfo{UsedBytes: userQuotaUsedBytes(ino), UsedInodes: 1})
+}
+
+func (uqMgr *MetaUserQuotaManager) removeInode(ino *Inode) {
+	uqMgr.updateUsedInfo(ino.Uid, ino.Gid, &proto.UserQuotaUsedInfo{UsedBytes: -userQuotaUsedBytes(ino), UsedInodes: -1})
+}
+
+func (uqMgr *MetaUserQuotaManager) updateSize(ino *Inode, oldSize uint64) {
+	if !proto.IsRegular(ino.Type) {
+		return
+	}
+	uqMgr.updateUsedInfo(ino.Uid, ino.Gid, &proto.UserQuotaUsedInfo{UsedBytes: int64(ino.Size) - int64(oldSize)})
+}
+
+func (uqMgr *MetaUserQuotaManager) changeOwner(ino *Inode, oldUid, oldGid uint32) {
+	if ino.Uid == oldUid && ino.Gid == oldGid {
+		return
+	}
+	uqMgr.addInode(ino)
+	uqMgr.updateUsedInfo(oldUid, oldGid, &proto.UserQuotaUsedInfo{UsedBytes: -userQuotaUsedBytes(ino), UsedInodes: -1})
+}
+
+func (uqMgr *MetaUserQuotaManager) statisticByLoad(ino *Inode) {
+	uqMgr.rwlock.Lock()
+	defer uqMgr.rwlock.Unlock()
+	addUserQuotaUsedInfo(uqMgr.usedInfos, ino.Uid, ino.Gid, &proto.UserQuotaUsedInfo{UsedBytes: userQuotaUsedBytes(ino), UsedInodes: 1})
+}
+
+func (uqMgr *MetaUserQuotaManager) statisticByStore(ino *Inode) {
+	uqMgr.rwlock.Lock()
+	defer uqMgr.rwlock.Unlock()
+	if !uqMgr.rbuildbySnapshot {
+		return
+	}
+	addUserQuotaUsedInfo(uqMgr.rebuildInfos, ino.Uid, ino.Gid, &proto.UserQuotaUsedInfo{UsedBytes: userQuotaUsedBytes(ino), UsedInodes: 1})
+}
+
+func (uqMgr *MetaUserQuotaManager) statisticRebuildStart() bool {
+	uqMgr.rwlock.Lock()
+	defer uqMgr.rwlock.Unlock()
+	if uqMgr.rbuildbySnapshot {
+		return false
+	}
+	uqMgr.rbuildbySnapshot = true
+	uqMgr.rebuildInfos = make(map[proto.UserQuotaKey]*proto.UserQuotaUsedInfo)
+	uqMgr.rebuildDeltas = make(map[proto.UserQuotaKey]*proto.UserQuotaUsedInfo)
+	return true
+}
+
+func (uqMgr *MetaUserQuotaManager) statisticRebuildFin(rebuild bool) {
+	uqMgr.rwlock.Lock()
+	defer uqMgr.rwlock.Unlock()
+	if !uqMgr.rbuildbySnapshot {
+		return
+	}
+	uqMgr.rbuildbySnapshot = false
+	if rebuild {
+		for key, delta := range uqMgr.rebuildDeltas {
+			if usedInfo, ok := uqMgr.rebuildInfos[key]; ok {
+				usedInfo.Add(delta)
+			} else {
+				usedInfo := *delta
+				uqMgr.rebuildInfos[key] = &usedInfo
+			}
+		}
+		for key, usedInfo := range uqMgr.rebuildInfos {
+			if old, ok := uqMgr.usedInfos[key]; !ok || *old != *usedInfo {
+				log.LogDebugf("statisticRebuildFin: mp[%v] %v %v used %v rebuilt to %v",
+					uqMgr.mpID, key.Type, key.Id, old, usedInfo)
+			}
+		}
+		uqMgr.usedInfos = uqMgr.rebuildInfos
+	}
+	uqMgr.rebuildInfos = nil
+	uqMgr.rebuildDeltas = nil
+}
+
+// isOverQuota checks if the quotas of the users and groups are exceeded after the allocation, the usage
+// aggregated by master is corrected with the changes of this partition since the last report.
+func (uqMgr *MetaUserQuotaManager) isOverQuota(keys []proto.UserQuotaKey, alloc proto.UserQuotaUsedInfo) (status uint8) {
+	uqMgr.rwlock.RLock()
+	defer uqMgr.rwlock.RUnlock()
+	if len(uqMgr.quotaInfos) == 0 {
+		return
+	}
+
+	now := time.Now().Unix()
+	for _, key := range keys {
+		quotaInfo, ok := uqMgr.quotaInfos[key]
+		if !ok {
+			continue
+		}
+		usedInfo := quotaInfo.UsedInfo
+		if local, ok := uqMgr.usedInfos[key]; ok {
+			usedInfo.Add(local)
+		}
+		reported := uqMgr.reportedInfos[key]
+		usedInfo.UsedBytes += alloc.UsedBytes - reported.UsedBytes
+		usedInfo.UsedInodes += alloc.UsedInodes - reported.UsedInodes
+
+		if (alloc.UsedBytes > 0 && quotaInfo.IsOverBytes(usedInfo.UsedBytes, now)) ||
+			(alloc.UsedInodes > 0 && quotaInfo.IsOverInodes(usedInfo.UsedInodes, now)) {
+			log.LogWarnf("isOverQuota: vol(%v) mp[%v] %v %v is over quota, used %v quota %v",
+				uqMgr.volName, uqMgr.mpID, key.Type, key.Id, usedInfo, quotaInfo)
+			return proto.OpNoSpaceErr
+		}
+	}
+	return
+}
@@ -464,12 +464,13 @@ func (m *MetaNode) newMetaManager(cfg *config.Config) (err error) {
 
 	// load metadataManager
 	conf := MetadataManagerConfig{
-		NodeID:           m.nodeId,
-		RootDir:          m.metadataDir,
-		RaftStore:        m.raftStore,
-		ZoneName:         m.zoneName,
-		EnableGcTimer:    cfg.GetBoolWithDefault(cfgEnableGcTimer, false),
-		GcRecyclePercent: gcRecyclePercent,
+		NodeID:             m.nodeId,
+		RootDir:            m.metadataDir,
+		RaftStore:          m.raftStore,
+		ZoneName:           m.zoneName,
+		EnableGcTimer:      cfg.GetBoolWithDefault(cfgEnableGcTimer, false),
+		GcRecyclePercent:   gcRecyclePercent,
+		ChangeFeedCapacity: int(cfg.GetInt64WithDefault(cfgChangeFeedCapacity, defaultChangeFeedCapacity)),
 	}
 	m.metadataManager = NewMetadataManager(conf, m)
 	return
@@ -88,6 +88,7 @@ func newPartition(conf *MetaPartitionConfig, manager *metadataManager) (mp *meta
 	mp.config.End = 100000
 	mp.uidManager = NewUidMgr(conf.VolName, mp.config.PartitionId)
 	mp.mqMgr = NewQuotaManager(conf.VolName, mp.config.PartitionId)
+	mp.uqMgr = NewUserQuotaManager(conf.VolName, mp.config.PartitionId)
 	return mp
 }
 
@@ -1175,6 +1176,7 @@ func NewMetaPartitionForTest() *metaPartition {
 	partition.uniqChecker.keepTime = 1
 	partition.uniqChecker.keepOps = 0
 	partition.mqMgr = NewQuotaManager(VolNameForTest, 1)
+	partition.uqMgr = NewUserQuotaManager(VolNameForTest, 1)
 
 	return partition
 }
@@ -1269,6 +1271,7 @@ func newMpWithMock(t *testing.T) {
 	mp.config.End = 100000
 	mp.uidManager = NewUidMgr(metaConf.VolName, metaConf.PartitionId)
 	mp.mqMgr = NewQuotaManager(metaConf.VolName, metaConf.PartitionId)
+	mp.uqMgr = NewUserQuotaManager(metaConf.VolName, metaConf.PartitionId)
 	mp.multiVersionList.VerList = append(mp.multiVersionList.VerList, &proto.VolVersionInfo{
 		Ver: 0,
 	})
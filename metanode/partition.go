@@ -166,6 +166,9 @@ type OpExtend interface {
 	ListXAttr(req *proto.ListXAttrRequest, p *Packet) (err error)
 	UpdateXAttr(req *proto.UpdateXAttrRequest, p *Packet) (err error)
 	LockDir(req *proto.LockDirRequest, p *Packet) (err error)
+	SetLock(req *proto.SetLockRequest, p *Packet) (err error)
+	GetLock(req *proto.GetLockRequest, p *Packet) (err error)
+	RenewLock(req *proto.RenewLockRequest, p *Packet) (err error)
 }
 
 // OpDentry defines the interface for the dentry operations.
@@ -174,6 +177,7 @@ type OpDentry interface {
 	DeleteDentry(req *DeleteDentryReq, p *Packet, remoteAddr string) (err error)
 	DeleteDentryBatch(req *BatchDeleteDentryReq, p *Packet, remoteAddr string) (err error)
 	UpdateDentry(req *UpdateDentryReq, p *Packet, remoteAddr string) (err error)
+	UpdateDentryIf(req *UpdateDentryIfReq, p *Packet, remoteAddr string) (err error)
 	ReadDir(req *ReadDirReq, p *Packet) (err error)
 	ReadDirLimit(req *ReadDirLimitReq, p *Packet) (err error)
 	ReadDirOnly(req *ReadDirOnlyReq, p *Packet) (err error)
@@ -206,6 +210,10 @@ type OpExtent interface {
 	ObjExtentsList(req *proto.GetExtentsRequest, p *Packet) (err error)
 	ExtentsTruncate(req *ExtentsTruncateReq, p *Packet, remoteAddr string) (err error)
 	BatchExtentAppend(req *proto.AppendExtentKeysRequest, p *Packet) (err error)
+	WriteInline(req *proto.WriteInlineRequest, p *Packet) (err error)
+	ReadInline(req *proto.ReadInlineRequest, p *Packet) (err error)
+	ShareExtents(req *proto.ShareExtentsRequest, p *Packet, remoteAddr string) (err error)
+	ExtentAppendIf(req *proto.AppendExtentKeysIfRequest, p *Packet, remoteAddr string) (err error)
 	// ExtentsDelete(req *proto.DelExtentKeyRequest, p *Packet) (err error)
 }
 
@@ -272,6 +280,7 @@ type OpPartition interface {
 	IsEquareCreateMetaPartitionRequst(request *proto.CreateMetaPartitionRequest) (err error)
 	GetUniqID(p *Packet, num uint32) (err error)
 	CloseAndBackupRaft() error
+	GetChangeEvents(req *proto.GetChangeEventsRequest, p *Packet) (err error)
 }
 
 // MetaPartition defines the interface for the meta partition operations.
@@ -407,6 +416,8 @@ func (uMgr *UidManager) accumInoUidSize(ino *Inode, accum map[uint32]int64) {
 type OpQuota interface {
 	setQuotaHbInfo(infos []*proto.QuotaHeartBeatInfo)
 	getQuotaReportInfos() (infos []*proto.QuotaReportInfo)
+	setUserQuotaHbInfo(infos []*proto.UserQuotaInfo)
+	getUserQuotaReportInfos() (infos []*proto.UserQuotaReportInfo)
 	batchSetInodeQuota(req *proto.BatchSetMetaserverQuotaReuqest,
 		resp *proto.BatchSetMetaserverQuotaResponse) (err error)
 	batchDeleteInodeQuota(req *proto.BatchDeleteMetaserverQuotaReuqest,
@@ -510,6 +521,7 @@ type metaPartition struct {
 	xattrLock                 sync.Mutex
 	fileRange                 []int64
 	mqMgr                     *MetaQuotaManager
+	uqMgr                     *MetaUserQuotaManager
 	nonIdempotent             sync.Mutex
 	uniqChecker               *uniqChecker
 	verSeq                    uint64
@@ -519,9 +531,12 @@ type metaPartition struct {
 	recycleInodeDelFileFlag   atomicutil.Flag
 	enablePersistAccessTime   bool
 	accessTimeValidInterval   uint64
+	inlineDataThreshold       uint64
 	statByStorageClass        []*proto.StatOfStorageClass
 	statByMigrateStorageClass []*proto.StatOfStorageClass
 	syncAtimeCh               chan uint64
+	changeFeed                *changeFeed
+	sharedExtents             sharedExtents
 }
 
 // IsLeader returns the raft leader address and if the current meta partition is the leader.
@@ -798,6 +813,7 @@ func (mp *metaPartition) onStart(isCreate bool) (err error) {
 			mp.config.PartitionId, err.Error())
 		return
 	}
+	mp.changeFeed.reset(mp.applyID)
 	mp.startScheduleTask()
 
 	retryCnt := 0
@@ -1019,6 +1035,9 @@ func NewMetaPartition(conf *MetaPartitionConfig, manager *metadataManager) MetaP
 		mp.config.ForbidWriteOpOfProtoVer0 = manager.isVolForbidWriteOpOfProtoVer0(mp.config.VolName)
 	}
 	mp.txProcessor = NewTransactionProcessor(mp)
+	if manager != nil {
+		mp.changeFeed = newChangeFeed(manager.changeFeedCapacity)
+	}
 	go mp.batchSyncInodeAtime()
 	return mp
 }
@@ -0,0 +1,236 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+)
+
+const (
+	defaultChangeEventsLimit  = 1000
+	defaultChangeFeedCapacity = 4096
+)
+
+// changeFeed keeps the latest change events applied by a meta partition in memory. The events are
+// recorded by every replica when the raft entries are applied, so they survive leader changes, but
+// they are not persisted: the events are lost when the partition restarts or installs a snapshot,
+// and the oldest events are dropped beyond the capacity. A consumer resuming from a sequence number
+// whose following events are lost is told the cursor is expired instead of being given a gap, it
+// should rescan the partition then and continue from the oldest events retained.
+type changeFeed struct {
+	sync.RWMutex
+	capacity int
+	events   []*proto.ChangeEvent
+	// the events after startSeq are all retained
+	startSeq uint64
+}
+
+func newChangeFeed(capacity int) *changeFeed {
+	return &changeFeed{capacity: capacity}
+}
+
+func (cf *changeFeed) enabled() bool {
+	return cf != nil && cf.capacity > 0
+}
+
+// reset drops the retained events, the events before applyID are not available any more.
+func (cf *changeFeed) reset(applyID uint64) {
+	if cf == nil {
+		return
+	}
+	cf.Lock()
+	defer cf.Unlock()
+	cf.events = nil
+	cf.startSeq = applyID
+}
+
+func (cf *changeFeed) append(ev *proto.ChangeEvent) {
+	cf.Lock()
+	defer cf.Unlock()
+	cf.events = append(cf.events, ev)
+	if drop := len(cf.events) - cf.capacity; drop > 0 {
+		// keep the events of the same raft entry together
+		for drop < len(cf.events) && cf.events[drop].Seq == cf.events[drop-1].Seq {
+			drop++
+		}
+		cf.startSeq = cf.events[drop-1].Seq
+		cf.events = cf.events[drop:]
+	}
+}
+
+// get returns at most limit events after from, the events of the same raft entry are never split.
+// The feed starts from the oldest events retained if from is zero, and the cursor is expired if
+// some events after from are not retained any more.
+func (cf *changeFeed) get(from uint64, limit int, applyID uint64) (resp *proto.GetChangeEventsResponse) {
+	cf.RLock()
+	defer cf.RUnlock()
+	resp = &proto.GetChangeEventsResponse{
+		Next:  applyID,
+		Start: cf.startSeq,
+	}
+	if from > 0 && from < cf.startSeq {
+		resp.Expired = true
+		resp.Next = from
+		return
+	}
+	i := sort.Search(len(cf.events), func(i int) bool {
+		return cf.events[i].Seq > from
+	})
+	for ; i < len(cf.events); i++ {
+		if len(resp.Events) >= limit && cf.events[i].Seq != resp.Events[len(resp.Events)-1].Seq {
+			resp.Next = resp.Events[len(resp.Events)-1].Seq
+			break
+		}
+		resp.Events = append(resp.Events, cf.events[i])
+	}
+	if n := len(resp.Events); n > 0 && resp.Events[n-1].Seq > resp.Next {
+		resp.Next = resp.Events[n-1].Seq
+	}
+	if resp.Next < from {
+		resp.Next = from
+	}
+	return
+}
+
+func (mp *metaPartition) recordChangeEvent(index uint64, ev *proto.ChangeEvent) {
+	ev.Seq = index
+	ev.PartitionId = mp.config.PartitionId
+	ev.Time = time.Now().Unix()
+	mp.changeFeed.append(ev)
+}
+
+func (mp *metaPartition) recordInodeChange(index uint64, typ proto.ChangeEventType, ino uint64, status uint8) {
+	if !mp.changeFeed.enabled() || status != proto.OpOk {
+		return
+	}
+	mp.recordChangeEvent(index, &proto.ChangeEvent{Type: typ, Inode: ino})
+}
+
+func (mp *metaPartition) recordDentryChange(index uint64, typ proto.ChangeEventType, den *Dentry, status uint8) {
+	if !mp.changeFeed.enabled() || status != proto.OpOk || den == nil {
+		return
+	}
+	mp.recordChangeEvent(index, &proto.ChangeEvent{
+		Type:     typ,
+		Inode:    den.Inode,
+		ParentId: den.ParentId,
+		Name:     den.Name,
+	})
+}
+
+// recordRenameChange records the update of the dentry to newIno, the dentry of the response keeps
+// the replaced inode, and it is nil if the dentry is not changed.
+func (mp *metaPartition) recordRenameChange(index uint64, den *Dentry, newIno uint64, resp *DentryResponse) {
+	if !mp.changeFeed.enabled() || resp.Status != proto.OpOk || resp.Msg == nil {
+		return
+	}
+	mp.recordChangeEvent(index, &proto.ChangeEvent{
+		Type:     proto.ChangeEventRename,
+		Inode:    newIno,
+		ParentId: den.ParentId,
+		Name:     den.Name,
+		OldInode: resp.Msg.Inode,
+	})
+}
+
+func (mp *metaPartition) recordXAttrChange(index uint64, typ proto.ChangeEventType, extend *Extend, err error) {
+	if !mp.changeFeed.enabled() || err != nil {
+		return
+	}
+	ev := &proto.ChangeEvent{Type: typ, Inode: extend.GetInode()}
+	extend.Range(func(key, value []byte) bool {
+		ev.Keys = append(ev.Keys, string(key))
+		return true
+	})
+	mp.recordChangeEvent(index, ev)
+}
+
+// txInodeRollbackChange returns the event of the inode to be published if the change made by the
+// transaction is rolled back, it is nil if there is nothing to publish.
+func (mp *metaPartition) txInodeRollbackChange(ifo *proto.TxInodeInfo) *proto.ChangeEvent {
+	if !mp.changeFeed.enabled() {
+		return nil
+	}
+	rbInode := mp.txProcessor.txResource.getTxRbInode(ifo.Ino)
+	if rbInode == nil || rbInode.txInodeInfo.TxID != ifo.TxID {
+		return nil
+	}
+	ev := &proto.ChangeEvent{Inode: ifo.Ino}
+	switch rbInode.rbType {
+	case TxAdd:
+		ev.Type = proto.ChangeEventInodeLink
+	case TxDelete:
+		ev.Type = proto.ChangeEventInodeUnlink
+	default:
+		return nil
+	}
+	return ev
+}
+
+// txDentryRollbackChange returns the event of the dentry to be published if the change made by the
+// transaction is rolled back, it is nil if there is nothing to publish.
+func (mp *metaPartition) txDentryRollbackChange(ifo *proto.TxDentryInfo) *proto.ChangeEvent {
+	if !mp.changeFeed.enabled() {
+		return nil
+	}
+	rbDentry := mp.txProcessor.txResource.getTxRbDentry(ifo.ParentId, ifo.Name)
+	if rbDentry == nil || rbDentry.txDentryInfo.TxID != ifo.TxID {
+		return nil
+	}
+	den := rbDentry.dentry
+	ev := &proto.ChangeEvent{Inode: den.Inode, ParentId: den.ParentId, Name: den.Name}
+	switch rbDentry.rbType {
+	case TxAdd:
+		ev.Type = proto.ChangeEventCreate
+	case TxDelete:
+		ev.Type = proto.ChangeEventUnlink
+	case TxUpdate:
+		item := mp.dentryTree.Get(den)
+		if item == nil {
+			return nil
+		}
+		ev.Type = proto.ChangeEventRename
+		ev.OldInode = item.(*Dentry).Inode
+	default:
+		return nil
+	}
+	return ev
+}
+
+// GetChangeEvents returns the change events of the partition after the requested sequence number.
+func (mp *metaPartition) GetChangeEvents(req *proto.GetChangeEventsRequest, p *Packet) (err error) {
+	if !mp.changeFeed.enabled() {
+		p.PacketErrorWithBody(proto.OpNotPerm, []byte("change feed is not enabled"))
+		return
+	}
+	limit := int(req.Limit)
+	if limit <= 0 || limit > defaultChangeEventsLimit {
+		limit = defaultChangeEventsLimit
+	}
+	resp := mp.changeFeed.get(req.From, limit, mp.getApplyID())
+
+	var reply []byte
+	if reply, err = json.Marshal(resp); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(reply)
+	return
+}
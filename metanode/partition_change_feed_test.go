@@ -0,0 +1,119 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangeFeedGet(t *testing.T) {
+	cf := newChangeFeed(4)
+	seqs := func(events []*proto.ChangeEvent) (result []uint64) {
+		for _, ev := range events {
+			result = append(result, ev.Seq)
+		}
+		return
+	}
+	// the batch deletion of seq 3 records two events
+	for _, seq := range []uint64{1, 2, 3, 3} {
+		cf.append(&proto.ChangeEvent{Seq: seq})
+	}
+
+	resp := cf.get(0, 10, 5)
+	require.Equal(t, []uint64{1, 2, 3, 3}, seqs(resp.Events))
+	require.Equal(t, uint64(5), resp.Next)
+	require.False(t, resp.Expired)
+
+	// the events of the same raft entry are not split by the limit
+	resp = cf.get(1, 2, 5)
+	require.Equal(t, []uint64{2, 3, 3}, seqs(resp.Events))
+	require.Equal(t, uint64(5), resp.Next)
+	resp = cf.get(0, 1, 5)
+	require.Equal(t, []uint64{1}, seqs(resp.Events))
+	require.Equal(t, uint64(1), resp.Next)
+
+	// the oldest events are dropped beyond the capacity, the feed without a cursor starts from
+	// the oldest events retained
+	cf.append(&proto.ChangeEvent{Seq: 4})
+	resp = cf.get(0, 10, 5)
+	require.Equal(t, []uint64{2, 3, 3, 4}, seqs(resp.Events))
+	require.False(t, resp.Expired)
+	require.Equal(t, uint64(1), resp.Start)
+	require.False(t, cf.get(1, 10, 5).Expired)
+
+	// the cursor is expired if the events after it are dropped
+	cf.append(&proto.ChangeEvent{Seq: 5})
+	resp = cf.get(1, 10, 5)
+	require.True(t, resp.Expired)
+	require.Empty(t, resp.Events)
+	require.Equal(t, uint64(1), resp.Next)
+	require.Equal(t, uint64(2), resp.Start)
+	resp = cf.get(resp.Start, 10, 5)
+	require.False(t, resp.Expired)
+	require.Equal(t, []uint64{3, 3, 4, 5}, seqs(resp.Events))
+
+	// the events before the restart are lost
+	cf.reset(8)
+	resp = cf.get(5, 10, 8)
+	require.True(t, resp.Expired)
+	require.Equal(t, uint64(8), resp.Start)
+	resp = cf.get(8, 10, 8)
+	require.False(t, resp.Expired)
+	require.Empty(t, resp.Events)
+	require.Equal(t, uint64(8), resp.Next)
+}
+
+func TestChangeFeedApply(t *testing.T) {
+	mp := NewMetaPartitionForTest()
+	mp.changeFeed = newChangeFeed(16)
+	apply := func(op uint32, index uint64, extend *Extend) {
+		val, err := extend.Bytes()
+		require.NoError(t, err)
+		cmd, err := NewMetaItem(op, nil, val).MarshalJson()
+		require.NoError(t, err)
+		_, err = mp.Apply(cmd, index)
+		require.NoError(t, err)
+	}
+
+	extend := NewExtend(100)
+	extend.Put([]byte("user.a"), []byte("1"), 0)
+	apply(opFSMSetXAttr, 1, extend)
+	apply(opFSMRemoveXAttr, 2, extend)
+
+	p := &Packet{}
+	require.NoError(t, mp.GetChangeEvents(&proto.GetChangeEventsRequest{From: 0}, p))
+	require.Equal(t, proto.OpOk, p.ResultCode)
+	resp := &proto.GetChangeEventsResponse{}
+	require.NoError(t, json.Unmarshal(p.Data, resp))
+	require.Len(t, resp.Events, 2)
+	require.Equal(t, proto.ChangeEventSetXAttr, resp.Events[0].Type)
+	require.Equal(t, proto.ChangeEventRemoveXAttr, resp.Events[1].Type)
+	for i, ev := range resp.Events {
+		require.Equal(t, uint64(i+1), ev.Seq)
+		require.Equal(t, uint64(100), ev.Inode)
+		require.Equal(t, uint64(PartitionIdForTest), ev.PartitionId)
+		require.Equal(t, []string{"user.a"}, ev.Keys)
+	}
+
+	// the feed is disabled by default
+	mp.changeFeed = nil
+	p = &Packet{}
+	require.NoError(t, mp.GetChangeEvents(&proto.GetChangeEventsRequest{}, p))
+	require.Equal(t, proto.OpNotPerm, p.ResultCode)
+}
@@ -110,6 +110,7 @@ func (mp *metaPartition) UpdateVolumeView(dataView *proto.DataPartitionsView, vo
 		volumeView.AccessTimeInterval = proto.MinAccessTimeValidInterval
 	}
 	atomic.StoreUint64(&mp.accessTimeValidInterval, uint64(volumeView.AccessTimeInterval))
+	atomic.StoreUint64(&mp.inlineDataThreshold, uint64(volumeView.InlineDataThreshold))
 }
 
 func (mp *metaPartition) checkHybridMigrationInode() {
@@ -527,7 +528,15 @@ func (mp *metaPartition) deleteMarkedReplicaInodes(inoSlice []uint64,
 		}
 
 		extInfo := inode.GetAllExtsOfflineInode(mp.config.PartitionId, isMigration)
+		// the extents shared with other inodes are deleted when the inode is removed if they are not
+		// referred to any more, see freeSharedExtents
+		hasSharedExtents := inode.HasSharedExtents()
 		for dpID, inodeExts := range extInfo {
+			if hasSharedExtents {
+				if inodeExts = mp.skipSharedExtents(inodeExts); len(inodeExts) == 0 {
+					continue
+				}
+			}
 			exts, ok := deleteExtentsByPartition[dpID]
 			if !ok {
 				exts = make([]*proto.DelExtentParam, 0)
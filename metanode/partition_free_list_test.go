@@ -48,6 +48,7 @@ func newPartitionForFreeList(conf *MetaPartitionConfig, manager *metadataManager
 	mp.config.End = 100000
 	mp.uidManager = NewUidMgr(conf.VolName, mp.config.PartitionId)
 	mp.mqMgr = NewQuotaManager(conf.VolName, mp.config.PartitionId)
+	mp.uqMgr = NewUserQuotaManager(conf.VolName, mp.config.PartitionId)
 	return mp
 }
 
@@ -65,7 +65,9 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 		if mp.config.Cursor < ino.Inode {
 			mp.config.Cursor = ino.Inode
 		}
-		resp = mp.fsmCreateInode(ino)
+		status := mp.fsmCreateInode(ino)
+		mp.recordInodeChange(index, proto.ChangeEventInodeCreate, ino.Inode, status)
+		resp = status
 	case opFSMCreateInodeQuota:
 		qinode := &MetaQuotaInode{}
 		if err = qinode.Unmarshal(msg.V); err != nil {
@@ -78,7 +80,9 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 		if len(qinode.quotaIds) > 0 {
 			mp.setInodeQuota(qinode.quotaIds, ino.Inode)
 		}
-		resp = mp.fsmCreateInode(ino)
+		status := mp.fsmCreateInode(ino)
+		mp.recordInodeChange(index, proto.ChangeEventInodeCreate, ino.Inode, status)
+		resp = status
 	case opFSMUnlinkInode:
 		ino := NewInode(0, 0)
 		if err = ino.Unmarshal(msg.V); err != nil {
@@ -90,7 +94,9 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 			resp = &InodeResponse{Status: status}
 			return
 		}
-		resp = mp.fsmUnlinkInode(ino, 0)
+		r := mp.fsmUnlinkInode(ino, 0)
+		mp.recordInodeChange(index, proto.ChangeEventInodeUnlink, ino.Inode, r.Status)
+		resp = r
 	case opFSMUnlinkInodeOnce:
 		var inoOnceWithVersion *InodeOnceWithVersion
 		if inoOnceWithVersion, err = InodeOnceUnmarshal(msg.V); err != nil {
@@ -104,19 +110,27 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 		}
 		ino := NewInode(inoOnceWithVersion.Inode, 0)
 		ino.setVer(inoOnceWithVersion.VerSeq)
-		resp = mp.fsmUnlinkInode(ino, inoOnceWithVersion.UniqID)
+		r := mp.fsmUnlinkInode(ino, inoOnceWithVersion.UniqID)
+		mp.recordInodeChange(index, proto.ChangeEventInodeUnlink, ino.Inode, r.Status)
+		resp = r
 	case opFSMUnlinkInodeBatch:
 		inodes, err := InodeBatchUnmarshal(msg.V)
 		if err != nil {
 			return nil, err
 		}
-		resp = mp.fsmUnlinkInodeBatch(inodes)
+		rs := mp.fsmUnlinkInodeBatch(inodes)
+		for i, r := range rs {
+			mp.recordInodeChange(index, proto.ChangeEventInodeUnlink, inodes[i].Inode, r.Status)
+		}
+		resp = rs
 	case opFSMExtentTruncate:
 		ino := NewInode(0, 0)
 		if err = ino.Unmarshal(msg.V); err != nil {
 			return
 		}
-		resp = mp.fsmExtentsTruncate(ino)
+		r := mp.fsmExtentsTruncate(ino)
+		mp.recordInodeChange(index, proto.ChangeEventExtents, ino.Inode, r.Status)
+		resp = r
 	case opFSMCreateLinkInode:
 		ino := NewInode(0, 0)
 		if err = ino.Unmarshal(msg.V); err != nil {
@@ -127,7 +141,9 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 			resp = &InodeResponse{Status: status}
 			return
 		}
-		resp = mp.fsmCreateLinkInode(ino, 0)
+		r := mp.fsmCreateLinkInode(ino, 0)
+		mp.recordInodeChange(index, proto.ChangeEventInodeLink, ino.Inode, r.Status)
+		resp = r
 	case opFSMCreateLinkInodeOnce:
 		var inoOnceWithVersion *InodeOnceWithVersion
 		if inoOnceWithVersion, err = InodeOnceUnmarshal(msg.V); err != nil {
@@ -140,7 +156,9 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 		}
 		ino := NewInode(inoOnceWithVersion.Inode, 0)
 		ino.setVer(inoOnceWithVersion.VerSeq)
-		resp = mp.fsmCreateLinkInode(ino, inoOnceWithVersion.UniqID)
+		r := mp.fsmCreateLinkInode(ino, inoOnceWithVersion.UniqID)
+		mp.recordInodeChange(index, proto.ChangeEventInodeLink, ino.Inode, r.Status)
+		resp = r
 	case opFSMEvictInode:
 		ino := NewInode(0, 0)
 		if err = ino.Unmarshal(msg.V); err != nil {
@@ -151,13 +169,19 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 			resp = &InodeResponse{Status: status}
 			return
 		}
-		resp = mp.fsmEvictInode(ino)
+		r := mp.fsmEvictInode(ino)
+		mp.recordInodeChange(index, proto.ChangeEventInodeEvict, ino.Inode, r.Status)
+		resp = r
 	case opFSMEvictInodeBatch:
 		inodes, err := InodeBatchUnmarshal(msg.V)
 		if err != nil {
 			return nil, err
 		}
-		resp = mp.fsmBatchEvictInode(inodes)
+		rs := mp.fsmBatchEvictInode(inodes)
+		for i, r := range rs {
+			mp.recordInodeChange(index, proto.ChangeEventInodeEvict, inodes[i].Inode, r.Status)
+		}
+		resp = rs
 	case opFSMSetAttr:
 		req := &SetattrRequest{}
 		err = json.Unmarshal(msg.V, req)
@@ -165,6 +189,9 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 			return
 		}
 		err = mp.fsmSetAttr(req)
+		if err == nil {
+			mp.recordInodeChange(index, proto.ChangeEventSetAttr, req.Inode, proto.OpOk)
+		}
 	case opFSMCreateDentry:
 		den := &Dentry{}
 		if err = den.Unmarshal(msg.V); err != nil {
@@ -177,7 +204,9 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 			return
 		}
 
-		resp = mp.fsmCreateDentry(den, false)
+		status = mp.fsmCreateDentry(den, false)
+		mp.recordDentryChange(index, proto.ChangeEventCreate, den, status)
+		resp = status
 	case opFSMDeleteDentry:
 		den := &Dentry{}
 		if err = den.Unmarshal(msg.V); err != nil {
@@ -192,13 +221,19 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 			return
 		}
 
-		resp = mp.fsmDeleteDentry(den, false)
+		r := mp.fsmDeleteDentry(den, false)
+		mp.recordDentryChange(index, proto.ChangeEventUnlink, r.Msg, r.Status)
+		resp = r
 	case opFSMDeleteDentryBatch:
 		db, err := DentryBatchUnmarshal(msg.V)
 		if err != nil {
 			return nil, err
 		}
-		resp = mp.fsmBatchDeleteDentry(db)
+		rs := mp.fsmBatchDeleteDentry(db)
+		for _, r := range rs {
+			mp.recordDentryChange(index, proto.ChangeEventUnlink, r.Msg, r.Status)
+		}
+		resp = rs
 	case opFSMUpdateDentry:
 		den := &Dentry{}
 		if err = den.Unmarshal(msg.V); err != nil {
@@ -211,7 +246,26 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 			return
 		}
 
-		resp = mp.fsmUpdateDentry(den)
+		newIno := den.Inode
+		r := mp.fsmUpdateDentry(den)
+		mp.recordRenameChange(index, den, newIno, r)
+		resp = r
+	case opFSMUpdateDentryIf:
+		upd := &UpdateDentryIf{}
+		if err = upd.Unmarshal(msg.V); err != nil {
+			return
+		}
+
+		status := mp.dentryInTx(upd.Dentry.ParentId, upd.Dentry.Name)
+		if status != proto.OpOk {
+			resp = &DentryResponse{Status: status}
+			return
+		}
+
+		newIno := upd.Dentry.Inode
+		r := mp.fsmUpdateDentryIf(upd.Dentry, upd.OldIno)
+		mp.recordRenameChange(index, upd.Dentry, newIno, r)
+		resp = r
 	case opFSMUpdatePartition:
 		req := &UpdatePartitionReq{}
 		if err = json.Unmarshal(msg.V, req); err != nil {
@@ -223,25 +277,48 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 		if err = ino.Unmarshal(msg.V); err != nil {
 			return
 		}
-		resp = mp.fsmAppendExtents(ino)
+		status := mp.fsmAppendExtents(ino)
+		mp.recordInodeChange(index, proto.ChangeEventExtents, ino.Inode, status)
+		resp = status
 	case opFSMExtentsAddWithCheck:
 		ino := NewInode(0, 0)
 		if err = ino.Unmarshal(msg.V); err != nil {
 			return
 		}
-		resp = mp.fsmAppendExtentsWithCheck(ino, false)
+		status := mp.fsmAppendExtentsWithCheck(ino, false)
+		mp.recordInodeChange(index, proto.ChangeEventExtents, ino.Inode, status)
+		resp = status
+	case opFSMExtentsAddIf:
+		req := &proto.AppendExtentKeysIfRequest{}
+		if err = json.Unmarshal(msg.V, req); err != nil {
+			return
+		}
+		extend := NewExtend(req.Inode)
+		for key, val := range req.Attrs {
+			extend.Put([]byte(key), []byte(val), 0)
+		}
+		status := mp.fsmAppendExtentsIf(req, extend)
+		mp.recordInodeChange(index, proto.ChangeEventExtents, req.Inode, status)
+		if status == proto.OpOk && len(req.Attrs) > 0 {
+			mp.recordXAttrChange(index, proto.ChangeEventSetXAttr, extend, nil)
+		}
+		resp = status
 	case opFSMExtentSplit:
 		ino := NewInode(0, 0)
 		if err = ino.Unmarshal(msg.V); err != nil {
 			return
 		}
-		resp = mp.fsmAppendExtentsWithCheck(ino, true)
+		status := mp.fsmAppendExtentsWithCheck(ino, true)
+		mp.recordInodeChange(index, proto.ChangeEventExtents, ino.Inode, status)
+		resp = status
 	case opFSMObjExtentsAdd:
 		ino := NewInode(0, 0)
 		if err = ino.Unmarshal(msg.V); err != nil {
 			return
 		}
-		resp = mp.fsmAppendObjExtents(ino)
+		status := mp.fsmAppendObjExtents(ino)
+		mp.recordInodeChange(index, proto.ChangeEventExtents, ino.Inode, status)
+		resp = status
 	case opFSMSentToChan:
 		resp = mp.fsmSendToChan(msg.V, false)
 	case opFSMSentToChanWithVer:
@@ -257,24 +334,26 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 		txId := mp.txProcessor.txManager.txIdAlloc.getTransactionID()
 		quotaRebuild := mp.mqMgr.statisticRebuildStart()
 		uidRebuild := mp.acucumRebuildStart()
+		userQuotaRebuild := mp.uqMgr.statisticRebuildStart()
 		uniqId := mp.GetUniqId()
 		uniqChecker := mp.uniqChecker.clone()
 		msg := &storeMsg{
-			command:        opFSMStoreTick,
-			applyIndex:     index,
-			txId:           txId,
-			inodeTree:      inodeTree,
-			dentryTree:     dentryTree,
-			extendTree:     extendTree,
-			multipartTree:  multipartTree,
-			txTree:         txTree,
-			txRbInodeTree:  txRbInodeTree,
-			txRbDentryTree: txRbDentryTree,
-			quotaRebuild:   quotaRebuild,
-			uidRebuild:     uidRebuild,
-			uniqId:         uniqId,
-			uniqChecker:    uniqChecker,
-			multiVerList:   mp.GetAllVerList(),
+			command:          opFSMStoreTick,
+			applyIndex:       index,
+			txId:             txId,
+			inodeTree:        inodeTree,
+			dentryTree:       dentryTree,
+			extendTree:       extendTree,
+			multipartTree:    multipartTree,
+			txTree:           txTree,
+			txRbInodeTree:    txRbInodeTree,
+			txRbDentryTree:   txRbDentryTree,
+			quotaRebuild:     quotaRebuild,
+			uidRebuild:       uidRebuild,
+			userQuotaRebuild: userQuotaRebuild,
+			uniqId:           uniqId,
+			uniqChecker:      uniqChecker,
+			multiVerList:     mp.GetAllVerList(),
 		}
 		log.LogDebugf("opFSMStoreTick: quotaRebuild [%v] uidRebuild [%v]", quotaRebuild, uidRebuild)
 		mp.storeChan <- msg
@@ -292,24 +371,55 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 			return
 		}
 		err = mp.fsmSetXAttr(extend)
+		mp.recordXAttrChange(index, proto.ChangeEventSetXAttr, extend, err)
 	case opFSMRemoveXAttr:
 		var extend *Extend
 		if extend, err = NewExtendFromBytes(msg.V); err != nil {
 			return
 		}
 		err = mp.fsmRemoveXAttr(extend)
+		mp.recordXAttrChange(index, proto.ChangeEventRemoveXAttr, extend, err)
 	case opFSMUpdateXAttr:
 		var extend *Extend
 		if extend, err = NewExtendFromBytes(msg.V); err != nil {
 			return
 		}
 		err = mp.fsmSetXAttr(extend)
+		mp.recordXAttrChange(index, proto.ChangeEventSetXAttr, extend, err)
 	case opFSMLockDir:
 		req := &proto.LockDirRequest{}
 		if err = json.Unmarshal(msg.V, req); err != nil {
 			return
 		}
 		resp = mp.fsmLockDir(req)
+	case opFSMSetLock:
+		req := &proto.SetLockRequest{}
+		if err = json.Unmarshal(msg.V, req); err != nil {
+			return
+		}
+		resp = mp.fsmSetLock(req)
+	case opFSMRenewLock:
+		req := &proto.RenewLockRequest{}
+		if err = json.Unmarshal(msg.V, req); err != nil {
+			return
+		}
+		resp = mp.fsmRenewLock(req)
+	case opFSMWriteInline:
+		req := &proto.WriteInlineRequest{}
+		if err = json.Unmarshal(msg.V, req); err != nil {
+			return
+		}
+		r := mp.fsmWriteInline(req)
+		mp.recordInodeChange(index, proto.ChangeEventExtents, req.Inode, r.Status)
+		resp = r
+	case opFSMShareExtents:
+		req := &proto.ShareExtentsRequest{}
+		if err = json.Unmarshal(msg.V, req); err != nil {
+			return
+		}
+		r := mp.fsmShareExtents(req)
+		mp.recordInodeChange(index, proto.ChangeEventInodeCreate, req.NewInode, r.Status)
+		resp = r
 	case opFSMCreateMultipart:
 		var multipart *Multipart
 		multipart = MultipartFromBytes(msg.V)
@@ -348,7 +458,9 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 		if mp.config.Cursor < txIno.Inode.Inode {
 			mp.config.Cursor = txIno.Inode.Inode
 		}
-		resp = mp.fsmTxCreateInode(txIno, []uint32{})
+		status := mp.fsmTxCreateInode(txIno, []uint32{})
+		mp.recordInodeChange(index, proto.ChangeEventInodeCreate, txIno.Inode.Inode, status)
+		resp = status
 	case opFSMTxCreateInodeQuota:
 		qinode := &TxMetaQuotaInode{}
 		if err = qinode.Unmarshal(msg.V); err != nil {
@@ -361,13 +473,17 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 		if len(qinode.quotaIds) > 0 {
 			mp.setInodeQuota(qinode.quotaIds, txIno.Inode.Inode)
 		}
-		resp = mp.fsmTxCreateInode(txIno, qinode.quotaIds)
+		status := mp.fsmTxCreateInode(txIno, qinode.quotaIds)
+		mp.recordInodeChange(index, proto.ChangeEventInodeCreate, txIno.Inode.Inode, status)
+		resp = status
 	case opFSMTxCreateDentry:
 		txDen := NewTxDentry(0, "", 0, 0, nil, nil)
 		if err = txDen.Unmarshal(msg.V); err != nil {
 			return
 		}
-		resp = mp.fsmTxCreateDentry(txDen)
+		status := mp.fsmTxCreateDentry(txDen)
+		mp.recordDentryChange(index, proto.ChangeEventCreate, txDen.Dentry, status)
+		resp = status
 	case opFSMTxSetState:
 		req := &proto.TxSetStateRequest{}
 		if err = json.Unmarshal(msg.V, req); err != nil {
@@ -385,7 +501,7 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 		if err = req.Unmarshal(msg.V); err != nil {
 			return
 		}
-		resp = mp.fsmTxRollbackRM(req)
+		resp = mp.fsmTxRollbackRM(req, index)
 	case opFSMTxCommit:
 		req := &proto.TxApplyRequest{}
 		if err = json.Unmarshal(msg.V, req); err != nil {
@@ -409,26 +525,35 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 		if err = txDen.Unmarshal(msg.V); err != nil {
 			return
 		}
-		resp = mp.fsmTxDeleteDentry(txDen)
+		r := mp.fsmTxDeleteDentry(txDen)
+		mp.recordDentryChange(index, proto.ChangeEventUnlink, r.Msg, r.Status)
+		resp = r
 	case opFSMTxUnlinkInode:
 		txIno := NewTxInode(0, 0, nil)
 		if err = txIno.Unmarshal(msg.V); err != nil {
 			return
 		}
-		resp = mp.fsmTxUnlinkInode(txIno)
+		r := mp.fsmTxUnlinkInode(txIno)
+		mp.recordInodeChange(index, proto.ChangeEventInodeUnlink, txIno.Inode.Inode, r.Status)
+		resp = r
 	case opFSMTxUpdateDentry:
 		// txDen := NewTxDentry(0, "", 0, 0, nil)
 		txUpdateDen := NewTxUpdateDentry(nil, nil, nil)
 		if err = txUpdateDen.Unmarshal(msg.V); err != nil {
 			return
 		}
-		resp = mp.fsmTxUpdateDentry(txUpdateDen)
+		newIno := txUpdateDen.NewDentry.Inode
+		r := mp.fsmTxUpdateDentry(txUpdateDen)
+		mp.recordRenameChange(index, txUpdateDen.OldDentry, newIno, r)
+		resp = r
 	case opFSMTxCreateLinkInode:
 		txIno := NewTxInode(0, 0, nil)
 		if err = txIno.Unmarshal(msg.V); err != nil {
 			return
 		}
-		resp = mp.fsmTxCreateLinkInode(txIno)
+		r := mp.fsmTxCreateLinkInode(txIno)
+		mp.recordInodeChange(index, proto.ChangeEventInodeLink, txIno.Inode.Inode, r.Status)
+		resp = r
 	case opFSMSetInodeQuotaBatch:
 		req := &proto.BatchSetMetaserverQuotaReuqest{}
 		if err = json.Unmarshal(msg.V, req); err != nil {
@@ -464,7 +589,9 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 				mp.config.PartitionId, err.Error())
 			return
 		}
-		resp = mp.fsmUpdateExtentKeyAfterMigration(ino)
+		r := mp.fsmUpdateExtentKeyAfterMigration(ino)
+		mp.recordInodeChange(index, proto.ChangeEventExtents, ino.Inode, r.Status)
+		resp = r
 	case opFSMSetInodeCreateTime:
 		req := &SetCreateTimeRequest{}
 		err = json.Unmarshal(msg.V, req)
@@ -695,6 +822,7 @@ func (mp *metaPartition) ApplySnapshot(peers []raftproto.Peer, iter raftproto.Sn
 		txRbDentryTree = NewBtree()
 		uniqChecker    = newUniqChecker()
 		verList        []*proto.VolVersionInfo
+		shared         = &sharedExtents{}
 	)
 
 	blockUntilStoreSnapshot := func() {
@@ -749,10 +877,12 @@ func (mp *metaPartition) ApplySnapshot(peers []raftproto.Peer, iter raftproto.Sn
 			mp.txProcessor.txResource.txRbInodeTree = txRbInodeTree
 			mp.txProcessor.txResource.txRbDentryTree = txRbDentryTree
 			mp.uniqChecker = uniqChecker
+			mp.sharedExtents.replace(shared)
 			mp.multiVersionList.VerList = make([]*proto.VolVersionInfo, len(verList))
 			copy(mp.multiVersionList.VerList, verList)
 			mp.verSeq = mp.multiVersionList.GetLastVer()
 			log.LogInfof("mp[%v] updateVerList (%v) seq [%v]", mp.config.PartitionId, mp.multiVersionList.VerList, mp.verSeq)
+			mp.changeFeed.reset(mp.applyID)
 			err = nil
 			// store message
 			mp.storeChan <- &storeMsg{
@@ -860,6 +990,7 @@ func (mp *metaPartition) ApplySnapshot(peers []raftproto.Peer, iter raftproto.Sn
 				cursor = ino.Inode
 			}
 			inodeTree.ReplaceOrInsert(ino, true)
+			shared.addInode(ino)
 			log.LogDebugf("ApplySnapshot: create inode: partitonID(%v) inode[%v].", mp.config.PartitionId, ino)
 		case opFSMCreateDentry:
 			dentry := &Dentry{}
@@ -368,6 +368,14 @@ func (mp *metaPartition) fsmTxUpdateDentry(txUpDateDentry *TxUpdateDentry) (resp
 
 func (mp *metaPartition) fsmUpdateDentry(dentry *Dentry) (
 	resp *DentryResponse,
+) {
+	return mp.fsmUpdateDentryIf(dentry, 0)
+}
+
+// fsmUpdateDentryIf updates the dentry only if it points to oldIno, the update is
+// unconditional if oldIno is zero.
+func (mp *metaPartition) fsmUpdateDentryIf(dentry *Dentry, oldIno uint64) (
+	resp *DentryResponse,
 ) {
 	resp = NewDentryResponse()
 	resp.Status = proto.OpOk
@@ -377,6 +385,10 @@ func (mp *metaPartition) fsmUpdateDentry(dentry *Dentry) (
 			return
 		}
 		d := item.(*Dentry)
+		if oldIno != 0 && d.Inode != oldIno {
+			resp.Status = proto.OpExistErr
+			return
+		}
 		if dentry.Inode == d.Inode {
 			return
 		}
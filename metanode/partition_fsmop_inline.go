@@ -0,0 +1,72 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+func (mp *metaPartition) fsmWriteInline(req *proto.WriteInlineRequest) (resp *proto.WriteInlineResponse) {
+	resp = &proto.WriteInlineResponse{Status: proto.OpOk}
+	item := mp.inodeTree.CopyGet(NewInode(req.Inode, 0))
+	if item == nil {
+		resp.Status = proto.OpNotExistErr
+		return
+	}
+	ino := item.(*Inode)
+	if ino.ShouldDelete() {
+		resp.Status = proto.OpNotExistErr
+		return
+	}
+	if !proto.IsRegular(ino.Type) {
+		resp.Status = proto.OpArgMismatchErr
+		return
+	}
+
+	ino.Lock()
+	defer ino.Unlock()
+	// the file has been promoted to extents by another client
+	if !ino.EmptyHybridExtents() {
+		log.LogDebugf("fsmWriteInline: mp(%v) ino(%v) has extents", mp.config.PartitionId, req.Inode)
+		resp.Status = proto.OpConflictExtentsErr
+		return
+	}
+
+	end := req.Offset + uint64(len(req.Data))
+	size := uint64(len(ino.InlineData))
+	if size < end {
+		size = end
+	}
+	// never modify the inline data in place, the snapshot may be marshaling it
+	data := make([]byte, size)
+	copy(data, ino.InlineData)
+	copy(data[req.Offset:], req.Data)
+	ino.InlineData = data
+
+	oldSize := ino.Size
+	if ino.Size < end {
+		ino.Size = end
+	}
+	ino.ModifyTime = req.SubmitTime.Unix()
+	ino.Generation++
+	mp.uqMgr.updateSize(ino, oldSize)
+
+	resp.Size = ino.Size
+	resp.Generation = ino.Generation
+	log.LogDebugf("fsmWriteInline: mp(%v) ino(%v) offset(%v) len(%v) size(%v) gen(%v)",
+		mp.config.PartitionId, req.Inode, req.Offset, len(req.Data), resp.Size, resp.Generation)
+	return
+}
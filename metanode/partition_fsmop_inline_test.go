@@ -0,0 +1,215 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInlineInodeMarshal(t *testing.T) {
+	ino := NewInode(1024, uint32(os.ModePerm))
+	ino.StorageClass = proto.StorageClass_Replica_SSD
+	ino.Size = 10
+	ino.InlineData = []byte("hello")
+	// the inline data is kept before the migration extents
+	ino.HybridCloudExtentsMigration.storageClass = proto.StorageClass_Replica_HDD
+
+	data, err := ino.Marshal()
+	require.NoError(t, err)
+	target := NewInode(0, 0)
+	require.NoError(t, target.Unmarshal(data))
+	require.Equal(t, ino.InlineData, target.InlineData)
+	require.Equal(t, ino.Size, target.Size)
+	require.NotZero(t, target.Reserved&V5InlineDataFlag)
+	require.Equal(t, ino.HybridCloudExtentsMigration.storageClass, target.HybridCloudExtentsMigration.storageClass)
+
+	require.Equal(t, ino.InlineData, ino.Copy().(*Inode).InlineData)
+	require.Equal(t, ino.InlineData, ino.CopyDirectly().(*Inode).InlineData)
+	require.Equal(t, uint64(5), ino.GetSpaceSize())
+}
+
+func TestFsmWriteInline(t *testing.T) {
+	mp := NewMetaPartitionForTest()
+	mp.uidManager = NewUidMgr(VolNameForTest, PartitionIdForTest)
+	ino := NewInode(100, uint32(os.ModePerm))
+	ino.StorageClass = proto.StorageClass_Replica_SSD
+	mp.inodeTree.ReplaceOrInsert(ino, true)
+	write := func(offset uint64, data string) *proto.WriteInlineResponse {
+		return mp.fsmWriteInline(&proto.WriteInlineRequest{Inode: 100, Offset: offset, Data: []byte(data), SubmitTime: time.Now()})
+	}
+
+	resp := write(0, "hello")
+	require.Equal(t, proto.OpOk, resp.Status)
+	require.Equal(t, uint64(5), resp.Size)
+	gen := resp.Generation
+
+	// the gap before the offset is filled with zeros
+	resp = write(8, "world")
+	require.Equal(t, proto.OpOk, resp.Status)
+	require.Equal(t, uint64(13), resp.Size)
+	require.Greater(t, resp.Generation, gen)
+	require.Equal(t, []byte("hello\x00\x00\x00world"), ino.InlineData)
+
+	p := &Packet{}
+	require.NoError(t, mp.ReadInline(&proto.ReadInlineRequest{Inode: 100, Offset: 3, Size: 4}, p))
+	require.Equal(t, proto.OpOk, p.ResultCode)
+	readResp := &proto.ReadInlineResponse{}
+	require.NoError(t, json.Unmarshal(p.Data, readResp))
+	require.Equal(t, []byte("lo\x00\x00"), readResp.Data)
+	require.Equal(t, uint64(13), readResp.Size)
+	require.Equal(t, ino.Generation, readResp.Generation)
+
+	// the data beyond the inline data reads zeros after the file is extended by truncate
+	ino.ExtentsTruncate(20, time.Now().Unix(), nil)
+	require.Equal(t, uint64(20), ino.Size)
+	require.Len(t, ino.InlineData, 13)
+	ino.ExtentsTruncate(4, time.Now().Unix(), nil)
+	require.Equal(t, uint64(4), ino.Size)
+	require.Equal(t, []byte("hell"), ino.InlineData)
+
+	// the inline data is dropped when the promoted extents are appended
+	param := NewInode(100, 0)
+	param.HybridCloudExtents.sortedEks = NewSortedExtentsFromEks([]proto.ExtentKey{{
+		FileOffset: 0, PartitionId: 1, ExtentId: 1025, Size: 4,
+	}})
+	require.Equal(t, proto.OpOk, mp.fsmAppendExtents(param))
+	require.Empty(t, ino.InlineData)
+	require.Equal(t, uint64(4), ino.Size)
+	require.Equal(t, proto.OpConflictExtentsErr, write(0, "again").Status)
+
+	dir := NewInode(200, uint32(os.ModeDir))
+	mp.inodeTree.ReplaceOrInsert(dir, true)
+	require.Equal(t, proto.OpArgMismatchErr, mp.fsmWriteInline(&proto.WriteInlineRequest{Inode: 200, Data: []byte("x")}).Status)
+	require.Equal(t, proto.OpNotExistErr, mp.fsmWriteInline(&proto.WriteInlineRequest{Inode: 300, Data: []byte("x")}).Status)
+
+	// the inline data of the deleted inode can't be read
+	ino.SetDeleteMark()
+	p = &Packet{}
+	require.NoError(t, mp.ReadInline(&proto.ReadInlineRequest{Inode: 100, Size: 4}, p))
+	require.Equal(t, proto.OpNotExistErr, p.ResultCode)
+}
+
+func TestWriteInlineThreshold(t *testing.T) {
+	mp := NewMetaPartitionForTest()
+	req := &proto.WriteInlineRequest{Inode: 100, Data: []byte("hello")}
+
+	// inline data is disabled by default
+	p := &Packet{}
+	require.NoError(t, mp.WriteInline(req, p))
+	require.Equal(t, proto.OpConflictExtentsErr, p.ResultCode)
+
+	mp.UpdateVolumeView(&proto.DataPartitionsView{}, &proto.SimpleVolView{InlineDataThreshold: 4})
+	p = &Packet{}
+	require.NoError(t, mp.WriteInline(req, p))
+	require.Equal(t, proto.OpConflictExtentsErr, p.ResultCode)
+}
+
+func TestWriteInlineAndPromote(t *testing.T) {
+	newMpWithMock(t)
+	mp.UpdateVolumeView(&proto.DataPartitionsView{}, &proto.SimpleVolView{InlineDataThreshold: 16})
+	file := testCreateInode(t, FileModeType)
+
+	p := &Packet{}
+	require.NoError(t, mp.WriteInline(&proto.WriteInlineRequest{Inode: file.Inode, Data: []byte("hello world")}, p))
+	require.Equal(t, proto.OpOk, p.ResultCode)
+	writeResp := &proto.WriteInlineResponse{}
+	require.NoError(t, json.Unmarshal(p.Data, writeResp))
+	require.Equal(t, uint64(11), writeResp.Size)
+
+	readInline := func() *proto.ReadInlineResponse {
+		p := &Packet{}
+		require.NoError(t, mp.ReadInline(&proto.ReadInlineRequest{Inode: file.Inode, Size: proto.MaxInlineDataThreshold}, p))
+		require.Equal(t, proto.OpOk, p.ResultCode)
+		resp := &proto.ReadInlineResponse{}
+		require.NoError(t, json.Unmarshal(p.Data, resp))
+		return resp
+	}
+	listExtents := func() *proto.GetExtentsResponse {
+		p := &Packet{}
+		require.NoError(t, mp.ExtentsList(&proto.GetExtentsRequest{Inode: file.Inode}, p))
+		require.Equal(t, proto.OpOk, p.ResultCode)
+		resp := &proto.GetExtentsResponse{}
+		require.NoError(t, json.Unmarshal(p.Data, resp))
+		return resp
+	}
+	require.Equal(t, []byte("hello world"), readInline().Data)
+	extents := listExtents()
+	require.True(t, extents.Inline)
+	require.Empty(t, extents.Extents)
+	require.Equal(t, uint64(11), extents.Size)
+
+	// the write beyond the threshold goes to the extents
+	p = &Packet{}
+	require.NoError(t, mp.WriteInline(&proto.WriteInlineRequest{Inode: file.Inode, Offset: 11, Data: []byte("0123456789")}, p))
+	require.Equal(t, proto.OpConflictExtentsErr, p.ResultCode)
+
+	// truncate within the inline data
+	p = &Packet{}
+	require.NoError(t, mp.ExtentsTruncate(&ExtentsTruncateReq{Inode: file.Inode, Size: 5}, p, localAddrForAudit))
+	require.Equal(t, proto.OpOk, p.ResultCode)
+	resp := readInline()
+	require.Equal(t, []byte("hello"), resp.Data)
+	require.Equal(t, uint64(5), resp.Size)
+
+	// the inline data is kept by the snapshot of the inode
+	ino := mp.inodeTree.Get(NewInode(file.Inode, 0)).(*Inode)
+	data, err := ino.Marshal()
+	require.NoError(t, err)
+	target := NewInode(0, 0)
+	require.NoError(t, target.Unmarshal(data))
+	require.NotZero(t, target.Reserved&V5InlineDataFlag)
+	require.Equal(t, []byte("hello"), target.InlineData)
+	require.Equal(t, uint64(5), target.Size)
+
+	// the promotion fails if the inline data has been changed since it was read
+	gen := resp.Generation
+	p = &Packet{}
+	require.NoError(t, mp.WriteInline(&proto.WriteInlineRequest{Inode: file.Inode, Offset: 5, Data: []byte("!")}, p))
+	require.Equal(t, proto.OpOk, p.ResultCode)
+	p = &Packet{}
+	require.NoError(t, mp.ExtentAppendWithCheck(&proto.AppendExtentKeyWithCheckRequest{
+		Inode:            file.Inode,
+		Extent:           proto.ExtentKey{FileOffset: 0, PartitionId: 1, ExtentId: 1025, Size: 20},
+		InlineGeneration: gen,
+	}, p, localAddrForAudit))
+	require.Equal(t, proto.OpConflictExtentsErr, p.ResultCode)
+	resp = readInline()
+	require.Equal(t, []byte("hello!"), resp.Data)
+	require.Greater(t, resp.Generation, gen)
+
+	// the file grows beyond the threshold, the inline data is promoted with the appended extent
+	p = &Packet{}
+	require.NoError(t, mp.ExtentAppendWithCheck(&proto.AppendExtentKeyWithCheckRequest{
+		Inode:            file.Inode,
+		Extent:           proto.ExtentKey{FileOffset: 0, PartitionId: 1, ExtentId: 1025, Size: 20},
+		InlineGeneration: resp.Generation,
+	}, p, localAddrForAudit))
+	require.Equal(t, proto.OpOk, p.ResultCode)
+	require.Empty(t, readInline().Data)
+	extents = listExtents()
+	require.False(t, extents.Inline)
+	require.Len(t, extents.Extents, 1)
+	require.Equal(t, uint64(20), extents.Size)
+
+	p = &Packet{}
+	require.NoError(t, mp.WriteInline(&proto.WriteInlineRequest{Inode: file.Inode, Data: []byte("again")}, p))
+	require.Equal(t, proto.OpConflictExtentsErr, p.ResultCode)
+}
@@ -83,7 +83,9 @@ func (mp *metaPartition) fsmCreateInode(ino *Inode) (status uint8) {
 	status = proto.OpOk
 	if _, ok := mp.inodeTree.ReplaceOrInsert(ino, false); !ok {
 		status = proto.OpExistErr
+		return
 	}
+	mp.uqMgr.addInode(ino)
 
 	return
 }
@@ -459,7 +461,13 @@ func (mp *metaPartition) internalDeleteBatch(val []byte) error {
 
 func (mp *metaPartition) internalDeleteInode(ino *Inode) {
 	log.LogDebugf("action[internalDeleteInode] vol(%v) mp(%v) ino[%v] really be deleted", mp.config.VolName, mp.config.PartitionId, ino)
-	mp.inodeTree.Delete(ino)
+	if item := mp.inodeTree.Delete(ino); item != nil {
+		inode := item.(*Inode)
+		mp.uqMgr.removeInode(inode)
+		if inode.HasSharedExtents() {
+			mp.freeSharedExtents(inode)
+		}
+	}
 	mp.freeList.Remove(ino.Inode)
 	mp.extendTree.Delete(&Extend{inode: ino.Inode}) // Also delete extend attribute.
 }
@@ -485,10 +493,60 @@ func (mp *metaPartition) fsmAppendExtents(ino *Inode) (status uint8) {
 	if status = mp.uidManager.addUidSpace(ino2.Uid, ino2.Inode, eks); status != proto.OpOk {
 		return
 	}
+	oldSize := ino2.Size
 	delExtents := ino2.AppendExtents(eks, ino.ModifyTime, mp.volType)
+	mp.uqMgr.updateSize(ino2, oldSize)
+	// the extent keys of the parts sharing extents are appended to the completed object
+	if ino2.HasSharedExtents() || mp.sharedExtents.anyShared(eks) {
+		ino2.SetSharedExtents()
+		mp.sharedExtents.addInode(ino2)
+	}
 	log.LogInfof("fsmAppendExtents mpId[%v].inode[%v] DecSplitExts deleteExtents(%v)", mp.config.PartitionId, ino2.Inode, delExtents)
 	ino2.DecSplitExts(mp.config.PartitionId, delExtents)
-	mp.extDelCh <- delExtents
+	mp.extDelCh <- mp.releaseSharedExtents(delExtents)
+	return
+}
+
+// fsmAppendExtentsIf appends the extents and sets the extended attributes of the inode in one step,
+// only if the size and generation of the inode are still the expected ones.
+func (mp *metaPartition) fsmAppendExtentsIf(req *proto.AppendExtentKeysIfRequest, extend *Extend) (status uint8) {
+	item := mp.inodeTree.CopyGet(NewInode(req.Inode, 0))
+	if item == nil {
+		status = proto.OpNotExistErr
+		return
+	}
+	ino := item.(*Inode)
+	if ino.ShouldDelete() {
+		status = proto.OpNotExistErr
+		return
+	}
+	if ino.Size != req.Size || ino.Generation != req.Generation {
+		log.LogWarnf("fsmAppendExtentsIf: mp(%v) ino(%v) changed, size(%v) gen(%v), expect size(%v) gen(%v)",
+			mp.config.PartitionId, req.Inode, ino.Size, ino.Generation, req.Size, req.Generation)
+		status = proto.OpConflictExtentsErr
+		return
+	}
+
+	if len(req.Extents) > 0 {
+		param := NewInode(req.Inode, 0)
+		param.ModifyTime = req.ModifyTime
+		extents := NewSortedExtents()
+		for _, ek := range req.Extents {
+			extents.Append(ek)
+		}
+		param.HybridCloudExtents.sortedEks = extents
+		if status = mp.fsmAppendExtents(param); status != proto.OpOk {
+			return
+		}
+	}
+	if len(req.Attrs) > 0 {
+		if err := mp.fsmSetXAttr(extend); err != nil {
+			log.LogErrorf("fsmAppendExtentsIf: mp(%v) ino(%v) set xattr err(%v)", mp.config.PartitionId, req.Inode, err)
+			status = proto.OpErr
+			return
+		}
+	}
+	status = proto.OpOk
 	return
 }
 
@@ -518,6 +576,15 @@ func (mp *metaPartition) fsmAppendExtentsWithCheck(ino *Inode, isSplit bool) (st
 		status = proto.OpNotExistErr
 		return
 	}
+	// the extent written from the inline data is appended only if the inode has not been changed since
+	// the inline data was read, otherwise the inline data written meanwhile would be dropped. The inline
+	// data is written at least once, so the checked generation is always larger than the initial one.
+	if ino.Generation > 1 && fsmIno.Generation != ino.Generation {
+		log.LogWarnf("fsmAppendExtentsWithCheck: inode changed since inline data read, mp %d, ino %d, gen %d, expect %d",
+			mp.config.PartitionId, ino.Inode, fsmIno.Generation, ino.Generation)
+		status = proto.OpConflictExtentsErr
+		return
+	}
 
 	// get eks from inoParm, so do not need transform from HybridCloudExtents
 	var (
@@ -570,12 +637,17 @@ func (mp *metaPartition) fsmAppendExtentsWithCheck(ino *Inode, isSplit bool) (st
 		isMigration:      isMigration,
 	}
 
+	oldSize := fsmIno.Size
+	defer mp.uqMgr.updateSize(fsmIno, oldSize)
 	if !isSplit {
 		delExtents, status = fsmIno.AppendExtentWithCheck(appendExtParam)
+		if status == proto.OpOk && fsmIno.HasSharedExtents() {
+			mp.sharedExtents.share(eks[:1], fsmIno.Inode)
+		}
 		if status == proto.OpOk {
 			log.LogInfof("action[fsmAppendExtentsWithCheck] mp[%v] DecSplitExts delExtents [%v]", mp.config.PartitionId, delExtents)
 			fsmIno.DecSplitExts(appendExtParam.mpId, delExtents)
-			mp.extDelCh <- delExtents
+			mp.extDelCh <- mp.releaseSharedExtents(delExtents)
 		}
 		// conflict need delete eks[0], to clear garbage data
 		if status == proto.OpConflictExtentsErr {
@@ -583,7 +655,7 @@ func (mp *metaPartition) fsmAppendExtentsWithCheck(ino *Inode, isSplit bool) (st
 			if !storage.IsTinyExtent(eks[0].ExtentId) && eks[0].ExtentOffset >= util.ExtentSize && clusterEnableSnapshot {
 				eks[0].SetSplit(true)
 			}
-			mp.extDelCh <- eks[:1]
+			mp.extDelCh <- mp.releaseSharedExtents(eks[:1])
 		}
 	} else {
 		if !clusterEnableSnapshot {
@@ -597,12 +669,12 @@ func (mp *metaPartition) fsmAppendExtentsWithCheck(ino *Inode, isSplit bool) (st
 		delExtents, status = fsmIno.SplitExtentWithCheck(appendExtParam)
 		log.LogInfof("action[fsmAppendExtentsWithCheck] mp[%v] DecSplitExts delExtents [%v]", mp.config.PartitionId, delExtents)
 		fsmIno.DecSplitExts(mp.config.PartitionId, delExtents)
-		mp.extDelCh <- delExtents
+		mp.extDelCh <- mp.releaseSharedExtents(delExtents)
 	}
 
 	// conflict need delete eks[0], to clear garbage data
 	if status == proto.OpConflictExtentsErr {
-		mp.extDelCh <- eks[:1]
+		mp.extDelCh <- mp.releaseSharedExtents(eks[:1])
 		log.LogWarnf("fsmAppendExtentsWithCheck mp[%v] delExtents inode[%v] ek(%v)", mp.config.PartitionId, fsmIno.Inode, delExtents)
 	}
 
@@ -638,7 +710,9 @@ func (mp *metaPartition) fsmAppendObjExtents(ino *Inode) (status uint8) {
 	}
 	// eks := ino.ObjExtents.CopyExtents()
 	eks := ino.HybridCloudExtents.sortedEks.(*SortedObjExtents).CopyExtents()
+	oldSize := inode.Size
 	err := inode.AppendObjExtents(eks, ino.ModifyTime)
+	mp.uqMgr.updateSize(inode, oldSize)
 	// if err is not nil, means obj eks exist overlap.
 	if err != nil {
 		log.LogErrorf("fsmAppendExtents inode[%v] err(%v)", inode.Inode, err)
@@ -696,7 +770,9 @@ func (mp *metaPartition) fsmExtentsTruncate(ino *Inode) (resp *InodeResponse) {
 		return
 	}
 
+	oldSize := i.Size
 	delExtents := i.ExtentsTruncate(ino.Size, ino.ModifyTime, insertSplitKey)
+	mp.uqMgr.updateSize(i, oldSize)
 	if len(delExtents) == 0 {
 		return
 	}
@@ -707,7 +783,7 @@ func (mp *metaPartition) fsmExtentsTruncate(ino *Inode) (resp *InodeResponse) {
 	// now we should delete the extent
 	log.LogInfof("fsmExtentsTruncate.mp (%v) inode[%v] DecSplitExts exts(%v)", mp.config.PartitionId, i.Inode, delExtents)
 	i.DecSplitExts(mp.config.PartitionId, delExtents)
-	mp.extDelCh <- delExtents
+	mp.extDelCh <- mp.releaseSharedExtents(delExtents)
 	return
 }
 
@@ -779,7 +855,9 @@ func (mp *metaPartition) fsmSetAttr(req *SetattrRequest) (err error) {
 	if ino.ShouldDelete() {
 		return
 	}
+	oldUid, oldGid := ino.Uid, ino.Gid
 	ino.SetAttr(req)
+	mp.uqMgr.changeOwner(ino, oldUid, oldGid)
 	return
 }
 
@@ -1202,7 +1280,7 @@ func (mp *metaPartition) fsmSetMigrationExtentKeyDeleteImmediately(inoParam *Ino
 				mp.config.PartitionId, i.Inode, proto.StorageClassString(i.StorageClass))
 		} else {
 			migrateExtents := i.HybridCloudExtentsMigration.sortedEks.(*SortedExtents)
-			mp.extDelCh <- migrateExtents.CopyExtents()
+			mp.extDelCh <- mp.releaseSharedExtents(migrateExtents.CopyExtents())
 			log.LogInfof("[fsmSetMigrationExtentKeyDeleteImmediately] mpId(%v) inode(%v) storageClass(%v) migration SortedExtents pushed into extDelCh",
 				mp.config.PartitionId, i.Inode, proto.StorageClassString(i.StorageClass))
 		}
@@ -0,0 +1,194 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"math"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// the posix locks and flocks of an inode are kept in the extend of the inode, so they are
+// replicated by raft and persisted by the snapshots with the xattrs.
+const innerPosixLockKey = "cfs_inner_xattr_posix_lock_key"
+
+func unmarshalPosixLocks(extend *Extend) (locks []*proto.PosixLock) {
+	if extend == nil {
+		return
+	}
+	value, exist := extend.Get([]byte(innerPosixLockKey))
+	if !exist {
+		return
+	}
+	if err := json.Unmarshal(value, &locks); err != nil {
+		log.LogErrorf("unmarshalPosixLocks: ino(%v) value(%s) err(%v)", extend.GetInode(), value, err)
+		return nil
+	}
+	return
+}
+
+// livePosixLocks filters out the locks which are not renewed by their clients before now.
+func livePosixLocks(locks []*proto.PosixLock, now int64) (lives []*proto.PosixLock) {
+	for _, lk := range locks {
+		if lk.Expire > now {
+			lives = append(lives, lk)
+		}
+	}
+	return
+}
+
+func findConflictPosixLock(locks []*proto.PosixLock, lk *proto.PosixLock) *proto.PosixLock {
+	for _, held := range locks {
+		if lk.Conflicts(held) {
+			return held
+		}
+	}
+	return nil
+}
+
+// setPosixLock replaces the locks of the same owner in the range of lk with lk, the locks partly
+// in the range are split, and the adjacent locks of the same type are merged like posix does.
+// The lock of type PosixLockUnlock just releases the range.
+func setPosixLock(locks []*proto.PosixLock, lk *proto.PosixLock) (result []*proto.PosixLock) {
+	newLk := *lk
+	for _, held := range locks {
+		if !held.SameOwner(lk) || held.Flock != lk.Flock {
+			result = append(result, held)
+			continue
+		}
+		if held.Flock {
+			continue
+		}
+		if newLk.Type != proto.PosixLockUnlock && held.Type == newLk.Type && posixLockAdjacent(held, &newLk) {
+			if held.Start < newLk.Start {
+				newLk.Start = held.Start
+			}
+			if held.End > newLk.End {
+				newLk.End = held.End
+			}
+			continue
+		}
+		if !held.Overlaps(lk) {
+			result = append(result, held)
+			continue
+		}
+		if held.Start < lk.Start {
+			left := *held
+			left.End = lk.Start - 1
+			result = append(result, &left)
+		}
+		if held.End > lk.End {
+			right := *held
+			right.Start = lk.End + 1
+			result = append(result, &right)
+		}
+	}
+	if newLk.Type != proto.PosixLockUnlock {
+		result = append(result, &newLk)
+	}
+	return
+}
+
+func posixLockAdjacent(a, b *proto.PosixLock) bool {
+	if a.Overlaps(b) {
+		return true
+	}
+	return (a.End != math.MaxUint64 && a.End+1 == b.Start) || (b.End != math.MaxUint64 && b.End+1 == a.Start)
+}
+
+func (mp *metaPartition) storePosixLocks(ino uint64, extend *Extend, locks []*proto.PosixLock) (err error) {
+	if len(locks) == 0 {
+		if extend != nil {
+			extend.Remove([]byte(innerPosixLockKey))
+		}
+		return
+	}
+	var value []byte
+	if value, err = json.Marshal(locks); err != nil {
+		return
+	}
+	if extend == nil {
+		extend = NewExtend(ino)
+		extend.Put([]byte(innerPosixLockKey), value, 0)
+		mp.extendTree.ReplaceOrInsert(extend, true)
+		return
+	}
+	extend.Put([]byte(innerPosixLockKey), value, 0)
+	return
+}
+
+func (mp *metaPartition) getInodeExtend(ino uint64) *Extend {
+	if item := mp.extendTree.CopyGet(NewExtend(ino)); item != nil {
+		return item.(*Extend)
+	}
+	return nil
+}
+
+func (mp *metaPartition) fsmSetLock(req *proto.SetLockRequest) (resp *proto.SetLockResponse) {
+	mp.xattrLock.Lock()
+	defer mp.xattrLock.Unlock()
+
+	resp = &proto.SetLockResponse{Status: proto.OpOk}
+	now := req.SubmitTime.Unix()
+	extend := mp.getInodeExtend(req.Inode)
+	locks := livePosixLocks(unmarshalPosixLocks(extend), now)
+
+	lk := req.Lock
+	if lk.Type != proto.PosixLockUnlock {
+		if conflict := findConflictPosixLock(locks, &lk); conflict != nil {
+			log.LogDebugf("fsmSetLock: mp(%v) ino(%v) lock(%v) conflicts with %v", mp.config.PartitionId, req.Inode, &lk, conflict)
+			resp.Status = proto.OpExistErr
+			resp.Conflict = conflict
+			return
+		}
+	}
+	lk.Expire = now + int64(req.Lease)
+
+	if err := mp.storePosixLocks(req.Inode, extend, setPosixLock(locks, &lk)); err != nil {
+		log.LogErrorf("fsmSetLock: mp(%v) ino(%v) lock(%v) err(%v)", mp.config.PartitionId, req.Inode, &lk, err)
+		resp.Status = proto.OpErr
+		return
+	}
+	log.LogDebugf("fsmSetLock: mp(%v) ino(%v) lock(%v) success", mp.config.PartitionId, req.Inode, &lk)
+	return
+}
+
+func (mp *metaPartition) fsmRenewLock(req *proto.RenewLockRequest) (resp *proto.RenewLockResponse) {
+	mp.xattrLock.Lock()
+	defer mp.xattrLock.Unlock()
+
+	resp = &proto.RenewLockResponse{}
+	now := req.SubmitTime.Unix()
+	for _, ino := range req.Inodes {
+		extend := mp.getInodeExtend(ino)
+		locks := livePosixLocks(unmarshalPosixLocks(extend), now)
+		var locked bool
+		for _, lk := range locks {
+			if lk.ClientId == req.ClientId {
+				lk.Expire = now + int64(req.Lease)
+				locked = true
+			}
+		}
+		if locked {
+			resp.Inodes = append(resp.Inodes, ino)
+		}
+		if err := mp.storePosixLocks(ino, extend, locks); err != nil {
+			log.LogErrorf("fsmRenewLock: mp(%v) ino(%v) client(%v) err(%v)", mp.config.PartitionId, ino, req.ClientId, err)
+		}
+	}
+	return
+}
@@ -0,0 +1,103 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetPosixLock(t *testing.T) {
+	owner := proto.PosixLock{ClientId: 1, Owner: 10}
+	lock := func(typ uint8, start, end uint64) *proto.PosixLock {
+		lk := owner
+		lk.Type, lk.Start, lk.End = typ, start, end
+		return &lk
+	}
+	ranges := func(locks []*proto.PosixLock) (result [][3]uint64) {
+		for _, lk := range locks {
+			result = append(result, [3]uint64{uint64(lk.Type), lk.Start, lk.End})
+		}
+		return
+	}
+
+	var locks []*proto.PosixLock
+	locks = setPosixLock(locks, lock(proto.PosixLockWrite, 0, 99))
+	// split by the read lock in the middle
+	locks = setPosixLock(locks, lock(proto.PosixLockRead, 10, 19))
+	require.Equal(t, [][3]uint64{
+		{uint64(proto.PosixLockWrite), 0, 9},
+		{uint64(proto.PosixLockWrite), 20, 99},
+		{uint64(proto.PosixLockRead), 10, 19},
+	}, ranges(locks))
+
+	// merged with the adjacent locks of the same type
+	locks = setPosixLock(locks, lock(proto.PosixLockWrite, 10, 19))
+	require.Equal(t, [][3]uint64{{uint64(proto.PosixLockWrite), 0, 99}}, ranges(locks))
+
+	// unlock to the end of file
+	locks = setPosixLock(locks, lock(proto.PosixLockUnlock, 50, math.MaxUint64))
+	require.Equal(t, [][3]uint64{{uint64(proto.PosixLockWrite), 0, 49}}, ranges(locks))
+
+	// the locks of the other owners and the flocks are kept
+	other := &proto.PosixLock{Type: proto.PosixLockRead, ClientId: 2, Owner: 10, Start: 60, End: 70}
+	flock := lock(proto.PosixLockWrite, 0, math.MaxUint64)
+	flock.Flock = true
+	locks = setPosixLock(append(locks, other, flock), lock(proto.PosixLockUnlock, 0, math.MaxUint64))
+	require.Equal(t, []*proto.PosixLock{other, flock}, locks)
+}
+
+func TestFsmSetLock(t *testing.T) {
+	mp := NewMetaPartitionForTest()
+	now := time.Now()
+	setLock := func(lk proto.PosixLock, submit time.Time) *proto.SetLockResponse {
+		return mp.fsmSetLock(&proto.SetLockRequest{Inode: 100, Lock: lk, Lease: 10, SubmitTime: submit})
+	}
+	lkA := proto.PosixLock{Type: proto.PosixLockWrite, ClientId: 1, Owner: 1, Start: 0, End: 99}
+	lkB := proto.PosixLock{Type: proto.PosixLockRead, ClientId: 2, Owner: 1, Start: 50, End: 149}
+
+	require.Equal(t, proto.OpOk, setLock(lkA, now).Status)
+	resp := setLock(lkB, now)
+	require.Equal(t, proto.OpExistErr, resp.Status)
+	require.True(t, resp.Conflict.SameOwner(&lkA))
+
+	// flocks never conflict with the byte-range locks
+	flock := lkB
+	flock.Flock = true
+	require.Equal(t, proto.OpOk, setLock(flock, now).Status)
+	flock.ClientId = 3
+	flock.Type = proto.PosixLockWrite
+	require.Equal(t, proto.OpExistErr, setLock(flock, now).Status)
+
+	// the renewed locks are kept after the lease of the others
+	renew := mp.fsmRenewLock(&proto.RenewLockRequest{ClientId: 1, Inodes: []uint64{100, 200}, Lease: 10, SubmitTime: now.Add(5 * time.Second)})
+	require.Equal(t, []uint64{100}, renew.Inodes)
+	require.Equal(t, proto.OpOk, setLock(flock, now.Add(11*time.Second)).Status)
+	require.Equal(t, proto.OpExistErr, setLock(lkB, now.Add(11*time.Second)).Status)
+
+	// the locks expire after the client stops renewing them
+	require.Equal(t, proto.OpOk, setLock(lkB, now.Add(16*time.Second)).Status)
+
+	lkB.Type = proto.PosixLockUnlock
+	require.Equal(t, proto.OpOk, setLock(lkB, now.Add(16*time.Second)).Status)
+	flock.Type = proto.PosixLockUnlock
+	require.Equal(t, proto.OpOk, setLock(flock, now.Add(16*time.Second)).Status)
+	_, exist := mp.getInodeExtend(100).Get([]byte(innerPosixLockKey))
+	require.False(t, exist)
+}
@@ -0,0 +1,193 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"github.com/cubefs/cubefs/datanode/storage"
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// fsmShareExtents creates the inode referring to the extents of the source inode in the range, and
+// registers the extents of both inodes.
+func (mp *metaPartition) fsmShareExtents(req *proto.ShareExtentsRequest) (resp *InodeResponse) {
+	resp = NewInodeResponse()
+	item := mp.inodeTree.CopyGet(NewInode(req.Inode, 0))
+	if item == nil {
+		resp.Status = proto.OpNotExistErr
+		return
+	}
+	src := item.(*Inode)
+	if src.ShouldDelete() {
+		resp.Status = proto.OpNotExistErr
+		return
+	}
+	eks, status := mp.sliceExtents(src, req.Offset, req.Size)
+	if status != proto.OpOk {
+		log.LogWarnf("fsmShareExtents: mp(%v) ino(%v) offset(%v) size(%v) can't share extents, status(%v)",
+			mp.config.PartitionId, req.Inode, req.Offset, req.Size, status)
+		resp.Status = status
+		return
+	}
+
+	ino := NewInode(req.NewInode, req.Mode)
+	ino.Uid = req.Uid
+	ino.Gid = req.Gid
+	ino.CreateTime = req.SubmitTime.Unix()
+	ino.AccessTime = ino.CreateTime
+	ino.ModifyTime = ino.CreateTime
+	ino.StorageClass = src.StorageClass
+	extents := NewSortedExtents()
+	for _, ek := range eks {
+		extents.Append(ek)
+	}
+	ino.HybridCloudExtents.sortedEks = extents
+	ino.Size = req.Size
+	ino.Flag |= SharedExtentsFlag
+
+	if resp.Status = mp.uidManager.addUidSpace(ino.Uid, ino.Inode, eks); resp.Status != proto.OpOk {
+		return
+	}
+	if _, ok := mp.inodeTree.ReplaceOrInsert(ino, false); !ok {
+		resp.Status = proto.OpExistErr
+		return
+	}
+	mp.uqMgr.addInode(ino)
+	src.SetSharedExtents()
+	mp.sharedExtents.addInode(src)
+	mp.sharedExtents.addInode(ino)
+	resp.Msg = ino
+	log.LogDebugf("fsmShareExtents: mp(%v) ino(%v) offset(%v) size(%v) newIno(%v) eks(%v)",
+		mp.config.PartitionId, req.Inode, req.Offset, req.Size, ino.Inode, eks)
+	return
+}
+
+// sliceExtents returns the extent keys of the inode in the range [offset, offset+size), the file
+// offsets of which are relative to the range. The range must be fully covered by the normal extents
+// not split by the snapshots, the tiny extents are shared by many files and never be shared again.
+func (mp *metaPartition) sliceExtents(ino *Inode, offset, size uint64) (eks []proto.ExtentKey, status uint8) {
+	ino.RLock()
+	defer ino.RUnlock()
+	if !proto.IsRegular(ino.Type) || size == 0 || offset+size < offset || offset+size > ino.Size {
+		status = proto.OpArgMismatchErr
+		return
+	}
+	if !proto.IsStorageClassReplica(ino.StorageClass) || len(ino.InlineData) > 0 || ino.getLayerLen() > 0 ||
+		mp.verSeq != 0 || (ino.HybridCloudExtentsMigration != nil && !ino.HybridCloudExtentsMigration.Empty()) {
+		status = proto.OpNotPerm
+		return
+	}
+
+	status = proto.OpOk
+	end := offset + size
+	cur := offset
+	ino.GetExtents().Range(func(_ int, ek proto.ExtentKey) bool {
+		ekEnd := ek.FileOffset + uint64(ek.Size)
+		if ekEnd <= cur {
+			return true
+		}
+		if ek.FileOffset > cur || storage.IsTinyExtent(ek.ExtentId) || ek.IsSplit() {
+			status = proto.OpNotPerm
+			return false
+		}
+		stop := ekEnd
+		if stop > end {
+			stop = end
+		}
+		eks = append(eks, proto.ExtentKey{
+			FileOffset:   cur - offset,
+			PartitionId:  ek.PartitionId,
+			ExtentId:     ek.ExtentId,
+			ExtentOffset: ek.ExtentOffset + cur - ek.FileOffset,
+			Size:         uint32(stop - cur),
+		})
+		cur = stop
+		return cur < end
+	})
+	if status == proto.OpOk && cur < end {
+		status = proto.OpNotPerm
+	}
+	return
+}
+
+// isExtentReferred returns if any extent key of the inode refers to the extent.
+func (mp *metaPartition) isExtentReferred(inodeID, id uint64) (ok bool) {
+	item := mp.inodeTree.Get(NewInode(inodeID, 0))
+	if item == nil {
+		return
+	}
+	item.(*Inode).GetExtents().Range(func(_ int, ek proto.ExtentKey) bool {
+		ok = sharedExtentID(&ek) == id
+		return !ok
+	})
+	return
+}
+
+// releaseSharedExtents filters out the extent keys to be deleted whose extents are still referred
+// to by other inodes. It's called after the extent keys are removed from the inode.
+func (mp *metaPartition) releaseSharedExtents(eks []proto.ExtentKey) []proto.ExtentKey {
+	if len(eks) == 0 || mp.sharedExtents.empty() {
+		return eks
+	}
+	inUse := make(map[uint64]bool)
+	delExtents := make([]proto.ExtentKey, 0, len(eks))
+	for _, ek := range eks {
+		id := sharedExtentID(&ek)
+		used, ok := inUse[id]
+		if !ok {
+			_, used = mp.sharedExtents.release(id, mp.isExtentReferred)
+			inUse[id] = used
+		}
+		if !used {
+			delExtents = append(delExtents, ek)
+		}
+	}
+	return delExtents
+}
+
+// freeSharedExtents deletes the shared extents of the inode removed from the partition, which are
+// skipped when the extents of the inode are freed, if no other inode refers to them.
+func (mp *metaPartition) freeSharedExtents(ino *Inode) {
+	eks := ino.GetExtents().CopyExtents()
+	free := make(map[uint64]bool)
+	delExtents := make([]proto.ExtentKey, 0)
+	for _, ek := range eks {
+		id := sharedExtentID(&ek)
+		ok, checked := free[id]
+		if !checked {
+			shared, inUse := mp.sharedExtents.release(id, mp.isExtentReferred)
+			ok = shared && !inUse
+			free[id] = ok
+		}
+		if ok {
+			delExtents = append(delExtents, ek)
+		}
+	}
+	if len(delExtents) > 0 {
+		mp.extDelCh <- delExtents
+	}
+}
+
+// skipSharedExtents filters out the extent keys of the shared extents when the extents of the inode
+// are freed.
+func (mp *metaPartition) skipSharedExtents(eks []*proto.ExtentKey) []*proto.ExtentKey {
+	delExtents := make([]*proto.ExtentKey, 0, len(eks))
+	for _, ek := range eks {
+		if !mp.sharedExtents.isShared(sharedExtentID(ek)) {
+			delExtents = append(delExtents, ek)
+		}
+	}
+	return delExtents
+}
@@ -0,0 +1,180 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func drainDelExtents(mp *metaPartition) (eks []proto.ExtentKey) {
+	for {
+		select {
+		case delExtents := <-mp.extDelCh:
+			eks = append(eks, delExtents...)
+		default:
+			return
+		}
+	}
+}
+
+func testShareExtentsSource(t *testing.T) *Inode {
+	src := testCreateInode(t, FileModeType)
+	for _, ek := range []proto.ExtentKey{
+		{FileOffset: 0, PartitionId: 1, ExtentId: 1025, Size: 4096},
+		{FileOffset: 4096, PartitionId: 1, ExtentId: 1026, Size: 4096},
+	} {
+		p := &Packet{}
+		require.NoError(t, mp.ExtentAppendWithCheck(&proto.AppendExtentKeyWithCheckRequest{Inode: src.Inode, Extent: ek}, p, localAddrForAudit))
+		require.Equal(t, proto.OpOk, p.ResultCode)
+	}
+	drainDelExtents(mp)
+	return src
+}
+
+func testShareExtents(t *testing.T, src, offset, size uint64) (status uint8, info *proto.InodeInfo) {
+	p := &Packet{}
+	req := &proto.ShareExtentsRequest{Inode: src, Offset: offset, Size: size, Mode: FileModeType}
+	require.NoError(t, mp.ShareExtents(req, p, localAddrForAudit))
+	if p.ResultCode != proto.OpOk {
+		return p.ResultCode, nil
+	}
+	resp := &proto.ShareExtentsResponse{}
+	require.NoError(t, json.Unmarshal(p.Data, resp))
+	return p.ResultCode, resp.Info
+}
+
+func TestShareExtents(t *testing.T) {
+	newMpWithMock(t)
+	src := testShareExtentsSource(t)
+
+	status, info := testShareExtents(t, src.Inode, 1024, 4096)
+	require.Equal(t, proto.OpOk, status)
+	require.Equal(t, uint64(4096), info.Size)
+	ino := mp.inodeTree.Get(NewInode(info.Inode, 0)).(*Inode)
+	require.Equal(t, []proto.ExtentKey{
+		{FileOffset: 0, PartitionId: 1, ExtentId: 1025, ExtentOffset: 1024, Size: 3072},
+		{FileOffset: 3072, PartitionId: 1, ExtentId: 1026, ExtentOffset: 0, Size: 1024},
+	}, ino.GetExtents().CopyExtents())
+	require.True(t, src.HasSharedExtents())
+	require.True(t, ino.HasSharedExtents())
+	require.True(t, mp.sharedExtents.isShared(1<<32|1025))
+	require.True(t, mp.sharedExtents.isShared(1<<32|1026))
+
+	// the shared extents are kept by the flag of the inodes
+	data, err := ino.Marshal()
+	require.NoError(t, err)
+	target := NewInode(0, 0)
+	require.NoError(t, target.Unmarshal(data))
+	require.True(t, target.HasSharedExtents())
+
+	// the range must be fully covered by the extents
+	status, _ = testShareExtents(t, src.Inode, 4096, 8192)
+	require.Equal(t, proto.OpArgMismatchErr, status)
+	status, _ = testShareExtents(t, src.Inode, 0, 0)
+	require.Equal(t, proto.OpArgMismatchErr, status)
+	p := &Packet{}
+	require.NoError(t, mp.ExtentsTruncate(&ExtentsTruncateReq{Inode: src.Inode, Size: 16384}, p, localAddrForAudit))
+	require.Equal(t, proto.OpOk, p.ResultCode)
+	status, _ = testShareExtents(t, src.Inode, 4096, 8192)
+	require.Equal(t, proto.OpNotPerm, status)
+
+	// the tiny extents are never shared
+	tiny := testCreateInode(t, FileModeType)
+	p = &Packet{}
+	require.NoError(t, mp.ExtentAppendWithCheck(&proto.AppendExtentKeyWithCheckRequest{
+		Inode:  tiny.Inode,
+		Extent: proto.ExtentKey{FileOffset: 0, PartitionId: 1, ExtentId: 1, ExtentOffset: 4096, Size: 100},
+	}, p, localAddrForAudit))
+	require.Equal(t, proto.OpOk, p.ResultCode)
+	status, _ = testShareExtents(t, tiny.Inode, 0, 100)
+	require.Equal(t, proto.OpNotPerm, status)
+}
+
+func TestReleaseSharedExtents(t *testing.T) {
+	newMpWithMock(t)
+	src := testShareExtentsSource(t)
+	status, info := testShareExtents(t, src.Inode, 0, 8192)
+	require.Equal(t, proto.OpOk, status)
+
+	// the extent truncated from the source is still referred to by the shared inode
+	p := &Packet{}
+	require.NoError(t, mp.ExtentsTruncate(&ExtentsTruncateReq{Inode: src.Inode, Size: 2048}, p, localAddrForAudit))
+	require.Equal(t, proto.OpOk, p.ResultCode)
+	require.Empty(t, drainDelExtents(mp))
+	require.True(t, mp.sharedExtents.isShared(1<<32|1026))
+
+	// the extents are shared by the completed object of a multipart upload
+	complete := testCreateInode(t, FileModeType)
+	eks := mp.inodeTree.Get(NewInode(info.Inode, 0)).(*Inode).GetExtents().CopyExtents()
+	completeIno := NewInode(complete.Inode, 0)
+	completeIno.HybridCloudExtents.sortedEks = NewSortedExtentsFromEks(eks)
+	require.Equal(t, proto.OpOk, mp.fsmAppendExtents(completeIno))
+	require.True(t, complete.HasSharedExtents())
+
+	// the shared extents are skipped when the inodes are freed, and deleted with the last inode
+	// referring to them
+	require.Empty(t, mp.skipSharedExtents([]*proto.ExtentKey{&eks[0], &eks[1]}))
+	mp.internalDeleteInode(NewInode(info.Inode, 0))
+	require.Empty(t, drainDelExtents(mp))
+	mp.internalDeleteInode(NewInode(src.Inode, 0))
+	require.Empty(t, drainDelExtents(mp))
+	mp.internalDeleteInode(NewInode(complete.Inode, 0))
+	require.Equal(t, eks, drainDelExtents(mp))
+	require.True(t, mp.sharedExtents.empty())
+}
+
+func TestFreeSharedExtents(t *testing.T) {
+	newMpWithMock(t)
+	src := testShareExtentsSource(t)
+	status, info := testShareExtents(t, src.Inode, 0, 4096)
+	require.Equal(t, proto.OpOk, status)
+	// all the extents of the source are tracked after sharing
+	require.Empty(t, mp.skipSharedExtents([]*proto.ExtentKey{{PartitionId: 1, ExtentId: 1025}, {PartitionId: 1, ExtentId: 1026}}))
+
+	// the extent referred to by no inode is deleted
+	p := &Packet{}
+	require.NoError(t, mp.ExtentsTruncate(&ExtentsTruncateReq{Inode: src.Inode, Size: 0}, p, localAddrForAudit))
+	require.Equal(t, proto.OpOk, p.ResultCode)
+	require.Equal(t, []proto.ExtentKey{{FileOffset: 4096, PartitionId: 1, ExtentId: 1026, Size: 4096}}, drainDelExtents(mp))
+	require.False(t, mp.sharedExtents.isShared(1<<32|1026))
+
+	// the extent skipped when the last inode is freed is deleted when the inode is removed
+	require.Empty(t, mp.skipSharedExtents([]*proto.ExtentKey{{PartitionId: 1, ExtentId: 1025}}))
+	mp.internalDeleteInode(NewInode(info.Inode, 0))
+	require.Equal(t, []proto.ExtentKey{{PartitionId: 1, ExtentId: 1025, Size: 4096}}, drainDelExtents(mp))
+	require.True(t, mp.sharedExtents.empty())
+}
+
+func TestLoadSharedExtents(t *testing.T) {
+	newMpWithMock(t)
+	src := testShareExtentsSource(t)
+	status, _ := testShareExtents(t, src.Inode, 0, 4096)
+	require.Equal(t, proto.OpOk, status)
+	p := &Packet{}
+	require.NoError(t, mp.ExtentsTruncate(&ExtentsTruncateReq{Inode: src.Inode, Size: 2048}, p, localAddrForAudit))
+	require.Equal(t, proto.OpOk, p.ResultCode)
+
+	// the table rebuilt from the loaded inodes is the same as the one kept by the partition
+	shared := &sharedExtents{}
+	mp.inodeTree.Ascend(func(item BtreeItem) bool {
+		shared.addInode(item.(*Inode))
+		return true
+	})
+	require.Equal(t, mp.sharedExtents.holders, shared.holders)
+}
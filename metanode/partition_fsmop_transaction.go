@@ -100,7 +100,7 @@ func (mp *metaPartition) fsmTxCommitRM(txInfo *proto.TransactionInfo) (status ui
 	return proto.OpOk
 }
 
-func (mp *metaPartition) fsmTxRollbackRM(txInfo *proto.TransactionInfo) (status uint8) {
+func (mp *metaPartition) fsmTxRollbackRM(txInfo *proto.TransactionInfo, index uint64) (status uint8) {
 	status = proto.OpOk
 	ifo := mp.txProcessor.txManager.copyGetTx(txInfo.TxID)
 	if ifo == nil || ifo.Finish() {
@@ -118,7 +118,10 @@ func (mp *metaPartition) fsmTxRollbackRM(txInfo *proto.TransactionInfo) (status
 			TxID:  ifo.TxID,
 			Inode: ifo.Ino,
 		}
-		mp.fsmTxInodeRollback(req)
+		ev := mp.txInodeRollbackChange(ifo)
+		if mp.fsmTxInodeRollback(req) == proto.OpOk && ev != nil {
+			mp.recordChangeEvent(index, ev)
+		}
 	}
 
 	// delete from rb tree
@@ -132,7 +135,10 @@ func (mp *metaPartition) fsmTxRollbackRM(txInfo *proto.TransactionInfo) (status
 			Pid:  ifo.ParentId,
 			Name: ifo.Name,
 		}
-		mp.fsmTxDentryRollback(req)
+		ev := mp.txDentryRollbackChange(ifo)
+		if mp.fsmTxDentryRollback(req) == proto.OpOk && ev != nil {
+			mp.recordChangeEvent(index, ev)
+		}
 	}
 
 	ifo.SetFinish()
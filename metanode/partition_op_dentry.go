@@ -499,7 +499,44 @@ func (mp *metaPartition) UpdateDentry(req *UpdateDentryReq, p *Packet, remoteAdd
 		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
 		return
 	}
-	resp, err := mp.submit(opFSMUpdateDentry, val)
+	return mp.submitUpdateDentry(opFSMUpdateDentry, val, p)
+}
+
+// UpdateDentryIf updates a dentry only if it still points to the expected inode.
+func (mp *metaPartition) UpdateDentryIf(req *UpdateDentryIfReq, p *Packet, remoteAddr string) (err error) {
+	start := time.Now()
+	if mp.IsEnableAuditLog() {
+		defer func() {
+			auditlog.LogDentryOp(remoteAddr, mp.GetVolName(), p.GetOpMsg(), req.Name, req.GetFullPath(), err, time.Since(start).Milliseconds(), req.Inode, req.ParentID)
+		}()
+	}
+	if req.ParentID == req.Inode {
+		err = fmt.Errorf("parentId is equal inodeId")
+		p.PacketErrorWithBody(proto.OpExistErr, []byte(err.Error()))
+		return
+	}
+	if req.OldIno == 0 {
+		err = fmt.Errorf("expected inode is not specified")
+		p.PacketErrorWithBody(proto.OpArgMismatchErr, []byte(err.Error()))
+		return
+	}
+
+	dentry := &Dentry{
+		ParentId: req.ParentID,
+		Name:     req.Name,
+		Inode:    req.Inode,
+	}
+	dentry.setVerSeq(mp.verSeq)
+	val, err := (&UpdateDentryIf{Dentry: dentry, OldIno: req.OldIno}).Marshal()
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	return mp.submitUpdateDentry(opFSMUpdateDentryIf, val, p)
+}
+
+func (mp *metaPartition) submitUpdateDentry(op uint32, val []byte, p *Packet) (err error) {
+	resp, err := mp.submit(op, val)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
 		return
@@ -62,6 +62,13 @@ func (mp *metaPartition) CheckQuota(inodeId uint64, p *Packet) (iParm *Inode, in
 		return
 	}
 	mp.uidManager.acLock.Unlock()
+
+	if status = mp.isOverUserQuota(inode.Uid, inode.Gid, proto.UserQuotaUsedInfo{UsedBytes: 1}); status != 0 {
+		err = errors.New("CheckQuota user quota is over quota")
+		reply := []byte(err.Error())
+		p.PacketErrorWithBody(status, reply)
+		return
+	}
 	return
 }
 
@@ -161,6 +168,11 @@ func (mp *metaPartition) ExtentAppendWithCheck(req *proto.AppendExtentKeyWithChe
 	if !req.IsCache {
 		inoParm.StorageClass = req.StorageClass
 	}
+	// the generation of the param is only checked for the extent written from the inline data,
+	// it's always the initial one of a new inode otherwise.
+	if req.InlineGeneration > 0 {
+		inoParm.Generation = req.InlineGeneration
+	}
 
 	if req.IsMigration {
 		inoParm.HybridCloudExtentsMigration.storageClass = req.StorageClass
@@ -493,6 +505,7 @@ func (mp *metaPartition) ExtentsList(req *proto.GetExtentsRequest, p *Packet) (e
 			ino.DoReadFunc(func() {
 				resp.Generation = ino.Generation
 				resp.Size = ino.Size
+				resp.Inline = len(ino.InlineData) > 0
 				if ino.HybridCloudExtents.sortedEks != nil {
 					extents := ino.HybridCloudExtents.sortedEks.(*SortedExtents)
 					extents.Range(func(_ int, ek proto.ExtentKey) bool {
@@ -669,6 +682,47 @@ func (mp *metaPartition) BatchExtentAppend(req *proto.AppendExtentKeysRequest, p
 	return
 }
 
+// ExtentAppendIf appends the extent keys and sets the extended attributes of the inode only if its
+// size and generation are still the expected ones, OpConflictExtentsErr is replied otherwise.
+func (mp *metaPartition) ExtentAppendIf(req *proto.AppendExtentKeysIfRequest, p *Packet, remoteAddr string) (err error) {
+	start := time.Now()
+	if mp.IsEnableAuditLog() {
+		defer func() {
+			auditlog.LogInodeOp(remoteAddr, mp.GetVolName(), p.GetOpMsg(), req.FullPath, err, time.Since(start).Milliseconds(), req.Inode, 0)
+		}()
+	}
+	if !proto.IsHot(mp.volType) {
+		err = fmt.Errorf("only support hot vol")
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+
+	var ino *Inode
+	if _, ino, err = mp.CheckQuota(req.Inode, p); err != nil {
+		log.LogErrorf("ExtentAppendIf fail err [%v]", err)
+		return
+	}
+	if !proto.IsStorageClassReplica(ino.StorageClass) || req.StorageClass != ino.StorageClass {
+		err = fmt.Errorf("ino %v storage type %v donot support ExtentAppendIf with storage type %v",
+			ino.Inode, ino.StorageClass, req.StorageClass)
+		p.PacketErrorWithBody(proto.OpArgMismatchErr, []byte(err.Error()))
+		return
+	}
+
+	val, err := json.Marshal(req)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	resp, err := mp.submit(opFSMExtentsAddIf, val)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	p.PacketErrorWithBody(resp.(uint8), nil)
+	return
+}
+
 func (mp *metaPartition) BatchObjExtentAppend(req *proto.AppendObjExtentKeysRequest, p *Packet) (err error) {
 	var ino *Inode
 	if ino, _, err = mp.CheckQuota(req.Inode, p); err != nil {
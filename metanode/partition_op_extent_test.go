@@ -0,0 +1,66 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func testExtentAppendIf(t *testing.T, ino *Inode, size, gen uint64, ek proto.ExtentKey, etag string) uint8 {
+	p := &Packet{}
+	req := &proto.AppendExtentKeysIfRequest{
+		Inode:        ino.Inode,
+		Extents:      []proto.ExtentKey{ek},
+		StorageClass: ino.StorageClass,
+		Size:         size,
+		Generation:   gen,
+		ModifyTime:   1000,
+		Attrs:        map[string]string{"oss:etag": etag},
+	}
+	require.NoError(t, mp.ExtentAppendIf(req, p, localAddrForAudit))
+	return p.ResultCode
+}
+
+func TestExtentAppendIf(t *testing.T) {
+	newMpWithMock(t)
+	ino := testCreateInode(t, FileModeType)
+	gen := ino.Generation
+
+	ek := proto.ExtentKey{FileOffset: 0, PartitionId: 1, ExtentId: 1025, Size: 4096}
+	require.Equal(t, proto.OpOk, testExtentAppendIf(t, ino, 0, gen, ek, "first"))
+	require.Equal(t, uint64(4096), ino.Size)
+	require.Equal(t, int64(1000), ino.ModifyTime)
+	require.Equal(t, gen+1, ino.Generation)
+	extend := mp.extendTree.Get(NewExtend(ino.Inode)).(*Extend)
+	value, _ := extend.Get([]byte("oss:etag"))
+	require.Equal(t, "first", string(value))
+
+	// the appends at the same position conflict, only the first one is applied
+	ek2 := proto.ExtentKey{FileOffset: 4096, PartitionId: 1, ExtentId: 1026, Size: 4096}
+	require.Equal(t, proto.OpConflictExtentsErr, testExtentAppendIf(t, ino, 0, gen, ek2, "second"))
+	require.Equal(t, proto.OpConflictExtentsErr, testExtentAppendIf(t, ino, 4096, gen, ek2, "second"))
+	require.Equal(t, uint64(4096), ino.Size)
+	value, _ = mp.extendTree.Get(NewExtend(ino.Inode)).(*Extend).Get([]byte("oss:etag"))
+	require.Equal(t, "first", string(value))
+
+	require.Equal(t, proto.OpOk, testExtentAppendIf(t, ino, 4096, gen+1, ek2, "second"))
+	require.Equal(t, uint64(8192), ino.Size)
+	require.Equal(t, []proto.ExtentKey{ek, ek2}, ino.GetExtents().CopyExtents())
+	value, _ = mp.extendTree.Get(NewExtend(ino.Inode)).(*Extend).Get([]byte("oss:etag"))
+	require.Equal(t, "second", string(value))
+}
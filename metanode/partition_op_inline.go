@@ -0,0 +1,102 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+)
+
+// WriteInline writes the data of a small file into its inode. OpConflictExtentsErr is returned if
+// the data can not be kept inline, and the client writes it into the extents instead.
+func (mp *metaPartition) WriteInline(req *proto.WriteInlineRequest, p *Packet) (err error) {
+	if !proto.IsHot(mp.volType) {
+		err = fmt.Errorf("only support hot vol")
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	// the inline data is not kept by the snapshots of the inode
+	threshold := atomic.LoadUint64(&mp.inlineDataThreshold)
+	if req.Offset+uint64(len(req.Data)) > threshold || mp.GetVerSeq() != 0 {
+		p.PacketErrorWithBody(proto.OpConflictExtentsErr, []byte("inline data is not allowed"))
+		return
+	}
+	if _, _, err = mp.CheckQuota(req.Inode, p); err != nil {
+		return
+	}
+
+	req.SubmitTime = time.Now()
+	val, err := json.Marshal(req)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return err
+	}
+
+	r, err := mp.submit(opFSMWriteInline, val)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return err
+	}
+
+	resp := r.(*proto.WriteInlineResponse)
+	status := resp.Status
+	var reply []byte
+	reply, err = json.Marshal(resp)
+	if err != nil {
+		status = proto.OpErr
+		reply = []byte(err.Error())
+	}
+	p.PacketErrorWithBody(status, reply)
+	return
+}
+
+// ReadInline returns the inline data of the inode in the requested range.
+func (mp *metaPartition) ReadInline(req *proto.ReadInlineRequest, p *Packet) (err error) {
+	item := mp.inodeTree.Get(NewInode(req.Inode, 0))
+	if item == nil {
+		p.PacketErrorWithBody(proto.OpNotExistErr, []byte(fmt.Sprintf("inode[%v] not exist", req.Inode)))
+		return
+	}
+	ino := item.(*Inode)
+	if ino.ShouldDelete() {
+		p.PacketErrorWithBody(proto.OpNotExistErr, []byte(fmt.Sprintf("inode[%v] not exist", req.Inode)))
+		return
+	}
+
+	resp := &proto.ReadInlineResponse{}
+	ino.RLock()
+	resp.Size = ino.Size
+	resp.Generation = ino.Generation
+	if req.Offset < uint64(len(ino.InlineData)) {
+		end := req.Offset + uint64(req.Size)
+		if end > uint64(len(ino.InlineData)) {
+			end = uint64(len(ino.InlineData))
+		}
+		resp.Data = ino.InlineData[req.Offset:end]
+	}
+	ino.RUnlock()
+
+	var reply []byte
+	if reply, err = json.Marshal(resp); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(reply)
+	return
+}
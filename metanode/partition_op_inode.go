@@ -176,6 +176,11 @@ func (mp *metaPartition) CreateInode(req *CreateInoReq, p *Packet, remoteAddr st
 		log.LogErrorf("[CreateInode] %v, req(%+v)", err.Error(), req)
 		return
 	}
+	if status = mp.isOverUserQuota(req.Uid, req.Gid, proto.UserQuotaUsedInfo{UsedInodes: 1}); status != 0 {
+		err = errors.New("create inode is over user quota")
+		p.PacketErrorWithBody(status, []byte(err.Error()))
+		return
+	}
 	inoID, err = mp.nextInodeID()
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpInodeFullErr, []byte(err.Error()))
@@ -247,6 +252,11 @@ func (mp *metaPartition) QuotaCreateInode(req *proto.QuotaCreateInodeRequest, p
 		return
 	}
 
+	if status = mp.isOverUserQuota(req.Uid, req.Gid, proto.UserQuotaUsedInfo{UsedInodes: 1}); status != 0 {
+		err = errors.New("create inode is over user quota")
+		p.PacketErrorWithBody(status, []byte(err.Error()))
+		return
+	}
 	inoID, err = mp.nextInodeID()
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpInodeFullErr, []byte(err.Error()))
@@ -849,6 +859,11 @@ func (mp *metaPartition) EvictInodeBatch(req *BatchEvictInodeReq, p *Packet, rem
 
 // SetAttr set the inode attributes.
 func (mp *metaPartition) SetAttr(req *SetattrRequest, reqData []byte, p *Packet) (err error) {
+	if status := mp.isOverUserQuotaByChown(req); status != 0 {
+		err = errors.New("change owner is over user quota")
+		p.PacketErrorWithBody(status, []byte(err.Error()))
+		return
+	}
 	if mp.verSeq != 0 {
 		req.VerSeq = mp.GetVerSeq()
 		reqData, err = json.Marshal(req)
@@ -954,6 +969,11 @@ func (mp *metaPartition) TxCreateInode(req *proto.TxCreateInodeRequest, p *Packe
 		return
 	}
 
+	if status = mp.isOverUserQuota(req.Uid, req.Gid, proto.UserQuotaUsedInfo{UsedInodes: 1}); status != 0 {
+		err = errors.New("create inode is over user quota")
+		p.PacketErrorWithBody(status, []byte(err.Error()))
+		return
+	}
 	inoID, err = mp.nextInodeID()
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpInodeFullErr, []byte(err.Error()))
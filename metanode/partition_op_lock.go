@@ -0,0 +1,112 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+)
+
+// SetLock sets or releases a posix lock or flock of the inode, the lock expires after the lease
+// unless the client renews it.
+func (mp *metaPartition) SetLock(req *proto.SetLockRequest, p *Packet) (err error) {
+	req.SubmitTime = time.Now()
+	if req.Lease == 0 {
+		req.Lease = proto.DefaultPosixLockLease
+	}
+	if !isValidPosixLock(&req.Lock) {
+		p.PacketErrorWithBody(proto.OpArgMismatchErr, []byte("invalid lock"))
+		return
+	}
+
+	val, err := json.Marshal(req)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return err
+	}
+
+	r, err := mp.submit(opFSMSetLock, val)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return err
+	}
+
+	resp := r.(*proto.SetLockResponse)
+	status := resp.Status
+	var reply []byte
+	reply, err = json.Marshal(resp)
+	if err != nil {
+		status = proto.OpErr
+		reply = []byte(err.Error())
+	}
+	p.PacketErrorWithBody(status, reply)
+	return
+}
+
+func isValidPosixLock(lk *proto.PosixLock) bool {
+	switch lk.Type {
+	case proto.PosixLockRead, proto.PosixLockWrite, proto.PosixLockUnlock:
+	default:
+		return false
+	}
+	return lk.Start <= lk.End
+}
+
+// GetLock returns the first lock conflicting with the requested one.
+func (mp *metaPartition) GetLock(req *proto.GetLockRequest, p *Packet) (err error) {
+	resp := &proto.GetLockResponse{}
+	if item := mp.extendTree.Get(NewExtend(req.Inode)); item != nil {
+		locks := livePosixLocks(unmarshalPosixLocks(item.(*Extend)), time.Now().Unix())
+		resp.Lock = findConflictPosixLock(locks, &req.Lock)
+	}
+
+	var reply []byte
+	if reply, err = json.Marshal(resp); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(reply)
+	return
+}
+
+// RenewLock extends the leases of the locks held by the client on the inodes.
+func (mp *metaPartition) RenewLock(req *proto.RenewLockRequest, p *Packet) (err error) {
+	req.SubmitTime = time.Now()
+	if req.Lease == 0 {
+		req.Lease = proto.DefaultPosixLockLease
+	}
+
+	val, err := json.Marshal(req)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return err
+	}
+
+	r, err := mp.submit(opFSMRenewLock, val)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return err
+	}
+
+	var reply []byte
+	if reply, err = json.Marshal(r.(*proto.RenewLockResponse)); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(reply)
+	return
+}
@@ -73,6 +73,43 @@ func (mp *metaPartition) getQuotaReportInfos() (infos []*proto.QuotaReportInfo)
 	return mp.mqMgr.getQuotaReportInfos()
 }
 
+func (mp *metaPartition) setUserQuotaHbInfo(infos []*proto.UserQuotaInfo) {
+	mp.uqMgr.setUserQuotaHbInfo(infos)
+}
+
+func (mp *metaPartition) getUserQuotaReportInfos() (infos []*proto.UserQuotaReportInfo) {
+	return mp.uqMgr.getUserQuotaReportInfos()
+}
+
+func (mp *metaPartition) isOverUserQuota(uid, gid uint32, alloc proto.UserQuotaUsedInfo) (status uint8) {
+	return mp.uqMgr.isOverQuota(userQuotaKeys(uid, gid), alloc)
+}
+
+// isOverUserQuotaByChown checks the quotas of the new owner and group of the inode, which take over
+// the inode and its bytes.
+func (mp *metaPartition) isOverUserQuotaByChown(req *SetattrRequest) (status uint8) {
+	if req.Valid&(proto.AttrUid|proto.AttrGid) == 0 {
+		return
+	}
+	item := mp.inodeTree.Get(NewInode(req.Inode, 0))
+	if item == nil {
+		return
+	}
+	ino := item.(*Inode)
+
+	var keys []proto.UserQuotaKey
+	if req.Valid&proto.AttrUid != 0 && req.Uid != ino.Uid {
+		keys = append(keys, proto.UserQuotaKey{Type: proto.UserQuotaTypeUser, Id: req.Uid})
+	}
+	if req.Valid&proto.AttrGid != 0 && req.Gid != ino.Gid {
+		keys = append(keys, proto.UserQuotaKey{Type: proto.UserQuotaTypeGroup, Id: req.Gid})
+	}
+	if len(keys) == 0 {
+		return
+	}
+	return mp.uqMgr.isOverQuota(keys, proto.UserQuotaUsedInfo{UsedBytes: userQuotaUsedBytes(ino), UsedInodes: 1})
+}
+
 func (mp *metaPartition) statisticExtendByLoad(extend *Extend, ino *Inode) {
 	mqMgr := mp.mqMgr
 	ino.Inode = extend.GetInode()
@@ -54,6 +54,63 @@ func TestQuotaHbInfo(t *testing.T) {
 	require.Equal(t, uint8(0), partition.mqMgr.IsOverQuota(true, true, quotaId2))
 }
 
+func TestUserQuota(t *testing.T) {
+	partition := NewMetaPartitionForQuotaTest()
+	partition.uidManager = NewUidMgr(VolNameForTest, 1)
+	uqMgr := partition.uqMgr
+	userKey := proto.UserQuotaKey{Type: proto.UserQuotaTypeUser, Id: 1000}
+	groupKey := proto.UserQuotaKey{Type: proto.UserQuotaTypeGroup, Id: 100}
+
+	ino := NewInode(1, 0o644)
+	ino.Uid, ino.Gid = 1000, 100
+	require.Equal(t, proto.OpOk, partition.fsmCreateInode(ino))
+	ino.Size = 4096
+	partition.uqMgr.updateSize(ino, 0)
+	require.Equal(t, proto.UserQuotaUsedInfo{UsedBytes: 4096, UsedInodes: 1}, *uqMgr.usedInfos[userKey])
+	require.Equal(t, proto.UserQuotaUsedInfo{UsedBytes: 4096, UsedInodes: 1}, *uqMgr.usedInfos[groupKey])
+
+	// no quota, no usage is reported
+	require.Nil(t, partition.getUserQuotaReportInfos())
+	require.Equal(t, uint8(0), partition.isOverUserQuota(1000, 100, proto.UserQuotaUsedInfo{UsedBytes: 1, UsedInodes: 1}))
+
+	partition.setUserQuotaHbInfo([]*proto.UserQuotaInfo{
+		{
+			VolName: VolNameForTest, Type: proto.UserQuotaTypeUser, Id: 1000, HardInodes: 2,
+			UsedInfo: proto.UserQuotaUsedInfo{UsedBytes: 4096, UsedInodes: 1},
+		},
+		{VolName: VolNameForTest, Type: proto.UserQuotaTypeGroup, Id: 200, HardBytes: 4095},
+		{VolName: "other", Type: proto.UserQuotaTypeGroup, Id: 100, HardBytes: 1},
+	})
+	require.Len(t, partition.getUserQuotaReportInfos(), 2)
+	require.Equal(t, uint8(0), partition.isOverUserQuota(1000, 100, proto.UserQuotaUsedInfo{UsedBytes: 1, UsedInodes: 1}))
+
+	// the inode created after the report is counted before master aggregates it
+	ino2 := NewInode(2, 0o644)
+	ino2.Uid, ino2.Gid = 1000, 100
+	require.Equal(t, proto.OpOk, partition.fsmCreateInode(ino2))
+	require.Equal(t, proto.OpNoSpaceErr, partition.isOverUserQuota(1000, 100, proto.UserQuotaUsedInfo{UsedInodes: 1}))
+	require.Equal(t, uint8(0), partition.isOverUserQuota(1000, 100, proto.UserQuotaUsedInfo{UsedBytes: 1}))
+
+	// chgrp to a group without space
+	req := &SetattrRequest{Inode: 1, Valid: proto.AttrGid, Gid: 200}
+	require.Equal(t, proto.OpNoSpaceErr, partition.isOverUserQuotaByChown(req))
+	req.Gid = 300
+	require.Equal(t, uint8(0), partition.isOverUserQuotaByChown(req))
+	require.NoError(t, partition.fsmSetAttr(req))
+	require.Equal(t, proto.UserQuotaUsedInfo{UsedInodes: 1}, *uqMgr.usedInfos[groupKey])
+	require.Equal(t, proto.UserQuotaUsedInfo{UsedBytes: 4096, UsedInodes: 1},
+		*uqMgr.usedInfos[proto.UserQuotaKey{Type: proto.UserQuotaTypeGroup, Id: 300}])
+
+	// the changes during the rebuild are kept
+	require.True(t, uqMgr.statisticRebuildStart())
+	require.False(t, uqMgr.statisticRebuildStart())
+	uqMgr.statisticByStore(ino)
+	partition.internalDeleteInode(ino2)
+	uqMgr.statisticRebuildFin(true)
+	require.Equal(t, proto.UserQuotaUsedInfo{UsedBytes: 4096, UsedInodes: 0}, *uqMgr.usedInfos[userKey])
+	require.Equal(t, uint8(0), partition.isOverUserQuota(1000, 100, proto.UserQuotaUsedInfo{UsedInodes: 1}))
+}
+
 func NewMetaPartitionForQuotaTest() *metaPartition {
 	mpC := &MetaPartitionConfig{
 		PartitionId: PartitionIdForTest,
@@ -63,5 +120,6 @@ func NewMetaPartitionForQuotaTest() *metaPartition {
 	partition.uniqChecker.keepTime = 1
 	partition.uniqChecker.keepOps = 0
 	partition.mqMgr = NewQuotaManager(VolNameForTest, 1)
+	partition.uqMgr = NewUserQuotaManager(VolNameForTest, 1)
 	return partition
 }
@@ -0,0 +1,78 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/auditlog"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// ShareExtents creates an inode referring to the extents of the source inode in the requested range
+// instead of copying the data. OpNotPerm is returned if the extents can't be shared, such as the
+// volume has snapshots or the range is not fully covered by the normal extents, and the client
+// copies the data instead.
+func (mp *metaPartition) ShareExtents(req *proto.ShareExtentsRequest, p *Packet, remoteAddr string) (err error) {
+	start := time.Now()
+	if mp.IsEnableAuditLog() {
+		defer func() {
+			auditlog.LogInodeOp(remoteAddr, mp.GetVolName(), p.GetOpMsg(), req.FullPath, err, time.Since(start).Milliseconds(), req.NewInode, 0)
+		}()
+	}
+	// the extents of the snapshots are referred to by ekRefMap of each inode
+	if !proto.IsHot(mp.volType) || mp.GetVerSeq() != 0 {
+		p.PacketErrorWithBody(proto.OpNotPerm, []byte("share extents is not allowed"))
+		return
+	}
+	if status := mp.isOverUserQuota(req.Uid, req.Gid, proto.UserQuotaUsedInfo{UsedInodes: 1, UsedBytes: int64(req.Size)}); status != 0 {
+		p.PacketErrorWithBody(status, []byte("share extents is over user quota"))
+		return
+	}
+	if req.NewInode, err = mp.nextInodeID(); err != nil {
+		p.PacketErrorWithBody(proto.OpInodeFullErr, []byte(err.Error()))
+		return
+	}
+
+	req.SubmitTime = time.Now()
+	val, err := json.Marshal(req)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	r, err := mp.submit(opFSMShareExtents, val)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+
+	resp := r.(*InodeResponse)
+	status := resp.Status
+	var reply []byte
+	if status == proto.OpOk {
+		info := &proto.InodeInfo{}
+		replyInfo(info, resp.Msg, make(map[uint32]*proto.MetaQuotaInfo))
+		if reply, err = json.Marshal(&proto.ShareExtentsResponse{Info: info}); err != nil {
+			status = proto.OpErr
+			reply = []byte(err.Error())
+		}
+	}
+	p.PacketErrorWithBody(status, reply)
+	log.LogDebugf("ShareExtents: mp(%v) ino(%v) offset(%v) size(%v) newIno(%v) status(%v)",
+		mp.config.PartitionId, req.Inode, req.Offset, req.Size, req.NewInode, status)
+	return
+}
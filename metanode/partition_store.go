@@ -144,6 +144,7 @@ func (mp *metaPartition) loadMetadata() (err error) {
 
 	mp.uidManager = NewUidMgr(mp.config.VolName, mp.config.PartitionId)
 	mp.mqMgr = NewQuotaManager(mp.config.VolName, mp.config.PartitionId)
+	mp.uqMgr = NewUserQuotaManager(mp.config.VolName, mp.config.PartitionId)
 
 	if mp.manager.metaNode.raftPartitionCanUsingDifferentPort && lackRaftPort {
 		if err = mp.persistMetadata(); err != nil {
@@ -233,6 +234,7 @@ func (mp *metaPartition) loadInode(rootDir string, crc uint32) (err error) {
 			ino.LeaseExpireTime = uint64(ino.ModifyTime) + proto.ForbiddenMigrationRenewalSeonds
 		}
 		mp.acucumUidSizeByLoad(ino)
+		mp.uqMgr.statisticByLoad(ino)
 		// data crc
 		if _, err = crcCheck.Write(data); err != nil {
 			return err
@@ -248,6 +250,7 @@ func (mp *metaPartition) loadInode(rootDir string, crc uint32) (err error) {
 		mp.size += ino.Size
 
 		mp.fsmCreateInode(ino)
+		mp.sharedExtents.addInode(ino)
 		mp.checkAndInsertFreeList(ino)
 		if mp.config.Cursor < ino.Inode {
 			mp.config.Cursor = ino.Inode
@@ -1169,6 +1172,11 @@ func (mp *metaPartition) storeTxInfo(rootDir string, sm *storeMsg) (crc uint32,
 func (mp *metaPartition) storeInode(rootDir string,
 	sm *storeMsg,
 ) (crc uint32, err error) {
+	if sm.userQuotaRebuild {
+		defer func() {
+			mp.uqMgr.statisticRebuildFin(err == nil)
+		}()
+	}
 	filename := path.Join(rootDir, inodeFile)
 	fp, err := newBufFile(filename, os.O_RDWR|os.O_TRUNC|os.O_APPEND|os.
 		O_CREATE, 0o755)
@@ -1198,6 +1206,9 @@ func (mp *metaPartition) storeInode(rootDir string,
 		if sm.uidRebuild {
 			mp.acucumUidSizeByStore(ino)
 		}
+		if sm.userQuotaRebuild {
+			mp.uqMgr.statisticByStore(ino)
+		}
 
 		buf := GetInodeBuf()
 		defer PutInodeBuf(buf)
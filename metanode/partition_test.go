@@ -74,6 +74,7 @@ func TestMetaPartition_LoadSnapshot(t *testing.T) {
 	}
 	mp.uidManager = NewUidMgr(mpC.VolName, mpC.PartitionId)
 	mp.mqMgr = NewQuotaManager(mpC.VolName, mpC.PartitionId)
+	mp.uqMgr = NewUserQuotaManager(mpC.VolName, mpC.PartitionId)
 	mp.multiVersionList = &proto.VolVersionInfoList{}
 
 	err := mp.store(msg)
@@ -205,6 +206,7 @@ func TestMetaPartition_LoadHybridCloudMigrationSnapshot(t *testing.T) {
 	}
 	mp.uidManager = NewUidMgr(mpC.VolName, mpC.PartitionId)
 	mp.mqMgr = NewQuotaManager(mpC.VolName, mpC.PartitionId)
+	mp.uqMgr = NewUserQuotaManager(mpC.VolName, mpC.PartitionId)
 	mp.multiVersionList = &proto.VolVersionInfoList{}
 	err := mp.store(msg)
 	require.Nil(t, err)
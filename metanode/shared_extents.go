@@ -0,0 +1,128 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"sync"
+
+	"github.com/cubefs/cubefs/proto"
+)
+
+// sharedExtents keeps the inodes referring to the extents shared by ShareExtents instead of copying
+// the data of a file. An extent in the table is deleted only after the last inode referring to it
+// releases it, and the extents are skipped when the inodes sharing them are freed.
+//
+// The table is not persisted, the inodes sharing extents are marked by SharedExtentsFlag and all
+// their extents are registered when the inodes are loaded. The extents not shared actually are
+// dropped from the table when they are released by their only inode.
+//
+// The data of a shared extent is not copied on write, so it's only shared between the inodes which
+// never overwrite it, such as the parts and the objects of the object storage.
+type sharedExtents struct {
+	sync.Mutex
+	holders map[uint64]map[uint64]struct{} // extent id -> inodes
+}
+
+// sharedExtentID is the id of the extent of the extent key, the same as the key of ekRefMap.
+func sharedExtentID(ek *proto.ExtentKey) uint64 {
+	return ek.PartitionId<<32 | ek.ExtentId
+}
+
+func (se *sharedExtents) add(id uint64, inodes ...uint64) {
+	if se.holders == nil {
+		se.holders = make(map[uint64]map[uint64]struct{})
+	}
+	holders, ok := se.holders[id]
+	if !ok {
+		holders = make(map[uint64]struct{})
+		se.holders[id] = holders
+	}
+	for _, ino := range inodes {
+		holders[ino] = struct{}{}
+	}
+}
+
+// share registers the inodes as the holders of the extents.
+func (se *sharedExtents) share(eks []proto.ExtentKey, inodes ...uint64) {
+	se.Lock()
+	defer se.Unlock()
+	for i := range eks {
+		se.add(sharedExtentID(&eks[i]), inodes...)
+	}
+}
+
+// anyShared returns if any extent of the extent keys is shared.
+func (se *sharedExtents) anyShared(eks []proto.ExtentKey) bool {
+	se.Lock()
+	defer se.Unlock()
+	for i := range eks {
+		if _, ok := se.holders[sharedExtentID(&eks[i])]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (se *sharedExtents) isShared(id uint64) (ok bool) {
+	se.Lock()
+	_, ok = se.holders[id]
+	se.Unlock()
+	return
+}
+
+func (se *sharedExtents) empty() bool {
+	se.Lock()
+	defer se.Unlock()
+	return len(se.holders) == 0
+}
+
+// release drops the holders no longer referring to the extent, and returns if the extent is
+// shared and still referred to by any inode. The extent is dropped without holders.
+func (se *sharedExtents) release(id uint64, referred func(ino, id uint64) bool) (shared, inUse bool) {
+	se.Lock()
+	defer se.Unlock()
+	holders, ok := se.holders[id]
+	if !ok {
+		return
+	}
+	for ino := range holders {
+		if !referred(ino, id) {
+			delete(holders, ino)
+		}
+	}
+	if len(holders) == 0 {
+		delete(se.holders, id)
+	}
+	return true, len(holders) > 0
+}
+
+// addInode registers all the extents of the inode marked by SharedExtentsFlag, the same as the
+// inode is loaded.
+func (se *sharedExtents) addInode(ino *Inode) {
+	if !ino.HasSharedExtents() {
+		return
+	}
+	eks := ino.GetExtents().CopyExtents()
+	se.share(eks, ino.Inode)
+}
+
+func (se *sharedExtents) replace(other *sharedExtents) {
+	other.Lock()
+	holders := other.holders
+	other.Unlock()
+	se.Lock()
+	se.holders = holders
+	se.Unlock()
+}
@@ -79,6 +79,7 @@ func newMetaPartition(PartitionId uint64, manager *metadataManager) (mp *metaPar
 
 	mp.txProcessor = NewTransactionProcessor(mp)
 	mp.uidManager = NewUidMgr(mp.config.VolName, mp.config.PartitionId)
+	mp.uqMgr = NewUserQuotaManager(mp.config.VolName, mp.config.PartitionId)
 	mp.manager.initFileStatsConfig()
 	return mp
 }
@@ -131,6 +131,11 @@ func (g *Grant) isAllowed(reqId string, apiName proto.Action) bool {
 	}
 }
 
+// isPublic reports whether the grant is to all users or all authenticated users.
+func (g *Grant) isPublic() bool {
+	return g.Grantee.Type == TypeGroup
+}
+
 func (acp *AccessControlPolicy) IsValid() error {
 	if len(acp.Acl.Grants) == 0 {
 		return ErrMissingGrants
@@ -165,6 +170,27 @@ func (acp *AccessControlPolicy) IsEmpty() bool {
 	return len(acp.Acl.Grants) == 0
 }
 
+// IsPublic reports whether the acl grants any permission to the public groups.
+func (acp *AccessControlPolicy) IsPublic() bool {
+	for i := range acp.Acl.Grants {
+		if acp.Acl.Grants[i].isPublic() {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutPublicGrants returns a copy of the acl with the grants to the public groups removed.
+func (acp *AccessControlPolicy) withoutPublicGrants() *AccessControlPolicy {
+	acl := &AccessControlPolicy{Xmlns: acp.Xmlns, Owner: acp.Owner}
+	for _, g := range acp.Acl.Grants {
+		if !g.isPublic() {
+			acl.Acl.Grants = append(acl.Acl.Grants, g)
+		}
+	}
+	return acl
+}
+
 func (acp *AccessControlPolicy) SetOwner(owner string) {
 	acp.Owner.Id = owner
 }
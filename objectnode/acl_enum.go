@@ -60,6 +60,7 @@ var (
 		proto.OSSListObjectsAction:          PermissionRead,
 		proto.OSSHeadBucketAction:           PermissionRead,
 		proto.OSSListMultipartUploadsAction: PermissionRead,
+		proto.OSSListObjectVersionsAction:   PermissionRead,
 		// bucket write
 		proto.OSSPutObjectAction:               PermissionWrite,
 		proto.OSSPostObjectAction:              PermissionWrite,
@@ -119,6 +119,11 @@ func (o *ObjectNode) createBucketHandler(w http.ResponseWriter, r *http.Request)
 		log.LogErrorf("createBucketHandler: parse acl fail: requestID(%v) err(%v)", GetRequestID(r), err)
 		return
 	}
+	// the new bucket is only subject to the public access block configuration of the account
+	if errorCode = newPublicAccessBlockConfiguration(userInfo.PublicAccessBlock).checkACL(acl); errorCode != nil {
+		log.LogErrorf("createBucketHandler: public acl is blocked: requestID(%v) acl(%+v)", GetRequestID(r), acl)
+		return
+	}
 
 	if err = o.mc.AdminAPI().CreateDefaultVolume(bucket, userInfo.UserID); err != nil {
 		log.LogErrorf("createBucketHandler: create bucket fail: requestID(%v) volume(%v) accessKey(%v) err(%v)",
@@ -531,6 +536,17 @@ func (o *ObjectNode) putObjectLockConfigurationHandler(w http.ResponseWriter, r
 			GetRequestID(r), vol.Name(), string(body), err)
 		return
 	}
+	// the protected object must be kept as a noncurrent version when it's overwritten or deleted
+	if !config.IsEmpty() {
+		var status string
+		if status, err = vol.versioningStatus(); err != nil {
+			return
+		}
+		if status != Enabled {
+			errorCode = ObjectLockVersioningNotEnabled
+			return
+		}
+	}
 	if body, err = json.Marshal(config); err != nil {
 		log.LogErrorf("putObjectLockConfigurationHandler: json.Marshal object lock config fail: requestID(%v) volume(%v) config(%v) err(%v)",
 			GetRequestID(r), vol.Name(), config, err)
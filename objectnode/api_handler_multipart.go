@@ -116,6 +116,40 @@ func (o *ObjectNode) createMultipleUploadHandler(w http.ResponseWriter, r *http.
 			GetRequestID(r), acl, err)
 		return
 	}
+	if err = checkPublicACL(vol, acl); err != nil {
+		log.LogErrorf("createMultipleUploadHandler: check public acl fail: requestID(%v) acl(%+v) err(%v)",
+			GetRequestID(r), acl, err)
+		return
+	}
+	// Check server-side encryption
+	var sse *SSEOption
+	if sse, err = parseWriteSSEOption(r.Header, vol); err != nil {
+		log.LogErrorf("createMultipleUploadHandler: parse sse fail: requestID(%v) err(%v)",
+			GetRequestID(r), err)
+		return
+	}
+	// Check object lock
+	var objectLock *ObjectLockConfig
+	if objectLock, err = vol.metaLoader.loadObjectLock(); err != nil {
+		log.LogErrorf("createMultipleUploadHandler: load volume objectLock fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	var lockOpt *ObjectLockOption
+	if lockOpt, err = ParseObjectLockOption(r.Header, objectLock); err != nil {
+		log.LogErrorf("createMultipleUploadHandler: parse object lock fail: requestID(%v) err(%v)",
+			GetRequestID(r), err)
+		return
+	}
+	// Check checksum algorithm of parts
+	var checksumAlgorithm string
+	if checksumAlgorithm, err = parseChecksumAlgorithm(r.Header); err != nil {
+		return
+	}
+	var checksumOpt *ChecksumOption
+	if checksumAlgorithm != "" {
+		checksumOpt = &ChecksumOption{Algorithm: checksumAlgorithm}
+	}
 	opt := &PutFileOption{
 		MIMEType:     contentType,
 		Disposition:  contentDisposition,
@@ -124,6 +158,13 @@ func (o *ObjectNode) createMultipleUploadHandler(w http.ResponseWriter, r *http.
 		CacheControl: cacheControl,
 		Expires:      expires,
 		ACL:          acl,
+		LockOption:   lockOpt,
+		SSE:          sse,
+		Checksum:     checksumOpt,
+	}
+
+	if err = vol.checkBucketQuota(0, 1); err != nil {
+		return
 	}
 
 	var uploadID string
@@ -133,6 +174,10 @@ func (o *ObjectNode) createMultipleUploadHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
+	sse.SetResponseHeader(w.Header())
+	if checksumAlgorithm != "" {
+		w.Header().Set(XAmzChecksumAlgorithm, checksumAlgorithm)
+	}
 	initResult := InitMultipartResult{
 		Bucket:   param.Bucket(),
 		Key:      param.Object(),
@@ -242,6 +287,19 @@ func (o *ObjectNode) uploadPartHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
 
+	// The customer provided key is required for the part of SSE-C multipart upload
+	var sse *SSEOption
+	if sse, err = ParseSSECustomerOption(r.Header, false); err != nil {
+		log.LogErrorf("uploadPartHandler: parse sse fail: requestID(%v) err(%v)", GetRequestID(r), err)
+		return
+	}
+	// Additional checksum of the part
+	var checksumOpt *ChecksumOption
+	if checksumOpt, err = parseChecksumOption(r); err != nil {
+		log.LogErrorf("uploadPartHandler: parse checksum fail: requestID(%v) err(%v)", GetRequestID(r), err)
+		return
+	}
+
 	// Flow Control
 	var reader io.Reader
 	if length > DefaultFlowLimitSize {
@@ -250,9 +308,13 @@ func (o *ObjectNode) uploadPartHandler(w http.ResponseWriter, r *http.Request) {
 		reader = r.Body
 	}
 
+	if err = vol.checkBucketQuota(uint64(length), 0); err != nil {
+		return
+	}
+
 	// Write Part
 	start := time.Now()
-	fsFileInfo, err := vol.WritePart(param.Object(), uploadId, partNumberInt, reader)
+	fsFileInfo, err := vol.WritePart(param.Object(), uploadId, partNumberInt, reader, sse, checksumOpt)
 	span.AppendTrackLog("part.w", start, err)
 	if err != nil {
 		log.LogErrorf("uploadPartHandler: write part fail: requestID(%v) volume(%v) path(%v) uploadId(%v) part(%v) err(%v)",
@@ -271,6 +333,8 @@ func (o *ObjectNode) uploadPartHandler(w http.ResponseWriter, r *http.Request) {
 
 	// write header to response
 	w.Header()[ETag] = []string{"\"" + fsFileInfo.ETag + "\""}
+	sse.SetResponseHeader(w.Header())
+	fsFileInfo.Checksum.SetResponseHeader(w.Header())
 }
 
 // Upload part copy
@@ -331,7 +395,7 @@ func (o *ObjectNode) uploadPartCopyHandler(w http.ResponseWriter, r *http.Reques
 	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
 
 	// step2: extract params from req
-	srcBucket, srcObject, _, err := extractSrcBucketKey(r)
+	srcBucket, srcObject, srcVersionId, err := extractSrcBucketKey(r)
 	if err != nil {
 		log.LogDebugf("uploadPartCopyHandler: copySource(%v) argument invalid: requestID(%v)",
 			r.Header.Get(XAmzCopySource), GetRequestID(r))
@@ -346,14 +410,17 @@ func (o *ObjectNode) uploadPartCopyHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 	start := time.Now()
-	srcFileInfo, _, err := srcVol.ObjectMeta(srcObject)
+	srcFileInfo, srcXAttr, err := srcVol.ObjectVersionMeta(srcObject, srcVersionId)
 	span.AppendTrackLog("meta.r", start, err)
 	if err != nil {
-		log.LogErrorf("uploadPartCopyHandler: get fileMeta fail: requestId(%v) srcVol(%v) path(%v) err(%v)",
-			GetRequestID(r), srcBucket, srcObject, err)
+		log.LogErrorf("uploadPartCopyHandler: get fileMeta fail: requestId(%v) srcVol(%v) path(%v) versionId(%v) err(%v)",
+			GetRequestID(r), srcBucket, srcObject, srcVersionId, err)
 		if err == syscall.ENOENT {
 			errorCode = NoSuchKey
 		}
+		if err == MethodNotAllowed {
+			errorCode = InvalidArgument
+		}
 		return
 	}
 
@@ -362,6 +429,27 @@ func (o *ObjectNode) uploadPartCopyHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	// the encrypted source object is decrypted before written to the part
+	var srcSSEOpt, sse *SSEOption
+	if srcSSEOpt, err = ParseSSECustomerOption(r.Header, true); err != nil {
+		return
+	}
+	if sse, err = ParseSSECustomerOption(r.Header, false); err != nil {
+		return
+	}
+	srcSSE, err := loadSSEMeta(srcXAttr)
+	if err != nil {
+		log.LogErrorf("uploadPartCopyHandler: load source sse meta fail: requestId(%v) srcVol(%v) path(%v) err(%v)",
+			GetRequestID(r), srcBucket, srcObject, err)
+		return
+	}
+	srcDataKey, err := objectDataKey(srcSSE, srcSSEOpt)
+	if err != nil {
+		log.LogErrorf("uploadPartCopyHandler: check source sse fail: requestId(%v) srcVol(%v) path(%v) err(%v)",
+			GetRequestID(r), srcBucket, srcObject, err)
+		return
+	}
+
 	// step4: extract range params
 	copyRange := r.Header.Get(XAmzCopySourceRange)
 	firstByte, copyLength, errorCode := determineCopyRange(copyRange, srcFileInfo.Size)
@@ -380,26 +468,50 @@ func (o *ObjectNode) uploadPartCopyHandler(w http.ResponseWriter, r *http.Reques
 	if err != nil {
 		return
 	}
-	reader, writer := io.Pipe()
-	go func() {
-		err = srcVol.readFile(srcFileInfo.Inode, size, srcObject, writer, fb, cl, srcFileInfo.StorageClass)
-		if err != nil {
-			log.LogErrorf("uploadPartCopyHandler: read srcObj err(%v): requestId(%v) srcVol(%v) path(%v)",
-				err, GetRequestID(r), srcBucket, srcObject)
+	if err = vol.checkBucketQuota(cl, 0); err != nil {
+		return
+	}
+
+	// step5: the part copied within the volume refers to the extents of the source without copying
+	// the data, and falls back to copying if the extents can't be shared
+	var fsFileInfo *FSFileInfo
+	if srcBucket == param.Bucket() && srcSSE == nil && sse == nil && srcFileInfo.ETag != "" &&
+		proto.IsStorageClassReplica(srcFileInfo.StorageClass) {
+		start = time.Now()
+		fsFileInfo, err = vol.SharePart(param.Object(), uploadId, partNumberInt, srcFileInfo.Inode, fb, cl,
+			sharedPartETag(srcFileInfo.ETag, size, fb, cl))
+		span.AppendTrackLog("part.s", start, err)
+		if err == errPartNotShared {
+			fsFileInfo, err = nil, nil
 		}
-		writer.CloseWithError(err)
-	}()
+	}
 
-	// step5: upload part by copy and flow control
-	var rd io.Reader
-	if copyLength > DefaultFlowLimitSize {
-		rd = rateLimit.GetReader(vol.owner, param.apiName, reader)
-	} else {
-		rd = reader
+	// step6: upload part by copy and flow control
+	if fsFileInfo == nil && err == nil {
+		reader, writer := io.Pipe()
+		var srcWriter io.Writer = writer
+		if srcSSE != nil {
+			srcWriter = srcSSE.decryptWriter(writer, srcDataKey, fb)
+		}
+		go func() {
+			err = srcVol.readFile(srcFileInfo.Inode, size, srcObject, srcWriter, fb, cl, srcFileInfo.StorageClass)
+			if err != nil {
+				log.LogErrorf("uploadPartCopyHandler: read srcObj err(%v): requestId(%v) srcVol(%v) path(%v)",
+					err, GetRequestID(r), srcBucket, srcObject)
+			}
+			writer.CloseWithError(err)
+		}()
+
+		var rd io.Reader
+		if copyLength > DefaultFlowLimitSize {
+			rd = rateLimit.GetReader(vol.owner, param.apiName, reader)
+		} else {
+			rd = reader
+		}
+		start = time.Now()
+		fsFileInfo, err = vol.WritePart(param.Object(), uploadId, partNumberInt, rd, sse, nil)
+		span.AppendTrackLog("part.w", start, err)
 	}
-	start = time.Now()
-	fsFileInfo, err := vol.WritePart(param.Object(), uploadId, partNumberInt, rd)
-	span.AppendTrackLog("part.w", start, err)
 	if err != nil {
 		log.LogErrorf("uploadPartCopyHandler: write part fail: requestID(%v) volume(%v) path(%v) uploadId(%v) part(%v) err(%v)",
 			GetRequestID(r), vol.Name(), param.Object(), uploadId, partNumberInt, err)
@@ -672,6 +784,13 @@ func (o *ObjectNode) completeMultipartUploadHandler(w http.ResponseWriter, r *ht
 		errorCode = KeyTooLong
 		return
 	}
+	// conditional write
+	var cond *WriteCondition
+	if cond, err = parseWriteCondition(r); err != nil {
+		log.LogErrorf("completeMultipartUploadHandler: parse precondition fail: requestID(%v) err(%v)",
+			GetRequestID(r), err)
+		return
+	}
 
 	var vol *Volume
 	if vol, err = o.getVol(param.Bucket()); err != nil {
@@ -762,9 +881,18 @@ func (o *ObjectNode) completeMultipartUploadHandler(w http.ResponseWriter, r *ht
 		return
 	}
 
+	// the checksums of parts in request are validated if the upload is initiated with checksum algorithm
+	var partChecksums map[uint16]string
+	if checksum, _ := parseObjectChecksum([]byte(multipartInfo.Extend[XAttrKeyOSSChecksum])); checksum != nil {
+		partChecksums = make(map[uint16]string)
+		for _, p := range multipartUploadRequest.Parts {
+			partChecksums[uint16(p.PartNumber)] = p.Checksum(checksum.Algorithm)
+		}
+	}
+
 	// complete multipart
 	start = time.Now()
-	fsFileInfo, err := vol.CompleteMultipart(param.Object(), uploadId, committedPartInfo, discardedInods)
+	fsFileInfo, err := vol.CompleteMultipart(param.Object(), uploadId, committedPartInfo, discardedInods, partChecksums, cond)
 	span.AppendTrackLog("part.c", start, err)
 	if err != nil {
 		log.LogErrorf("completeMultipartUploadHandler: complete multipart fail: requestID(%v) volume(%v) uploadID(%v) err(%v)",
@@ -775,10 +903,26 @@ func (o *ObjectNode) completeMultipartUploadHandler(w http.ResponseWriter, r *ht
 		return
 	}
 
+	if len(fsFileInfo.VersionId) > 0 {
+		w.Header().Set(XAmzVersionId, fsFileInfo.VersionId)
+	}
+	if sse, _ := parseSSEMeta([]byte(multipartInfo.Extend[XAttrKeyOSSSSE])); sse != nil {
+		sse.SetResponseHeader(w.Header())
+	}
+	o.notify(param, vol, EventObjectCreatedCompleteMultipartUpload, &notificationObject{
+		Key:       param.Object(),
+		Size:      fsFileInfo.Size,
+		ETag:      fsFileInfo.ETag,
+		VersionID: fsFileInfo.VersionId,
+	})
 	completeResult := CompleteMultipartResult{
-		Bucket: param.Bucket(),
-		Key:    param.Object(),
-		ETag:   wrapUnescapedQuot(fsFileInfo.ETag),
+		Bucket:         param.Bucket(),
+		Key:            param.Object(),
+		ETag:           wrapUnescapedQuot(fsFileInfo.ETag),
+		ChecksumCRC32:  fsFileInfo.Checksum.valueOf(ChecksumAlgorithmCRC32),
+		ChecksumCRC32C: fsFileInfo.Checksum.valueOf(ChecksumAlgorithmCRC32C),
+		ChecksumSHA1:   fsFileInfo.Checksum.valueOf(ChecksumAlgorithmSHA1),
+		ChecksumSHA256: fsFileInfo.Checksum.valueOf(ChecksumAlgorithmSHA256),
 	}
 	response, ierr := MarshalXMLEntity(completeResult)
 	if ierr != nil {
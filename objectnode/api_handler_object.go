@@ -20,6 +20,7 @@ import (
 	"encoding/xml"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/url"
 	"regexp"
@@ -84,9 +85,21 @@ func (o *ObjectNode) getObjectHandler(w http.ResponseWriter, r *http.Request) {
 		rangeUpper  uint64
 		partSize    uint64
 		partCount   uint64
+		rangeSpecs  []byteRangeSpec
 	)
 	rangeOpt := strings.TrimSpace(r.Header.Get(Range))
-	if len(rangeOpt) > 0 {
+	if strings.Contains(rangeOpt, ",") {
+		// several ranges are returned in the multipart/byteranges body
+		if rangeSpecs, err = parseByteRangeSpecs(rangeOpt); err != nil {
+			log.LogErrorf("getObjectHandler: invalid range header: requestID(%v) volume(%v) path(%v) rangeOpt(%v)",
+				GetRequestID(r), param.Bucket(), param.Object(), rangeOpt)
+			return
+		}
+		if len(r.URL.Query().Get(ParamPartNumber)) > 0 {
+			errorCode = InvalidArgument
+			return
+		}
+	} else if len(rangeOpt) > 0 {
 		if !rangeRegexp.MatchString(rangeOpt) {
 			log.LogErrorf("getObjectHandler: invalid range header: requestID(%v) volume(%v) path(%v) rangeOpt(%v)",
 				GetRequestID(r), param.Bucket(), param.Object(), rangeOpt)
@@ -155,11 +168,13 @@ func (o *ObjectNode) getObjectHandler(w http.ResponseWriter, r *http.Request) {
 
 	// get object meta
 	start := time.Now()
-	fileInfo, xattr, err := vol.ObjectMeta(param.Object())
+	versionId := r.URL.Query().Get(ParamVersionId)
+	fileInfo, xattr, err := vol.ObjectVersionMeta(param.Object(), versionId)
 	span.AppendTrackLog("meta.r", start, err)
 	if err != nil {
-		log.LogErrorf("getObjectHandler: get file meta fail: requestId(%v) volume(%v) path(%v) err(%v)",
-			GetRequestID(r), vol.Name(), param.Object(), err)
+		log.LogErrorf("getObjectHandler: get file meta fail: requestId(%v) volume(%v) path(%v) versionId(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), versionId, err)
+		setDeleteMarkerHeader(w, vol, param.Object(), versionId, err)
 		if err == syscall.ENOENT {
 			errorCode = NoSuchKey
 		}
@@ -172,6 +187,28 @@ func (o *ObjectNode) getObjectHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// check server-side encryption
+	sse, dataKey, err := checkObjectSSE(w, r, xattr)
+	if err != nil {
+		log.LogErrorf("getObjectHandler: check sse fail: requestId(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+
+	// the single satisfiable range of the ranges is returned as the range read
+	var byteRanges []byteRange
+	if len(rangeSpecs) > 0 {
+		if byteRanges = resolveByteRanges(rangeSpecs, uint64(fileInfo.Size)); len(byteRanges) == 0 {
+			errorCode = InvalidRange
+			return
+		}
+		if len(byteRanges) == 1 {
+			isRangeRead = true
+			rangeLower, rangeUpper = byteRanges[0].start, byteRanges[0].start+byteRanges[0].length-1
+			byteRanges = nil
+		}
+	}
+
 	// validate and fix range
 	if isRangeRead && rangeUpper > uint64(fileInfo.Size)-1 {
 		rangeUpper = uint64(fileInfo.Size) - 1
@@ -229,9 +266,17 @@ func (o *ObjectNode) getObjectHandler(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set(Expires, fileInfo.Expires)
 	}
 	if len(fileInfo.RetainUntilDate) > 0 {
-		w.Header().Set(XAmzObjectLockMode, ComplianceMode)
+		w.Header().Set(XAmzObjectLockMode, fileInfo.ObjectLockMode)
 		w.Header().Set(XAmzObjectLockRetainUntilDate, fileInfo.RetainUntilDate)
 	}
+	if len(fileInfo.LegalHold) > 0 {
+		w.Header().Set(XAmzObjectLockLegalHold, fileInfo.LegalHold)
+	}
+	if len(fileInfo.VersionId) > 0 {
+		w.Header().Set(XAmzVersionId, fileInfo.VersionId)
+	}
+	setReplicationStatusHeader(w, xattr)
+	setRestoreHeader(w, fileInfo, xattr)
 
 	// check request is whether contain param : partNumber
 	partNumber := r.URL.Query().Get(ParamPartNumber)
@@ -268,6 +313,23 @@ func (o *ObjectNode) getObjectHandler(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set(ContentRange, fmt.Sprintf("bytes %d-%d/%d", rangeLower, rangeUpper, fileInfo.Size))
 		}
 	}
+	var (
+		boundary        string
+		partContentType string
+		rangesSize      uint64
+	)
+	if len(byteRanges) > 0 {
+		boundary = multipart.NewWriter(nil).Boundary()
+		partContentType = w.Header().Get(ContentType)
+		w.Header().Set(ContentType, ValueMultipartByteRanges+"; boundary="+boundary)
+		rangesSize = byteRangesSize(boundary, partContentType, uint64(fileInfo.Size), byteRanges)
+		w.Header().Set(ContentLength, strconv.FormatUint(rangesSize, 10))
+	}
+
+	// the checksum is only returned for the whole object
+	if !isRangeRead && len(partNumber) == 0 && len(byteRanges) == 0 {
+		setChecksumResponseHeader(w, r, xattr)
+	}
 
 	// User-defined metadata
 	for name, value := range fileInfo.Metadata {
@@ -278,6 +340,12 @@ func (o *ObjectNode) getObjectHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the object in blobstore is read from the restored copy if there is
+	inode, storageClass := fileInfo.Inode, fileInfo.StorageClass
+	if restore := restoredCopy(xattr); restore != nil && proto.IsStorageClassBlobStore(storageClass) {
+		inode, storageClass = restore.Inode, restore.StorageClass
+	}
+
 	// get object content
 	offset := rangeLower
 	size, err := safeConvertInt64ToUint64(fileInfo.Size)
@@ -285,6 +353,31 @@ func (o *ObjectNode) getObjectHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		return
 	}
+	if len(byteRanges) > 0 {
+		w.WriteHeader(http.StatusPartialContent)
+		var writer io.Writer = w
+		if rangesSize > DefaultFlowLimitSize {
+			writer = rateLimit.GetResponseWriter(vol.owner, param.apiName, w)
+		}
+		start = time.Now()
+		err = writeByteRanges(writer, boundary, partContentType, fileSize, byteRanges,
+			func(pw io.Writer, offset, length uint64) error {
+				// only the range is decrypted
+				if sse != nil {
+					pw = sse.decryptWriter(pw, dataKey, offset)
+				}
+				return vol.readFile(inode, fileSize, param.Object(), pw, offset, length, storageClass)
+			})
+		span.AppendTrackLog("file.r", start, err)
+		if err != nil {
+			log.LogErrorf("getObjectHandler: read ranges fail: requestID(%v) volume(%v) path(%v) ranges(%v) err(%v)",
+				GetRequestID(r), param.Bucket(), param.Object(), rangeOpt, err)
+			if err == syscall.ENOENT {
+				errorCode = NoSuchKey
+			}
+		}
+		return
+	}
 	if isRangeRead || len(partNumber) > 0 {
 		size = rangeUpper - rangeLower + 1
 		w.WriteHeader(http.StatusPartialContent)
@@ -298,9 +391,14 @@ func (o *ObjectNode) getObjectHandler(w http.ResponseWriter, r *http.Request) {
 		writer = w
 	}
 
+	// only the requested range is decrypted
+	if sse != nil {
+		writer = sse.decryptWriter(writer, dataKey, offset)
+	}
+
 	// read file
 	start = time.Now()
-	err = vol.readFile(fileInfo.Inode, fileSize, param.Object(), writer, offset, size, fileInfo.StorageClass)
+	err = vol.readFile(inode, fileSize, param.Object(), writer, offset, size, storageClass)
 	span.AppendTrackLog("file.r", start, err)
 	if err != nil {
 		log.LogErrorf("getObjectHandler: read file fail: requestID(%v) volume(%v) path(%v) offset(%v) size(%v) err(%v)",
@@ -408,17 +506,26 @@ func (o *ObjectNode) headObjectHandler(w http.ResponseWriter, r *http.Request) {
 
 	// get object meta
 	start := time.Now()
-	fileInfo, _, err := vol.ObjectMeta(param.Object())
+	versionId := r.URL.Query().Get(ParamVersionId)
+	fileInfo, xattr, err := vol.ObjectVersionMeta(param.Object(), versionId)
 	span.AppendTrackLog("meta.r", start, err)
 	if err != nil {
-		log.LogErrorf("headObjectHandler: get file meta fail: requestId(%v) volume(%v) path(%v) err(%v)",
-			GetRequestID(r), vol.Name(), param.Object(), err)
+		log.LogErrorf("headObjectHandler: get file meta fail: requestId(%v) volume(%v) path(%v) versionId(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), versionId, err)
+		setDeleteMarkerHeader(w, vol, param.Object(), versionId, err)
 		if err == syscall.ENOENT {
 			errorCode = NoSuchKey
 		}
 		return
 	}
 
+	// check server-side encryption
+	if _, _, err = checkObjectSSE(w, r, xattr); err != nil {
+		log.LogErrorf("headObjectHandler: check sse fail: requestId(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+
 	// parse request header
 	match := r.Header.Get(IfMatch)
 	noneMatch := r.Header.Get(IfNoneMatch)
@@ -497,9 +604,17 @@ func (o *ObjectNode) headObjectHandler(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set(Expires, fileInfo.Expires)
 	}
 	if len(fileInfo.RetainUntilDate) > 0 {
-		w.Header().Set(XAmzObjectLockMode, ComplianceMode)
+		w.Header().Set(XAmzObjectLockMode, fileInfo.ObjectLockMode)
 		w.Header().Set(XAmzObjectLockRetainUntilDate, fileInfo.RetainUntilDate)
 	}
+	if len(fileInfo.LegalHold) > 0 {
+		w.Header().Set(XAmzObjectLockLegalHold, fileInfo.LegalHold)
+	}
+	if len(fileInfo.VersionId) > 0 {
+		w.Header().Set(XAmzVersionId, fileInfo.VersionId)
+	}
+	setReplicationStatusHeader(w, xattr)
+	setRestoreHeader(w, fileInfo, xattr)
 
 	// check request is whether contain param : partNumber
 	partNumber := r.URL.Query().Get(ParamPartNumber)
@@ -528,6 +643,7 @@ func (o *ObjectNode) headObjectHandler(w http.ResponseWriter, r *http.Request) {
 		if len(fileInfo.ETag) > 0 {
 			w.Header()[ETag] = []string{wrapUnescapedQuot(fileInfo.ETag)}
 		}
+		setChecksumResponseHeader(w, r, xattr)
 	}
 
 	// User-defined metadata
@@ -632,6 +748,8 @@ func (o *ObjectNode) deleteObjectsHandler(w http.ResponseWriter, r *http.Request
 		allowByAcl = true
 	}
 
+	// only the bucket owner is allowed to bypass the retention in GOVERNANCE mode
+	bypassGovernance := isBypassGovernance(r.Header) && userInfo.UserID == vol.owner
 	deletedObjects := make([]Deleted, 0, len(deleteReq.Objects))
 	deletedErrors := make([]Error, 0)
 	start := time.Now()
@@ -661,16 +779,27 @@ func (o *ObjectNode) deleteObjectsHandler(w http.ResponseWriter, r *http.Request
 		if err = rateLimit.AcquireLimitResource(vol.owner, DELETE_OBJECT); err != nil {
 			return
 		}
-		if err1 := vol.DeletePath(object.Key); err1 != nil {
-			log.LogErrorf("deleteObjectsHandler: delete object failed: requestID(%v) volume(%v) path(%v) err(%v)",
-				GetRequestID(r), vol.Name(), object.Key, err1)
-			if !strings.Contains(err1.Error(), AccessDenied.ErrorMessage) {
-				deletedErrors = append(deletedErrors, Error{Key: object.Key, Code: "InternalError", Message: err1.Error()})
+		isDeleteMarker, versionId, err1 := vol.DeleteObjectVersion(object.Key, object.VersionId, bypassGovernance)
+		if err1 != nil {
+			log.LogErrorf("deleteObjectsHandler: delete object failed: requestID(%v) volume(%v) path(%v) versionId(%v) err(%v)",
+				GetRequestID(r), vol.Name(), object.Key, object.VersionId, err1)
+			if ec, ok := err1.(*ErrorCode); ok {
+				deletedErrors = append(deletedErrors, Error{Key: object.Key, VersionId: object.VersionId, Code: ec.ErrorCode, Message: ec.ErrorMessage})
+			} else if !strings.Contains(err1.Error(), AccessDenied.ErrorMessage) {
+				deletedErrors = append(deletedErrors, Error{Key: object.Key, VersionId: object.VersionId, Code: "InternalError", Message: err1.Error()})
 			} else {
-				deletedErrors = append(deletedErrors, Error{Key: object.Key, Code: "AccessDenied", Message: err1.Error()})
+				deletedErrors = append(deletedErrors, Error{Key: object.Key, VersionId: object.VersionId, Code: "AccessDenied", Message: err1.Error()})
 			}
 		} else {
-			deletedObjects = append(deletedObjects, Deleted{Key: object.Key})
+			deleted := Deleted{Key: object.Key, VersionId: object.VersionId}
+			event := EventObjectRemovedDelete
+			if isDeleteMarker {
+				deleted.DeleteMarker = "true"
+				deleted.DeleteMarkerVersionId = versionId
+				event = EventObjectRemovedDeleteMarkerCreated
+			}
+			deletedObjects = append(deletedObjects, deleted)
+			o.notify(param, vol, event, &notificationObject{Key: object.Key, VersionID: versionId})
 		}
 		rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
 	}
@@ -781,7 +910,7 @@ func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	// parse x-amz-copy-source header
-	sourceBucket, sourceObject, _, err := extractSrcBucketKey(r)
+	sourceBucket, sourceObject, sourceVersionId, err := extractSrcBucketKey(r)
 	if err != nil {
 		log.LogErrorf("copyObjectHandler: copySource(%v) argument invalid: requestID(%v) volume(%v) err(%v)",
 			r.Header.Get(XAmzCopySource), GetRequestID(r), param.Bucket(), err)
@@ -801,6 +930,11 @@ func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
 			GetRequestID(r), param.Bucket(), acl, err)
 		return
 	}
+	if err = checkPublicACL(vol, acl); err != nil {
+		log.LogErrorf("copyObjectHandler: check public acl fail: requestID(%v) volume(%v) acl(%+v) err(%v)",
+			GetRequestID(r), param.Bucket(), acl, err)
+		return
+	}
 
 	// get src object meta
 	var sourceVol *Volume
@@ -812,14 +946,17 @@ func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
 
 	// get object meta
 	start := time.Now()
-	fileInfo, _, err := sourceVol.ObjectMeta(sourceObject)
+	fileInfo, _, err := sourceVol.ObjectVersionMeta(sourceObject, sourceVersionId)
 	span.AppendTrackLog("meta.r", start, err)
 	if err != nil {
-		log.LogErrorf("copyObjectHandler: get object meta fail: requestID(%v) srcVolume(%v) srcObject(%v) err(%v)",
-			GetRequestID(r), sourceBucket, sourceObject, err)
+		log.LogErrorf("copyObjectHandler: get object meta fail: requestID(%v) srcVolume(%v) srcObject(%v) srcVersionId(%v) err(%v)",
+			GetRequestID(r), sourceBucket, sourceObject, sourceVersionId, err)
 		if err == syscall.ENOENT {
 			errorCode = NoSuchKey
 		}
+		if err == MethodNotAllowed {
+			errorCode = InvalidArgument
+		}
 		return
 	}
 	if fileInfo.Size > SinglePutLimit {
@@ -884,22 +1021,57 @@ func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
 			GetRequestID(r), param.Bucket(), err)
 		return
 	}
+	var lockOpt *ObjectLockOption
+	if lockOpt, err = ParseObjectLockOption(r.Header, objetLock); err != nil {
+		log.LogErrorf("copyObjectHandler: parse object lock fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), param.Object(), err)
+		return
+	}
 
 	// parse user-defined metadata
 	metadata := ParseUserDefinedMetadata(r.Header)
 
+	// parse server-side encryption of the target and the source object
+	var sse, sourceSSE *SSEOption
+	if sse, err = parseWriteSSEOption(r.Header, vol); err != nil {
+		log.LogErrorf("copyObjectHandler: parse sse fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+	if sourceSSE, err = ParseSSECustomerOption(r.Header, true); err != nil {
+		log.LogErrorf("copyObjectHandler: parse copy source sse fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+	// the checksum of the target object is computed with the specified algorithm
+	var checksumAlgorithm string
+	if checksumAlgorithm, err = parseChecksumAlgorithm(r.Header); err != nil {
+		return
+	}
+	var checksumOpt *ChecksumOption
+	if checksumAlgorithm != "" {
+		checksumOpt = &ChecksumOption{Algorithm: checksumAlgorithm}
+	}
+
 	// copy file
 	opt := &PutFileOption{
-		MIMEType:     contentType,
-		Disposition:  contentDisposition,
-		Metadata:     metadata,
-		CacheControl: cacheControl,
-		Expires:      expires,
-		ACL:          acl,
-		ObjectLock:   objetLock,
+		MIMEType:      contentType,
+		Disposition:   contentDisposition,
+		Metadata:      metadata,
+		CacheControl:  cacheControl,
+		Expires:       expires,
+		ACL:           acl,
+		ObjectLock:    objetLock,
+		LockOption:    lockOpt,
+		SSE:           sse,
+		CopySourceSSE: sourceSSE,
+		Checksum:      checksumOpt,
+	}
+	if err = vol.checkBucketQuota(uint64(fileInfo.Size), 1); err != nil {
+		return
 	}
 	start = time.Now()
-	fsFileInfo, err := vol.CopyFile(sourceVol, sourceObject, param.Object(), metadataDirective, opt)
+	fsFileInfo, err := vol.CopyFile(sourceVol, sourceObject, sourceVersionId, param.Object(), metadataDirective, opt)
 	span.AppendTrackLog("file.c", start, err)
 	if err != nil && err != syscall.EINVAL && err != syscall.EFBIG {
 		log.LogErrorf("copyObjectHandler: Volume copy file fail: requestID(%v) Volume(%v) source(%v) target(%v) err(%v)",
@@ -919,9 +1091,27 @@ func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if len(fileInfo.VersionId) > 0 {
+		w.Header().Set(XAmzCopySourceVersionId, fileInfo.VersionId)
+	}
+	if len(fsFileInfo.VersionId) > 0 {
+		w.Header().Set(XAmzVersionId, fsFileInfo.VersionId)
+	}
+	sse.SetResponseHeader(w.Header())
+	o.notify(param, vol, EventObjectCreatedCopy, &notificationObject{
+		Key:       param.Object(),
+		Size:      fsFileInfo.Size,
+		ETag:      fsFileInfo.ETag,
+		VersionID: fsFileInfo.VersionId,
+	})
+
 	copyResult := CopyResult{
-		ETag:         "\"" + fsFileInfo.ETag + "\"",
-		LastModified: formatTimeISO(fsFileInfo.ModifyTime),
+		ETag:           "\"" + fsFileInfo.ETag + "\"",
+		LastModified:   formatTimeISO(fsFileInfo.ModifyTime),
+		ChecksumCRC32:  fsFileInfo.Checksum.valueOf(ChecksumAlgorithmCRC32),
+		ChecksumCRC32C: fsFileInfo.Checksum.valueOf(ChecksumAlgorithmCRC32C),
+		ChecksumSHA1:   fsFileInfo.Checksum.valueOf(ChecksumAlgorithmSHA1),
+		ChecksumSHA256: fsFileInfo.Checksum.valueOf(ChecksumAlgorithmSHA256),
 	}
 	response, ierr := MarshalXMLEntity(copyResult)
 	if ierr != nil {
@@ -1290,7 +1480,13 @@ func (o *ObjectNode) putObjectHandler(w http.ResponseWriter, r *http.Request) {
 			GetRequestID(r), param.Bucket(), err)
 		return
 	}
-	if objetLock != nil && objetLock.ToRetention() != nil && requestMD5 == "" {
+	var lockOpt *ObjectLockOption
+	if lockOpt, err = ParseObjectLockOption(r.Header, objetLock); err != nil {
+		log.LogErrorf("putObjectHandler: parse object lock fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), param.Object(), err)
+		return
+	}
+	if (objetLock != nil && objetLock.ToRetention() != nil || lockOpt != nil) && requestMD5 == "" {
 		errorCode = NoContentMd5HeaderErr
 		return
 	}
@@ -1331,6 +1527,11 @@ func (o *ObjectNode) putObjectHandler(w http.ResponseWriter, r *http.Request) {
 			GetRequestID(r), vol.Name(), param.Object(), acl, err)
 		return
 	}
+	if err = checkPublicACL(vol, acl); err != nil {
+		log.LogErrorf("putObjectHandler: check public acl fail: requestID(%v) volume(%v) path(%v) acl(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), acl, err)
+		return
+	}
 
 	// Verify ContentLength
 	length := GetContentLength(r)
@@ -1375,6 +1576,30 @@ func (o *ObjectNode) putObjectHandler(w http.ResponseWriter, r *http.Request) {
 		reader = r.Body
 	}
 
+	// Server-side encryption
+	var sse *SSEOption
+	if sse, err = parseWriteSSEOption(r.Header, vol); err != nil {
+		log.LogErrorf("putObjectHandler: parse sse fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+
+	// Additional checksum
+	var checksumOpt *ChecksumOption
+	if checksumOpt, err = parseChecksumOption(r); err != nil {
+		log.LogErrorf("putObjectHandler: parse checksum fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+
+	// Conditional write
+	var cond *WriteCondition
+	if cond, err = parseWriteCondition(r); err != nil {
+		log.LogErrorf("putObjectHandler: parse precondition fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+
 	// Put Object
 	opt := &PutFileOption{
 		MIMEType:     contentType,
@@ -1385,6 +1610,15 @@ func (o *ObjectNode) putObjectHandler(w http.ResponseWriter, r *http.Request) {
 		Expires:      expires,
 		ACL:          acl,
 		ObjectLock:   objetLock,
+		LockOption:   lockOpt,
+		SSE:          sse,
+		// the object replicated from another cluster is marked as replica
+		ReplicationStatus: o.replicaStatus(r, param.AccessKey()),
+		Checksum:          checksumOpt,
+		Condition:         cond,
+	}
+	if err = vol.checkBucketQuota(uint64(length), 1); err != nil {
+		return
 	}
 	start := time.Now()
 	fsFileInfo, err := vol.PutObject(param.Object(), reader, opt)
@@ -1406,6 +1640,18 @@ func (o *ObjectNode) putObjectHandler(w http.ResponseWriter, r *http.Request) {
 
 	// set response header
 	w.Header()[ETag] = []string{wrapUnescapedQuot(fsFileInfo.ETag)}
+	if len(fsFileInfo.VersionId) > 0 {
+		w.Header().Set(XAmzVersionId, fsFileInfo.VersionId)
+	}
+	sse.SetResponseHeader(w.Header())
+	fsFileInfo.Checksum.SetResponseHeader(w.Header())
+
+	o.notify(param, vol, EventObjectCreatedPut, &notificationObject{
+		Key:       param.Object(),
+		Size:      fsFileInfo.Size,
+		ETag:      fsFileInfo.ETag,
+		VersionID: fsFileInfo.VersionId,
+	})
 }
 
 // Post object
@@ -1506,6 +1752,11 @@ func (o *ObjectNode) postObjectHandler(w http.ResponseWriter, r *http.Request) {
 			errorCode.ErrorMessage = fmt.Sprintf("%s (%v)", errorCode.ErrorMessage, err)
 			return
 		}
+		if err = checkPublicACL(vol, aclInfo); err != nil {
+			log.LogErrorf("postObjectHandler: check public acl fail: requestID(%v) volume(%v) acl(%v) err(%v)",
+				GetRequestID(r), param.Bucket(), acl, err)
+			return
+		}
 	}
 
 	var tagging *Tagging
@@ -1603,6 +1854,39 @@ func (o *ObjectNode) postObjectHandler(w http.ResponseWriter, r *http.Request) {
 		reader = f
 	}
 
+	// server-side encryption specified by form fields
+	sseHeader := make(http.Header)
+	for name, value := range forms {
+		if strings.HasPrefix(name, XAmzServerSideEncryption) {
+			sseHeader.Set(name, value)
+		}
+	}
+	var sse *SSEOption
+	if sse, err = parseWriteSSEOption(sseHeader, vol); err != nil {
+		log.LogErrorf("postObjectHandler: parse sse fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), key, err)
+		return
+	}
+
+	// object lock specified by form fields
+	lockHeader := make(http.Header)
+	for _, name := range []string{XAmzObjectLockMode, XAmzObjectLockRetainUntilDate, XAmzObjectLockLegalHold} {
+		if value, ok := forms[strings.ToLower(name)]; ok {
+			lockHeader.Set(name, value)
+		}
+	}
+	var lockOpt *ObjectLockOption
+	if lockOpt, err = ParseObjectLockOption(lockHeader, objetLock); err != nil {
+		log.LogErrorf("postObjectHandler: parse object lock fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), key, err)
+		return
+	}
+	if lockOpt != nil && requestMD5 == "" {
+		errorCode = MalformedPOSTRequest.Copy()
+		errorCode.ErrorMessage = fmt.Sprintf("%s (%s)", errorCode.ErrorMessage, "No Content-MD5 with Object Lock")
+		return
+	}
+
 	// put object
 	putOpt := &PutFileOption{
 		MIMEType:     contentType,
@@ -1613,6 +1897,11 @@ func (o *ObjectNode) postObjectHandler(w http.ResponseWriter, r *http.Request) {
 		Expires:      expires,
 		ACL:          aclInfo,
 		ObjectLock:   objetLock,
+		LockOption:   lockOpt,
+		SSE:          sse,
+	}
+	if err = vol.checkBucketQuota(uint64(size), 1); err != nil {
+		return
 	}
 	start := time.Now()
 	fsFileInfo, err := vol.PutObject(key, reader, putOpt)
@@ -1635,6 +1924,17 @@ func (o *ObjectNode) postObjectHandler(w http.ResponseWriter, r *http.Request) {
 	// set response header
 	etag := wrapUnescapedQuot(fsFileInfo.ETag)
 	w.Header()[ETag] = []string{etag}
+	if len(fsFileInfo.VersionId) > 0 {
+		w.Header().Set(XAmzVersionId, fsFileInfo.VersionId)
+	}
+	sse.SetResponseHeader(w.Header())
+
+	o.notify(param, vol, EventObjectCreatedPost, &notificationObject{
+		Key:       key,
+		Size:      fsFileInfo.Size,
+		ETag:      fsFileInfo.ETag,
+		VersionID: fsFileInfo.VersionId,
+	})
 
 	// return response depending on success_action_xxx parameter
 	if successRedirectURL != nil {
@@ -1718,17 +2018,28 @@ func (o *ObjectNode) deleteObjectHandler(w http.ResponseWriter, r *http.Request)
 
 	// Delete file
 	start := time.Now()
-	err = vol.DeletePath(param.Object())
+	versionId := r.URL.Query().Get(ParamVersionId)
+	bypassGovernance := o.bypassGovernance(r, param, vol)
+	isDeleteMarker, resultVersionId, err := vol.DeleteObjectVersion(param.Object(), versionId, bypassGovernance)
 	span.AppendTrackLog("file.d", start, err)
 	if err != nil {
 		log.LogErrorf("deleteObjectHandler: Volume delete file fail: "+
-			"requestID(%v) volume(%v) path(%v) err(%v)", GetRequestID(r), vol.Name(), param.Object(), err)
+			"requestID(%v) volume(%v) path(%v) versionId(%v) err(%v)", GetRequestID(r), vol.Name(), param.Object(), versionId, err)
 		if strings.Contains(err.Error(), AccessDenied.ErrorMessage) {
 			err = AccessDenied
 		}
 		return
 	}
 
+	event := EventObjectRemovedDelete
+	if isDeleteMarker {
+		w.Header().Set(XAmzDeleteMarker, "true")
+		event = EventObjectRemovedDeleteMarkerCreated
+	}
+	if len(resultVersionId) > 0 {
+		w.Header().Set(XAmzVersionId, resultVersionId)
+	}
+	o.notify(param, vol, event, &notificationObject{Key: param.Object(), VersionID: resultVersionId})
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -1866,6 +2177,8 @@ func (o *ObjectNode) putObjectTaggingHandler(w http.ResponseWriter, r *http.Requ
 		}
 		return
 	}
+
+	o.notify(param, vol, EventObjectTaggingPut, &notificationObject{Key: param.Object()})
 }
 
 // Delete object tagging
@@ -1916,6 +2229,7 @@ func (o *ObjectNode) deleteObjectTaggingHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	o.notify(param, vol, EventObjectTaggingDelete, &notificationObject{Key: param.Object()})
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -2209,30 +2523,30 @@ func (o *ObjectNode) getObjectRetentionHandler(w http.ResponseWriter, r *http.Re
 
 	// get object meta
 	start := time.Now()
-	_, xattrs, err := vol.ObjectMeta(param.Object())
+	versionId := r.URL.Query().Get(ParamVersionId)
+	_, xattrs, err := vol.ObjectVersionMeta(param.Object(), versionId)
 	span.AppendTrackLog("meta.r", start, err)
 	if err != nil {
-		log.LogErrorf("getObjectRetentionHandler: get file meta fail: requestId(%v) volume(%v) path(%v) err(%v)",
-			GetRequestID(r), vol.Name(), param.Object(), err)
+		log.LogErrorf("getObjectRetentionHandler: get file meta fail: requestId(%v) volume(%v) path(%v) versionId(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), versionId, err)
 		if err == syscall.ENOENT {
 			errorCode = NoSuchKey
 		}
 		return
 	}
-	retainUntilDate := string(xattrs.Get(XAttrKeyOSSLock))
-	if retainUntilDate == "" {
-		errorCode = NoSuchObjectLockConfiguration
-		return
-	}
-	retainUntilDateInt64, err := strconv.ParseInt(retainUntilDate, 10, 64)
+	lockInfo, err := parseObjectLockInfo(xattrs)
 	if err != nil {
 		log.LogErrorf("getObjectRetentionHandler: parse retainUntilDate fail: requestId(%v) volume(%v) path(%v) err(%v)",
 			GetRequestID(r), vol.Name(), param.Object(), err)
 		return
 	}
+	if lockInfo.RetainUntilDate == 0 {
+		errorCode = NoSuchObjectLockConfiguration
+		return
+	}
 	var objectRetention ObjectRetention
-	objectRetention.Mode = ComplianceMode
-	objectRetention.RetainUntilDate = RetentionDate{Time: time.Unix(0, retainUntilDateInt64).UTC()}
+	objectRetention.Mode = lockInfo.Mode
+	objectRetention.RetainUntilDate = RetentionDate{Time: time.Unix(0, lockInfo.RetainUntilDate).UTC()}
 	b, err := xml.Marshal(objectRetention)
 	if err != nil {
 		log.LogErrorf("getObjectRetentionHandler: xml marshal fail: requestId(%v) volume(%v) result(%v) err(%v)",
@@ -2243,6 +2557,263 @@ func (o *ObjectNode) getObjectRetentionHandler(w http.ResponseWriter, r *http.Re
 	writeSuccessResponseXML(w, b)
 }
 
+// PutObjectRetention
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutObjectRetention.html
+func (o *ObjectNode) putObjectRetentionHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+
+	span := trace.SpanFromContextSafe(r.Context())
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	// check args
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	if param.Object() == "" {
+		errorCode = InvalidKey
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("putObjectRetentionHandler: load volume fail: requestID(%v) err(%v)",
+			GetRequestID(r), err)
+		return
+	}
+
+	var objectLock *ObjectLockConfig
+	if objectLock, err = vol.metaLoader.loadObjectLock(); err != nil {
+		log.LogErrorf("putObjectRetentionHandler: load volume objectLock fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if !objectLock.Enabled() {
+		errorCode = ObjectLockNotEnabled
+		return
+	}
+
+	var body []byte
+	if body, err = io.ReadAll(io.LimitReader(r.Body, MaxObjectLockSize+1)); err != nil {
+		log.LogErrorf("putObjectRetentionHandler: read request body fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if len(body) > MaxObjectLockSize {
+		errorCode = EntityTooLarge
+		return
+	}
+	var retention *ObjectRetention
+	if retention, err = ParseObjectRetentionFromXML(body); err != nil {
+		log.LogErrorf("putObjectRetentionHandler: parse retention fail: requestID(%v) volume(%v) retention(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), err)
+		return
+	}
+
+	// get object meta
+	start := time.Now()
+	versionId := r.URL.Query().Get(ParamVersionId)
+	fileInfo, xattrs, err := vol.ObjectVersionMeta(param.Object(), versionId)
+	span.AppendTrackLog("meta.r", start, err)
+	if err != nil {
+		log.LogErrorf("putObjectRetentionHandler: get file meta fail: requestId(%v) volume(%v) path(%v) versionId(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), versionId, err)
+		if err == syscall.ENOENT {
+			errorCode = NoSuchKey
+		}
+		return
+	}
+	lockInfo, err := parseObjectLockInfo(xattrs)
+	if err != nil {
+		log.LogErrorf("putObjectRetentionHandler: parse object lock fail: requestId(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+	if err = lockInfo.checkRetentionUpdate(retention, o.bypassGovernance(r, param, vol)); err != nil {
+		log.LogWarnf("putObjectRetentionHandler: retention cannot be updated: requestId(%v) volume(%v) path(%v) "+
+			"mode(%v) retainUntilDate(%v) newRetention(%v)", GetRequestID(r), vol.Name(), param.Object(),
+			lockInfo.Mode, lockInfo.RetainUntilDate, string(body))
+		return
+	}
+	if err = vol.setObjectRetention(fileInfo.Inode, retention); err != nil {
+		log.LogErrorf("putObjectRetentionHandler: set retention fail: requestId(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+
+	log.LogInfof("Audit: put object retention: requestID(%v) volume(%v) path(%v) versionId(%v) retention(%v)",
+		GetRequestID(r), vol.Name(), param.Object(), versionId, string(body))
+}
+
+// GetObjectLegalHold
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObjectLegalHold.html
+func (o *ObjectNode) getObjectLegalHoldHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+
+	span := trace.SpanFromContextSafe(r.Context())
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	// check args
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	if param.Object() == "" {
+		errorCode = InvalidKey
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("getObjectLegalHoldHandler: load volume fail: requestID(%v) err(%v)",
+			GetRequestID(r), err)
+		return
+	}
+
+	var objectLock *ObjectLockConfig
+	if objectLock, err = vol.metaLoader.loadObjectLock(); err != nil {
+		log.LogErrorf("getObjectLegalHoldHandler: load volume objectLock fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if !objectLock.Enabled() {
+		errorCode = ObjectLockNotEnabled
+		return
+	}
+
+	// get object meta
+	start := time.Now()
+	versionId := r.URL.Query().Get(ParamVersionId)
+	fileInfo, _, err := vol.ObjectVersionMeta(param.Object(), versionId)
+	span.AppendTrackLog("meta.r", start, err)
+	if err != nil {
+		log.LogErrorf("getObjectLegalHoldHandler: get file meta fail: requestId(%v) volume(%v) path(%v) versionId(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), versionId, err)
+		if err == syscall.ENOENT {
+			errorCode = NoSuchKey
+		}
+		return
+	}
+	legalHold := ObjectLegalHold{XMLNS: XMLNS, Status: LegalHoldOff}
+	if fileInfo.LegalHold == LegalHoldOn {
+		legalHold.Status = LegalHoldOn
+	}
+	var data []byte
+	if data, err = MarshalXMLEntity(legalHold); err != nil {
+		log.LogErrorf("getObjectLegalHoldHandler: xml marshal fail: requestId(%v) volume(%v) result(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), legalHold, err)
+		return
+	}
+
+	writeSuccessResponseXML(w, data)
+}
+
+// PutObjectLegalHold
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutObjectLegalHold.html
+func (o *ObjectNode) putObjectLegalHoldHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+
+	span := trace.SpanFromContextSafe(r.Context())
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	// check args
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	if param.Object() == "" {
+		errorCode = InvalidKey
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("putObjectLegalHoldHandler: load volume fail: requestID(%v) err(%v)",
+			GetRequestID(r), err)
+		return
+	}
+
+	var objectLock *ObjectLockConfig
+	if objectLock, err = vol.metaLoader.loadObjectLock(); err != nil {
+		log.LogErrorf("putObjectLegalHoldHandler: load volume objectLock fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if !objectLock.Enabled() {
+		errorCode = ObjectLockNotEnabled
+		return
+	}
+
+	var body []byte
+	if body, err = io.ReadAll(io.LimitReader(r.Body, MaxObjectLockSize+1)); err != nil {
+		log.LogErrorf("putObjectLegalHoldHandler: read request body fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if len(body) > MaxObjectLockSize {
+		errorCode = EntityTooLarge
+		return
+	}
+	var legalHold *ObjectLegalHold
+	if legalHold, err = ParseObjectLegalHoldFromXML(body); err != nil {
+		log.LogErrorf("putObjectLegalHoldHandler: parse legal hold fail: requestID(%v) volume(%v) legalHold(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), err)
+		return
+	}
+
+	// get object meta
+	start := time.Now()
+	versionId := r.URL.Query().Get(ParamVersionId)
+	fileInfo, _, err := vol.ObjectVersionMeta(param.Object(), versionId)
+	span.AppendTrackLog("meta.r", start, err)
+	if err != nil {
+		log.LogErrorf("putObjectLegalHoldHandler: get file meta fail: requestId(%v) volume(%v) path(%v) versionId(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), versionId, err)
+		if err == syscall.ENOENT {
+			errorCode = NoSuchKey
+		}
+		return
+	}
+	if err = vol.setObjectLegalHold(fileInfo.Inode, legalHold.Status); err != nil {
+		log.LogErrorf("putObjectLegalHoldHandler: set legal hold fail: requestId(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+
+	log.LogInfof("Audit: put object legal hold: requestID(%v) volume(%v) path(%v) versionId(%v) status(%v)",
+		GetRequestID(r), vol.Name(), param.Object(), versionId, legalHold.Status)
+}
+
+// bypassGovernance reports whether the request bypasses the retention in GOVERNANCE mode,
+// which is only allowed for the owner of the bucket.
+func (o *ObjectNode) bypassGovernance(r *http.Request, param *RequestParam, vol *Volume) bool {
+	if !isBypassGovernance(r.Header) {
+		return false
+	}
+	userInfo, err := o.getUserInfoByAccessKeyV2(param.AccessKey())
+	if err != nil {
+		log.LogWarnf("bypassGovernance: get user info fail: requestID(%v) accessKey(%v) err(%v)",
+			GetRequestID(r), param.AccessKey(), err)
+		return false
+	}
+	return userInfo.UserID == vol.GetOwner()
+}
+
 func parsePartInfo(partNumber uint64, fileSize uint64) (uint64, uint64, uint64, uint64) {
 	var partSize uint64
 	var partCount uint64
@@ -0,0 +1,64 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/xml"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cubefs/cubefs/sdk/meta"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestVolume returns the volume whose bucket configs are all synced and empty, the handlers
+// must fail before the meta wrapper talks to the meta nodes.
+func newTestVolume(name string) *Volume {
+	synced := int32(1)
+	return &Volume{
+		name:       name,
+		owner:      "owner",
+		mw:         &meta.MetaWrapper{},
+		metaLoader: &cacheMetaLoader{om: new(OSSMeta), synced: &synced},
+	}
+}
+
+// newTestRouter returns the router of the node serving the volumes without the middlewares.
+func newTestRouter(vols ...*Volume) *mux.Router {
+	vm := &VolumeManager{}
+	for i := range vm.loaders {
+		vm.loaders[i] = &VolumeLoader{volumes: make(map[string]*Volume)}
+	}
+	for _, vol := range vols {
+		vm.selectLoader(vol.name).volumes[vol.name] = vol
+	}
+	o := &ObjectNode{
+		domains:   []string{"cube.io"},
+		vm:        vm,
+		rateLimit: &NullRateLimit{},
+	}
+	router := mux.NewRouter()
+	o.registerApiRouters(router)
+	return router
+}
+
+// requireErrorResponse checks the status code and the error code of the response.
+func requireErrorResponse(t *testing.T, ec *ErrorCode, w *httptest.ResponseRecorder) {
+	require.Equal(t, ec.StatusCode, w.Code)
+	var resp ErrorResponse
+	require.NoError(t, xml.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, ec.ErrorCode, resp.Code)
+}
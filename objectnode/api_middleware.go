@@ -97,6 +97,12 @@ func (o *ObjectNode) traceMiddleware(next http.Handler) http.Handler {
 			if o.externalAudit != nil {
 				o.externalAudit.Logger(w, r)
 			}
+			if o.bucketLogger != nil {
+				o.logBucketAccess(w, r)
+			}
+			if o.bucketMetrics != nil {
+				o.recordBucketMetrics(w, r)
+			}
 		}()
 
 		requestID, err := generateRequestID()
@@ -184,6 +190,40 @@ func (o *ObjectNode) authMiddleware(next http.Handler) http.Handler {
 		})
 }
 
+// SwiftAuthMiddleware returns a pre-handle middleware handler to perform the token authentication
+// and the access check of the Swift requests, which replaces the auth, ACL and policy check of S3.
+func (o *ObjectNode) swiftAuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(XTransId, GetRequestID(r))
+			w.Header().Set(XOpenstackRequestId, GetRequestID(r))
+			param := ParseRequestParam(r)
+			if param.action == proto.OSSSwiftAuthAction {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			userInfo, err := o.swiftUserInfo(r)
+			if err != nil {
+				log.LogErrorf("swiftAuthMiddleware: authenticate fail: requestID(%v) err(%v)", GetRequestID(r), err)
+				o.swiftErrorResponse(w, r, err, nil)
+				return
+			}
+			mux.Vars(r)[ContextKeyAccessKey] = userInfo.AccessKey
+			mux.Vars(r)[ContextKeyRequester] = userInfo.UserID
+
+			account := mux.Vars(r)[swiftRouteAccount]
+			if err = checkSwiftAccess(userInfo, account, param); err != nil {
+				log.LogErrorf("swiftAuthMiddleware: access denied: requestID(%v) uid(%v) account(%v) action(%v) container(%v)",
+					GetRequestID(r), userInfo.UserID, account, param.action, param.Bucket())
+				o.swiftErrorResponse(w, r, err, nil)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+}
+
 // PolicyCheckMiddleware returns a pre-handle middleware handler to process policy check.
 func (o *ObjectNode) policyCheckMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(
@@ -195,11 +235,17 @@ func (o *ObjectNode) policyCheckMiddleware(next http.Handler) http.Handler {
 // ContentMiddleware returns a middleware handler to process reader for content.
 // If the request contains the "X-amz-Decoded-Content-Length" header, it means that the data
 // in the request body is chunked. Use ChunkedReader to parse the data.
+// The signed aws-chunked body is parsed while checking the signature, and the unsigned one
+// with trailing headers is parsed here.
 func (o *ObjectNode) contentMiddleware(next http.Handler) http.Handler {
 	var handlerFunc http.HandlerFunc = func(w http.ResponseWriter, r *http.Request) {
 		if r.Header.Get(XAmzDecodedContentLength) != "" && r.Header.Get(ContentEncoding) != streamingContentEncoding {
 			r.Body = NewClosableChunkedReader(r.Body)
 			log.LogDebugf("contentMiddleware: chunk reader inited: requestID(%v)", GetRequestID(r))
+		} else if r.Header.Get(ContentEncoding) == streamingContentEncoding &&
+			r.Header.Get(XAmzContentSha256) == streamingUnsignedPayloadTrailer {
+			r.Body = NewUnsignedChunkedReader(r.Body)
+			log.LogDebugf("contentMiddleware: unsigned chunk reader inited: requestID(%v)", GetRequestID(r))
 		}
 		next.ServeHTTP(w, r)
 	}
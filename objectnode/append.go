@@ -0,0 +1,385 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"crypto/md5"
+	"encoding"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// appendDataOffset is the offset of the appended data in the temporary inode, which is beyond the
+// inline threshold so that the data is always written into the extents.
+const appendDataOffset = proto.MaxInlineDataThreshold
+
+var errInvalidAppendState = errors.New("invalid append state")
+
+type AppendOption struct {
+	// Position is the offset to append at, which must be equal to the current size of the object.
+	Position uint64
+	// The following options take effect only if the object is created by the append.
+	MIMEType     string
+	Disposition  string
+	CacheControl string
+	Expires      string
+	Metadata     map[string]string
+}
+
+// parseAppendPosition parses the position of the append from the request query.
+func parseAppendPosition(value string) (uint64, error) {
+	if value == "" {
+		return 0, InvalidArgument
+	}
+	position, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, InvalidArgument
+	}
+	return position, nil
+}
+
+// encodeAppendState encodes the internal state of the MD5 hash of the object, so that the ETag
+// of the object can be computed by continuing the hash with the appended data.
+func encodeAppendState(h hash.Hash) (string, error) {
+	marshaler, ok := h.(encoding.BinaryMarshaler)
+	if !ok {
+		return "", errInvalidAppendState
+	}
+	state, err := marshaler.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(state), nil
+}
+
+func decodeAppendState(raw string) (hash.Hash, error) {
+	state, err := hex.DecodeString(raw)
+	if err != nil {
+		return nil, errInvalidAppendState
+	}
+	h := md5.New()
+	if err = h.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+		return nil, errInvalidAppendState
+	}
+	return h, nil
+}
+
+// checkAppendable checks whether the data can be appended to the existing object. The object uploaded by
+// multipart upload has no MD5 of the whole data, the encrypted object can not be appended without
+// re-encrypting, and the data in blobstore can not be overwritten, so these objects are not appendable.
+func (v *Volume) checkAppendable(inoInfo *proto.InodeInfo, xattr *proto.XAttrInfo) error {
+	if proto.IsCold(v.volType) || proto.IsStorageClassBlobStore(inoInfo.StorageClass) {
+		return ObjectNotAppendable
+	}
+	if len(xattr.Get(XAttrKeyOSSSSE)) > 0 {
+		return ObjectNotAppendable
+	}
+	rawETag := string(xattr.Get(XAttrKeyOSSETag))
+	if len(rawETag) == 0 {
+		rawETag = string(xattr.Get(XAttrKeyOSSETagDeprecated))
+	}
+	if ParseETagValue(rawETag).PartNum > 0 {
+		return ObjectNotAppendable
+	}
+	return nil
+}
+
+// AppendObject appends the data to the end of the object, the object is created if it does not exist
+// and the position is zero. PositionNotEqualToLength is returned if the position is not equal to the
+// current size of the object.
+//
+// The data is written into a temporary inode first, and its extents are appended to the object along
+// with the new ETag only if the object has not been changed since its size was checked, so the
+// concurrent appends at the same position through any nodes are serialized by the meta partition.
+func (v *Volume) AppendObject(path string, reader io.Reader, opt *AppendOption) (fsInfo *FSFileInfo, err error) {
+	defer func() {
+		// Audit behavior
+		log.LogInfof("Audit: AppendObject: volume(%v) path(%v) position(%v) err(%v)", v.name, path, opt.Position, err)
+	}()
+
+	pathItems := NewPathIterator(path).ToSlice()
+	if len(pathItems) == 0 || pathItems[len(pathItems)-1].IsDirectory {
+		err = syscall.EINVAL
+		return
+	}
+	lastPathItem := pathItems[len(pathItems)-1]
+
+	var parentId uint64
+	if parentId, err = v.recursiveMakeDirectory(path); err != nil {
+		log.LogErrorf("AppendObject: recursive make directory fail: volume(%v) path(%v) err(%v)",
+			v.name, path, err)
+		return
+	}
+	inode, mode, err := v.mw.Lookup_ll(parentId, lastPathItem.Name)
+	if err != nil && err != syscall.ENOENT {
+		log.LogErrorf("AppendObject: lookup name fail: volume(%v) path(%v) parentInode(%v) name(%v) err(%v)",
+			v.name, path, parentId, lastPathItem.Name, err)
+		return
+	}
+	if err == syscall.ENOENT {
+		return v.createAppendableObject(path, reader, opt)
+	}
+	if os.FileMode(mode).IsDir() {
+		log.LogErrorf("AppendObject: the last name is a dir: volume(%v) path(%v) name(%v)",
+			v.name, path, lastPathItem.Name)
+		err = syscall.EINVAL
+		return
+	}
+
+	var inoInfo *proto.InodeInfo
+	if inoInfo, err = v.mw.InodeGet_ll(inode); err != nil {
+		log.LogErrorf("AppendObject: get inode fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, inode, err)
+		return
+	}
+	if inoInfo.Size != opt.Position {
+		log.LogWarnf("AppendObject: position mismatch: volume(%v) path(%v) inode(%v) size(%v) position(%v)",
+			v.name, path, inode, inoInfo.Size, opt.Position)
+		err = PositionNotEqualToLength
+		return
+	}
+
+	var xattr *proto.XAttrInfo
+	if xattr, err = v.mw.XAttrGetAll_ll(inode); err != nil {
+		log.LogErrorf("AppendObject: get xattr fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, inode, err)
+		return
+	}
+	if err = v.checkAppendable(inoInfo, xattr); err != nil {
+		log.LogWarnf("AppendObject: object not appendable: volume(%v) path(%v) inode(%v)", v.name, path, inode)
+		return
+	}
+
+	// the size and generation are checked again when the extents are appended, and the inline data of
+	// a small object is dropped by the append, so it's written into the extents along with the data
+	var (
+		inline    []byte
+		size, gen uint64
+	)
+	if inline, size, gen, err = v.mw.ReadInline(inode, 0, proto.MaxInlineDataThreshold); err != nil {
+		log.LogErrorf("AppendObject: read inline fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, inode, err)
+		return
+	}
+	if size != opt.Position {
+		log.LogWarnf("AppendObject: position mismatch: volume(%v) path(%v) inode(%v) size(%v) position(%v)",
+			v.name, path, inode, size, opt.Position)
+		err = PositionNotEqualToLength
+		return
+	}
+
+	// the state of the hash is rebuilt by reading the data if the object is not created by append
+	var md5Hash hash.Hash
+	if raw := string(xattr.Get(XAttrKeyOSSAppend)); raw != "" {
+		if md5Hash, err = decodeAppendState(raw); err != nil {
+			log.LogWarnf("AppendObject: decode append state fail: volume(%v) path(%v) inode(%v) err(%v)",
+				v.name, path, inode, err)
+		}
+	}
+	if md5Hash == nil {
+		md5Hash = md5.New()
+		if err = v.appendInodeHash(md5Hash, inode, size, nil); err != nil {
+			log.LogErrorf("AppendObject: rebuild md5 fail: volume(%v) path(%v) inode(%v) err(%v)",
+				v.name, path, inode, err)
+			return
+		}
+	}
+
+	var (
+		tempInode uint64
+		eks       []proto.ExtentKey
+		written   uint64
+	)
+	if tempInode, eks, written, err = v.writeAppendData(path, parentId, inline, size, reader, md5Hash, inoInfo.StorageClass); err != nil {
+		log.LogErrorf("AppendObject: write data fail: volume(%v) path(%v) inode(%v) position(%v) err(%v)",
+			v.name, path, inode, opt.Position, err)
+		return
+	}
+
+	modifyTime := time.Unix(time.Now().Unix(), 0)
+	etagValue := ETagValue{
+		Value: hex.EncodeToString(md5Hash.Sum(nil)),
+		TS:    modifyTime,
+	}
+	var state string
+	if state, err = encodeAppendState(md5Hash); err != nil {
+		log.LogErrorf("AppendObject: encode append state fail: volume(%v) path(%v) err(%v)", v.name, path, err)
+		v.discardAppendData(tempInode, path)
+		return
+	}
+	attrs := map[string]string{
+		XAttrKeyOSSETag:   etagValue.Encode(),
+		XAttrKeyOSSAppend: state,
+	}
+	if err = v.mw.AppendExtentKeysIf_ll(inode, size, gen, eks, inoInfo.StorageClass, modifyTime.Unix(), attrs, path); err != nil {
+		// the append may have been applied even if the response is lost, the extents must not be
+		// discarded then
+		if !v.appendCommitted(inode, eks) {
+			log.LogWarnf("AppendObject: append extents fail: volume(%v) path(%v) inode(%v) size(%v) gen(%v) err(%v)",
+				v.name, path, inode, size, gen, err)
+			v.discardAppendData(tempInode, path)
+			if err == syscall.ENOTSUP {
+				err = PositionNotEqualToLength
+			}
+			return
+		}
+		err = nil
+	}
+	// the extents belong to the object now, the temporary inode is deleted without releasing them
+	if delErr := v.mw.InodeDelete_ll(tempInode, path); delErr != nil {
+		log.LogWarnf("AppendObject: delete temp inode fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, tempInode, delErr)
+	}
+
+	// the additional checksum is computed over the data before the append
+	if _, ok := xattr.XAttrs[XAttrKeyOSSChecksum]; ok {
+		if err = v.mw.XAttrDel_ll(inode, XAttrKeyOSSChecksum); err != nil {
+			log.LogErrorf("AppendObject: delete checksum fail: volume(%v) path(%v) inode(%v) err(%v)",
+				v.name, path, inode, err)
+			return
+		}
+		delete(xattr.XAttrs, XAttrKeyOSSChecksum)
+	}
+	for key, value := range attrs {
+		xattr.XAttrs[key] = value
+	}
+	putAttrCache(&AttrItem{XAttrInfo: *xattr}, v.name)
+
+	fsInfo = &FSFileInfo{
+		Path:       path,
+		Size:       int64(size + written),
+		Mode:       os.FileMode(inoInfo.Mode),
+		CreateTime: inoInfo.CreateTime,
+		ModifyTime: modifyTime,
+		ETag:       etagValue.ETag(),
+		Inode:      inoInfo.Inode,
+	}
+	return
+}
+
+// writeAppendData writes the data appended at the offset size into a temporary inode, and returns
+// the extent keys of the data with the file offsets of the object. The data is written beyond the
+// inline threshold, so it's always written into the extents, and the inline data of the object is
+// written before it.
+func (v *Volume) writeAppendData(path string, parentId uint64, inline []byte, size uint64, reader io.Reader,
+	h hash.Hash, storageClass uint32,
+) (tempInode uint64, eks []proto.ExtentKey, written uint64, err error) {
+	var tempInodeInfo *proto.InodeInfo
+	if tempInodeInfo, err = v.mw.InodeCreate_ll(parentId, DefaultFileMode, 0, 0, nil, make([]uint64, 0), path); err != nil {
+		log.LogErrorf("writeAppendData: create temp inode fail: volume(%v) path(%v) err(%v)", v.name, path, err)
+		return
+	}
+	tempInode = tempInodeInfo.Inode
+	defer func() {
+		if err != nil {
+			v.discardAppendData(tempInode, path)
+		}
+	}()
+
+	if err = v.ec.OpenStream(tempInode, true, false, path); err != nil {
+		log.LogErrorf("writeAppendData: open stream fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, tempInode, err)
+		return
+	}
+	defer func() {
+		if closeErr := v.ec.CloseStream(tempInode); closeErr != nil {
+			log.LogErrorf("writeAppendData: close stream fail: volume(%v) inode(%v) err(%v)", v.name, tempInode, closeErr)
+		}
+	}()
+	if len(inline) > 0 {
+		prefix := make([]byte, size)
+		copy(prefix, inline)
+		if _, err = v.ec.Write(tempInode, appendDataOffset, prefix, 0, nil, storageClass, false, false); err != nil {
+			log.LogErrorf("writeAppendData: write inline data fail: volume(%v) path(%v) inode(%v) err(%v)",
+				v.name, path, tempInode, err)
+			return
+		}
+	}
+	if written, err = v.streamWriteAt(tempInode, appendDataOffset+int(size), reader, h, storageClass); err != nil {
+		return
+	}
+	if err = v.ec.Flush(tempInode); err != nil {
+		log.LogErrorf("writeAppendData: flush fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, tempInode, err)
+		return
+	}
+	if _, _, eks, err = v.mw.GetExtents(tempInode, false, false, false); err != nil {
+		log.LogErrorf("writeAppendData: get extents fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, tempInode, err)
+		return
+	}
+	for i := range eks {
+		eks[i].FileOffset -= appendDataOffset
+	}
+	return
+}
+
+// appendCommitted checks whether the extents have been appended to the object.
+func (v *Volume) appendCommitted(inode uint64, eks []proto.ExtentKey) bool {
+	if len(eks) == 0 {
+		return false
+	}
+	_, _, extents, err := v.mw.GetExtents(inode, false, false, false)
+	if err != nil {
+		return false
+	}
+	for _, ek := range extents {
+		if ek.FileOffset == eks[0].FileOffset && ek.PartitionId == eks[0].PartitionId &&
+			ek.ExtentId == eks[0].ExtentId && ek.ExtentOffset == eks[0].ExtentOffset {
+			return true
+		}
+	}
+	return false
+}
+
+// discardAppendData removes the temporary inode along with the data written into it.
+func (v *Volume) discardAppendData(tempInode uint64, path string) {
+	log.LogWarnf("discardAppendData: unlink temp inode: volume(%v) path(%v) inode(%v)", v.name, path, tempInode)
+	_, _ = v.mw.InodeUnlink_ll(tempInode, path)
+	log.LogWarnf("discardAppendData: evict temp inode: volume(%v) path(%v) inode(%v)", v.name, path, tempInode)
+	_ = v.mw.Evict(tempInode, path)
+}
+
+// createAppendableObject creates the object by the first append. The object is created only if it
+// still does not exist, otherwise the position zero is not equal to the size of the object created
+// by others.
+func (v *Volume) createAppendableObject(path string, reader io.Reader, opt *AppendOption) (fsInfo *FSFileInfo, err error) {
+	if opt.Position != 0 {
+		err = PositionNotEqualToLength
+		return
+	}
+	putOpt := &PutFileOption{
+		MIMEType:     opt.MIMEType,
+		Disposition:  opt.Disposition,
+		CacheControl: opt.CacheControl,
+		Expires:      opt.Expires,
+		Metadata:     opt.Metadata,
+		Condition:    &WriteCondition{IfNoneMatch: true},
+		Appendable:   true,
+	}
+	if fsInfo, err = v.PutObject(path, reader, putOpt); err == PreconditionFailed {
+		err = PositionNotEqualToLength
+	}
+	return
+}
@@ -0,0 +1,175 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cubefs/cubefs/blobstore/common/trace"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// Append object
+// Notes: compatible with the AppendObject of OSS, the data is appended at the position which must be
+// equal to the current size of the object, and the next position is returned in the response header.
+func (o *ObjectNode) appendObjectHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+
+	span := trace.SpanFromContextSafe(r.Context())
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	if param.Object() == "" || strings.HasSuffix(param.Object(), pathSep) {
+		errorCode = InvalidKey
+		return
+	}
+	if len(param.Object()) > MaxKeyLength {
+		errorCode = KeyTooLong
+		return
+	}
+
+	var position uint64
+	if position, err = parseAppendPosition(r.URL.Query().Get(ParamPosition)); err != nil {
+		log.LogErrorf("appendObjectHandler: invalid position: requestID(%v) position(%v)",
+			GetRequestID(r), r.URL.Query().Get(ParamPosition))
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("appendObjectHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	// the object is modified in place, which conflicts with the versions, the retentions
+	// and the encryption of the whole object
+	var status string
+	if status, err = vol.versioningStatus(); err != nil {
+		log.LogErrorf("appendObjectHandler: load versioning fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	var objectLock *ObjectLockConfig
+	if objectLock, err = vol.metaLoader.loadObjectLock(); err != nil {
+		log.LogErrorf("appendObjectHandler: load object lock fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	var sse *SSEOption
+	if sse, err = parseWriteSSEOption(r.Header, vol); err != nil {
+		log.LogErrorf("appendObjectHandler: parse sse fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+	if status != "" || objectLock != nil || sse != nil {
+		errorCode = AppendNotSupported
+		return
+	}
+
+	// QPS and Concurrency Limit
+	rateLimit := o.AcquireRateLimiter()
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+		return
+	}
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+
+	// Verify ContentLength
+	length := GetContentLength(r)
+	if length > SinglePutLimit {
+		errorCode = EntityTooLarge
+		return
+	}
+	if length < 0 {
+		errorCode = MissingContentLength
+		return
+	}
+
+	cacheControl := r.Header.Get(CacheControl)
+	if len(cacheControl) > 0 && !ValidateCacheControl(cacheControl) {
+		errorCode = InvalidCacheArgument
+		return
+	}
+	expires := r.Header.Get(Expires)
+	if len(expires) > 0 && !ValidateCacheExpires(expires) {
+		errorCode = InvalidCacheArgument
+		return
+	}
+	log.LogInfof("Audit: append object: requestID(%v) remote(%v) volume(%v) path(%v) position(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name(), param.Object(), position)
+
+	// Flow Control
+	var reader io.Reader
+	if length > DefaultFlowLimitSize {
+		reader = rateLimit.GetReader(vol.owner, param.apiName, r.Body)
+	} else {
+		reader = r.Body
+	}
+
+	opt := &AppendOption{
+		Position:     position,
+		MIMEType:     r.Header.Get(ContentType),
+		Disposition:  r.Header.Get(ContentDisposition),
+		CacheControl: cacheControl,
+		Expires:      expires,
+		Metadata:     ParseUserDefinedMetadata(r.Header),
+	}
+	// the object is created only by the append at the position zero
+	var objects uint64
+	if position == 0 {
+		objects = 1
+	}
+	if err = vol.checkBucketQuota(uint64(length), objects); err != nil {
+		return
+	}
+	start := time.Now()
+	fsFileInfo, err := vol.AppendObject(param.Object(), reader, opt)
+	span.AppendTrackLog("file.a", start, err)
+	if err == PositionNotEqualToLength {
+		// the client continues the append at the returned position
+		if info, _, statErr := vol.ObjectMeta(param.Object()); statErr == nil {
+			w.Header().Set(XAmzNextAppendPosition, strconv.FormatInt(info.Size, 10))
+		}
+		return
+	}
+	if err != nil {
+		log.LogErrorf("appendObjectHandler: append object fail: requestId(%v) volume(%v) path(%v) position(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), position, err)
+		err = handlePutObjectErr(err)
+		return
+	}
+
+	w.Header()[ETag] = []string{wrapUnescapedQuot(fsFileInfo.ETag)}
+	w.Header().Set(XAmzNextAppendPosition, strconv.FormatInt(fsFileInfo.Size, 10))
+
+	o.notify(param, vol, EventObjectCreatedAppend, &notificationObject{
+		Key:  param.Object(),
+		Size: fsFileInfo.Size,
+		ETag: fsFileInfo.ETag,
+	})
+}
@@ -0,0 +1,110 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"crypto/md5"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendState(t *testing.T) {
+	h := md5.New()
+	_, _ = h.Write([]byte("hello "))
+	state, err := encodeAppendState(h)
+	require.NoError(t, err)
+
+	resumed, err := decodeAppendState(state)
+	require.NoError(t, err)
+	_, _ = resumed.Write([]byte("world"))
+	expected := md5.Sum([]byte("hello world"))
+	require.Equal(t, expected[:], resumed.Sum(nil))
+
+	_, err = decodeAppendState("not hex")
+	require.Equal(t, errInvalidAppendState, err)
+	_, err = decodeAppendState("0011")
+	require.Equal(t, errInvalidAppendState, err)
+}
+
+func TestParseAppendPosition(t *testing.T) {
+	position, err := parseAppendPosition("1024")
+	require.NoError(t, err)
+	require.Equal(t, uint64(1024), position)
+
+	for _, value := range []string{"", "-1", "abc"} {
+		_, err = parseAppendPosition(value)
+		require.Equal(t, InvalidArgument, err, value)
+	}
+}
+
+func TestCheckAppendable(t *testing.T) {
+	v := &Volume{}
+	inoInfo := &proto.InodeInfo{}
+	xattr := &proto.XAttrInfo{XAttrs: map[string]string{
+		XAttrKeyOSSETag: ETagValue{Value: "etag"}.Encode(),
+	}}
+	require.NoError(t, v.checkAppendable(inoInfo, xattr))
+
+	xattr.XAttrs[XAttrKeyOSSETag] = ETagValue{Value: "etag", PartNum: 2}.Encode()
+	require.Equal(t, ObjectNotAppendable, v.checkAppendable(inoInfo, xattr))
+
+	xattr.XAttrs[XAttrKeyOSSETag] = ETagValue{Value: "etag"}.Encode()
+	xattr.XAttrs[XAttrKeyOSSSSE] = "sse"
+	require.Equal(t, ObjectNotAppendable, v.checkAppendable(inoInfo, xattr))
+}
+
+func TestCreateAppendableObject(t *testing.T) {
+	// the object only exists after the first append at the position zero
+	v := &Volume{}
+	_, err := v.createAppendableObject("key", strings.NewReader("data"), &AppendOption{Position: 4})
+	require.Equal(t, PositionNotEqualToLength, err)
+}
+
+func TestAppendObjectHandler(t *testing.T) {
+	versioning := newTestVolume("versioning")
+	versioning.metaLoader.storeVersioning(&VersioningConfiguration{Status: Enabled})
+	quota := newTestVolume("quota")
+	quota.mw.EnableQuota = true
+	quota.mw.QuotaInfoMap = map[uint32]*proto.QuotaInfo{
+		1: {
+			PathInfos: []proto.QuotaPathInfo{{FullPath: "/", RootInode: rootIno}},
+			MaxBytes:  quotaLimit(0),
+			MaxFiles:  10,
+			UsedInfo:  proto.QuotaUsedInfo{UsedFiles: 10},
+		},
+	}
+	router := newTestRouter(newTestVolume("bucket"), versioning, quota)
+
+	tests := []struct {
+		url string
+		ec  *ErrorCode
+	}{
+		{url: "http://bucket.cube.io/key?append&position=abc", ec: InvalidArgument},
+		{url: "http://bucket.cube.io/dir/?append&position=0", ec: InvalidKey},
+		{url: "http://versioning.cube.io/key?append&position=0", ec: AppendNotSupported},
+		// the object is created by the append at the position zero
+		{url: "http://quota.cube.io/key?append&position=0", ec: QuotaExceeded},
+	}
+	for _, tc := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tc.url, strings.NewReader("data")))
+		requireErrorResponse(t, tc.ec, w)
+	}
+}
@@ -33,20 +33,10 @@ type KafkaAudit struct {
 }
 
 func NewKafkaAudit(id string, conf KafkaAuditConfig) (*KafkaAudit, error) {
-	if err := conf.FixConfig(); err != nil {
-		return nil, err
-	}
-
-	producer, err := conf.BuildSyncProducer()
+	a, err := newKafkaAudit("kafka-audit-"+id, conf)
 	if err != nil {
 		return nil, err
 	}
-
-	a := &KafkaAudit{
-		name:             "kafka-audit-" + id,
-		producer:         producer,
-		KafkaAuditConfig: conf,
-	}
 	if !conf.IsTest {
 		if err = a.sendTest(); err != nil {
 			a.producer.Close()
@@ -57,6 +47,24 @@ func NewKafkaAudit(id string, conf KafkaAuditConfig) (*KafkaAudit, error) {
 	return a, nil
 }
 
+// newKafkaAudit creates the Kafka sender without sending the test data.
+func newKafkaAudit(name string, conf KafkaAuditConfig) (*KafkaAudit, error) {
+	if err := conf.FixConfig(); err != nil {
+		return nil, err
+	}
+
+	producer, err := conf.BuildSyncProducer()
+	if err != nil {
+		return nil, err
+	}
+
+	return &KafkaAudit{
+		name:             name,
+		producer:         producer,
+		KafkaAuditConfig: conf,
+	}, nil
+}
+
 func (k *KafkaAudit) sendTest() error {
 	return k.Send([]byte("{}"))
 }
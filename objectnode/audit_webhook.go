@@ -41,6 +41,19 @@ type WebhookAudit struct {
 }
 
 func NewWebhookAudit(id string, conf WebhookAuditConfig) (*WebhookAudit, error) {
+	a, err := newWebhookAudit("webhook-audit-"+id, conf)
+	if err != nil {
+		return nil, err
+	}
+	if err = a.sendTest(); err != nil {
+		return nil, fmt.Errorf("webhook: send test data to '%s' failed: %v", a.Endpoint, err)
+	}
+
+	return a, nil
+}
+
+// newWebhookAudit creates the webhook sender without sending the test data.
+func newWebhookAudit(name string, conf WebhookAuditConfig) (*WebhookAudit, error) {
 	if err := conf.FixConfig(); err != nil {
 		return nil, err
 	}
@@ -50,16 +63,11 @@ func NewWebhookAudit(id string, conf WebhookAuditConfig) (*WebhookAudit, error)
 		return nil, err
 	}
 
-	a := &WebhookAudit{
-		name:               "webhook-audit-" + id,
+	return &WebhookAudit{
+		name:               name,
 		client:             client,
 		WebhookAuditConfig: conf,
-	}
-	if err = a.sendTest(); err != nil {
-		return nil, fmt.Errorf("webhook: send test data to '%s' failed: %v", a.Endpoint, err)
-	}
-
-	return a, nil
+	}, nil
 }
 
 func (w *WebhookAudit) sendTest() error {
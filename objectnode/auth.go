@@ -240,7 +240,7 @@ func (o *ObjectNode) validateAuthInfo(r *http.Request, auth Auther) (err error)
 			return AccessDenied
 		}
 		action := "s3:" + param.apiName
-		if !stsInfo.Policy.IsAllow(action, param.bucket, param.object) {
+		if !stsInfo.IsAllow(action, param.bucket, param.object) {
 			log.LogErrorf("validateAuthInfo: sts policy not allow: requestID(%v) policy(%v) api(%v) resource(%v)",
 				GetRequestID(r), *stsInfo.Policy, param.apiName, param.resource)
 			return AccessDenied
@@ -261,7 +261,7 @@ func (o *ObjectNode) validateAuthInfo(r *http.Request, auth Auther) (err error)
 			return AccessDenied
 		}
 		action := "s3:" + param.apiName
-		if !stsInfo.Policy.IsAllow(action, param.bucket, param.object) {
+		if !stsInfo.IsAllow(action, param.bucket, param.object) {
 			log.LogErrorf("validateAuthInfo: sts policy not allow: requestID(%v) policy(%v) api(%v) resource(%v)",
 				GetRequestID(r), *stsInfo.Policy, param.apiName, param.resource)
 			return AccessDenied
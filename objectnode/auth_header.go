@@ -181,8 +181,10 @@ func (auth *HeaderAuth) SignatureMatch(secretKey string, wildcards Wildcards) bo
 		return auth.signature == auth.buildSignatureV2(secretKey, wildcards)
 	case signatureV4:
 		var signature string
+		// the body of unsigned payload with trailer is not signed by chunks
 		if auth.request.Header.Get(XAmzDecodedContentLength) != "" &&
-			auth.request.Header.Get(ContentEncoding) == streamingContentEncoding {
+			auth.request.Header.Get(ContentEncoding) == streamingContentEncoding &&
+			auth.request.Header.Get(XAmzContentSha256) != streamingUnsignedPayloadTrailer {
 			signature = auth.buildSignatureChunk(secretKey)
 		} else {
 			signature = auth.buildSignatureV4(secretKey)
@@ -233,7 +235,7 @@ func (auth *HeaderAuth) buildSignatureChunk(secretKey string) string {
 
 	signature := calculateSignature(signingKey, auth.stringToSign)
 
-	auth.request.Body = NewSignChunkedReader(auth.request.Body, signingKey, scope, cred.Date, signature)
+	auth.request.Body = NewSignChunkedReader(auth.request.Body, signingKey, scope, cred.TimeStamp, signature)
 
 	return signature
 }
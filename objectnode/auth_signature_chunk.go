@@ -20,9 +20,16 @@ import (
 	"encoding/hex"
 	"errors"
 	"io"
+	"net/http"
 	"strings"
 )
 
+const (
+	streamingUnsignedPayloadTrailer = "STREAMING-UNSIGNED-PAYLOAD-TRAILER"
+
+	trailerSignatureHeader = "x-amz-trailer-signature"
+)
+
 // https://docs.aws.amazon.com/zh_cn/AmazonS3/latest/API/sigv4-streaming.html#sigv4-chunked-body-definition
 
 func NewSignChunkedReader(r io.Reader, key []byte, scope, datetime, seed string) *SignChunkedReader {
@@ -37,12 +44,31 @@ func NewSignChunkedReader(r io.Reader, key []byte, scope, datetime, seed string)
 		scope:    scope,
 		datetime: datetime,
 		prevSig:  seed,
+		trailer:  make(http.Header),
+	}
+}
+
+// NewUnsignedChunkedReader returns the reader of the aws-chunked body without the chunk signatures,
+// which is sent with the STREAMING-UNSIGNED-PAYLOAD-TRAILER payload.
+func NewUnsignedChunkedReader(r io.ReadCloser) *SignChunkedReader {
+	return &SignChunkedReader{
+		reader:   bufio.NewReader(r),
+		buf:      bytes.NewBuffer(nil),
+		unsigned: true,
+		trailer:  make(http.Header),
+		src:      r,
 	}
 }
 
 type SignChunkedReader struct {
 	reader *bufio.Reader
 	buf    *bytes.Buffer
+	src    io.Closer
+
+	// unsigned is set if the chunks are not signed, the trailer is the trailing headers sent
+	// after the last chunk, e.g. the additional checksum of the body.
+	unsigned bool
+	trailer  http.Header
 
 	key      []byte // signingKey
 	scope    string // <yyyymmdd>/<region>/<service>/aws4_request
@@ -88,14 +114,26 @@ func (cr *SignChunkedReader) fillCheck() error {
 		return err
 	}
 
-	signature, size, err := parseSignChunkedHeader(string(header))
+	var (
+		signature string
+		size      int64
+	)
+	if cr.unsigned {
+		size, err = parseUnsignedChunkedHeader(string(header))
+	} else {
+		signature, size, err = parseSignChunkedHeader(string(header))
+	}
 	if err != nil {
 		return err
 	}
 	if size == 0 {
-		if signature != cr.getSignature(cr.buf.Bytes()) {
+		if !cr.unsigned && signature != cr.getSignature(cr.buf.Bytes()) {
 			return errors.New("signature of chunk does not match")
 		}
+		cr.prevSig = signature
+		if err = cr.readTrailer(); err != nil {
+			return err
+		}
 		return io.EOF
 	}
 
@@ -109,7 +147,7 @@ func (cr *SignChunkedReader) fillCheck() error {
 	if cn != size {
 		return io.ErrShortBuffer
 	}
-	if signature != cr.getSignature(cr.buf.Bytes()) {
+	if !cr.unsigned && signature != cr.getSignature(cr.buf.Bytes()) {
 		return errors.New("signature of chunk does not match")
 	}
 	last := make([]byte, 2)
@@ -137,7 +175,69 @@ func (cr *SignChunkedReader) getSignature(data []byte) string {
 	return hex.EncodeToString(MakeHmacSha256(cr.key, []byte(stringToSign)))
 }
 
+// readTrailer reads the trailing headers after the last chunk until the empty line, and checks
+// the trailer signature if the chunks are signed.
+func (cr *SignChunkedReader) readTrailer() error {
+	var (
+		signature string
+		canonical strings.Builder
+	)
+	for {
+		line, truncated, err := cr.reader.ReadLine()
+		if truncated {
+			return errors.New("trailing header line is too long")
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if len(line) == 0 {
+			break
+		}
+		items := strings.SplitN(string(line), ":", 2)
+		if len(items) != 2 {
+			return errors.New("malformed trailing header")
+		}
+		name, value := strings.ToLower(strings.TrimSpace(items[0])), strings.TrimSpace(items[1])
+		if name == trailerSignatureHeader {
+			signature = value
+			continue
+		}
+		cr.trailer.Add(name, value)
+		canonical.WriteString(name + ":" + value + "\n")
+	}
+	if cr.unsigned || canonical.Len() == 0 {
+		return nil
+	}
+	if signature != cr.getTrailerSignature(canonical.String()) {
+		return errors.New("signature of trailer does not match")
+	}
+	return nil
+}
+
+func (cr *SignChunkedReader) getTrailerSignature(trailer string) string {
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256-TRAILER",
+		cr.datetime,
+		cr.scope,
+		cr.prevSig,
+		hex.EncodeToString(MakeSha256([]byte(trailer))),
+	}, "\n")
+
+	return hex.EncodeToString(MakeHmacSha256(cr.key, []byte(stringToSign)))
+}
+
+// Trailer returns the trailing headers, which are available after all the chunks are read.
+func (cr *SignChunkedReader) Trailer() http.Header {
+	return cr.trailer
+}
+
 func (cr *SignChunkedReader) Close() error {
+	if cr.src != nil {
+		return cr.src.Close()
+	}
 	return nil
 }
 
@@ -159,6 +259,18 @@ func parseSignChunkedHeader(header string) (sign string, size int64, err error)
 	return
 }
 
+func parseUnsignedChunkedHeader(header string) (size int64, err error) {
+	if idx := strings.IndexByte(header, ';'); idx >= 0 {
+		header = header[:idx]
+	}
+	csize, err := parseHexUint([]byte(strings.TrimSpace(header)))
+	if err != nil {
+		return
+	}
+	size = int64(csize)
+	return
+}
+
 // parseHexUint copy from net/http/internal/chunked.go
 func parseHexUint(v []byte) (n uint64, err error) {
 	for i, b := range v {
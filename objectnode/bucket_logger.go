@@ -0,0 +1,281 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/util/log"
+	"golang.org/x/time/rate"
+)
+
+const (
+	defaultBucketLoggingQueueLimit    = 100000
+	defaultBucketLoggingFlushInterval = 300 // s
+	defaultBucketLoggingMaxRecords    = 10000
+	defaultBucketLoggingRateLimit     = 10 // log objects per second
+	defaultBucketLoggingRetryInterval = 3000
+
+	bucketLoggingTargetName = "bucket-logging"
+)
+
+// BucketLoggingConfig is the configuration of the delivery of the server access logs. The records of
+// each bucket are batched in memory, and the batches are persisted in the queue directory before being
+// written as objects into the target buckets, so that they survive the restart of the objectnode.
+type BucketLoggingConfig struct {
+	QueueDir         string  `json:"queue_dir"`
+	QueueLimit       int     `json:"queue_limit"`
+	FlushIntervalSec int64   `json:"flush_interval_sec"`
+	MaxRecords       int     `json:"max_records"`
+	RateLimit        float64 `json:"rate_limit"`
+	RetryIntervalMs  int64   `json:"retry_interval_ms"`
+}
+
+// FixConfig validates and fixes the configuration.
+func (c *BucketLoggingConfig) FixConfig() error {
+	if c.QueueDir == "" {
+		return errors.New("bucket logging: no queue_dir found")
+	}
+	if c.QueueLimit <= 0 {
+		c.QueueLimit = defaultBucketLoggingQueueLimit
+	}
+	if c.FlushIntervalSec <= 0 {
+		c.FlushIntervalSec = defaultBucketLoggingFlushInterval
+	}
+	if c.MaxRecords <= 0 {
+		c.MaxRecords = defaultBucketLoggingMaxRecords
+	}
+	if c.RateLimit <= 0 {
+		c.RateLimit = defaultBucketLoggingRateLimit
+	}
+	if c.RetryIntervalMs <= 0 {
+		c.RetryIntervalMs = defaultBucketLoggingRetryInterval
+	}
+	return nil
+}
+
+// accessLogBatch is the records of the source bucket to be written as a log object into the target bucket.
+type accessLogBatch struct {
+	Bucket       string   `json:"bucket"`
+	TargetBucket string   `json:"target_bucket"`
+	TargetPrefix string   `json:"target_prefix"`
+	TargetGrants []Grant  `json:"target_grants,omitempty"`
+	Records      []string `json:"records"`
+}
+
+// BucketLogger collects the server access logs of the buckets and delivers them to the target buckets.
+type BucketLogger struct {
+	conf    BucketLoggingConfig
+	queue   *notificationQueue
+	batches map[string]*accessLogBatch
+	lock    sync.Mutex
+	stopC   chan struct{}
+	once    sync.Once
+	wg      sync.WaitGroup
+}
+
+func NewBucketLogger(conf BucketLoggingConfig, getVol func(bucket string) (*Volume, error)) (*BucketLogger, error) {
+	if err := conf.FixConfig(); err != nil {
+		return nil, err
+	}
+	target := &accessLogTarget{
+		getVol:  getVol,
+		limiter: rate.NewLimiter(rate.Limit(conf.RateLimit), 1),
+	}
+	queue, err := newNotificationQueue(conf.QueueDir, target, conf.QueueLimit,
+		time.Duration(conf.RetryIntervalMs)*time.Millisecond)
+	if err != nil {
+		return nil, err
+	}
+	l := &BucketLogger{
+		conf:    conf,
+		queue:   queue,
+		batches: make(map[string]*accessLogBatch),
+		stopC:   make(chan struct{}),
+	}
+	l.wg.Add(2)
+	go func() {
+		defer l.wg.Done()
+		queue.run(l.stopC)
+	}()
+	go func() {
+		defer l.wg.Done()
+		l.flushLoop()
+	}()
+	return l, nil
+}
+
+// log appends the record to the batch of the bucket, the batch is flushed to the queue if it is full.
+func (l *BucketLogger) log(bucket string, enabled *LoggingEnabled, record string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	batch := l.batches[bucket]
+	if batch != nil && (batch.TargetBucket != enabled.TargetBucket || batch.TargetPrefix != enabled.TargetPrefix) {
+		l.flushBatch(batch)
+		batch = nil
+	}
+	if batch == nil {
+		batch = &accessLogBatch{
+			Bucket:       bucket,
+			TargetBucket: enabled.TargetBucket,
+			TargetPrefix: enabled.TargetPrefix,
+			TargetGrants: enabled.TargetGrants,
+		}
+		l.batches[bucket] = batch
+	}
+	batch.Records = append(batch.Records, record)
+	if len(batch.Records) >= l.conf.MaxRecords {
+		l.flushBatch(batch)
+	}
+}
+
+func (l *BucketLogger) flushLoop() {
+	ticker := time.NewTicker(time.Duration(l.conf.FlushIntervalSec) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stopC:
+			return
+		case <-ticker.C:
+			l.flush()
+		}
+	}
+}
+
+// flush persists all the pending batches to the queue.
+func (l *BucketLogger) flush() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	for _, batch := range l.batches {
+		l.flushBatch(batch)
+	}
+}
+
+// flushBatch must be called with the lock held.
+func (l *BucketLogger) flushBatch(batch *accessLogBatch) {
+	delete(l.batches, batch.Bucket)
+	data, err := json.Marshal(batch)
+	if err == nil {
+		err = l.queue.put(data)
+	}
+	if err != nil {
+		log.LogErrorf("BucketLogger: persist access logs fail, drop them: bucket(%v) target(%v) records(%v) err(%v)",
+			batch.Bucket, batch.TargetBucket, len(batch.Records), err)
+	}
+}
+
+// Close stops the delivery, and the pending batches are persisted to be delivered after the restart.
+func (l *BucketLogger) Close() error {
+	l.once.Do(func() {
+		close(l.stopC)
+		l.wg.Wait()
+		l.flush()
+	})
+	return nil
+}
+
+// logBucketAccess records the request to the server access log of the bucket if the logging is enabled.
+func (o *ObjectNode) logBucketAccess(w http.ResponseWriter, r *http.Request) {
+	stater, ok := w.(*ResponseStater)
+	if !ok {
+		return
+	}
+	param := ParseRequestParam(r)
+	// the owner is set only if the bucket has been loaded by the middleware
+	if param.Bucket() == "" || param.Owner() == "" {
+		return
+	}
+	vol, err := o.vm.Volume(param.Bucket())
+	if err != nil {
+		return
+	}
+	status, err := vol.metaLoader.loadLogging()
+	if err != nil {
+		log.LogErrorf("logBucketAccess: load logging fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if status == nil || status.IsEmpty() {
+		return
+	}
+	o.bucketLogger.log(vol.Name(), status.LoggingEnabled, formatAccessLogRecord(stater, r, time.Since(stater.StartTime)))
+}
+
+// accessLogTarget writes the batches of the records as the log objects into the target buckets.
+type accessLogTarget struct {
+	getVol  func(bucket string) (*Volume, error)
+	limiter *rate.Limiter
+}
+
+func (t *accessLogTarget) Name() string {
+	return bucketLoggingTargetName
+}
+
+func (t *accessLogTarget) Send(data []byte) (err error) {
+	batch := &accessLogBatch{}
+	if err = json.Unmarshal(data, batch); err != nil {
+		log.LogErrorf("accessLogTarget: unmarshal access logs fail, drop them: err(%v)", err)
+		return nil
+	}
+	if err = t.limiter.Wait(context.Background()); err != nil {
+		return
+	}
+	var vol *Volume
+	if vol, err = t.getVol(batch.TargetBucket); err != nil {
+		if err == NoSuchBucket {
+			log.LogWarnf("accessLogTarget: target bucket not found, drop access logs: bucket(%v) target(%v) records(%v)",
+				batch.Bucket, batch.TargetBucket, len(batch.Records))
+			return nil
+		}
+		return
+	}
+	var encryption *ServerSideEncryptionConfiguration
+	if encryption, err = vol.metaLoader.loadEncryption(); err != nil {
+		return
+	}
+
+	unique := make([]byte, 8)
+	if _, err = rand.Read(unique); err != nil {
+		return
+	}
+	key := accessLogKey(batch.TargetPrefix, time.Now(), strings.ToUpper(hex.EncodeToString(unique)))
+	opt := &PutFileOption{
+		MIMEType: ValueContentTypeText,
+		ACL:      accessLogACL(vol.GetOwner(), batch.TargetGrants),
+		SSE:      encryption.defaultSSEOption(),
+	}
+	content := strings.Join(batch.Records, "\n") + "\n"
+	if _, err = vol.PutObject(key, bytes.NewReader([]byte(content)), opt); err != nil {
+		log.LogErrorf("accessLogTarget: put log object fail: bucket(%v) target(%v) key(%v) err(%v)",
+			batch.Bucket, batch.TargetBucket, key, err)
+		return
+	}
+	log.LogInfof("Audit: deliver access logs: bucket(%v) target(%v) key(%v) records(%v)",
+		batch.Bucket, batch.TargetBucket, key, len(batch.Records))
+	return nil
+}
+
+func (t *accessLogTarget) Close() error {
+	return nil
+}
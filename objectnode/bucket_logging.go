@@ -0,0 +1,245 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/ServerLogs.html
+
+import (
+	"crypto/tls"
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	MaxBucketLoggingConfigSize = 1 << 14
+	MaxLoggingTargetPrefixLen  = 512
+
+	accessLogTimeLayout = "02/Jan/2006:15:04:05 -0700"
+	accessLogKeyLayout  = "2006-01-02-15-04-05-"
+	accessLogEmptyField = "-"
+)
+
+// accessLogSubResources are the sub-resources which the operation of the access log record is named after,
+// e.g. REST.GET.ACL for the request of GetBucketAcl.
+var accessLogSubResources = []string{
+	"acl", "cors", "delete", "encryption", "legal-hold", "lifecycle", "location", "logging", "notification",
+	"object-lock", "policy", "policyStatus", "publicAccessBlock", "replication", "restore", "retention",
+	"select", "tagging", "uploads", "versioning", "versions", "website",
+}
+
+type BucketLoggingStatus struct {
+	XMLName        xml.Name        `xml:"BucketLoggingStatus" json:"-"`
+	XMLNS          string          `xml:"xmlns,attr,omitempty" json:"-"`
+	LoggingEnabled *LoggingEnabled `xml:"LoggingEnabled,omitempty" json:"logging_enabled,omitempty"`
+}
+
+type LoggingEnabled struct {
+	TargetBucket string  `xml:"TargetBucket" json:"target_bucket"`
+	TargetPrefix string  `xml:"TargetPrefix" json:"target_prefix"`
+	TargetGrants []Grant `xml:"TargetGrants>Grant,omitempty" json:"target_grants,omitempty"`
+}
+
+func ParseBucketLoggingStatusFromXML(data []byte) (*BucketLoggingStatus, error) {
+	status := &BucketLoggingStatus{}
+	if err := xml.Unmarshal(data, status); err != nil {
+		return nil, MalformedXML
+	}
+	if err := status.validate(); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// IsEmpty reports whether the status disables the logging of the bucket.
+func (s *BucketLoggingStatus) IsEmpty() bool {
+	return s.LoggingEnabled == nil
+}
+
+func (s *BucketLoggingStatus) validate() error {
+	if s.IsEmpty() {
+		return nil
+	}
+	enabled := s.LoggingEnabled
+	if enabled.TargetBucket == "" || len(enabled.TargetPrefix) > MaxLoggingTargetPrefixLen {
+		return MalformedXML
+	}
+	if len(enabled.TargetGrants) > maxGrantCount {
+		return ErrTooManyGrants
+	}
+	for _, g := range enabled.TargetGrants {
+		// only these permissions are allowed to be granted to the log objects
+		switch g.Permission {
+		case PermissionFullControl, PermissionRead, PermissionWrite:
+		default:
+			return ErrInvalidPermission
+		}
+		if err := g.Grantee.isValid(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// accessLogACL returns the acl of the log objects, which are owned by the owner of the target bucket.
+func accessLogACL(owner string, grants []Grant) *AccessControlPolicy {
+	acl := CreateDefaultACL(owner)
+	acl.Acl.Grants = append(acl.Acl.Grants, grants...)
+	return acl
+}
+
+// accessLogKey returns the key of the log object in the form of "TargetPrefixYYYY-mm-DD-HH-MM-SS-UniqueString".
+func accessLogKey(prefix string, t time.Time, unique string) string {
+	return prefix + t.UTC().Format(accessLogKeyLayout) + unique
+}
+
+// formatAccessLogRecord formats the request to a record of the server access log, whose fields are
+// separated by spaces in the order described by the reference:
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/LogFormat.html
+func formatAccessLogRecord(w *ResponseStater, r *http.Request, cost time.Duration) string {
+	param := ParseRequestParam(r)
+	sigVersion, authType := accessLogAuth(r)
+	cipherSuite, tlsVersion := accessLogTLS(r)
+	bytesSent := accessLogEmptyField
+	if w.Written > 0 {
+		bytesSent = strconv.FormatInt(w.Written, 10)
+	}
+	key := accessLogEmptyField
+	if param.Object() != "" {
+		key = (&url.URL{Path: param.Object()}).EscapedPath()
+	}
+
+	fields := []string{
+		accessLogField(param.Owner()),
+		accessLogField(param.Bucket()),
+		"[" + w.StartTime.UTC().Format(accessLogTimeLayout) + "]",
+		accessLogField(getRequestIP(r)),
+		accessLogField(param.Requester()),
+		accessLogField(param.RequestID()),
+		accessLogOperation(r, param),
+		key,
+		accessLogQuoted(r.Method + " " + r.RequestURI + " " + r.Proto),
+		strconv.Itoa(w.StatusCode),
+		accessLogField(getResponseErrorCode(r)),
+		bytesSent,
+		accessLogEmptyField, // object size
+		strconv.FormatInt(cost.Milliseconds(), 10),
+		accessLogEmptyField, // turn-around time
+		accessLogQuoted(r.Referer()),
+		accessLogQuoted(r.UserAgent()),
+		accessLogField(r.URL.Query().Get(ParamVersionId)),
+		accessLogEmptyField, // host id
+		sigVersion,
+		cipherSuite,
+		authType,
+		accessLogField(r.Host),
+		tlsVersion,
+		accessLogEmptyField, // access point arn
+		accessLogEmptyField, // acl required
+	}
+	return strings.Join(fields, " ")
+}
+
+func accessLogField(value string) string {
+	if value == "" {
+		return accessLogEmptyField
+	}
+	return strings.ReplaceAll(value, " ", "%20")
+}
+
+func accessLogQuoted(value string) string {
+	if value == "" {
+		value = accessLogEmptyField
+	}
+	return `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+}
+
+// accessLogOperation returns the operation in the form of REST.HTTP_method.resource_type.
+func accessLogOperation(r *http.Request, param *RequestParam) string {
+	method := r.Method
+	if r.Header.Get(XAmzCopySource) != "" {
+		method = "COPY"
+	}
+	resource := "BUCKET"
+	if param.Object() != "" {
+		resource = "OBJECT"
+	}
+	query := r.URL.Query()
+	switch {
+	case query.Has(ParamPartNumber) && query.Has(ParamUploadId):
+		resource = "PART"
+	case query.Has(ParamUploadId):
+		resource = "UPLOAD"
+	default:
+		for _, sub := range accessLogSubResources {
+			if query.Has(sub) {
+				resource = strings.ToUpper(strings.ReplaceAll(sub, "-", "_"))
+				break
+			}
+		}
+	}
+	return "REST." + method + "." + resource
+}
+
+// accessLogAuth returns the signature version and the authentication type of the request.
+func accessLogAuth(r *http.Request) (sigVersion, authType string) {
+	if auth := r.Header.Get(Authorization); auth != "" {
+		switch {
+		case strings.HasPrefix(auth, signatureV4):
+			return "SigV4", "AuthHeader"
+		case strings.HasPrefix(auth, signatureV2+" "):
+			return "SigV2", "AuthHeader"
+		}
+	}
+	query := r.URL.Query()
+	switch {
+	case query.Get(XAmzAlgorithm) != "":
+		return "SigV4", "QueryString"
+	case query.Get(Signature) != "":
+		return "SigV2", "QueryString"
+	}
+	return accessLogEmptyField, accessLogEmptyField
+}
+
+// accessLogTLS returns the cipher suite and the version of TLS of the request.
+func accessLogTLS(r *http.Request) (cipherSuite, version string) {
+	if r.TLS == nil {
+		return accessLogEmptyField, accessLogEmptyField
+	}
+	switch r.TLS.Version {
+	case tls.VersionTLS10:
+		version = "TLSv1"
+	case tls.VersionTLS11:
+		version = "TLSv1.1"
+	case tls.VersionTLS12:
+		version = "TLSv1.2"
+	case tls.VersionTLS13:
+		version = "TLSv1.3"
+	default:
+		version = accessLogEmptyField
+	}
+	return tls.CipherSuiteName(r.TLS.CipherSuite), version
+}
+
+func storeBucketLogging(bytes []byte, vol *Volume) (err error) {
+	return vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSLogging, bytes)
+}
+
+func deleteBucketLogging(vol *Volume) (err error) {
+	return vol.store.Delete(vol.name, bucketRootPath, XAttrKeyOSSLogging)
+}
@@ -0,0 +1,167 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// Get bucket logging
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketLogging.html
+func (o *ObjectNode) getBucketLoggingHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("getBucketLoggingHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var status *BucketLoggingStatus
+	if status, err = vol.metaLoader.loadLogging(); err != nil {
+		log.LogErrorf("getBucketLoggingHandler: load logging fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	// an empty status is responded if the logging is not enabled
+	output := &BucketLoggingStatus{XMLNS: XMLNS}
+	if status != nil && !status.IsEmpty() {
+		enabled := *status.LoggingEnabled
+		enabled.TargetGrants = make([]Grant, 0, len(status.LoggingEnabled.TargetGrants))
+		for _, g := range status.LoggingEnabled.TargetGrants {
+			g.Grantee.Xmlxsi = XMLSI
+			g.Grantee.XsiType = g.Grantee.Type
+			enabled.TargetGrants = append(enabled.TargetGrants, g)
+		}
+		output.LoggingEnabled = &enabled
+	}
+
+	var data []byte
+	if data, err = MarshalXMLEntity(output); err != nil {
+		log.LogErrorf("getBucketLoggingHandler: xml marshal fail: requestID(%v) volume(%v) status(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), output, err)
+		return
+	}
+
+	writeSuccessResponseXML(w, data)
+}
+
+// Put bucket logging
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketLogging.html
+func (o *ObjectNode) putBucketLoggingHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("putBucketLoggingHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var body []byte
+	if body, err = io.ReadAll(io.LimitReader(r.Body, MaxBucketLoggingConfigSize+1)); err != nil {
+		log.LogErrorf("putBucketLoggingHandler: read request body fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if len(body) > MaxBucketLoggingConfigSize {
+		errorCode = EntityTooLarge
+		return
+	}
+
+	var status *BucketLoggingStatus
+	if status, err = ParseBucketLoggingStatusFromXML(body); err != nil {
+		log.LogErrorf("putBucketLoggingHandler: parse logging status fail: requestID(%v) volume(%v) status(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), err)
+		return
+	}
+
+	// the empty status disables the logging of the bucket
+	if status.IsEmpty() {
+		if err = deleteBucketLogging(vol); err != nil {
+			log.LogErrorf("putBucketLoggingHandler: delete logging status fail: requestID(%v) volume(%v) err(%v)",
+				GetRequestID(r), vol.Name(), err)
+			return
+		}
+		vol.metaLoader.storeLogging(nil)
+		log.LogInfof("Audit: delete bucket logging: requestID(%v) volume(%v)", GetRequestID(r), vol.Name())
+		return
+	}
+
+	if o.bucketLogger == nil {
+		errorCode = BucketLoggingNotEnabled
+		return
+	}
+	// the log objects are written into the target bucket on behalf of the owner of the source bucket
+	var target *Volume
+	if target, err = o.getVol(status.LoggingEnabled.TargetBucket); err != nil {
+		log.LogErrorf("putBucketLoggingHandler: load target volume fail: requestID(%v) volume(%v) target(%v) err(%v)",
+			GetRequestID(r), vol.Name(), status.LoggingEnabled.TargetBucket, err)
+		if err == NoSuchBucket {
+			errorCode = InvalidLoggingTargetBucket
+		}
+		return
+	}
+	if target.GetOwner() != vol.GetOwner() {
+		log.LogErrorf("putBucketLoggingHandler: target owner mismatch: requestID(%v) volume(%v) owner(%v) target(%v) targetOwner(%v)",
+			GetRequestID(r), vol.Name(), vol.GetOwner(), target.Name(), target.GetOwner())
+		errorCode = LoggingTargetOwnerMismatch
+		return
+	}
+
+	if body, err = json.Marshal(status); err != nil {
+		log.LogErrorf("putBucketLoggingHandler: json marshal logging status fail: requestID(%v) volume(%v) status(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), status, err)
+		return
+	}
+	if err = storeBucketLogging(body, vol); err != nil {
+		log.LogErrorf("putBucketLoggingHandler: store logging status fail: requestID(%v) volume(%v) status(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), err)
+		return
+	}
+	vol.metaLoader.storeLogging(status)
+
+	log.LogInfof("Audit: put bucket logging: requestID(%v) volume(%v) status(%v)",
+		GetRequestID(r), vol.Name(), string(body))
+}
@@ -0,0 +1,158 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBucketLoggingStatus(t *testing.T) {
+	status, err := ParseBucketLoggingStatusFromXML([]byte(`<BucketLoggingStatus xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+		<LoggingEnabled>
+			<TargetBucket>logs</TargetBucket>
+			<TargetPrefix>bucket/</TargetPrefix>
+			<TargetGrants>
+				<Grant>
+					<Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="CanonicalUser"><ID>user</ID></Grantee>
+					<Permission>READ</Permission>
+				</Grant>
+			</TargetGrants>
+		</LoggingEnabled>
+	</BucketLoggingStatus>`))
+	require.NoError(t, err)
+	require.False(t, status.IsEmpty())
+	require.Equal(t, "logs", status.LoggingEnabled.TargetBucket)
+	require.Equal(t, "bucket/", status.LoggingEnabled.TargetPrefix)
+	require.Len(t, status.LoggingEnabled.TargetGrants, 1)
+
+	acl := accessLogACL("owner", status.LoggingEnabled.TargetGrants)
+	require.True(t, acl.IsAllowed("owner", proto.OSSGetObjectAction))
+	require.True(t, acl.IsAllowed("user", proto.OSSGetObjectAction))
+	require.False(t, acl.IsAllowed("other", proto.OSSGetObjectAction))
+
+	status, err = ParseBucketLoggingStatusFromXML([]byte(`<BucketLoggingStatus/>`))
+	require.NoError(t, err)
+	require.True(t, status.IsEmpty())
+
+	tests := []struct {
+		value string
+		err   error
+	}{
+		{value: `<BucketLoggingStatus>`, err: MalformedXML},
+		{value: `<BucketLoggingStatus><LoggingEnabled><TargetPrefix>a/</TargetPrefix></LoggingEnabled></BucketLoggingStatus>`, err: MalformedXML},
+		{
+			value: `<BucketLoggingStatus><LoggingEnabled><TargetBucket>logs</TargetBucket><TargetGrants><Grant>
+				<Grantee xsi:type="CanonicalUser"><ID>user</ID></Grantee><Permission>READ_ACP</Permission></Grant></TargetGrants>
+				</LoggingEnabled></BucketLoggingStatus>`,
+			err: ErrInvalidPermission,
+		},
+	}
+	for i, tc := range tests {
+		_, err = ParseBucketLoggingStatusFromXML([]byte(tc.value))
+		require.Equal(t, tc.err, err, "case %d", i)
+	}
+}
+
+func TestFormatAccessLogRecord(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPut, "http://bucket.cube.io/a%20b/c.txt?acl", nil)
+	r.RequestURI = "/a%20b/c.txt?acl"
+	r.RemoteAddr = "192.168.0.1:1234"
+	r.Header.Set(Authorization, "AWS4-HMAC-SHA256 Credential=...")
+	r.Header.Set("User-Agent", "aws-cli")
+	r = mux.SetURLVars(r, map[string]string{
+		ContextKeyBucket:    "bucket",
+		ContextKeyObject:    "a b/c.txt",
+		ContextKeyOwner:     "owner",
+		ContextKeyRequester: "user",
+	})
+	SetRequestID(r, "id")
+	SetRequestAction(r, proto.OSSPutObjectAclAction)
+	AccessDenied.ServeResponse(httptest.NewRecorder(), r)
+
+	w := NewResponseStater(httptest.NewRecorder())
+	w.StartTime = time.Date(2024, 2, 6, 0, 0, 38, 0, time.UTC)
+	w.StatusCode = http.StatusForbidden
+	w.Written = 243
+	record := formatAccessLogRecord(w, r, 15*time.Millisecond)
+	require.Equal(t, `owner bucket [06/Feb/2024:00:00:38 +0000] 192.168.0.1 user id REST.PUT.ACL a%20b/c.txt `+
+		`"PUT /a%20b/c.txt?acl HTTP/1.1" 403 AccessDenied 243 - 15 - "-" "aws-cli" - - SigV4 - AuthHeader bucket.cube.io - - -`, record)
+
+	r = httptest.NewRequest(http.MethodGet, "http://cube.io/bucket?uploads&X-Amz-Algorithm=AWS4-HMAC-SHA256", nil)
+	r = mux.SetURLVars(r, map[string]string{ContextKeyBucket: "bucket"})
+	SetRequestAction(r, proto.OSSListMultipartUploadsAction)
+	w = NewResponseStater(httptest.NewRecorder())
+	fields := strings.Split(formatAccessLogRecord(w, r, 0), " ")
+	require.Equal(t, "-", fields[0])
+	require.Equal(t, "REST.GET.UPLOADS", fields[7])
+	require.Equal(t, "-", fields[8])
+	require.Equal(t, "200", fields[12])
+	require.Equal(t, "-", fields[13])
+	require.Equal(t, "SigV4", fields[22])
+	require.Equal(t, "QueryString", fields[24])
+
+	require.Equal(t, "logs/2024-02-06-00-00-38-ABCD", accessLogKey("logs/", time.Date(2024, 2, 6, 8, 0, 38, 0, time.FixedZone("UTC+8", 8*3600)), "ABCD"))
+}
+
+func TestBucketLoggerBatch(t *testing.T) {
+	conf := BucketLoggingConfig{QueueDir: t.TempDir(), MaxRecords: 2, RetryIntervalMs: 3600 * 1000}
+	unavailable := errors.New("unavailable")
+	getVol := func(bucket string) (*Volume, error) { return nil, unavailable }
+	logger, err := NewBucketLogger(conf, getVol)
+	require.NoError(t, err)
+
+	enabled := &LoggingEnabled{TargetBucket: "logs", TargetPrefix: "a/"}
+	logger.log("bucket", enabled, "record1")
+	logger.log("bucket", enabled, "record2")
+	logger.log("bucket", &LoggingEnabled{TargetBucket: "logs", TargetPrefix: "b/"}, "record3")
+	logger.log("other", enabled, "record4")
+	require.NoError(t, logger.Close())
+
+	// the pending batches are persisted on close
+	names, err := logger.queue.list()
+	require.NoError(t, err)
+	require.Len(t, names, 3)
+	batches := make(map[string]*accessLogBatch)
+	for _, name := range names {
+		data, err := os.ReadFile(logger.queue.dir + "/" + name)
+		require.NoError(t, err)
+		batch := &accessLogBatch{}
+		require.NoError(t, json.Unmarshal(data, batch))
+		batches[batch.Bucket+":"+batch.TargetPrefix] = batch
+	}
+	require.Equal(t, []string{"record1", "record2"}, batches["bucket:a/"].Records)
+	require.Equal(t, []string{"record3"}, batches["bucket:b/"].Records)
+	require.Equal(t, []string{"record4"}, batches["other:a/"].Records)
+
+	// the batches are delivered after the restart, those of the deleted target bucket are dropped
+	conf.RetryIntervalMs = 10
+	logger, err = NewBucketLogger(conf, func(bucket string) (*Volume, error) { return nil, NoSuchBucket })
+	require.NoError(t, err)
+	defer logger.Close()
+	require.Eventually(t, func() bool {
+		names, err = logger.queue.list()
+		return err == nil && len(names) == 0
+	}, 10*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,364 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/metrics-configurations.html
+
+import (
+	"encoding/xml"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/util/exporter"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	MaxMetricsConfigSize     = 1 << 14
+	MaxMetricsConfigurations = 1000
+	MaxMetricsConfigsPerList = 100
+	MaxMetricsIdLength       = 64
+
+	defaultBucketMetricsMaxBuckets = 1000
+	defaultBucketMetricsMaxUsers   = 1000
+	defaultBucketMetricsMaxFilters = 1000
+
+	metricsLabelBucket = "bucket"
+	metricsLabelUser   = "user"
+	metricsLabelFilter = "filter"
+	metricsLabelAPI    = "api"
+	metricsLabelClass  = "class"
+
+	// the label value of the buckets or users beyond the limit of the label cardinality
+	metricsLabelOther     = "other"
+	metricsLabelAnonymous = "anonymous"
+)
+
+var regexMetricsId = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// BucketMetricsConfig is the configuration of the per-bucket and per-user request metrics. The number
+// of the distinct values of each label is limited, the requests of the buckets or users beyond the limit
+// are counted as "other", and the requests matching the metrics configurations beyond the limit are not
+// counted by filter.
+type BucketMetricsConfig struct {
+	MaxBuckets int `json:"max_buckets"`
+	MaxUsers   int `json:"max_users"`
+	MaxFilters int `json:"max_filters"`
+}
+
+// FixConfig validates and fixes the configuration.
+func (c *BucketMetricsConfig) FixConfig() error {
+	if c.MaxBuckets <= 0 {
+		c.MaxBuckets = defaultBucketMetricsMaxBuckets
+	}
+	if c.MaxUsers <= 0 {
+		c.MaxUsers = defaultBucketMetricsMaxUsers
+	}
+	if c.MaxFilters <= 0 {
+		c.MaxFilters = defaultBucketMetricsMaxFilters
+	}
+	return nil
+}
+
+type MetricsConfiguration struct {
+	XMLName xml.Name       `xml:"MetricsConfiguration" json:"-"`
+	XMLNS   string         `xml:"xmlns,attr,omitempty" json:"-"`
+	ID      string         `xml:"Id" json:"id"`
+	Filter  *MetricsFilter `xml:"Filter,omitempty" json:"filter,omitempty"`
+}
+
+type MetricsFilter struct {
+	Prefix         string      `xml:"Prefix,omitempty" json:"prefix,omitempty"`
+	Tag            *Tag        `xml:"Tag,omitempty" json:"-"`
+	AccessPointArn string      `xml:"AccessPointArn,omitempty" json:"-"`
+	And            *MetricsAnd `xml:"And,omitempty" json:"-"`
+}
+
+type MetricsAnd struct {
+	Prefix         string `xml:"Prefix,omitempty"`
+	Tags           []Tag  `xml:"Tag,omitempty"`
+	AccessPointArn string `xml:"AccessPointArn,omitempty"`
+}
+
+// BucketMetricsConfigurations are the metrics configurations of the bucket, which are stored in the
+// xattr of the bucket root.
+type BucketMetricsConfigurations struct {
+	Configurations []*MetricsConfiguration `json:"configurations"`
+}
+
+type ListMetricsConfigurationsResult struct {
+	XMLName               xml.Name                `xml:"ListMetricsConfigurationsResult"`
+	XMLNS                 string                  `xml:"xmlns,attr,omitempty"`
+	Configurations        []*MetricsConfiguration `xml:"MetricsConfiguration,omitempty"`
+	IsTruncated           bool                    `xml:"IsTruncated"`
+	ContinuationToken     string                  `xml:"ContinuationToken,omitempty"`
+	NextContinuationToken string                  `xml:"NextContinuationToken,omitempty"`
+}
+
+func ParseMetricsConfigurationFromXML(data []byte) (*MetricsConfiguration, error) {
+	conf := &MetricsConfiguration{}
+	if err := xml.Unmarshal(data, conf); err != nil {
+		return nil, MalformedXML
+	}
+	if err := conf.validate(); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+func (c *MetricsConfiguration) validate() error {
+	if c.ID == "" || len(c.ID) > MaxMetricsIdLength || !regexMetricsId.MatchString(c.ID) {
+		return InvalidMetricsId
+	}
+	if c.Filter != nil && (c.Filter.Tag != nil || c.Filter.And != nil || c.Filter.AccessPointArn != "") {
+		return MetricsFilterNotSupported
+	}
+	return nil
+}
+
+// match reports whether the object is matched by the filter, the configuration without any filter
+// matches all the requests of the bucket.
+func (c *MetricsConfiguration) match(object string) bool {
+	if c.Filter == nil || c.Filter.Prefix == "" {
+		return true
+	}
+	return strings.HasPrefix(object, c.Filter.Prefix)
+}
+
+func (c *BucketMetricsConfigurations) find(id string) int {
+	for i, conf := range c.Configurations {
+		if conf.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// list returns a page of the configurations sorted by their ids, the id of the last configuration in
+// the page is used as the continuation token of the next page.
+func (c *BucketMetricsConfigurations) list(token string) *ListMetricsConfigurationsResult {
+	sorted := make([]*MetricsConfiguration, 0, len(c.Configurations))
+	for _, conf := range c.Configurations {
+		if conf.ID > token {
+			sorted = append(sorted, conf)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	result := &ListMetricsConfigurationsResult{XMLNS: XMLNS, ContinuationToken: token}
+	if len(sorted) > MaxMetricsConfigsPerList {
+		sorted = sorted[:MaxMetricsConfigsPerList]
+		result.IsTruncated = true
+		result.NextContinuationToken = sorted[len(sorted)-1].ID
+	}
+	result.Configurations = sorted
+	return result
+}
+
+func storeBucketMetrics(bytes []byte, vol *Volume) (err error) {
+	return vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSMetrics, bytes)
+}
+
+func deleteBucketMetrics(vol *Volume) (err error) {
+	return vol.store.Delete(vol.name, bucketRootPath, XAttrKeyOSSMetrics)
+}
+
+// metricsLabelLimiter limits the number of the distinct values of a label.
+type metricsLabelLimiter struct {
+	max    int
+	lock   sync.RWMutex
+	values map[string]struct{}
+}
+
+func newMetricsLabelLimiter(max int) *metricsLabelLimiter {
+	return &metricsLabelLimiter{max: max, values: make(map[string]struct{})}
+}
+
+// allow reports whether the value is allowed to be used as the label value.
+func (l *metricsLabelLimiter) allow(value string) bool {
+	l.lock.RLock()
+	_, ok := l.values[value]
+	l.lock.RUnlock()
+	if ok {
+		return true
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if _, ok = l.values[value]; ok {
+		return true
+	}
+	if len(l.values) >= l.max {
+		return false
+	}
+	l.values[value] = struct{}{}
+	return true
+}
+
+func (l *metricsLabelLimiter) value(value string) string {
+	if l.allow(value) {
+		return value
+	}
+	return metricsLabelOther
+}
+
+// requestStat is the statistics of a request to be exported.
+type requestStat struct {
+	api      string
+	status   int
+	bytesIn  int64
+	bytesOut int64
+	start    time.Time
+}
+
+func newRequestStat(w *ResponseStater, r *http.Request, param *RequestParam) *requestStat {
+	stat := &requestStat{
+		api:      param.API(),
+		status:   w.StatusCode,
+		bytesOut: w.Written,
+		start:    w.StartTime,
+	}
+	// the size of the decoded content is counted for the chunked uploads
+	if decoded := r.Header.Get(XAmzDecodedContentLength); decoded != "" {
+		stat.bytesIn, _ = strconv.ParseInt(decoded, 10, 64)
+	} else if r.ContentLength > 0 {
+		stat.bytesIn = r.ContentLength
+	}
+	return stat
+}
+
+// errorClass returns the class of the failed request, or empty if the request succeeds.
+func (s *requestStat) errorClass() string {
+	switch {
+	case s.status >= http.StatusInternalServerError:
+		return "5xx"
+	case s.status >= http.StatusBadRequest:
+		return "4xx"
+	}
+	return ""
+}
+
+// BucketMetrics exports the request metrics by bucket, by user and by the metrics configurations
+// of the buckets. The metrics named with the prefix of bucket, user and bucket_filter are:
+//   - requests: the number of requests by API
+//   - errors: the number of 4xx and 5xx requests by API
+//   - bytes_in and bytes_out: the bytes of the requests and responses
+//   - latency_hist: the histogram of the latency by API
+type BucketMetrics struct {
+	conf    BucketMetricsConfig
+	buckets *metricsLabelLimiter
+	users   *metricsLabelLimiter
+	filters *metricsLabelLimiter
+}
+
+func NewBucketMetrics(conf BucketMetricsConfig) (*BucketMetrics, error) {
+	if err := conf.FixConfig(); err != nil {
+		return nil, err
+	}
+	return &BucketMetrics{
+		conf:    conf,
+		buckets: newMetricsLabelLimiter(conf.MaxBuckets),
+		users:   newMetricsLabelLimiter(conf.MaxUsers),
+		filters: newMetricsLabelLimiter(conf.MaxFilters),
+	}, nil
+}
+
+func (m *BucketMetrics) observeUser(user string, stat *requestStat) {
+	if user == "" {
+		user = metricsLabelAnonymous
+	}
+	m.observe("user", map[string]string{metricsLabelUser: m.users.value(user)}, stat)
+}
+
+func (m *BucketMetrics) observeBucket(bucket string, stat *requestStat) {
+	m.observe("bucket", map[string]string{metricsLabelBucket: m.buckets.value(bucket)}, stat)
+}
+
+func (m *BucketMetrics) observeFilter(bucket, id string, stat *requestStat) {
+	if !m.filters.allow(bucket + "/" + id) {
+		return
+	}
+	m.observe("bucket_filter", map[string]string{metricsLabelBucket: bucket, metricsLabelFilter: id}, stat)
+}
+
+// observe exports the metrics of the request, the labels are not changed after being exported since
+// the metrics are collected asynchronously.
+func (m *BucketMetrics) observe(name string, labels map[string]string, stat *requestStat) {
+	apiLabels := withMetricsLabel(labels, metricsLabelAPI, stat.api)
+	exporter.NewCounter(name+"_requests").AddWithLabels(1, apiLabels)
+	exporter.NewTPFrom(name+"_latency", stat.start).SetWithLabels(apiLabels)
+	if class := stat.errorClass(); class != "" {
+		exporter.NewCounter(name+"_errors").AddWithLabels(1, withMetricsLabel(apiLabels, metricsLabelClass, class))
+	}
+	if stat.bytesIn > 0 {
+		exporter.NewCounter(name+"_bytes_in").AddWithLabels(stat.bytesIn, labels)
+	}
+	if stat.bytesOut > 0 {
+		exporter.NewCounter(name+"_bytes_out").AddWithLabels(stat.bytesOut, labels)
+	}
+}
+
+func withMetricsLabel(labels map[string]string, key, value string) map[string]string {
+	m := make(map[string]string, len(labels)+1)
+	for k, v := range labels {
+		m[k] = v
+	}
+	m[key] = value
+	return m
+}
+
+// recordBucketMetrics exports the metrics of the request by the requester, the bucket and the metrics
+// configurations of the bucket.
+func (o *ObjectNode) recordBucketMetrics(w http.ResponseWriter, r *http.Request) {
+	stater, ok := w.(*ResponseStater)
+	if !ok {
+		return
+	}
+	param := ParseRequestParam(r)
+	// the requests not routed to any API are not counted
+	if param.Action().IsNone() {
+		return
+	}
+	stat := newRequestStat(stater, r, param)
+	o.bucketMetrics.observeUser(param.Requester(), stat)
+
+	// the owner is set only if the bucket has been loaded by the middleware
+	if param.Bucket() == "" || param.Owner() == "" {
+		return
+	}
+	o.bucketMetrics.observeBucket(param.Bucket(), stat)
+	vol, err := o.vm.Volume(param.Bucket())
+	if err != nil {
+		return
+	}
+	configs, err := vol.metaLoader.loadMetrics()
+	if err != nil {
+		log.LogErrorf("recordBucketMetrics: load metrics fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if configs == nil {
+		return
+	}
+	for _, conf := range configs.Configurations {
+		if conf.match(param.Object()) {
+			o.bucketMetrics.observeFilter(vol.Name(), conf.ID, stat)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// Get bucket metrics configuration
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketMetricsConfiguration.html
+func (o *ObjectNode) getBucketMetricsHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("getBucketMetricsHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var configs *BucketMetricsConfigurations
+	if configs, err = vol.metaLoader.loadMetrics(); err != nil {
+		log.LogErrorf("getBucketMetricsHandler: load metrics fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if configs == nil {
+		errorCode = NoSuchMetricsConfiguration
+		return
+	}
+	i := configs.find(r.URL.Query().Get(ParamId))
+	if i < 0 {
+		errorCode = NoSuchMetricsConfiguration
+		return
+	}
+	output := *configs.Configurations[i]
+	output.XMLNS = XMLNS
+
+	var data []byte
+	if data, err = MarshalXMLEntity(&output); err != nil {
+		log.LogErrorf("getBucketMetricsHandler: xml marshal fail: requestID(%v) volume(%v) config(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), output, err)
+		return
+	}
+
+	writeSuccessResponseXML(w, data)
+}
+
+// List bucket metrics configurations
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_ListBucketMetricsConfigurations.html
+func (o *ObjectNode) listBucketMetricsHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("listBucketMetricsHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var configs *BucketMetricsConfigurations
+	if configs, err = vol.metaLoader.loadMetrics(); err != nil {
+		log.LogErrorf("listBucketMetricsHandler: load metrics fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if configs == nil {
+		configs = &BucketMetricsConfigurations{}
+	}
+	result := configs.list(r.URL.Query().Get(ParamContToken))
+
+	var data []byte
+	if data, err = MarshalXMLEntity(result); err != nil {
+		log.LogErrorf("listBucketMetricsHandler: xml marshal fail: requestID(%v) volume(%v) result(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), result, err)
+		return
+	}
+
+	writeSuccessResponseXML(w, data)
+}
+
+// Put bucket metrics configuration
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketMetricsConfiguration.html
+func (o *ObjectNode) putBucketMetricsHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	if o.bucketMetrics == nil {
+		errorCode = BucketMetricsNotEnabled
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("putBucketMetricsHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var body []byte
+	if body, err = io.ReadAll(io.LimitReader(r.Body, MaxMetricsConfigSize+1)); err != nil {
+		log.LogErrorf("putBucketMetricsHandler: read request body fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if len(body) > MaxMetricsConfigSize {
+		errorCode = EntityTooLarge
+		return
+	}
+
+	var conf *MetricsConfiguration
+	if conf, err = ParseMetricsConfigurationFromXML(body); err != nil {
+		log.LogErrorf("putBucketMetricsHandler: parse metrics fail: requestID(%v) volume(%v) config(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), err)
+		return
+	}
+	if conf.ID != r.URL.Query().Get(ParamId) {
+		errorCode = MetricsIdMismatch
+		return
+	}
+
+	var configs *BucketMetricsConfigurations
+	if configs, err = vol.metaLoader.loadMetrics(); err != nil {
+		log.LogErrorf("putBucketMetricsHandler: load metrics fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	// the cached configurations are not modified in place
+	updated := &BucketMetricsConfigurations{}
+	if configs != nil {
+		updated.Configurations = append(updated.Configurations, configs.Configurations...)
+	}
+	if i := updated.find(conf.ID); i >= 0 {
+		updated.Configurations[i] = conf
+	} else {
+		if len(updated.Configurations) >= MaxMetricsConfigurations {
+			errorCode = TooManyMetricsConfigurations
+			return
+		}
+		updated.Configurations = append(updated.Configurations, conf)
+	}
+
+	if body, err = json.Marshal(updated); err != nil {
+		log.LogErrorf("putBucketMetricsHandler: json marshal metrics fail: requestID(%v) volume(%v) configs(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), updated, err)
+		return
+	}
+	if err = storeBucketMetrics(body, vol); err != nil {
+		log.LogErrorf("putBucketMetricsHandler: store metrics fail: requestID(%v) volume(%v) configs(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), err)
+		return
+	}
+	vol.metaLoader.storeMetrics(updated)
+
+	log.LogInfof("Audit: put bucket metrics: requestID(%v) volume(%v) id(%v) filter(%+v)",
+		GetRequestID(r), vol.Name(), conf.ID, conf.Filter)
+}
+
+// Delete bucket metrics configuration
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketMetricsConfiguration.html
+func (o *ObjectNode) deleteBucketMetricsHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("deleteBucketMetricsHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var configs *BucketMetricsConfigurations
+	if configs, err = vol.metaLoader.loadMetrics(); err != nil {
+		log.LogErrorf("deleteBucketMetricsHandler: load metrics fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	id := r.URL.Query().Get(ParamId)
+	if configs == nil || configs.find(id) < 0 {
+		errorCode = NoSuchMetricsConfiguration
+		return
+	}
+	updated := &BucketMetricsConfigurations{}
+	for _, conf := range configs.Configurations {
+		if conf.ID != id {
+			updated.Configurations = append(updated.Configurations, conf)
+		}
+	}
+
+	if len(updated.Configurations) == 0 {
+		if err = deleteBucketMetrics(vol); err != nil {
+			log.LogErrorf("deleteBucketMetricsHandler: delete metrics fail: requestID(%v) volume(%v) err(%v)",
+				GetRequestID(r), vol.Name(), err)
+			return
+		}
+		vol.metaLoader.storeMetrics(nil)
+	} else {
+		var body []byte
+		if body, err = json.Marshal(updated); err != nil {
+			log.LogErrorf("deleteBucketMetricsHandler: json marshal metrics fail: requestID(%v) volume(%v) configs(%+v) err(%v)",
+				GetRequestID(r), vol.Name(), updated, err)
+			return
+		}
+		if err = storeBucketMetrics(body, vol); err != nil {
+			log.LogErrorf("deleteBucketMetricsHandler: store metrics fail: requestID(%v) volume(%v) configs(%v) err(%v)",
+				GetRequestID(r), vol.Name(), string(body), err)
+			return
+		}
+		vol.metaLoader.storeMetrics(updated)
+	}
+
+	log.LogInfof("Audit: delete bucket metrics: requestID(%v) volume(%v) id(%v)", GetRequestID(r), vol.Name(), id)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,142 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMetricsConfiguration(t *testing.T) {
+	tests := []struct {
+		value string
+		err   error
+	}{
+		{
+			value: `<MetricsConfiguration><Id>EntireBucket</Id></MetricsConfiguration>`,
+		},
+		{
+			value: `<MetricsConfiguration><Id>Documents</Id><Filter><Prefix>documents/</Prefix></Filter></MetricsConfiguration>`,
+		},
+		{
+			value: `<MetricsConfiguration><Id>Documents</Id>`,
+			err:   MalformedXML,
+		},
+		{
+			value: `<MetricsConfiguration><Filter><Prefix>documents/</Prefix></Filter></MetricsConfiguration>`,
+			err:   InvalidMetricsId,
+		},
+		{
+			value: `<MetricsConfiguration><Id>a/b</Id></MetricsConfiguration>`,
+			err:   InvalidMetricsId,
+		},
+		{
+			value: `<MetricsConfiguration><Id>` + strings.Repeat("a", MaxMetricsIdLength+1) + `</Id></MetricsConfiguration>`,
+			err:   InvalidMetricsId,
+		},
+		{
+			value: `<MetricsConfiguration><Id>Tagged</Id><Filter><Tag><Key>k</Key><Value>v</Value></Tag></Filter></MetricsConfiguration>`,
+			err:   MetricsFilterNotSupported,
+		},
+		{
+			value: `<MetricsConfiguration><Id>And</Id><Filter><And><Prefix>a</Prefix></And></Filter></MetricsConfiguration>`,
+			err:   MetricsFilterNotSupported,
+		},
+	}
+	for _, tc := range tests {
+		_, err := ParseMetricsConfigurationFromXML([]byte(tc.value))
+		require.Equal(t, tc.err, err, tc.value)
+	}
+}
+
+func TestMetricsConfigurationMatch(t *testing.T) {
+	conf := &MetricsConfiguration{ID: "all"}
+	require.True(t, conf.match(""))
+	require.True(t, conf.match("a/b"))
+
+	conf.Filter = &MetricsFilter{Prefix: "documents/"}
+	require.True(t, conf.match("documents/a.txt"))
+	require.False(t, conf.match("images/a.png"))
+	require.False(t, conf.match(""))
+}
+
+func TestListMetricsConfigurations(t *testing.T) {
+	configs := &BucketMetricsConfigurations{}
+	for i := MaxMetricsConfigsPerList + 1; i > 0; i-- {
+		configs.Configurations = append(configs.Configurations, &MetricsConfiguration{ID: fmt.Sprintf("id%03d", i)})
+	}
+	require.Equal(t, 1, configs.find("id100"))
+	require.Equal(t, -1, configs.find("id000"))
+
+	result := configs.list("")
+	require.True(t, result.IsTruncated)
+	require.Len(t, result.Configurations, MaxMetricsConfigsPerList)
+	require.Equal(t, "id001", result.Configurations[0].ID)
+	require.Equal(t, "id100", result.NextContinuationToken)
+
+	result = configs.list(result.NextContinuationToken)
+	require.False(t, result.IsTruncated)
+	require.Len(t, result.Configurations, 1)
+	require.Equal(t, "id101", result.Configurations[0].ID)
+}
+
+func TestMetricsLabelLimiter(t *testing.T) {
+	l := newMetricsLabelLimiter(2)
+	require.Equal(t, "a", l.value("a"))
+	require.Equal(t, "b", l.value("b"))
+	require.Equal(t, metricsLabelOther, l.value("c"))
+	require.Equal(t, "a", l.value("a"))
+	require.False(t, l.allow("d"))
+	require.True(t, l.allow("b"))
+}
+
+func TestRequestStat(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPut, "http://bucket.cube.io/key", strings.NewReader("hello"))
+	w := NewResponseStater(httptest.NewRecorder())
+	w.WriteHeader(http.StatusForbidden)
+	_, _ = w.Write([]byte("denied"))
+	stat := newRequestStat(w, r, &RequestParam{apiName: "PutObject"})
+	require.Equal(t, "PutObject", stat.api)
+	require.Equal(t, int64(5), stat.bytesIn)
+	require.Equal(t, int64(6), stat.bytesOut)
+	require.Equal(t, "4xx", stat.errorClass())
+
+	r.Header.Set(XAmzDecodedContentLength, "3")
+	stat = newRequestStat(w, r, &RequestParam{})
+	require.Equal(t, int64(3), stat.bytesIn)
+
+	stat.status = http.StatusServiceUnavailable
+	require.Equal(t, "5xx", stat.errorClass())
+	stat.status = http.StatusPartialContent
+	require.Empty(t, stat.errorClass())
+}
+
+func TestBucketMetricsConfig(t *testing.T) {
+	m, err := NewBucketMetrics(BucketMetricsConfig{MaxUsers: 1})
+	require.NoError(t, err)
+	require.Equal(t, defaultBucketMetricsMaxBuckets, m.conf.MaxBuckets)
+	require.Equal(t, 1, m.conf.MaxUsers)
+	require.Equal(t, defaultBucketMetricsMaxFilters, m.conf.MaxFilters)
+	// the metrics are not exported if prometheus is disabled
+	m.observeUser("", &requestStat{api: "GetObject"})
+	m.observeUser("user", &requestStat{api: "GetObject"})
+	require.Equal(t, metricsLabelOther, m.users.value("user"))
+	require.Equal(t, metricsLabelAnonymous, m.users.value(metricsLabelAnonymous))
+}
@@ -0,0 +1,215 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/xml"
+	"math"
+	"strconv"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	MaxBucketQuotaSize = 1 << 10
+
+	// the max number of inodes handled concurrently when the quota is applied to or revoked from the existing inodes
+	bucketQuotaMaxConcurrencyInode = 1000
+)
+
+// BucketQuota is the capacity and object count quota of the bucket, which is kept by master as the
+// directory quota of the root directory of the volume. The zero limit means unlimited.
+type BucketQuota struct {
+	XMLName     xml.Name `xml:"BucketQuota"`
+	XMLNS       string   `xml:"xmlns,attr,omitempty"`
+	MaxBytes    uint64   `xml:"MaxBytes"`
+	MaxObjects  uint64   `xml:"MaxObjects"`
+	UsedBytes   int64    `xml:"UsedBytes"`
+	UsedObjects int64    `xml:"UsedObjects"`
+}
+
+func ParseBucketQuotaFromXML(raw []byte) (*BucketQuota, error) {
+	quota := &BucketQuota{}
+	if err := UnmarshalXMLEntity(raw, quota); err != nil {
+		return nil, MalformedXML
+	}
+	if quota.MaxBytes == 0 && quota.MaxObjects == 0 {
+		return nil, InvalidBucketQuota
+	}
+	return quota, nil
+}
+
+// quotaLimit converts the limit of the bucket quota to the limit of the directory quota.
+func quotaLimit(max uint64) uint64 {
+	if max == 0 {
+		return math.MaxUint64
+	}
+	return max
+}
+
+func newBucketQuota(info *proto.QuotaInfo) *BucketQuota {
+	quota := &BucketQuota{
+		XMLNS:       XMLNS,
+		MaxBytes:    info.MaxBytes,
+		MaxObjects:  info.MaxFiles,
+		UsedBytes:   info.UsedInfo.UsedBytes,
+		UsedObjects: info.UsedInfo.UsedFiles,
+	}
+	if quota.MaxBytes == math.MaxUint64 {
+		quota.MaxBytes = 0
+	}
+	if quota.MaxObjects == math.MaxUint64 {
+		quota.MaxObjects = 0
+	}
+	return quota
+}
+
+// isBucketQuota checks whether the quota is the quota of the root directory.
+func isBucketQuota(info *proto.QuotaInfo) bool {
+	for _, pathInfo := range info.PathInfos {
+		if pathInfo.RootInode == rootIno {
+			return true
+		}
+	}
+	return false
+}
+
+// exceedQuota checks whether the quota is exceeded after the bytes and the objects are written.
+// The object count of the quota includes the directories made for the object keys.
+func exceedQuota(info *proto.QuotaInfo, bytes, objects uint64) bool {
+	if info.LimitedInfo.LimitedBytes && bytes > 0 || info.LimitedInfo.LimitedFiles && objects > 0 {
+		return true
+	}
+	exceed := func(used int64, max, inc uint64) bool {
+		if inc == 0 {
+			return false
+		}
+		if used < 0 {
+			used = 0
+		}
+		return uint64(used) >= max || inc > max-uint64(used)
+	}
+	return exceed(info.UsedInfo.UsedBytes, info.MaxBytes, bytes) ||
+		exceed(info.UsedInfo.UsedFiles, info.MaxFiles, objects)
+}
+
+// checkBucketQuota checks the bucket quota before the data is written, QuotaExceeded is returned if the
+// quota is exceeded. The usage is refreshed by the meta wrapper periodically, so the check is done early
+// to reject the requests in time, and the quota is enforced by the data and meta nodes finally.
+func (v *Volume) checkBucketQuota(bytes, objects uint64) error {
+	if !v.mw.EnableQuota {
+		return nil
+	}
+	v.mw.QuotaLock.RLock()
+	defer v.mw.QuotaLock.RUnlock()
+	for _, info := range v.mw.QuotaInfoMap {
+		if !isBucketQuota(info) {
+			continue
+		}
+		if exceedQuota(info, bytes, objects) {
+			log.LogWarnf("checkBucketQuota: quota exceeded: volume(%v) quotaId(%v) maxBytes(%v) maxFiles(%v) used(%+v) bytes(%v) objects(%v)",
+				v.name, info.QuotaId, info.MaxBytes, info.MaxFiles, info.UsedInfo, bytes, objects)
+			return QuotaExceeded
+		}
+		return nil
+	}
+	return nil
+}
+
+// loadBucketQuota loads the bucket quota from master, nil is returned if the bucket has no quota.
+func (v *Volume) loadBucketQuota() (*proto.QuotaInfo, error) {
+	infos, err := v.mc.AdminAPI().ListQuota(v.name)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range infos {
+		if isBucketQuota(info) {
+			return info, nil
+		}
+	}
+	return nil, nil
+}
+
+// setBucketQuota creates or updates the bucket quota. The quota is applied to the existing inodes of the
+// volume before the request returns, and it's deleted again if the apply fails, so the bucket never has
+// a quota which does not cover all of its objects.
+func (v *Volume) setBucketQuota(quota *BucketQuota) (err error) {
+	volumeInfo, err := v.mc.AdminAPI().GetVolumeSimpleInfo(v.name)
+	if err != nil {
+		return
+	}
+	if !volumeInfo.EnableQuota {
+		return QuotaNotEnabled
+	}
+
+	maxFiles, maxBytes := quotaLimit(quota.MaxObjects), quotaLimit(quota.MaxBytes)
+	var info *proto.QuotaInfo
+	if info, err = v.loadBucketQuota(); err != nil {
+		return
+	}
+	if info != nil {
+		return v.mc.AdminAPI().UpdateQuota(v.name, strconv.FormatUint(uint64(info.QuotaId), 10), maxFiles, maxBytes)
+	}
+
+	mp := v.mw.GetPartitionByInodeId_ll(rootIno)
+	if mp == nil {
+		log.LogErrorf("setBucketQuota: meta partition of root not found: volume(%v)", v.name)
+		return InternalErrorCode(nil)
+	}
+	pathInfos := []proto.QuotaPathInfo{{FullPath: pathSep, RootInode: rootIno, PartitionId: mp.PartitionID}}
+	var quotaId uint32
+	if quotaId, err = v.mc.AdminAPI().CreateQuota(v.name, pathInfos, maxFiles, maxBytes); err != nil {
+		return
+	}
+	inodes, err := v.mw.ApplyQuota_ll(rootIno, quotaId, bucketQuotaMaxConcurrencyInode)
+	if err != nil {
+		log.LogErrorf("setBucketQuota: apply quota fail: volume(%v) quotaId(%v) inodes(%v) err(%v)",
+			v.name, quotaId, inodes, err)
+		if revokeErr := v.revokeBucketQuota(quotaId); revokeErr != nil {
+			log.LogErrorf("setBucketQuota: roll back quota fail: volume(%v) quotaId(%v) err(%v)",
+				v.name, quotaId, revokeErr)
+		}
+		return
+	}
+	log.LogInfof("setBucketQuota: apply quota: volume(%v) quotaId(%v) inodes(%v)", v.name, quotaId, inodes)
+	return
+}
+
+// deleteBucketQuota revokes the bucket quota from the inodes of the volume and deletes it. The quota is
+// kept if the revoke fails, so the deletion can be retried.
+func (v *Volume) deleteBucketQuota() (err error) {
+	var info *proto.QuotaInfo
+	if info, err = v.loadBucketQuota(); err != nil {
+		return
+	}
+	if info == nil {
+		return NoSuchBucketQuota
+	}
+	return v.revokeBucketQuota(info.QuotaId)
+}
+
+// revokeBucketQuota revokes the quota from the inodes of the volume, and deletes it after the revoke
+// succeeds like the quota delete of cli.
+func (v *Volume) revokeBucketQuota(quotaId uint32) (err error) {
+	inodes, err := v.mw.RevokeQuota_ll(rootIno, quotaId, bucketQuotaMaxConcurrencyInode)
+	if err != nil {
+		log.LogErrorf("revokeBucketQuota: revoke quota fail: volume(%v) quotaId(%v) inodes(%v) err(%v)",
+			v.name, quotaId, inodes, err)
+		return
+	}
+	log.LogInfof("revokeBucketQuota: revoke quota: volume(%v) quotaId(%v) inodes(%v)", v.name, quotaId, inodes)
+	return v.mc.AdminAPI().DeleteQuota(v.name, strconv.FormatUint(uint64(quotaId), 10))
+}
@@ -0,0 +1,156 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// Get bucket quota
+// Notes: extension api of CubeFS, the limits and the usage of the bucket quota are returned.
+func (o *ObjectNode) getBucketQuotaHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("getBucketQuotaHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var info *proto.QuotaInfo
+	if info, err = vol.loadBucketQuota(); err != nil {
+		log.LogErrorf("getBucketQuotaHandler: load quota fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if info == nil {
+		errorCode = NoSuchBucketQuota
+		return
+	}
+	output := newBucketQuota(info)
+
+	var data []byte
+	if data, err = MarshalXMLEntity(output); err != nil {
+		log.LogErrorf("getBucketQuotaHandler: xml marshal fail: requestID(%v) volume(%v) quota(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), output, err)
+		return
+	}
+
+	writeSuccessResponseXML(w, data)
+}
+
+// Put bucket quota
+// Notes: extension api of CubeFS, the quota of the root directory is created or updated, which requires
+// the quota to be enabled for the volume.
+func (o *ObjectNode) putBucketQuotaHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("putBucketQuotaHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var body []byte
+	if body, err = io.ReadAll(io.LimitReader(r.Body, MaxBucketQuotaSize+1)); err != nil {
+		log.LogErrorf("putBucketQuotaHandler: read request body fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if len(body) > MaxBucketQuotaSize {
+		errorCode = EntityTooLarge
+		return
+	}
+
+	var quota *BucketQuota
+	if quota, err = ParseBucketQuotaFromXML(body); err != nil {
+		log.LogErrorf("putBucketQuotaHandler: parse quota fail: requestID(%v) volume(%v) quota(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), err)
+		return
+	}
+
+	if err = vol.setBucketQuota(quota); err != nil {
+		log.LogErrorf("putBucketQuotaHandler: set quota fail: requestID(%v) volume(%v) quota(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), quota, err)
+		return
+	}
+
+	log.LogInfof("Audit: put bucket quota: requestID(%v) volume(%v) maxBytes(%v) maxObjects(%v)",
+		GetRequestID(r), vol.Name(), quota.MaxBytes, quota.MaxObjects)
+}
+
+// Delete bucket quota
+// Notes: extension api of CubeFS
+func (o *ObjectNode) deleteBucketQuotaHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("deleteBucketQuotaHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	if err = vol.deleteBucketQuota(); err != nil {
+		log.LogErrorf("deleteBucketQuotaHandler: delete quota fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+
+	log.LogInfof("Audit: delete bucket quota: requestID(%v) volume(%v)", GetRequestID(r), vol.Name())
+	w.WriteHeader(http.StatusNoContent)
+}
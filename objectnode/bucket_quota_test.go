@@ -0,0 +1,123 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBucketQuota(t *testing.T) {
+	tests := []struct {
+		value      string
+		maxBytes   uint64
+		maxObjects uint64
+		err        error
+	}{
+		{
+			value:      `<BucketQuota><MaxBytes>1073741824</MaxBytes><MaxObjects>1000</MaxObjects></BucketQuota>`,
+			maxBytes:   1 << 30,
+			maxObjects: 1000,
+		},
+		{
+			value:    `<BucketQuota><MaxBytes>1024</MaxBytes></BucketQuota>`,
+			maxBytes: 1024,
+		},
+		{
+			value: `<BucketQuota></BucketQuota>`,
+			err:   InvalidBucketQuota,
+		},
+		{
+			value: `<BucketQuota><MaxBytes>-1</MaxBytes></BucketQuota>`,
+			err:   MalformedXML,
+		},
+	}
+	for _, tc := range tests {
+		quota, err := ParseBucketQuotaFromXML([]byte(tc.value))
+		require.Equal(t, tc.err, err, tc.value)
+		if err == nil {
+			require.Equal(t, tc.maxBytes, quota.MaxBytes)
+			require.Equal(t, tc.maxObjects, quota.MaxObjects)
+		}
+	}
+}
+
+func TestNewBucketQuota(t *testing.T) {
+	info := &proto.QuotaInfo{
+		MaxBytes: quotaLimit(1024),
+		MaxFiles: quotaLimit(0),
+		UsedInfo: proto.QuotaUsedInfo{UsedBytes: 512, UsedFiles: 3},
+	}
+	require.Equal(t, uint64(math.MaxUint64), info.MaxFiles)
+	quota := newBucketQuota(info)
+	require.Equal(t, uint64(1024), quota.MaxBytes)
+	require.Equal(t, uint64(0), quota.MaxObjects)
+	require.Equal(t, int64(512), quota.UsedBytes)
+	require.Equal(t, int64(3), quota.UsedObjects)
+
+	require.True(t, isBucketQuota(&proto.QuotaInfo{PathInfos: []proto.QuotaPathInfo{{FullPath: "/", RootInode: rootIno}}}))
+	require.False(t, isBucketQuota(&proto.QuotaInfo{PathInfos: []proto.QuotaPathInfo{{FullPath: "/a", RootInode: 100}}}))
+}
+
+func TestExceedQuota(t *testing.T) {
+	info := &proto.QuotaInfo{
+		MaxBytes: 1024,
+		MaxFiles: quotaLimit(0),
+		UsedInfo: proto.QuotaUsedInfo{UsedBytes: 1000, UsedFiles: 10},
+	}
+	require.False(t, exceedQuota(info, 24, 1))
+	require.True(t, exceedQuota(info, 25, 1))
+	require.False(t, exceedQuota(info, 0, math.MaxUint32))
+
+	info.MaxFiles = 10
+	require.True(t, exceedQuota(info, 0, 1))
+	require.False(t, exceedQuota(info, 24, 0))
+
+	// the limit reported by meta nodes takes effect even if the usage is not refreshed
+	info.MaxFiles = 100
+	info.LimitedInfo.LimitedBytes = true
+	require.True(t, exceedQuota(info, 1, 0))
+	require.False(t, exceedQuota(info, 0, 1))
+}
+
+func TestCheckBucketQuota(t *testing.T) {
+	v := newTestVolume("bucket")
+	v.mw.QuotaInfoMap = map[uint32]*proto.QuotaInfo{
+		1: {
+			QuotaId:   1,
+			PathInfos: []proto.QuotaPathInfo{{FullPath: "/a", RootInode: 100}},
+			MaxBytes:  quotaLimit(0),
+			MaxFiles:  1,
+			UsedInfo:  proto.QuotaUsedInfo{UsedFiles: 1},
+		},
+		2: {
+			QuotaId:   2,
+			PathInfos: []proto.QuotaPathInfo{{FullPath: "/", RootInode: rootIno}},
+			MaxBytes:  1024,
+			MaxFiles:  quotaLimit(0),
+			UsedInfo:  proto.QuotaUsedInfo{UsedBytes: 1000},
+		},
+	}
+	// the quotas are not checked until quota is enabled for the volume
+	require.NoError(t, v.checkBucketQuota(1024, 1))
+
+	// the quota of the sub directory is checked by meta nodes
+	v.mw.EnableQuota = true
+	require.NoError(t, v.checkBucketQuota(24, 1))
+	require.Equal(t, QuotaExceeded, v.checkBucketQuota(25, 0))
+}
@@ -0,0 +1,162 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MaxByteRanges is the max number of ranges in the Range header of a request.
+	MaxByteRanges = 100
+
+	ValueMultipartByteRanges = "multipart/byteranges"
+)
+
+// byteRangeSpec is a range of the Range header, e.g. 0-99, 100- or -100.
+type byteRangeSpec struct {
+	first   uint64
+	last    uint64
+	hasLast bool
+	// the first is the length of the suffix if the range is a suffix range
+	suffix bool
+}
+
+// byteRange is a satisfiable range of the object.
+type byteRange struct {
+	start  uint64
+	length uint64
+}
+
+func (br byteRange) contentRange(size uint64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", br.start, br.start+br.length-1, size)
+}
+
+// parseByteRangeSpecs parses the Range header with one or more ranges, see RFC 7233 section 2.1.
+func parseByteRangeSpecs(value string) ([]byteRangeSpec, error) {
+	if !strings.HasPrefix(value, "bytes=") {
+		return nil, InvalidRange
+	}
+	items := strings.Split(value[len("bytes="):], ",")
+	if len(items) > MaxByteRanges {
+		return nil, InvalidRange
+	}
+	specs := make([]byteRangeSpec, 0, len(items))
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		i := strings.Index(item, "-")
+		if i < 0 {
+			return nil, InvalidRange
+		}
+		first, last := strings.TrimSpace(item[:i]), strings.TrimSpace(item[i+1:])
+		var (
+			spec byteRangeSpec
+			err  error
+		)
+		if first == "" {
+			// suffix range: -N
+			if spec.first, err = strconv.ParseUint(last, 10, 64); err != nil {
+				return nil, InvalidRange
+			}
+			spec.suffix = true
+			specs = append(specs, spec)
+			continue
+		}
+		if spec.first, err = strconv.ParseUint(first, 10, 64); err != nil {
+			return nil, InvalidRange
+		}
+		if last != "" {
+			if spec.last, err = strconv.ParseUint(last, 10, 64); err != nil || spec.last < spec.first {
+				return nil, InvalidRange
+			}
+			spec.hasLast = true
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// resolveByteRanges returns the satisfiable ranges of the object with the size,
+// the unsatisfiable ranges are ignored.
+func resolveByteRanges(specs []byteRangeSpec, size uint64) []byteRange {
+	ranges := make([]byteRange, 0, len(specs))
+	for _, spec := range specs {
+		if spec.suffix {
+			if spec.first == 0 || size == 0 {
+				continue
+			}
+			length := spec.first
+			if length > size {
+				length = size
+			}
+			ranges = append(ranges, byteRange{start: size - length, length: length})
+			continue
+		}
+		if spec.first >= size {
+			continue
+		}
+		last := size - 1
+		if spec.hasLast && spec.last < last {
+			last = spec.last
+		}
+		ranges = append(ranges, byteRange{start: spec.first, length: last - spec.first + 1})
+	}
+	return ranges
+}
+
+// writeByteRanges writes the ranges of the object as the multipart/byteranges body, the data
+// of each range is written by the read function.
+func writeByteRanges(w io.Writer, boundary, contentType string, size uint64, ranges []byteRange,
+	read func(w io.Writer, offset, length uint64) error) error {
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(boundary); err != nil {
+		return err
+	}
+	for _, br := range ranges {
+		header := make(textproto.MIMEHeader)
+		header.Set(ContentType, contentType)
+		header.Set(ContentRange, br.contentRange(size))
+		part, err := mw.CreatePart(header)
+		if err != nil {
+			return err
+		}
+		if err = read(part, br.start, br.length); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+type countingWriter uint64
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	*c += countingWriter(len(p))
+	return len(p), nil
+}
+
+// byteRangesSize returns the length of the multipart/byteranges body.
+func byteRangesSize(boundary, contentType string, size uint64, ranges []byteRange) uint64 {
+	var c countingWriter
+	_ = writeByteRanges(&c, boundary, contentType, size, ranges, func(_ io.Writer, _, length uint64) error {
+		c += countingWriter(length)
+		return nil
+	})
+	return uint64(c)
+}
@@ -0,0 +1,95 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseByteRangeSpecs(t *testing.T) {
+	tests := []struct {
+		value string
+		specs []byteRangeSpec
+		err   error
+	}{
+		{
+			value: "bytes=0-99,200-",
+			specs: []byteRangeSpec{{first: 0, last: 99, hasLast: true}, {first: 200}},
+		},
+		{
+			value: "bytes=0-0, -100",
+			specs: []byteRangeSpec{{first: 0, last: 0, hasLast: true}, {first: 100, suffix: true}},
+		},
+		{value: "bytes=10-5,20-30", err: InvalidRange},
+		{value: "bytes=a-5,20-30", err: InvalidRange},
+		{value: "bytes=0-5,", err: InvalidRange},
+		{value: "items=0-5,6-7", err: InvalidRange},
+		{value: "bytes=" + strings.Repeat("0-1,", MaxByteRanges) + "0-1", err: InvalidRange},
+	}
+	for _, tc := range tests {
+		specs, err := parseByteRangeSpecs(tc.value)
+		require.Equal(t, tc.err, err, tc.value)
+		if err == nil {
+			require.Equal(t, tc.specs, specs, tc.value)
+		}
+	}
+}
+
+func TestResolveByteRanges(t *testing.T) {
+	specs, err := parseByteRangeSpecs("bytes=0-9,90-200,-5,-0,100-,50-")
+	require.NoError(t, err)
+	ranges := resolveByteRanges(specs, 100)
+	require.Equal(t, []byteRange{{0, 10}, {90, 10}, {95, 5}, {50, 50}}, ranges)
+
+	specs, err = parseByteRangeSpecs("bytes=-200,0-1")
+	require.NoError(t, err)
+	require.Equal(t, []byteRange{{0, 100}, {0, 2}}, resolveByteRanges(specs, 100))
+	require.Empty(t, resolveByteRanges(specs, 0))
+}
+
+func TestWriteByteRanges(t *testing.T) {
+	data := []byte("0123456789abcdefghijklmnopqrstuvwxyz")
+	size := uint64(len(data))
+	ranges := []byteRange{{0, 4}, {10, 6}, {30, 6}}
+	boundary := multipart.NewWriter(nil).Boundary()
+
+	buf := &bytes.Buffer{}
+	err := writeByteRanges(buf, boundary, "text/plain", size, ranges, func(w io.Writer, offset, length uint64) error {
+		_, err := w.Write(data[offset : offset+length])
+		return err
+	})
+	require.NoError(t, err)
+	require.Equal(t, byteRangesSize(boundary, "text/plain", size, ranges), uint64(buf.Len()))
+
+	reader := multipart.NewReader(buf, boundary)
+	for _, br := range ranges {
+		part, err := reader.NextPart()
+		require.NoError(t, err)
+		require.Equal(t, "text/plain", part.Header.Get(ContentType))
+		require.Equal(t, fmt.Sprintf("bytes %d-%d/%d", br.start, br.start+br.length-1, size), part.Header.Get(ContentRange))
+		body, err := io.ReadAll(part)
+		require.NoError(t, err)
+		require.Equal(t, data[br.start:br.start+br.length], body)
+	}
+	_, err = reader.NextPart()
+	require.Equal(t, io.EOF, err)
+}
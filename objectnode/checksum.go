@@ -0,0 +1,254 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"hash"
+	"hash/crc32"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cubefs/cubefs/proto"
+)
+
+const (
+	ChecksumAlgorithmCRC32  = "CRC32"
+	ChecksumAlgorithmCRC32C = "CRC32C"
+	ChecksumAlgorithmSHA1   = "SHA1"
+	ChecksumAlgorithmSHA256 = "SHA256"
+
+	ChecksumModeEnabled = "ENABLED"
+)
+
+var checksumAlgorithms = []string{
+	ChecksumAlgorithmCRC32,
+	ChecksumAlgorithmCRC32C,
+	ChecksumAlgorithmSHA1,
+	ChecksumAlgorithmSHA256,
+}
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// newChecksumHash returns the hash of the additional checksum algorithm, nil if the algorithm is unsupported.
+func newChecksumHash(algorithm string) hash.Hash {
+	switch algorithm {
+	case ChecksumAlgorithmCRC32:
+		return crc32.NewIEEE()
+	case ChecksumAlgorithmCRC32C:
+		return crc32.New(crc32cTable)
+	case ChecksumAlgorithmSHA1:
+		return sha1.New()
+	case ChecksumAlgorithmSHA256:
+		return sha256.New()
+	}
+	return nil
+}
+
+func checksumHeaderName(algorithm string) string {
+	return XAmzChecksumPrefix + strings.ToLower(algorithm)
+}
+
+func validChecksumValue(algorithm, value string) bool {
+	decoded, err := base64.StdEncoding.DecodeString(value)
+	return err == nil && len(decoded) == newChecksumHash(algorithm).Size()
+}
+
+// ObjectChecksum is the additional checksum of the object or part stored in the xattr.
+// The checksum of the multipart object is the composite checksum, which is the checksum
+// of the concatenated checksums of all the parts.
+type ObjectChecksum struct {
+	Algorithm string `json:"alg"`
+	Value     string `json:"val,omitempty"` // base64 encoded
+	PartNum   int    `json:"parts,omitempty"`
+}
+
+func (c *ObjectChecksum) Encode() string {
+	data, _ := json.Marshal(c)
+	return string(data)
+}
+
+// String returns the checksum in the format of response, the number of parts is appended
+// to the composite checksum.
+func (c *ObjectChecksum) String() string {
+	if c.PartNum > 0 {
+		return c.Value + "-" + strconv.Itoa(c.PartNum)
+	}
+	return c.Value
+}
+
+func (c *ObjectChecksum) SetResponseHeader(header http.Header) {
+	if c != nil && c.Value != "" {
+		header.Set(checksumHeaderName(c.Algorithm), c.String())
+	}
+}
+
+// valueOf returns the checksum of the algorithm, it's used to fill the checksum fields of the XML results.
+func (c *ObjectChecksum) valueOf(algorithm string) string {
+	if c == nil || c.Algorithm != algorithm {
+		return ""
+	}
+	return c.String()
+}
+
+func parseObjectChecksum(raw []byte) (*ObjectChecksum, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	checksum := &ObjectChecksum{}
+	if err := json.Unmarshal(raw, checksum); err != nil {
+		return nil, err
+	}
+	return checksum, nil
+}
+
+// setChecksumResponseHeader returns the checksum of the object only if the checksum mode is enabled.
+func setChecksumResponseHeader(w http.ResponseWriter, r *http.Request, xattr *proto.XAttrInfo) {
+	if xattr == nil || !strings.EqualFold(r.Header.Get(XAmzChecksumMode), ChecksumModeEnabled) {
+		return
+	}
+	if checksum, _ := parseObjectChecksum(xattr.Get(XAttrKeyOSSChecksum)); checksum != nil {
+		checksum.SetResponseHeader(w.Header())
+	}
+}
+
+// compositeChecksum computes the checksum of the multipart object from the checksums of parts.
+func compositeChecksum(algorithm string, partChecksums []string) (*ObjectChecksum, error) {
+	h := newChecksumHash(algorithm)
+	if h == nil {
+		return nil, InvalidChecksumAlgorithm
+	}
+	for _, value := range partChecksums {
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil || len(decoded) != h.Size() {
+			return nil, InvalidPart
+		}
+		h.Write(decoded)
+	}
+	return &ObjectChecksum{
+		Algorithm: algorithm,
+		Value:     base64.StdEncoding.EncodeToString(h.Sum(nil)),
+		PartNum:   len(partChecksums),
+	}, nil
+}
+
+// trailerReader is the body reader which is able to read the trailing headers, the trailing
+// headers are available after all the data is read.
+type trailerReader interface {
+	Trailer() http.Header
+}
+
+// ChecksumOption is the additional checksum to be computed when writing the object or part.
+type ChecksumOption struct {
+	Algorithm string
+	// Value is the base64 encoded checksum specified in the header, which is empty if the
+	// checksum is sent in the trailing header or the checksum is not specified.
+	Value   string
+	trailer trailerReader
+}
+
+// expected returns the checksum specified by the request to be validated.
+func (o *ChecksumOption) expected() (string, error) {
+	if o.trailer == nil {
+		return o.Value, nil
+	}
+	value := o.trailer.Trailer().Get(checksumHeaderName(o.Algorithm))
+	if value == "" {
+		return "", MissingChecksumTrailer
+	}
+	if !validChecksumValue(o.Algorithm, value) {
+		return "", InvalidChecksumValue
+	}
+	return value, nil
+}
+
+// verify checks the computed checksum against the specified one, and returns the checksum to be stored.
+func (o *ChecksumOption) verify(sum []byte) (*ObjectChecksum, error) {
+	expected, err := o.expected()
+	if err != nil {
+		return nil, err
+	}
+	value := base64.StdEncoding.EncodeToString(sum)
+	if expected != "" && expected != value {
+		return nil, BadChecksum
+	}
+	return &ObjectChecksum{Algorithm: o.Algorithm, Value: value}, nil
+}
+
+// parseChecksumAlgorithm parses the algorithm specified by the x-amz-checksum-algorithm header.
+func parseChecksumAlgorithm(header http.Header) (string, error) {
+	algorithm := strings.ToUpper(header.Get(XAmzChecksumAlgorithm))
+	if algorithm != "" && newChecksumHash(algorithm) == nil {
+		return "", InvalidChecksumAlgorithm
+	}
+	return algorithm, nil
+}
+
+// parseChecksumOption parses the additional checksum of the request body, the checksum is specified
+// by the x-amz-checksum-* header, or by the trailing header declared in x-amz-trailer of the aws-chunked
+// body. If only the algorithm is specified, the checksum is computed without validation.
+// It returns nil if no checksum is required.
+func parseChecksumOption(r *http.Request) (*ChecksumOption, error) {
+	var opt *ChecksumOption
+	for _, algorithm := range checksumAlgorithms {
+		value := r.Header.Get(checksumHeaderName(algorithm))
+		if value == "" {
+			continue
+		}
+		if opt != nil {
+			return nil, MultipleChecksumTypes
+		}
+		if !validChecksumValue(algorithm, value) {
+			return nil, InvalidChecksumValue
+		}
+		opt = &ChecksumOption{Algorithm: algorithm, Value: value}
+	}
+
+	if trailer := r.Header.Get(XAmzTrailer); trailer != "" {
+		trailer = strings.ToLower(strings.TrimSpace(trailer))
+		algorithm := strings.ToUpper(strings.TrimPrefix(trailer, XAmzChecksumPrefix))
+		if !strings.HasPrefix(trailer, XAmzChecksumPrefix) || newChecksumHash(algorithm) == nil {
+			return nil, InvalidChecksumTrailer
+		}
+		if opt != nil {
+			return nil, MultipleChecksumTypes
+		}
+		reader, ok := r.Body.(trailerReader)
+		if !ok {
+			return nil, InvalidChecksumTrailer
+		}
+		opt = &ChecksumOption{Algorithm: algorithm, trailer: reader}
+	}
+
+	algorithm := r.Header.Get(XAmzSdkChecksumAlgorithm)
+	if algorithm == "" {
+		algorithm = r.Header.Get(XAmzChecksumAlgorithm)
+	}
+	if algorithm = strings.ToUpper(algorithm); algorithm != "" {
+		if newChecksumHash(algorithm) == nil {
+			return nil, InvalidChecksumAlgorithm
+		}
+		if opt == nil {
+			opt = &ChecksumOption{Algorithm: algorithm}
+		} else if opt.Algorithm != algorithm {
+			return nil, ChecksumTypeMismatch
+		}
+	}
+	return opt, nil
+}
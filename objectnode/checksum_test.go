@@ -0,0 +1,175 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func computeChecksum(algorithm string, data []byte) string {
+	h := newChecksumHash(algorithm)
+	h.Write(data)
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func TestChecksumHash(t *testing.T) {
+	data := []byte("hello world")
+	require.Equal(t, "DUoRhQ==", computeChecksum(ChecksumAlgorithmCRC32, data))
+	require.Equal(t, "yZRlqg==", computeChecksum(ChecksumAlgorithmCRC32C, data))
+	require.Equal(t, "Kq5sNclPz7QV2+lfQIuc6R7oRu0=", computeChecksum(ChecksumAlgorithmSHA1, data))
+	sum := sha256.Sum256(data)
+	require.Equal(t, base64.StdEncoding.EncodeToString(sum[:]), computeChecksum(ChecksumAlgorithmSHA256, data))
+	require.Nil(t, newChecksumHash("MD5"))
+}
+
+func TestParseChecksumOption(t *testing.T) {
+	crc32 := computeChecksum(ChecksumAlgorithmCRC32, []byte("hello world"))
+	sha1 := computeChecksum(ChecksumAlgorithmSHA1, []byte("hello world"))
+
+	tests := []struct {
+		headers   map[string]string
+		algorithm string
+		value     string
+		err       error
+	}{
+		{headers: map[string]string{}},
+		{headers: map[string]string{"x-amz-checksum-crc32": crc32}, algorithm: ChecksumAlgorithmCRC32, value: crc32},
+		{headers: map[string]string{"x-amz-checksum-sha1": sha1, "x-amz-sdk-checksum-algorithm": "sha1"}, algorithm: ChecksumAlgorithmSHA1, value: sha1},
+		{headers: map[string]string{"x-amz-sdk-checksum-algorithm": "CRC32C"}, algorithm: ChecksumAlgorithmCRC32C},
+		{headers: map[string]string{"x-amz-checksum-algorithm": "SHA256"}, algorithm: ChecksumAlgorithmSHA256},
+		{headers: map[string]string{"x-amz-checksum-crc32": crc32, "x-amz-checksum-sha1": sha1}, err: MultipleChecksumTypes},
+		{headers: map[string]string{"x-amz-checksum-crc32": sha1}, err: InvalidChecksumValue},
+		{headers: map[string]string{"x-amz-checksum-crc32": "not base64"}, err: InvalidChecksumValue},
+		{headers: map[string]string{"x-amz-sdk-checksum-algorithm": "MD5"}, err: InvalidChecksumAlgorithm},
+		{headers: map[string]string{"x-amz-checksum-crc32": crc32, "x-amz-sdk-checksum-algorithm": "SHA1"}, err: ChecksumTypeMismatch},
+		{headers: map[string]string{"x-amz-trailer": "x-amz-checksum-crc32"}, err: InvalidChecksumTrailer},
+		{headers: map[string]string{"x-amz-trailer": "x-amz-meta-foo"}, err: InvalidChecksumTrailer},
+	}
+	for _, tc := range tests {
+		r := httptest.NewRequest("PUT", "/bucket/key", strings.NewReader("hello world"))
+		for name, value := range tc.headers {
+			r.Header.Set(name, value)
+		}
+		opt, err := parseChecksumOption(r)
+		require.Equal(t, tc.err, err, tc.headers)
+		if tc.err != nil || tc.algorithm == "" {
+			require.Nil(t, opt, tc.headers)
+			continue
+		}
+		require.Equal(t, tc.algorithm, opt.Algorithm)
+		require.Equal(t, tc.value, opt.Value)
+	}
+}
+
+func TestChecksumOptionVerify(t *testing.T) {
+	data := []byte("hello world")
+	crc32 := computeChecksum(ChecksumAlgorithmCRC32, data)
+	sum := func() []byte {
+		h := newChecksumHash(ChecksumAlgorithmCRC32)
+		h.Write(data)
+		return h.Sum(nil)
+	}
+
+	checksum, err := (&ChecksumOption{Algorithm: ChecksumAlgorithmCRC32, Value: crc32}).verify(sum())
+	require.NoError(t, err)
+	require.Equal(t, &ObjectChecksum{Algorithm: ChecksumAlgorithmCRC32, Value: crc32}, checksum)
+
+	checksum, err = (&ChecksumOption{Algorithm: ChecksumAlgorithmCRC32}).verify(sum())
+	require.NoError(t, err)
+	require.Equal(t, crc32, checksum.Value)
+
+	_, err = (&ChecksumOption{Algorithm: ChecksumAlgorithmCRC32, Value: "AAAAAA=="}).verify(sum())
+	require.Equal(t, BadChecksum, err)
+
+	// the checksum is sent in the trailing header of the aws-chunked body
+	newTrailerRequest := func(trailer string) (*ChecksumOption, io.Reader) {
+		body := "b\r\nhello world\r\n0\r\n" + trailer + "\r\n"
+		r := httptest.NewRequest("PUT", "/bucket/key", nil)
+		r.Body = NewUnsignedChunkedReader(io.NopCloser(bytes.NewBufferString(body)))
+		r.Header.Set(XAmzTrailer, "x-amz-checksum-crc32")
+		opt, err := parseChecksumOption(r)
+		require.NoError(t, err)
+		return opt, r.Body
+	}
+	opt, body := newTrailerRequest("x-amz-checksum-crc32:" + crc32 + "\r\n")
+	h := newChecksumHash(opt.Algorithm)
+	_, err = io.Copy(h, body)
+	require.NoError(t, err)
+	checksum, err = opt.verify(h.Sum(nil))
+	require.NoError(t, err)
+	require.Equal(t, crc32, checksum.Value)
+
+	opt, body = newTrailerRequest("x-amz-checksum-crc32:AAAAAA==\r\n")
+	_, err = io.ReadAll(body)
+	require.NoError(t, err)
+	_, err = opt.verify(sum())
+	require.Equal(t, BadChecksum, err)
+
+	opt, body = newTrailerRequest("")
+	_, err = io.ReadAll(body)
+	require.NoError(t, err)
+	_, err = opt.verify(sum())
+	require.Equal(t, MissingChecksumTrailer, err)
+}
+
+func TestCompositeChecksum(t *testing.T) {
+	parts := [][]byte{[]byte("hello "), []byte("world")}
+	values := make([]string, 0, len(parts))
+	h := newChecksumHash(ChecksumAlgorithmSHA256)
+	for _, part := range parts {
+		sum := sha256.Sum256(part)
+		h.Write(sum[:])
+		values = append(values, base64.StdEncoding.EncodeToString(sum[:]))
+	}
+	checksum, err := compositeChecksum(ChecksumAlgorithmSHA256, values)
+	require.NoError(t, err)
+	require.Equal(t, 2, checksum.PartNum)
+	require.Equal(t, base64.StdEncoding.EncodeToString(h.Sum(nil))+"-2", checksum.String())
+	require.Equal(t, checksum.String(), checksum.valueOf(ChecksumAlgorithmSHA256))
+	require.Equal(t, "", checksum.valueOf(ChecksumAlgorithmCRC32))
+
+	_, err = compositeChecksum(ChecksumAlgorithmCRC32, values)
+	require.Equal(t, InvalidPart, err)
+
+	parsed, err := parseObjectChecksum([]byte(checksum.Encode()))
+	require.NoError(t, err)
+	require.Equal(t, checksum, parsed)
+	parsed, err = parseObjectChecksum(nil)
+	require.NoError(t, err)
+	require.Nil(t, parsed)
+}
+
+func TestSetChecksumResponseHeader(t *testing.T) {
+	checksum := &ObjectChecksum{Algorithm: ChecksumAlgorithmCRC32C, Value: "yZRlqg==", PartNum: 3}
+	xattr := &proto.XAttrInfo{XAttrs: map[string]string{XAttrKeyOSSChecksum: checksum.Encode()}}
+
+	r := httptest.NewRequest("GET", "/bucket/key", nil)
+	w := httptest.NewRecorder()
+	setChecksumResponseHeader(w, r, xattr)
+	require.Equal(t, "", w.Header().Get("x-amz-checksum-crc32c"))
+
+	r.Header.Set(XAmzChecksumMode, "ENABLED")
+	setChecksumResponseHeader(w, r, xattr)
+	require.Equal(t, "yZRlqg==-3", w.Header().Get("x-amz-checksum-crc32c"))
+}
@@ -0,0 +1,40 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/conditional-writes.html
+
+import (
+	"net/http"
+	"strings"
+)
+
+// parseWriteCondition parses the precondition of PutObject and CompleteMultipartUpload. The write
+// with If-None-Match succeeds only if the object does not exist, and the write with If-Match succeeds
+// only if the ETag of the current object matches, nil is returned if there is no precondition.
+func parseWriteCondition(r *http.Request) (*WriteCondition, error) {
+	match := strings.TrimSpace(r.Header.Get(IfMatch))
+	noneMatch := strings.TrimSpace(r.Header.Get(IfNoneMatch))
+	if match == "" && noneMatch == "" {
+		return nil, nil
+	}
+	if noneMatch != "" && noneMatch != "*" {
+		return nil, ConditionalWriteNotImplemented
+	}
+	return &WriteCondition{
+		IfNoneMatch: noneMatch == "*",
+		IfMatch:     match,
+	}, nil
+}
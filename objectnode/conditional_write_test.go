@@ -0,0 +1,62 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWriteCondition(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPut, "http://bucket.cube.io/key", nil)
+	cond, err := parseWriteCondition(r)
+	require.NoError(t, err)
+	require.Nil(t, cond)
+
+	r.Header.Set(IfNoneMatch, "*")
+	cond, err = parseWriteCondition(r)
+	require.NoError(t, err)
+	require.Equal(t, &WriteCondition{IfNoneMatch: true}, cond)
+
+	r.Header.Set(IfNoneMatch, "\"etag\"")
+	_, err = parseWriteCondition(r)
+	require.Equal(t, ConditionalWriteNotImplemented, err)
+
+	r.Header.Del(IfNoneMatch)
+	r.Header.Set(IfMatch, "\"etag\"")
+	cond, err = parseWriteCondition(r)
+	require.NoError(t, err)
+	require.Equal(t, &WriteCondition{IfMatch: "\"etag\""}, cond)
+}
+
+func TestCheckWriteCondition(t *testing.T) {
+	v := &Volume{}
+	inode, err := v.checkWriteCondition(nil, 10, true)
+	require.NoError(t, err)
+	require.Zero(t, inode)
+
+	cond := &WriteCondition{IfNoneMatch: true}
+	inode, err = v.checkWriteCondition(cond, 0, false)
+	require.NoError(t, err)
+	require.Zero(t, inode)
+	_, err = v.checkWriteCondition(cond, 10, true)
+	require.Equal(t, PreconditionFailed, err)
+
+	_, err = v.checkWriteCondition(&WriteCondition{IfMatch: "etag"}, 0, false)
+	require.Equal(t, NoSuchKey, err)
+}
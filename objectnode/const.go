@@ -71,6 +71,8 @@ const (
 	XAmzTagging                     = "x-amz-tagging"
 	XAmzMetaPrefix                  = "x-amz-meta-"
 	XAmzMpPartsCount                = "x-amz-mp-parts-count"
+	XAmzObjectAttributes            = "x-amz-object-attributes"
+	XAmzNextAppendPosition          = "x-amz-next-append-position"
 	XAmzMetadataDirective           = "x-amz-metadata-directive"
 	XAmzBucketRegion                = "x-amz-bucket-region"
 	XAmzStorageClass                = "x-amz-storage-class"
@@ -85,16 +87,68 @@ const (
 	XAmzSecurityToken               = "X-Amz-Security-Token" // #nosec G101
 	XAmzObjectLockMode              = "X-Amz-Object-Lock-Mode"
 	XAmzObjectLockRetainUntilDate   = "X-Amz-Object-Lock-Retain-Until-Date"
+	XAmzObjectLockLegalHold         = "X-Amz-Object-Lock-Legal-Hold"
+	XAmzBypassGovernanceRetention   = "X-Amz-Bypass-Governance-Retention"
+	XAmzVersionId                   = "x-amz-version-id"
+	XAmzDeleteMarker                = "x-amz-delete-marker"
+	XAmzCopySourceVersionId         = "x-amz-copy-source-version-id"
+
+	XAmzServerSideEncryption                            = "x-amz-server-side-encryption"
+	XAmzServerSideEncryptionCustomerAlgorithm           = "x-amz-server-side-encryption-customer-algorithm"
+	XAmzServerSideEncryptionCustomerKey                 = "x-amz-server-side-encryption-customer-key"
+	XAmzServerSideEncryptionCustomerKeyMD5              = "x-amz-server-side-encryption-customer-key-MD5"
+	XAmzCopySourceServerSideEncryptionCustomerAlgorithm = "x-amz-copy-source-server-side-encryption-customer-algorithm"
+	XAmzCopySourceServerSideEncryptionCustomerKey       = "x-amz-copy-source-server-side-encryption-customer-key"
+	XAmzCopySourceServerSideEncryptionCustomerKeyMD5    = "x-amz-copy-source-server-side-encryption-customer-key-MD5"
+	XAmzReplicationStatus                               = "x-amz-replication-status"
+	XAmzChecksumPrefix                                  = "x-amz-checksum-"
+	XAmzChecksumAlgorithm                               = "x-amz-checksum-algorithm"
+	XAmzSdkChecksumAlgorithm                            = "x-amz-sdk-checksum-algorithm"
+	XAmzChecksumMode                                    = "x-amz-checksum-mode"
+	XAmzTrailer                                         = "x-amz-trailer"
+	XAmzRestore                                         = "x-amz-restore"
 
 	HeaderNameXAmzDecodedContentLength = "x-amz-decoded-content-length"
 )
 
+// headers of the Swift API
+const (
+	XAuthUser                  = "X-Auth-User"
+	XAuthKey                   = "X-Auth-Key"
+	XAuthToken                 = "X-Auth-Token"
+	XAuthTokenExpires          = "X-Auth-Token-Expires"
+	XStorageUser               = "X-Storage-User"
+	XStoragePass               = "X-Storage-Pass"
+	XStorageToken              = "X-Storage-Token"
+	XStorageUrl                = "X-Storage-Url"
+	XSubjectToken              = "X-Subject-Token"
+	XTransId                   = "X-Trans-Id"
+	XOpenstackRequestId        = "X-Openstack-Request-Id"
+	XAccountMetaPrefix         = "X-Account-Meta-"
+	XRemoveAccountMetaPrefix   = "X-Remove-Account-Meta-"
+	XAccountContainerCount     = "X-Account-Container-Count"
+	XAccountObjectCount        = "X-Account-Object-Count"
+	XAccountBytesUsed          = "X-Account-Bytes-Used"
+	XContainerMetaPrefix       = "X-Container-Meta-"
+	XRemoveContainerMetaPrefix = "X-Remove-Container-Meta-"
+	XContainerObjectCount      = "X-Container-Object-Count"
+	XContainerBytesUsed        = "X-Container-Bytes-Used"
+	XObjectMetaPrefix          = "X-Object-Meta-"
+	XObjectManifest            = "X-Object-Manifest"
+	XStaticLargeObject         = "X-Static-Large-Object"
+	XCopyFrom                  = "X-Copy-From"
+	XFreshMetadata             = "X-Fresh-Metadata"
+	XTimestamp                 = "X-Timestamp"
+	Destination                = "Destination"
+)
+
 const (
 	ValueServer               = "CubeFS"
 	ValueAcceptRanges         = "bytes"
 	ValueContentTypeStream    = "application/octet-stream"
 	ValueContentTypeXML       = "application/xml"
 	ValueContentTypeJSON      = "application/json"
+	ValueContentTypeText      = "text/plain"
 	ValueContentTypeDirectory = "application/directory"
 	ValueMultipartFormData    = "multipart/form-data"
 )
@@ -115,6 +169,11 @@ const (
 	ParamMaxKeys    = "max-keys"
 	ParamStartAfter = "start-after"
 	ParamKey        = "key"
+	ParamId         = "id"
+	ParamPosition   = "position"
+
+	ParamVersionId       = "versionId"
+	ParamVersionIdMarker = "version-id-marker"
 
 	ParamMaxParts       = "max-parts"
 	ParamUploadIdMarker = "upload-id-marker"
@@ -154,6 +213,27 @@ const (
 	XAttrKeyOSSLock         = "oss:lock"
 	XAttrKeyOSSCacheControl = "oss:cache"
 	XAttrKeyOSSExpires      = "oss:expires"
+	XAttrKeyOSSVersioning   = "oss:versioning"
+	XAttrKeyOSSVersionId    = "oss:vid"
+	XAttrKeyOSSEncryption   = "oss:encryption"
+	XAttrKeyOSSSSE          = "oss:sse"
+	XAttrKeyOSSReplication  = "oss:replication"
+	XAttrKeyOSSNotification = "oss:notification"
+	XAttrKeyOSSWebsite      = "oss:website"
+	XAttrKeyOSSLogging      = "oss:logging"
+	XAttrKeyOSSMetrics      = "oss:metrics"
+	XAttrKeyOSSPAB          = "oss:public-access-block"
+	XAttrKeyOSSSwiftMeta    = "oss:swift-meta"
+	XAttrKeyOSSKeyIndex     = "oss:key-index"
+
+	XAttrKeyOSSReplicationStatus = "oss:replication-status"
+	XAttrKeyOSSChecksum          = "oss:checksum"
+	XAttrKeyOSSAppend            = "oss:append"
+	XAttrKeyOSSLockMode          = "oss:lock-mode"
+	XAttrKeyOSSLegalHold         = "oss:legal-hold"
+	XAttrKeyOSSSwiftSLO          = "oss:swift-slo"
+	XAttrKeyOSSSwiftDLO          = "oss:swift-dlo"
+	XAttrKeyOSSSharedPart        = "oss:shared-part"
 
 	// Deprecated
 	XAttrKeyOSSETagDeprecated = "oss:tag"
@@ -34,7 +34,11 @@ type FSFileInfo struct {
 	Expires         string
 	Metadata        map[string]string `graphql:"-"` // User-defined metadata
 	RetainUntilDate string
+	ObjectLockMode  string
+	LegalHold       string
 	StorageClass    uint32
+	VersionId       string
+	Checksum        *ObjectChecksum `graphql:"-"` // Additional checksum
 }
 
 type Prefixes []string
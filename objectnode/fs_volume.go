@@ -16,7 +16,9 @@ package objectnode
 
 import (
 	"context"
+	"crypto/aes"
 	"crypto/md5"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"encoding/xml"
@@ -93,6 +95,32 @@ type PutFileOption struct {
 	CacheControl string
 	Expires      string
 	ObjectLock   *ObjectLockConfig
+	// LockOption is the object lock specified by the request, its retention overrides the
+	// default retention of ObjectLock.
+	LockOption *ObjectLockOption
+	// SSE is the encryption of the object to be written, CopySourceSSE is the customer
+	// provided key of the encrypted copy source object.
+	SSE           *SSEOption
+	CopySourceSSE *SSEOption
+	// ReplicationStatus is set to REPLICA if the object is written by the replication.
+	ReplicationStatus string
+	// Checksum is the additional checksum to be computed and validated, only the algorithm
+	// is used by the initiation of multipart upload and the copy.
+	Checksum *ChecksumOption
+	// Condition is the precondition of the write, it is checked again atomically when
+	// the written object is applied to the dentry.
+	Condition *WriteCondition
+	// Appendable keeps the state of the MD5 hash of the object, so that the ETag can be
+	// updated by the following appends without reading the object.
+	Appendable bool
+}
+
+// WriteCondition is the precondition of the conditional write.
+type WriteCondition struct {
+	// IfNoneMatch requires that the object does not exist.
+	IfNoneMatch bool
+	// IfMatch requires that the ETag of the current object matches.
+	IfMatch string
 }
 
 type ListFilesV1Option struct {
@@ -213,6 +241,71 @@ func (v *Volume) loadOSSMeta() {
 		return
 	}
 	v.metaLoader.storeObjectLock(objectlock)
+
+	var versioning *VersioningConfiguration
+	if versioning, err = v.loadBucketVersioning(); err != nil {
+		return
+	}
+	v.metaLoader.storeVersioning(versioning)
+
+	var encryption *ServerSideEncryptionConfiguration
+	if encryption, err = v.loadBucketEncryption(); err != nil {
+		return
+	}
+	v.metaLoader.storeEncryption(encryption)
+
+	var replication *ReplicationConfiguration
+	if replication, err = v.loadBucketReplication(); err != nil {
+		return
+	}
+	v.metaLoader.storeReplication(replication)
+
+	var notification *NotificationConfiguration
+	if notification, err = v.loadBucketNotification(); err != nil {
+		return
+	}
+	v.metaLoader.storeNotification(notification)
+
+	var website *WebsiteConfiguration
+	if website, err = v.loadBucketWebsite(); err != nil {
+		return
+	}
+	v.metaLoader.storeWebsite(website)
+
+	var logging *BucketLoggingStatus
+	if logging, err = v.loadBucketLogging(); err != nil {
+		return
+	}
+	v.metaLoader.storeLogging(logging)
+
+	var metrics *BucketMetricsConfigurations
+	if metrics, err = v.loadBucketMetrics(); err != nil {
+		return
+	}
+	v.metaLoader.storeMetrics(metrics)
+
+	var pab *PublicAccessBlockConfiguration
+	if pab, err = v.loadBucketPublicAccessBlock(); err != nil {
+		return
+	}
+	v.metaLoader.storePublicAccessBlock(pab)
+
+	var keyIndex *BucketKeyIndex
+	if keyIndex, err = v.loadBucketKeyIndex(); err != nil {
+		return
+	}
+	v.metaLoader.storeKeyIndex(keyIndex)
+
+	// the account level configuration is kept if it fails to be refreshed from the master,
+	// which is not the failure of the volume.
+	var accountPAB *PublicAccessBlockConfiguration
+	if accountPAB, err = v.loadAccountPublicAccessBlock(); err != nil {
+		log.LogWarnf("loadOSSMeta: load account public access block fail: volume(%s) owner(%s) err(%v)",
+			v.name, v.owner, err)
+		err = nil
+	} else {
+		v.metaLoader.storeAccountPublicAccessBlock(accountPAB)
+	}
 	v.metaLoader.setSynced()
 }
 
@@ -292,6 +385,167 @@ func (v *Volume) loadObjectLock() (configuration *ObjectLockConfig, err error) {
 	return configuration, nil
 }
 
+func (v *Volume) loadBucketVersioning() (configuration *VersioningConfiguration, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSVersioning); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	configuration = &VersioningConfiguration{}
+	if err = json.Unmarshal(raw, configuration); err != nil {
+		return
+	}
+	return configuration, nil
+}
+
+func (v *Volume) loadBucketEncryption() (configuration *ServerSideEncryptionConfiguration, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSEncryption); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	configuration = &ServerSideEncryptionConfiguration{}
+	if err = json.Unmarshal(raw, configuration); err != nil {
+		return
+	}
+	return configuration, nil
+}
+
+func (v *Volume) loadBucketReplication() (configuration *ReplicationConfiguration, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSReplication); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	configuration = &ReplicationConfiguration{}
+	if err = json.Unmarshal(raw, configuration); err != nil {
+		return
+	}
+	return configuration, nil
+}
+
+func (v *Volume) loadBucketNotification() (configuration *NotificationConfiguration, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSNotification); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	configuration = &NotificationConfiguration{}
+	if err = json.Unmarshal(raw, configuration); err != nil {
+		return
+	}
+	return configuration, nil
+}
+
+func (v *Volume) loadBucketWebsite() (configuration *WebsiteConfiguration, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSWebsite); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	configuration = &WebsiteConfiguration{}
+	if err = json.Unmarshal(raw, configuration); err != nil {
+		return
+	}
+	return configuration, nil
+}
+
+func (v *Volume) loadBucketLogging() (status *BucketLoggingStatus, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSLogging); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	status = &BucketLoggingStatus{}
+	if err = json.Unmarshal(raw, status); err != nil {
+		return
+	}
+	return status, nil
+}
+
+func (v *Volume) loadBucketMetrics() (configs *BucketMetricsConfigurations, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSMetrics); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	configs = &BucketMetricsConfigurations{}
+	if err = json.Unmarshal(raw, configs); err != nil {
+		return
+	}
+	return configs, nil
+}
+
+func (v *Volume) loadBucketPublicAccessBlock() (configuration *PublicAccessBlockConfiguration, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSPAB); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	configuration = &PublicAccessBlockConfiguration{}
+	if err = json.Unmarshal(raw, configuration); err != nil {
+		return
+	}
+	return configuration, nil
+}
+
+func (v *Volume) loadBucketKeyIndex() (index *BucketKeyIndex, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSKeyIndex); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	index = &BucketKeyIndex{}
+	if err = json.Unmarshal(raw, index); err != nil {
+		return
+	}
+	return index, nil
+}
+
+// loadAccountPublicAccessBlock loads the public access block configuration of the bucket owner.
+func (v *Volume) loadAccountPublicAccessBlock() (configuration *PublicAccessBlockConfiguration, err error) {
+	var userInfo *proto.UserInfo
+	if userInfo, err = v.mc.UserAPI().GetUserInfo(v.owner); err != nil {
+		if err == proto.ErrUserNotExists {
+			return nil, nil
+		}
+		return
+	}
+	return newPublicAccessBlockConfiguration(userInfo.PublicAccessBlock), nil
+}
+
+// publicAccessBlock returns the effective public access block configuration of the bucket,
+// which combines the configurations of the bucket and its owner.
+func (v *Volume) publicAccessBlock() (*PublicAccessBlockConfiguration, error) {
+	bucket, err := v.metaLoader.loadPublicAccessBlock()
+	if err != nil {
+		return nil, err
+	}
+	account, err := v.metaLoader.loadAccountPublicAccessBlock()
+	if err != nil {
+		return nil, err
+	}
+	return mergePublicAccessBlock(bucket, account), nil
+}
+
 func (v *Volume) getInodeFromPath(path string) (inode uint64, err error) {
 	if path == "/" {
 		return volumeRootInode, nil
@@ -377,7 +631,12 @@ func (v *Volume) IsEmpty() bool {
 		log.LogDebugf("IsEmpty: parent ino(%v), children: %v", proto.RootIno, children)
 		return false
 	}
-	return true
+	hasVersions, err := v.hasObjectVersions("")
+	if err != nil {
+		log.LogErrorf("IsEmpty: check versions: parent ino(%v) err(%v)", proto.RootIno, err)
+		return false
+	}
+	return !hasVersions
 }
 
 func (v *Volume) GetXAttr(path string, key string) (info *proto.XAttrInfo, err error) {
@@ -686,10 +945,22 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 		return
 	}
 
-	// check whether existing object is protected by object lock
-	if oldInode != 0 && opt != nil && opt.ObjectLock != nil {
-		err = isObjectLocked(v, oldInode, lastPathItem.Name, path)
-		if err != nil {
+	// check the precondition before writing data, the inode to be replaced is checked again
+	// when the new inode is applied to the dentry.
+	var cond *WriteCondition
+	if opt != nil {
+		cond = opt.Condition
+	}
+	var expectInode uint64
+	if expectInode, err = v.checkWriteCondition(cond, oldInode, err == nil); err != nil {
+		log.LogErrorf("PutObject: check precondition fail: volume(%v) path(%v) inode(%v) cond(%+v) err(%v)",
+			v.name, path, oldInode, cond, err)
+		return
+	}
+
+	// check whether the replaced objects are protected by object lock
+	if opt != nil && opt.ObjectLock != nil {
+		if err = v.checkReplaceLocked(oldInode, lastPathItem.Name, path); err != nil {
 			return
 		}
 	}
@@ -714,7 +985,27 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 		}
 	}()
 
+	// The MD5 is always computed over the plaintext, and the data is encrypted before written if required.
 	md5Hash := md5.New()
+	dataHash := hash.Hash(md5Hash)
+	var checksumHash hash.Hash
+	if opt != nil && opt.Checksum != nil {
+		checksumHash = newChecksumHash(opt.Checksum.Algorithm)
+		reader = io.TeeReader(reader, checksumHash)
+	}
+	var sse *sseMeta
+	if opt != nil && opt.SSE != nil {
+		var dataKey []byte
+		if sse, dataKey, err = newSSEMeta(opt.SSE); err != nil {
+			log.LogErrorf("PutObject: new sse meta fail: volume(%v) path(%v) err(%v)", v.name, path, err)
+			return
+		}
+		if reader, err = encryptReader(io.TeeReader(reader, md5Hash), dataKey, sse.IV); err != nil {
+			log.LogErrorf("PutObject: new encrypt reader fail: volume(%v) path(%v) err(%v)", v.name, path, err)
+			return
+		}
+		dataHash = md5.New()
+	}
 	isCache := false
 	if proto.IsCold(v.volType) || proto.IsStorageClassBlobStore(invisibleTempDataInode.StorageClass) {
 		isCache = true
@@ -732,13 +1023,13 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 	}()
 
 	if proto.IsCold(v.volType) || proto.IsStorageClassBlobStore(invisibleTempDataInode.StorageClass) {
-		if _, err = v.ebsWrite(invisibleTempDataInode.Inode, reader, md5Hash, invisibleTempDataInode.StorageClass); err != nil {
+		if _, err = v.ebsWrite(invisibleTempDataInode.Inode, reader, dataHash, invisibleTempDataInode.StorageClass); err != nil {
 			log.LogErrorf("PutObject: ebs write fail: volume(%v) path(%v) inode(%v) err(%v)",
 				v.name, path, invisibleTempDataInode.Inode, err)
 			return
 		}
 	} else {
-		if _, err = v.streamWrite(invisibleTempDataInode.Inode, reader, md5Hash, invisibleTempDataInode.StorageClass); err != nil {
+		if _, err = v.streamWrite(invisibleTempDataInode.Inode, reader, dataHash, invisibleTempDataInode.StorageClass); err != nil {
 			log.LogErrorf("PutObject: stream write fail: volume(%v) path(%v) inode(%v) err(%v)",
 				v.name, path, invisibleTempDataInode.Inode, err)
 			return
@@ -764,6 +1055,16 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 		TS:      finalInode.ModifyTime,
 	}
 
+	// the checksum of the trailing header is available after the whole body is read
+	var checksum *ObjectChecksum
+	if checksumHash != nil {
+		if checksum, err = opt.Checksum.verify(checksumHash.Sum(nil)); err != nil {
+			log.LogErrorf("PutObject: verify checksum fail: volume(%v) path(%v) algorithm(%v) err(%v)",
+				v.name, path, opt.Checksum.Algorithm, err)
+			return
+		}
+	}
+
 	attr := &AttrItem{
 		XAttrInfo: proto.XAttrInfo{
 			Inode:  invisibleTempDataInode.Inode,
@@ -790,8 +1091,25 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 	if opt != nil && opt.ACL != nil {
 		attr.XAttrs[XAttrKeyOSSACL] = opt.ACL.Encode()
 	}
-	if opt != nil && opt.ObjectLock != nil && opt.ObjectLock.ToRetention() != nil {
-		attr.XAttrs[XAttrKeyOSSLock] = formatRetentionDateStr(finalInode.ModifyTime, opt.ObjectLock.ToRetention())
+	if opt != nil {
+		setObjectLockXAttrs(attr.XAttrs, finalInode.ModifyTime, opt.ObjectLock, opt.LockOption)
+	}
+	if sse != nil {
+		attr.XAttrs[XAttrKeyOSSSSE] = sse.Encode()
+	}
+	if opt != nil && opt.ReplicationStatus != "" {
+		attr.XAttrs[XAttrKeyOSSReplicationStatus] = opt.ReplicationStatus
+	}
+	if checksum != nil {
+		attr.XAttrs[XAttrKeyOSSChecksum] = checksum.Encode()
+	}
+	if opt != nil && opt.Appendable {
+		var state string
+		if state, err = encodeAppendState(md5Hash); err != nil {
+			log.LogErrorf("PutObject: encode append state fail: volume(%v) path(%v) err(%v)", v.name, path, err)
+			return
+		}
+		attr.XAttrs[XAttrKeyOSSAppend] = state
 	}
 
 	// If user-defined metadata have been specified, use extend attributes for storage.
@@ -804,6 +1122,12 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 		}
 	}
 
+	var versionId string
+	if versionId, err = v.assignVersionId(attr.XAttrs); err != nil {
+		log.LogErrorf("PutObject: assign version id fail: volume(%v) path(%v) err(%v)", v.name, path, err)
+		return
+	}
+
 	if err = v.mw.BatchSetXAttr_ll(invisibleTempDataInode.Inode, attr.XAttrs); err != nil {
 		log.LogErrorf("PutObject: BatchSetXAttr_ll fail: volume(%v) path(%v) inode(%v) attrs(%v) err(%v)",
 			v.name, path, invisibleTempDataInode.Inode, attr.XAttrs, err)
@@ -820,11 +1144,13 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 		ModifyTime: finalInode.ModifyTime,
 		ETag:       etagValue.ETag(),
 		Inode:      finalInode.Inode,
+		VersionId:  versionId,
+		Checksum:   checksum,
 	}
 
 	// apply new inode to dentry
 	err = v.applyInodeToDEntry(parentId, lastPathItem.Name, invisibleTempDataInode.Inode, false,
-		fixedPath, invisibleTempDataInode.StorageClass)
+		fixedPath, invisibleTempDataInode.StorageClass, cond, expectInode)
 	if err != nil {
 		log.LogErrorf("PutObject: apply new inode to dentry fail: parentID(%v) name(%v) inode(%v) err(%v)",
 			parentId, lastPathItem.Name, invisibleTempDataInode.Inode, err)
@@ -838,8 +1164,43 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 	return fsInfo, nil
 }
 
+// checkWriteCondition checks the precondition of the write against the current object, and returns
+// the inode which is expected to be replaced by the conditional write, zero if there is no condition on it.
+func (v *Volume) checkWriteCondition(cond *WriteCondition, oldInode uint64, exist bool) (expectInode uint64, err error) {
+	if cond == nil {
+		return
+	}
+	if cond.IfNoneMatch && exist {
+		err = PreconditionFailed
+		return
+	}
+	if cond.IfMatch == "" {
+		return
+	}
+	if !exist {
+		err = NoSuchKey
+		return
+	}
+	var xattr *proto.XAttrInfo
+	if xattr, err = v.mw.XAttrGetAll_ll(oldInode); err != nil {
+		log.LogErrorf("checkWriteCondition: get xattr fail: volume(%v) inode(%v) err(%v)", v.name, oldInode, err)
+		return
+	}
+	rawETag := string(xattr.Get(XAttrKeyOSSETag))
+	if len(rawETag) == 0 {
+		rawETag = string(xattr.Get(XAttrKeyOSSETagDeprecated))
+	}
+	if match := strings.Trim(cond.IfMatch, "\""); match != "*" && match != ParseETagValue(rawETag).ETag() {
+		err = PreconditionFailed
+		return
+	}
+	return oldInode, nil
+}
+
+// applyInodeToDEntry applies the inode to the dentry of the object. If the condition is specified,
+// the dentry is created only if it does not exist, or updated only if it still points to expectInode.
 func (v *Volume) applyInodeToDEntry(parentId uint64, name string, inode uint64, isCompleteMultipart bool,
-	fullPath string, storageClass uint32,
+	fullPath string, storageClass uint32, cond *WriteCondition, expectInode uint64,
 ) (err error) {
 	var existMode uint32
 	_, existMode, err = v.mw.Lookup_ll(parentId, name) // exist object inode
@@ -849,7 +1210,17 @@ func (v *Volume) applyInodeToDEntry(parentId uint64, name string, inode uint64,
 	}
 
 	if err == syscall.ENOENT {
+		if expectInode != 0 {
+			log.LogWarnf("applyInodeToDEntry: object removed concurrently: parentID(%v) name(%v) expectInode(%v)",
+				parentId, name, expectInode)
+			return PreconditionFailed
+		}
 		if err = v.applyInodeToNewDentry(parentId, name, inode, fullPath); err != nil {
+			if err == syscall.EEXIST && cond != nil && cond.IfNoneMatch {
+				log.LogWarnf("applyInodeToDEntry: object created concurrently: parentID(%v) name(%v) inode(%v)",
+					parentId, name, inode)
+				return PreconditionFailed
+			}
 			log.LogErrorf("applyInodeToDEntry: apply inode to new dentry fail: parentID(%v) name(%v) inode(%v) err(%v)",
 				parentId, name, inode, err)
 			return
@@ -863,15 +1234,26 @@ func (v *Volume) applyInodeToDEntry(parentId uint64, name string, inode uint64,
 			err = syscall.EINVAL
 			return
 		}
-		// current implementation doesn't support object versioning, so uploading a object with a key already existed in bucket
-		// is implemented with replacing the old one instead.
+		if cond != nil && cond.IfNoneMatch {
+			log.LogWarnf("applyInodeToDEntry: object created concurrently: parentID(%v) name(%v) inode(%v)",
+				parentId, name, inode)
+			return PreconditionFailed
+		}
+		// uploading a object with a key already existed in bucket is implemented with replacing the old one,
+		// and the old one is kept as a noncurrent version if bucket versioning is configured.
 		// refer: https://docs.aws.amazon.com/AmazonS3/latest/userguide/upload-objects.html
-		if err = v.applyInodeToExistDentry(parentId, name, inode, isCompleteMultipart, fullPath, storageClass); err != nil {
+		if err = v.applyInodeToExistDentry(parentId, name, inode, isCompleteMultipart, fullPath, storageClass, expectInode); err != nil {
 			log.LogErrorf("applyInodeToDEntry: apply inode to exist dentry fail: parentID(%v) name(%v) inode(%v) err(%v)",
 				parentId, name, inode, err)
 			return
 		}
 	}
+	v.putKeyIndex(fullPath, inode)
+	// the new object is the null version if versioning is suspended, which replaces the noncurrent null version.
+	if releaseErr := v.releaseNullVersion(name, fullPath); releaseErr != nil {
+		log.LogWarnf("applyInodeToDEntry: release null version fail: parentID(%v) name(%v) err(%v)",
+			parentId, name, releaseErr)
+	}
 	return
 }
 
@@ -883,6 +1265,10 @@ func (v *Volume) applyInodeToDEntry(parentId uint64, name string, inode uint64,
 // This method will only returns internal system errors.
 // This method will not return syscall.ENOENT error
 func (v *Volume) DeletePath(path string) (err error) {
+	return v.deletePath(path, false)
+}
+
+func (v *Volume) deletePath(path string, bypassGovernance bool) (err error) {
 	defer func() {
 		// Audit behavior
 		log.LogInfof("Audit: DeletePath: volume(%v) path(%v), err(%v)", v.name, path, err)
@@ -912,6 +1298,11 @@ func (v *Volume) DeletePath(path string) (err error) {
 		if err != nil || len(dentries) > 0 {
 			return
 		}
+		// The directory holding noncurrent versions cannot be deleted.
+		var hasVersions bool
+		if hasVersions, err = v.hasObjectVersions(strings.TrimSuffix(path, pathSep) + pathSep); err != nil || hasVersions {
+			return
+		}
 	}
 	// check whether object is protected by object lock
 	objetLock, err := v.metaLoader.loadObjectLock()
@@ -923,13 +1314,30 @@ func (v *Volume) DeletePath(path string) (err error) {
 
 	// delete dentry with condition when objectlock is open
 	if objetLock != nil {
-		_, err = v.mw.DeleteWithCond_ll(parent, ino, name, mode.IsDir(), path)
+		// the condition deletion refuses to delete any retained object, so the bypassed
+		// retention in GOVERNANCE mode is removed in advance, and restored if the deletion fails.
+		var bypassed *ObjectRetention
+		if bypassGovernance && !mode.IsDir() {
+			if bypassed, err = v.removeGovernanceRetention(ino, name, path); err != nil {
+				return
+			}
+		}
+		if _, err = v.mw.DeleteWithCond_ll(parent, ino, name, mode.IsDir(), path); err != nil && bypassed != nil {
+			if restoreErr := v.setObjectRetention(ino, bypassed); restoreErr != nil {
+				log.LogErrorf("DeletePath: restore retention fail: volume(%v) path(%v) inode(%v) retention(%v) err(%v)",
+					v.name, path, ino, bypassed, restoreErr)
+			}
+		}
 	} else {
 		_, err = v.mw.Delete_ll(parent, name, mode.IsDir(), path)
 	}
 	if err != nil {
 		return
 	}
+	if !mode.IsDir() {
+		v.releaseRestoredCopy(ino, path)
+		v.syncKeyIndex(path)
+	}
 
 	if err = v.ec.EvictStream(ino); err != nil {
 		log.LogWarnf("DeletePath EvictStream: path(%v) inode(%v)", path, ino)
@@ -997,6 +1405,23 @@ func (v *Volume) InitMultipart(path string, opt *PutFileOption) (multipartID str
 	if opt != nil && opt.ACL != nil {
 		extend[XAttrKeyOSSACL] = opt.ACL.Encode()
 	}
+	// If object lock have been specified, use extend attributes for storage.
+	if opt != nil && opt.LockOption != nil {
+		setObjectLockXAttrs(extend, time.Time{}, nil, opt.LockOption)
+	}
+	// If encryption have been specified, all parts are encrypted with the same data key.
+	if opt != nil && opt.SSE != nil {
+		var sse *sseMeta
+		if sse, _, err = newSSEMeta(opt.SSE); err != nil {
+			log.LogErrorf("InitMultipart: new sse meta fail: volume(%v) path(%v) err(%v)", v.name, path, err)
+			return
+		}
+		extend[XAttrKeyOSSSSE] = sse.Encode()
+	}
+	// If checksum algorithm have been specified, the checksums of all parts are computed with it.
+	if opt != nil && opt.Checksum != nil {
+		extend[XAttrKeyOSSChecksum] = (&ObjectChecksum{Algorithm: opt.Checksum.Algorithm}).Encode()
+	}
 
 	if v.mw.EnableQuota {
 		var parentId uint64
@@ -1020,7 +1445,8 @@ func (v *Volume) InitMultipart(path string, opt *PutFileOption) (multipartID str
 	return multipartID, nil
 }
 
-func (v *Volume) WritePart(path string, multipartId string, partId uint16, reader io.Reader) (*FSFileInfo, error) {
+func (v *Volume) WritePart(path string, multipartId string, partId uint16, reader io.Reader, sse *SSEOption,
+	checksumOpt *ChecksumOption) (*FSFileInfo, error) {
 	var exist bool
 	var err error
 	defer func() {
@@ -1032,6 +1458,43 @@ func (v *Volume) WritePart(path string, multipartId string, partId uint16, reade
 	var fInfo *FSFileInfo
 	_, fileName := splitPath(path)
 
+	// the part of an encrypted multipart upload is encrypted with the data key of the upload
+	var multipartInfo *proto.MultipartInfo
+	if multipartInfo, err = v.mw.GetMultipart_ll(path, multipartId); err != nil {
+		log.LogErrorf("WritePart: meta get multipart fail: volume(%v) path(%v) multipartID(%v) err(%v)",
+			v.name, path, multipartId, err)
+		return nil, err
+	}
+	var (
+		meta    *sseMeta
+		dataKey []byte
+	)
+	if meta, err = parseSSEMeta([]byte(multipartInfo.Extend[XAttrKeyOSSSSE])); err != nil {
+		log.LogErrorf("WritePart: parse sse meta fail: volume(%v) path(%v) multipartID(%v) err(%v)",
+			v.name, path, multipartId, err)
+		return nil, err
+	}
+	if dataKey, err = objectDataKey(meta, sse); err != nil {
+		log.LogErrorf("WritePart: check sse fail: volume(%v) path(%v) multipartID(%v) err(%v)",
+			v.name, path, multipartId, err)
+		return nil, err
+	}
+	// the part checksum is computed with the algorithm of the upload if it's not specified by the request
+	var uploadChecksum *ObjectChecksum
+	if uploadChecksum, err = parseObjectChecksum([]byte(multipartInfo.Extend[XAttrKeyOSSChecksum])); err != nil {
+		log.LogErrorf("WritePart: parse checksum fail: volume(%v) path(%v) multipartID(%v) err(%v)",
+			v.name, path, multipartId, err)
+		return nil, err
+	}
+	if uploadChecksum != nil {
+		if checksumOpt == nil {
+			checksumOpt = &ChecksumOption{Algorithm: uploadChecksum.Algorithm}
+		} else if checksumOpt.Algorithm != uploadChecksum.Algorithm {
+			err = ChecksumTypeMismatch
+			return nil, err
+		}
+	}
+
 	// create temp file (inode only, invisible for user)
 	var tempInodeInfo *proto.InodeInfo
 	if tempInodeInfo, err = v.mw.InodeCreate_ll(0, DefaultFileMode, 0, 0, nil, make([]uint64, 0), path); err != nil {
@@ -1065,10 +1528,34 @@ func (v *Volume) WritePart(path string, multipartId string, partId uint16, reade
 	}()
 
 	var (
-		size    uint64
-		etag    string
-		md5Hash = md5.New()
+		size         uint64
+		etag         string
+		md5Hash      = md5.New()
+		dataHash     = hash.Hash(md5Hash)
+		checksumHash hash.Hash
+		checksum     *ObjectChecksum
+		partAttrs    = make(map[string]string)
 	)
+	if checksumOpt != nil {
+		checksumHash = newChecksumHash(checksumOpt.Algorithm)
+		reader = io.TeeReader(reader, checksumHash)
+	}
+	if meta != nil {
+		// every written part has its own IV, a part uploaded again never reuses the keystream
+		var iv []byte
+		if iv, err = newSSEIV(); err != nil {
+			log.LogErrorf("WritePart: new sse iv fail: volume(%v) path(%v) multipartID(%v) partID(%v) err(%v)",
+				v.name, path, multipartId, partId, err)
+			return nil, err
+		}
+		if reader, err = encryptReader(io.TeeReader(reader, md5Hash), dataKey, iv); err != nil {
+			log.LogErrorf("WritePart: new encrypt reader fail: volume(%v) path(%v) multipartID(%v) partID(%v) err(%v)",
+				v.name, path, multipartId, partId, err)
+			return nil, err
+		}
+		dataHash = md5.New()
+		partAttrs[XAttrKeyOSSSSE] = (&ssePart{Number: partId, IV: iv}).Encode()
+	}
 	isCache := false
 	if proto.IsCold(v.volType) || proto.IsStorageClassBlobStore(tempInodeInfo.StorageClass) {
 		isCache = true
@@ -1085,14 +1572,14 @@ func (v *Volume) WritePart(path string, multipartId string, partId uint16, reade
 		}
 	}()
 	if proto.IsCold(v.volType) || proto.IsStorageClassBlobStore(tempInodeInfo.StorageClass) {
-		if size, err = v.ebsWrite(tempInodeInfo.Inode, reader, md5Hash, tempInodeInfo.StorageClass); err != nil {
+		if size, err = v.ebsWrite(tempInodeInfo.Inode, reader, dataHash, tempInodeInfo.StorageClass); err != nil {
 			log.LogErrorf("WritePart: ebs write fail: volume(%v) inode(%v) multipartID(%v) partID(%v) err(%v)",
 				v.name, tempInodeInfo.Inode, multipartId, partId, err)
 			return nil, err
 		}
 	} else {
 		// Write data to data node
-		if size, err = v.streamWrite(tempInodeInfo.Inode, reader, md5Hash, tempInodeInfo.StorageClass); err != nil {
+		if size, err = v.streamWrite(tempInodeInfo.Inode, reader, dataHash, tempInodeInfo.StorageClass); err != nil {
 			log.LogErrorf("WritePart: stream write fail: volume(%v) inode(%v) multipartID(%v) partID(%v) err(%v)",
 				v.name, tempInodeInfo.Inode, multipartId, partId, err)
 			return nil, err
@@ -1107,6 +1594,23 @@ func (v *Volume) WritePart(path string, multipartId string, partId uint16, reade
 	// compute file md5
 	etag = hex.EncodeToString(md5Hash.Sum(nil))
 
+	// the checksum and the encryption IV of part are stored in the xattrs of the part inode
+	if checksumHash != nil {
+		if checksum, err = checksumOpt.verify(checksumHash.Sum(nil)); err != nil {
+			log.LogErrorf("WritePart: verify checksum fail: volume(%v) path(%v) multipartID(%v) partID(%v) algorithm(%v) err(%v)",
+				v.name, path, multipartId, partId, checksumOpt.Algorithm, err)
+			return nil, err
+		}
+		partAttrs[XAttrKeyOSSChecksum] = checksum.Encode()
+	}
+	if len(partAttrs) > 0 {
+		if err = v.mw.BatchSetXAttr_ll(tempInodeInfo.Inode, partAttrs); err != nil {
+			log.LogErrorf("WritePart: set part xattrs fail: volume(%v) path(%v) multipartID(%v) partID(%v) inode(%v) err(%v)",
+				v.name, path, multipartId, partId, tempInodeInfo.Inode, err)
+			return nil, err
+		}
+	}
+
 	// update temp file inode to meta with session, overwrite existing part can result in exist == true
 	oldInode, exist, err = v.mw.AddMultipartPart_ll(path, multipartId, partId, size, etag, tempInodeInfo)
 	if err != nil {
@@ -1125,6 +1629,7 @@ func (v *Volume) WritePart(path string, multipartId string, partId uint16, reade
 		CreateTime: tempInodeInfo.CreateTime,
 		ETag:       etag,
 		Inode:      tempInodeInfo.Inode,
+		Checksum:   checksum,
 	}
 	return fInfo, nil
 }
@@ -1165,7 +1670,10 @@ func (v *Volume) AbortMultipart(path string, multipartID string) (err error) {
 	return nil
 }
 
-func (v *Volume) CompleteMultipart(path, multipartID string, multipartInfo *proto.MultipartInfo, discardedPartInodes map[uint64]uint16) (fsFileInfo *FSFileInfo, err error) {
+// CompleteMultipart merges the parts into the object, the partChecksums are the checksums of parts
+// specified by the request to be validated.
+func (v *Volume) CompleteMultipart(path, multipartID string, multipartInfo *proto.MultipartInfo, discardedPartInodes map[uint64]uint16,
+	partChecksums map[uint16]string, cond *WriteCondition) (fsFileInfo *FSFileInfo, err error) {
 	defer func() {
 		log.LogInfof("Audit: CompleteMultipart: volume(%v) path(%v) multipartID(%v) err(%v)",
 			v.name, path, multipartID, err)
@@ -1194,24 +1702,39 @@ func (v *Volume) CompleteMultipart(path, multipartID string, multipartInfo *prot
 		err = syscall.EINVAL
 		return
 	}
+	var expectInode uint64
+	if expectInode, err = v.checkWriteCondition(cond, oldInode, err == nil); err != nil {
+		log.LogErrorf("CompleteMultipart: check precondition fail: volume(%v) path(%v) multipartID(%v) inode(%v) cond(%+v) err(%v)",
+			v.name, path, multipartID, oldInode, cond, err)
+		return
+	}
 	// check whether object is protected by object lock
 	objectLock, err := v.metaLoader.loadObjectLock()
 	if err != nil {
 		log.LogErrorf("CompleteMultipart: load volume objectLock: volume(%v) err(%v)", v.name, err)
 		return
 	}
-	if oldInode != 0 && objectLock != nil {
-		err = isObjectLocked(v, oldInode, filename, path)
-		if err != nil {
+	if objectLock != nil {
+		if err = v.checkReplaceLocked(oldInode, filename, path); err != nil {
 			return
 		}
 	}
 	parts := multipartInfo.Parts
 	sort.SliceStable(parts, func(i, j int) bool { return parts[i].ID < parts[j].ID })
 
-	// create inode for complete data
+	// create inode for complete data, which keeps the extents shared by the parts copied within the
+	// volume only if it's in the same meta partition as the shared parts
+	var sharedInode uint64
+	if sharedInode, err = v.sharedPartInode(path, multipartID, parts, 0); err != nil {
+		return
+	}
 	var completeInodeInfo *proto.InodeInfo
-	if completeInodeInfo, err = v.mw.InodeCreate_ll(parentId, DefaultFileMode, 0, 0, nil, make([]uint64, 0), path); err != nil {
+	if sharedInode != 0 {
+		completeInodeInfo, err = v.mw.InodeCreateNear_ll(sharedInode, parentId, DefaultFileMode, 0, 0, nil, path)
+	} else {
+		completeInodeInfo, err = v.mw.InodeCreate_ll(parentId, DefaultFileMode, 0, 0, nil, make([]uint64, 0), path)
+	}
+	if err != nil {
 		log.LogErrorf("CompleteMultipart: meta inode create fail: volume(%v) path(%v) multipartID(%v) err(%v)",
 			v.name, path, multipartID, err)
 		return
@@ -1319,8 +1842,36 @@ func (v *Volume) CompleteMultipart(path, multipartID string, multipartInfo *prot
 			attrs[key] = value
 		}
 	}
-	if objectLock != nil && objectLock.ToRetention() != nil {
-		attrs[XAttrKeyOSSLock] = formatRetentionDateStr(finalInode.ModifyTime, objectLock.ToRetention())
+	// the retention specified by the request of initiation overrides the default retention
+	if _, ok := attrs[XAttrKeyOSSLock]; !ok {
+		setObjectLockXAttrs(attrs, finalInode.ModifyTime, objectLock, nil)
+	}
+	// record the parts of the encrypted object to locate the counter of any offset
+	if raw, ok := attrs[XAttrKeyOSSSSE]; ok {
+		var sse *sseMeta
+		if sse, err = parseSSEMeta([]byte(raw)); err != nil {
+			log.LogErrorf("CompleteMultipart: parse sse meta fail: volume(%v) multipartID(%v) err(%v)",
+				v.name, multipartID, err)
+			return
+		}
+		if sse.Parts, err = v.multipartSSEParts(path, multipartID, parts); err != nil {
+			return
+		}
+		attrs[XAttrKeyOSSSSE] = sse.Encode()
+	}
+	// the checksum of the object is the composite checksum of parts
+	var checksum *ObjectChecksum
+	if raw, ok := attrs[XAttrKeyOSSChecksum]; ok {
+		if checksum, err = v.multipartChecksum(path, multipartID, []byte(raw), parts, partChecksums); err != nil {
+			return
+		}
+		attrs[XAttrKeyOSSChecksum] = checksum.Encode()
+	}
+	var versionId string
+	if versionId, err = v.assignVersionId(attrs); err != nil {
+		log.LogErrorf("CompleteMultipart: assign version id fail: volume(%v) multipartID(%v) err(%v)",
+			v.name, multipartID, err)
+		return
 	}
 	if err = v.mw.BatchSetXAttr_ll(finalInode.Inode, attrs); err != nil {
 		log.LogErrorf("CompleteMultipart: store multipart extend fail: volume(%v) multipartID(%v) inode(%v) "+
@@ -1330,7 +1881,7 @@ func (v *Volume) CompleteMultipart(path, multipartID string, multipartInfo *prot
 
 	// apply new inode to dentry
 	if err = v.applyInodeToDEntry(parentId, filename, completeInodeInfo.Inode, true,
-		path, completeInodeInfo.StorageClass); err != nil {
+		path, completeInodeInfo.StorageClass, cond, expectInode); err != nil {
 		log.LogErrorf("CompleteMultipart: apply inode to dentry fail: volume(%v) multipartID(%v) parentId(%v) "+
 			"fileName(%v) inode(%v) err(%v)", v.name, multipartID, parentId, filename, completeInodeInfo.Inode, err)
 		return
@@ -1380,11 +1931,82 @@ func (v *Volume) CompleteMultipart(path, multipartID string, multipartInfo *prot
 		ModifyTime: time.Now(),
 		ETag:       etagValue.ETag(),
 		Inode:      finalInode.Inode,
+		VersionId:  versionId,
+		Checksum:   checksum,
 	}
 
 	return fInfo, nil
 }
 
+// multipartSSEParts returns the sizes of parts and the IVs stored with the parts.
+func (v *Volume) multipartSSEParts(path, multipartID string, parts []*proto.MultipartPartInfo) (sseParts []*ssePart, err error) {
+	inodes := make([]uint64, 0, len(parts))
+	for _, part := range parts {
+		inodes = append(inodes, part.Inode)
+	}
+	var xattrs []*proto.XAttrInfo
+	if xattrs, err = v.mw.BatchGetXAttr(inodes, []string{XAttrKeyOSSSSE}); err != nil {
+		log.LogErrorf("CompleteMultipart: get part sse fail: volume(%v) path(%v) multipartID(%v) err(%v)",
+			v.name, path, multipartID, err)
+		return
+	}
+	partXAttrs := make(map[uint64]*proto.XAttrInfo, len(xattrs))
+	for _, xattr := range xattrs {
+		partXAttrs[xattr.Inode] = xattr
+	}
+	sseParts = make([]*ssePart, 0, len(parts))
+	for _, part := range parts {
+		var partSSE *ssePart
+		if xattr, ok := partXAttrs[part.Inode]; ok {
+			partSSE, err = parseSSEPart(xattr.Get(XAttrKeyOSSSSE))
+		}
+		if err != nil || partSSE == nil || len(partSSE.IV) != aes.BlockSize {
+			log.LogErrorf("CompleteMultipart: invalid part sse: volume(%v) path(%v) multipartID(%v) partID(%v) inode(%v) err(%v)",
+				v.name, path, multipartID, part.ID, part.Inode, err)
+			return nil, InvalidPart
+		}
+		partSSE.Number, partSSE.Size = part.ID, part.Size
+		sseParts = append(sseParts, partSSE)
+	}
+	return
+}
+
+// multipartChecksum computes the composite checksum from the checksums stored with the parts.
+func (v *Volume) multipartChecksum(path, multipartID string, raw []byte, parts []*proto.MultipartPartInfo,
+	partChecksums map[uint16]string) (checksum *ObjectChecksum, err error) {
+	var uploadChecksum *ObjectChecksum
+	if uploadChecksum, err = parseObjectChecksum(raw); err != nil {
+		log.LogErrorf("CompleteMultipart: parse checksum fail: volume(%v) path(%v) multipartID(%v) err(%v)",
+			v.name, path, multipartID, err)
+		return
+	}
+	values := make([]string, 0, len(parts))
+	for _, part := range parts {
+		var xattr *proto.XAttrInfo
+		if xattr, err = v.mw.XAttrGet_ll(part.Inode, XAttrKeyOSSChecksum); err != nil {
+			log.LogErrorf("CompleteMultipart: get part checksum fail: volume(%v) path(%v) multipartID(%v) partID(%v) inode(%v) err(%v)",
+				v.name, path, multipartID, part.ID, part.Inode, err)
+			return
+		}
+		var partChecksum *ObjectChecksum
+		if partChecksum, err = parseObjectChecksum(xattr.Get(XAttrKeyOSSChecksum)); err != nil || partChecksum == nil ||
+			partChecksum.Algorithm != uploadChecksum.Algorithm {
+			log.LogErrorf("CompleteMultipart: invalid part checksum: volume(%v) path(%v) multipartID(%v) partID(%v) checksum(%v) err(%v)",
+				v.name, path, multipartID, part.ID, partChecksum, err)
+			err = InvalidPart
+			return
+		}
+		if expected := partChecksums[part.ID]; expected != "" && expected != partChecksum.Value {
+			log.LogErrorf("CompleteMultipart: part checksum not match: volume(%v) path(%v) multipartID(%v) partID(%v) expected(%v) checksum(%v)",
+				v.name, path, multipartID, part.ID, expected, partChecksum.Value)
+			err = InvalidPart
+			return
+		}
+		values = append(values, partChecksum.Value)
+	}
+	return compositeChecksum(uploadChecksum.Algorithm, values)
+}
+
 func (v *Volume) ebsWrite(inode uint64, reader io.Reader, h hash.Hash, storageClass uint32) (size uint64, err error) {
 	ctx := context.Background()
 	size, err = v.getEbsWriter(inode, storageClass).WriteFromReader(ctx, reader, h)
@@ -1392,10 +2014,15 @@ func (v *Volume) ebsWrite(inode uint64, reader io.Reader, h hash.Hash, storageCl
 }
 
 func (v *Volume) streamWrite(inode uint64, reader io.Reader, h hash.Hash, storageClass uint32) (size uint64, err error) {
+	return v.streamWriteAt(inode, 0, reader, h, storageClass)
+}
+
+// streamWriteAt writes the data from the offset of the file.
+func (v *Volume) streamWriteAt(inode uint64, offset int, reader io.Reader, h hash.Hash, storageClass uint32) (size uint64, err error) {
 	var (
-		buf                   = make([]byte, 2*util.BlockSize)
-		teeReader             = io.TeeReader(reader, h)
-		readN, writeN, offset int
+		buf           = make([]byte, 2*util.BlockSize)
+		teeReader     = io.TeeReader(reader, h)
+		readN, writeN int
 	)
 	for {
 		readN, err = teeReader.Read(buf)
@@ -1496,10 +2123,20 @@ func (v *Volume) applyInodeToNewDentry(parentID uint64, name string, inode uint6
 }
 
 func (v *Volume) applyInodeToExistDentry(parentID uint64, name string, inode uint64, isCompleteMultipart bool,
-	fullPath string, storageClass uint32,
+	fullPath string, storageClass uint32, expectInode uint64,
 ) (err error) {
 	var oldInode uint64
-	oldInode, err = v.mw.DentryUpdate_ll(parentID, name, inode, fullPath)
+	if expectInode != 0 {
+		// the dentry is replaced only if it still points to the object checked by the precondition
+		oldInode, err = v.mw.DentryUpdateIf_ll(parentID, name, inode, expectInode, fullPath)
+		if err == syscall.EEXIST || err == syscall.ENOENT {
+			log.LogWarnf("applyInodeToExistDentry: object replaced concurrently: parentID(%v) name(%v) inode(%v) expectInode(%v)",
+				parentID, name, inode, expectInode)
+			return PreconditionFailed
+		}
+	} else {
+		oldInode, err = v.mw.DentryUpdate_ll(parentID, name, inode, fullPath)
+	}
 	if err != nil {
 		log.LogErrorf("applyInodeToExistDentry: meta update dentry fail: parentID(%v) name(%v) inode(%v) err(%v)",
 			parentID, name, inode, err)
@@ -1524,7 +2161,27 @@ func (v *Volume) applyInodeToExistDentry(parentID uint64, name string, inode uin
 		}
 	}
 
+	// keep old inode as a noncurrent version
+	archived, err := v.archiveObjectVersion(fullPath, oldInode)
+	if err != nil {
+		log.LogErrorf("applyInodeToExistDentry: archive old version fail: volume(%v) parentID(%v) name(%v) inode(%v) err(%v)",
+			v.name, parentID, name, oldInode, err)
+		// restore the old inode as the current version, so that it will not be left without dentry
+		if _, rollbackErr := v.mw.DentryUpdate_ll(parentID, name, oldInode, fullPath); rollbackErr != nil {
+			// the new inode has been applied and must not be released by the caller,
+			// the old inode is left without dentry.
+			log.LogErrorf("applyInodeToExistDentry: restore old inode fail: volume(%v) parentID(%v) name(%v) inode(%v) err(%v)",
+				v.name, parentID, name, oldInode, rollbackErr)
+			return nil
+		}
+		return
+	}
+	if archived {
+		return
+	}
+
 	// unlink and evict old inode
+	v.releaseRestoredCopy(oldInode, fullPath)
 	log.LogWarnf("applyInodeToExistDentry: unlink inode: volume(%v) inode(%v)", v.name, oldInode)
 	if _, err = v.mw.InodeUnlink_ll(oldInode, fullPath); err != nil {
 		log.LogWarnf("applyInodeToExistDentry: unlink inode fail: volume(%v) inode(%v) err(%v)",
@@ -1728,7 +2385,25 @@ func (v *Volume) ObjectMeta(path string) (info *FSFileInfo, xattr *proto.XAttrIn
 		}
 		break
 	}
+	if info, xattr, err = v.objectMetaOfInode(path, mode, inoInfo); err != nil {
+		return
+	}
 
+	// the object without version ID is the null version once versioning is configured.
+	if !mode.IsDir() {
+		var status string
+		if status, err = v.versioningStatus(); err != nil {
+			return
+		}
+		if info.VersionId == "" && status != "" {
+			info.VersionId = NullVersionId
+		}
+	}
+	return
+}
+
+func (v *Volume) objectMetaOfInode(path string, mode os.FileMode, inoInfo *proto.InodeInfo) (info *FSFileInfo, xattr *proto.XAttrInfo, err error) {
+	inode := inoInfo.Inode
 	var (
 		etagValue    ETagValue
 		mimeType     string
@@ -1789,7 +2464,7 @@ func (v *Volume) ObjectMeta(path string) (info *FSFileInfo, xattr *proto.XAttrIn
 		}
 	}
 	// Load user-defined metadata
-	var retainUntilDate string
+	var retainUntilDate, lockMode string
 	var retainUntilDateInt64 int64
 	metadata := make(map[string]string)
 	for key, val := range xattr.XAttrs {
@@ -1804,6 +2479,11 @@ func (v *Volume) ObjectMeta(path string) (info *FSFileInfo, xattr *proto.XAttrIn
 				return
 			}
 			retainUntilDate = time.Unix(0, retainUntilDateInt64).UTC().Format(ISO8601Layout)
+			// objects locked before GOVERNANCE mode is supported have no mode xattr
+			lockMode = ComplianceMode
+			if mode := xattr.Get(XAttrKeyOSSLockMode); len(mode) > 0 {
+				lockMode = string(mode)
+			}
 		}
 	}
 
@@ -1827,7 +2507,10 @@ func (v *Volume) ObjectMeta(path string) (info *FSFileInfo, xattr *proto.XAttrIn
 		Expires:         expires,
 		Metadata:        metadata,
 		RetainUntilDate: retainUntilDate,
+		ObjectLockMode:  lockMode,
+		LegalHold:       string(xattr.Get(XAttrKeyOSSLegalHold)),
 		StorageClass:    inoInfo.StorageClass,
+		VersionId:       string(xattr.Get(XAttrKeyOSSVersionId)),
 	}
 	return
 }
@@ -2128,6 +2811,14 @@ func (v *Volume) listFilesV1(prefix, marker, delimiter string, maxKeys uint64, o
 ) {
 	prefixMap := PrefixMap(make(map[string]struct{}))
 
+	if delimiter == "" && onlyObject {
+		var indexed bool
+		if infos, nextMarker, indexed = v.listKeyIndex(prefix, marker, maxKeys); indexed {
+			err = v.supplyListFileInfo(infos)
+			return
+		}
+	}
+
 	parentId, dirs, err := v.findParentId(prefix)
 
 	// The method returns an ENOENT error, indicating that there
@@ -2183,6 +2874,13 @@ func (v *Volume) listFilesV2(prefix, startAfter, contToken, delimiter string, ma
 	if contToken != "" {
 		marker = contToken
 	}
+	if delimiter == "" {
+		var indexed bool
+		if infos, nextMarker, indexed = v.listKeyIndex(prefix, marker, maxKeys); indexed {
+			err = v.supplyListFileInfo(infos)
+			return
+		}
+	}
 	parentId, dirs, err := v.findParentId(prefix)
 
 	// The method returns an ENOENT error, indicating that there
@@ -2619,10 +3317,10 @@ func (v *Volume) ListParts(path, uploadId string, maxParts, partNumberMarker uin
 	return parts, nextMarker, isTruncated, nil
 }
 
-func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective string, opt *PutFileOption) (info *FSFileInfo, err error) {
+func (v *Volume) CopyFile(sv *Volume, sourcePath, sourceVersionId, targetPath, metaDirective string, opt *PutFileOption) (info *FSFileInfo, err error) {
 	defer func() {
-		log.LogInfof("Audit: copy file: source path(%v) target path(%v) err(%v)",
-			sourcePath, targetPath, err)
+		log.LogInfof("Audit: copy file: source path(%v) source version(%v) target path(%v) err(%v)",
+			sourcePath, sourceVersionId, targetPath, err)
 	}()
 
 	// operation at source object
@@ -2633,7 +3331,20 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 		sInodeInfo *proto.InodeInfo
 	)
 
-	if _, sInode, sName, sMode, err = sv.recursiveLookupTarget(sourcePath, false); err != nil {
+	if _, sInode, sName, sMode, err = sv.recursiveLookupTarget(sourcePath, false); err != nil && err != syscall.ENOENT {
+		log.LogErrorf("CopyFile: look up source path fail, source path(%v) err(%v)", sourcePath, err)
+		return
+	}
+	if sourceVersionId != "" {
+		// copy from the specified version, which may be a noncurrent version
+		var sInfo *FSFileInfo
+		if sInfo, _, err = sv.ObjectVersionMeta(sourcePath, sourceVersionId); err != nil {
+			log.LogErrorf("CopyFile: get source version fail, source path(%v) version(%v) err(%v)",
+				sourcePath, sourceVersionId, err)
+			return
+		}
+		sInode, sMode = sInfo.Inode, sInfo.Mode
+	} else if err != nil {
 		log.LogErrorf("CopyFile: look up source path fail, source path(%v) err(%v)", sourcePath, err)
 		return
 	}
@@ -2645,6 +3356,42 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 		log.LogErrorf("CopyFile: copy source path file size greater than 5GB, source path(%v), target path(%v)", sourcePath, targetPath)
 		return nil, syscall.EFBIG
 	}
+	// the encrypted source object is decrypted before being copied
+	var (
+		sSSEXAttr *proto.XAttrInfo
+		sSSE      *sseMeta
+		sDataKey  []byte
+	)
+	if sSSEXAttr, err = sv.mw.XAttrGet_ll(sInode, XAttrKeyOSSSSE); err != nil {
+		log.LogErrorf("CopyFile: get source sse meta fail, source path(%v) err(%v)", sourcePath, err)
+		return
+	}
+	if sSSE, err = loadSSEMeta(sSSEXAttr); err != nil {
+		log.LogErrorf("CopyFile: parse source sse meta fail, source path(%v) err(%v)", sourcePath, err)
+		return
+	}
+	var sourceSSEOpt *SSEOption
+	if opt != nil {
+		sourceSSEOpt = opt.CopySourceSSE
+	}
+	if sDataKey, err = objectDataKey(sSSE, sourceSSEOpt); err != nil {
+		log.LogErrorf("CopyFile: check source sse fail, source path(%v) err(%v)", sourcePath, err)
+		return
+	}
+	// the checksum of the target is computed with the specified algorithm or the algorithm of source
+	var checksumAlgorithm string
+	if opt != nil && opt.Checksum != nil {
+		checksumAlgorithm = opt.Checksum.Algorithm
+	} else {
+		var sChecksumXAttr *proto.XAttrInfo
+		if sChecksumXAttr, err = sv.mw.XAttrGet_ll(sInode, XAttrKeyOSSChecksum); err != nil {
+			log.LogErrorf("CopyFile: get source checksum fail, source path(%v) err(%v)", sourcePath, err)
+			return
+		}
+		if sChecksum, _ := parseObjectChecksum(sChecksumXAttr.Get(XAttrKeyOSSChecksum)); sChecksum != nil {
+			checksumAlgorithm = sChecksum.Algorithm
+		}
+	}
 	isCache := false
 	if proto.IsCold(sv.volType) || proto.IsStorageClassBlobStore(sInodeInfo.StorageClass) {
 		isCache = true
@@ -2664,14 +3411,20 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 	var xattr *proto.XAttrInfo
 	// if source path is same with target path, just reset file metadata
 	// source path is same with target path, and metadata directive is not 'REPLACE', objectNode does nothing
-	if targetPath == sourcePath && v.name == sv.name {
+	// a new version is always created in a versioned bucket.
+	var versioning string
+	if versioning, err = v.versioningStatus(); err != nil {
+		return
+	}
+	// the data is rewritten if the source or the target object is encrypted
+	if targetPath == sourcePath && v.name == sv.name && versioning == "" && sSSE == nil && (opt == nil || opt.SSE == nil) {
 		if metaDirective != MetadataDirectiveReplace {
 			log.LogInfof("CopyFile: targetPath(%v) is equal with sourcePath(%v),but metaDirective(%v) is not REPLACE",
 				targetPath, sourcePath, metaDirective)
 		} else {
 			// check whether target object is protected by object lock
 			if opt != nil && opt.ObjectLock != nil {
-				err = isObjectLocked(v, sInode, sName, sourcePath)
+				err = isObjectLocked(v, sInode, sName, sourcePath, false)
 				if err != nil {
 					return
 				}
@@ -2699,8 +3452,8 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 			if opt != nil && opt.ACL != nil {
 				attr.XAttrs[XAttrKeyOSSACL] = opt.ACL.Encode()
 			}
-			if opt != nil && opt.ObjectLock != nil && opt.ObjectLock.ToRetention() != nil {
-				attr.XAttrs[XAttrKeyOSSLock] = formatRetentionDateStr(time.Now(), opt.ObjectLock.ToRetention())
+			if opt != nil {
+				setObjectLockXAttrs(attr.XAttrs, time.Now(), opt.ObjectLock, opt.LockOption)
 			}
 			// If user-defined metadata have been specified, use extend attributes for storage.
 			if opt != nil && len(opt.Metadata) > 0 {
@@ -2788,10 +3541,9 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 	}
 	tLastName = pathItems[len(pathItems)-1].Name
 
-	// check whether existing object is protected by object lock
-	if oldtInode != 0 && opt != nil && opt.ObjectLock != nil {
-		err = isObjectLocked(v, oldtInode, tLastName, targetPath)
-		if err != nil {
+	// check whether the replaced objects are protected by object lock
+	if opt != nil && opt.ObjectLock != nil {
+		if err = v.checkReplaceLocked(oldtInode, tLastName, targetPath); err != nil {
 			return
 		}
 	}
@@ -2839,7 +3591,28 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 		readSize    int
 		rest        int
 		buf         = make([]byte, 2*util.BlockSize)
+		sCipher     *sseObjectCipher
+		tCipher     *sseObjectCipher
+		tSSE        *sseMeta
+		hashes      = io.Writer(md5Hash)
+		checksumH   hash.Hash
+		checksum    *ObjectChecksum
 	)
+	if checksumAlgorithm != "" {
+		checksumH = newChecksumHash(checksumAlgorithm)
+		hashes = io.MultiWriter(md5Hash, checksumH)
+	}
+	if sSSE != nil {
+		sCipher = sSSE.objectCipher(sDataKey, 0)
+	}
+	if opt != nil && opt.SSE != nil {
+		var tDataKey []byte
+		if tSSE, tDataKey, err = newSSEMeta(opt.SSE); err != nil {
+			log.LogErrorf("CopyFile: new sse meta fail: volume(%v) path(%v) err(%v)", v.name, targetPath, err)
+			return
+		}
+		tCipher = tSSE.objectCipher(tDataKey, 0)
+	}
 
 	var sctx context.Context
 	var ebsReader *blobstore.Reader
@@ -2872,6 +3645,18 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 			return
 		}
 		if readN > 0 {
+			// the MD5 is computed over the plaintext
+			if sCipher != nil {
+				if err = sCipher.XORKeyStream(buf[:readN], buf[:readN]); err != nil {
+					return
+				}
+			}
+			hashes.Write(buf[:readN])
+			if tCipher != nil {
+				if err = tCipher.XORKeyStream(buf[:readN], buf[:readN]); err != nil {
+					return
+				}
+			}
 			if proto.IsCold(v.volType) || proto.IsStorageClassBlobStore(tInodeInfo.StorageClass) {
 				writeN, err = ebsWriter.WriteWithoutPool(tctx, writeOffset, buf[:readN])
 			} else {
@@ -2885,7 +3670,6 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 			}
 			readOffset += readN
 			writeOffset += writeN
-			md5Hash.Write(buf[:readN])
 		}
 		if err == io.EOF {
 			err = nil
@@ -2904,6 +3688,9 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 	}
 
 	md5Value = hex.EncodeToString(md5Hash.Sum(nil))
+	if checksumH != nil {
+		checksum = &ObjectChecksum{Algorithm: checksumAlgorithm, Value: base64.StdEncoding.EncodeToString(checksumH.Sum(nil))}
+	}
 	log.LogDebugf("Audit: copy file: write file finished, volume(%v), path(%v), etag(%v) from  volume(%v), path(%v)", v.name, targetPath, md5Value, sv.Name(), sourcePath)
 
 	var finalInode *proto.InodeInfo
@@ -2925,6 +3712,17 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 		},
 	}
 	targetAttr.XAttrs[XAttrKeyOSSETag] = etagValue.Encode()
+	if tSSE != nil {
+		targetAttr.XAttrs[XAttrKeyOSSSSE] = tSSE.Encode()
+	}
+	if checksum != nil {
+		targetAttr.XAttrs[XAttrKeyOSSChecksum] = checksum.Encode()
+	}
+	var versionId string
+	if versionId, err = v.assignVersionId(targetAttr.XAttrs); err != nil {
+		log.LogErrorf("CopyFile: assign version id fail: volume(%v) target path(%v) err(%v)", v.name, targetPath, err)
+		return
+	}
 
 	// copy source file metadata to write target file metadata
 	if metaDirective != MetadataDirectiveReplace {
@@ -2933,7 +3731,8 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 			return
 		}
 		for key, val := range xattr.XAttrs {
-			if key == XAttrKeyOSSETag {
+			if key == XAttrKeyOSSETag || key == XAttrKeyOSSVersionId || key == XAttrKeyOSSSSE ||
+				key == XAttrKeyOSSChecksum || key == XAttrKeyOSSReplicationStatus || isObjectLockXAttr(key) {
 				continue
 			}
 			targetAttr.XAttrs[key] = val
@@ -2941,8 +3740,8 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 		if opt != nil && opt.ACL != nil {
 			targetAttr.XAttrs[XAttrKeyOSSACL] = opt.ACL.Encode()
 		}
-		if opt != nil && opt.ObjectLock != nil && opt.ObjectLock.ToRetention() != nil {
-			targetAttr.XAttrs[XAttrKeyOSSLock] = formatRetentionDateStr(tInodeInfo.ModifyTime, opt.ObjectLock.ToRetention())
+		if opt != nil {
+			setObjectLockXAttrs(targetAttr.XAttrs, tInodeInfo.ModifyTime, opt.ObjectLock, opt.LockOption)
 		}
 		if err = v.mw.BatchSetXAttr_ll(tInodeInfo.Inode, targetAttr.XAttrs); err != nil {
 			log.LogErrorf("CopyFile: set target xattr fail: volume(%v) target path(%v) inode(%v) xattr (%v)err(%v)",
@@ -2970,8 +3769,8 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 		if opt != nil && opt.ACL != nil {
 			targetAttr.XAttrs[XAttrKeyOSSACL] = opt.ACL.Encode()
 		}
-		if opt != nil && opt.ObjectLock != nil && opt.ObjectLock.ToRetention() != nil {
-			targetAttr.XAttrs[XAttrKeyOSSLock] = formatRetentionDateStr(tInodeInfo.ModifyTime, opt.ObjectLock.ToRetention())
+		if opt != nil {
+			setObjectLockXAttrs(targetAttr.XAttrs, tInodeInfo.ModifyTime, opt.ObjectLock, opt.LockOption)
 		}
 
 		// If user-defined metadata have been specified, use extend attributes for storage.
@@ -3004,11 +3803,13 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 		CreateTime: tInodeInfo.CreateTime,
 		ETag:       md5Value,
 		Inode:      tInodeInfo.Inode,
+		VersionId:  versionId,
+		Checksum:   checksum,
 	}
 
 	// apply new inode to dentry
 	err = v.applyInodeToDEntry(tParentId, tLastName, tInodeInfo.Inode, false,
-		targetPath, tInodeInfo.StorageClass)
+		targetPath, tInodeInfo.StorageClass, nil, 0)
 	if err != nil {
 		log.LogErrorf("CopyFile: apply inode to new dentry fail: path(%v) parentID(%v) name(%v) inode(%v) err(%v)",
 			targetPath, tParentId, tLastName, tInodeInfo.Inode, err)
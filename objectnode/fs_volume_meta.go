@@ -26,10 +26,30 @@ type ossMetaLoader interface {
 	loadACL() (p *AccessControlPolicy, err error)
 	loadCORS() (cors *CORSConfiguration, err error)
 	loadObjectLock() (config *ObjectLockConfig, err error)
+	loadVersioning() (config *VersioningConfiguration, err error)
+	loadEncryption() (config *ServerSideEncryptionConfiguration, err error)
+	loadReplication() (config *ReplicationConfiguration, err error)
+	loadNotification() (config *NotificationConfiguration, err error)
+	loadWebsite() (config *WebsiteConfiguration, err error)
+	loadLogging() (status *BucketLoggingStatus, err error)
+	loadMetrics() (configs *BucketMetricsConfigurations, err error)
+	loadPublicAccessBlock() (config *PublicAccessBlockConfiguration, err error)
+	loadAccountPublicAccessBlock() (config *PublicAccessBlockConfiguration, err error)
+	loadKeyIndex() (index *BucketKeyIndex, err error)
 	storePolicy(p *Policy)
 	storeACL(p *AccessControlPolicy)
 	storeCORS(cors *CORSConfiguration)
 	storeObjectLock(config *ObjectLockConfig)
+	storeVersioning(config *VersioningConfiguration)
+	storeEncryption(config *ServerSideEncryptionConfiguration)
+	storeReplication(config *ReplicationConfiguration)
+	storeNotification(config *NotificationConfiguration)
+	storeWebsite(config *WebsiteConfiguration)
+	storeLogging(status *BucketLoggingStatus)
+	storeMetrics(configs *BucketMetricsConfigurations)
+	storePublicAccessBlock(config *PublicAccessBlockConfiguration)
+	storeAccountPublicAccessBlock(config *PublicAccessBlockConfiguration)
+	storeKeyIndex(index *BucketKeyIndex)
 	setSynced()
 }
 
@@ -46,14 +66,34 @@ type cacheMetaLoader struct {
 
 // OSSMeta is bucket policy and ACL metadata.
 type OSSMeta struct {
-	policy     *Policy
-	acl        *AccessControlPolicy
-	corsConfig *CORSConfiguration
-	lockConfig *ObjectLockConfig
-	policyLock sync.RWMutex
-	aclLock    sync.RWMutex
-	corsLock   sync.RWMutex
-	objectLock sync.RWMutex
+	policy             *Policy
+	acl                *AccessControlPolicy
+	corsConfig         *CORSConfiguration
+	lockConfig         *ObjectLockConfig
+	versioningConfig   *VersioningConfiguration
+	encryptionConfig   *ServerSideEncryptionConfiguration
+	replicationConfig  *ReplicationConfiguration
+	notificationConfig *NotificationConfiguration
+	websiteConfig      *WebsiteConfiguration
+	loggingStatus      *BucketLoggingStatus
+	metricsConfigs     *BucketMetricsConfigurations
+	pabConfig          *PublicAccessBlockConfiguration
+	accountPABConfig   *PublicAccessBlockConfiguration
+	keyIndex           *BucketKeyIndex
+	policyLock         sync.RWMutex
+	aclLock            sync.RWMutex
+	corsLock           sync.RWMutex
+	objectLock         sync.RWMutex
+	versioningLock     sync.RWMutex
+	encryptionLock     sync.RWMutex
+	replicationLock    sync.RWMutex
+	notificationLock   sync.RWMutex
+	websiteLock        sync.RWMutex
+	loggingLock        sync.RWMutex
+	metricsLock        sync.RWMutex
+	pabLock            sync.RWMutex
+	accountPABLock     sync.RWMutex
+	keyIndexLock       sync.RWMutex
 }
 
 func (c *cacheMetaLoader) loadPolicy() (p *Policy, err error) {
@@ -152,6 +192,246 @@ func (c *cacheMetaLoader) storeObjectLock(config *ObjectLockConfig) {
 	c.om.objectLock.Unlock()
 }
 
+func (c *cacheMetaLoader) loadVersioning() (config *VersioningConfiguration, err error) {
+	c.om.versioningLock.RLock()
+	config = c.om.versioningConfig
+	c.om.versioningLock.RUnlock()
+	if config == nil && atomic.LoadInt32(c.synced) == 0 {
+		ret, err, _ := c.sf.Do(XAttrKeyOSSVersioning, func() (interface{}, error) {
+			vc, err := c.sml.loadVersioning()
+			return vc, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		config = ret.(*VersioningConfiguration)
+		c.storeVersioning(config)
+	}
+	return
+}
+
+func (c *cacheMetaLoader) storeVersioning(config *VersioningConfiguration) {
+	c.om.versioningLock.Lock()
+	c.om.versioningConfig = config
+	c.om.versioningLock.Unlock()
+}
+
+func (c *cacheMetaLoader) loadEncryption() (config *ServerSideEncryptionConfiguration, err error) {
+	c.om.encryptionLock.RLock()
+	config = c.om.encryptionConfig
+	c.om.encryptionLock.RUnlock()
+	if config == nil && atomic.LoadInt32(c.synced) == 0 {
+		ret, err, _ := c.sf.Do(XAttrKeyOSSEncryption, func() (interface{}, error) {
+			ec, err := c.sml.loadEncryption()
+			return ec, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		config = ret.(*ServerSideEncryptionConfiguration)
+		c.storeEncryption(config)
+	}
+	return
+}
+
+func (c *cacheMetaLoader) storeEncryption(config *ServerSideEncryptionConfiguration) {
+	c.om.encryptionLock.Lock()
+	c.om.encryptionConfig = config
+	c.om.encryptionLock.Unlock()
+}
+
+func (c *cacheMetaLoader) loadReplication() (config *ReplicationConfiguration, err error) {
+	c.om.replicationLock.RLock()
+	config = c.om.replicationConfig
+	c.om.replicationLock.RUnlock()
+	if config == nil && atomic.LoadInt32(c.synced) == 0 {
+		ret, err, _ := c.sf.Do(XAttrKeyOSSReplication, func() (interface{}, error) {
+			rc, err := c.sml.loadReplication()
+			return rc, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		config = ret.(*ReplicationConfiguration)
+		c.storeReplication(config)
+	}
+	return
+}
+
+func (c *cacheMetaLoader) storeReplication(config *ReplicationConfiguration) {
+	c.om.replicationLock.Lock()
+	c.om.replicationConfig = config
+	c.om.replicationLock.Unlock()
+}
+
+func (c *cacheMetaLoader) loadNotification() (config *NotificationConfiguration, err error) {
+	c.om.notificationLock.RLock()
+	config = c.om.notificationConfig
+	c.om.notificationLock.RUnlock()
+	if config == nil && atomic.LoadInt32(c.synced) == 0 {
+		ret, err, _ := c.sf.Do(XAttrKeyOSSNotification, func() (interface{}, error) {
+			nc, err := c.sml.loadNotification()
+			return nc, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		config = ret.(*NotificationConfiguration)
+		c.storeNotification(config)
+	}
+	return
+}
+
+func (c *cacheMetaLoader) storeNotification(config *NotificationConfiguration) {
+	c.om.notificationLock.Lock()
+	c.om.notificationConfig = config
+	c.om.notificationLock.Unlock()
+}
+
+func (c *cacheMetaLoader) loadWebsite() (config *WebsiteConfiguration, err error) {
+	c.om.websiteLock.RLock()
+	config = c.om.websiteConfig
+	c.om.websiteLock.RUnlock()
+	if config == nil && atomic.LoadInt32(c.synced) == 0 {
+		ret, err, _ := c.sf.Do(XAttrKeyOSSWebsite, func() (interface{}, error) {
+			wc, err := c.sml.loadWebsite()
+			return wc, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		config = ret.(*WebsiteConfiguration)
+		c.storeWebsite(config)
+	}
+	return
+}
+
+func (c *cacheMetaLoader) storeWebsite(config *WebsiteConfiguration) {
+	c.om.websiteLock.Lock()
+	c.om.websiteConfig = config
+	c.om.websiteLock.Unlock()
+}
+
+func (c *cacheMetaLoader) loadLogging() (status *BucketLoggingStatus, err error) {
+	c.om.loggingLock.RLock()
+	status = c.om.loggingStatus
+	c.om.loggingLock.RUnlock()
+	if status == nil && atomic.LoadInt32(c.synced) == 0 {
+		ret, err, _ := c.sf.Do(XAttrKeyOSSLogging, func() (interface{}, error) {
+			ls, err := c.sml.loadLogging()
+			return ls, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		status = ret.(*BucketLoggingStatus)
+		c.storeLogging(status)
+	}
+	return
+}
+
+func (c *cacheMetaLoader) storeLogging(status *BucketLoggingStatus) {
+	c.om.loggingLock.Lock()
+	c.om.loggingStatus = status
+	c.om.loggingLock.Unlock()
+}
+
+func (c *cacheMetaLoader) loadMetrics() (configs *BucketMetricsConfigurations, err error) {
+	c.om.metricsLock.RLock()
+	configs = c.om.metricsConfigs
+	c.om.metricsLock.RUnlock()
+	if configs == nil && atomic.LoadInt32(c.synced) == 0 {
+		ret, err, _ := c.sf.Do(XAttrKeyOSSMetrics, func() (interface{}, error) {
+			mc, err := c.sml.loadMetrics()
+			return mc, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		configs = ret.(*BucketMetricsConfigurations)
+		c.storeMetrics(configs)
+	}
+	return
+}
+
+func (c *cacheMetaLoader) storeMetrics(configs *BucketMetricsConfigurations) {
+	c.om.metricsLock.Lock()
+	c.om.metricsConfigs = configs
+	c.om.metricsLock.Unlock()
+}
+
+func (c *cacheMetaLoader) loadPublicAccessBlock() (config *PublicAccessBlockConfiguration, err error) {
+	c.om.pabLock.RLock()
+	config = c.om.pabConfig
+	c.om.pabLock.RUnlock()
+	if config == nil && atomic.LoadInt32(c.synced) == 0 {
+		ret, err, _ := c.sf.Do(XAttrKeyOSSPAB, func() (interface{}, error) {
+			pc, err := c.sml.loadPublicAccessBlock()
+			return pc, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		config = ret.(*PublicAccessBlockConfiguration)
+		c.storePublicAccessBlock(config)
+	}
+	return
+}
+
+func (c *cacheMetaLoader) storePublicAccessBlock(config *PublicAccessBlockConfiguration) {
+	c.om.pabLock.Lock()
+	c.om.pabConfig = config
+	c.om.pabLock.Unlock()
+}
+
+func (c *cacheMetaLoader) loadAccountPublicAccessBlock() (config *PublicAccessBlockConfiguration, err error) {
+	c.om.accountPABLock.RLock()
+	config = c.om.accountPABConfig
+	c.om.accountPABLock.RUnlock()
+	if config == nil && atomic.LoadInt32(c.synced) == 0 {
+		ret, err, _ := c.sf.Do("account:"+XAttrKeyOSSPAB, func() (interface{}, error) {
+			pc, err := c.sml.loadAccountPublicAccessBlock()
+			return pc, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		config = ret.(*PublicAccessBlockConfiguration)
+		c.storeAccountPublicAccessBlock(config)
+	}
+	return
+}
+
+func (c *cacheMetaLoader) storeAccountPublicAccessBlock(config *PublicAccessBlockConfiguration) {
+	c.om.accountPABLock.Lock()
+	c.om.accountPABConfig = config
+	c.om.accountPABLock.Unlock()
+}
+
+func (c *cacheMetaLoader) loadKeyIndex() (index *BucketKeyIndex, err error) {
+	c.om.keyIndexLock.RLock()
+	index = c.om.keyIndex
+	c.om.keyIndexLock.RUnlock()
+	if index == nil && atomic.LoadInt32(c.synced) == 0 {
+		ret, err, _ := c.sf.Do(XAttrKeyOSSKeyIndex, func() (interface{}, error) {
+			ki, err := c.sml.loadKeyIndex()
+			return ki, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		index = ret.(*BucketKeyIndex)
+		c.storeKeyIndex(index)
+	}
+	return
+}
+
+func (c *cacheMetaLoader) storeKeyIndex(index *BucketKeyIndex) {
+	c.om.keyIndexLock.Lock()
+	c.om.keyIndex = index
+	c.om.keyIndexLock.Unlock()
+}
+
 func (c *cacheMetaLoader) setSynced() {
 	atomic.StoreInt32(c.synced, 1)
 }
@@ -188,6 +468,86 @@ func (s *strictMetaLoader) storeObjectLock(cors *ObjectLockConfig) {
 	// do nothing
 }
 
+func (s *strictMetaLoader) loadVersioning() (config *VersioningConfiguration, err error) {
+	return s.v.loadBucketVersioning()
+}
+
+func (s *strictMetaLoader) storeVersioning(config *VersioningConfiguration) {
+	// do nothing
+}
+
+func (s *strictMetaLoader) loadEncryption() (config *ServerSideEncryptionConfiguration, err error) {
+	return s.v.loadBucketEncryption()
+}
+
+func (s *strictMetaLoader) storeEncryption(config *ServerSideEncryptionConfiguration) {
+	// do nothing
+}
+
+func (s *strictMetaLoader) loadReplication() (config *ReplicationConfiguration, err error) {
+	return s.v.loadBucketReplication()
+}
+
+func (s *strictMetaLoader) storeReplication(config *ReplicationConfiguration) {
+	// do nothing
+}
+
+func (s *strictMetaLoader) loadNotification() (config *NotificationConfiguration, err error) {
+	return s.v.loadBucketNotification()
+}
+
+func (s *strictMetaLoader) storeNotification(config *NotificationConfiguration) {
+	// do nothing
+}
+
+func (s *strictMetaLoader) loadWebsite() (config *WebsiteConfiguration, err error) {
+	return s.v.loadBucketWebsite()
+}
+
+func (s *strictMetaLoader) storeWebsite(config *WebsiteConfiguration) {
+	// do nothing
+}
+
+func (s *strictMetaLoader) loadLogging() (status *BucketLoggingStatus, err error) {
+	return s.v.loadBucketLogging()
+}
+
+func (s *strictMetaLoader) storeLogging(status *BucketLoggingStatus) {
+	// do nothing
+}
+
+func (s *strictMetaLoader) loadMetrics() (configs *BucketMetricsConfigurations, err error) {
+	return s.v.loadBucketMetrics()
+}
+
+func (s *strictMetaLoader) storeMetrics(configs *BucketMetricsConfigurations) {
+	// do nothing
+}
+
+func (s *strictMetaLoader) loadPublicAccessBlock() (config *PublicAccessBlockConfiguration, err error) {
+	return s.v.loadBucketPublicAccessBlock()
+}
+
+func (s *strictMetaLoader) storePublicAccessBlock(config *PublicAccessBlockConfiguration) {
+	// do nothing
+}
+
+func (s *strictMetaLoader) loadAccountPublicAccessBlock() (config *PublicAccessBlockConfiguration, err error) {
+	return s.v.loadAccountPublicAccessBlock()
+}
+
+func (s *strictMetaLoader) storeAccountPublicAccessBlock(config *PublicAccessBlockConfiguration) {
+	// do nothing
+}
+
+func (s *strictMetaLoader) loadKeyIndex() (index *BucketKeyIndex, err error) {
+	return s.v.loadBucketKeyIndex()
+}
+
+func (s *strictMetaLoader) storeKeyIndex(index *BucketKeyIndex) {
+	// do nothing
+}
+
 func (s *strictMetaLoader) setSynced() {
 	// do nothing
 }
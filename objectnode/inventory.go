@@ -0,0 +1,92 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/storage-inventory.html
+
+import (
+	"encoding/xml"
+	"net/http"
+	"sort"
+
+	"github.com/cubefs/cubefs/proto"
+)
+
+const (
+	MaxInventoryConfigSize     = 1 << 14
+	MaxInventoryConfigsPerList = 100
+)
+
+type ListInventoryConfigurationsResult struct {
+	XMLName               xml.Name                        `xml:"ListInventoryConfigurationsResult"`
+	XMLNS                 string                          `xml:"xmlns,attr,omitempty"`
+	Configurations        []*proto.InventoryConfiguration `xml:"InventoryConfiguration,omitempty"`
+	IsTruncated           bool                            `xml:"IsTruncated"`
+	ContinuationToken     string                          `xml:"ContinuationToken,omitempty"`
+	NextContinuationToken string                          `xml:"NextContinuationToken,omitempty"`
+}
+
+func ParseInventoryConfigurationFromXML(data []byte) (*proto.InventoryConfiguration, error) {
+	conf := &proto.InventoryConfiguration{}
+	if err := xml.Unmarshal(data, conf); err != nil {
+		return nil, MalformedXML
+	}
+	if err := proto.ValidInventory(conf); err != nil {
+		return nil, inventoryErrorCode(err)
+	}
+	return conf, nil
+}
+
+func inventoryErrorCode(err error) *ErrorCode {
+	if err == proto.InventoryErrNotImplemented {
+		return InventoryNotImplemented
+	}
+	return &ErrorCode{
+		ErrorCode:    "InvalidArgument",
+		ErrorMessage: err.Error(),
+		StatusCode:   http.StatusBadRequest,
+	}
+}
+
+// findInventory returns the index of the inventory configuration with the id, or -1 if not found.
+func findInventory(inventories []*proto.InventoryConfiguration, id string) int {
+	for i, c := range inventories {
+		if c.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// listInventories returns a page of the inventory configurations sorted by their ids, the id of the last
+// configuration in the page is used as the continuation token of the next page.
+func listInventories(inventories []*proto.InventoryConfiguration, token string) *ListInventoryConfigurationsResult {
+	sorted := make([]*proto.InventoryConfiguration, 0, len(inventories))
+	for _, c := range inventories {
+		if c.ID > token {
+			sorted = append(sorted, c)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	result := &ListInventoryConfigurationsResult{XMLNS: XMLNS, ContinuationToken: token}
+	if len(sorted) > MaxInventoryConfigsPerList {
+		sorted = sorted[:MaxInventoryConfigsPerList]
+		result.IsTruncated = true
+		result.NextContinuationToken = sorted[len(sorted)-1].ID
+	}
+	result.Configurations = sorted
+	return result
+}
@@ -0,0 +1,262 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// The inventory configurations are kept by master in the lifecycle configuration of the bucket, so that
+// the inventory reports are generated by lcnode in the same way as the lifecycle rules are applied.
+
+// loadInventories returns the lifecycle configuration of the bucket, an empty one is returned if not found.
+func (o *ObjectNode) loadInventories(bucket string) (*proto.LcConfiguration, error) {
+	lcConf, err := o.mc.AdminAPI().GetBucketLifecycle(bucket)
+	if err != nil {
+		if err.Error() == proto.ErrNoSuchLifecycleConfiguration.Error() {
+			return &proto.LcConfiguration{VolName: bucket}, nil
+		}
+		return nil, err
+	}
+	return lcConf, nil
+}
+
+// Get bucket inventory configuration
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketInventoryConfiguration.html
+func (o *ObjectNode) getBucketInventoryHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	if _, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("getBucketInventoryHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var lcConf *proto.LcConfiguration
+	if lcConf, err = o.loadInventories(param.Bucket()); err != nil {
+		log.LogErrorf("getBucketInventoryHandler: load inventories fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+	i := findInventory(lcConf.Inventories, r.URL.Query().Get(ParamId))
+	if i < 0 {
+		errorCode = NoSuchInventoryConfiguration
+		return
+	}
+
+	var data []byte
+	if data, err = MarshalXMLEntity(lcConf.Inventories[i]); err != nil {
+		log.LogErrorf("getBucketInventoryHandler: xml marshal fail: requestID(%v) volume(%v) inventory(%+v) err(%v)",
+			GetRequestID(r), param.Bucket(), lcConf.Inventories[i], err)
+		return
+	}
+
+	writeSuccessResponseXML(w, data)
+}
+
+// List bucket inventory configurations
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_ListBucketInventoryConfigurations.html
+func (o *ObjectNode) listBucketInventoriesHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	if _, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("listBucketInventoriesHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var lcConf *proto.LcConfiguration
+	if lcConf, err = o.loadInventories(param.Bucket()); err != nil {
+		log.LogErrorf("listBucketInventoriesHandler: load inventories fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+	result := listInventories(lcConf.Inventories, r.URL.Query().Get(ParamContToken))
+
+	var data []byte
+	if data, err = MarshalXMLEntity(result); err != nil {
+		log.LogErrorf("listBucketInventoriesHandler: xml marshal fail: requestID(%v) volume(%v) result(%+v) err(%v)",
+			GetRequestID(r), param.Bucket(), result, err)
+		return
+	}
+
+	writeSuccessResponseXML(w, data)
+}
+
+// Put bucket inventory configuration
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketInventoryConfiguration.html
+func (o *ObjectNode) putBucketInventoryHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("putBucketInventoryHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var body []byte
+	if body, err = io.ReadAll(io.LimitReader(r.Body, MaxInventoryConfigSize+1)); err != nil {
+		log.LogErrorf("putBucketInventoryHandler: read request body fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if len(body) > MaxInventoryConfigSize {
+		errorCode = EntityTooLarge
+		return
+	}
+
+	var conf *proto.InventoryConfiguration
+	if conf, err = ParseInventoryConfigurationFromXML(body); err != nil {
+		log.LogErrorf("putBucketInventoryHandler: parse inventory fail: requestID(%v) volume(%v) inventory(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), err)
+		return
+	}
+	if conf.ID != r.URL.Query().Get(ParamId) {
+		errorCode = InventoryIdMismatch
+		return
+	}
+
+	// the inventory reports are written into the destination bucket on behalf of the owner of the source bucket
+	var dest *Volume
+	if dest, err = o.getVol(conf.DestinationBucket()); err != nil {
+		log.LogErrorf("putBucketInventoryHandler: load destination volume fail: requestID(%v) volume(%v) destination(%v) err(%v)",
+			GetRequestID(r), vol.Name(), conf.DestinationBucket(), err)
+		if err == NoSuchBucket {
+			errorCode = InvalidInventoryDestination
+		}
+		return
+	}
+	if dest.GetOwner() != vol.GetOwner() {
+		log.LogErrorf("putBucketInventoryHandler: destination owner mismatch: requestID(%v) volume(%v) owner(%v) destination(%v) destinationOwner(%v)",
+			GetRequestID(r), vol.Name(), vol.GetOwner(), dest.Name(), dest.GetOwner())
+		errorCode = InventoryDestinationOwnerMismatch
+		return
+	}
+
+	var lcConf *proto.LcConfiguration
+	if lcConf, err = o.loadInventories(vol.Name()); err != nil {
+		log.LogErrorf("putBucketInventoryHandler: load inventories fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if i := findInventory(lcConf.Inventories, conf.ID); i >= 0 {
+		lcConf.Inventories[i] = conf
+	} else {
+		lcConf.Inventories = append(lcConf.Inventories, conf)
+	}
+	if err = proto.ValidInventories(lcConf.Inventories); err != nil {
+		errorCode = inventoryErrorCode(err)
+		return
+	}
+	if err = o.mc.AdminAPI().SetBucketLifecycle(lcConf); err != nil {
+		log.LogErrorf("putBucketInventoryHandler: set lifecycle fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+
+	log.LogInfof("Audit: put bucket inventory: requestID(%v) volume(%v) inventory(%v)",
+		GetRequestID(r), vol.Name(), string(body))
+}
+
+// Delete bucket inventory configuration
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketInventoryConfiguration.html
+func (o *ObjectNode) deleteBucketInventoryHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	if _, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("deleteBucketInventoryHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var lcConf *proto.LcConfiguration
+	if lcConf, err = o.loadInventories(param.Bucket()); err != nil {
+		log.LogErrorf("deleteBucketInventoryHandler: load inventories fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+	id := r.URL.Query().Get(ParamId)
+	i := findInventory(lcConf.Inventories, id)
+	if i < 0 {
+		errorCode = NoSuchInventoryConfiguration
+		return
+	}
+	lcConf.Inventories = append(lcConf.Inventories[:i], lcConf.Inventories[i+1:]...)
+
+	// the lifecycle configuration is removed with the last inventory if there is no lifecycle rule
+	if len(lcConf.Rules) == 0 && len(lcConf.Inventories) == 0 {
+		err = o.mc.AdminAPI().DelBucketLifecycle(param.Bucket())
+	} else {
+		err = o.mc.AdminAPI().SetBucketLifecycle(lcConf)
+	}
+	if err != nil {
+		log.LogErrorf("deleteBucketInventoryHandler: update lifecycle fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	log.LogInfof("Audit: delete bucket inventory: requestID(%v) volume(%v) id(%v)", GetRequestID(r), param.Bucket(), id)
+	w.WriteHeader(http.StatusNoContent)
+}
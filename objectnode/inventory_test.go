@@ -0,0 +1,121 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+const testInventoryXML = `
+<InventoryConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+   <Destination>
+      <S3BucketDestination>
+         <AccountId>123456789012</AccountId>
+         <Bucket>arn:aws:s3:::reports</Bucket>
+         <Format>%s</Format>
+         <Prefix>inventory</Prefix>
+      </S3BucketDestination>
+   </Destination>
+   <IsEnabled>true</IsEnabled>
+   <Filter>
+      <Prefix>logs/</Prefix>
+   </Filter>
+   <Id>%s</Id>
+   <IncludedObjectVersions>%s</IncludedObjectVersions>
+   <OptionalFields>
+      <Field>Size</Field>
+      <Field>ETag</Field>
+   </OptionalFields>
+   <Schedule>
+      <Frequency>Weekly</Frequency>
+   </Schedule>
+</InventoryConfiguration>
+`
+
+func TestParseInventoryConfiguration(t *testing.T) {
+	conf, err := ParseInventoryConfigurationFromXML([]byte(fmt.Sprintf(testInventoryXML, "CSV", "report1", "Current")))
+	require.NoError(t, err)
+	require.Equal(t, "report1", conf.ID)
+	require.True(t, conf.IsEnabled)
+	require.Equal(t, "reports", conf.DestinationBucket())
+	require.Equal(t, "logs/", conf.GetPrefix())
+	require.Equal(t, []string{proto.InventoryFieldSize, proto.InventoryFieldETag}, conf.OptionalFields)
+	require.Equal(t, proto.InventoryFrequencyWeekly, conf.Schedule.Frequency)
+
+	data, err := xml.Marshal(conf)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "<OptionalFields><Field>Size</Field><Field>ETag</Field></OptionalFields>")
+
+	_, err = ParseInventoryConfigurationFromXML([]byte("<InventoryConfiguration>"))
+	require.Equal(t, MalformedXML, err)
+	_, err = ParseInventoryConfigurationFromXML([]byte(fmt.Sprintf(testInventoryXML, "ORC", "report1", "Current")))
+	require.Equal(t, InventoryNotImplemented, err)
+	_, err = ParseInventoryConfigurationFromXML([]byte(fmt.Sprintf(testInventoryXML, "CSV", "report1", "All")))
+	require.Equal(t, InventoryNotImplemented, err)
+	_, err = ParseInventoryConfigurationFromXML([]byte(fmt.Sprintf(testInventoryXML, "JSON", "report1", "Current")))
+	require.Equal(t, proto.InventoryErrFormat.Error(), err.(*ErrorCode).ErrorMessage)
+	_, err = ParseInventoryConfigurationFromXML([]byte(fmt.Sprintf(testInventoryXML, "CSV", "report/1", "Current")))
+	require.Equal(t, proto.InventoryErrInvalidID.Error(), err.(*ErrorCode).ErrorMessage)
+
+	xmlWithoutARN := strings.Replace(testInventoryXML, "arn:aws:s3:::reports", "reports", 1)
+	_, err = ParseInventoryConfigurationFromXML([]byte(fmt.Sprintf(xmlWithoutARN, "CSV", "report1", "Current")))
+	require.Equal(t, proto.InventoryErrDestination.Error(), err.(*ErrorCode).ErrorMessage)
+}
+
+func TestListInventories(t *testing.T) {
+	var inventories []*proto.InventoryConfiguration
+	for i := MaxInventoryConfigsPerList + 1; i > 0; i-- {
+		inventories = append(inventories, &proto.InventoryConfiguration{ID: fmt.Sprintf("id%03d", i)})
+	}
+	result := listInventories(inventories, "")
+	require.True(t, result.IsTruncated)
+	require.Len(t, result.Configurations, MaxInventoryConfigsPerList)
+	require.Equal(t, "id001", result.Configurations[0].ID)
+	require.Equal(t, "id100", result.NextContinuationToken)
+
+	result = listInventories(inventories, result.NextContinuationToken)
+	require.False(t, result.IsTruncated)
+	require.Len(t, result.Configurations, 1)
+	require.Equal(t, "id101", result.Configurations[0].ID)
+	require.Empty(t, result.NextContinuationToken)
+}
+
+func TestGenInventoryTasks(t *testing.T) {
+	lcConf := &proto.LcConfiguration{
+		VolName: "vol",
+		Inventories: []*proto.InventoryConfiguration{
+			{ID: "daily", IsEnabled: true, Schedule: &proto.InventorySchedule{Frequency: proto.InventoryFrequencyDaily}},
+			{ID: "weekly", IsEnabled: true, Schedule: &proto.InventorySchedule{Frequency: proto.InventoryFrequencyWeekly}},
+			{ID: "disabled", Schedule: &proto.InventorySchedule{Frequency: proto.InventoryFrequencyDaily}},
+		},
+	}
+	monday := time.Date(2024, 2, 5, 0, 0, 0, 0, time.UTC)
+	tasks := lcConf.GenInventoryTasks(monday)
+	require.Len(t, tasks, 1)
+	require.Equal(t, "vol:inventory/daily", tasks[0].Id)
+	require.True(t, proto.IsInventoryTask(tasks[0].Id))
+	require.Equal(t, lcConf.Inventories[0], tasks[0].Inventory)
+
+	tasks = lcConf.GenInventoryTasks(monday.AddDate(0, 0, 6))
+	require.Len(t, tasks, 2)
+	require.False(t, proto.IsInventoryTask("vol:rule1"))
+}
@@ -119,3 +119,16 @@ func (c *KafkaConfig) BuildSyncProducer() (sarama.SyncProducer, error) {
 
 	return sarama.NewSyncProducer(strings.Split(c.Brokers, ","), cfg)
 }
+
+// BuildConsumerGroup creates a Kafka consumer group, the offsets marked by the consumer are committed automatically.
+// Make sure to call FixConfig before calling it to validate and fix the configuration.
+func (c *KafkaConfig) BuildConsumerGroup(group string) (sarama.ConsumerGroup, error) {
+	cfg, err := c.newSaramaConfig()
+	if err != nil {
+		return nil, err
+	}
+	cfg.Consumer.Return.Errors = true
+	cfg.Consumer.Offsets.Initial = sarama.OffsetNewest
+
+	return sarama.NewConsumerGroup(strings.Split(c.Brokers, ","), group, cfg)
+}
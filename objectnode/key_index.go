@@ -0,0 +1,518 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	KeyIndexStatusBuilding = "Building"
+	KeyIndexStatusEnabled  = "Enabled"
+	KeyIndexStatusStale    = "Stale"
+
+	// the number of the dentries read at once when the index is built, listed or dropped
+	keyIndexReadLimit = 1000
+
+	// the byte to escape the separator and the bytes less than it in the index names
+	keyIndexEscape = 0x01
+
+	// the interval to retry storing the stale status of the index
+	keyIndexStaleRetryInterval = 5 * time.Second
+)
+
+// the build waits until the other nodes have loaded the index, so that the objects written through
+// them are indexed before the existing keys are scanned.
+var keyIndexBuildDelay = OSSMetaUpdateDuration + 5*time.Second
+
+var (
+	errKeyIndexChanged   = errors.New("key index changed")
+	errInvalidIndexEntry = errors.New("invalid key index entry")
+)
+
+// BucketKeyIndex is the state of the sorted key index of the bucket. The index is a directory inode
+// without any dentry linked to it, and each object key is kept as a dentry named by the encoded key
+// under it, which points to the inode of the object. So that the keys can be listed by a range scan
+// of the index instead of walking the directory tree.
+//
+// The index is maintained by the writes through the object nodes, the changes made through the other
+// interfaces of the volume, such as the renames by the clients of the file system, are not indexed
+// until the index is rebuilt. The index missing a write of the object nodes is marked as stale, and
+// the listing walks the directory tree until it is rebuilt.
+type BucketKeyIndex struct {
+	XMLName    xml.Name   `xml:"KeyIndexConfiguration" json:"-"`
+	XMLNS      string     `xml:"xmlns,attr,omitempty" json:"-"`
+	Status     string     `xml:"Status" json:"status"`
+	Inode      uint64     `xml:"-" json:"inode"`
+	CreateTime time.Time  `xml:"CreateTime" json:"create_time"`
+	BuildTime  *time.Time `xml:"BuildTime,omitempty" json:"build_time,omitempty"`
+}
+
+// encodeIndexKey encodes the object key to the name of the index entry. The separator is encoded
+// as the smallest byte sequence, so that the entries are sorted in the same order as the keys listed
+// by walking the directory tree, and the keys with the same prefix are stored continuously.
+func encodeIndexKey(key string) string {
+	var b strings.Builder
+	b.Grow(len(key))
+	for i := 0; i < len(key); i++ {
+		switch c := key[i]; c {
+		case '/':
+			b.WriteByte(keyIndexEscape)
+			b.WriteByte(0x01)
+		case 0x00:
+			b.WriteByte(keyIndexEscape)
+			b.WriteByte(0x02)
+		case keyIndexEscape:
+			b.WriteByte(keyIndexEscape)
+			b.WriteByte(0x03)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func decodeIndexKey(name string) (string, error) {
+	var b strings.Builder
+	b.Grow(len(name))
+	for i := 0; i < len(name); i++ {
+		if name[i] != keyIndexEscape {
+			b.WriteByte(name[i])
+			continue
+		}
+		if i++; i >= len(name) {
+			return "", errInvalidIndexEntry
+		}
+		switch name[i] {
+		case 0x01:
+			b.WriteByte('/')
+		case 0x02:
+			b.WriteByte(0x00)
+		case 0x03:
+			b.WriteByte(keyIndexEscape)
+		default:
+			return "", errInvalidIndexEntry
+		}
+	}
+	return b.String(), nil
+}
+
+func storeBucketKeyIndex(vol *Volume, index *BucketKeyIndex) (err error) {
+	var data []byte
+	if data, err = json.Marshal(index); err != nil {
+		return
+	}
+	return vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSKeyIndex, data)
+}
+
+func deleteBucketKeyIndex(vol *Volume) (err error) {
+	return vol.store.Delete(vol.name, bucketRootPath, XAttrKeyOSSKeyIndex)
+}
+
+// keyIndex returns the key index of the bucket, nil is returned if the bucket is not indexed.
+func (v *Volume) keyIndex() *BucketKeyIndex {
+	index, err := v.metaLoader.loadKeyIndex()
+	if err != nil {
+		log.LogWarnf("keyIndex: load key index fail: volume(%v) err(%v)", v.name, err)
+		return nil
+	}
+	return index
+}
+
+// enableKeyIndex creates the key index of the bucket and builds it in background,
+// the existing index is returned if the bucket has been indexed.
+func (v *Volume) enableKeyIndex() (index *BucketKeyIndex, err error) {
+	if index, err = v.loadBucketKeyIndex(); err != nil || index != nil {
+		return
+	}
+	if index, err = v.newKeyIndex(); err != nil {
+		return
+	}
+	go v.buildKeyIndex(index)
+	return
+}
+
+// rebuildKeyIndex replaces the key index of the bucket with a new one, which is built in background.
+// The listing walks the directory tree until the new index is built.
+func (v *Volume) rebuildKeyIndex() (index *BucketKeyIndex, err error) {
+	var old *BucketKeyIndex
+	if old, err = v.loadBucketKeyIndex(); err != nil {
+		return
+	}
+	if old == nil {
+		return nil, NoSuchBucketKeyIndex
+	}
+	if index, err = v.newKeyIndex(); err != nil {
+		return
+	}
+	go v.dropKeyIndex(old.Inode)
+	go v.buildKeyIndex(index)
+	return
+}
+
+// disableKeyIndex removes the key index of the bucket, the entries are dropped in background.
+func (v *Volume) disableKeyIndex() (err error) {
+	var index *BucketKeyIndex
+	if index, err = v.loadBucketKeyIndex(); err != nil {
+		return
+	}
+	if index == nil {
+		return NoSuchBucketKeyIndex
+	}
+	if err = deleteBucketKeyIndex(v); err != nil {
+		return
+	}
+	v.metaLoader.storeKeyIndex(nil)
+	go v.dropKeyIndex(index.Inode)
+	return
+}
+
+func (v *Volume) newKeyIndex() (index *BucketKeyIndex, err error) {
+	var info *proto.InodeInfo
+	if info, err = v.mw.InodeCreate_ll(0, uint32(DefaultDirMode), 0, 0, nil, make([]uint64, 0), ""); err != nil {
+		log.LogErrorf("newKeyIndex: create index inode fail: volume(%v) err(%v)", v.name, err)
+		return
+	}
+	index = &BucketKeyIndex{
+		Status:     KeyIndexStatusBuilding,
+		Inode:      info.Inode,
+		CreateTime: time.Now().UTC(),
+	}
+	if err = storeBucketKeyIndex(v, index); err != nil {
+		log.LogErrorf("newKeyIndex: store key index fail: volume(%v) inode(%v) err(%v)", v.name, info.Inode, err)
+		v.dropKeyIndex(info.Inode)
+		return nil, err
+	}
+	v.metaLoader.storeKeyIndex(index)
+	return
+}
+
+// buildKeyIndex indexes the existing objects of the bucket, and enables the index for listing when
+// all objects have been indexed. The build stops if the index is rebuilt or disabled meanwhile.
+func (v *Volume) buildKeyIndex(index *BucketKeyIndex) {
+	select {
+	case <-time.After(keyIndexBuildDelay):
+	case <-v.closeCh:
+		return
+	}
+
+	start := time.Now()
+	var count uint64
+	if err := v.walkKeyIndex(index, rootIno, "", &count); err != nil {
+		if err == errKeyIndexChanged {
+			log.LogInfof("buildKeyIndex: key index changed: volume(%v) inode(%v)", v.name, index.Inode)
+			return
+		}
+		log.LogErrorf("buildKeyIndex: build fail: volume(%v) inode(%v) keys(%v) err(%v)",
+			v.name, index.Inode, count, err)
+		return
+	}
+
+	current, err := v.loadBucketKeyIndex()
+	if err != nil || current == nil || current.Inode != index.Inode {
+		log.LogWarnf("buildKeyIndex: key index changed: volume(%v) inode(%v) err(%v)", v.name, index.Inode, err)
+		return
+	}
+	if current.Status == KeyIndexStatusStale {
+		log.LogWarnf("buildKeyIndex: key index is stale: volume(%v) inode(%v)", v.name, index.Inode)
+		return
+	}
+	now := time.Now().UTC()
+	current.Status = KeyIndexStatusEnabled
+	current.BuildTime = &now
+	if err = storeBucketKeyIndex(v, current); err != nil {
+		log.LogErrorf("buildKeyIndex: store key index fail: volume(%v) inode(%v) err(%v)", v.name, index.Inode, err)
+		return
+	}
+	v.metaLoader.storeKeyIndex(current)
+	log.LogInfof("buildKeyIndex: build finished: volume(%v) inode(%v) keys(%v) cost(%v)",
+		v.name, index.Inode, count, time.Since(start))
+}
+
+func (v *Volume) walkKeyIndex(index *BucketKeyIndex, parentId uint64, dir string, count *uint64) (err error) {
+	var from string
+	for {
+		var children []proto.Dentry
+		if children, err = v.mw.ReadDirLimit_ll(parentId, from, keyIndexReadLimit); err != nil {
+			// the directory has been deleted concurrently
+			if err == syscall.ENOENT {
+				return nil
+			}
+			return
+		}
+		var current *BucketKeyIndex
+		if current, err = v.loadBucketKeyIndex(); err != nil {
+			return
+		}
+		if current == nil || current.Inode != index.Inode {
+			return errKeyIndexChanged
+		}
+		for _, child := range children {
+			if child.Name == from {
+				continue
+			}
+			path := dir + child.Name
+			if os.FileMode(child.Type).IsDir() {
+				if err = v.walkKeyIndex(index, child.Inode, path+pathSep, count); err != nil {
+					return
+				}
+				continue
+			}
+			if !os.FileMode(child.Type).IsRegular() {
+				continue
+			}
+			// the entry created by the write meanwhile is newer than the scanned one
+			err = v.mw.DentryCreate_ll(index.Inode, encodeIndexKey(path), child.Inode, DefaultFileMode, "")
+			if err != nil && err != syscall.EEXIST {
+				return
+			}
+			*count++
+		}
+		if len(children) < keyIndexReadLimit {
+			return nil
+		}
+		from = children[len(children)-1].Name
+	}
+}
+
+// dropKeyIndex removes all entries of the index and releases the index inode,
+// the inodes of the objects are untouched.
+func (v *Volume) dropKeyIndex(ino uint64) {
+	for {
+		children, err := v.mw.ReadDirLimit_ll(ino, "", keyIndexReadLimit)
+		if err != nil {
+			log.LogErrorf("dropKeyIndex: read index fail: volume(%v) inode(%v) err(%v)", v.name, ino, err)
+			return
+		}
+		if len(children) == 0 {
+			break
+		}
+		for _, child := range children {
+			if _, err = v.mw.DentryDelete_ll(ino, child.Name, ""); err != nil && err != syscall.ENOENT {
+				log.LogErrorf("dropKeyIndex: delete entry fail: volume(%v) inode(%v) err(%v)", v.name, ino, err)
+				return
+			}
+		}
+	}
+	if _, err := v.mw.InodeUnlink_ll(ino, ""); err != nil {
+		log.LogErrorf("dropKeyIndex: unlink index inode fail: volume(%v) inode(%v) err(%v)", v.name, ino, err)
+		return
+	}
+	if err := v.mw.Evict(ino, ""); err != nil {
+		log.LogWarnf("dropKeyIndex: evict index inode fail: volume(%v) inode(%v) err(%v)", v.name, ino, err)
+	}
+	log.LogInfof("dropKeyIndex: key index dropped: volume(%v) inode(%v)", v.name, ino)
+}
+
+// putKeyIndex points the index entry of the key to the inode of the object. The write of the object
+// has been done, so the index is marked as stale on failure instead of failing the write.
+func (v *Volume) putKeyIndex(key string, inode uint64) {
+	index := v.keyIndex()
+	if index == nil {
+		return
+	}
+	name := encodeIndexKey(key)
+	err := v.mw.DentryCreate_ll(index.Inode, name, inode, DefaultFileMode, "")
+	if err == syscall.EEXIST {
+		_, err = v.mw.DentryUpdate_ll(index.Inode, name, inode, "")
+	}
+	if err != nil {
+		log.LogErrorf("putKeyIndex: put entry fail: volume(%v) key(%v) inode(%v) err(%v)", v.name, key, inode, err)
+		v.markKeyIndexStale(index.Inode)
+	}
+}
+
+// syncKeyIndex updates the index entry of the key according to the current object of the key
+// after it is deleted, since the deletion of a version may make another one current.
+func (v *Volume) syncKeyIndex(key string) {
+	index := v.keyIndex()
+	if index == nil {
+		return
+	}
+	_, ino, _, mode, err := v.recursiveLookupTarget(key, true)
+	if err == nil && mode.IsRegular() {
+		v.putKeyIndex(key, ino)
+		return
+	}
+	if err != nil && err != syscall.ENOENT {
+		log.LogErrorf("syncKeyIndex: lookup fail: volume(%v) key(%v) err(%v)", v.name, key, err)
+		v.markKeyIndexStale(index.Inode)
+		return
+	}
+	if _, err = v.mw.DentryDelete_ll(index.Inode, encodeIndexKey(key), ""); err != nil && err != syscall.ENOENT {
+		log.LogErrorf("syncKeyIndex: delete entry fail: volume(%v) key(%v) err(%v)", v.name, key, err)
+		v.markKeyIndexStale(index.Inode)
+	}
+}
+
+// markKeyIndexStale marks the index missing a change of the objects as stale, so that the listing
+// walks the directory tree until the index is rebuilt. The status is retried in background until
+// it's stored, and the listing of this node stops using the index meanwhile.
+func (v *Volume) markKeyIndexStale(ino uint64) {
+	if err := v.storeKeyIndexStale(ino); err == nil {
+		return
+	}
+	if index := v.keyIndex(); index != nil && index.Inode == ino {
+		stale := *index
+		stale.Status = KeyIndexStatusStale
+		v.metaLoader.storeKeyIndex(&stale)
+	}
+	go func() {
+		for {
+			select {
+			case <-time.After(keyIndexStaleRetryInterval):
+			case <-v.closeCh:
+				return
+			}
+			if err := v.storeKeyIndexStale(ino); err == nil {
+				return
+			}
+		}
+	}()
+}
+
+func (v *Volume) storeKeyIndexStale(ino uint64) (err error) {
+	var current *BucketKeyIndex
+	if current, err = v.loadBucketKeyIndex(); err != nil {
+		log.LogErrorf("storeKeyIndexStale: load key index fail: volume(%v) inode(%v) err(%v)", v.name, ino, err)
+		return
+	}
+	// the index has been rebuilt or disabled meanwhile
+	if current == nil || current.Inode != ino || current.Status == KeyIndexStatusStale {
+		return nil
+	}
+	current.Status = KeyIndexStatusStale
+	if err = storeBucketKeyIndex(v, current); err != nil {
+		log.LogErrorf("storeKeyIndexStale: store key index fail: volume(%v) inode(%v) err(%v)", v.name, ino, err)
+		return
+	}
+	v.metaLoader.storeKeyIndex(current)
+	log.LogWarnf("storeKeyIndexStale: key index is stale until rebuilt: volume(%v) inode(%v)", v.name, ino)
+	return nil
+}
+
+// listKeyIndex lists the keys after the marker with the prefix by a range scan of the key index,
+// and the last listed key is returned as the next marker if the result is truncated, which is
+// excluded by the next listing. The listing falls back to walking the directory tree if ok is false.
+func (v *Volume) listKeyIndex(prefix, marker string, maxKeys uint64) (infos []*FSFileInfo, nextMarker string, ok bool) {
+	index := v.keyIndex()
+	if index == nil || index.Status != KeyIndexStatusEnabled || maxKeys == 0 {
+		return
+	}
+	read := func(from string) ([]proto.Dentry, error) {
+		return v.mw.ReadDirLimit_ll(index.Inode, from, keyIndexReadLimit)
+	}
+	var err error
+	if infos, nextMarker, err = scanKeyIndex(read, v.filterKeyIndex, prefix, marker, maxKeys); err != nil {
+		log.LogWarnf("listKeyIndex: read index fail: volume(%v) inode(%v) prefix(%v) marker(%v) err(%v)",
+			v.name, index.Inode, prefix, marker, err)
+		return nil, "", false
+	}
+	return infos, nextMarker, true
+}
+
+// scanKeyIndex scans the entries of the index from the marker exclusively, the entries are read
+// by the batches of keyIndexReadLimit starting from the name inclusively, and filtered before
+// they are listed.
+func scanKeyIndex(read func(from string) ([]proto.Dentry, error), filter func([]*FSFileInfo) []*FSFileInfo,
+	prefix, marker string, maxKeys uint64,
+) (infos []*FSFileInfo, nextMarker string, err error) {
+	fromName := encodeIndexKey(prefix)
+	var skipName string
+	if marker != "" {
+		skipName = encodeIndexKey(marker)
+		if skipName > fromName {
+			fromName = skipName
+		}
+	}
+
+	infos = make([]*FSFileInfo, 0)
+	for {
+		var children []proto.Dentry
+		if children, err = read(fromName); err != nil {
+			return nil, "", err
+		}
+		batch := make([]*FSFileInfo, 0, len(children))
+		finished := len(children) < keyIndexReadLimit
+		for _, child := range children {
+			if child.Name == skipName {
+				continue
+			}
+			key, err := decodeIndexKey(child.Name)
+			if err != nil {
+				log.LogWarnf("scanKeyIndex: decode entry fail: name(%v) err(%v)", child.Name, err)
+				continue
+			}
+			// the keys with the prefix are continuous in the index
+			if !strings.HasPrefix(key, prefix) {
+				finished = true
+				break
+			}
+			batch = append(batch, &FSFileInfo{Inode: child.Inode, Path: key})
+		}
+		for _, info := range filter(batch) {
+			if uint64(len(infos)) >= maxKeys {
+				return infos, infos[len(infos)-1].Path, nil
+			}
+			infos = append(infos, info)
+		}
+		if finished {
+			return infos, "", nil
+		}
+		// the last entry read has been listed or filtered out
+		fromName = children[len(children)-1].Name
+		skipName = fromName
+	}
+}
+
+// filterKeyIndex filters out the stale entries whose objects have been removed by the other
+// interfaces of the volume, and the entries are synchronized with the current objects.
+func (v *Volume) filterKeyIndex(infos []*FSFileInfo) []*FSFileInfo {
+	if len(infos) == 0 {
+		return infos
+	}
+	inodes := make([]uint64, 0, len(infos))
+	for _, info := range infos {
+		inodes = append(inodes, info.Inode)
+	}
+	exists := make(map[uint64]struct{}, len(infos))
+	for _, inodeInfo := range v.mw.BatchInodeGet(inodes) {
+		exists[inodeInfo.Inode] = struct{}{}
+	}
+	filtered := infos[:0]
+	for _, info := range infos {
+		if _, ok := exists[info.Inode]; ok {
+			filtered = append(filtered, info)
+			continue
+		}
+		// the batch may miss some inodes on failure, so the entry is removed only if the inode is surely gone.
+		if _, err := v.mw.InodeGet_ll(info.Inode); err != syscall.ENOENT {
+			filtered = append(filtered, info)
+			continue
+		}
+		log.LogDebugf("filterKeyIndex: stale entry: volume(%v) key(%v) inode(%v)", v.name, info.Path, info.Inode)
+		v.syncKeyIndex(info.Path)
+	}
+	return filtered
+}
@@ -0,0 +1,180 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"net/http"
+
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// Get bucket key index
+// Notes: extension api of CubeFS, the status of the sorted key index of the bucket is returned.
+func (o *ObjectNode) getBucketKeyIndexHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("getBucketKeyIndexHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var index *BucketKeyIndex
+	if index, err = vol.loadBucketKeyIndex(); err != nil {
+		log.LogErrorf("getBucketKeyIndexHandler: load key index fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if index == nil {
+		errorCode = NoSuchBucketKeyIndex
+		return
+	}
+
+	err = writeBucketKeyIndex(w, r, vol, index)
+}
+
+// Put bucket key index
+// Notes: extension api of CubeFS, the key index is created and the existing objects are indexed in
+// background, the listing uses the index after the status becomes Enabled.
+func (o *ObjectNode) putBucketKeyIndexHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("putBucketKeyIndexHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var index *BucketKeyIndex
+	if index, err = vol.enableKeyIndex(); err != nil {
+		log.LogErrorf("putBucketKeyIndexHandler: enable key index fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+
+	log.LogInfof("Audit: put bucket key index: requestID(%v) volume(%v) inode(%v) status(%v)",
+		GetRequestID(r), vol.Name(), index.Inode, index.Status)
+	err = writeBucketKeyIndex(w, r, vol, index)
+}
+
+// Rebuild bucket key index
+// Notes: extension api of CubeFS, the key index is replaced by a new one built in background, which
+// fixes the index after the objects are changed through the other interfaces of the volume.
+func (o *ObjectNode) rebuildBucketKeyIndexHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("rebuildBucketKeyIndexHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var index *BucketKeyIndex
+	if index, err = vol.rebuildKeyIndex(); err != nil {
+		log.LogErrorf("rebuildBucketKeyIndexHandler: rebuild key index fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+
+	log.LogInfof("Audit: rebuild bucket key index: requestID(%v) volume(%v) inode(%v)",
+		GetRequestID(r), vol.Name(), index.Inode)
+	err = writeBucketKeyIndex(w, r, vol, index)
+}
+
+// Delete bucket key index
+// Notes: extension api of CubeFS
+func (o *ObjectNode) deleteBucketKeyIndexHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("deleteBucketKeyIndexHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	if err = vol.disableKeyIndex(); err != nil {
+		log.LogErrorf("deleteBucketKeyIndexHandler: disable key index fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+
+	log.LogInfof("Audit: delete bucket key index: requestID(%v) volume(%v)", GetRequestID(r), vol.Name())
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeBucketKeyIndex(w http.ResponseWriter, r *http.Request, vol *Volume, index *BucketKeyIndex) error {
+	output := *index
+	output.XMLNS = XMLNS
+	data, err := MarshalXMLEntity(&output)
+	if err != nil {
+		log.LogErrorf("writeBucketKeyIndex: xml marshal fail: requestID(%v) volume(%v) index(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), index, err)
+		return err
+	}
+	writeSuccessResponseXML(w, data)
+	return nil
+}
@@ -0,0 +1,154 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexKeyEncoding(t *testing.T) {
+	for _, key := range []string{"", "a", "a/b/c", "dir/", "a\x00b", "a\x01b/\x01", "中文/对象"} {
+		name := encodeIndexKey(key)
+		require.NotContains(t, name, "/")
+		require.NotContains(t, name, "\x00")
+		decoded, err := decodeIndexKey(name)
+		require.NoError(t, err)
+		require.Equal(t, key, decoded)
+	}
+
+	for _, name := range []string{"a\x01", "a\x01\x04"} {
+		_, err := decodeIndexKey(name)
+		require.Equal(t, errInvalidIndexEntry, err)
+	}
+}
+
+func TestIndexKeyOrder(t *testing.T) {
+	// the order of the keys listed by walking the directory tree
+	walked := []string{"a/b", "a/c/d", "a\x00", "a\x01", "a.txt", "a0", "ab/x", "b"}
+	keys := append([]string(nil), walked...)
+	sort.Slice(keys, func(i, j int) bool {
+		return encodeIndexKey(keys[i]) < encodeIndexKey(keys[j])
+	})
+	require.Equal(t, walked, keys)
+
+	// the keys with the same prefix are continuous
+	for _, prefix := range []string{"a", "a/", "a/c", "ab"} {
+		first, last := -1, -1
+		for i, key := range keys {
+			if strings.HasPrefix(key, prefix) {
+				if first < 0 {
+					first = i
+				}
+				last = i
+			}
+		}
+		for i := first; i <= last; i++ {
+			require.True(t, strings.HasPrefix(keys[i], prefix), prefix)
+		}
+		require.False(t, encodeIndexKey(prefix) > encodeIndexKey(keys[first]), prefix)
+	}
+}
+
+func TestBucketKeyIndexEncoding(t *testing.T) {
+	now := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	index := &BucketKeyIndex{Status: KeyIndexStatusBuilding, Inode: 100, CreateTime: now}
+	data, err := json.Marshal(index)
+	require.NoError(t, err)
+	loaded := &BucketKeyIndex{}
+	require.NoError(t, json.Unmarshal(data, loaded))
+	require.Equal(t, index.Inode, loaded.Inode)
+	require.Equal(t, index.Status, loaded.Status)
+	require.Nil(t, loaded.BuildTime)
+
+	data, err = MarshalXMLEntity(index)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "100")
+	require.NotContains(t, string(data), "BuildTime")
+	require.Contains(t, string(data), "<Status>Building</Status>")
+
+	index.BuildTime = &now
+	data, err = MarshalXMLEntity(index)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "<BuildTime>2024-05-01T00:00:00Z</BuildTime>")
+}
+
+func TestScanKeyIndexPages(t *testing.T) {
+	keys := make([]string, 0)
+	for i := 0; i < 2*keyIndexReadLimit+10; i++ {
+		keys = append(keys, fmt.Sprintf("a/%05d", i))
+	}
+	keys = append(keys, "b/1", "b/2")
+	dentries := make([]proto.Dentry, 0, len(keys))
+	for i, key := range keys {
+		dentries = append(dentries, proto.Dentry{Name: encodeIndexKey(key), Inode: uint64(i + 1)})
+	}
+	sort.Slice(dentries, func(i, j int) bool { return dentries[i].Name < dentries[j].Name })
+	read := func(from string) ([]proto.Dentry, error) {
+		i := sort.Search(len(dentries), func(i int) bool { return dentries[i].Name >= from })
+		end := i + keyIndexReadLimit
+		if end > len(dentries) {
+			end = len(dentries)
+		}
+		return dentries[i:end], nil
+	}
+	// the entries of every seventh inode are stale
+	filter := func(infos []*FSFileInfo) []*FSFileInfo {
+		filtered := infos[:0]
+		for _, info := range infos {
+			if info.Inode%7 != 0 {
+				filtered = append(filtered, info)
+			}
+		}
+		return filtered
+	}
+	expected := make([]string, 0)
+	for i, key := range keys {
+		if strings.HasPrefix(key, "a/") && (i+1)%7 != 0 {
+			expected = append(expected, key)
+		}
+	}
+
+	for _, maxKeys := range []uint64{1, 7, keyIndexReadLimit - 1, keyIndexReadLimit, keyIndexReadLimit + 1, 5000} {
+		listed := make([]string, 0)
+		marker := ""
+		for {
+			infos, nextMarker, err := scanKeyIndex(read, filter, "a/", marker, maxKeys)
+			require.NoError(t, err)
+			require.LessOrEqual(t, uint64(len(infos)), maxKeys)
+			for _, info := range infos {
+				listed = append(listed, info.Path)
+			}
+			if nextMarker == "" {
+				break
+			}
+			require.Equal(t, infos[len(infos)-1].Path, nextMarker)
+			marker = nextMarker
+		}
+		require.Equal(t, expected, listed, maxKeys)
+	}
+
+	// the marker is excluded
+	infos, _, err := scanKeyIndex(read, filter, "", "a/00001", 2)
+	require.NoError(t, err)
+	require.Equal(t, "a/00002", infos[0].Path)
+}
@@ -64,6 +64,11 @@ func (o *ObjectNode) getBucketLifecycleConfigurationHandler(w http.ResponseWrite
 		}
 		return
 	}
+	// the configuration may only have the inventories without any lifecycle rule
+	if len(lcConf.Rules) == 0 {
+		errorCode = NoSuchLifecycleConfiguration
+		return
+	}
 
 	lifeCycle := NewLifecycleConfiguration()
 	lifeCycle.Rules = lcConf.Rules
@@ -123,9 +128,16 @@ func (o *ObjectNode) putBucketLifecycleConfigurationHandler(w http.ResponseWrite
 		return
 	}
 
+	// the inventories kept in the lifecycle configuration are not changed
+	var lcConf *proto.LcConfiguration
+	if lcConf, err = o.loadInventories(param.Bucket()); err != nil {
+		log.LogErrorf("putBucketLifecycle failed: load inventories err: requestID(%v) bucket[%v] err(%v)", GetRequestID(r), param.Bucket(), err)
+		return
+	}
 	req := proto.LcConfiguration{
-		VolName: param.Bucket(),
-		Rules:   lifeCycle.Rules,
+		VolName:     param.Bucket(),
+		Rules:       lifeCycle.Rules,
+		Inventories: lcConf.Inventories,
 	}
 	if err = o.mc.AdminAPI().SetBucketLifecycle(&req); err != nil {
 		log.LogErrorf("putBucketLifecycle failed: SetBucketLifecycle err: requestID(%v) bucket[%v] err(%v)", GetRequestID(r), param.Bucket(), err)
@@ -158,7 +170,19 @@ func (o *ObjectNode) deleteBucketLifecycleConfigurationHandler(w http.ResponseWr
 		return
 	}
 
-	if err = o.mc.AdminAPI().DelBucketLifecycle(param.Bucket()); err != nil {
+	var lcConf *proto.LcConfiguration
+	if lcConf, err = o.loadInventories(param.Bucket()); err != nil {
+		log.LogErrorf("deleteBucketLifecycle failed: load inventories err: bucket[%v] err(%v)", param.Bucket(), err)
+		return
+	}
+	// only the lifecycle rules are deleted if there are inventories of the bucket
+	if len(lcConf.Inventories) > 0 {
+		lcConf.Rules = nil
+		err = o.mc.AdminAPI().SetBucketLifecycle(lcConf)
+	} else {
+		err = o.mc.AdminAPI().DelBucketLifecycle(param.Bucket())
+	}
+	if err != nil {
 		log.LogErrorf("deleteBucketLifecycle failed: bucket[%v] err(%v)", param.Bucket(), err)
 		return
 	}
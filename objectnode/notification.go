@@ -0,0 +1,359 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	NotificationInvalidEventErr       = errors.New("The event is not supported for notifications")
+	NotificationNoEventErr            = errors.New("At least one event must be specified in the notification configuration")
+	NotificationInvalidArnErr         = errors.New("The ARN of the notification destination is invalid")
+	NotificationInvalidFilterErr      = errors.New("The filter rule name must be either prefix or suffix")
+	NotificationDuplicatedFilterErr   = errors.New("Cannot specify more than one prefix or suffix rule in a filter")
+	NotificationFilterValueTooLongErr = errors.New("The value of the filter rule must be no longer than 1024 characters")
+	NotificationIdDuplicatedErr       = errors.New("The ID of notification configurations must be unique")
+	NotificationIdTooLongErr          = errors.New("The ID of notification configuration must be no longer than 255 characters")
+	NotificationCloudFunctionErr      = errors.New("Cloud function notification destination is not supported")
+	NotificationTooManyConfigsErr     = errors.New("The number of notification configurations must not exceed the allowed limit of 100")
+	NotificationUnknownDestinationErr = errors.New("Unable to validate the following destination configurations")
+)
+
+const (
+	EventObjectCreatedAll                     = "s3:ObjectCreated:*"
+	EventObjectCreatedPut                     = "s3:ObjectCreated:Put"
+	EventObjectCreatedPost                    = "s3:ObjectCreated:Post"
+	EventObjectCreatedCopy                    = "s3:ObjectCreated:Copy"
+	EventObjectCreatedCompleteMultipartUpload = "s3:ObjectCreated:CompleteMultipartUpload"
+	EventObjectCreatedAppend                  = "s3:ObjectCreated:Append"
+	EventObjectRemovedAll                     = "s3:ObjectRemoved:*"
+	EventObjectRemovedDelete                  = "s3:ObjectRemoved:Delete"
+	EventObjectRemovedDeleteMarkerCreated     = "s3:ObjectRemoved:DeleteMarkerCreated"
+	EventObjectTaggingAll                     = "s3:ObjectTagging:*"
+	EventObjectTaggingPut                     = "s3:ObjectTagging:Put"
+	EventObjectTaggingDelete                  = "s3:ObjectTagging:Delete"
+
+	MaxNotificationConfigSize     = 1 << 20 // 1MB
+	maxNotificationConfigs        = 100
+	maxNotificationIdLen          = 255
+	maxNotificationFilterValueLen = 1024
+
+	notificationEventVersion  = "2.1"
+	notificationEventSource   = "aws:s3"
+	notificationSchemaVersion = "1.0"
+)
+
+var notificationEvents = map[string]struct{}{
+	EventObjectCreatedAll:                     {},
+	EventObjectCreatedPut:                     {},
+	EventObjectCreatedPost:                    {},
+	EventObjectCreatedCopy:                    {},
+	EventObjectCreatedCompleteMultipartUpload: {},
+	EventObjectCreatedAppend:                  {},
+	EventObjectRemovedAll:                     {},
+	EventObjectRemovedDelete:                  {},
+	EventObjectRemovedDeleteMarkerCreated:     {},
+	EventObjectTaggingAll:                     {},
+	EventObjectTaggingPut:                     {},
+	EventObjectTaggingDelete:                  {},
+}
+
+// NotificationConfiguration is the notification configuration of the bucket. The events are sent to the
+// targets configured in the ObjectNode configuration, which are referenced by the last part of the queue
+// or topic ARN, e.g. "arn:aws:sqs:::<target>".
+type NotificationConfiguration struct {
+	XMLNS          string              `xml:"xmlns,attr,omitempty" json:"-"`
+	XMLName        xml.Name            `xml:"NotificationConfiguration" json:"-"`
+	Topics         []*NotificationRule `xml:"TopicConfiguration" json:"topics,omitempty"`
+	Queues         []*NotificationRule `xml:"QueueConfiguration" json:"queues,omitempty"`
+	CloudFunctions []*NotificationRule `xml:"CloudFunctionConfiguration" json:"-"`
+}
+
+// NotificationRule is one of the topic or queue configurations, only the destination of its kind is set.
+type NotificationRule struct {
+	ID     string              `xml:"Id,omitempty" json:"id,omitempty"`
+	Topic  string              `xml:"Topic,omitempty" json:"topic,omitempty"`
+	Queue  string              `xml:"Queue,omitempty" json:"queue,omitempty"`
+	Events []string            `xml:"Event" json:"events"`
+	Filter *NotificationFilter `xml:"Filter,omitempty" json:"filter,omitempty"`
+}
+
+type NotificationFilter struct {
+	S3Key *NotificationKeyFilter `xml:"S3Key" json:"key,omitempty"`
+}
+
+type NotificationKeyFilter struct {
+	FilterRules []NotificationFilterRule `xml:"FilterRule" json:"rules,omitempty"`
+}
+
+type NotificationFilterRule struct {
+	Name  string `xml:"Name" json:"name"`
+	Value string `xml:"Value" json:"value"`
+}
+
+func newNotificationError(err error) *ErrorCode {
+	return NewError("InvalidArgument", err.Error(), 400)
+}
+
+// parse NotificationConfiguration from xml
+func ParseNotificationConfigFromXML(data []byte) (*NotificationConfiguration, error) {
+	config := &NotificationConfiguration{}
+	if err := xml.Unmarshal(data, config); err != nil {
+		return nil, MalformedXML
+	}
+	if err := config.CheckValid(); err != nil {
+		return nil, newNotificationError(err)
+	}
+	return config, nil
+}
+
+func (c *NotificationConfiguration) IsEmpty() bool {
+	return c == nil || len(c.Topics) == 0 && len(c.Queues) == 0
+}
+
+func (c *NotificationConfiguration) CheckValid() error {
+	if len(c.CloudFunctions) > 0 {
+		return NotificationCloudFunctionErr
+	}
+	if len(c.Topics)+len(c.Queues) > maxNotificationConfigs {
+		return NotificationTooManyConfigsErr
+	}
+	ids := make(map[string]struct{})
+	for _, rule := range c.rules() {
+		if err := rule.checkValid(); err != nil {
+			return err
+		}
+		if rule.ID != "" {
+			if _, ok := ids[rule.ID]; ok {
+				return NotificationIdDuplicatedErr
+			}
+			ids[rule.ID] = struct{}{}
+		}
+	}
+	for _, rule := range c.Topics {
+		if rule.Queue != "" {
+			return NotificationInvalidArnErr
+		}
+		if _, err := parseNotificationArn(rule.Topic, "sns"); err != nil {
+			return err
+		}
+	}
+	for _, rule := range c.Queues {
+		if rule.Topic != "" {
+			return NotificationInvalidArnErr
+		}
+		if _, err := parseNotificationArn(rule.Queue, "sqs"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *NotificationConfiguration) rules() []*NotificationRule {
+	rules := make([]*NotificationRule, 0, len(c.Topics)+len(c.Queues))
+	rules = append(rules, c.Topics...)
+	return append(rules, c.Queues...)
+}
+
+// matchRules returns the rules which the event of the object is sent by.
+func (c *NotificationConfiguration) matchRules(event, key string) (matched []*NotificationRule) {
+	if c == nil {
+		return nil
+	}
+	for _, rule := range c.rules() {
+		if rule.match(event, key) {
+			matched = append(matched, rule)
+		}
+	}
+	return
+}
+
+func (r *NotificationRule) checkValid() error {
+	if len(r.ID) > maxNotificationIdLen {
+		return NotificationIdTooLongErr
+	}
+	if len(r.Events) == 0 {
+		return NotificationNoEventErr
+	}
+	for _, event := range r.Events {
+		if _, ok := notificationEvents[event]; !ok {
+			return NotificationInvalidEventErr
+		}
+	}
+	if r.Filter != nil && r.Filter.S3Key != nil {
+		names := make(map[string]struct{})
+		for _, rule := range r.Filter.S3Key.FilterRules {
+			name := strings.ToLower(rule.Name)
+			if name != "prefix" && name != "suffix" {
+				return NotificationInvalidFilterErr
+			}
+			if _, ok := names[name]; ok {
+				return NotificationDuplicatedFilterErr
+			}
+			if len(rule.Value) > maxNotificationFilterValueLen {
+				return NotificationFilterValueTooLongErr
+			}
+			names[name] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// target returns the name of the target which the events are sent to.
+func (r *NotificationRule) target() string {
+	if r.Queue != "" {
+		target, _ := parseNotificationArn(r.Queue, "sqs")
+		return target
+	}
+	target, _ := parseNotificationArn(r.Topic, "sns")
+	return target
+}
+
+func (r *NotificationRule) match(event, key string) bool {
+	matched := false
+	for _, e := range r.Events {
+		if e == event || strings.HasSuffix(e, "*") && strings.HasPrefix(event, e[:len(e)-1]) {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return false
+	}
+	if r.Filter != nil && r.Filter.S3Key != nil {
+		for _, rule := range r.Filter.S3Key.FilterRules {
+			switch strings.ToLower(rule.Name) {
+			case "prefix":
+				if !strings.HasPrefix(key, rule.Value) {
+					return false
+				}
+			case "suffix":
+				if !strings.HasSuffix(key, rule.Value) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// parseNotificationArn parses the ARN in the form "arn:<partition>:<service>:<region>:<account>:<target>",
+// the region and account are ignored.
+func parseNotificationArn(arn, service string) (target string, err error) {
+	items := strings.Split(arn, ":")
+	if len(items) != 6 || items[0] != "arn" || items[2] != service || items[5] == "" {
+		return "", NotificationInvalidArnErr
+	}
+	return items[5], nil
+}
+
+func storeBucketNotification(bytes []byte, vol *Volume) (err error) {
+	return vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSNotification, bytes)
+}
+
+func deleteBucketNotification(vol *Volume) (err error) {
+	return vol.store.Delete(vol.name, bucketRootPath, XAttrKeyOSSNotification)
+}
+
+// NotificationEvent is the message sent to the targets, it's compatible with the event message of AWS S3.
+type NotificationEvent struct {
+	Records []*NotificationRecord `json:"Records"`
+}
+
+type NotificationIdentity struct {
+	PrincipalID string `json:"principalId"`
+}
+
+type NotificationRecord struct {
+	EventVersion      string               `json:"eventVersion"`
+	EventSource       string               `json:"eventSource"`
+	AwsRegion         string               `json:"awsRegion"`
+	EventTime         string               `json:"eventTime"`
+	EventName         string               `json:"eventName"`
+	UserIdentity      NotificationIdentity `json:"userIdentity"`
+	RequestParameters struct {
+		SourceIPAddress string `json:"sourceIPAddress"`
+	} `json:"requestParameters"`
+	ResponseElements struct {
+		RequestID string `json:"x-amz-request-id"`
+	} `json:"responseElements"`
+	S3 struct {
+		SchemaVersion   string `json:"s3SchemaVersion"`
+		ConfigurationID string `json:"configurationId"`
+		Bucket          struct {
+			Name          string               `json:"name"`
+			OwnerIdentity NotificationIdentity `json:"ownerIdentity"`
+			Arn           string               `json:"arn"`
+		} `json:"bucket"`
+		Object struct {
+			Key       string `json:"key"`
+			Size      int64  `json:"size,omitempty"`
+			ETag      string `json:"eTag,omitempty"`
+			VersionID string `json:"versionId,omitempty"`
+			Sequencer string `json:"sequencer"`
+		} `json:"object"`
+	} `json:"s3"`
+}
+
+// notificationObject is the object which the event occurs on.
+type notificationObject struct {
+	Key       string
+	Size      int64
+	ETag      string
+	VersionID string
+}
+
+// notificationSource is the request which the event is caused by.
+type notificationSource struct {
+	Region    string
+	Bucket    string
+	Owner     string
+	Requester string
+	SourceIP  string
+	RequestID string
+}
+
+// newNotificationEvent creates the event message of the rule.
+func newNotificationEvent(event string, rule *NotificationRule, source *notificationSource,
+	object *notificationObject, now time.Time) ([]byte, error) {
+	record := &NotificationRecord{
+		EventVersion: notificationEventVersion,
+		EventSource:  notificationEventSource,
+		AwsRegion:    source.Region,
+		EventTime:    now.UTC().Format(time.RFC3339Nano),
+		EventName:    strings.TrimPrefix(event, "s3:"),
+		UserIdentity: NotificationIdentity{PrincipalID: source.Requester},
+	}
+	record.RequestParameters.SourceIPAddress = source.SourceIP
+	record.ResponseElements.RequestID = source.RequestID
+	record.S3.SchemaVersion = notificationSchemaVersion
+	record.S3.ConfigurationID = rule.ID
+	record.S3.Bucket.Name = source.Bucket
+	record.S3.Bucket.OwnerIdentity.PrincipalID = source.Owner
+	record.S3.Bucket.Arn = bucketArnPrefix + "::" + source.Bucket
+	record.S3.Object.Key = url.QueryEscape(object.Key)
+	record.S3.Object.Size = object.Size
+	record.S3.Object.ETag = object.ETag
+	record.S3.Object.VersionID = object.VersionID
+	record.S3.Object.Sequencer = strings.ToUpper(strconv.FormatInt(now.UnixNano(), 16))
+	return json.Marshal(&NotificationEvent{Records: []*NotificationRecord{record}})
+}
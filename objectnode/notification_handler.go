@@ -0,0 +1,194 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// Get bucket notification
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketNotificationConfiguration.html
+func (o *ObjectNode) getBucketNotificationHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("getBucketNotificationHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var config *NotificationConfiguration
+	if config, err = vol.metaLoader.loadNotification(); err != nil {
+		log.LogErrorf("getBucketNotificationHandler: load notification fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	// an empty configuration is responded if the notification is not configured
+	if config == nil {
+		config = &NotificationConfiguration{}
+	}
+	config.XMLNS = XMLNS
+
+	var data []byte
+	if data, err = MarshalXMLEntity(config); err != nil {
+		log.LogErrorf("getBucketNotificationHandler: xml marshal fail: requestID(%v) volume(%v) config(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), config, err)
+		return
+	}
+
+	writeSuccessResponseXML(w, data)
+}
+
+// Put bucket notification
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketNotificationConfiguration.html
+func (o *ObjectNode) putBucketNotificationHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("putBucketNotificationHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var body []byte
+	if body, err = io.ReadAll(io.LimitReader(r.Body, MaxNotificationConfigSize+1)); err != nil {
+		log.LogErrorf("putBucketNotificationHandler: read request body fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if len(body) > MaxNotificationConfigSize {
+		errorCode = EntityTooLarge
+		return
+	}
+
+	var config *NotificationConfiguration
+	if config, err = ParseNotificationConfigFromXML(body); err != nil {
+		log.LogErrorf("putBucketNotificationHandler: parse notification config fail: requestID(%v) volume(%v) config(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), err)
+		return
+	}
+
+	// the empty configuration disables the notification of the bucket
+	if config.IsEmpty() {
+		if err = deleteBucketNotification(vol); err != nil {
+			log.LogErrorf("putBucketNotificationHandler: delete notification config fail: requestID(%v) volume(%v) err(%v)",
+				GetRequestID(r), vol.Name(), err)
+			return
+		}
+		vol.metaLoader.storeNotification(nil)
+		log.LogInfof("Audit: delete bucket notification: requestID(%v) volume(%v)", GetRequestID(r), vol.Name())
+		return
+	}
+
+	if o.notifier == nil {
+		errorCode = NotificationNotEnabled
+		return
+	}
+	for _, rule := range config.rules() {
+		if !o.notifier.hasTarget(rule.target()) {
+			log.LogErrorf("putBucketNotificationHandler: unknown notification target: requestID(%v) volume(%v) target(%v)",
+				GetRequestID(r), vol.Name(), rule.target())
+			errorCode = newNotificationError(NotificationUnknownDestinationErr)
+			return
+		}
+	}
+
+	if body, err = json.Marshal(config); err != nil {
+		log.LogErrorf("putBucketNotificationHandler: json marshal notification config fail: requestID(%v) volume(%v) config(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), config, err)
+		return
+	}
+	if err = storeBucketNotification(body, vol); err != nil {
+		log.LogErrorf("putBucketNotificationHandler: store notification config fail: requestID(%v) volume(%v) config(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), err)
+		return
+	}
+	vol.metaLoader.storeNotification(config)
+
+	log.LogInfof("Audit: put bucket notification: requestID(%v) volume(%v) config(%v)",
+		GetRequestID(r), vol.Name(), string(body))
+}
+
+// notify sends the event of the object to the targets according to the notification configuration of the bucket.
+// The event is persisted to the queues of the targets before the response, and delivered asynchronously.
+func (o *ObjectNode) notify(param *RequestParam, vol *Volume, event string, object *notificationObject) {
+	if o.notifier == nil {
+		return
+	}
+	config, err := vol.metaLoader.loadNotification()
+	if err != nil {
+		log.LogErrorf("notify: load notification fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(param.r), vol.Name(), err)
+		return
+	}
+	rules := config.matchRules(event, object.Key)
+	if len(rules) == 0 {
+		return
+	}
+	requester := param.Requester()
+	if requester == "" {
+		requester = param.AccessKey()
+	}
+	source := &notificationSource{
+		Region:    o.region,
+		Bucket:    vol.Name(),
+		Owner:     vol.owner,
+		Requester: requester,
+		SourceIP:  param.sourceIP,
+		RequestID: GetRequestID(param.r),
+	}
+	now := time.Now()
+	for _, rule := range rules {
+		data, err := newNotificationEvent(event, rule, source, object, now)
+		if err == nil {
+			err = o.notifier.send(rule.target(), data)
+		}
+		if err != nil {
+			log.LogErrorf("notify: send event fail: requestID(%v) volume(%v) event(%v) key(%v) target(%v) err(%v)",
+				GetRequestID(param.r), vol.Name(), event, object.Key, rule.target(), err)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNotificationConfig(t *testing.T) {
+	tests := []struct {
+		value string
+		valid bool
+	}{
+		{
+			value: `<NotificationConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+						<QueueConfiguration>
+							<Id>images</Id>
+							<Queue>arn:aws:sqs:::hook</Queue>
+							<Event>s3:ObjectCreated:*</Event>
+							<Event>s3:ObjectRemoved:Delete</Event>
+							<Filter><S3Key>
+								<FilterRule><Name>prefix</Name><Value>images/</Value></FilterRule>
+								<FilterRule><Name>suffix</Name><Value>.jpg</Value></FilterRule>
+							</S3Key></Filter>
+						</QueueConfiguration>
+						<TopicConfiguration>
+							<Topic>arn:aws:sns:cfs::events</Topic>
+							<Event>s3:ObjectTagging:*</Event>
+						</TopicConfiguration>
+					</NotificationConfiguration>`,
+			valid: true,
+		},
+		{
+			value: `<NotificationConfiguration></NotificationConfiguration>`,
+			valid: true,
+		},
+		{
+			value: `<NotificationConfiguration><QueueConfiguration>
+						<Queue>arn:aws:sqs:::hook</Queue><Event>s3:ObjectAccessed:*</Event>
+					</QueueConfiguration></NotificationConfiguration>`,
+		},
+		{
+			value: `<NotificationConfiguration><QueueConfiguration>
+						<Queue>arn:aws:sqs:::hook</Queue>
+					</QueueConfiguration></NotificationConfiguration>`,
+		},
+		{
+			value: `<NotificationConfiguration><QueueConfiguration>
+						<Queue>arn:aws:sns:::hook</Queue><Event>s3:ObjectCreated:Put</Event>
+					</QueueConfiguration></NotificationConfiguration>`,
+		},
+		{
+			value: `<NotificationConfiguration><QueueConfiguration>
+						<Queue>arn:aws:sqs:::hook</Queue><Event>s3:ObjectCreated:Put</Event>
+						<Filter><S3Key><FilterRule><Name>regex</Name><Value>.*</Value></FilterRule></S3Key></Filter>
+					</QueueConfiguration></NotificationConfiguration>`,
+		},
+		{
+			value: `<NotificationConfiguration><QueueConfiguration>
+						<Queue>arn:aws:sqs:::hook</Queue><Event>s3:ObjectCreated:Put</Event>
+						<Filter><S3Key>
+							<FilterRule><Name>prefix</Name><Value>a</Value></FilterRule>
+							<FilterRule><Name>Prefix</Name><Value>b</Value></FilterRule>
+						</S3Key></Filter>
+					</QueueConfiguration></NotificationConfiguration>`,
+		},
+		{
+			value: `<NotificationConfiguration>
+						<QueueConfiguration><Id>a</Id><Queue>arn:aws:sqs:::hook</Queue><Event>s3:ObjectCreated:Put</Event></QueueConfiguration>
+						<TopicConfiguration><Id>a</Id><Topic>arn:aws:sns:::hook</Topic><Event>s3:ObjectCreated:Put</Event></TopicConfiguration>
+					</NotificationConfiguration>`,
+		},
+		{
+			value: `<NotificationConfiguration><CloudFunctionConfiguration>
+						<CloudFunction>arn:aws:lambda:::hook</CloudFunction><Event>s3:ObjectCreated:Put</Event>
+					</CloudFunctionConfiguration></NotificationConfiguration>`,
+		},
+		{
+			value: `<NotificationConfiguration><QueueConfiguration>`,
+		},
+	}
+	for i, tc := range tests {
+		_, err := ParseNotificationConfigFromXML([]byte(tc.value))
+		if tc.valid {
+			require.NoError(t, err, "case %d", i)
+		} else {
+			require.Error(t, err, "case %d", i)
+		}
+	}
+}
+
+func TestNotificationMatchRules(t *testing.T) {
+	config, err := ParseNotificationConfigFromXML([]byte(`<NotificationConfiguration>
+		<QueueConfiguration>
+			<Id>images</Id>
+			<Queue>arn:aws:sqs:::hook</Queue>
+			<Event>s3:ObjectCreated:*</Event>
+			<Filter><S3Key>
+				<FilterRule><Name>prefix</Name><Value>images/</Value></FilterRule>
+				<FilterRule><Name>suffix</Name><Value>.jpg</Value></FilterRule>
+			</S3Key></Filter>
+		</QueueConfiguration>
+		<TopicConfiguration>
+			<Id>removed</Id>
+			<Topic>arn:aws:sns:::events</Topic>
+			<Event>s3:ObjectRemoved:DeleteMarkerCreated</Event>
+		</TopicConfiguration>
+	</NotificationConfiguration>`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		event string
+		key   string
+		ids   []string
+	}{
+		{EventObjectCreatedPut, "images/a.jpg", []string{"images"}},
+		{EventObjectCreatedCompleteMultipartUpload, "images/b/c.jpg", []string{"images"}},
+		{EventObjectCreatedPut, "images/a.png", nil},
+		{EventObjectCreatedPut, "docs/a.jpg", nil},
+		{EventObjectRemovedDelete, "images/a.jpg", nil},
+		{EventObjectRemovedDeleteMarkerCreated, "images/a.jpg", []string{"removed"}},
+	}
+	for _, tc := range tests {
+		var ids []string
+		for _, rule := range config.matchRules(tc.event, tc.key) {
+			ids = append(ids, rule.ID)
+		}
+		require.Equal(t, tc.ids, ids, "%v %v", tc.event, tc.key)
+	}
+	require.Equal(t, "hook", config.Queues[0].target())
+	require.Equal(t, "events", config.Topics[0].target())
+	require.Nil(t, (*NotificationConfiguration)(nil).matchRules(EventObjectCreatedPut, "a"))
+}
+
+func TestNewNotificationEvent(t *testing.T) {
+	rule := &NotificationRule{ID: "images"}
+	source := &notificationSource{Region: "cfs", Bucket: "bucket", Owner: "owner", Requester: "user", RequestID: "id"}
+	object := &notificationObject{Key: "images/a b.jpg", Size: 10, ETag: "etag", VersionID: "v1"}
+	data, err := newNotificationEvent(EventObjectCreatedPut, rule, source, object, time.Now())
+	require.NoError(t, err)
+
+	var event NotificationEvent
+	require.NoError(t, json.Unmarshal(data, &event))
+	require.Len(t, event.Records, 1)
+	record := event.Records[0]
+	require.Equal(t, "ObjectCreated:Put", record.EventName)
+	require.Equal(t, "cfs", record.AwsRegion)
+	require.Equal(t, "user", record.UserIdentity.PrincipalID)
+	require.Equal(t, "id", record.ResponseElements.RequestID)
+	require.Equal(t, "images", record.S3.ConfigurationID)
+	require.Equal(t, "bucket", record.S3.Bucket.Name)
+	require.Equal(t, "arn:aws:s3:::bucket", record.S3.Bucket.Arn)
+	require.Equal(t, "images%2Fa+b.jpg", record.S3.Object.Key)
+	require.Equal(t, int64(10), record.S3.Object.Size)
+	require.Equal(t, "v1", record.S3.Object.VersionID)
+}
+
+// notificationTestServer is the stand-in webhook target, it fails the requests while down is set.
+type notificationTestServer struct {
+	*httptest.Server
+	down     int32
+	lock     sync.Mutex
+	received []string
+}
+
+func newNotificationTestServer() *notificationTestServer {
+	s := &notificationTestServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&s.down) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		s.lock.Lock()
+		s.received = append(s.received, string(data))
+		s.lock.Unlock()
+	}))
+	return s
+}
+
+func (s *notificationTestServer) events() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]string{}, s.received...)
+}
+
+func TestNotifierDelivery(t *testing.T) {
+	server := newNotificationTestServer()
+	defer server.Close()
+	conf := NotificationConfig{
+		QueueDir:        t.TempDir(),
+		RetryIntervalMs: 50,
+		Webhook:         map[string]WebhookConfig{"hook": {Endpoint: server.URL}},
+	}
+
+	// the events are kept in the queue while the target is unavailable
+	atomic.StoreInt32(&server.down, 1)
+	notifier, err := NewNotifier(conf)
+	require.NoError(t, err)
+	require.True(t, notifier.hasTarget("hook"))
+	require.False(t, notifier.hasTarget("unknown"))
+	require.Error(t, notifier.send("unknown", []byte("event")))
+	for _, event := range []string{"event1", "event2", "event3"} {
+		require.NoError(t, notifier.send("hook", []byte(event)))
+	}
+	require.NoError(t, notifier.Close())
+	entries, err := os.ReadDir(filepath.Join(conf.QueueDir, "hook"))
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	require.Empty(t, server.events())
+
+	// the pending events are delivered in order after restart
+	atomic.StoreInt32(&server.down, 0)
+	notifier, err = NewNotifier(conf)
+	require.NoError(t, err)
+	defer notifier.Close()
+	require.NoError(t, notifier.send("hook", []byte("event4")))
+	require.Eventually(t, func() bool { return len(server.events()) == 4 }, 10*time.Second, 10*time.Millisecond)
+	require.Equal(t, []string{"event1", "event2", "event3", "event4"}, server.events())
+	require.Eventually(t, func() bool {
+		entries, err = os.ReadDir(filepath.Join(conf.QueueDir, "hook"))
+		return err == nil && len(entries) == 0
+	}, 10*time.Second, 10*time.Millisecond)
+}
+
+func TestNotificationQueueLimit(t *testing.T) {
+	server := newNotificationTestServer()
+	defer server.Close()
+	atomic.StoreInt32(&server.down, 1)
+	notifier, err := NewNotifier(NotificationConfig{
+		QueueDir:   t.TempDir(),
+		QueueLimit: 2,
+		Webhook:    map[string]WebhookConfig{"hook": {Endpoint: server.URL}},
+	})
+	require.NoError(t, err)
+	defer notifier.Close()
+	require.NoError(t, notifier.send("hook", []byte("event1")))
+	require.NoError(t, notifier.send("hook", []byte("event2")))
+	require.Equal(t, errNotificationQueueFull, notifier.send("hook", []byte("event3")))
+
+	_, err = NewNotifier(NotificationConfig{Webhook: map[string]WebhookConfig{"hook": {Endpoint: server.URL}}})
+	require.Error(t, err)
+}
@@ -0,0 +1,288 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	defaultNotificationQueueLimit    = 100000
+	defaultNotificationRetryInterval = 3000 // ms
+
+	notificationEventExt = ".event"
+	notificationTempExt  = ".tmp"
+)
+
+var errNotificationQueueFull = errors.New("notification: the queue is full")
+
+// NotificationConfig is the configuration of the targets which the bucket events are sent to.
+// The events are persisted in the queue directory of each target before being sent, and removed
+// after being delivered, so that they are delivered at least once even if the target is unavailable
+// or the objectnode restarts.
+type NotificationConfig struct {
+	QueueDir        string                   `json:"queue_dir"`
+	QueueLimit      int                      `json:"queue_limit"`
+	RetryIntervalMs int64                    `json:"retry_interval_ms"`
+	Webhook         map[string]WebhookConfig `json:"webhook"`
+	Kafka           map[string]KafkaConfig   `json:"kafka"`
+}
+
+// FixConfig validates and fixes the configuration.
+func (c *NotificationConfig) FixConfig() error {
+	if c.QueueDir == "" {
+		return errors.New("notification: no queue_dir found")
+	}
+	if c.QueueLimit <= 0 {
+		c.QueueLimit = defaultNotificationQueueLimit
+	}
+	if c.RetryIntervalMs <= 0 {
+		c.RetryIntervalMs = defaultNotificationRetryInterval
+	}
+	for name := range c.Kafka {
+		if _, ok := c.Webhook[name]; ok {
+			return fmt.Errorf("notification: duplicated target '%s'", name)
+		}
+	}
+	return nil
+}
+
+// Notifier sends the events of the buckets to the targets. The webhook and Kafka writers of the audit
+// are reused as the targets, each of which has its own persistent queue.
+type Notifier struct {
+	queues map[string]*notificationQueue
+	stopC  chan struct{}
+	once   sync.Once
+	wg     sync.WaitGroup
+}
+
+func NewNotifier(conf NotificationConfig) (*Notifier, error) {
+	if err := conf.FixConfig(); err != nil {
+		return nil, err
+	}
+	n := &Notifier{
+		queues: make(map[string]*notificationQueue, len(conf.Webhook)+len(conf.Kafka)),
+		stopC:  make(chan struct{}),
+	}
+	if err := n.addTargets(conf); err != nil {
+		n.closeTargets()
+		return nil, err
+	}
+	for _, q := range n.queues {
+		n.wg.Add(1)
+		go func(q *notificationQueue) {
+			defer n.wg.Done()
+			q.run(n.stopC)
+		}(q)
+	}
+	return n, nil
+}
+
+func (n *Notifier) addTargets(conf NotificationConfig) error {
+	for name, cfg := range conf.Webhook {
+		target, err := newWebhookAudit("webhook-notification-"+name, WebhookAuditConfig{Enable: true, WebhookConfig: cfg})
+		if err != nil {
+			return fmt.Errorf("notification: target '%s': %v", name, err)
+		}
+		if err = n.addQueue(name, target, conf); err != nil {
+			return err
+		}
+	}
+	for name, cfg := range conf.Kafka {
+		target, err := newKafkaAudit("kafka-notification-"+name, KafkaAuditConfig{Enable: true, KafkaConfig: cfg})
+		if err != nil {
+			return fmt.Errorf("notification: target '%s': %v", name, err)
+		}
+		if err = n.addQueue(name, target, conf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *Notifier) addQueue(name string, target AuditLogger, conf NotificationConfig) error {
+	q, err := newNotificationQueue(filepath.Join(conf.QueueDir, name), target, conf.QueueLimit,
+		time.Duration(conf.RetryIntervalMs)*time.Millisecond)
+	if err != nil {
+		target.Close()
+		return fmt.Errorf("notification: target '%s': %v", name, err)
+	}
+	n.queues[name] = q
+	return nil
+}
+
+// hasTarget checks whether the target referenced by the notification configuration is configured.
+func (n *Notifier) hasTarget(name string) bool {
+	_, ok := n.queues[name]
+	return ok
+}
+
+// send persists the event to the queue of the target, the event is delivered asynchronously.
+func (n *Notifier) send(name string, data []byte) error {
+	q, ok := n.queues[name]
+	if !ok {
+		return fmt.Errorf("notification: unknown target '%s'", name)
+	}
+	return q.put(data)
+}
+
+func (n *Notifier) Close() error {
+	n.once.Do(func() {
+		close(n.stopC)
+		n.wg.Wait()
+		n.closeTargets()
+	})
+	return nil
+}
+
+func (n *Notifier) closeTargets() {
+	for _, q := range n.queues {
+		_ = q.target.Close()
+	}
+}
+
+// notificationQueue is the persistent queue of the events to be sent to the target, each event is stored
+// in a file of the directory, and the events are sent in the order of the file names.
+type notificationQueue struct {
+	dir           string
+	target        AuditLogger
+	limit         int64
+	size          int64
+	seq           uint64
+	retryInterval time.Duration
+	notifyC       chan struct{}
+}
+
+func newNotificationQueue(dir string, target AuditLogger, limit int, retryInterval time.Duration) (*notificationQueue, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	q := &notificationQueue{
+		dir:           dir,
+		target:        target,
+		limit:         int64(limit),
+		seq:           uint64(time.Now().UnixNano()),
+		retryInterval: retryInterval,
+		notifyC:       make(chan struct{}, 1),
+	}
+	names, err := q.list()
+	if err != nil {
+		return nil, err
+	}
+	q.size = int64(len(names))
+	return q, nil
+}
+
+// list returns the names of the pending events in order.
+func (q *notificationQueue) list() ([]string, error) {
+	entries, err := os.ReadDir(q.dir)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), notificationEventExt) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// put writes the event to a temporary file and renames it, so that a partially written event is never sent.
+func (q *notificationQueue) put(data []byte) (err error) {
+	if atomic.AddInt64(&q.size, 1) > q.limit {
+		atomic.AddInt64(&q.size, -1)
+		return errNotificationQueueFull
+	}
+	defer func() {
+		if err != nil {
+			atomic.AddInt64(&q.size, -1)
+		}
+	}()
+	name := fmt.Sprintf("%020d", atomic.AddUint64(&q.seq, 1))
+	tmp := filepath.Join(q.dir, name+notificationTempExt)
+	if err = os.WriteFile(tmp, data, 0o644); err != nil {
+		os.Remove(tmp)
+		return
+	}
+	if err = os.Rename(tmp, filepath.Join(q.dir, name+notificationEventExt)); err != nil {
+		os.Remove(tmp)
+		return
+	}
+	select {
+	case q.notifyC <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (q *notificationQueue) run(stopC <-chan struct{}) {
+	ticker := time.NewTicker(q.retryInterval)
+	defer ticker.Stop()
+	for {
+		q.flush(stopC)
+		select {
+		case <-stopC:
+			return
+		case <-q.notifyC:
+		case <-ticker.C:
+		}
+	}
+}
+
+// flush sends the pending events in order, it stops at the first failure and the rest are retried later.
+func (q *notificationQueue) flush(stopC <-chan struct{}) {
+	names, err := q.list()
+	if err != nil {
+		log.LogErrorf("notificationQueue: list events fail: target(%v) err(%v)", q.target.Name(), err)
+		return
+	}
+	for _, name := range names {
+		select {
+		case <-stopC:
+			return
+		default:
+		}
+		path := filepath.Join(q.dir, name)
+		data, err := os.ReadFile(path)
+		if err == nil {
+			if err = q.target.Send(data); err != nil {
+				log.LogWarnf("notificationQueue: send event fail, retry later: target(%v) event(%v) err(%v)",
+					q.target.Name(), name, err)
+				return
+			}
+		} else {
+			log.LogErrorf("notificationQueue: read event fail, drop it: target(%v) event(%v) err(%v)",
+				q.target.Name(), name, err)
+		}
+		if err = os.Remove(path); err != nil {
+			log.LogErrorf("notificationQueue: remove event fail: target(%v) event(%v) err(%v)",
+				q.target.Name(), name, err)
+			return
+		}
+		atomic.AddInt64(&q.size, -1)
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/xml"
+	"strings"
+
+	"github.com/cubefs/cubefs/proto"
+)
+
+const (
+	ObjectAttributeETag         = "ETag"
+	ObjectAttributeChecksum     = "Checksum"
+	ObjectAttributeObjectParts  = "ObjectParts"
+	ObjectAttributeStorageClass = "StorageClass"
+	ObjectAttributeObjectSize   = "ObjectSize"
+)
+
+var validObjectAttributes = []string{
+	ObjectAttributeETag,
+	ObjectAttributeChecksum,
+	ObjectAttributeObjectParts,
+	ObjectAttributeStorageClass,
+	ObjectAttributeObjectSize,
+}
+
+type GetObjectAttributesResult struct {
+	XMLName      xml.Name                  `xml:"GetObjectAttributesOutput"`
+	XMLNS        string                    `xml:"xmlns,attr,omitempty"`
+	ETag         string                    `xml:"ETag,omitempty"`
+	Checksum     *ObjectAttributesChecksum `xml:"Checksum,omitempty"`
+	ObjectParts  *ObjectAttributesParts    `xml:"ObjectParts,omitempty"`
+	StorageClass string                    `xml:"StorageClass,omitempty"`
+	ObjectSize   *int64                    `xml:"ObjectSize,omitempty"`
+}
+
+type ObjectAttributesChecksum struct {
+	ChecksumCRC32  string `xml:"ChecksumCRC32,omitempty"`
+	ChecksumCRC32C string `xml:"ChecksumCRC32C,omitempty"`
+	ChecksumSHA1   string `xml:"ChecksumSHA1,omitempty"`
+	ChecksumSHA256 string `xml:"ChecksumSHA256,omitempty"`
+}
+
+// ObjectAttributesParts only returns the number of parts, the size and checksum of
+// each part are not kept after the multipart upload is completed.
+type ObjectAttributesParts struct {
+	PartsCount int `xml:"PartsCount"`
+}
+
+// parseObjectAttributes parses the attributes requested by the x-amz-object-attributes header,
+// which is a comma separated list and may be specified multiple times.
+func parseObjectAttributes(values []string) (map[string]bool, error) {
+	attrs := make(map[string]bool)
+	for _, value := range values {
+		for _, attr := range strings.Split(value, ",") {
+			attr = strings.TrimSpace(attr)
+			if !StringListContain(validObjectAttributes, attr) {
+				return nil, InvalidObjectAttributes
+			}
+			attrs[attr] = true
+		}
+	}
+	if len(attrs) == 0 {
+		return nil, InvalidObjectAttributes
+	}
+	return attrs, nil
+}
+
+// objectStorageClass returns the storage class of the object in the S3 response.
+func objectStorageClass(info *FSFileInfo) string {
+	if proto.IsStorageClassBlobStore(info.StorageClass) {
+		return proto.OpTypeStorageClassEBS
+	}
+	return StorageClassStandard
+}
+
+func newObjectAttributesResult(attrs map[string]bool, info *FSFileInfo, xattr *proto.XAttrInfo) *GetObjectAttributesResult {
+	result := &GetObjectAttributesResult{XMLNS: XMLNS}
+	if attrs[ObjectAttributeETag] {
+		result.ETag = info.ETag
+	}
+	if attrs[ObjectAttributeChecksum] && xattr != nil {
+		// the checksum of the multipart object is returned without the number of parts
+		if checksum, _ := parseObjectChecksum(xattr.Get(XAttrKeyOSSChecksum)); checksum != nil && checksum.Value != "" {
+			result.Checksum = &ObjectAttributesChecksum{}
+			switch checksum.Algorithm {
+			case ChecksumAlgorithmCRC32:
+				result.Checksum.ChecksumCRC32 = checksum.Value
+			case ChecksumAlgorithmCRC32C:
+				result.Checksum.ChecksumCRC32C = checksum.Value
+			case ChecksumAlgorithmSHA1:
+				result.Checksum.ChecksumSHA1 = checksum.Value
+			case ChecksumAlgorithmSHA256:
+				result.Checksum.ChecksumSHA256 = checksum.Value
+			}
+		}
+	}
+	if attrs[ObjectAttributeObjectParts] && xattr != nil {
+		rawETag := string(xattr.Get(XAttrKeyOSSETag))
+		if len(rawETag) == 0 {
+			rawETag = string(xattr.Get(XAttrKeyOSSETagDeprecated))
+		}
+		// only the object uploaded by multipart upload has parts
+		if etagValue := ParseETagValue(rawETag); etagValue.PartNum > 0 {
+			result.ObjectParts = &ObjectAttributesParts{PartsCount: etagValue.PartNum}
+		}
+	}
+	if attrs[ObjectAttributeStorageClass] {
+		result.StorageClass = objectStorageClass(info)
+	}
+	if attrs[ObjectAttributeObjectSize] {
+		size := info.Size
+		result.ObjectSize = &size
+	}
+	return result
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"net/http"
+	"syscall"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// Get object attributes
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObjectAttributes.html
+func (o *ObjectNode) getObjectAttributesHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	if param.Object() == "" {
+		errorCode = InvalidKey
+		return
+	}
+
+	var attrs map[string]bool
+	if attrs, err = parseObjectAttributes(r.Header.Values(XAmzObjectAttributes)); err != nil {
+		log.LogErrorf("getObjectAttributesHandler: parse attributes fail: requestID(%v) attributes(%v) err(%v)",
+			GetRequestID(r), r.Header.Values(XAmzObjectAttributes), err)
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("getObjectAttributesHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	versionId := r.URL.Query().Get(ParamVersionId)
+	var (
+		info  *FSFileInfo
+		xattr *proto.XAttrInfo
+	)
+	if info, xattr, err = vol.ObjectVersionMeta(param.Object(), versionId); err != nil {
+		log.LogErrorf("getObjectAttributesHandler: get object meta fail: requestID(%v) volume(%v) path(%v) versionId(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), versionId, err)
+		setDeleteMarkerHeader(w, vol, param.Object(), versionId, err)
+		if err == syscall.ENOENT {
+			errorCode = NoSuchKey
+		}
+		return
+	}
+	if info.Mode.IsDir() {
+		errorCode = NoSuchKey
+		return
+	}
+
+	// the customer provided key is required for the encrypted object
+	if _, _, err = checkObjectSSE(w, r, xattr); err != nil {
+		log.LogErrorf("getObjectAttributesHandler: check sse fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+
+	result := newObjectAttributesResult(attrs, info, xattr)
+	var data []byte
+	if data, err = MarshalXMLEntity(result); err != nil {
+		log.LogErrorf("getObjectAttributesHandler: xml marshal fail: requestID(%v) volume(%v) path(%v) result(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), result, err)
+		return
+	}
+
+	w.Header().Set(LastModified, formatTimeRFC1123(info.ModifyTime))
+	if len(info.VersionId) > 0 {
+		w.Header().Set(XAmzVersionId, info.VersionId)
+	}
+	writeSuccessResponseXML(w, data)
+}
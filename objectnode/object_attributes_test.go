@@ -0,0 +1,75 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseObjectAttributes(t *testing.T) {
+	attrs, err := parseObjectAttributes([]string{"ETag, ObjectSize", "StorageClass"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{
+		ObjectAttributeETag:         true,
+		ObjectAttributeObjectSize:   true,
+		ObjectAttributeStorageClass: true,
+	}, attrs)
+
+	_, err = parseObjectAttributes(nil)
+	require.Equal(t, InvalidObjectAttributes, err)
+	_, err = parseObjectAttributes([]string{"ETag,Size"})
+	require.Equal(t, InvalidObjectAttributes, err)
+	_, err = parseObjectAttributes([]string{"etag"})
+	require.Equal(t, InvalidObjectAttributes, err)
+}
+
+func TestObjectAttributesResult(t *testing.T) {
+	etagValue := ETagValue{Value: "d41d8cd98f00b204e9800998ecf8427e", PartNum: 3, TS: time.Unix(1700000000, 0)}
+	checksum := &ObjectChecksum{Algorithm: ChecksumAlgorithmSHA256, Value: "checksum", PartNum: 3}
+	xattr := &proto.XAttrInfo{XAttrs: map[string]string{
+		XAttrKeyOSSETag:     etagValue.Encode(),
+		XAttrKeyOSSChecksum: checksum.Encode(),
+	}}
+	info := &FSFileInfo{ETag: etagValue.ETag(), Size: 1024, StorageClass: proto.StorageClass_BlobStore}
+
+	attrs, err := parseObjectAttributes([]string{"ETag,Checksum,ObjectParts,StorageClass,ObjectSize"})
+	require.NoError(t, err)
+	result := newObjectAttributesResult(attrs, info, xattr)
+	require.Equal(t, "d41d8cd98f00b204e9800998ecf8427e-3", result.ETag)
+	require.Equal(t, "checksum", result.Checksum.ChecksumSHA256)
+	require.Equal(t, 3, result.ObjectParts.PartsCount)
+	require.Equal(t, proto.OpTypeStorageClassEBS, result.StorageClass)
+	require.Equal(t, int64(1024), *result.ObjectSize)
+
+	attrs, err = parseObjectAttributes([]string{"ObjectSize"})
+	require.NoError(t, err)
+	data, err := xml.Marshal(newObjectAttributesResult(attrs, info, xattr))
+	require.NoError(t, err)
+	require.Equal(t, `<GetObjectAttributesOutput xmlns="`+XMLNS+`"><ObjectSize>1024</ObjectSize></GetObjectAttributesOutput>`, string(data))
+
+	info = &FSFileInfo{Size: 0, StorageClass: proto.StorageClass_Replica_HDD}
+	attrs, err = parseObjectAttributes([]string{"ObjectParts,StorageClass,ObjectSize,Checksum"})
+	require.NoError(t, err)
+	result = newObjectAttributesResult(attrs, info, &proto.XAttrInfo{})
+	require.Nil(t, result.ObjectParts)
+	require.Nil(t, result.Checksum)
+	require.Equal(t, StorageClassStandard, result.StorageClass)
+	require.Equal(t, int64(0), *result.ObjectSize)
+}
@@ -17,9 +17,12 @@ package objectnode
 import (
 	"encoding/xml"
 	"errors"
+	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/util/log"
 )
 
@@ -32,12 +35,18 @@ var (
 	InvalidObjectLockEnabledErr    = errors.New("Only Enabled value is allowd to ObjectLockEnabled element")
 	NilDefaultRetentionErr         = errors.New("Default retention cannot be nil")
 	NilDefaultRuleErr              = errors.New("Default rule cannot be nil")
+	BothModeAndDateRequiredErr     = errors.New("Both Mode and RetainUntilDate must be specified, or neither of them")
+	PastRetainUntilDateErr         = errors.New("The retain until date must be in the future")
 )
 
 const (
 	ComplianceMode = "COMPLIANCE"
+	GovernanceMode = "GOVERNANCE"
 	Enabled        = "Enabled"
 
+	LegalHoldOn  = "ON"
+	LegalHoldOff = "OFF"
+
 	MaxObjectLockSize     = 1 << 12 // 16KB
 	maximumRetentionDays  = 70 * 365
 	maximumRetentionYears = 70
@@ -125,7 +134,7 @@ func ParseObjectLockConfigFromXML(data []byte) (*ObjectLockConfig, error) {
 // check valid of DefaultRetention
 func (d DefaultRetention) isValid() error {
 	switch d.Mode {
-	case ComplianceMode:
+	case ComplianceMode, GovernanceMode:
 	default:
 		return InvalidModeErr
 	}
@@ -179,26 +188,332 @@ func storeObjectLock(bytes []byte, vol *Volume) (err error) {
 	return vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSLock, bytes)
 }
 
-func isObjectLocked(v *Volume, inode uint64, name, path string) error {
-	xattrInfo, err := v.mw.XAttrGet_ll(inode, XAttrKeyOSSLock)
+// Enabled reports whether object lock is enabled for the bucket.
+func (c *ObjectLockConfig) Enabled() bool {
+	return c != nil && c.ObjectLockEnabled == Enabled
+}
+
+// ObjectLockOption is the object lock specified by the x-amz-object-lock-* headers of the write request,
+// the retention specified explicitly overrides the default retention of the bucket.
+type ObjectLockOption struct {
+	Mode            string
+	RetainUntilDate time.Time
+	LegalHold       string
+}
+
+// ParseObjectLockOption parses the object lock headers, nil is returned if none of them is specified.
+func ParseObjectLockOption(header http.Header, config *ObjectLockConfig) (*ObjectLockOption, error) {
+	mode := header.Get(XAmzObjectLockMode)
+	date := header.Get(XAmzObjectLockRetainUntilDate)
+	legalHold := header.Get(XAmzObjectLockLegalHold)
+	if mode == "" && date == "" && legalHold == "" {
+		return nil, nil
+	}
+	if !config.Enabled() {
+		return nil, ObjectLockNotEnabled
+	}
+	opt := &ObjectLockOption{}
+	if mode != "" || date != "" {
+		retention := &ObjectRetention{Mode: mode}
+		if date != "" {
+			t, err := time.Parse(time.RFC3339, date)
+			if err != nil {
+				return nil, InvalidArgument
+			}
+			retention.RetainUntilDate = RetentionDate{Time: t}
+		}
+		if err := retention.checkValid(); err != nil {
+			return nil, err
+		}
+		opt.Mode = retention.Mode
+		opt.RetainUntilDate = retention.RetainUntilDate.Time
+	}
+	if legalHold != "" {
+		if legalHold != LegalHoldOn && legalHold != LegalHoldOff {
+			return nil, InvalidLegalHoldStatus
+		}
+		opt.LegalHold = legalHold
+	}
+	return opt, nil
+}
+
+// setObjectLockXAttrs sets the object lock xattrs of the object written at modifyTime.
+func setObjectLockXAttrs(xattrs map[string]string, modifyTime time.Time, config *ObjectLockConfig, opt *ObjectLockOption) {
+	if opt != nil && opt.Mode != "" {
+		xattrs[XAttrKeyOSSLock] = strconv.FormatInt(opt.RetainUntilDate.UnixNano(), 10)
+		xattrs[XAttrKeyOSSLockMode] = opt.Mode
+	} else if config != nil && config.ToRetention() != nil {
+		retention := config.ToRetention()
+		xattrs[XAttrKeyOSSLock] = formatRetentionDateStr(modifyTime, retention)
+		xattrs[XAttrKeyOSSLockMode] = retention.Mode
+	}
+	if opt != nil && opt.LegalHold == LegalHoldOn {
+		xattrs[XAttrKeyOSSLegalHold] = LegalHoldOn
+	}
+}
+
+// isObjectLockXAttr reports whether the xattr belongs to the object lock, which is not inherited by the copies.
+func isObjectLockXAttr(key string) bool {
+	return key == XAttrKeyOSSLock || key == XAttrKeyOSSLockMode || key == XAttrKeyOSSLegalHold
+}
+
+// check valid of the retention specified by PutObjectRetention or the object lock headers,
+// an empty retention removes the retention of the object.
+func (r *ObjectRetention) checkValid() error {
+	if r.Mode == "" && r.RetainUntilDate.IsZero() {
+		return nil
+	}
+	if r.Mode == "" || r.RetainUntilDate.IsZero() {
+		return NewError("InvalidArgument", BothModeAndDateRequiredErr.Error(), http.StatusBadRequest)
+	}
+	if r.Mode != ComplianceMode && r.Mode != GovernanceMode {
+		return NewError("InvalidArgument", InvalidModeErr.Error(), http.StatusBadRequest)
+	}
+	if !r.RetainUntilDate.After(time.Now()) {
+		return NewError("InvalidArgument", PastRetainUntilDateErr.Error(), http.StatusBadRequest)
+	}
+	return nil
+}
+
+// parse ObjectRetention from xml
+func ParseObjectRetentionFromXML(data []byte) (*ObjectRetention, error) {
+	retention := &ObjectRetention{}
+	if err := xml.Unmarshal(data, retention); err != nil {
+		return nil, MalformedXML
+	}
+	if err := retention.checkValid(); err != nil {
+		return nil, err
+	}
+	return retention, nil
+}
+
+type ObjectLegalHold struct {
+	XMLNS   string   `xml:"xmlns,attr,omitempty"`
+	XMLName xml.Name `xml:"LegalHold"`
+	Status  string   `xml:"Status"`
+}
+
+// parse ObjectLegalHold from xml
+func ParseObjectLegalHoldFromXML(data []byte) (*ObjectLegalHold, error) {
+	legalHold := &ObjectLegalHold{}
+	if err := xml.Unmarshal(data, legalHold); err != nil {
+		return nil, MalformedXML
+	}
+	if legalHold.Status != LegalHoldOn && legalHold.Status != LegalHoldOff {
+		return nil, InvalidLegalHoldStatus
+	}
+	return legalHold, nil
+}
+
+// objectLockInfo is the object lock of an object loaded from the xattrs of the object inode.
+type objectLockInfo struct {
+	Mode            string
+	RetainUntilDate int64 // unix nanoseconds, zero if the object has no retention
+	LegalHold       bool
+}
+
+func parseObjectLockInfo(xattr *proto.XAttrInfo) (info *objectLockInfo, err error) {
+	info = &objectLockInfo{}
+	if xattr == nil {
+		return
+	}
+	if raw := xattr.Get(XAttrKeyOSSLock); len(raw) > 0 {
+		if info.RetainUntilDate, err = strconv.ParseInt(string(raw), 10, 64); err != nil {
+			return nil, err
+		}
+		// objects locked before GOVERNANCE mode is supported have no mode xattr
+		info.Mode = ComplianceMode
+		if mode := xattr.Get(XAttrKeyOSSLockMode); len(mode) > 0 {
+			info.Mode = string(mode)
+		}
+	}
+	info.LegalHold = string(xattr.Get(XAttrKeyOSSLegalHold)) == LegalHoldOn
+	return
+}
+
+func (i *objectLockInfo) retained() bool {
+	return i.RetainUntilDate > time.Now().UnixNano()
+}
+
+// check returns AccessDenied if the object is protected from being deleted or overwritten,
+// the retention in GOVERNANCE mode can be bypassed.
+func (i *objectLockInfo) check(bypassGovernance bool) error {
+	if i.LegalHold {
+		return AccessDenied
+	}
+	if i.retained() && !(i.Mode == GovernanceMode && bypassGovernance) {
+		return AccessDenied
+	}
+	return nil
+}
+
+// checkRetentionUpdate checks whether the retention of the object can be replaced with the given one.
+// The retention in COMPLIANCE mode can only be extended, and the retention in GOVERNANCE mode can be
+// shortened or removed only if it is bypassed.
+func (i *objectLockInfo) checkRetentionUpdate(retention *ObjectRetention, bypassGovernance bool) error {
+	if !i.retained() || i.Mode == GovernanceMode && bypassGovernance {
+		return nil
+	}
+	if retention.Mode == i.Mode && retention.RetainUntilDate.UnixNano() >= i.RetainUntilDate {
+		return nil
+	}
+	if i.Mode == GovernanceMode && retention.Mode == ComplianceMode && retention.RetainUntilDate.UnixNano() >= i.RetainUntilDate {
+		return nil
+	}
+	return AccessDenied
+}
+
+// isBypassGovernance reports whether the request asks to bypass the GOVERNANCE mode retention.
+func isBypassGovernance(header http.Header) bool {
+	return strings.EqualFold(header.Get(XAmzBypassGovernanceRetention), "true")
+}
+
+func isObjectLocked(v *Volume, inode uint64, name, path string, bypassGovernance bool) error {
+	xattrInfo, err := v.mw.XAttrGetAll_ll(inode)
 	if err != nil {
 		log.LogErrorf("isObjectLocked: check ObjectLock err(%v) volume(%v) path(%v) name(%v)",
 			err, v.name, path, name)
 		return err
 	}
-	retainUntilDate := xattrInfo.Get(XAttrKeyOSSLock)
-	if len(retainUntilDate) > 0 {
-		retainUntilDateInt64, err := strconv.ParseInt(string(retainUntilDate), 10, 64)
-		if err != nil {
+	info, err := parseObjectLockInfo(xattrInfo)
+	if err != nil {
+		return err
+	}
+	if err = info.check(bypassGovernance); err != nil {
+		log.LogWarnf("isObjectLocked: object is locked, mode(%v) retainUntilDate(%v) legalHold(%v) volume(%v) path(%v) name(%v)",
+			info.Mode, info.RetainUntilDate, info.LegalHold, v.name, path, name)
+		return err
+	}
+	return nil
+}
+
+// removeGovernanceRetention removes the retention of the object inode if it is in GOVERNANCE mode,
+// AccessDenied is returned if the object is still protected by the legal hold or COMPLIANCE mode.
+// The removed retention is returned to be restored if the object fails to be deleted.
+func (v *Volume) removeGovernanceRetention(inode uint64, name, path string) (*ObjectRetention, error) {
+	xattrInfo, err := v.mw.XAttrGetAll_ll(inode)
+	if err != nil {
+		log.LogErrorf("removeGovernanceRetention: get xattr fail: volume(%v) path(%v) name(%v) err(%v)",
+			v.name, path, name, err)
+		return nil, err
+	}
+	info, err := parseObjectLockInfo(xattrInfo)
+	if err != nil {
+		return nil, err
+	}
+	if err = info.check(true); err != nil {
+		return nil, err
+	}
+	if !info.retained() {
+		return nil, nil
+	}
+	log.LogInfof("removeGovernanceRetention: bypass governance retention: volume(%v) path(%v) retainUntilDate(%v)",
+		v.name, path, info.RetainUntilDate)
+	if err = v.setObjectRetention(inode, &ObjectRetention{}); err != nil {
+		return nil, err
+	}
+	return &ObjectRetention{
+		Mode:            info.Mode,
+		RetainUntilDate: RetentionDate{Time: time.Unix(0, info.RetainUntilDate)},
+	}, nil
+}
+
+// checkReplaceLocked checks the object lock before the current object, zero if it does not exist, is replaced by
+// a new one. The replaced object is kept as a noncurrent version unless the bucket is unversioned, or it's a null
+// version in a versioning-suspended bucket, where the new object also replaces the noncurrent null version.
+// Only the objects to be released are checked.
+func (v *Volume) checkReplaceLocked(inode uint64, name, path string) error {
+	status, err := v.versioningStatus()
+	if err != nil || status == Enabled {
+		return err
+	}
+	if status == VersioningSuspended {
+		return v.checkNullVersionsLocked(inode, name, path, false)
+	}
+	if inode == 0 {
+		return nil
+	}
+	return isObjectLocked(v, inode, name, path, false)
+}
+
+// checkNullVersionsLocked checks whether the null versions of the object are protected by object lock, which are
+// released when a new null version is created in a versioning-suspended bucket. The current object, zero if it
+// does not exist, is checked only if it's a null version.
+func (v *Volume) checkNullVersionsLocked(inode uint64, name, path string, bypassGovernance bool) error {
+	objectLock, err := v.metaLoader.loadObjectLock()
+	if err != nil || objectLock == nil {
+		return err
+	}
+	ver, err := v.getObjectVersion(path, NullVersionId)
+	if err != nil {
+		return err
+	}
+	if ver != nil && !ver.DeleteMarker {
+		if err = isObjectLocked(v, ver.Inode, name, path, bypassGovernance); err != nil {
 			return err
 		}
-		if retainUntilDateInt64 > time.Now().UnixNano() {
-			log.LogWarnf("isObjectLocked: object is locked, retainUntilDate(%v) volume(%v) path(%v) name(%v)",
-				retainUntilDateInt64, v.name, path, name)
-			return AccessDenied
+	}
+	if inode == 0 {
+		return nil
+	}
+	versionId, err := v.inodeVersionId(inode)
+	if err != nil || versionId != NullVersionId {
+		return err
+	}
+	return isObjectLocked(v, inode, name, path, bypassGovernance)
+}
+
+// setObjectRetention replaces the retention of the object inode, the retention is removed if it is empty.
+func (v *Volume) setObjectRetention(inode uint64, retention *ObjectRetention) (err error) {
+	if retention.Mode == "" {
+		for _, key := range []string{XAttrKeyOSSLock, XAttrKeyOSSLockMode} {
+			if err = v.mw.XAttrDel_ll(inode, key); err != nil {
+				log.LogErrorf("setObjectRetention: delete xattr fail: volume(%v) inode(%v) key(%v) err(%v)",
+					v.name, inode, key, err)
+				return
+			}
+			if objMetaCache != nil {
+				objMetaCache.DeleteAttrWithKey(v.name, inode, key)
+			}
+		}
+		return
+	}
+	attrs := map[string]string{
+		XAttrKeyOSSLock:     strconv.FormatInt(retention.RetainUntilDate.UnixNano(), 10),
+		XAttrKeyOSSLockMode: retention.Mode,
+	}
+	if err = v.mw.BatchSetXAttr_ll(inode, attrs); err != nil {
+		log.LogErrorf("setObjectRetention: set xattr fail: volume(%v) inode(%v) retention(%v) err(%v)",
+			v.name, inode, attrs, err)
+		return
+	}
+	if objMetaCache != nil {
+		objMetaCache.MergeAttr(v.name, &AttrItem{XAttrInfo: proto.XAttrInfo{Inode: inode, XAttrs: attrs}})
+	}
+	return
+}
+
+// setObjectLegalHold turns the legal hold of the object inode on or off.
+func (v *Volume) setObjectLegalHold(inode uint64, status string) (err error) {
+	if status == LegalHoldOff {
+		if err = v.mw.XAttrDel_ll(inode, XAttrKeyOSSLegalHold); err != nil {
+			log.LogErrorf("setObjectLegalHold: delete xattr fail: volume(%v) inode(%v) err(%v)", v.name, inode, err)
+			return
+		}
+		if objMetaCache != nil {
+			objMetaCache.DeleteAttrWithKey(v.name, inode, XAttrKeyOSSLegalHold)
 		}
+		return
 	}
-	return nil
+	if err = v.mw.XAttrSet_ll(inode, []byte(XAttrKeyOSSLegalHold), []byte(LegalHoldOn)); err != nil {
+		log.LogErrorf("setObjectLegalHold: set xattr fail: volume(%v) inode(%v) err(%v)", v.name, inode, err)
+		return
+	}
+	if objMetaCache != nil {
+		attrs := map[string]string{XAttrKeyOSSLegalHold: LegalHoldOn}
+		objMetaCache.MergeAttr(v.name, &AttrItem{XAttrInfo: proto.XAttrInfo{Inode: inode, XAttrs: attrs}})
+	}
+	return
 }
 
 func formatRetentionDateStr(modifyTime time.Time, retention *Retention) string {
@@ -16,10 +16,12 @@ package objectnode
 
 import (
 	"encoding/xml"
+	"net/http"
 	"strconv"
 	"testing"
 	"time"
 
+	"github.com/cubefs/cubefs/proto"
 	"github.com/stretchr/testify/require"
 )
 
@@ -217,3 +219,164 @@ func TestMarshalXML(t *testing.T) {
 	_, err := xml.Marshal(objectRetention)
 	require.NoError(t, err)
 }
+
+func TestParseObjectLockOption(t *testing.T) {
+	days := int64(1)
+	config := &ObjectLockConfig{
+		ObjectLockEnabled: Enabled,
+		Rule:              &ObjectLockRule{DefaultRetention: &DefaultRetention{Mode: GovernanceMode, Days: &days}},
+	}
+	future := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	header := make(http.Header)
+	opt, err := ParseObjectLockOption(header, nil)
+	require.NoError(t, err)
+	require.Nil(t, opt)
+
+	header.Set(XAmzObjectLockLegalHold, LegalHoldOn)
+	_, err = ParseObjectLockOption(header, nil)
+	require.Equal(t, ObjectLockNotEnabled, err)
+	opt, err = ParseObjectLockOption(header, config)
+	require.NoError(t, err)
+	require.Equal(t, LegalHoldOn, opt.LegalHold)
+	require.Equal(t, "", opt.Mode)
+
+	header.Set(XAmzObjectLockMode, GovernanceMode)
+	_, err = ParseObjectLockOption(header, config)
+	require.Error(t, err)
+	header.Set(XAmzObjectLockRetainUntilDate, future.Format(time.RFC3339))
+	opt, err = ParseObjectLockOption(header, config)
+	require.NoError(t, err)
+	require.Equal(t, GovernanceMode, opt.Mode)
+	require.True(t, future.Equal(opt.RetainUntilDate))
+
+	header.Set(XAmzObjectLockMode, "governance")
+	_, err = ParseObjectLockOption(header, config)
+	require.Error(t, err)
+	header.Set(XAmzObjectLockMode, ComplianceMode)
+	header.Set(XAmzObjectLockRetainUntilDate, time.Now().Add(-time.Hour).Format(time.RFC3339))
+	_, err = ParseObjectLockOption(header, config)
+	require.Error(t, err)
+	header.Set(XAmzObjectLockRetainUntilDate, "tomorrow")
+	_, err = ParseObjectLockOption(header, config)
+	require.Equal(t, InvalidArgument, err)
+
+	header = make(http.Header)
+	header.Set(XAmzObjectLockLegalHold, "on")
+	_, err = ParseObjectLockOption(header, config)
+	require.Equal(t, InvalidLegalHoldStatus, err)
+}
+
+func TestSetObjectLockXAttrs(t *testing.T) {
+	days := int64(1)
+	config := &ObjectLockConfig{
+		ObjectLockEnabled: Enabled,
+		Rule:              &ObjectLockRule{DefaultRetention: &DefaultRetention{Mode: GovernanceMode, Days: &days}},
+	}
+	now := time.Date(2023, 5, 26, 0, 0, 0, 0, time.UTC)
+
+	xattrs := make(map[string]string)
+	setObjectLockXAttrs(xattrs, now, config, nil)
+	require.Equal(t, strconv.FormatInt(now.Add(24*time.Hour).UnixNano(), 10), xattrs[XAttrKeyOSSLock])
+	require.Equal(t, GovernanceMode, xattrs[XAttrKeyOSSLockMode])
+	require.NotContains(t, xattrs, XAttrKeyOSSLegalHold)
+
+	until := now.Add(time.Hour)
+	xattrs = make(map[string]string)
+	setObjectLockXAttrs(xattrs, now, config, &ObjectLockOption{Mode: ComplianceMode, RetainUntilDate: until, LegalHold: LegalHoldOn})
+	require.Equal(t, strconv.FormatInt(until.UnixNano(), 10), xattrs[XAttrKeyOSSLock])
+	require.Equal(t, ComplianceMode, xattrs[XAttrKeyOSSLockMode])
+	require.Equal(t, LegalHoldOn, xattrs[XAttrKeyOSSLegalHold])
+
+	xattrs = make(map[string]string)
+	setObjectLockXAttrs(xattrs, now, nil, &ObjectLockOption{LegalHold: LegalHoldOff})
+	require.Empty(t, xattrs)
+}
+
+func TestObjectLockInfo(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	newXAttr := func(xattrs map[string]string) *proto.XAttrInfo {
+		return &proto.XAttrInfo{XAttrs: xattrs}
+	}
+
+	// objects locked before GOVERNANCE mode is supported are in COMPLIANCE mode
+	info, err := parseObjectLockInfo(newXAttr(map[string]string{XAttrKeyOSSLock: strconv.FormatInt(future.UnixNano(), 10)}))
+	require.NoError(t, err)
+	require.Equal(t, ComplianceMode, info.Mode)
+	require.Equal(t, AccessDenied, info.check(true))
+
+	info, err = parseObjectLockInfo(newXAttr(map[string]string{
+		XAttrKeyOSSLock:     strconv.FormatInt(future.UnixNano(), 10),
+		XAttrKeyOSSLockMode: GovernanceMode,
+	}))
+	require.NoError(t, err)
+	require.Equal(t, AccessDenied, info.check(false))
+	require.NoError(t, info.check(true))
+
+	info, err = parseObjectLockInfo(newXAttr(map[string]string{
+		XAttrKeyOSSLock:      strconv.FormatInt(time.Now().Add(-time.Hour).UnixNano(), 10),
+		XAttrKeyOSSLegalHold: LegalHoldOn,
+	}))
+	require.NoError(t, err)
+	require.Equal(t, AccessDenied, info.check(true))
+	info.LegalHold = false
+	require.NoError(t, info.check(false))
+
+	_, err = parseObjectLockInfo(newXAttr(map[string]string{XAttrKeyOSSLock: "invalid"}))
+	require.Error(t, err)
+	info, err = parseObjectLockInfo(nil)
+	require.NoError(t, err)
+	require.NoError(t, info.check(false))
+}
+
+func TestCheckRetentionUpdate(t *testing.T) {
+	now := time.Now()
+	retention := func(mode string, d time.Duration) *ObjectRetention {
+		if mode == "" {
+			return &ObjectRetention{}
+		}
+		return &ObjectRetention{Mode: mode, RetainUntilDate: RetentionDate{Time: now.Add(d)}}
+	}
+
+	compliance := &objectLockInfo{Mode: ComplianceMode, RetainUntilDate: now.Add(time.Hour).UnixNano()}
+	require.NoError(t, compliance.checkRetentionUpdate(retention(ComplianceMode, 2*time.Hour), false))
+	require.Equal(t, AccessDenied, compliance.checkRetentionUpdate(retention(ComplianceMode, time.Minute), true))
+	require.Equal(t, AccessDenied, compliance.checkRetentionUpdate(retention(GovernanceMode, 2*time.Hour), true))
+	require.Equal(t, AccessDenied, compliance.checkRetentionUpdate(retention("", 0), true))
+
+	governance := &objectLockInfo{Mode: GovernanceMode, RetainUntilDate: now.Add(time.Hour).UnixNano()}
+	require.NoError(t, governance.checkRetentionUpdate(retention(GovernanceMode, 2*time.Hour), false))
+	require.NoError(t, governance.checkRetentionUpdate(retention(ComplianceMode, 2*time.Hour), false))
+	require.Equal(t, AccessDenied, governance.checkRetentionUpdate(retention(GovernanceMode, time.Minute), false))
+	require.Equal(t, AccessDenied, governance.checkRetentionUpdate(retention("", 0), false))
+	require.NoError(t, governance.checkRetentionUpdate(retention(GovernanceMode, time.Minute), true))
+	require.NoError(t, governance.checkRetentionUpdate(retention("", 0), true))
+
+	expired := &objectLockInfo{Mode: ComplianceMode, RetainUntilDate: now.Add(-time.Hour).UnixNano()}
+	require.NoError(t, expired.checkRetentionUpdate(retention("", 0), false))
+}
+
+func TestParseObjectRetentionAndLegalHold(t *testing.T) {
+	future := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Millisecond)
+	retention, err := ParseObjectRetentionFromXML([]byte(`<Retention xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+		<Mode>GOVERNANCE</Mode><RetainUntilDate>` + future.Format(ISO8601Layout) + `</RetainUntilDate></Retention>`))
+	require.NoError(t, err)
+	require.Equal(t, GovernanceMode, retention.Mode)
+	require.True(t, future.Equal(retention.RetainUntilDate.Time))
+
+	retention, err = ParseObjectRetentionFromXML([]byte(`<Retention></Retention>`))
+	require.NoError(t, err)
+	require.Equal(t, "", retention.Mode)
+	_, err = ParseObjectRetentionFromXML([]byte(`<Retention><Mode>GOVERNANCE</Mode></Retention>`))
+	require.Error(t, err)
+	_, err = ParseObjectRetentionFromXML([]byte(`<Retention><Mode>GOVERNANCE</Mode><RetainUntilDate>2020-01-01T00:00:00.000Z</RetainUntilDate></Retention>`))
+	require.Error(t, err)
+	_, err = ParseObjectRetentionFromXML([]byte(`<Retention><Mode>GOVERNANCE</Mode><RetainUntilDate>never</RetainUntilDate></Retention>`))
+	require.Equal(t, MalformedXML, err)
+
+	legalHold, err := ParseObjectLegalHoldFromXML([]byte(`<LegalHold xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Status>ON</Status></LegalHold>`))
+	require.NoError(t, err)
+	require.Equal(t, LegalHoldOn, legalHold.Status)
+	_, err = ParseObjectLegalHoldFromXML([]byte(`<LegalHold><Status>on</Status></LegalHold>`))
+	require.Equal(t, InvalidLegalHoldStatus, err)
+}
@@ -61,6 +61,25 @@ func (p *Policy) IsEmpty() bool {
 	return len(p.Statements) == 0
 }
 
+// IsPublic reports whether the policy allows access to everyone, that is any statement allows
+// the wildcard principal and is not restricted to fixed source ip addresses.
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/access-control-block-public-access.html#access-control-block-public-access-policy-status
+func (p *Policy) IsPublic() bool {
+	if p == nil {
+		return false
+	}
+	for i := range p.Statements {
+		s := &p.Statements[i]
+		if s.effect() != POLICY_ALLOW || !s.matchPrincipal(AnonymousUser) {
+			continue
+		}
+		if _, restricted := s.Condition.Keys()[AWSSourceIP]; !restricted {
+			return true
+		}
+	}
+	return false
+}
+
 func ParsePolicy(data []byte) (*Policy, error) {
 	policy := new(Policy)
 	dec := json.NewDecoder(bytes.NewReader(data))
@@ -163,6 +182,13 @@ func (o *ObjectNode) policyCheck(f http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
+		// The website endpoint serves anonymous requests, whose permission is checked against the
+		// bucket policy and ACL for each object to be served by the website handler.
+		if param.apiName == GET_WEBSITE_OBJECT {
+			allowed = true
+			return
+		}
+
 		// step1. The account level api does not need to check any user policy and volume policy.
 		if IsAccountLevelApi(param.apiName) {
 			if !isAnonymous(param.accessKey) {
@@ -243,6 +269,12 @@ func (o *ObjectNode) policyCheck(f http.HandlerFunc) http.HandlerFunc {
 		}
 		log.LogDebugf("bucket policy check: load bucket metadata, requestID(%v) userPolicy(%v/%+v) vol(%v/%v) acl(%+v) policy(%+v)",
 			GetRequestID(r), userInfo.UserID, userInfo.Policy, vol.Name(), vol.GetOwner(), acl, policy)
+		pab, err := vol.publicAccessBlock()
+		if err != nil {
+			log.LogErrorf("bucket policy check: load public access block fail: requestID(%v) err(%v)", GetRequestID(r), err)
+			allowed = false
+			return
+		}
 		if vol != nil && policy != nil && !policy.IsEmpty() {
 			log.LogDebugf("bucket policy check: requestID(%v) policy(%v)", GetRequestID(r), policy)
 			conditionCheck := map[string]string{
@@ -254,6 +286,7 @@ func (o *ObjectNode) policyCheck(f http.HandlerFunc) http.HandlerFunc {
 				conditionCheck[KEYNAME] = param.object
 			}
 			pcr := policy.IsAllowed(param, userInfo.UserID, vol.owner, conditionCheck)
+			pcr = pab.checkPolicyResult(pcr, policy, userInfo.UserID, vol.owner)
 			switch pcr {
 			case POLICY_ALLOW:
 				allowed = true
@@ -287,6 +320,7 @@ func (o *ObjectNode) policyCheck(f http.HandlerFunc) http.HandlerFunc {
 				}
 				err = nil
 			}
+			acl = pab.effectiveACL(acl)
 			if acl == nil && !isOwner {
 				allowed = false
 				log.LogWarnf("acl check: empty acl disallows: requestID(%v) reqUid(%v) ownerUid(%v) volume(%v) action(%v)",
@@ -112,6 +112,19 @@ func (o *ObjectNode) putBucketPolicyHandler(w http.ResponseWriter, r *http.Reque
 			GetRequestID(r), policy, vol.name, err)
 		return
 	}
+	// the public policy is rejected if BlockPublicPolicy is enabled
+	var pab *PublicAccessBlockConfiguration
+	if pab, err = vol.publicAccessBlock(); err != nil {
+		log.LogErrorf("putBucketPolicyHandler: load public access block fail: requestID(%v) bucket(%v) err(%v)",
+			GetRequestID(r), vol.name, err)
+		return
+	}
+	if pab.blockPublicPolicy() && policy.IsPublic() {
+		log.LogWarnf("putBucketPolicyHandler: public policy is blocked: requestID(%v) bucket(%v) policy(%v)",
+			GetRequestID(r), vol.name, string(policyRaw))
+		ec = AccessDenied
+		return
+	}
 	if err = storeBucketPolicy(vol, policyRaw); err != nil {
 		log.LogErrorf("putBucketPolicyHandler: store policy fail: requestID(%v) err(%v)", GetRequestID(r), err)
 		return
@@ -121,6 +134,48 @@ func (o *ObjectNode) putBucketPolicyHandler(w http.ResponseWriter, r *http.Reque
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketPolicyStatus.html
+func (o *ObjectNode) getBucketPolicyStatusHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err error
+		ec  *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, ec)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		ec = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("getBucketPolicyStatusHandler: load volume fail: requestID(%v) err(%v)",
+			GetRequestID(r), err)
+		return
+	}
+	var policy *Policy
+	if policy, err = vol.metaLoader.loadPolicy(); err != nil {
+		log.LogErrorf("getBucketPolicyStatusHandler: load volume policy fail: requestID(%v) err(%v)",
+			GetRequestID(r), err)
+		return
+	}
+	if policy == nil {
+		ec = NoSuchBucketPolicy
+		return
+	}
+
+	response, err := MarshalXMLEntity(&PolicyStatus{XMLNS: XMLNS, IsPublic: policy.IsPublic()})
+	if err != nil {
+		log.LogErrorf("getBucketPolicyStatusHandler: xml marshal fail, requestID(%v) policy(%v) err(%v)",
+			GetRequestID(r), policy, err)
+		return
+	}
+
+	writeSuccessResponseXML(w, response)
+}
+
 // https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketPolicy.html
 func (o *ObjectNode) deleteBucketPolicyHandler(w http.ResponseWriter, r *http.Request) {
 	var (
@@ -0,0 +1,154 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/access-control-block-public-access.html
+
+import (
+	"encoding/xml"
+
+	"github.com/cubefs/cubefs/proto"
+)
+
+const (
+	MaxPublicAccessBlockConfigSize = 1 << 10
+
+	// the path of the account level public access block api of S3 Control
+	accountPublicAccessBlockPath = "/v20180820/configuration/publicAccessBlock"
+)
+
+type PublicAccessBlockConfiguration struct {
+	XMLName               xml.Name `xml:"PublicAccessBlockConfiguration" json:"-"`
+	XMLNS                 string   `xml:"xmlns,attr,omitempty" json:"-"`
+	BlockPublicAcls       bool     `xml:"BlockPublicAcls" json:"block_public_acls"`
+	IgnorePublicAcls      bool     `xml:"IgnorePublicAcls" json:"ignore_public_acls"`
+	BlockPublicPolicy     bool     `xml:"BlockPublicPolicy" json:"block_public_policy"`
+	RestrictPublicBuckets bool     `xml:"RestrictPublicBuckets" json:"restrict_public_buckets"`
+}
+
+type PolicyStatus struct {
+	XMLName  xml.Name `xml:"PolicyStatus"`
+	XMLNS    string   `xml:"xmlns,attr,omitempty"`
+	IsPublic bool     `xml:"IsPublic"`
+}
+
+func ParsePublicAccessBlockConfigFromXML(data []byte) (*PublicAccessBlockConfiguration, error) {
+	config := &PublicAccessBlockConfiguration{}
+	if err := xml.Unmarshal(data, config); err != nil {
+		return nil, MalformedXML
+	}
+	return config, nil
+}
+
+func newPublicAccessBlockConfiguration(config *proto.PublicAccessBlockConfig) *PublicAccessBlockConfiguration {
+	if config == nil {
+		return nil
+	}
+	return &PublicAccessBlockConfiguration{
+		BlockPublicAcls:       config.BlockPublicAcls,
+		IgnorePublicAcls:      config.IgnorePublicAcls,
+		BlockPublicPolicy:     config.BlockPublicPolicy,
+		RestrictPublicBuckets: config.RestrictPublicBuckets,
+	}
+}
+
+func (c *PublicAccessBlockConfiguration) toProto() *proto.PublicAccessBlockConfig {
+	return &proto.PublicAccessBlockConfig{
+		BlockPublicAcls:       c.BlockPublicAcls,
+		IgnorePublicAcls:      c.IgnorePublicAcls,
+		BlockPublicPolicy:     c.BlockPublicPolicy,
+		RestrictPublicBuckets: c.RestrictPublicBuckets,
+	}
+}
+
+// mergePublicAccessBlock returns the most restrictive combination of the bucket and account level
+// configurations, each setting is enabled if it is enabled at either level. Nil means nothing is blocked.
+func mergePublicAccessBlock(bucket, account *PublicAccessBlockConfiguration) *PublicAccessBlockConfiguration {
+	if bucket == nil {
+		return account
+	}
+	if account == nil {
+		return bucket
+	}
+	return &PublicAccessBlockConfiguration{
+		BlockPublicAcls:       bucket.BlockPublicAcls || account.BlockPublicAcls,
+		IgnorePublicAcls:      bucket.IgnorePublicAcls || account.IgnorePublicAcls,
+		BlockPublicPolicy:     bucket.BlockPublicPolicy || account.BlockPublicPolicy,
+		RestrictPublicBuckets: bucket.RestrictPublicBuckets || account.RestrictPublicBuckets,
+	}
+}
+
+func (c *PublicAccessBlockConfiguration) blockPublicAcls() bool {
+	return c != nil && c.BlockPublicAcls
+}
+
+func (c *PublicAccessBlockConfiguration) ignorePublicAcls() bool {
+	return c != nil && c.IgnorePublicAcls
+}
+
+func (c *PublicAccessBlockConfiguration) blockPublicPolicy() bool {
+	return c != nil && c.BlockPublicPolicy
+}
+
+func (c *PublicAccessBlockConfiguration) restrictPublicBuckets() bool {
+	return c != nil && c.RestrictPublicBuckets
+}
+
+// checkACL rejects the public acl to be set if BlockPublicAcls is enabled.
+func (c *PublicAccessBlockConfiguration) checkACL(acl *AccessControlPolicy) *ErrorCode {
+	if c.blockPublicAcls() && acl != nil && acl.IsPublic() {
+		return AccessDenied
+	}
+	return nil
+}
+
+// effectiveACL returns the acl evaluated for the requests, the public grants are ignored
+// if IgnorePublicAcls is enabled.
+func (c *PublicAccessBlockConfiguration) effectiveACL(acl *AccessControlPolicy) *AccessControlPolicy {
+	if c.ignorePublicAcls() && acl != nil && acl.IsPublic() {
+		return acl.withoutPublicGrants()
+	}
+	return acl
+}
+
+// checkPolicyResult restricts the access granted by the public policy to the bucket owner
+// if RestrictPublicBuckets is enabled.
+func (c *PublicAccessBlockConfiguration) checkPolicyResult(result PolicyCheckResult, policy *Policy, reqUid, ownerUid string) PolicyCheckResult {
+	if result == POLICY_ALLOW && c.restrictPublicBuckets() && reqUid != ownerUid && policy.IsPublic() {
+		return POLICY_UNKNOW
+	}
+	return result
+}
+
+// checkPublicACL rejects the public acl to be set to the bucket or its objects, if it is blocked
+// by the public access block configuration of the bucket.
+func checkPublicACL(vol *Volume, acl *AccessControlPolicy) error {
+	pab, err := vol.publicAccessBlock()
+	if err != nil {
+		return err
+	}
+	if ec := pab.checkACL(acl); ec != nil {
+		return ec
+	}
+	return nil
+}
+
+func storeBucketPublicAccessBlock(bytes []byte, vol *Volume) (err error) {
+	return vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSPAB, bytes)
+}
+
+func deleteBucketPublicAccessBlock(vol *Volume) (err error) {
+	return vol.store.Delete(vol.name, bucketRootPath, XAttrKeyOSSPAB)
+}
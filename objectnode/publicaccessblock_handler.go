@@ -0,0 +1,274 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// Get public access block
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetPublicAccessBlock.html
+func (o *ObjectNode) getPublicAccessBlockHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("getPublicAccessBlockHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var config *PublicAccessBlockConfiguration
+	if config, err = vol.metaLoader.loadPublicAccessBlock(); err != nil {
+		log.LogErrorf("getPublicAccessBlockHandler: load public access block fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if config == nil {
+		errorCode = NoSuchPublicAccessBlock
+		return
+	}
+
+	writePublicAccessBlock(w, r, config)
+}
+
+// Put public access block
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutPublicAccessBlock.html
+func (o *ObjectNode) putPublicAccessBlockHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("putPublicAccessBlockHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var config *PublicAccessBlockConfiguration
+	if config, errorCode = readPublicAccessBlock(r); errorCode != nil {
+		log.LogErrorf("putPublicAccessBlockHandler: read public access block fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), errorCode)
+		return
+	}
+
+	var data []byte
+	if data, err = json.Marshal(config); err != nil {
+		log.LogErrorf("putPublicAccessBlockHandler: json marshal fail: requestID(%v) volume(%v) config(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), config, err)
+		return
+	}
+	if err = storeBucketPublicAccessBlock(data, vol); err != nil {
+		log.LogErrorf("putPublicAccessBlockHandler: store public access block fail: requestID(%v) volume(%v) config(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(data), err)
+		return
+	}
+	vol.metaLoader.storePublicAccessBlock(config)
+
+	log.LogInfof("Audit: put bucket public access block: requestID(%v) volume(%v) config(%v)",
+		GetRequestID(r), vol.Name(), string(data))
+}
+
+// Delete public access block
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeletePublicAccessBlock.html
+func (o *ObjectNode) deletePublicAccessBlockHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("deletePublicAccessBlockHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	if err = deleteBucketPublicAccessBlock(vol); err != nil {
+		log.LogErrorf("deletePublicAccessBlockHandler: delete public access block fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	vol.metaLoader.storePublicAccessBlock(nil)
+	log.LogInfof("Audit: delete bucket public access block: requestID(%v) volume(%v)", GetRequestID(r), vol.Name())
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Get account public access block
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_control_GetPublicAccessBlock.html
+func (o *ObjectNode) getAccountPublicAccessBlockHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	var userInfo *proto.UserInfo
+	if userInfo, errorCode = o.loadAccountUser(r); errorCode != nil {
+		return
+	}
+	if userInfo.PublicAccessBlock == nil {
+		errorCode = NoSuchPublicAccessBlock
+		return
+	}
+
+	writePublicAccessBlock(w, r, newPublicAccessBlockConfiguration(userInfo.PublicAccessBlock))
+}
+
+// Put account public access block
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_control_PutPublicAccessBlock.html
+func (o *ObjectNode) putAccountPublicAccessBlockHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	var userInfo *proto.UserInfo
+	if userInfo, errorCode = o.loadAccountUser(r); errorCode != nil {
+		return
+	}
+
+	var config *PublicAccessBlockConfiguration
+	if config, errorCode = readPublicAccessBlock(r); errorCode != nil {
+		log.LogErrorf("putAccountPublicAccessBlockHandler: read public access block fail: requestID(%v) userID(%v) err(%v)",
+			GetRequestID(r), userInfo.UserID, errorCode)
+		return
+	}
+
+	param := &proto.UserPublicAccessBlockUpdateParam{UserID: userInfo.UserID, Config: config.toProto()}
+	if _, err = o.mc.UserAPI().UpdatePublicAccessBlock(param, ""); err != nil {
+		log.LogErrorf("putAccountPublicAccessBlockHandler: update public access block fail: requestID(%v) userID(%v) config(%+v) err(%v)",
+			GetRequestID(r), userInfo.UserID, config, err)
+		return
+	}
+
+	log.LogInfof("Audit: put account public access block: requestID(%v) userID(%v) config(%+v)",
+		GetRequestID(r), userInfo.UserID, *param.Config)
+}
+
+// Delete account public access block
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_control_DeletePublicAccessBlock.html
+func (o *ObjectNode) deleteAccountPublicAccessBlockHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	var userInfo *proto.UserInfo
+	if userInfo, errorCode = o.loadAccountUser(r); errorCode != nil {
+		return
+	}
+
+	param := &proto.UserPublicAccessBlockUpdateParam{UserID: userInfo.UserID}
+	if _, err = o.mc.UserAPI().UpdatePublicAccessBlock(param, ""); err != nil {
+		log.LogErrorf("deleteAccountPublicAccessBlockHandler: delete public access block fail: requestID(%v) userID(%v) err(%v)",
+			GetRequestID(r), userInfo.UserID, err)
+		return
+	}
+	log.LogInfof("Audit: delete account public access block: requestID(%v) userID(%v)", GetRequestID(r), userInfo.UserID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// loadAccountUser loads the user of the account level request, which is not allowed to be anonymous.
+func (o *ObjectNode) loadAccountUser(r *http.Request) (*proto.UserInfo, *ErrorCode) {
+	param := ParseRequestParam(r)
+	if isAnonymous(param.AccessKey()) {
+		return nil, AccessDenied
+	}
+	userInfo, err := o.getUserInfoByAccessKeyV2(param.AccessKey())
+	if err != nil {
+		log.LogErrorf("loadAccountUser: load user fail: requestID(%v) accessKey(%v) err(%v)",
+			GetRequestID(r), param.AccessKey(), err)
+		if ec, ok := err.(*ErrorCode); ok {
+			return nil, ec
+		}
+		return nil, InternalErrorCode(err)
+	}
+	return userInfo, nil
+}
+
+func readPublicAccessBlock(r *http.Request) (*PublicAccessBlockConfiguration, *ErrorCode) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, MaxPublicAccessBlockConfigSize+1))
+	if err != nil {
+		return nil, InternalErrorCode(err)
+	}
+	if len(body) > MaxPublicAccessBlockConfigSize {
+		return nil, EntityTooLarge
+	}
+	config, err := ParsePublicAccessBlockConfigFromXML(body)
+	if err != nil {
+		return nil, MalformedXML
+	}
+	return config, nil
+}
+
+func writePublicAccessBlock(w http.ResponseWriter, r *http.Request, config *PublicAccessBlockConfiguration) {
+	config.XMLNS = XMLNS
+	data, err := MarshalXMLEntity(config)
+	if err != nil {
+		log.LogErrorf("writePublicAccessBlock: xml marshal fail: requestID(%v) config(%+v) err(%v)",
+			GetRequestID(r), config, err)
+		InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	writeSuccessResponseXML(w, data)
+}
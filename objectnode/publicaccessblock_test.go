@@ -0,0 +1,127 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePublicAccessBlockConfig(t *testing.T) {
+	config, err := ParsePublicAccessBlockConfigFromXML([]byte(`<PublicAccessBlockConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+		<BlockPublicAcls>true</BlockPublicAcls>
+		<IgnorePublicAcls>false</IgnorePublicAcls>
+		<RestrictPublicBuckets>true</RestrictPublicBuckets>
+	</PublicAccessBlockConfiguration>`))
+	require.NoError(t, err)
+	require.True(t, config.BlockPublicAcls)
+	require.False(t, config.IgnorePublicAcls)
+	require.False(t, config.BlockPublicPolicy)
+	require.True(t, config.RestrictPublicBuckets)
+
+	_, err = ParsePublicAccessBlockConfigFromXML([]byte(`<PublicAccessBlockConfiguration><BlockPublicAcls>yes</BlockPublicAcls></PublicAccessBlockConfiguration>`))
+	require.Error(t, err)
+	_, err = ParsePublicAccessBlockConfigFromXML([]byte(`<PublicAccessBlockConfiguration>`))
+	require.Error(t, err)
+}
+
+func TestMergePublicAccessBlock(t *testing.T) {
+	require.Nil(t, mergePublicAccessBlock(nil, nil))
+	bucket := &PublicAccessBlockConfiguration{BlockPublicAcls: true}
+	account := newPublicAccessBlockConfiguration(&proto.PublicAccessBlockConfig{RestrictPublicBuckets: true})
+	require.Equal(t, bucket, mergePublicAccessBlock(bucket, nil))
+	require.Equal(t, account, mergePublicAccessBlock(nil, account))
+
+	merged := mergePublicAccessBlock(bucket, account)
+	require.True(t, merged.blockPublicAcls())
+	require.False(t, merged.ignorePublicAcls())
+	require.False(t, merged.blockPublicPolicy())
+	require.True(t, merged.restrictPublicBuckets())
+	require.Equal(t, &proto.PublicAccessBlockConfig{BlockPublicAcls: true, RestrictPublicBuckets: true}, merged.toProto())
+}
+
+func TestPublicAccessBlockACL(t *testing.T) {
+	private := &AccessControlPolicy{}
+	private.SetPrivate("owner")
+	public := &AccessControlPolicy{}
+	public.SetPublicRead("owner")
+	public.AddGrant("user", TypeCanonicalUser, PermissionRead)
+	require.False(t, private.IsPublic())
+	require.True(t, public.IsPublic())
+
+	var pab *PublicAccessBlockConfiguration
+	require.Nil(t, pab.checkACL(public))
+	require.Equal(t, public, pab.effectiveACL(public))
+
+	pab = &PublicAccessBlockConfiguration{BlockPublicAcls: true, IgnorePublicAcls: true}
+	require.Nil(t, pab.checkACL(nil))
+	require.Nil(t, pab.checkACL(private))
+	require.Equal(t, AccessDenied, pab.checkACL(public))
+
+	require.True(t, public.IsAllowed(AnonymousUser, proto.OSSGetObjectAction))
+	acl := pab.effectiveACL(public)
+	require.False(t, acl.IsPublic())
+	require.Len(t, acl.Acl.Grants, 2)
+	require.False(t, acl.IsAllowed(AnonymousUser, proto.OSSGetObjectAction))
+	require.True(t, acl.IsAllowed("user", proto.OSSGetObjectAction))
+	require.True(t, acl.IsAllowed("owner", proto.OSSPutObjectAction))
+	require.True(t, public.IsPublic())
+}
+
+func TestPolicyIsPublic(t *testing.T) {
+	tests := []struct {
+		policy string
+		public bool
+	}{
+		{
+			policy: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::bucket/*"}]}`,
+			public: true,
+		},
+		{
+			policy: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":["*"]},"Action":"s3:GetObject","Resource":"arn:aws:s3:::bucket/*",
+				"Condition":{"StringLike":{"aws:Referer":["http://example.com/*"]}}}]}`,
+			public: true,
+		},
+		{
+			policy: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::bucket/*",
+				"Condition":{"IpAddress":{"aws:SourceIp":"10.0.0.0/8"}}}]}`,
+		},
+		{
+			policy: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":["user"]},"Action":"s3:GetObject","Resource":"arn:aws:s3:::bucket/*"}]}`,
+		},
+		{
+			policy: `{"Version":"2012-10-17","Statement":[{"Effect":"Deny","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::bucket/*"}]}`,
+		},
+	}
+	for i, tc := range tests {
+		policy, err := ParsePolicy([]byte(tc.policy))
+		require.NoError(t, err, "case %d", i)
+		require.Equal(t, tc.public, policy.IsPublic(), "case %d", i)
+	}
+	require.False(t, (*Policy)(nil).IsPublic())
+
+	public, err := ParsePolicy([]byte(tests[0].policy))
+	require.NoError(t, err)
+	private, err := ParsePolicy([]byte(tests[3].policy))
+	require.NoError(t, err)
+	pab := &PublicAccessBlockConfiguration{RestrictPublicBuckets: true}
+	require.Equal(t, POLICY_UNKNOW, pab.checkPolicyResult(POLICY_ALLOW, public, AnonymousUser, "owner"))
+	require.Equal(t, POLICY_ALLOW, pab.checkPolicyResult(POLICY_ALLOW, public, "owner", "owner"))
+	require.Equal(t, POLICY_DENY, pab.checkPolicyResult(POLICY_DENY, public, AnonymousUser, "owner"))
+	require.Equal(t, POLICY_ALLOW, pab.checkPolicyResult(POLICY_ALLOW, private, "user", "owner"))
+	require.Equal(t, POLICY_ALLOW, (*PublicAccessBlockConfiguration)(nil).checkPolicyResult(POLICY_ALLOW, public, AnonymousUser, "owner"))
+}
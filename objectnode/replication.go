@@ -0,0 +1,304 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/xml"
+	"errors"
+	"strings"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+var (
+	ReplicationRulesLessThanOneErr     = errors.New("At least one replication rule must be specified")
+	ReplicationRulesGreaterThan1KErr   = errors.New("The number of replication rules must not exceed the allowed limit of 1000 rules")
+	ReplicationRuleIdTooLongErr        = errors.New("The ID of replication rule must be no longer than 255 characters")
+	ReplicationRuleIdDuplicatedErr     = errors.New("The ID of replication rules must be unique")
+	ReplicationPriorityDuplicatedErr   = errors.New("The priority of replication rules must be unique")
+	ReplicationInvalidStatusErr        = errors.New("The status of replication rule must be Enabled or Disabled")
+	ReplicationInvalidFilterErr        = errors.New("Only one of Prefix, Tag and And can be specified in the filter of replication rule")
+	ReplicationBothPrefixAndFilterErr  = errors.New("Prefix and Filter cannot be specified in the same replication rule")
+	ReplicationNilDestinationErr       = errors.New("Destination of replication rule must be specified")
+	ReplicationInvalidBucketArnErr     = errors.New("Invalid bucket ARN of replication destination")
+	ReplicationInvalidDeleteMarkerErr  = errors.New("The status of delete marker replication must be Enabled or Disabled")
+	ReplicationDeleteMarkerWithTagsErr = errors.New("Delete marker replication is not supported if any tag filter is specified")
+)
+
+const (
+	ReplicationStatusPending   = "PENDING"
+	ReplicationStatusCompleted = "COMPLETED"
+	ReplicationStatusFailed    = "FAILED"
+	ReplicationStatusReplica   = "REPLICA"
+
+	Disabled = "Disabled"
+
+	MaxReplicationConfigSize = 1 << 20 // 1MB
+	maxReplicationRules      = 1000
+	maxReplicationRuleIdLen  = 255
+
+	bucketArnPrefix = "arn:aws:s3:"
+)
+
+// ReplicationConfiguration is the replication configuration of the bucket.
+type ReplicationConfiguration struct {
+	XMLNS   string             `xml:"xmlns,attr,omitempty" json:"-"`
+	XMLName xml.Name           `xml:"ReplicationConfiguration" json:"-"`
+	Role    string             `xml:"Role,omitempty" json:"role,omitempty"`
+	Rules   []*ReplicationRule `xml:"Rule" json:"rules"`
+}
+
+type ReplicationRule struct {
+	ID                      string                   `xml:"ID,omitempty" json:"id,omitempty"`
+	Priority                int                      `xml:"Priority,omitempty" json:"priority,omitempty"`
+	Status                  string                   `xml:"Status" json:"status"`
+	Prefix                  *string                  `xml:"Prefix" json:"prefix,omitempty"`
+	Filter                  *ReplicationFilter       `xml:"Filter" json:"filter,omitempty"`
+	Destination             *ReplicationDestination  `xml:"Destination" json:"destination"`
+	DeleteMarkerReplication *DeleteMarkerReplication `xml:"DeleteMarkerReplication,omitempty" json:"delete_marker,omitempty"`
+}
+
+type ReplicationFilter struct {
+	Prefix *string               `xml:"Prefix" json:"prefix,omitempty"`
+	Tag    *Tag                  `xml:"Tag" json:"tag,omitempty"`
+	And    *ReplicationFilterAnd `xml:"And" json:"and,omitempty"`
+}
+
+type ReplicationFilterAnd struct {
+	Prefix string `xml:"Prefix,omitempty" json:"prefix,omitempty"`
+	Tags   []Tag  `xml:"Tag" json:"tags,omitempty"`
+}
+
+// ReplicationDestination is the destination bucket of the replication. The bucket is specified by its ARN,
+// "arn:aws:s3:::<bucket>" refers to a bucket in this cluster, and "arn:aws:s3:<target>::<bucket>" refers to
+// a bucket of the remote S3 endpoint which is configured as <target> in the ObjectNode configuration.
+type ReplicationDestination struct {
+	Bucket       string `xml:"Bucket" json:"bucket"`
+	StorageClass string `xml:"StorageClass,omitempty" json:"storage_class,omitempty"`
+}
+
+type DeleteMarkerReplication struct {
+	Status string `xml:"Status" json:"status"`
+}
+
+func newReplicationError(err error) *ErrorCode {
+	return NewError("InvalidRequest", err.Error(), 400)
+}
+
+// parse ReplicationConfiguration from xml
+func ParseReplicationConfigFromXML(data []byte) (*ReplicationConfiguration, error) {
+	config := &ReplicationConfiguration{}
+	if err := xml.Unmarshal(data, config); err != nil {
+		return nil, MalformedXML
+	}
+	if err := config.CheckValid(); err != nil {
+		if ec, ok := err.(*ErrorCode); ok {
+			return nil, ec
+		}
+		return nil, newReplicationError(err)
+	}
+	return config, nil
+}
+
+func (c *ReplicationConfiguration) CheckValid() error {
+	if len(c.Rules) == 0 {
+		return ReplicationRulesLessThanOneErr
+	}
+	if len(c.Rules) > maxReplicationRules {
+		return ReplicationRulesGreaterThan1KErr
+	}
+	ids := make(map[string]struct{}, len(c.Rules))
+	priorities := make(map[int]struct{}, len(c.Rules))
+	for _, rule := range c.Rules {
+		if err := rule.checkValid(); err != nil {
+			return err
+		}
+		if rule.ID != "" {
+			if _, ok := ids[rule.ID]; ok {
+				return ReplicationRuleIdDuplicatedErr
+			}
+			ids[rule.ID] = struct{}{}
+		}
+		if rule.Filter != nil {
+			if _, ok := priorities[rule.Priority]; ok {
+				return ReplicationPriorityDuplicatedErr
+			}
+			priorities[rule.Priority] = struct{}{}
+		}
+	}
+	return nil
+}
+
+func (r *ReplicationRule) checkValid() error {
+	if len(r.ID) > maxReplicationRuleIdLen {
+		return ReplicationRuleIdTooLongErr
+	}
+	if r.Status != Enabled && r.Status != Disabled {
+		return ReplicationInvalidStatusErr
+	}
+	if r.Prefix != nil && r.Filter != nil {
+		return ReplicationBothPrefixAndFilterErr
+	}
+	if r.Filter != nil {
+		if err := r.Filter.checkValid(); err != nil {
+			return err
+		}
+	}
+	if r.Destination == nil {
+		return ReplicationNilDestinationErr
+	}
+	if _, _, err := parseBucketArn(r.Destination.Bucket); err != nil {
+		return err
+	}
+	if r.DeleteMarkerReplication != nil {
+		switch r.DeleteMarkerReplication.Status {
+		case Enabled:
+			if r.Filter != nil && r.Filter.hasTags() {
+				return ReplicationDeleteMarkerWithTagsErr
+			}
+		case Disabled:
+		default:
+			return ReplicationInvalidDeleteMarkerErr
+		}
+	}
+	return nil
+}
+
+func (f *ReplicationFilter) checkValid() error {
+	count := 0
+	if f.Prefix != nil {
+		count++
+	}
+	if f.Tag != nil {
+		if !f.Tag.isValid() {
+			return InvalidTag
+		}
+		count++
+	}
+	if f.And != nil {
+		for _, tag := range f.And.Tags {
+			if !tag.isValid() {
+				return InvalidTag
+			}
+		}
+		count++
+	}
+	if count > 1 {
+		return ReplicationInvalidFilterErr
+	}
+	return nil
+}
+
+func (f *ReplicationFilter) hasTags() bool {
+	return f.Tag != nil || (f.And != nil && len(f.And.Tags) > 0)
+}
+
+func (f *ReplicationFilter) match(key string, tags map[string]string) bool {
+	switch {
+	case f.Prefix != nil:
+		return strings.HasPrefix(key, *f.Prefix)
+	case f.Tag != nil:
+		value, ok := tags[f.Tag.Key]
+		return ok && value == f.Tag.Value
+	case f.And != nil:
+		if !strings.HasPrefix(key, f.And.Prefix) {
+			return false
+		}
+		for _, tag := range f.And.Tags {
+			if value, ok := tags[tag.Key]; !ok || value != tag.Value {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (r *ReplicationRule) match(key string, tags map[string]string) bool {
+	if r.Status != Enabled {
+		return false
+	}
+	if r.Prefix != nil {
+		return strings.HasPrefix(key, *r.Prefix)
+	}
+	if r.Filter != nil {
+		return r.Filter.match(key, tags)
+	}
+	return true
+}
+
+func (r *ReplicationRule) deleteMarkerEnabled() bool {
+	return r.DeleteMarkerReplication != nil && r.DeleteMarkerReplication.Status == Enabled
+}
+
+// matchRule returns the enabled rule that applies to the object, the rule with the highest priority
+// wins if more than one rules match.
+func (c *ReplicationConfiguration) matchRule(key string, tagging *Tagging) (matched *ReplicationRule) {
+	if c == nil {
+		return nil
+	}
+	tags := make(map[string]string)
+	if tagging != nil {
+		for _, tag := range tagging.TagSet {
+			tags[tag.Key] = tag.Value
+		}
+	}
+	for _, rule := range c.Rules {
+		if rule.match(key, tags) && (matched == nil || rule.Priority > matched.Priority) {
+			matched = rule
+		}
+	}
+	return
+}
+
+// parseBucketArn parses the bucket ARN in the form "arn:aws:s3:<target>::<bucket>",
+// an empty target indicates the bucket is in this cluster.
+func parseBucketArn(arn string) (target, bucket string, err error) {
+	if !strings.HasPrefix(arn, bucketArnPrefix) {
+		return "", "", ReplicationInvalidBucketArnErr
+	}
+	items := strings.Split(arn[len(bucketArnPrefix):], ":")
+	if len(items) != 3 || items[2] == "" || strings.Contains(items[2], "/") {
+		return "", "", ReplicationInvalidBucketArnErr
+	}
+	return items[0], items[2], nil
+}
+
+func storeBucketReplication(bytes []byte, vol *Volume) (err error) {
+	return vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSReplication, bytes)
+}
+
+func deleteBucketReplication(vol *Volume) (err error) {
+	return vol.store.Delete(vol.name, bucketRootPath, XAttrKeyOSSReplication)
+}
+
+// setReplicationStatus updates the replication status of the object inode.
+func (v *Volume) setReplicationStatus(inode uint64, status string) (err error) {
+	if err = v.mw.XAttrSet_ll(inode, []byte(XAttrKeyOSSReplicationStatus), []byte(status)); err != nil {
+		log.LogErrorf("setReplicationStatus: set xattr fail: volume(%v) inode(%v) status(%v) err(%v)",
+			v.name, inode, status, err)
+		return
+	}
+	if objMetaCache != nil {
+		attr := &AttrItem{
+			XAttrInfo: proto.XAttrInfo{
+				Inode:  inode,
+				XAttrs: map[string]string{XAttrKeyOSSReplicationStatus: status},
+			},
+		}
+		objMetaCache.MergeAttr(v.name, attr)
+	}
+	return
+}
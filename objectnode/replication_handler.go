@@ -0,0 +1,211 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// Get bucket replication
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketReplication.html
+func (o *ObjectNode) getBucketReplicationHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("getBucketReplicationHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var config *ReplicationConfiguration
+	if config, err = vol.metaLoader.loadReplication(); err != nil {
+		log.LogErrorf("getBucketReplicationHandler: load replication fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if config == nil || len(config.Rules) == 0 {
+		errorCode = NoSuchReplicationConfiguration
+		return
+	}
+	config.XMLNS = XMLNS
+
+	var data []byte
+	if data, err = MarshalXMLEntity(config); err != nil {
+		log.LogErrorf("getBucketReplicationHandler: xml marshal fail: requestID(%v) volume(%v) config(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), config, err)
+		return
+	}
+
+	writeSuccessResponseXML(w, data)
+}
+
+// Put bucket replication
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketReplication.html
+func (o *ObjectNode) putBucketReplicationHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	if o.replicator == nil {
+		errorCode = ReplicationNotEnabled
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("putBucketReplicationHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var body []byte
+	if body, err = io.ReadAll(io.LimitReader(r.Body, MaxReplicationConfigSize+1)); err != nil {
+		log.LogErrorf("putBucketReplicationHandler: read request body fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if len(body) > MaxReplicationConfigSize {
+		errorCode = EntityTooLarge
+		return
+	}
+
+	var config *ReplicationConfiguration
+	if config, err = ParseReplicationConfigFromXML(body); err != nil {
+		log.LogErrorf("putBucketReplicationHandler: parse replication config fail: requestID(%v) volume(%v) config(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), err)
+		return
+	}
+
+	// the noncurrent versions are kept on the source bucket while the replication is in progress
+	var versioning string
+	if versioning, err = vol.versioningStatus(); err != nil {
+		log.LogErrorf("putBucketReplicationHandler: load versioning fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if versioning != Enabled {
+		errorCode = ReplicationVersioningRequired
+		return
+	}
+	for _, rule := range config.Rules {
+		if err = o.replicator.checkDestination(vol.Name(), rule.Destination); err != nil {
+			log.LogErrorf("putBucketReplicationHandler: check destination fail: requestID(%v) volume(%v) destination(%v) err(%v)",
+				GetRequestID(r), vol.Name(), rule.Destination.Bucket, err)
+			return
+		}
+	}
+
+	if body, err = json.Marshal(config); err != nil {
+		log.LogErrorf("putBucketReplicationHandler: json marshal replication config fail: requestID(%v) volume(%v) config(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), config, err)
+		return
+	}
+	if err = storeBucketReplication(body, vol); err != nil {
+		log.LogErrorf("putBucketReplicationHandler: store replication config fail: requestID(%v) volume(%v) config(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), err)
+		return
+	}
+	vol.metaLoader.storeReplication(config)
+
+	log.LogInfof("Audit: put bucket replication: requestID(%v) volume(%v) config(%v)",
+		GetRequestID(r), vol.Name(), string(body))
+}
+
+// Delete bucket replication
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketReplication.html
+func (o *ObjectNode) deleteBucketReplicationHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("deleteBucketReplicationHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	if err = deleteBucketReplication(vol); err != nil {
+		log.LogErrorf("deleteBucketReplicationHandler: delete replication config fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	vol.metaLoader.storeReplication(nil)
+
+	log.LogInfof("Audit: delete bucket replication: requestID(%v) volume(%v)", GetRequestID(r), vol.Name())
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// replicaStatus returns REPLICA if the object is written by the replication of another cluster.
+// The replication status of the request is ignored unless it's signed by the access key of a replication peer,
+// otherwise any client is able to prevent its object from being replicated.
+func (o *ObjectNode) replicaStatus(r *http.Request, accessKey string) string {
+	if r.Header.Get(XAmzReplicationStatus) != ReplicationStatusReplica {
+		return ""
+	}
+	if o.replicator == nil || !o.replicator.isPeer(accessKey) {
+		log.LogWarnf("replicaStatus: ignore replication status of non-peer: requestID(%v) accessKey(%v)",
+			GetRequestID(r), accessKey)
+		return ""
+	}
+	return ReplicationStatusReplica
+}
+
+// setReplicationStatusHeader sets the replication status of the object to the response header.
+func setReplicationStatusHeader(w http.ResponseWriter, xattr *proto.XAttrInfo) {
+	if xattr == nil {
+		return
+	}
+	if status := xattr.Get(XAttrKeyOSSReplicationStatus); len(status) > 0 {
+		w.Header().Set(XAmzReplicationStatus, string(status))
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Shopify/sarama"
+
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	defaultReplicationKafkaGroup = "cubefs-objectnode-replication"
+	replicationConsumeRetryDelay = 5 * time.Second
+)
+
+// ReplicationKafkaConfig is the topic of the Kafka audit consumed by the replicator.
+type ReplicationKafkaConfig struct {
+	Group string `json:"group"`
+
+	KafkaConfig
+}
+
+// FixConfig validates and fixes the configuration.
+func (c *ReplicationKafkaConfig) FixConfig() error {
+	if c.Group == "" {
+		c.Group = defaultReplicationKafkaGroup
+	}
+	return c.KafkaConfig.FixConfig()
+}
+
+// replicationConsumer consumes the audit entries from Kafka. The offset of an entry is marked only after
+// the object has been replicated, so the entries being replicated are consumed again after restart. The
+// entry failed by a transient error, e.g. the volume is unavailable, is retried rather than skipped.
+// All objectnodes share the consumer group, and the partitions are replicated concurrently.
+type replicationConsumer struct {
+	r     *Replicator
+	group sarama.ConsumerGroup
+	topic string
+}
+
+func newReplicationConsumer(r *Replicator, conf ReplicationKafkaConfig) (*replicationConsumer, error) {
+	group, err := conf.BuildConsumerGroup(conf.Group)
+	if err != nil {
+		return nil, err
+	}
+	return &replicationConsumer{r: r, group: group, topic: conf.Topic}, nil
+}
+
+func (c *replicationConsumer) run(stopC <-chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stopC:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	go func() {
+		for err := range c.group.Errors() {
+			log.LogWarnf("replicator: kafka consumer error: topic(%v) err(%v)", c.topic, err)
+		}
+	}()
+	for {
+		// Consume returns when the group is rebalanced, and it's called again to rejoin the group.
+		if err := c.group.Consume(ctx, []string{c.topic}, c); err != nil {
+			log.LogErrorf("replicator: consume kafka fail: topic(%v) err(%v)", c.topic, err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(replicationConsumeRetryDelay):
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func (c *replicationConsumer) Setup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+func (c *replicationConsumer) Cleanup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+func (c *replicationConsumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for {
+		select {
+		case <-session.Context().Done():
+			return nil
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+			if !c.handle(session.Context(), msg) {
+				return nil
+			}
+			session.MarkMessage(msg, "")
+		}
+	}
+}
+
+// handle consumes the entry until it succeeds, false is returned if the session ends before, and the
+// entry is consumed again by the next session.
+func (c *replicationConsumer) handle(ctx context.Context, msg *sarama.ConsumerMessage) bool {
+	for {
+		err := c.consume(msg)
+		if err == nil {
+			return true
+		}
+		log.LogWarnf("replicator: consume audit entry fail, retry later: topic(%v) partition(%v) offset(%v) err(%v)",
+			msg.Topic, msg.Partition, msg.Offset, err)
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(replicationConsumeRetryDelay):
+		}
+	}
+}
+
+func (c *replicationConsumer) consume(msg *sarama.ConsumerMessage) error {
+	var entry AuditEntry
+	if err := json.Unmarshal(msg.Value, &entry); err != nil {
+		log.LogWarnf("replicator: skip invalid audit entry: topic(%v) partition(%v) offset(%v) err(%v)",
+			msg.Topic, msg.Partition, msg.Offset, err)
+		return nil
+	}
+	if task := newReplicationTask(&entry); task != nil {
+		return c.r.replicate(task)
+	}
+	return nil
+}
+
+func (c *replicationConsumer) Close() error {
+	return c.group.Close()
+}
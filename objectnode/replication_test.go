@@ -0,0 +1,339 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReplicationConfig(t *testing.T) {
+	tests := []struct {
+		value string
+		valid bool
+	}{
+		{
+			value: `<ReplicationConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+						<Role>arn:aws:iam::123456789012:role/replication</Role>
+						<Rule>
+							<ID>rule1</ID>
+							<Priority>1</Priority>
+							<Status>Enabled</Status>
+							<Filter><Prefix>logs/</Prefix></Filter>
+							<Destination><Bucket>arn:aws:s3:::backup</Bucket></Destination>
+							<DeleteMarkerReplication><Status>Enabled</Status></DeleteMarkerReplication>
+						</Rule>
+						<Rule>
+							<ID>rule2</ID>
+							<Priority>2</Priority>
+							<Status>Disabled</Status>
+							<Filter><And><Prefix>data/</Prefix><Tag><Key>k</Key><Value>v</Value></Tag></And></Filter>
+							<Destination><Bucket>arn:aws:s3:remote::backup</Bucket></Destination>
+						</Rule>
+					</ReplicationConfiguration>`,
+			valid: true,
+		},
+		{
+			value: `<ReplicationConfiguration>
+						<Rule><Status>Enabled</Status><Prefix></Prefix>
+						<Destination><Bucket>arn:aws:s3:::backup</Bucket></Destination></Rule>
+					</ReplicationConfiguration>`,
+			valid: true,
+		},
+		{
+			value: `<ReplicationConfiguration></ReplicationConfiguration>`,
+		},
+		{
+			value: `<ReplicationConfiguration>
+						<Rule><Status>enabled</Status><Destination><Bucket>arn:aws:s3:::backup</Bucket></Destination></Rule>
+					</ReplicationConfiguration>`,
+		},
+		{
+			value: `<ReplicationConfiguration>
+						<Rule><Status>Enabled</Status><Prefix>a</Prefix><Filter><Prefix>a</Prefix></Filter>
+						<Destination><Bucket>arn:aws:s3:::backup</Bucket></Destination></Rule>
+					</ReplicationConfiguration>`,
+		},
+		{
+			value: `<ReplicationConfiguration>
+						<Rule><ID>r</ID><Status>Enabled</Status><Destination><Bucket>arn:aws:s3:::a</Bucket></Destination></Rule>
+						<Rule><ID>r</ID><Status>Enabled</Status><Destination><Bucket>arn:aws:s3:::b</Bucket></Destination></Rule>
+					</ReplicationConfiguration>`,
+		},
+		{
+			value: `<ReplicationConfiguration>
+						<Rule><Priority>1</Priority><Status>Enabled</Status><Filter></Filter>
+						<Destination><Bucket>arn:aws:s3:::a</Bucket></Destination></Rule>
+						<Rule><Priority>1</Priority><Status>Enabled</Status><Filter></Filter>
+						<Destination><Bucket>arn:aws:s3:::b</Bucket></Destination></Rule>
+					</ReplicationConfiguration>`,
+		},
+		{
+			value: `<ReplicationConfiguration>
+						<Rule><Status>Enabled</Status><Destination><Bucket>backup</Bucket></Destination></Rule>
+					</ReplicationConfiguration>`,
+		},
+		{
+			value: `<ReplicationConfiguration>
+						<Rule><Status>Enabled</Status>
+						<Filter><Prefix>a</Prefix><Tag><Key>k</Key><Value>v</Value></Tag></Filter>
+						<Destination><Bucket>arn:aws:s3:::backup</Bucket></Destination></Rule>
+					</ReplicationConfiguration>`,
+		},
+		{
+			value: `<ReplicationConfiguration>
+						<Rule><Status>Enabled</Status><Filter><Tag><Key>k</Key><Value>v</Value></Tag></Filter>
+						<Destination><Bucket>arn:aws:s3:::backup</Bucket></Destination>
+						<DeleteMarkerReplication><Status>Enabled</Status></DeleteMarkerReplication></Rule>
+					</ReplicationConfiguration>`,
+		},
+		{
+			value: `<ReplicationConfiguration><Rule>`,
+		},
+	}
+
+	for i, tc := range tests {
+		_, err := ParseReplicationConfigFromXML([]byte(tc.value))
+		if tc.valid {
+			require.NoError(t, err, "case %d", i)
+		} else {
+			require.Error(t, err, "case %d", i)
+		}
+	}
+}
+
+func TestParseBucketArn(t *testing.T) {
+	target, bucket, err := parseBucketArn("arn:aws:s3:::backup")
+	require.NoError(t, err)
+	require.Equal(t, "", target)
+	require.Equal(t, "backup", bucket)
+
+	target, bucket, err = parseBucketArn("arn:aws:s3:remote::backup")
+	require.NoError(t, err)
+	require.Equal(t, "remote", target)
+	require.Equal(t, "backup", bucket)
+
+	for _, arn := range []string{"", "backup", "arn:aws:s3:::", "arn:aws:s3::::backup", "arn:aws:s3:::backup/key", "arn:aws:iam:::backup"} {
+		_, _, err = parseBucketArn(arn)
+		require.Equal(t, ReplicationInvalidBucketArnErr, err, arn)
+	}
+}
+
+func TestReplicationMatchRule(t *testing.T) {
+	logs, data := "logs/", "data/"
+	config := &ReplicationConfiguration{
+		Rules: []*ReplicationRule{
+			{ID: "all", Priority: 1, Status: Enabled, Filter: &ReplicationFilter{}},
+			{ID: "logs", Priority: 2, Status: Enabled, Filter: &ReplicationFilter{Prefix: &logs}},
+			{ID: "disabled", Priority: 3, Status: Disabled, Filter: &ReplicationFilter{Prefix: &data}},
+			{ID: "tagged", Priority: 4, Status: Enabled, Filter: &ReplicationFilter{
+				And: &ReplicationFilterAnd{Prefix: data, Tags: []Tag{{Key: "k", Value: "v"}}},
+			}},
+		},
+	}
+
+	require.Equal(t, "all", config.matchRule("a", nil).ID)
+	require.Equal(t, "logs", config.matchRule("logs/a", nil).ID)
+	require.Equal(t, "all", config.matchRule("data/a", nil).ID)
+	require.Equal(t, "all", config.matchRule("data/a", &Tagging{TagSet: []Tag{{Key: "k", Value: "x"}}}).ID)
+	require.Equal(t, "tagged", config.matchRule("data/a", &Tagging{TagSet: []Tag{{Key: "k", Value: "v"}}}).ID)
+
+	config.Rules = config.Rules[1:]
+	require.Nil(t, config.matchRule("a", nil))
+	config = nil
+	require.Nil(t, config.matchRule("a", nil))
+}
+
+func TestNewReplicationTask(t *testing.T) {
+	newEntry := func(api string, statusCode int, query map[string]string) *AuditEntry {
+		entry := &AuditEntry{}
+		entry.Request.API = api
+		entry.Request.Bucket = "bucket"
+		entry.Request.Object = "key"
+		entry.Request.Query = query
+		entry.Response.StatusCode = statusCode
+		return entry
+	}
+
+	for _, api := range []string{PUT_OBJECT, POST_OBJECT, COPY_OBJECT, COMPLETE_MULTIPART_UPLOAD} {
+		task := newReplicationTask(newEntry(api, http.StatusOK, nil))
+		require.NotNil(t, task)
+		require.Equal(t, replicationOpPut, task.op)
+		require.Equal(t, "bucket", task.bucket)
+		require.Equal(t, "key", task.object)
+	}
+	task := newReplicationTask(newEntry(DELETE_OBJECT, http.StatusNoContent, nil))
+	require.NotNil(t, task)
+	require.Equal(t, replicationOpDelete, task.op)
+
+	require.Nil(t, newReplicationTask(newEntry(DELETE_OBJECT, http.StatusNoContent, map[string]string{ParamVersionId: "1"})))
+	require.Nil(t, newReplicationTask(newEntry(PUT_OBJECT, http.StatusBadRequest, nil)))
+	require.Nil(t, newReplicationTask(newEntry(GET_OBJECT, http.StatusOK, nil)))
+	entry := newEntry(PUT_OBJECT, http.StatusOK, nil)
+	entry.Request.Object = ""
+	require.Nil(t, newReplicationTask(entry))
+}
+
+func TestS3ReplicationTarget(t *testing.T) {
+	var (
+		requests []*http.Request
+		bodies   [][]byte
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r)
+		bodies = append(bodies, body)
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set(ETag, "\"etag\"")
+	}))
+	defer server.Close()
+
+	conf := ReplicationTargetConfig{Endpoint: server.URL, AccessKey: "ak", SecretKey: "sk"}
+	require.NoError(t, conf.FixConfig())
+	target, err := newS3ReplicationTarget(conf, "")
+	require.NoError(t, err)
+
+	data := []byte(strings.Repeat("replication", 100))
+	object := &replicaObject{
+		Key:      "dir/key",
+		Size:     int64(len(data)),
+		MIMEType: "text/plain",
+		Metadata: map[string]string{"owner": "cubefs"},
+		Tagging:  &Tagging{TagSet: []Tag{{Key: "k", Value: "v"}}},
+	}
+	require.NoError(t, target.PutObject("backup", object, bytes.NewReader(data)))
+	require.NoError(t, target.DeleteObject("backup", "dir/key"))
+
+	require.Equal(t, 2, len(requests))
+	put := requests[0]
+	require.Equal(t, http.MethodPut, put.Method)
+	require.Equal(t, "/backup/dir/key", put.URL.Path)
+	require.Equal(t, data, bodies[0])
+	require.Equal(t, "text/plain", put.Header.Get(ContentType))
+	require.Equal(t, "cubefs", put.Header.Get("X-Amz-Meta-Owner"))
+	require.Equal(t, "k=v", put.Header.Get(XAmzTagging))
+	require.Equal(t, ReplicationStatusReplica, put.Header.Get(XAmzReplicationStatus))
+	require.True(t, strings.HasPrefix(put.Header.Get(Authorization), "AWS4-HMAC-SHA256"))
+	require.Equal(t, http.MethodDelete, requests[1].Method)
+	require.Equal(t, "/backup/dir/key", requests[1].URL.Path)
+
+	conf = ReplicationTargetConfig{Endpoint: "ftp://127.0.0.1", AccessKey: "ak", SecretKey: "sk"}
+	require.Error(t, conf.FixConfig())
+	conf = ReplicationTargetConfig{Endpoint: server.URL}
+	require.Error(t, conf.FixConfig())
+}
+
+func TestReplicaStatus(t *testing.T) {
+	newRequest := func(status string) *http.Request {
+		r := httptest.NewRequest(http.MethodPut, "/bucket/key", nil)
+		if status != "" {
+			r.Header.Set(XAmzReplicationStatus, status)
+		}
+		return r
+	}
+
+	o := &ObjectNode{}
+	require.Equal(t, "", o.replicaStatus(newRequest(ReplicationStatusReplica), "peer"))
+
+	o.replicator = &Replicator{peers: map[string]struct{}{"peer": {}}}
+	require.Equal(t, ReplicationStatusReplica, o.replicaStatus(newRequest(ReplicationStatusReplica), "peer"))
+	require.Equal(t, "", o.replicaStatus(newRequest(ReplicationStatusReplica), "user"))
+	require.Equal(t, "", o.replicaStatus(newRequest(ReplicationStatusPending), "peer"))
+	require.Equal(t, "", o.replicaStatus(newRequest(""), "peer"))
+}
+
+type testConsumerSession struct {
+	sarama.ConsumerGroupSession
+	ctx    context.Context
+	marked []int64
+}
+
+func (s *testConsumerSession) Context() context.Context {
+	return s.ctx
+}
+
+func (s *testConsumerSession) MarkMessage(msg *sarama.ConsumerMessage, metadata string) {
+	s.marked = append(s.marked, msg.Offset)
+}
+
+type testConsumerClaim struct {
+	sarama.ConsumerGroupClaim
+	messages chan *sarama.ConsumerMessage
+}
+
+func (c *testConsumerClaim) Messages() <-chan *sarama.ConsumerMessage {
+	return c.messages
+}
+
+func TestReplicationConsumer(t *testing.T) {
+	var buckets []string
+	r := &Replicator{getVol: func(bucket string) (*Volume, error) {
+		buckets = append(buckets, bucket)
+		return nil, NoSuchBucket
+	}}
+	consumer := &replicationConsumer{r: r, topic: "audit"}
+
+	entry := &AuditEntry{}
+	entry.Request.API = PUT_OBJECT
+	entry.Request.Bucket = "bucket"
+	entry.Request.Object = "key"
+	entry.Response.StatusCode = http.StatusOK
+	data, err := json.Marshal(entry)
+	require.NoError(t, err)
+
+	claim := &testConsumerClaim{messages: make(chan *sarama.ConsumerMessage, 3)}
+	claim.messages <- &sarama.ConsumerMessage{Offset: 1, Value: data}
+	claim.messages <- &sarama.ConsumerMessage{Offset: 2, Value: []byte("invalid")}
+	claim.messages <- &sarama.ConsumerMessage{Offset: 3, Value: []byte("{}")}
+	close(claim.messages)
+	session := &testConsumerSession{ctx: context.Background()}
+	require.NoError(t, consumer.ConsumeClaim(session, claim))
+
+	// every entry is marked after it has been handled
+	require.Equal(t, []int64{1, 2, 3}, session.marked)
+	require.Equal(t, []string{"bucket"}, buckets)
+
+	// the entry failed by a transient error is not marked, and consumed again by the next session
+	r.getVol = func(bucket string) (*Volume, error) {
+		return nil, errors.New("volume unavailable")
+	}
+	claim = &testConsumerClaim{messages: make(chan *sarama.ConsumerMessage, 1)}
+	claim.messages <- &sarama.ConsumerMessage{Offset: 4, Value: data}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	session = &testConsumerSession{ctx: ctx}
+	require.NoError(t, consumer.ConsumeClaim(session, claim))
+	require.Empty(t, session.marked)
+}
+
+func TestReplicationConfigRequiresKafka(t *testing.T) {
+	conf := ReplicationConfig{}
+	require.Error(t, conf.FixConfig())
+	conf.Kafka = &ReplicationKafkaConfig{KafkaConfig: KafkaConfig{Brokers: "127.0.0.1:9092", Topic: "audit"}}
+	require.NoError(t, conf.FixConfig())
+}
@@ -0,0 +1,525 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/cubefs/cubefs/blobstore/util/retry"
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	replicationOpPut    = "put"
+	replicationOpDelete = "delete"
+
+	defaultReplicationRegion = "us-east-1"
+	// the object is buffered in memory before uploading to the remote target if it is not larger than this,
+	// otherwise it is spooled to a temporary file.
+	replicationMemoryBufferSize = 8 << 20 // 8MB
+)
+
+var errReplicationSSEC = errors.New("replication: object encrypted with customer provided key cannot be replicated")
+
+type ReplicationConfig struct {
+	TempDir string                             `json:"temp_dir"`
+	Targets map[string]ReplicationTargetConfig `json:"targets"`
+	// Kafka is the topic of the Kafka audit which the replicator consumes the object writes from. It's
+	// required, so that no write is lost when the replication falls behind or the objectnode restarts.
+	Kafka *ReplicationKafkaConfig `json:"kafka"`
+	// PeerAccessKeys are the access keys used by the other clusters to replicate objects to this cluster,
+	// the x-amz-replication-status header is trusted only if the request is signed by one of them.
+	PeerAccessKeys []string `json:"peer_access_keys"`
+}
+
+// ReplicationTargetConfig is the remote S3 endpoint which the objects can be replicated to.
+type ReplicationTargetConfig struct {
+	Endpoint  string          `json:"endpoint"`
+	Region    string          `json:"region"`
+	AccessKey string          `json:"access_key"`
+	SecretKey string          `json:"secret_key"`
+	Transport TransportConfig `json:"transport"`
+}
+
+// FixConfig validates and fixes the configuration.
+func (c *ReplicationConfig) FixConfig() error {
+	if c.Kafka == nil {
+		return errors.New("replication: kafka must be configured")
+	}
+	if err := c.Kafka.FixConfig(); err != nil {
+		return fmt.Errorf("replication: %v", err)
+	}
+	for name, target := range c.Targets {
+		if err := target.FixConfig(); err != nil {
+			return fmt.Errorf("replication: target '%s': %v", name, err)
+		}
+		c.Targets[name] = target
+	}
+	return nil
+}
+
+// FixConfig validates and fixes the configuration.
+func (c *ReplicationTargetConfig) FixConfig() error {
+	if c.Endpoint == "" {
+		return errors.New("no endpoint found")
+	}
+	u, err := url.Parse(c.Endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid endpoint '%s'", c.Endpoint)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+	default:
+		return fmt.Errorf("unsupported scheme in '%s'", c.Endpoint)
+	}
+	if c.AccessKey == "" || c.SecretKey == "" {
+		return errors.New("access_key and secret_key must be specified")
+	}
+	if c.Region == "" {
+		c.Region = defaultReplicationRegion
+	}
+	return c.Transport.FixConfig()
+}
+
+// replicaObject is the object to be written to the destination bucket.
+type replicaObject struct {
+	Key          string
+	Size         int64
+	MIMEType     string
+	Disposition  string
+	CacheControl string
+	Expires      string
+	Metadata     map[string]string
+	Tagging      *Tagging
+}
+
+type replicationTarget interface {
+	PutObject(bucket string, object *replicaObject, reader io.Reader) error
+	DeleteObject(bucket, key string) error
+}
+
+type replicationTask struct {
+	op     string
+	bucket string
+	object string
+}
+
+// newReplicationTask extracts the replication task from the audit entry of the request,
+// nil is returned if the request does not change any object.
+func newReplicationTask(entry *AuditEntry) *replicationTask {
+	if entry.Response.StatusCode/100 != 2 || entry.Request.Bucket == "" || entry.Request.Object == "" {
+		return nil
+	}
+	task := &replicationTask{
+		bucket: entry.Request.Bucket,
+		object: entry.Request.Object,
+	}
+	switch entry.Request.API {
+	case PUT_OBJECT, POST_OBJECT, COPY_OBJECT, COMPLETE_MULTIPART_UPLOAD:
+		task.op = replicationOpPut
+	case DELETE_OBJECT:
+		// the deletion of the specified version is never replicated
+		if entry.Request.Query[ParamVersionId] != "" {
+			return nil
+		}
+		task.op = replicationOpDelete
+	default:
+		return nil
+	}
+	return task
+}
+
+// Replicator replicates the objects to the destination buckets according to the replication configuration
+// of the bucket. It follows the object writes by consuming the audit entries from the topic of the Kafka
+// audit, so the writes not replicated yet are kept in Kafka rather than in memory.
+type Replicator struct {
+	getVol   func(bucket string) (*Volume, error)
+	local    replicationTarget
+	targets  map[string]replicationTarget
+	peers    map[string]struct{}
+	consumer *replicationConsumer
+	stopC    chan struct{}
+	once     sync.Once
+	wg       sync.WaitGroup
+}
+
+func NewReplicator(conf ReplicationConfig, getVol func(bucket string) (*Volume, error)) (*Replicator, error) {
+	if err := conf.FixConfig(); err != nil {
+		return nil, err
+	}
+	r := &Replicator{
+		getVol:  getVol,
+		local:   &localReplicationTarget{getVol: getVol},
+		targets: make(map[string]replicationTarget, len(conf.Targets)),
+		peers:   make(map[string]struct{}, len(conf.PeerAccessKeys)),
+		stopC:   make(chan struct{}),
+	}
+	for name, target := range conf.Targets {
+		st, err := newS3ReplicationTarget(target, conf.TempDir)
+		if err != nil {
+			return nil, fmt.Errorf("replication: target '%s': %v", name, err)
+		}
+		r.targets[name] = st
+	}
+	for _, accessKey := range conf.PeerAccessKeys {
+		r.peers[accessKey] = struct{}{}
+	}
+	consumer, err := newReplicationConsumer(r, *conf.Kafka)
+	if err != nil {
+		return nil, fmt.Errorf("replication: %v", err)
+	}
+	r.consumer = consumer
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		consumer.run(r.stopC)
+	}()
+	return r, nil
+}
+
+// isPeer checks whether the access key is used by another cluster to replicate objects to this cluster.
+func (r *Replicator) isPeer(accessKey string) bool {
+	_, ok := r.peers[accessKey]
+	return ok
+}
+
+func (r *Replicator) Close() error {
+	r.once.Do(func() {
+		close(r.stopC)
+		r.wg.Wait()
+		if r.consumer != nil {
+			_ = r.consumer.Close()
+		}
+	})
+	return nil
+}
+
+// checkDestination checks whether the destination bucket is available for the source bucket.
+func (r *Replicator) checkDestination(source string, dest *ReplicationDestination) error {
+	target, bucket, err := parseBucketArn(dest.Bucket)
+	if err != nil {
+		return newReplicationError(err)
+	}
+	if target != "" {
+		if _, ok := r.targets[target]; !ok {
+			return ReplicationUnknownTarget
+		}
+		return nil
+	}
+	if bucket == source {
+		return ReplicationInvalidDestination
+	}
+	if _, err = r.getVol(bucket); err != nil {
+		return ReplicationInvalidDestination
+	}
+	return nil
+}
+
+func (r *Replicator) destination(dest *ReplicationDestination) (replicationTarget, string, error) {
+	target, bucket, err := parseBucketArn(dest.Bucket)
+	if err != nil {
+		return nil, "", err
+	}
+	if target == "" {
+		return r.local, bucket, nil
+	}
+	rt, ok := r.targets[target]
+	if !ok {
+		return nil, "", fmt.Errorf("replication: unknown target '%s'", target)
+	}
+	return rt, bucket, nil
+}
+
+// replicate replicates the object of the task. The error is returned if the task can't be handled for
+// now and should be retried, the object which fails to be replicated is marked as FAILED instead.
+func (r *Replicator) replicate(task *replicationTask) error {
+	vol, err := r.getVol(task.bucket)
+	if err != nil {
+		if err == NoSuchBucket {
+			return nil
+		}
+		log.LogWarnf("replicator: load volume fail: bucket(%v) err(%v)", task.bucket, err)
+		return err
+	}
+	config, err := vol.metaLoader.loadReplication()
+	if err != nil {
+		log.LogErrorf("replicator: load replication config fail: bucket(%v) err(%v)", task.bucket, err)
+		return err
+	}
+	if config == nil {
+		return nil
+	}
+	switch task.op {
+	case replicationOpPut:
+		return r.replicateObject(vol, config, task.object)
+	case replicationOpDelete:
+		r.replicateDelete(vol, config, task.object)
+	}
+	return nil
+}
+
+func (r *Replicator) replicateObject(vol *Volume, config *ReplicationConfiguration, path string) error {
+	fileInfo, xattr, err := vol.ObjectMeta(path)
+	if err != nil {
+		if err == syscall.ENOENT {
+			return nil
+		}
+		log.LogErrorf("replicator: get object meta fail: volume(%v) path(%v) err(%v)", vol.Name(), path, err)
+		return err
+	}
+	if fileInfo.Mode.IsDir() {
+		return nil
+	}
+	// the replica is never replicated again to avoid replication loop
+	if string(xattr.Get(XAttrKeyOSSReplicationStatus)) == ReplicationStatusReplica {
+		return nil
+	}
+	tagging, _ := ParseTagging(string(xattr.Get(XAttrKeyOSSTagging)))
+	rule := config.matchRule(path, tagging)
+	if rule == nil {
+		return nil
+	}
+	if err = vol.setReplicationStatus(fileInfo.Inode, ReplicationStatusPending); err != nil {
+		return err
+	}
+
+	status := ReplicationStatusCompleted
+	target, bucket, err := r.destination(rule.Destination)
+	if err == nil {
+		object := &replicaObject{
+			Key:          path,
+			Size:         fileInfo.Size,
+			MIMEType:     fileInfo.MIMEType,
+			Disposition:  fileInfo.Disposition,
+			CacheControl: fileInfo.CacheControl,
+			Expires:      fileInfo.Expires,
+			Metadata:     fileInfo.Metadata,
+			Tagging:      tagging,
+		}
+		err = retry.ExponentialBackoff(3, 200).On(func() error {
+			return r.putReplica(vol, fileInfo, xattr, target, bucket, object)
+		})
+	}
+	if err != nil {
+		log.LogErrorf("replicator: replicate object fail: volume(%v) path(%v) destination(%v) err(%v)",
+			vol.Name(), path, rule.Destination.Bucket, err)
+		status = ReplicationStatusFailed
+	} else {
+		log.LogInfof("Audit: replicate object: volume(%v) path(%v) destination(%v)",
+			vol.Name(), path, rule.Destination.Bucket)
+	}
+	// the status is left PENDING if it fails to be set, and the object is replicated again
+	return vol.setReplicationStatus(fileInfo.Inode, status)
+}
+
+func (r *Replicator) putReplica(vol *Volume, fileInfo *FSFileInfo, xattr *proto.XAttrInfo, target replicationTarget,
+	bucket string, object *replicaObject) (err error) {
+	sse, err := loadSSEMeta(xattr)
+	if err != nil {
+		return
+	}
+	var dataKey []byte
+	if sse != nil {
+		if sse.Type == sseTypeC {
+			return errReplicationSSEC
+		}
+		if dataKey, err = objectDataKey(sse, nil); err != nil {
+			return
+		}
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		var err error
+		if fileInfo.Size > 0 {
+			var w io.Writer = writer
+			if sse != nil {
+				w = sse.decryptWriter(writer, dataKey, 0)
+			}
+			err = vol.readFile(fileInfo.Inode, uint64(fileInfo.Size), fileInfo.Path, w, 0, uint64(fileInfo.Size),
+				fileInfo.StorageClass)
+		}
+		writer.CloseWithError(err)
+	}()
+	defer reader.Close()
+
+	return target.PutObject(bucket, object, reader)
+}
+
+func (r *Replicator) replicateDelete(vol *Volume, config *ReplicationConfiguration, path string) {
+	// delete marker replication does not support the rules with tag filter,
+	// so the tags of the deleted object are not required.
+	rule := config.matchRule(path, nil)
+	if rule == nil || !rule.deleteMarkerEnabled() {
+		return
+	}
+	target, bucket, err := r.destination(rule.Destination)
+	if err == nil {
+		err = retry.ExponentialBackoff(3, 200).On(func() error {
+			return target.DeleteObject(bucket, path)
+		})
+	}
+	if err != nil {
+		log.LogErrorf("replicator: replicate delete fail: volume(%v) path(%v) destination(%v) err(%v)",
+			vol.Name(), path, rule.Destination.Bucket, err)
+		return
+	}
+	log.LogInfof("Audit: replicate delete: volume(%v) path(%v) destination(%v)",
+		vol.Name(), path, rule.Destination.Bucket)
+}
+
+// localReplicationTarget replicates the objects to the buckets in this cluster.
+type localReplicationTarget struct {
+	getVol func(bucket string) (*Volume, error)
+}
+
+func (t *localReplicationTarget) PutObject(bucket string, object *replicaObject, reader io.Reader) (err error) {
+	var vol *Volume
+	if vol, err = t.getVol(bucket); err != nil {
+		return
+	}
+	var encryption *ServerSideEncryptionConfiguration
+	if encryption, err = vol.metaLoader.loadEncryption(); err != nil {
+		return
+	}
+	opt := &PutFileOption{
+		MIMEType:          object.MIMEType,
+		Disposition:       object.Disposition,
+		Tagging:           object.Tagging,
+		Metadata:          object.Metadata,
+		CacheControl:      object.CacheControl,
+		Expires:           object.Expires,
+		SSE:               encryption.defaultSSEOption(),
+		ReplicationStatus: ReplicationStatusReplica,
+	}
+	_, err = vol.PutObject(object.Key, reader, opt)
+	return
+}
+
+func (t *localReplicationTarget) DeleteObject(bucket, key string) (err error) {
+	var vol *Volume
+	if vol, err = t.getVol(bucket); err != nil {
+		return
+	}
+	if _, _, err = vol.DeleteObjectVersion(key, "", false); err == syscall.ENOENT {
+		err = nil
+	}
+	return
+}
+
+// s3ReplicationTarget replicates the objects to the buckets of the remote S3 endpoint.
+type s3ReplicationTarget struct {
+	client  *s3.S3
+	tempDir string
+}
+
+func newS3ReplicationTarget(conf ReplicationTargetConfig, tempDir string) (*s3ReplicationTarget, error) {
+	transport, err := conf.Transport.BuildTransport()
+	if err != nil {
+		return nil, err
+	}
+	sess, err := session.NewSession(aws.NewConfig().
+		WithEndpoint(conf.Endpoint).
+		WithRegion(conf.Region).
+		WithCredentials(credentials.NewStaticCredentials(conf.AccessKey, conf.SecretKey, "")).
+		WithS3ForcePathStyle(true).
+		WithHTTPClient(&http.Client{Transport: transport}))
+	if err != nil {
+		return nil, err
+	}
+	return &s3ReplicationTarget{client: s3.New(sess), tempDir: tempDir}, nil
+}
+
+func (t *s3ReplicationTarget) PutObject(bucket string, object *replicaObject, reader io.Reader) (err error) {
+	// the request body must be seekable to be signed
+	var body io.ReadSeeker
+	if object.Size <= replicationMemoryBufferSize {
+		var data []byte
+		if data, err = io.ReadAll(reader); err != nil {
+			return
+		}
+		body = bytes.NewReader(data)
+	} else {
+		var f *os.File
+		if f, err = os.CreateTemp(t.tempDir, "replication-"); err != nil {
+			return
+		}
+		defer func() {
+			f.Close()
+			os.Remove(f.Name())
+		}()
+		if _, err = io.Copy(f, reader); err != nil {
+			return
+		}
+		if _, err = f.Seek(0, io.SeekStart); err != nil {
+			return
+		}
+		body = f
+	}
+
+	input := &s3.PutObjectInput{
+		Bucket:        aws.String(bucket),
+		Key:           aws.String(object.Key),
+		Body:          body,
+		ContentLength: aws.Int64(object.Size),
+	}
+	if object.MIMEType != "" {
+		input.ContentType = aws.String(object.MIMEType)
+	}
+	if object.Disposition != "" {
+		input.ContentDisposition = aws.String(object.Disposition)
+	}
+	if object.CacheControl != "" {
+		input.CacheControl = aws.String(object.CacheControl)
+	}
+	if object.Expires != "" {
+		if expires, err := parseTimeRFC1123(object.Expires); err == nil {
+			input.Expires = aws.Time(expires)
+		}
+	}
+	if len(object.Metadata) > 0 {
+		input.Metadata = aws.StringMap(object.Metadata)
+	}
+	if object.Tagging != nil && len(object.Tagging.TagSet) > 0 {
+		input.Tagging = aws.String(object.Tagging.Encode())
+	}
+	req, _ := t.client.PutObjectRequest(input)
+	// mark the object as replica, so the destination which is also a CubeFS cluster never replicates it again
+	req.HTTPRequest.Header.Set(XAmzReplicationStatus, ReplicationStatusReplica)
+	return req.Send()
+}
+
+func (t *s3ReplicationTarget) DeleteObject(bucket, key string) error {
+	_, err := t.client.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
@@ -0,0 +1,266 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/restoring-objects.html
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	MaxRestoreRequestSize = 1 << 10
+	MaxRestoreDays        = 36500
+
+	RestoreTierStandard  = "Standard"
+	RestoreTierBulk      = "Bulk"
+	RestoreTierExpedited = "Expedited"
+)
+
+type RestoreRequest struct {
+	XMLName              xml.Name              `xml:"RestoreRequest"`
+	Days                 int                   `xml:"Days"`
+	Type                 string                `xml:"Type,omitempty"`
+	GlacierJobParameters *GlacierJobParameters `xml:"GlacierJobParameters,omitempty"`
+}
+
+type GlacierJobParameters struct {
+	Tier string `xml:"Tier"`
+}
+
+func ParseRestoreRequestFromXML(data []byte) (*RestoreRequest, error) {
+	req := &RestoreRequest{}
+	if err := xml.Unmarshal(data, req); err != nil {
+		return nil, MalformedXML
+	}
+	// the restore of select type is not supported
+	if req.Type != "" {
+		return nil, UnsupportedOperation
+	}
+	if req.Days < 1 || req.Days > MaxRestoreDays {
+		return nil, MalformedXML
+	}
+	// all tiers are accepted, the object is always restored before the response
+	if req.GlacierJobParameters != nil {
+		switch req.GlacierJobParameters.Tier {
+		case RestoreTierStandard, RestoreTierBulk, RestoreTierExpedited:
+		default:
+			return nil, MalformedXML
+		}
+	}
+	return req, nil
+}
+
+// restoreExpiryDate returns the expiry date of the restored copy, which is rounded up to
+// the next midnight UTC after the days.
+func restoreExpiryDate(now time.Time, days int) time.Time {
+	t := now.UTC().AddDate(0, 0, days)
+	return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+}
+
+// restoreState returns the restored copy of the object if it has not expired at the time, otherwise
+// the expired copy which has not been released by lcnode yet. Both are nil if the object is not restored.
+func restoreState(xattr *proto.XAttrInfo, now time.Time) (valid, expired *proto.ObjectRestore) {
+	if xattr == nil {
+		return
+	}
+	value := xattr.Get(proto.XAttrKeyOSSRestore)
+	if len(value) == 0 {
+		return
+	}
+	restore, err := proto.ParseObjectRestore(value)
+	if err != nil {
+		log.LogWarnf("restoreState: parse restore fail: inode(%v) restore(%v) err(%v)", xattr.Inode, string(value), err)
+		return
+	}
+	if restore.Expired(now) {
+		return nil, restore
+	}
+	return restore, nil
+}
+
+// restoredCopy returns the restored copy of the object if it has not expired.
+func restoredCopy(xattr *proto.XAttrInfo) *proto.ObjectRestore {
+	restore, _ := restoreState(xattr, time.Now())
+	return restore
+}
+
+func restoreHeaderValue(restore *proto.ObjectRestore) string {
+	return fmt.Sprintf("ongoing-request=\"false\", expiry-date=\"%s\"", formatTimeRFC1123(time.Unix(restore.ExpiryDate, 0)))
+}
+
+// setRestoreHeader sets the storage class of the object in blobstore, and the restore status if it is restored.
+func setRestoreHeader(w http.ResponseWriter, info *FSFileInfo, xattr *proto.XAttrInfo) {
+	if !proto.IsStorageClassBlobStore(info.StorageClass) {
+		return
+	}
+	w.Header().Set(XAmzStorageClass, proto.OpTypeStorageClassEBS)
+	if restore := restoredCopy(xattr); restore != nil {
+		w.Header().Set(XAmzRestore, restoreHeaderValue(restore))
+	}
+}
+
+// RestoreObject copies the data of the object in blobstore to a temporary replica copy, which is
+// released by lcnode after the days. If the object has been restored, only the expiry date is extended.
+func (v *Volume) RestoreObject(path string, info *FSFileInfo, days int) (restored bool, err error) {
+	defer func() {
+		log.LogInfof("Audit: RestoreObject: volume(%v) path(%v) inode(%v) days(%v) restored(%v) err(%v)",
+			v.name, path, info.Inode, days, restored, err)
+	}()
+
+	if info.Mode.IsDir() || proto.IsCold(v.volType) || !proto.IsStorageClassBlobStore(info.StorageClass) {
+		return false, InvalidObjectState
+	}
+	// the copy is written in the default storage class of the volume, which must be replica
+	if !proto.IsStorageClassReplica(v.mw.GetStorageClass()) {
+		log.LogErrorf("RestoreObject: default storage class is not replica: volume(%v) path(%v) storageClass(%v)",
+			v.name, path, proto.StorageClassString(v.mw.GetStorageClass()))
+		return false, InvalidObjectState
+	}
+	now := time.Now()
+	expiry := restoreExpiryDate(now, days)
+
+	// the cached xattr may be stale, the restore state is always loaded from meta
+	var xattr *proto.XAttrInfo
+	if xattr, err = v.mw.XAttrGet_ll(info.Inode, proto.XAttrKeyOSSRestore); err != nil {
+		log.LogErrorf("RestoreObject: get restore fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, info.Inode, err)
+		return
+	}
+	restore, expired := restoreState(xattr, now)
+	if restore != nil {
+		restore.ExpiryDate = expiry.Unix()
+		err = v.setObjectRestore(info.Inode, restore)
+		return true, err
+	}
+
+	// the expired copy is replaced by the new one
+	if restore, err = v.copyToReplica(path, info); err != nil {
+		return
+	}
+	restore.ExpiryDate = expiry.Unix()
+	if err = v.setObjectRestore(info.Inode, restore); err != nil {
+		v.releaseRestoredInode(restore.Inode, path)
+		return
+	}
+	if expired != nil {
+		v.releaseRestoredInode(expired.Inode, path)
+	}
+	return false, nil
+}
+
+// copyToReplica writes the data of the object to a new inode without dentry.
+func (v *Volume) copyToReplica(path string, info *FSFileInfo) (restore *proto.ObjectRestore, err error) {
+	var tempInode *proto.InodeInfo
+	if tempInode, err = v.mw.InodeCreate_ll(0, DefaultFileMode, 0, 0, nil, make([]uint64, 0), path); err != nil {
+		log.LogErrorf("copyToReplica: inode create fail: volume(%v) path(%v) err(%v)", v.name, path, err)
+		return
+	}
+	defer func() {
+		if err != nil {
+			v.releaseRestoredInode(tempInode.Inode, path)
+		}
+	}()
+
+	if err = v.ec.OpenStream(tempInode.Inode, true, false, path); err != nil {
+		log.LogErrorf("copyToReplica: open stream fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, tempInode.Inode, err)
+		return
+	}
+	defer func() {
+		if closeErr := v.ec.CloseStream(tempInode.Inode); closeErr != nil {
+			log.LogErrorf("copyToReplica: close stream fail: volume(%v) inode(%v) err(%v)",
+				v.name, tempInode.Inode, closeErr)
+		}
+	}()
+
+	size := uint64(info.Size)
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(v.readFile(info.Inode, size, path, writer, 0, size, info.StorageClass))
+	}()
+	defer reader.Close()
+
+	if _, err = v.streamWrite(tempInode.Inode, reader, md5.New(), tempInode.StorageClass); err != nil {
+		log.LogErrorf("copyToReplica: stream write fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, tempInode.Inode, err)
+		return
+	}
+	if err = v.ec.Flush(tempInode.Inode); err != nil {
+		log.LogErrorf("copyToReplica: data flush inode fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, tempInode.Inode, err)
+		return
+	}
+	return &proto.ObjectRestore{Inode: tempInode.Inode, StorageClass: tempInode.StorageClass}, nil
+}
+
+func (v *Volume) setObjectRestore(inode uint64, restore *proto.ObjectRestore) (err error) {
+	var data []byte
+	if data, err = json.Marshal(restore); err != nil {
+		return
+	}
+	if err = v.mw.XAttrSet_ll(inode, []byte(proto.XAttrKeyOSSRestore), data); err != nil {
+		log.LogErrorf("setObjectRestore: set xattr fail: volume(%v) inode(%v) restore(%v) err(%v)",
+			v.name, inode, string(data), err)
+		return
+	}
+	updateAttrCache(inode, proto.XAttrKeyOSSRestore, string(data), v.name)
+	return
+}
+
+// releaseRestoredCopy releases the restored copy of the object inode to be deleted.
+func (v *Volume) releaseRestoredCopy(inode uint64, path string) {
+	if proto.IsCold(v.volType) {
+		return
+	}
+	xattr, err := v.mw.XAttrGet_ll(inode, proto.XAttrKeyOSSRestore)
+	if err != nil {
+		log.LogWarnf("releaseRestoredCopy: get restore fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, inode, err)
+		return
+	}
+	value := xattr.Get(proto.XAttrKeyOSSRestore)
+	if len(value) == 0 {
+		return
+	}
+	restore, err := proto.ParseObjectRestore(value)
+	if err != nil {
+		log.LogWarnf("releaseRestoredCopy: parse restore fail: volume(%v) path(%v) inode(%v) restore(%v) err(%v)",
+			v.name, path, inode, string(value), err)
+		return
+	}
+	v.releaseRestoredInode(restore.Inode, path)
+}
+
+func (v *Volume) releaseRestoredInode(inode uint64, path string) {
+	log.LogInfof("releaseRestoredInode: release restored copy: volume(%v) path(%v) inode(%v)", v.name, path, inode)
+	if _, err := v.mw.InodeUnlink_ll(inode, path); err != nil {
+		log.LogWarnf("releaseRestoredInode: unlink inode fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, inode, err)
+	}
+	if err := v.mw.Evict(inode, path); err != nil {
+		log.LogWarnf("releaseRestoredInode: evict inode fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, inode, err)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"io"
+	"net/http"
+	"syscall"
+
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// Restore object
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_RestoreObject.html
+func (o *ObjectNode) restoreObjectHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	if param.Object() == "" {
+		errorCode = InvalidKey
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("restoreObjectHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var body []byte
+	if body, err = io.ReadAll(io.LimitReader(r.Body, MaxRestoreRequestSize+1)); err != nil {
+		log.LogErrorf("restoreObjectHandler: read request body fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+	if len(body) > MaxRestoreRequestSize {
+		errorCode = EntityTooLarge
+		return
+	}
+	var req *RestoreRequest
+	if req, err = ParseRestoreRequestFromXML(body); err != nil {
+		log.LogErrorf("restoreObjectHandler: parse restore request fail: requestID(%v) volume(%v) path(%v) body(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), string(body), err)
+		return
+	}
+
+	versionId := r.URL.Query().Get(ParamVersionId)
+	var fileInfo *FSFileInfo
+	if fileInfo, _, err = vol.ObjectVersionMeta(param.Object(), versionId); err != nil {
+		log.LogErrorf("restoreObjectHandler: get file meta fail: requestID(%v) volume(%v) path(%v) versionId(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), versionId, err)
+		if err == syscall.ENOENT {
+			errorCode = NoSuchKey
+		}
+		return
+	}
+
+	var restored bool
+	if restored, err = vol.RestoreObject(param.Object(), fileInfo, req.Days); err != nil {
+		log.LogErrorf("restoreObjectHandler: restore object fail: requestID(%v) volume(%v) path(%v) days(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), req.Days, err)
+		return
+	}
+
+	// 200 if the object has been restored, otherwise 202
+	if !restored {
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
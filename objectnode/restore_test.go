@@ -0,0 +1,149 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRestoreRequest(t *testing.T) {
+	tests := []struct {
+		value string
+		err   error
+	}{
+		{
+			value: `<RestoreRequest xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Days>2</Days>
+						<GlacierJobParameters><Tier>Bulk</Tier></GlacierJobParameters></RestoreRequest>`,
+		},
+		{value: `<RestoreRequest><Days>1</Days></RestoreRequest>`},
+		{value: `<RestoreRequest></RestoreRequest>`, err: MalformedXML},
+		{value: `<RestoreRequest><Days>0</Days></RestoreRequest>`, err: MalformedXML},
+		{value: `<RestoreRequest><Days>1</Days><GlacierJobParameters><Tier>Fast</Tier></GlacierJobParameters></RestoreRequest>`, err: MalformedXML},
+		{value: `<RestoreRequest><Type>SELECT</Type></RestoreRequest>`, err: UnsupportedOperation},
+		{value: `<RestoreRequest>`, err: MalformedXML},
+	}
+	for i, tc := range tests {
+		_, err := ParseRestoreRequestFromXML([]byte(tc.value))
+		if tc.err == nil {
+			require.NoError(t, err, "case %d", i)
+		} else {
+			require.Equal(t, tc.err, err, "case %d", i)
+		}
+	}
+}
+
+func TestRestoreExpiryDate(t *testing.T) {
+	now := time.Date(2024, 12, 30, 15, 4, 5, 0, time.FixedZone("UTC+8", 8*3600))
+	require.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), restoreExpiryDate(now, 1))
+	require.Equal(t, time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC), restoreExpiryDate(now, 31))
+}
+
+func TestRestoreHeader(t *testing.T) {
+	expiry := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	require.Equal(t, `ongoing-request="false", expiry-date="Wed, 01 Jan 2025 00:00:00 GMT"`,
+		restoreHeaderValue(&proto.ObjectRestore{Inode: 2, ExpiryDate: expiry}))
+
+	xattr := &proto.XAttrInfo{Inode: 1, XAttrs: map[string]string{}}
+	require.Nil(t, restoredCopy(nil))
+	require.Nil(t, restoredCopy(xattr))
+	xattr.XAttrs[proto.XAttrKeyOSSRestore] = fmt.Sprintf(`{"inode":2,"expiry_date":%d}`, expiry)
+	require.Nil(t, restoredCopy(xattr))
+	expiry = time.Now().Add(time.Hour).Unix()
+	xattr.XAttrs[proto.XAttrKeyOSSRestore] = fmt.Sprintf(`{"inode":2,"storage_class":1,"expiry_date":%d}`, expiry)
+	require.Equal(t, &proto.ObjectRestore{Inode: 2, StorageClass: proto.StorageClass_Replica_SSD, ExpiryDate: expiry}, restoredCopy(xattr))
+
+	w := httptest.NewRecorder()
+	setRestoreHeader(w, &FSFileInfo{StorageClass: proto.StorageClass_Replica_SSD}, xattr)
+	require.Empty(t, w.Header().Get(XAmzStorageClass))
+	require.Empty(t, w.Header().Get(XAmzRestore))
+	setRestoreHeader(w, &FSFileInfo{StorageClass: proto.StorageClass_BlobStore}, xattr)
+	require.Equal(t, proto.OpTypeStorageClassEBS, w.Header().Get(XAmzStorageClass))
+	require.Equal(t, restoreHeaderValue(restoredCopy(xattr)), w.Header().Get(XAmzRestore))
+}
+
+func TestRestoreState(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	xattr := &proto.XAttrInfo{Inode: 1, XAttrs: map[string]string{}}
+
+	// not restored
+	valid, expired := restoreState(xattr, now)
+	require.Nil(t, valid)
+	require.Nil(t, expired)
+
+	// restored, the copy is valid until the expiry date
+	expiry := now.Add(time.Hour).Unix()
+	xattr.XAttrs[proto.XAttrKeyOSSRestore] = fmt.Sprintf(`{"inode":2,"expiry_date":%d}`, expiry)
+	valid, expired = restoreState(xattr, now)
+	require.Equal(t, &proto.ObjectRestore{Inode: 2, ExpiryDate: expiry}, valid)
+	require.Nil(t, expired)
+
+	// expired, the copy is kept for the release until it is replaced
+	valid, expired = restoreState(xattr, now.Add(time.Hour))
+	require.Nil(t, valid)
+	require.Equal(t, &proto.ObjectRestore{Inode: 2, ExpiryDate: expiry}, expired)
+
+	// the broken state is treated as not restored
+	xattr.XAttrs[proto.XAttrKeyOSSRestore] = "{"
+	valid, expired = restoreState(xattr, now)
+	require.Nil(t, valid)
+	require.Nil(t, expired)
+}
+
+func TestRestoreObjectInvalidState(t *testing.T) {
+	v := newTestVolume("bucket")
+	atomic.StoreUint32(&v.mw.DefaultStorageClass, proto.StorageClass_Replica_HDD)
+	blobstore := &FSFileInfo{Inode: 1, StorageClass: proto.StorageClass_BlobStore}
+
+	_, err := v.RestoreObject("dir", &FSFileInfo{Inode: 1, Mode: os.ModeDir, StorageClass: proto.StorageClass_BlobStore}, 1)
+	require.Equal(t, InvalidObjectState, err)
+	_, err = v.RestoreObject("key", &FSFileInfo{Inode: 1, StorageClass: proto.StorageClass_Replica_HDD}, 1)
+	require.Equal(t, InvalidObjectState, err)
+
+	// the restored copy can not be written in the cold volume or the default storage class of blobstore
+	v.volType = proto.VolumeTypeCold
+	_, err = v.RestoreObject("key", blobstore, 1)
+	require.Equal(t, InvalidObjectState, err)
+	v.volType = proto.VolumeTypeHot
+	atomic.StoreUint32(&v.mw.DefaultStorageClass, proto.StorageClass_BlobStore)
+	_, err = v.RestoreObject("key", blobstore, 1)
+	require.Equal(t, InvalidObjectState, err)
+}
+
+func TestRestoreObjectHandler(t *testing.T) {
+	router := newTestRouter(newTestVolume("bucket"))
+	tests := []struct {
+		body string
+		ec   *ErrorCode
+	}{
+		{body: strings.Repeat(" ", MaxRestoreRequestSize+1), ec: EntityTooLarge},
+		{body: `<RestoreRequest><Days>0</Days></RestoreRequest>`, ec: MalformedXML},
+		{body: `<RestoreRequest><Type>SELECT</Type></RestoreRequest>`, ec: UnsupportedOperation},
+	}
+	for _, tc := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "http://bucket.cube.io/key?restore", strings.NewReader(tc.body)))
+		requireErrorResponse(t, tc.ec, w)
+	}
+}
@@ -97,11 +97,15 @@ type InitMultipartResult struct {
 }
 
 type CompleteMultipartResult struct {
-	XMLName  xml.Name `xml:"CompleteMultipartUploadResult"`
-	Location string   `xml:"Location"`
-	Bucket   string   `xml:"Bucket"`
-	Key      string   `xml:"Key"`
-	ETag     string   `xml:"ETag"`
+	XMLName        xml.Name `xml:"CompleteMultipartUploadResult"`
+	Location       string   `xml:"Location"`
+	Bucket         string   `xml:"Bucket"`
+	Key            string   `xml:"Key"`
+	ETag           string   `xml:"ETag"`
+	ChecksumCRC32  string   `xml:"ChecksumCRC32,omitempty"`
+	ChecksumCRC32C string   `xml:"ChecksumCRC32C,omitempty"`
+	ChecksumSHA1   string   `xml:"ChecksumSHA1,omitempty"`
+	ChecksumSHA256 string   `xml:"ChecksumSHA256,omitempty"`
 }
 
 type Initiator struct {
@@ -190,6 +194,45 @@ type ListBucketResult struct {
 	CommonPrefixes []*CommonPrefix `xml:"CommonPrefixes"`
 }
 
+type VersionEntry struct {
+	XMLName      xml.Name     `xml:"Version"`
+	Key          string       `xml:"Key"`
+	VersionId    string       `xml:"VersionId"`
+	IsLatest     bool         `xml:"IsLatest"`
+	LastModified string       `xml:"LastModified"`
+	ETag         string       `xml:"ETag"`
+	Size         int          `xml:"Size"`
+	StorageClass string       `xml:"StorageClass"`
+	Owner        *BucketOwner `xml:"Owner,omitempty"`
+}
+
+type DeleteMarkerEntry struct {
+	XMLName      xml.Name     `xml:"DeleteMarker"`
+	Key          string       `xml:"Key"`
+	VersionId    string       `xml:"VersionId"`
+	IsLatest     bool         `xml:"IsLatest"`
+	LastModified string       `xml:"LastModified"`
+	Owner        *BucketOwner `xml:"Owner,omitempty"`
+}
+
+// ListVersionsResult keeps versions and delete markers in a single slice
+// so that they are encoded in the order of key and version.
+type ListVersionsResult struct {
+	XMLName             xml.Name        `xml:"ListVersionsResult"`
+	Bucket              string          `xml:"Name"`
+	Prefix              string          `xml:"Prefix"`
+	KeyMarker           string          `xml:"KeyMarker"`
+	VersionIdMarker     string          `xml:"VersionIdMarker"`
+	MaxKeys             int             `xml:"MaxKeys"`
+	Delimiter           string          `xml:"Delimiter,omitempty"`
+	EncodingType        string          `xml:"EncodingType,omitempty"`
+	IsTruncated         bool            `xml:"IsTruncated"`
+	NextKeyMarker       string          `xml:"NextKeyMarker,omitempty"`
+	NextVersionIdMarker string          `xml:"NextVersionIdMarker,omitempty"`
+	Versions            []interface{}   `xml:",any"`
+	CommonPrefixes      []*CommonPrefix `xml:"CommonPrefixes"`
+}
+
 func NewParts(fsParts []*FSPart) []*Part {
 	parts := make([]*Part, 0)
 	for _, fsPart := range fsParts {
@@ -248,9 +291,13 @@ type DeleteRequest struct {
 }
 
 type CopyResult struct {
-	XMLName      xml.Name `xml:"CopyObjectResult"`
-	LastModified string   `xml:"LastModified,omitempty"`
-	ETag         string   `xml:"ETag,omitempty"`
+	XMLName        xml.Name `xml:"CopyObjectResult"`
+	LastModified   string   `xml:"LastModified,omitempty"`
+	ETag           string   `xml:"ETag,omitempty"`
+	ChecksumCRC32  string   `xml:"ChecksumCRC32,omitempty"`
+	ChecksumCRC32C string   `xml:"ChecksumCRC32C,omitempty"`
+	ChecksumSHA1   string   `xml:"ChecksumSHA1,omitempty"`
+	ChecksumSHA256 string   `xml:"ChecksumSHA256,omitempty"`
 }
 
 type ListBucketResultV2 struct {
@@ -389,9 +436,28 @@ type ListXAttrsOutput struct {
 }
 
 type PartRequest struct {
-	XMLName    xml.Name `xml:"Part"`
-	PartNumber int      `xml:"PartNumber"`
-	ETag       string   `xml:"ETag"`
+	XMLName        xml.Name `xml:"Part"`
+	PartNumber     int      `xml:"PartNumber"`
+	ETag           string   `xml:"ETag"`
+	ChecksumCRC32  string   `xml:"ChecksumCRC32,omitempty"`
+	ChecksumCRC32C string   `xml:"ChecksumCRC32C,omitempty"`
+	ChecksumSHA1   string   `xml:"ChecksumSHA1,omitempty"`
+	ChecksumSHA256 string   `xml:"ChecksumSHA256,omitempty"`
+}
+
+// Checksum returns the checksum of the part with the algorithm.
+func (p *PartRequest) Checksum(algorithm string) string {
+	switch algorithm {
+	case ChecksumAlgorithmCRC32:
+		return p.ChecksumCRC32
+	case ChecksumAlgorithmCRC32C:
+		return p.ChecksumCRC32C
+	case ChecksumAlgorithmSHA1:
+		return p.ChecksumSHA1
+	case ChecksumAlgorithmSHA256:
+		return p.ChecksumSHA256
+	}
+	return ""
 }
 
 type CompleteMultipartUploadRequest struct {
@@ -63,10 +63,17 @@ var (
 	AccessDeniedBySTS                   = &ErrorCode{ErrorCode: "AccessDeniedBySTS", ErrorMessage: "Access Denied by STS.", StatusCode: http.StatusForbidden}
 	InvalidToken                        = &ErrorCode{ErrorCode: "InvalidToken", ErrorMessage: "The provided token is malformed or otherwise invalid.", StatusCode: http.StatusBadRequest}
 	ExpiredToken                        = &ErrorCode{ErrorCode: "ExpiredToken", ErrorMessage: "The provided token has expired.", StatusCode: http.StatusBadRequest}
+	InvalidIdentityToken                = &ErrorCode{ErrorCode: "InvalidIdentityToken", ErrorMessage: "The web identity token that was passed could not be validated.", StatusCode: http.StatusBadRequest}
+	ExpiredIdentityToken                = &ErrorCode{ErrorCode: "ExpiredTokenException", ErrorMessage: "The web identity token that was passed is expired.", StatusCode: http.StatusBadRequest}
+	IDPCommunicationError               = &ErrorCode{ErrorCode: "IDPCommunicationError", ErrorMessage: "The identity provider could not be reached to verify the web identity token.", StatusCode: http.StatusBadRequest}
+	InvalidDurationSeconds              = &ErrorCode{ErrorCode: "ValidationError", ErrorMessage: "The requested DurationSeconds is out of the range allowed for the role.", StatusCode: http.StatusBadRequest}
+	WebIdentityNotEnabled               = &ErrorCode{ErrorCode: "NotImplemented", ErrorMessage: "AssumeRoleWithWebIdentity is not enabled.", StatusCode: http.StatusNotImplemented}
 	MissingSecurityElement              = &ErrorCode{ErrorCode: "MissingSecurityElement", ErrorMessage: "The request is missing a security element.", StatusCode: http.StatusBadRequest}
 	RequestTimeTooSkewed                = &ErrorCode{ErrorCode: "RequestTimeTooSkewed", ErrorMessage: "The difference between the request time and the server's time is too large.", StatusCode: http.StatusBadRequest}
 	NoSuchTagSetError                   = &ErrorCode{ErrorCode: "NoSuchTagSetError", ErrorMessage: "The TagSet does not exist.", StatusCode: http.StatusNotFound}
 	MissingTagInBody                    = &ErrorCode{ErrorCode: "MissingTagInBody", ErrorMessage: "Missing tag in body.", StatusCode: http.StatusBadRequest}
+	NoSuchWebsiteConfiguration          = &ErrorCode{ErrorCode: "NoSuchWebsiteConfiguration", ErrorMessage: "The specified bucket does not have a website configuration.", StatusCode: http.StatusNotFound}
+	NoSuchPublicAccessBlock             = &ErrorCode{ErrorCode: "NoSuchPublicAccessBlockConfiguration", ErrorMessage: "The public access block configuration was not found.", StatusCode: http.StatusNotFound}
 	NoSuchCORSConfiguration             = &ErrorCode{ErrorCode: "NoSuchCORSConfiguration", ErrorMessage: "The CORS configuration does not exist.", StatusCode: http.StatusNotFound}
 	CORSRuleNotMatch                    = &ErrorCode{ErrorCode: "AccessForbidden", ErrorMessage: "CORSResponse: This CORS request is not allowed.", StatusCode: http.StatusForbidden}
 	ErrCORSNotEnabled                   = &ErrorCode{ErrorCode: "AccessForbidden", ErrorMessage: "CORSResponse: CORS is not enabled for this bucket.", StatusCode: http.StatusForbidden}
@@ -91,6 +98,70 @@ var (
 	TooManyRequests                     = &ErrorCode{"TooManyRequests", "too many requests, please retry later", http.StatusTooManyRequests}
 	MalformedPOSTRequest                = &ErrorCode{ErrorCode: "MalformedPOSTRequest", ErrorMessage: "The body of your POST request is not well-formed multipart/form-data.", StatusCode: http.StatusBadRequest}
 	DuplicateVol                        = &ErrorCode{ErrorCode: "DuplicateVol", ErrorMessage: "Duplicate Vol", StatusCode: http.StatusBadRequest}
+	NoSuchVersion                       = &ErrorCode{ErrorCode: "NoSuchVersion", ErrorMessage: "The specified version does not exist.", StatusCode: http.StatusNotFound}
+	MethodNotAllowed                    = &ErrorCode{ErrorCode: "MethodNotAllowed", ErrorMessage: "The specified method is not allowed against this resource.", StatusCode: http.StatusMethodNotAllowed}
+	IllegalVersioningConfiguration      = &ErrorCode{ErrorCode: "IllegalVersioningConfigurationException", ErrorMessage: "The versioning configuration specified in the request is invalid.", StatusCode: http.StatusBadRequest}
+	InvalidBucketState                  = &ErrorCode{ErrorCode: "InvalidBucketState", ErrorMessage: "The request is not valid with the current state of the bucket.", StatusCode: http.StatusConflict}
+	ObjectLockVersioningNotEnabled      = &ErrorCode{ErrorCode: "InvalidBucketState", ErrorMessage: "Versioning must be 'Enabled' on the bucket to apply a Object Lock configuration.", StatusCode: http.StatusConflict}
+	NoSuchEncryptionConfiguration       = &ErrorCode{ErrorCode: "ServerSideEncryptionConfigurationNotFoundError", ErrorMessage: "The server side encryption configuration was not found.", StatusCode: http.StatusNotFound}
+	SSES3NotEnabled                     = &ErrorCode{ErrorCode: "NotImplemented", ErrorMessage: "Server side encryption with managed keys is not enabled.", StatusCode: http.StatusNotImplemented}
+	SSEKMSNotSupported                  = &ErrorCode{ErrorCode: "NotImplemented", ErrorMessage: "Server side encryption with KMS managed keys is not supported.", StatusCode: http.StatusNotImplemented}
+	InvalidEncryptionAlgorithm          = &ErrorCode{ErrorCode: "InvalidEncryptionAlgorithmError", ErrorMessage: "The encryption request you specified is not valid. The valid value is AES256.", StatusCode: http.StatusBadRequest}
+	InvalidSSEHeaders                   = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "Server side encryption with customer provided keys cannot be used together with other server side encryption.", StatusCode: http.StatusBadRequest}
+	InvalidSSECustomerKey               = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "The secret key was invalid for the specified algorithm.", StatusCode: http.StatusBadRequest}
+	SSECustomerKeyMD5Mismatch           = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "The calculated MD5 hash of the key did not match the hash that was provided.", StatusCode: http.StatusBadRequest}
+	SSECustomerKeyRequired              = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "The object was stored using a form of Server Side Encryption. The correct parameters must be provided to retrieve the object.", StatusCode: http.StatusBadRequest}
+	SSEParametersNotApplicable          = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "The encryption parameters are not applicable to this object.", StatusCode: http.StatusBadRequest}
+	NoSuchReplicationConfiguration      = &ErrorCode{ErrorCode: "ReplicationConfigurationNotFoundError", ErrorMessage: "The replication configuration was not found.", StatusCode: http.StatusNotFound}
+	ReplicationNotEnabled               = &ErrorCode{ErrorCode: "NotImplemented", ErrorMessage: "Bucket replication is not enabled.", StatusCode: http.StatusNotImplemented}
+	ReplicationVersioningRequired       = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "Versioning must be 'Enabled' on the bucket to apply a replication configuration.", StatusCode: http.StatusBadRequest}
+	ReplicationInvalidDestination       = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "Destination bucket must exist and must be different from the source bucket.", StatusCode: http.StatusBadRequest}
+	ReplicationUnknownTarget            = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "The remote target of the replication destination is not configured.", StatusCode: http.StatusBadRequest}
+	NotificationNotEnabled              = &ErrorCode{ErrorCode: "NotImplemented", ErrorMessage: "Bucket notification is not enabled.", StatusCode: http.StatusNotImplemented}
+	BucketLoggingNotEnabled             = &ErrorCode{ErrorCode: "NotImplemented", ErrorMessage: "Bucket logging is not enabled.", StatusCode: http.StatusNotImplemented}
+	InvalidLoggingTargetBucket          = &ErrorCode{ErrorCode: "InvalidTargetBucketForLogging", ErrorMessage: "The target bucket for logging does not exist.", StatusCode: http.StatusBadRequest}
+	LoggingTargetOwnerMismatch          = &ErrorCode{ErrorCode: "InvalidTargetBucketForLogging", ErrorMessage: "The owner for the bucket to be logged and the target bucket must be the same.", StatusCode: http.StatusBadRequest}
+	NoSuchInventoryConfiguration        = &ErrorCode{ErrorCode: "NoSuchConfiguration", ErrorMessage: "The specified inventory configuration does not exist.", StatusCode: http.StatusNotFound}
+	InventoryIdMismatch                 = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "The Id of the inventory configuration does not match the id parameter.", StatusCode: http.StatusBadRequest}
+	InvalidInventoryDestination         = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "The destination bucket of the inventory does not exist.", StatusCode: http.StatusBadRequest}
+	InventoryDestinationOwnerMismatch   = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "The owner for the source bucket and the destination bucket of the inventory must be the same.", StatusCode: http.StatusBadRequest}
+	InventoryNotImplemented             = &ErrorCode{ErrorCode: "NotImplemented", ErrorMessage: "The ORC format and the inventory of all object versions are not supported.", StatusCode: http.StatusNotImplemented}
+	BucketMetricsNotEnabled             = &ErrorCode{ErrorCode: "NotImplemented", ErrorMessage: "Bucket metrics is not enabled.", StatusCode: http.StatusNotImplemented}
+	NoSuchMetricsConfiguration          = &ErrorCode{ErrorCode: "NoSuchConfiguration", ErrorMessage: "The specified metrics configuration does not exist.", StatusCode: http.StatusNotFound}
+	MetricsIdMismatch                   = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "The Id of the metrics configuration does not match the id parameter.", StatusCode: http.StatusBadRequest}
+	InvalidMetricsId                    = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "The Id of the metrics configuration is invalid.", StatusCode: http.StatusBadRequest}
+	MetricsFilterNotSupported           = &ErrorCode{ErrorCode: "NotImplemented", ErrorMessage: "Only the prefix filter of the metrics configuration is supported.", StatusCode: http.StatusNotImplemented}
+	TooManyMetricsConfigurations        = &ErrorCode{ErrorCode: "TooManyConfigurations", ErrorMessage: "You are attempting to create a new configuration but have already reached the 1,000-configuration limit.", StatusCode: http.StatusBadRequest}
+	InvalidObjectAttributes             = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "Invalid attribute name specified.", StatusCode: http.StatusBadRequest}
+	ConditionalWriteNotImplemented      = &ErrorCode{ErrorCode: "NotImplemented", ErrorMessage: "Only the If-None-Match header with value * is supported for conditional writes.", StatusCode: http.StatusNotImplemented}
+	PositionNotEqualToLength            = &ErrorCode{ErrorCode: "PositionNotEqualToLength", ErrorMessage: "Position is not equal to file length.", StatusCode: http.StatusConflict}
+	ObjectNotAppendable                 = &ErrorCode{ErrorCode: "ObjectNotAppendable", ErrorMessage: "The object created by multipart upload, encrypted or in blobstore storage class is not appendable.", StatusCode: http.StatusConflict}
+	AppendNotSupported                  = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "Append is not supported in the bucket with versioning, object lock or default encryption configured.", StatusCode: http.StatusBadRequest}
+	QuotaExceeded                       = &ErrorCode{ErrorCode: "QuotaExceeded", ErrorMessage: "The bucket quota of capacity or object count is exceeded.", StatusCode: http.StatusForbidden}
+	QuotaNotEnabled                     = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "Quota is not enabled for the bucket.", StatusCode: http.StatusBadRequest}
+	NoSuchBucketQuota                   = &ErrorCode{ErrorCode: "NoSuchBucketQuota", ErrorMessage: "The bucket quota does not exist.", StatusCode: http.StatusNotFound}
+	InvalidBucketQuota                  = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "At least one of MaxBytes and MaxObjects must be specified.", StatusCode: http.StatusBadRequest}
+	NoSuchBucketKeyIndex                = &ErrorCode{ErrorCode: "NoSuchBucketKeyIndex", ErrorMessage: "The key index of the bucket does not exist.", StatusCode: http.StatusNotFound}
+	ObjectLockNotEnabled                = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "Bucket is missing Object Lock Configuration.", StatusCode: http.StatusBadRequest}
+	InvalidLegalHoldStatus              = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "Legal Hold must be either of 'ON' or 'OFF'.", StatusCode: http.StatusBadRequest}
+	InvalidExpressionType               = &ErrorCode{ErrorCode: "InvalidExpressionType", ErrorMessage: "The ExpressionType is invalid. Only SQL expressions are supported.", StatusCode: http.StatusBadRequest}
+	InvalidCompressionFormat            = &ErrorCode{ErrorCode: "InvalidCompressionFormat", ErrorMessage: "The file is not in a supported compression format. Only GZIP and BZIP2 are supported.", StatusCode: http.StatusBadRequest}
+	InvalidFileHeaderInfo               = &ErrorCode{ErrorCode: "InvalidFileHeaderInfo", ErrorMessage: "The FileHeaderInfo is invalid. Only NONE, USE, and IGNORE are supported.", StatusCode: http.StatusBadRequest}
+	InvalidJsonType                     = &ErrorCode{ErrorCode: "InvalidJsonType", ErrorMessage: "The JsonType is invalid. Only DOCUMENT and LINES are supported.", StatusCode: http.StatusBadRequest}
+	InvalidQuoteFields                  = &ErrorCode{ErrorCode: "InvalidQuoteFields", ErrorMessage: "The QuoteFields is invalid. Only ALWAYS and ASNEEDED are supported.", StatusCode: http.StatusBadRequest}
+	InvalidScanRange                    = &ErrorCode{ErrorCode: "InvalidRequestParameter", ErrorMessage: "The value of a parameter in ScanRange element is invalid.", StatusCode: http.StatusBadRequest}
+	UnsupportedScanRangeInput           = &ErrorCode{ErrorCode: "UnsupportedScanRangeInput", ErrorMessage: "Scan range queries are not supported on this type of object.", StatusCode: http.StatusBadRequest}
+	InvalidChecksumAlgorithm            = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "Checksum algorithm provided is unsupported. Please try again with any of the valid types: [CRC32, CRC32C, SHA1, SHA256]", StatusCode: http.StatusBadRequest}
+	InvalidChecksumValue                = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "Value for x-amz-checksum header is invalid.", StatusCode: http.StatusBadRequest}
+	MultipleChecksumTypes               = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "Expecting a single x-amz-checksum- header. Multiple checksum Types are not allowed.", StatusCode: http.StatusBadRequest}
+	ChecksumTypeMismatch                = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "Checksum Type mismatch occurred, the checksum type must be the same as the algorithm specified.", StatusCode: http.StatusBadRequest}
+	InvalidChecksumTrailer              = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "The value specified in the x-amz-trailer header is not supported.", StatusCode: http.StatusBadRequest}
+	MissingChecksumTrailer              = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "The trailing checksum declared in the x-amz-trailer header is not received.", StatusCode: http.StatusBadRequest}
+	InvalidObjectState                  = &ErrorCode{ErrorCode: "InvalidObjectState", ErrorMessage: "The operation is not valid for the object's storage class.", StatusCode: http.StatusForbidden}
+	BadChecksum                         = &ErrorCode{ErrorCode: "BadDigest", ErrorMessage: "The checksum you specified did not match the calculated checksum.", StatusCode: http.StatusBadRequest}
+	SwiftUnauthorized                   = &ErrorCode{ErrorCode: "Unauthorized", ErrorMessage: "The authentication token is missing, invalid or expired.", StatusCode: http.StatusUnauthorized}
+	InvalidSwiftManifest                = &ErrorCode{ErrorCode: "InvalidManifest", ErrorMessage: "The manifest of the large object is invalid.", StatusCode: http.StatusBadRequest}
+	SwiftSegmentMismatch                = &ErrorCode{ErrorCode: "Conflict", ErrorMessage: "The segment of the large object does not match the manifest.", StatusCode: http.StatusConflict}
 )
 
 type ErrorCode struct {
@@ -120,6 +191,7 @@ func (ec *ErrorCode) ServeResponse(w http.ResponseWriter, r *http.Request) {
 	// traceMiddleWare send exception request to prometheus via status code
 	SetResponseStatusCode(r, strconv.Itoa(ec.StatusCode))
 	SetResponseErrorMessage(r, ec.ErrorMessage)
+	SetResponseErrorCode(r, ec.ErrorCode)
 
 	errorResponse := ErrorResponse{
 		Code:      ec.ErrorCode,
@@ -24,6 +24,37 @@ import (
 
 // register api routers
 func (o *ObjectNode) registerApiRouters(router *mux.Router) {
+	// The website endpoints are registered before the bucket routers, as the website domain may
+	// be the subdomain of the api domains.
+	for _, d := range o.websiteDomains {
+		for _, host := range []string{"{bucket:.+}." + d, "{bucket:.+}." + d + ":{port:[0-9]+}"} {
+			// Get website object
+			// API reference: https://docs.aws.amazon.com/AmazonS3/latest/userguide/WebsiteEndpoints.html
+			router.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetWebsiteObjectAction)).
+				Host(host).
+				Path("/{" + websiteRouteKey + ":.*}").
+				HandlerFunc(o.websiteHandler)
+		}
+	}
+
+	// The account level public access block of S3 Control is registered before the bucket routers,
+	// as its path is matched by the path-style bucket router.
+	// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_control_GetPublicAccessBlock.html
+	router.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetAccountPublicAccessBlockAction)).
+		Methods(http.MethodGet).
+		Path(accountPublicAccessBlockPath).
+		HandlerFunc(o.getAccountPublicAccessBlockHandler)
+	// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_control_PutPublicAccessBlock.html
+	router.NewRoute().Name(ActionToUniqueRouteName(proto.OSSPutAccountPublicAccessBlockAction)).
+		Methods(http.MethodPut).
+		Path(accountPublicAccessBlockPath).
+		HandlerFunc(o.putAccountPublicAccessBlockHandler)
+	// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_control_DeletePublicAccessBlock.html
+	router.NewRoute().Name(ActionToUniqueRouteName(proto.OSSDeleteAccountPublicAccessBlockAction)).
+		Methods(http.MethodDelete).
+		Path(accountPublicAccessBlockPath).
+		HandlerFunc(o.deleteAccountPublicAccessBlockHandler)
+
 	var bucketRouters []*mux.Router
 	bRouter := router.PathPrefix("/").Subrouter()
 	for _, d := range o.domains {
@@ -96,12 +127,11 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 
 		// Get object legal hold
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObjectLegalHold.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetObjectLegalHoldAction)).
 			Methods(http.MethodGet).
 			Path("/{object:.+}").
 			Queries("legal-hold", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.getObjectLegalHoldHandler)
 
 		// Get object retention
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObjectRetention.html
@@ -111,6 +141,14 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 			Queries("retention", "").
 			HandlerFunc(o.getObjectRetentionHandler)
 
+		// Get object attributes
+		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObjectAttributes.html
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetObjectAttributesAction)).
+			Methods(http.MethodGet).
+			Path("/{object:.+}").
+			Queries("attributes", "").
+			HandlerFunc(o.getObjectAttributesHandler)
+
 		// Get object torrent
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObjectTorrent.html
 		// Notes: unsupported operation
@@ -157,11 +195,10 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 
 		// Get bucket policy status
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketPolicyStatus.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetBucketPolicyStatusAction)).
 			Methods(http.MethodGet).
 			Queries("policyStatus", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.getBucketPolicyStatusHandler)
 
 		// Get bucket acl
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketAcl.html
@@ -179,11 +216,10 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 
 		// Get bucket encryption
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketEncryption.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetBucketEncryptionAction)).
 			Methods(http.MethodGet).
 			Queries("encryption", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.getBucketEncryptionHandler)
 
 		// Get bucket cors
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketCors.html
@@ -195,19 +231,17 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 
 		// Get bucket website
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketWebsite.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetBucketWebsiteAction)).
 			Methods(http.MethodGet).
 			Queries("website", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.getBucketWebsiteHandler)
 
 		// Get public access block
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetPublicAccessBlock.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetPublicAccessBlockAction)).
 			Methods(http.MethodGet).
 			Queries("publicAccessBlock", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.getPublicAccessBlockHandler)
 
 		// Get bucket request payment
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketRequestPayment.html
@@ -219,11 +253,66 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 
 		// Get bucket replication
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketReplication.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetBucketReplicationAction)).
 			Methods(http.MethodGet).
 			Queries("replication", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.getBucketReplicationHandler)
+
+		// Get bucket notification
+		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketNotificationConfiguration.html
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetBucketNotificationAction)).
+			Methods(http.MethodGet).
+			Queries("notification", "").
+			HandlerFunc(o.getBucketNotificationHandler)
+
+		// Get bucket logging
+		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketLogging.html
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetBucketLoggingAction)).
+			Methods(http.MethodGet).
+			Queries("logging", "").
+			HandlerFunc(o.getBucketLoggingHandler)
+
+		// Get bucket inventory configuration
+		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketInventoryConfiguration.html
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetBucketInventoryConfigurationAction)).
+			Methods(http.MethodGet).
+			Queries("inventory", "", "id", "{id:.+}").
+			HandlerFunc(o.getBucketInventoryHandler)
+
+		// List bucket inventory configurations
+		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_ListBucketInventoryConfigurations.html
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSListBucketInventoryConfigurationsAction)).
+			Methods(http.MethodGet).
+			Queries("inventory", "").
+			HandlerFunc(o.listBucketInventoriesHandler)
+
+		// Get bucket metrics configuration
+		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketMetricsConfiguration.html
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetBucketMetricsConfigurationAction)).
+			Methods(http.MethodGet).
+			Queries("metrics", "", "id", "{id:.+}").
+			HandlerFunc(o.getBucketMetricsHandler)
+
+		// List bucket metrics configurations
+		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_ListBucketMetricsConfigurations.html
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSListBucketMetricsConfigurationsAction)).
+			Methods(http.MethodGet).
+			Queries("metrics", "").
+			HandlerFunc(o.listBucketMetricsHandler)
+
+		// Get bucket quota
+		// Notes: extension api of CubeFS, the quota is the quota of the root directory of the volume.
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetBucketQuotaAction)).
+			Methods(http.MethodGet).
+			Queries("quota", "").
+			HandlerFunc(o.getBucketQuotaHandler)
+
+		// Get bucket key index
+		// Notes: extension api of CubeFS, the status of the sorted key index of the bucket is returned.
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetBucketKeyIndexAction)).
+			Methods(http.MethodGet).
+			Queries("keyIndex", "").
+			HandlerFunc(o.getBucketKeyIndexHandler)
 
 		// Get bucket lifecycle
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketLifecycle.html
@@ -242,19 +331,17 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 
 		// Get bucket versioning
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketVersioning.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetBucketVersioningAction)).
 			Methods(http.MethodGet).
 			Queries("versioning", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.getBucketVersioningHandler)
 
 		// List object versions
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_ListObjectVersions.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSListObjectVersionsAction)).
 			Methods(http.MethodGet).
 			Queries("versions", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.listObjectVersionsHandler)
 
 		// List objects version 1
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_ListObjects.html
@@ -280,14 +367,36 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 			Queries("uploadId", "{uploadId:.*}").
 			HandlerFunc(o.completeMultipartUploadHandler)
 
+		// Append object
+		// Notes: CubeFS owned API compatible with the AppendObject of OSS
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSAppendObjectAction)).
+			Methods(http.MethodPost).
+			Path("/{object:.+}").
+			Queries("append", "").
+			HandlerFunc(o.appendObjectHandler)
+
 		// Restore object
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_RestoreObject.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSRestoreObjectAction)).
 			Methods(http.MethodPost).
 			Path("/{object:.+}").
 			Queries("restore", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.restoreObjectHandler)
+
+		// Select object content
+		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_SelectObjectContent.html
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSSelectObjectContentAction)).
+			Methods(http.MethodPost).
+			Path("/{object:.+}").
+			Queries("select", "", "select-type", "2").
+			HandlerFunc(o.selectObjectContentHandler)
+
+		// Rebuild bucket key index
+		// Notes: extension api of CubeFS
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSRebuildBucketKeyIndexAction)).
+			Methods(http.MethodPost).
+			Queries("keyIndex", "").
+			HandlerFunc(o.rebuildBucketKeyIndexHandler)
 
 		// Delete objects (multiple objects)
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteObjects.html
@@ -362,21 +471,19 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 
 		// Put object legal hold
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutObjectLegalHold.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSPutObjectLegalHoldAction)).
 			Methods(http.MethodPut).
 			Path("/{object:.+}").
 			Queries("legal-hold", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.putObjectLegalHoldHandler)
 
 		// Put object retention
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutObjectRetention.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSPutObjectRetentionAction)).
 			Methods(http.MethodPut).
 			Path("/{object:.+}").
 			Queries("retention", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.putObjectRetentionHandler)
 
 		// Put object
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutObject.html
@@ -408,11 +515,10 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 
 		// Put bucket encryption
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketEncryption.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSPutBucketEncryptionAction)).
 			Methods(http.MethodPut).
 			Queries("encryption", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.putBucketEncryptionHandler)
 
 		// Put bucket cors
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketCors.html
@@ -424,19 +530,17 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 
 		// Put bucket website
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketWebsite.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSPutBucketWebsiteAction)).
 			Methods(http.MethodPut).
 			Queries("website", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.putBucketWebsiteHandler)
 
 		// Put public access block
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutPublicAccessBlock.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSPutPublicAccessBlockAction)).
 			Methods(http.MethodPut).
 			Queries("publicAccessBlock", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.putPublicAccessBlockHandler)
 
 		// Put bucket request payment
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketRequestPayment.html
@@ -448,11 +552,52 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 
 		// Put bucket replication
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketReplication.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSPutBucketReplicationAction)).
 			Methods(http.MethodPut).
 			Queries("replication", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.putBucketReplicationHandler)
+
+		// Put bucket notification
+		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketNotificationConfiguration.html
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSPutBucketNotificationAction)).
+			Methods(http.MethodPut).
+			Queries("notification", "").
+			HandlerFunc(o.putBucketNotificationHandler)
+
+		// Put bucket logging
+		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketLogging.html
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSPutBucketLoggingAction)).
+			Methods(http.MethodPut).
+			Queries("logging", "").
+			HandlerFunc(o.putBucketLoggingHandler)
+
+		// Put bucket inventory configuration
+		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketInventoryConfiguration.html
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSPutBucketInventoryConfigurationAction)).
+			Methods(http.MethodPut).
+			Queries("inventory", "", "id", "{id:.+}").
+			HandlerFunc(o.putBucketInventoryHandler)
+
+		// Put bucket metrics configuration
+		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketMetricsConfiguration.html
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSPutBucketMetricsConfigurationAction)).
+			Methods(http.MethodPut).
+			Queries("metrics", "", "id", "{id:.+}").
+			HandlerFunc(o.putBucketMetricsHandler)
+
+		// Put bucket quota
+		// Notes: extension api of CubeFS
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSPutBucketQuotaAction)).
+			Methods(http.MethodPut).
+			Queries("quota", "").
+			HandlerFunc(o.putBucketQuotaHandler)
+
+		// Put bucket key index
+		// Notes: extension api of CubeFS
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSPutBucketKeyIndexAction)).
+			Methods(http.MethodPut).
+			Queries("keyIndex", "").
+			HandlerFunc(o.putBucketKeyIndexHandler)
 
 		// Put bucket lifecycle
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketLifecycle.html
@@ -471,11 +616,10 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 
 		// Put bucket versioning
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketVersioning.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSPutBucketVersioningAction)).
 			Methods(http.MethodPut).
 			Queries("versioning", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.putBucketVersioningHandler)
 
 		// Create bucket
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_CreateBucket.html
@@ -532,11 +676,10 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 
 		// Delete bucket encryption
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketEncryption.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSDeleteBucketEncryptionAction)).
 			Methods(http.MethodDelete).
 			Queries("encryption", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.deleteBucketEncryptionHandler)
 
 		// Delete bucket cors
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketCors.html
@@ -548,27 +691,52 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 
 		// Delete bucket website
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketWebsite.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSDeleteBucketWebsiteAction)).
 			Methods(http.MethodDelete).
 			Queries("website", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.deleteBucketWebsiteHandler)
 
 		// Delete public access block
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeletePublicAccessBlock.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSDeletePublicAccessBlockAction)).
 			Methods(http.MethodDelete).
 			Queries("publicAccessBlock", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.deletePublicAccessBlockHandler)
 
 		// Delete bucket replication
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketReplication.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSDeleteBucketReplicationAction)).
 			Methods(http.MethodDelete).
 			Queries("replication", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.deleteBucketReplicationHandler)
+
+		// Delete bucket inventory configuration
+		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketInventoryConfiguration.html
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSDeleteBucketInventoryConfigurationAction)).
+			Methods(http.MethodDelete).
+			Queries("inventory", "", "id", "{id:.+}").
+			HandlerFunc(o.deleteBucketInventoryHandler)
+
+		// Delete bucket metrics configuration
+		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketMetricsConfiguration.html
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSDeleteBucketMetricsConfigurationAction)).
+			Methods(http.MethodDelete).
+			Queries("metrics", "", "id", "{id:.+}").
+			HandlerFunc(o.deleteBucketMetricsHandler)
+
+		// Delete bucket quota
+		// Notes: extension api of CubeFS
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSDeleteBucketQuotaAction)).
+			Methods(http.MethodDelete).
+			Queries("quota", "").
+			HandlerFunc(o.deleteBucketQuotaHandler)
+
+		// Delete bucket key index
+		// Notes: extension api of CubeFS
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSDeleteBucketKeyIndexAction)).
+			Methods(http.MethodDelete).
+			Queries("keyIndex", "").
+			HandlerFunc(o.deleteBucketKeyIndexHandler)
 
 		// Delete bucket lifecycle
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketLifecycle.html
@@ -615,6 +783,16 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 		Methods(http.MethodGet).
 		HandlerFunc(o.listBucketsHandler)
 
+	// Assume Role With Web Identity (STS)
+	// API reference: https://docs.aws.amazon.com/STS/latest/APIReference/API_AssumeRoleWithWebIdentity.html
+	router.NewRoute().Name(ActionToUniqueRouteName(proto.OSSAssumeRoleWithWebIdentityAction)).
+		Methods(http.MethodPost).
+		Path("/").
+		MatcherFunc(func(r *http.Request, rm *mux.RouteMatch) bool {
+			return stsRequestAction(r) == stsAssumeRoleWithWebIdentity
+		}).
+		HandlerFunc(o.assumeRoleWithWebIdentityHandler)
+
 	// Get Federation Token (STS)
 	// API reference: https://docs.aws.amazon.com/STS/latest/APIReference/API_GetFederationToken.html
 	router.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetFederationTokenAction)).
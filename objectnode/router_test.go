@@ -0,0 +1,149 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouterActions(t *testing.T) {
+	o := &ObjectNode{
+		domains:        []string{"cube.io"},
+		websiteDomains: []string{"website.cube.io"},
+		swift:          &SwiftAuthenticator{},
+	}
+	router := o.newRouter()
+
+	swiftToken := map[string]string{XAuthToken: "t"}
+	tests := []struct {
+		method string
+		url    string
+		header map[string]string
+		form   url.Values
+		action proto.Action
+		vars   map[string]string
+	}{
+		// website
+		{
+			method: http.MethodGet, url: "http://bucket.website.cube.io/", action: proto.OSSGetWebsiteObjectAction,
+			vars: map[string]string{ContextKeyBucket: "bucket", websiteRouteKey: ""},
+		},
+		{
+			method: http.MethodGet, url: "http://bucket.website.cube.io:8080/docs/a.html", action: proto.OSSGetWebsiteObjectAction,
+			vars: map[string]string{ContextKeyBucket: "bucket", websiteRouteKey: "docs/a.html"},
+		},
+		{
+			method: http.MethodGet, url: "http://bucket.cube.io/docs/a.html", action: proto.OSSGetObjectAction,
+			vars: map[string]string{ContextKeyBucket: "bucket", websiteRouteKey: ""},
+		},
+		// public access block
+		{method: http.MethodGet, url: "http://123456.cube.io/v20180820/configuration/publicAccessBlock", action: proto.OSSGetAccountPublicAccessBlockAction},
+		{method: http.MethodPut, url: "http://cube.io/v20180820/configuration/publicAccessBlock", action: proto.OSSPutAccountPublicAccessBlockAction},
+		{method: http.MethodDelete, url: "http://cube.io/v20180820/configuration/publicAccessBlock", action: proto.OSSDeleteAccountPublicAccessBlockAction},
+		{method: http.MethodGet, url: "http://bucket.cube.io/?publicAccessBlock", action: proto.OSSGetPublicAccessBlockAction},
+		{method: http.MethodDelete, url: "http://cube.io/bucket?publicAccessBlock", action: proto.OSSDeletePublicAccessBlockAction},
+		{method: http.MethodGet, url: "http://bucket.cube.io/?policyStatus", action: proto.OSSGetBucketPolicyStatusAction},
+		// restore
+		{method: http.MethodPost, url: "http://bucket.cube.io/a/b.txt?restore", action: proto.OSSRestoreObjectAction},
+		// logging
+		{method: http.MethodGet, url: "http://bucket.cube.io/?logging", action: proto.OSSGetBucketLoggingAction},
+		{method: http.MethodPut, url: "http://cube.io/bucket?logging", action: proto.OSSPutBucketLoggingAction},
+		// inventory
+		{method: http.MethodGet, url: "http://bucket.cube.io/?inventory&id=report1", action: proto.OSSGetBucketInventoryConfigurationAction},
+		{method: http.MethodGet, url: "http://bucket.cube.io/?inventory", action: proto.OSSListBucketInventoryConfigurationsAction},
+		{method: http.MethodGet, url: "http://cube.io/bucket?inventory&continuation-token=a", action: proto.OSSListBucketInventoryConfigurationsAction},
+		{method: http.MethodPut, url: "http://cube.io/bucket?inventory&id=report1", action: proto.OSSPutBucketInventoryConfigurationAction},
+		{method: http.MethodDelete, url: "http://bucket.cube.io/?inventory&id=report1", action: proto.OSSDeleteBucketInventoryConfigurationAction},
+		// metrics
+		{method: http.MethodGet, url: "http://bucket.cube.io/?metrics&id=all", action: proto.OSSGetBucketMetricsConfigurationAction},
+		{method: http.MethodGet, url: "http://bucket.cube.io/?metrics", action: proto.OSSListBucketMetricsConfigurationsAction},
+		{method: http.MethodPut, url: "http://cube.io/bucket?metrics&id=all", action: proto.OSSPutBucketMetricsConfigurationAction},
+		{method: http.MethodDelete, url: "http://bucket.cube.io/?metrics&id=all", action: proto.OSSDeleteBucketMetricsConfigurationAction},
+		// object attributes
+		{method: http.MethodGet, url: "http://bucket.cube.io/key?attributes", action: proto.OSSGetObjectAttributesAction},
+		{method: http.MethodGet, url: "http://cube.io/bucket/dir/key?attributes&versionId=1", action: proto.OSSGetObjectAttributesAction},
+		{method: http.MethodGet, url: "http://bucket.cube.io/key", action: proto.OSSGetObjectAction},
+		// append
+		{method: http.MethodPost, url: "http://bucket.cube.io/key?append&position=0", action: proto.OSSAppendObjectAction},
+		// sts, the form is kept for the signature and the handler
+		{
+			method: http.MethodPost, url: "http://cube.io/", action: proto.OSSAssumeRoleWithWebIdentityAction,
+			form: url.Values{stsActionKey: {stsAssumeRoleWithWebIdentity}, stsRoleSessionNameKey: {"session"}},
+		},
+		{
+			method: http.MethodPost, url: "http://cube.io/", action: proto.OSSGetFederationTokenAction,
+			form: url.Values{stsActionKey: {stsActionValue}, stsRoleSessionNameKey: {"session"}},
+		},
+		// quota
+		{method: http.MethodGet, url: "http://bucket.cube.io/?quota", action: proto.OSSGetBucketQuotaAction},
+		{method: http.MethodPut, url: "http://cube.io/bucket?quota", action: proto.OSSPutBucketQuotaAction},
+		{method: http.MethodDelete, url: "http://bucket.cube.io/?quota", action: proto.OSSDeleteBucketQuotaAction},
+		// swift
+		{method: http.MethodGet, url: "http://cube.io/auth/v1.0", header: map[string]string{XAuthUser: "ak"}, action: proto.OSSSwiftAuthAction},
+		{method: http.MethodGet, url: "http://cube.io/auth/v1", header: map[string]string{XStorageUser: "ak"}, action: proto.OSSSwiftAuthAction},
+		{method: http.MethodGet, url: "http://cube.io/v1/AUTH_user", header: swiftToken, action: proto.OSSListBucketsAction},
+		{method: http.MethodHead, url: "http://cube.io/v1/AUTH_user", header: swiftToken, action: proto.OSSSwiftHeadAccountAction},
+		{method: http.MethodPost, url: "http://cube.io/v1/AUTH_user", header: map[string]string{XStorageToken: "t"}, action: proto.OSSSwiftPostAccountAction},
+		{method: http.MethodGet, url: "http://cube.io/v1/AUTH_user/c", header: swiftToken, action: proto.OSSListObjectsAction},
+		{method: http.MethodPut, url: "http://cube.io/v1/AUTH_user/c", header: swiftToken, action: proto.OSSCreateBucketAction},
+		{method: http.MethodPost, url: "http://cube.io/v1/AUTH_user/c", header: swiftToken, action: proto.OSSSwiftPostContainerAction},
+		{method: http.MethodDelete, url: "http://cube.io/v1/AUTH_user/c", header: swiftToken, action: proto.OSSDeleteBucketAction},
+		{method: http.MethodGet, url: "http://cube.io/v1/AUTH_user/c/a/b", header: swiftToken, action: proto.OSSGetObjectAction},
+		{method: http.MethodPut, url: "http://cube.io/v1/AUTH_user/c/o", header: swiftToken, action: proto.OSSPutObjectAction},
+		{method: http.MethodPut, url: "http://cube.io/v1/AUTH_user/c/o", header: map[string]string{XAuthToken: "t", XCopyFrom: "c/src"}, action: proto.OSSCopyObjectAction},
+		{method: swiftMethodCopy, url: "http://cube.io/v1/AUTH_user/c/o", header: map[string]string{XAuthToken: "t", Destination: "c/dst"}, action: proto.OSSCopyObjectAction},
+		{method: http.MethodPost, url: "http://cube.io/v1/AUTH_user/c/o", header: swiftToken, action: proto.OSSSwiftPostObjectAction},
+		{method: http.MethodDelete, url: "http://cube.io/v1/AUTH_user/c/o", header: swiftToken, action: proto.OSSDeleteObjectAction},
+		// the requests without the token are the path-style requests of S3
+		{method: http.MethodGet, url: "http://cube.io/v1/AUTH_user", action: proto.OSSGetObjectAction},
+		{method: http.MethodGet, url: "http://cube.io/auth/v1.0", action: proto.OSSGetObjectAction},
+		// key index
+		{method: http.MethodGet, url: "http://bucket.cube.io/?keyIndex", action: proto.OSSGetBucketKeyIndexAction},
+		{method: http.MethodPut, url: "http://cube.io/bucket?keyIndex", action: proto.OSSPutBucketKeyIndexAction},
+		{method: http.MethodPost, url: "http://bucket.cube.io/?keyIndex", action: proto.OSSRebuildBucketKeyIndexAction},
+		{method: http.MethodDelete, url: "http://bucket.cube.io/?keyIndex", action: proto.OSSDeleteBucketKeyIndexAction},
+	}
+	for _, tc := range tests {
+		name := tc.method + " " + tc.url
+		var req *http.Request
+		if tc.form != nil {
+			req = httptest.NewRequest(tc.method, tc.url, strings.NewReader(tc.form.Encode()))
+			req.Header.Set(ContentType, "application/x-www-form-urlencoded")
+		} else {
+			req = httptest.NewRequest(tc.method, tc.url, nil)
+		}
+		for key, value := range tc.header {
+			req.Header.Set(key, value)
+		}
+
+		var match mux.RouteMatch
+		require.True(t, router.Match(req, &match), name)
+		require.Equal(t, tc.action, ActionFromRouteName(match.Route.GetName()), name)
+		for key, value := range tc.vars {
+			require.Equal(t, value, match.Vars[key], name)
+		}
+		for key := range tc.form {
+			require.Equal(t, tc.form.Get(key), req.PostFormValue(key), name)
+		}
+	}
+}
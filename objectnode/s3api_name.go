@@ -22,22 +22,27 @@ package objectnode
 const (
 	UNSUPPORT_API              = "UnSupportAPI"
 	GET_FEDERATION_TOKEN       = "GetFederationToken"         // api:  POST /,  host=s3-cn-east-1.cs.com, create sts token
+	ASSUME_ROLE_WEB_IDENTITY   = "AssumeRoleWithWebIdentity"  // api:  POST /,  host=s3-cn-east-1.cs.com, create sts token with web identity
 	List_BUCKETS               = "ListBuckets"                // api:  GET / , host=s3-cn-east-1.cs.com, list all buckets
 	DELETE_BUCKET              = "DeleteBucket"               // api:  Delete /  , host=<bucket>.domain
 	DELETE_BUCKET_CORS         = "DeleteBucketCors"           // api:  Delete /?cors  , host=<bucket>.domain
 	DELETE_BUCKET_ENCRYPTION   = "DeleteBucketEncryption"     // api:  Delete /?encryption  , host=<bucket>.domain
+	DELETE_BUCKET_KEY_INDEX    = "DeleteBucketKeyIndex"       // api:  Delete /?keyIndex  , host=<bucket>.domain
 	DELETE_BUCKET_LIFECYCLE    = "DeleteBucketLifeCycle"      // api:  Delete /?lifycycle  , host=<bucket>.domain
 	DELETE_BUCKET_METRICS      = "DeleteBucketMetrics"        // api:  Delete /?metrics&id=<ID>  , host=<bucket>.domain
 	DELETE_BUCKET_POLICY       = "DeleteBucketPolicy"         // api:  Delete /?policy  , host=<bucket>.domain
+	DELETE_BUCKET_QUOTA        = "DeleteBucketQuota"          // api:  Delete /?quota  , host=<bucket>.domain
 	DELETE_BUCKET_REPLICATION  = "DeleteBucketReplication"    // api:  Delete /?replication  , host=<bucket>.domain
 	DELETE_BUCKET_TAGGING      = "DeleteBucketTagging"        // api:  Delete /?tagging  , host=<bucket>.domain
 	DELETE_BUCKET_WEBSITE      = "DeleteBucketWebsite"        // api:  Delete /?website  , host=<bucket>.domain
+	DELETE_PUBLIC_ACCESS_BLOCK = "DeletePublicAccessBlock"    // api:  Delete /?publicAccessBlock  , host=<bucket>.domain
 	LIST_OBJECTS               = "ListObjects"                // api:  Get /  ,  host=<bucket>.domain ,  GetBucket version1
 	LIST_OBJECTS_V2            = "ListObjectsV2"              // api:  Get /?list-type=2, host=<bucket>.domain, GetBucket Version2
 	GET_BUCKET_ACCELERATE      = "GetBucketAccelerate"        // api:  GET /<bucketname>?accelerate
 	GET_BUCKET_ACL             = "GetBucketAcl"               // api:  GET /<bucketname>?acl
 	GET_BUCKET_CORS            = "GetBucketCors"              // api:  Get /?cors  , host=<bucket>.domain
 	GET_BUCKET_ENCRYPTION      = "GetBucketEncryption"        // api:  Get /?encryption  , host=<bucket>.domain
+	GET_BUCKET_KEY_INDEX       = "GetBucketKeyIndex"          // api:  Get /?keyIndex  , host=<bucket>.domain
 	GET_BUCKET_LIFECYCLE       = "GetBucketLifeCycle"         // api:  Get /?lifycycle  , host=<bucket>.domain
 	GET_BUCKET_LOCATION        = "GetBucketLocation"          // api:  GET /?location , host=<bucket>.domain
 	GET_PUBLIC_ACCESS_BLOCK    = "GetPublicAccessBlock"       // api:  Get /?publicAccessBlock  , host=<bucket>.domain
@@ -47,6 +52,7 @@ const (
 	GET_BUCKET_POLICY_STATUS   = "GetBucketPolicyStatus"      // api:  Get /?policyStatus  , host=<bucket>.domain
 	GET_BUCKET_OBJECT_VERSIONS = "GetBucketObjectVersions"    // api:  Get /?versions  , host=<bucket>.domain
 	GET_BUCKET_POLICY          = "GetBucketPolicy"            // api:  Get /?policy  , host=<bucket>.domain
+	GET_BUCKET_QUOTA           = "GetBucketQuota"             // api:  Get /?quota  , host=<bucket>.domain
 	GET_BUCKET_REPLICATION     = "GetBucketReplication"       // api:  Get /?replication  , host=<bucket>.domain
 	GET_BUCKET_TAGGING         = "GetBucketTagging"           // api:  Get /?tagging  , host=<bucket>.domain
 	GET_BUCKET_VERSIONING      = "GetBucketVersioning"        // api:  Get /?versioning  , host=<bucket>.domain
@@ -59,12 +65,14 @@ const (
 	PUT_BUCKET_ACL             = "PutBucketAcl"               // api:  Put  /?acl , host=<bucket>.domain
 	PUT_BUCKET_CORS            = "PutBucketCors"              // api:  PUT /?cors , host=<bucket>.domain
 	PUT_BUCKET_ENCRYPTION      = "PutBucketEncryption"        // api:  PUT /?encryption , host=<bucket>.domain
+	PUT_BUCKET_KEY_INDEX       = "PutBucketKeyIndex"          // api:  PUT /?keyIndex , host=<bucket>.domain
 	PUT_BUCKET_LIFECYCLE       = "PutBucketLifecycle"         // api:  PUT /?lifecycle , host=<bucket>.domain
 	PUT_PUBLIC_ACCESS_BLOCK    = "PutPublicAccessBlock"       // api:  PUT /<bucketname>?publicAccessBlock , host=<bucket>.domain,
 	PUT_BUCKET_LOGGING         = "PutBucketLogging"           // api:  PUT /?logging , host=<bucket>.domain
 	PUT_BUCKET_METRICS         = "PutBucketMetrics"           // api:  PUT /?metrics&id=<id> , host=<bucket>.domain
 	PUT_BUCKET_NOTIFICATION    = "PutBucketNotification"      // api:  PUT /?notification , host=<bucket>.domain
 	PUT_BUCKET_POLICY          = "PutBucketPolicy"            // api:  PUT /?policy , host=<bucket>.domain
+	PUT_BUCKET_QUOTA           = "PutBucketQuota"             // api:  PUT /?quota , host=<bucket>.domain
 	PUT_BUCKET_REPLICATION     = "PutBucketReplication"       // api:  PUT /?replication , host=<bucket>.domain
 	PUT_BUCKET_REQUEST_PAYMENT = "PutBucketRequestPayment"    // api:  PUT /?requestPayment , host=<bucket>.domain
 	PUT_BUCKET_TAGGING         = "PutBucketTagging"           // api:  PUT /?tagging , host=<bucket>.domain
@@ -72,16 +80,25 @@ const (
 	PUT_BUCKET_WEBSITE         = "PutBucketWebsite"           // api:  PUT /?website , host=<bucket>.domain
 	PUT_OBJECT_LOCK_CFG        = "PutObjectLockConfiguration" // api:  Put /?object-lock, host=<bucket>.domain
 	BATCH_DELETE               = "DeleteObjects"              // api:  POST /?delete , host=<bucket>.domain,  "DeleteObjects"
+	REBUILD_BUCKET_KEY_INDEX   = "RebuildBucketKeyIndex"      // api:  POST /?keyIndex , host=<bucket>.domain
 	DELETE_OBJECT              = "DeleteObject"               // api:  Delete /<objname>, host=<bucket>.domain
 	DELETE_OBJECT_TAGGING      = "DeleteObjectTagging"        // api:  Delete /<objname>?tagging, host=<bucket>.domain
 	GET_OBJECT                 = "GetObject"                  // api:  Get /<objname> , host=<bucket>.domain
+	GET_WEBSITE_OBJECT         = "GetWebsiteObject"           // api:  Get /<objname> , host=<bucket>.<website domain>
 	GET_OBJECT_ACL             = "GetObjectAcl"               // api:  Get /<bucketname>/<objname>?acl   , host=<bucket>.domain
 	GET_OBJECT_TAGGING         = "GetObjectTagging"           // api:  Get /<bucketname>/<objname>?tagging   , host=<bucket>.domain
+	GET_OBJECT_ATTRIBUTES      = "GetObjectAttributes"        // api:  Get /<bucketname>/<objname>?attributes, host=<bucket>.domain
 	GET_OBJECT_RETENTION       = "GetObjectRetention"         // api:  Get /<bucketname>/<objname>?retention, host=<bucket>.domain
+	PUT_OBJECT_RETENTION       = "PutObjectRetention"         // api:  Put /<bucketname>/<objname>?retention, host=<bucket>.domain
+	GET_OBJECT_LEGAL_HOLD      = "GetObjectLegalHold"         // api:  Get /<bucketname>/<objname>?legal-hold, host=<bucket>.domain
+	PUT_OBJECT_LEGAL_HOLD      = "PutObjectLegalHold"         // api:  Put /<bucketname>/<objname>?legal-hold, host=<bucket>.domain
+	SELECT_OBJECT_CONTENT      = "SelectObjectContent"        // api:  POST /<bucketname>/<objname>?select&select-type=2, host=<bucket>.domain
+	RESTORE_OBJECT             = "RestoreObject"              // api:  POST /<ObjectName>?restore , host=<bucket>.domain
 	HEAD_OBJECT                = "HeadObject"                 // api:  HEAD /<ObjectName> , host=<bucket>.domain
 	OPTIONS_OBJECT             = "OptionsObject"              // api:  OPTIONS /<ObjectName>, host=<bucket>.domain
 	POST_OBJECT                = "PostObject"                 // api:  Post /  , host=<bucket>.domain
 	PUT_OBJECT                 = "PutObject"                  // api:  Put  /<objname>,  host=<bucket>.domain
+	APPEND_OBJECT              = "AppendObject"               // api:  POST /<objname>?append&position=<position>, host=<bucket>.domain
 	COPY_OBJECT                = "CopyObject"                 // api:  Put /<destObjname>  ,host=<destbucket>.domain,  header["x-amz-copy-source"]
 	PUT_OBJECT_ACL             = "PutObjectAcl"               // api:  Put /<ObjectName>?acl  , host=<bucket>.domain
 	PUT_OBJECT_TAGGING         = "PutObjectTagging"           // api:  Put /<ObjectName>?tagging  , host=<bucket>.domain
@@ -92,3 +109,21 @@ const (
 	COMPLETE_MULTIPART_UPLOAD  = "CompleteMultipartUpload"    // api:  POST /<ObjectName>?uploadId=<Id> , host=<bucket>.domain
 	ABORT_MULTIPART_UPLOAD     = "AbortMultipartUpload"       // api:  DELETE /<ObjectName>?uploadId=<Id> , host=<bucket>.domain
 )
+
+// account level public access block api of S3 Control, refer to:
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_Operations_AWS_S3_Control.html
+const (
+	GET_ACCOUNT_PUBLIC_ACCESS_BLOCK    = "GetAccountPublicAccessBlock"    // api:  GET /v20180820/configuration/publicAccessBlock
+	PUT_ACCOUNT_PUBLIC_ACCESS_BLOCK    = "PutAccountPublicAccessBlock"    // api:  PUT /v20180820/configuration/publicAccessBlock
+	DELETE_ACCOUNT_PUBLIC_ACCESS_BLOCK = "DeleteAccountPublicAccessBlock" // api:  DELETE /v20180820/configuration/publicAccessBlock
+)
+
+// the extra api of the Swift API front-end, the other operations share the api names of S3, refer to:
+// https://docs.openstack.org/api-ref/object-store/
+const (
+	SWIFT_AUTH           = "SwiftAuth"          // api:  GET /auth/v1.0 , header["X-Auth-User"]
+	SWIFT_HEAD_ACCOUNT   = "SwiftHeadAccount"   // api:  HEAD /v1/<account>
+	SWIFT_POST_ACCOUNT   = "SwiftPostAccount"   // api:  POST /v1/<account>
+	SWIFT_POST_CONTAINER = "SwiftPostContainer" // api:  POST /v1/<account>/<container>
+	SWIFT_POST_OBJECT    = "SwiftPostObject"    // api:  POST /v1/<account>/<container>/<object>
+)
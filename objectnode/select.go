@@ -0,0 +1,558 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	MaxSelectRequestSize = 256 << 10 // 256KB
+
+	SelectExpressionTypeSQL = "SQL"
+
+	SelectCompressionNone  = "NONE"
+	SelectCompressionGzip  = "GZIP"
+	SelectCompressionBzip2 = "BZIP2"
+
+	CSVFileHeaderUse    = "USE"
+	CSVFileHeaderIgnore = "IGNORE"
+	CSVFileHeaderNone   = "NONE"
+
+	CSVQuoteFieldsAlways   = "ALWAYS"
+	CSVQuoteFieldsAsNeeded = "ASNEEDED"
+
+	JSONTypeDocument = "DOCUMENT"
+	JSONTypeLines    = "LINES"
+
+	// selectRecordsChunkSize is the max size of the payload of a Records message
+	selectRecordsChunkSize = 128 << 10
+
+	defaultSelectMemoryLimit = 256 << 20 // 256MB
+)
+
+// selectMemoryLimit is the max memory used to decode the data of a single request, e.g. a Parquet row group.
+var selectMemoryLimit int64 = defaultSelectMemoryLimit
+
+// SelectObjectContentRequest is the request body of SelectObjectContent.
+type SelectObjectContentRequest struct {
+	XMLName             xml.Name             `xml:"SelectObjectContentRequest"`
+	Expression          string               `xml:"Expression"`
+	ExpressionType      string               `xml:"ExpressionType"`
+	RequestProgress     *RequestProgress     `xml:"RequestProgress"`
+	InputSerialization  *InputSerialization  `xml:"InputSerialization"`
+	OutputSerialization *OutputSerialization `xml:"OutputSerialization"`
+	ScanRange           *ScanRange           `xml:"ScanRange"`
+}
+
+type RequestProgress struct {
+	Enabled bool `xml:"Enabled"`
+}
+
+type InputSerialization struct {
+	CompressionType string        `xml:"CompressionType"`
+	CSV             *CSVInput     `xml:"CSV"`
+	JSON            *JSONInput    `xml:"JSON"`
+	Parquet         *ParquetInput `xml:"Parquet"`
+}
+
+type CSVInput struct {
+	FileHeaderInfo             string `xml:"FileHeaderInfo"`
+	Comments                   string `xml:"Comments"`
+	QuoteEscapeCharacter       string `xml:"QuoteEscapeCharacter"`
+	RecordDelimiter            string `xml:"RecordDelimiter"`
+	FieldDelimiter             string `xml:"FieldDelimiter"`
+	QuoteCharacter             string `xml:"QuoteCharacter"`
+	AllowQuotedRecordDelimiter bool   `xml:"AllowQuotedRecordDelimiter"`
+}
+
+type JSONInput struct {
+	Type string `xml:"Type"`
+}
+
+type ParquetInput struct{}
+
+type OutputSerialization struct {
+	CSV  *CSVOutput  `xml:"CSV"`
+	JSON *JSONOutput `xml:"JSON"`
+}
+
+type CSVOutput struct {
+	QuoteFields          string `xml:"QuoteFields"`
+	QuoteEscapeCharacter string `xml:"QuoteEscapeCharacter"`
+	RecordDelimiter      string `xml:"RecordDelimiter"`
+	FieldDelimiter       string `xml:"FieldDelimiter"`
+	QuoteCharacter       string `xml:"QuoteCharacter"`
+}
+
+type JSONOutput struct {
+	RecordDelimiter string `xml:"RecordDelimiter"`
+}
+
+// ScanRange is the byte range of the object to query, the records which start within the range are processed.
+type ScanRange struct {
+	Start *int64 `xml:"Start"`
+	End   *int64 `xml:"End"`
+}
+
+// SelectStats is the payload of the Progress and Stats messages.
+type SelectStats struct {
+	BytesScanned   int64 `xml:"BytesScanned"`
+	BytesProcessed int64 `xml:"BytesProcessed"`
+	BytesReturned  int64 `xml:"BytesReturned"`
+}
+
+// parse SelectObjectContentRequest from xml
+func ParseSelectRequestFromXML(data []byte) (*SelectObjectContentRequest, error) {
+	req := &SelectObjectContentRequest{}
+	if err := xml.Unmarshal(data, req); err != nil {
+		return nil, MalformedXML
+	}
+	if err := req.checkValid(); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func newSelectRequestError(msg string) *ErrorCode {
+	return NewError("InvalidRequestParameter", msg, 400)
+}
+
+func (req *SelectObjectContentRequest) checkValid() error {
+	if strings.TrimSpace(req.Expression) == "" {
+		return NewError("MissingRequiredParameter", "The SelectRequest entity is missing a required parameter Expression.", 400)
+	}
+	if !strings.EqualFold(req.ExpressionType, SelectExpressionTypeSQL) {
+		return InvalidExpressionType
+	}
+
+	input := req.InputSerialization
+	if input == nil {
+		return NewError("MissingRequiredParameter", "The SelectRequest entity is missing a required parameter InputSerialization.", 400)
+	}
+	formats := 0
+	if input.CSV != nil {
+		formats++
+		if err := input.CSV.checkValid(); err != nil {
+			return err
+		}
+	}
+	if input.JSON != nil {
+		formats++
+		switch strings.ToUpper(input.JSON.Type) {
+		case JSONTypeDocument, JSONTypeLines:
+		default:
+			return InvalidJsonType
+		}
+	}
+	if input.Parquet != nil {
+		formats++
+	}
+	if formats != 1 {
+		return newSelectRequestError("Exactly one of CSV, JSON and Parquet must be specified in InputSerialization.")
+	}
+	switch strings.ToUpper(input.CompressionType) {
+	case "", SelectCompressionNone:
+	case SelectCompressionGzip, SelectCompressionBzip2:
+		if input.Parquet != nil {
+			return newSelectRequestError("Compression is not supported for Parquet objects.")
+		}
+	default:
+		return InvalidCompressionFormat
+	}
+
+	output := req.OutputSerialization
+	if output == nil {
+		return NewError("MissingRequiredParameter", "The SelectRequest entity is missing a required parameter OutputSerialization.", 400)
+	}
+	if (output.CSV == nil) == (output.JSON == nil) {
+		return newSelectRequestError("Exactly one of CSV and JSON must be specified in OutputSerialization.")
+	}
+	if output.CSV != nil {
+		if err := output.CSV.checkValid(); err != nil {
+			return err
+		}
+	}
+
+	if req.ScanRange != nil {
+		if err := req.checkScanRange(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *CSVInput) checkValid() error {
+	switch strings.ToUpper(c.FileHeaderInfo) {
+	case "", CSVFileHeaderUse, CSVFileHeaderIgnore, CSVFileHeaderNone:
+	default:
+		return InvalidFileHeaderInfo
+	}
+	if len(c.QuoteCharacter) > 1 || len(c.QuoteEscapeCharacter) > 1 || len(c.FieldDelimiter) > 2 || len(c.RecordDelimiter) > 2 {
+		return newSelectRequestError("The delimiters, quote and escape characters of CSV are too long.")
+	}
+	return nil
+}
+
+func (c *CSVOutput) checkValid() error {
+	switch strings.ToUpper(c.QuoteFields) {
+	case "", CSVQuoteFieldsAlways, CSVQuoteFieldsAsNeeded:
+	default:
+		return InvalidQuoteFields
+	}
+	if len(c.QuoteCharacter) > 1 || len(c.QuoteEscapeCharacter) > 1 || len(c.FieldDelimiter) > 2 || len(c.RecordDelimiter) > 2 {
+		return newSelectRequestError("The delimiters, quote and escape characters of CSV are too long.")
+	}
+	return nil
+}
+
+func (req *SelectObjectContentRequest) checkScanRange() error {
+	input := req.InputSerialization
+	if compression := strings.ToUpper(input.CompressionType); compression != "" && compression != SelectCompressionNone {
+		return UnsupportedScanRangeInput
+	}
+	if input.JSON != nil && !strings.EqualFold(input.JSON.Type, JSONTypeLines) {
+		return UnsupportedScanRangeInput
+	}
+	r := req.ScanRange
+	if r.Start == nil && r.End == nil {
+		return InvalidScanRange
+	}
+	if r.Start != nil && *r.Start < 0 || r.End != nil && *r.End < 0 {
+		return InvalidScanRange
+	}
+	if r.Start != nil && r.End != nil && *r.Start > *r.End {
+		return InvalidScanRange
+	}
+	return nil
+}
+
+// scanRange returns the range [start, end] of the object to query, end < 0 means the end of the object.
+// Only End specified means the last End bytes of the object.
+func (req *SelectObjectContentRequest) scanRange(size int64) (start, end int64) {
+	if req.ScanRange == nil {
+		return 0, -1
+	}
+	r := req.ScanRange
+	switch {
+	case r.Start != nil && r.End != nil:
+		return *r.Start, *r.End
+	case r.Start != nil:
+		return *r.Start, -1
+	default:
+		if start = size - *r.End; start < 0 {
+			start = 0
+		}
+		return start, -1
+	}
+}
+
+// selectSource is the data of the object to query.
+type selectSource interface {
+	Size() int64
+	// NewReader returns the reader of the object data from the offset, at most length bytes are read.
+	NewReader(offset, length int64) (io.ReadCloser, error)
+}
+
+// selectSourceReaderAt reads the selectSource randomly, which is required by Parquet objects.
+type selectSourceReaderAt struct {
+	source  selectSource
+	scanned *int64
+}
+
+func (s *selectSourceReaderAt) ReadAt(p []byte, off int64) (n int, err error) {
+	r, err := s.source.NewReader(off, int64(len(p)))
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+	n, err = io.ReadFull(r, p)
+	atomic.AddInt64(s.scanned, int64(n))
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return
+}
+
+// selectOutput receives the results of the query.
+type selectOutput interface {
+	// SendRecords sends the serialized records.
+	SendRecords(payload []byte) error
+}
+
+// selectExecutor runs the query of the request over the object.
+type selectExecutor struct {
+	req    *SelectObjectContentRequest
+	query  *selectQuery
+	writer selectRecordWriter
+
+	scanned   int64
+	processed int64
+	returned  int64
+}
+
+func newSelectExecutor(req *SelectObjectContentRequest) (*selectExecutor, error) {
+	query, err := parseSelectQuery(req.Expression)
+	if err != nil {
+		return nil, err
+	}
+	e := &selectExecutor{req: req, query: query}
+	if req.OutputSerialization.CSV != nil {
+		e.writer = newCSVRecordWriter(req.OutputSerialization.CSV)
+	} else {
+		e.writer = newJSONRecordWriter(req.OutputSerialization.JSON)
+	}
+	return e, nil
+}
+
+// Stats returns the current statistics of the query.
+func (e *selectExecutor) Stats() *SelectStats {
+	return &SelectStats{
+		BytesScanned:   atomic.LoadInt64(&e.scanned),
+		BytesProcessed: atomic.LoadInt64(&e.processed),
+		BytesReturned:  atomic.LoadInt64(&e.returned),
+	}
+}
+
+// openReader opens the record reader of the object data according to the input serialization.
+func (e *selectExecutor) openReader(source selectSource) (reader selectRecordReader, closer io.Closer, err error) {
+	input := e.req.InputSerialization
+	start, end := e.req.scanRange(source.Size())
+
+	if input.Parquet != nil {
+		p, err := newParquetRecordReader(&selectSourceReaderAt{source: source, scanned: &e.scanned}, source.Size(), start, end, selectMemoryLimit)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &parquetProcessedCounter{parquetRecordReader: p, processed: &e.processed}, io.NopCloser(nil), nil
+	}
+
+	// the reading starts from the record delimiter before the start to know whether the start is
+	// the beginning of a record
+	readStart := start
+	if start > 0 {
+		delimLen := int64(1)
+		if input.CSV != nil && len(input.CSV.RecordDelimiter) > 1 {
+			delimLen = int64(len(input.CSV.RecordDelimiter))
+		}
+		if readStart -= delimLen; readStart < 0 {
+			readStart = 0
+		}
+	}
+	rc, err := source.NewReader(readStart, source.Size()-readStart)
+	if err != nil {
+		return nil, nil, err
+	}
+	var r io.Reader = &countingReader{r: rc, n: &e.scanned}
+	switch strings.ToUpper(input.CompressionType) {
+	case SelectCompressionGzip:
+		if r, err = gzip.NewReader(r); err != nil {
+			rc.Close()
+			return nil, nil, InvalidCompressionFormat
+		}
+	case SelectCompressionBzip2:
+		r = bzip2.NewReader(r)
+	}
+	r = &countingReader{r: r, n: &e.processed}
+
+	if input.CSV != nil {
+		c := newCSVRecordReader(r, input.CSV)
+		if start == 0 {
+			if err = c.readHeader(); err != nil {
+				rc.Close()
+				return nil, nil, err
+			}
+		} else {
+			if err = e.readCSVHeader(source, c); err != nil {
+				rc.Close()
+				return nil, nil, err
+			}
+			if err = c.skipPartialRecord(); err != nil && err != io.EOF {
+				rc.Close()
+				return nil, nil, err
+			}
+		}
+		if end >= 0 {
+			c.end = end - readStart
+		}
+		return c, rc, nil
+	}
+
+	j := newJSONRecordReader(r, input.JSON, e.query.fromPath)
+	if start > 0 {
+		if err = j.skipPartialRecord(); err != nil && err != io.EOF {
+			rc.Close()
+			return nil, nil, err
+		}
+	}
+	if end >= 0 {
+		j.end = end - readStart
+	}
+	return j, rc, nil
+}
+
+// readCSVHeader reads the header line from the beginning of the object if the scan range does not start from 0.
+func (e *selectExecutor) readCSVHeader(source selectSource, c *csvRecordReader) error {
+	if header := strings.ToUpper(c.input.FileHeaderInfo); header != CSVFileHeaderUse {
+		return nil
+	}
+	rc, err := source.NewReader(0, source.Size())
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	h := newCSVRecordReader(&countingReader{r: rc, n: &e.scanned}, c.input)
+	if err = h.readHeader(); err != nil {
+		return err
+	}
+	c.names = h.names
+	return nil
+}
+
+// Run runs the query and sends the results to the output.
+func (e *selectExecutor) Run(source selectSource, output selectOutput) (err error) {
+	reader, closer, err := e.openReader(source)
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+
+	var (
+		buf     bytes.Buffer
+		count   int64
+		query   = e.query
+		limited = query.limit >= 0
+	)
+	flush := func() error {
+		if buf.Len() == 0 {
+			return nil
+		}
+		if err := output.SendRecords(buf.Bytes()); err != nil {
+			return err
+		}
+		atomic.AddInt64(&e.returned, int64(buf.Len()))
+		buf.Reset()
+		return nil
+	}
+
+	for !limited || count < query.limit {
+		rec, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if query.where != nil {
+			v, err := query.where.eval(rec)
+			if err != nil {
+				return err
+			}
+			if b, ok := v.(bool); !ok || !b {
+				continue
+			}
+		}
+		count++
+		if query.isAggregate() {
+			for _, agg := range query.aggregates {
+				if err = agg.accumulate(rec); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		out, err := e.project(rec)
+		if err != nil {
+			return err
+		}
+		if err = e.writer.Write(&buf, out); err != nil {
+			return err
+		}
+		if buf.Len() >= selectRecordsChunkSize {
+			if err = flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if query.isAggregate() && (!limited || query.limit > 0) {
+		out, err := e.project(nil)
+		if err != nil {
+			return err
+		}
+		if err = e.writer.Write(&buf, out); err != nil {
+			return err
+		}
+	}
+	return flush()
+}
+
+// project evaluates the projections of the query over the record.
+func (e *selectExecutor) project(rec *selectRecord) (*selectOutputRecord, error) {
+	out := &selectOutputRecord{}
+	if e.query.selectAll {
+		if rec.object != nil {
+			for _, key := range rec.object.keys {
+				out.names = append(out.names, key)
+				out.values = append(out.values, rec.object.values[key])
+			}
+			return out, nil
+		}
+		for i, field := range rec.fields {
+			out.names = append(out.names, rec.columnName(i))
+			out.values = append(out.values, field)
+		}
+		return out, nil
+	}
+	for i, proj := range e.query.projections {
+		v, err := proj.expr.eval(rec)
+		if err != nil {
+			return nil, err
+		}
+		name := proj.alias
+		if name == "" {
+			if col, ok := proj.expr.(*sqlColumnRef); ok {
+				name = col.name()
+			}
+		}
+		if name == "" {
+			name = "_" + strconv.Itoa(i+1)
+		}
+		out.names = append(out.names, name)
+		out.values = append(out.values, v)
+	}
+	return out, nil
+}
+
+// parquetProcessedCounter counts the size of the records of Parquet objects as BytesProcessed.
+type parquetProcessedCounter struct {
+	*parquetRecordReader
+	processed *int64
+}
+
+func (p *parquetProcessedCounter) Read() (*selectRecord, error) {
+	rec, err := p.parquetRecordReader.Read()
+	if err == nil {
+		atomic.AddInt64(p.processed, int64(len(formatSelectValue(rec.object))))
+	}
+	return rec, err
+}
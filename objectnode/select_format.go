@@ -0,0 +1,706 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// maxSelectRecordSize is the max size of a record of the CSV and JSON objects.
+const maxSelectRecordSize = 1 << 20
+
+// jsonObject is the JSON object which keeps the order of its keys.
+type jsonObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newJSONObject() *jsonObject {
+	return &jsonObject{values: make(map[string]interface{})}
+}
+
+func (o *jsonObject) set(key string, value interface{}) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+// get returns the value of the key, the unquoted key is matched case-insensitively.
+func (o *jsonObject) get(key string, caseSensitive bool) (interface{}, bool) {
+	if v, ok := o.values[key]; ok {
+		return v, true
+	}
+	if !caseSensitive {
+		for _, k := range o.keys {
+			if strings.EqualFold(k, key) {
+				return o.values[k], true
+			}
+		}
+	}
+	return nil, false
+}
+
+// getPositional returns the value of the positional column name _N, N starts from 1.
+func getPositional(name string, n int, value func(i int) interface{}) (interface{}, bool) {
+	if len(name) < 2 || name[0] != '_' {
+		return nil, false
+	}
+	i, err := strconv.Atoi(name[1:])
+	if err != nil || i < 1 || i > n {
+		return nil, false
+	}
+	return value(i - 1), true
+}
+
+func (o *jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(k)
+		buf.Write(key)
+		buf.WriteByte(':')
+		if err := writeJSONValue(&buf, o.values[k]); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// selectRecord is a record of the object, it is either the fields of a CSV row or a JSON object.
+type selectRecord struct {
+	names  []string
+	fields []string
+	object *jsonObject
+}
+
+func (r *selectRecord) get(name string, quoted bool) (interface{}, bool) {
+	if r.object != nil {
+		if v, ok := r.object.get(name, quoted); ok {
+			return v, true
+		}
+		return getPositional(name, len(r.object.keys), func(i int) interface{} {
+			return r.object.values[r.object.keys[i]]
+		})
+	}
+	for i, n := range r.names {
+		if i < len(r.fields) && (n == name || !quoted && strings.EqualFold(n, name)) {
+			return r.fields[i], true
+		}
+	}
+	return getPositional(name, len(r.fields), func(i int) interface{} {
+		return r.fields[i]
+	})
+}
+
+// value returns the whole record as a JSON object.
+func (r *selectRecord) value() interface{} {
+	if r.object != nil {
+		return r.object
+	}
+	obj := newJSONObject()
+	for i, field := range r.fields {
+		obj.set(r.columnName(i), field)
+	}
+	return obj
+}
+
+func (r *selectRecord) columnName(i int) string {
+	if i < len(r.names) && r.names[i] != "" {
+		return r.names[i]
+	}
+	return "_" + strconv.Itoa(i+1)
+}
+
+// selectRecordReader reads the records of the object one by one, io.EOF is returned after the last record.
+type selectRecordReader interface {
+	Read() (*selectRecord, error)
+}
+
+// countingReader adds the number of bytes read from the underlying reader to the counter.
+type countingReader struct {
+	r io.Reader
+	n *int64
+}
+
+func (c *countingReader) Read(p []byte) (n int, err error) {
+	n, err = c.r.Read(p)
+	atomic.AddInt64(c.n, int64(n))
+	return
+}
+
+// csvRecordReader reads the CSV records with the configurable delimiters, quote and escape characters.
+type csvRecordReader struct {
+	r           *bufio.Reader
+	input       *CSVInput
+	names       []string
+	recordDelim string
+	fieldDelim  string
+	quote       byte
+	escape      byte
+	comments    string
+
+	offset int64 // offset of the next byte relative to the start of reading
+	end    int64 // records starting after the end are not read, -1 means no limit
+}
+
+func newCSVRecordReader(r io.Reader, input *CSVInput) *csvRecordReader {
+	c := &csvRecordReader{
+		r:           bufio.NewReader(r),
+		input:       input,
+		recordDelim: "\n",
+		fieldDelim:  ",",
+		quote:       '"',
+		escape:      '"',
+		comments:    input.Comments,
+		end:         -1,
+	}
+	if input.RecordDelimiter != "" {
+		c.recordDelim = input.RecordDelimiter
+	}
+	if input.FieldDelimiter != "" {
+		c.fieldDelim = input.FieldDelimiter
+	}
+	if input.QuoteCharacter != "" {
+		c.quote = input.QuoteCharacter[0]
+	}
+	if input.QuoteEscapeCharacter != "" {
+		c.escape = input.QuoteEscapeCharacter[0]
+	}
+	return c
+}
+
+// readHeader reads the first line of the object as the column names if it is required.
+func (c *csvRecordReader) readHeader() error {
+	if err := c.skipComments(); err != nil && err != io.EOF {
+		return err
+	}
+	switch strings.ToUpper(c.input.FileHeaderInfo) {
+	case CSVFileHeaderUse:
+		fields, err := c.readFields()
+		if err != nil && err != io.EOF {
+			return err
+		}
+		c.names = fields
+	case CSVFileHeaderIgnore:
+		if _, err := c.readFields(); err != nil && err != io.EOF {
+			return err
+		}
+	}
+	return nil
+}
+
+// skipPartialRecord skips the bytes until the start of the next record, it is used if the reading starts
+// from the middle of the object.
+func (c *csvRecordReader) skipPartialRecord() error {
+	return skipToDelimiter(c.r, c.recordDelim, &c.offset)
+}
+
+func skipToDelimiter(r *bufio.Reader, delim string, offset *int64) error {
+	last := delim[len(delim)-1]
+	for {
+		line, err := r.ReadSlice(last)
+		*offset += int64(len(line))
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if len(delim) == 1 || bytes.HasSuffix(line, []byte(delim)) {
+			return nil
+		}
+	}
+}
+
+func (c *csvRecordReader) Read() (*selectRecord, error) {
+	for {
+		if c.end >= 0 && c.offset > c.end {
+			return nil, io.EOF
+		}
+		if err := c.skipComments(); err != nil {
+			return nil, err
+		}
+		start := c.offset
+		fields, err := c.readFields()
+		if err != nil {
+			return nil, err
+		}
+		// skip the empty lines
+		if len(fields) == 1 && fields[0] == "" && c.offset-start <= int64(len(c.recordDelim))+1 {
+			continue
+		}
+		return &selectRecord{names: c.names, fields: fields}, nil
+	}
+}
+
+// skipComments skips the lines starting with the comment prefix.
+func (c *csvRecordReader) skipComments() error {
+	if c.comments == "" {
+		return nil
+	}
+	for {
+		if prefix, _ := c.r.Peek(len(c.comments)); string(prefix) != c.comments {
+			return nil
+		}
+		if err := skipToDelimiter(c.r, c.recordDelim, &c.offset); err != nil {
+			return err
+		}
+	}
+}
+
+// match consumes the string s if the next bytes, the first of which is b, are s.
+func (c *csvRecordReader) match(b byte, s string) bool {
+	if b != s[0] {
+		return false
+	}
+	if len(s) > 1 {
+		next, err := c.r.Peek(len(s) - 1)
+		if err != nil || string(next) != s[1:] {
+			return false
+		}
+		_, _ = c.r.Discard(len(s) - 1)
+		c.offset += int64(len(s) - 1)
+	}
+	return true
+}
+
+func (c *csvRecordReader) readFields() (fields []string, err error) {
+	var (
+		field  []byte
+		quoted bool
+		size   int
+		start  = c.offset
+	)
+	for {
+		b, err := c.r.ReadByte()
+		if err == io.EOF {
+			if c.offset == start {
+				return nil, io.EOF
+			}
+			return append(fields, c.trimField(field, true)), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		c.offset++
+		if size++; size > maxSelectRecordSize {
+			return nil, OverMaxRecordSize
+		}
+		if quoted {
+			if b == c.escape && c.escape != c.quote {
+				if next, err := c.r.ReadByte(); err == nil {
+					c.offset++
+					field = append(field, next)
+				}
+				continue
+			}
+			if b == c.quote {
+				// a doubled quote is a literal quote
+				if next, err := c.r.Peek(1); err == nil && next[0] == c.quote && c.escape == c.quote {
+					_, _ = c.r.Discard(1)
+					c.offset++
+					field = append(field, c.quote)
+					continue
+				}
+				quoted = false
+				continue
+			}
+			field = append(field, b)
+			continue
+		}
+		switch {
+		case b == c.quote:
+			quoted = true
+		case c.match(b, c.recordDelim):
+			return append(fields, c.trimField(field, true)), nil
+		case c.match(b, c.fieldDelim):
+			fields = append(fields, c.trimField(field, false))
+			field = field[:0]
+		default:
+			field = append(field, b)
+		}
+	}
+}
+
+// trimField removes the carriage return at the end of the line if the record delimiter is the default one.
+func (c *csvRecordReader) trimField(field []byte, last bool) string {
+	if last && c.recordDelim == "\n" {
+		field = bytes.TrimSuffix(field, []byte{'\r'})
+	}
+	return string(field)
+}
+
+// jsonRecordReader reads the records of JSON LINES or JSON DOCUMENT objects.
+type jsonRecordReader struct {
+	r        *bufio.Reader
+	lines    bool
+	dec      *json.Decoder
+	fromPath []sqlPathElem
+	pending  []interface{}
+
+	offset int64
+	end    int64
+}
+
+func newJSONRecordReader(r io.Reader, input *JSONInput, fromPath []sqlPathElem) *jsonRecordReader {
+	j := &jsonRecordReader{
+		r:     bufio.NewReader(r),
+		lines: strings.EqualFold(input.Type, JSONTypeLines),
+		end:   -1,
+	}
+	if !j.lines {
+		j.dec = json.NewDecoder(j.r)
+		j.dec.UseNumber()
+	}
+	// the first wildcard refers to the top level values of the object
+	if len(fromPath) > 0 && fromPath[0].wildcard {
+		fromPath = fromPath[1:]
+	}
+	j.fromPath = fromPath
+	return j
+}
+
+func (j *jsonRecordReader) skipPartialRecord() error {
+	return skipToDelimiter(j.r, "\n", &j.offset)
+}
+
+func (j *jsonRecordReader) Read() (*selectRecord, error) {
+	for {
+		if len(j.pending) > 0 {
+			v := j.pending[0]
+			j.pending = j.pending[1:]
+			obj, ok := v.(*jsonObject)
+			if !ok {
+				obj = newJSONObject()
+				obj.set("_1", v)
+			}
+			return &selectRecord{object: obj}, nil
+		}
+		v, err := j.readValue()
+		if err != nil {
+			return nil, err
+		}
+		j.pending = expandJSONPath(v, j.fromPath, j.pending)
+	}
+}
+
+func (j *jsonRecordReader) readValue() (interface{}, error) {
+	if !j.lines {
+		v, err := decodeOrderedJSON(j.dec)
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, NewError("JSONParsingError", "Error parsing JSON file: "+err.Error(), 400)
+		}
+		return v, nil
+	}
+	for {
+		if j.end >= 0 && j.offset > j.end {
+			return nil, io.EOF
+		}
+		line, err := j.readLine()
+		if err == io.EOF && len(line) == 0 {
+			return nil, io.EOF
+		}
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		dec := json.NewDecoder(bytes.NewReader(line))
+		dec.UseNumber()
+		v, err := decodeOrderedJSON(dec)
+		if err != nil {
+			return nil, NewError("JSONParsingError", "Error parsing JSON file: "+err.Error(), 400)
+		}
+		return v, nil
+	}
+}
+
+func (j *jsonRecordReader) readLine() ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := j.r.ReadSlice('\n')
+		j.offset += int64(len(chunk))
+		line = append(line, chunk...)
+		if len(line) > maxSelectRecordSize {
+			return nil, OverMaxRecordSize
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		return line, err
+	}
+}
+
+// expandJSONPath appends the values of the path in v to the records, [*] iterates the elements of an array.
+func expandJSONPath(v interface{}, path []sqlPathElem, records []interface{}) []interface{} {
+	if len(path) == 0 {
+		return append(records, v)
+	}
+	elem := path[0]
+	switch x := v.(type) {
+	case *jsonObject:
+		if elem.wildcard || elem.index {
+			return records
+		}
+		if child, ok := x.get(elem.name, elem.quoted); ok {
+			return expandJSONPath(child, path[1:], records)
+		}
+	case []interface{}:
+		if elem.wildcard {
+			for _, child := range x {
+				records = expandJSONPath(child, path[1:], records)
+			}
+		} else if elem.index && elem.i < len(x) {
+			return expandJSONPath(x[elem.i], path[1:], records)
+		}
+	}
+	return records
+}
+
+// decodeOrderedJSON decodes the next JSON value, the objects are decoded as *jsonObject to keep the key order.
+func decodeOrderedJSON(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			obj := newJSONObject()
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, ok := keyTok.(string)
+				if !ok {
+					return nil, io.ErrUnexpectedEOF
+				}
+				value, err := decodeOrderedJSON(dec)
+				if err != nil {
+					return nil, unexpectedEOF(err)
+				}
+				obj.set(key, value)
+			}
+			if _, err = dec.Token(); err != nil {
+				return nil, unexpectedEOF(err)
+			}
+			return obj, nil
+		case '[':
+			arr := make([]interface{}, 0)
+			for dec.More() {
+				value, err := decodeOrderedJSON(dec)
+				if err != nil {
+					return nil, unexpectedEOF(err)
+				}
+				arr = append(arr, value)
+			}
+			if _, err = dec.Token(); err != nil {
+				return nil, unexpectedEOF(err)
+			}
+			return arr, nil
+		}
+		return nil, io.ErrUnexpectedEOF
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i, nil
+		}
+		f, err := t.Float64()
+		if err != nil {
+			return nil, err
+		}
+		return f, nil
+	default:
+		return t, nil
+	}
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// selectOutputRecord is a record of the query result, the values are in the order of the projections.
+type selectOutputRecord struct {
+	names  []string
+	values []interface{}
+}
+
+// selectRecordWriter serializes the records of the query result.
+type selectRecordWriter interface {
+	Write(buf *bytes.Buffer, rec *selectOutputRecord) error
+}
+
+type csvRecordWriter struct {
+	recordDelim string
+	fieldDelim  string
+	quote       string
+	escape      string
+	quoteAlways bool
+}
+
+func newCSVRecordWriter(output *CSVOutput) *csvRecordWriter {
+	c := &csvRecordWriter{
+		recordDelim: "\n",
+		fieldDelim:  ",",
+		quote:       `"`,
+		escape:      `"`,
+		quoteAlways: strings.EqualFold(output.QuoteFields, CSVQuoteFieldsAlways),
+	}
+	if output.RecordDelimiter != "" {
+		c.recordDelim = output.RecordDelimiter
+	}
+	if output.FieldDelimiter != "" {
+		c.fieldDelim = output.FieldDelimiter
+	}
+	if output.QuoteCharacter != "" {
+		c.quote = output.QuoteCharacter
+	}
+	if output.QuoteEscapeCharacter != "" {
+		c.escape = output.QuoteEscapeCharacter
+	}
+	return c
+}
+
+func (c *csvRecordWriter) Write(buf *bytes.Buffer, rec *selectOutputRecord) error {
+	for i, v := range rec.values {
+		if i > 0 {
+			buf.WriteString(c.fieldDelim)
+		}
+		field := formatSelectValue(v)
+		if c.quoteAlways || strings.Contains(field, c.fieldDelim) || strings.Contains(field, c.quote) ||
+			strings.Contains(field, c.recordDelim) || strings.ContainsAny(field, "\r\n") {
+			buf.WriteString(c.quote)
+			buf.WriteString(strings.ReplaceAll(field, c.quote, c.escape+c.quote))
+			buf.WriteString(c.quote)
+		} else {
+			buf.WriteString(field)
+		}
+	}
+	buf.WriteString(c.recordDelim)
+	return nil
+}
+
+type jsonRecordWriter struct {
+	recordDelim string
+}
+
+func newJSONRecordWriter(output *JSONOutput) *jsonRecordWriter {
+	j := &jsonRecordWriter{recordDelim: "\n"}
+	if output.RecordDelimiter != "" {
+		j.recordDelim = output.RecordDelimiter
+	}
+	return j
+}
+
+func (j *jsonRecordWriter) Write(buf *bytes.Buffer, rec *selectOutputRecord) error {
+	obj := newJSONObject()
+	for i, v := range rec.values {
+		obj.set(rec.names[i], v)
+	}
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+	buf.WriteString(j.recordDelim)
+	return nil
+}
+
+func writeJSONValue(buf *bytes.Buffer, v interface{}) error {
+	switch x := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case int64:
+		buf.WriteString(formatSelectValue(x))
+	case float64:
+		if math.IsInf(x, 0) || math.IsNaN(x) {
+			buf.WriteString("null")
+		} else {
+			buf.WriteString(formatSelectValue(x))
+		}
+	case *jsonObject:
+		data, err := x.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, item := range x {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSONValue(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		data, err := json.Marshal(x)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+	}
+	return nil
+}
+
+// formatSelectValue formats the value as a string, the objects and arrays are formatted as JSON.
+func formatSelectValue(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case bool:
+		return strconv.FormatBool(x)
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case float64:
+		if math.IsInf(x, 0) || math.IsNaN(x) {
+			return strconv.FormatFloat(x, 'g', -1, 64)
+		}
+		if abs := math.Abs(x); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+			return strconv.FormatFloat(x, 'g', -1, 64)
+		}
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	default:
+		var buf bytes.Buffer
+		if err := writeJSONValue(&buf, x); err != nil {
+			return ""
+		}
+		return buf.String()
+	}
+}
@@ -0,0 +1,312 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go/private/protocol/eventstream"
+	"github.com/cubefs/cubefs/blobstore/common/trace"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	selectProgressInterval  = time.Second
+	selectKeepAliveInterval = 5 * time.Second
+)
+
+// Select object content
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_SelectObjectContent.html
+func (o *ObjectNode) selectObjectContentHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+
+	span := trace.SpanFromContextSafe(r.Context())
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	if param.Object() == "" {
+		errorCode = InvalidKey
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("selectObjectContentHandler: load volume fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), param.Object(), err)
+		return
+	}
+
+	// QPS and Concurrency Limit
+	rateLimit := o.AcquireRateLimiter()
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+		return
+	}
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+
+	var body []byte
+	if body, err = io.ReadAll(io.LimitReader(r.Body, MaxSelectRequestSize+1)); err != nil {
+		log.LogErrorf("selectObjectContentHandler: read request body fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+	if len(body) > MaxSelectRequestSize {
+		errorCode = EntityTooLarge
+		return
+	}
+	var req *SelectObjectContentRequest
+	if req, err = ParseSelectRequestFromXML(body); err != nil {
+		log.LogErrorf("selectObjectContentHandler: parse request fail: requestID(%v) volume(%v) path(%v) request(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), string(body), err)
+		return
+	}
+	var executor *selectExecutor
+	if executor, err = newSelectExecutor(req); err != nil {
+		log.LogErrorf("selectObjectContentHandler: parse expression fail: requestID(%v) volume(%v) path(%v) expression(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), req.Expression, err)
+		return
+	}
+
+	// get object meta
+	start := time.Now()
+	fileInfo, xattr, err := vol.ObjectVersionMeta(param.Object(), "")
+	span.AppendTrackLog("meta.r", start, err)
+	if err != nil {
+		log.LogErrorf("selectObjectContentHandler: get file meta fail: requestId(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		if err == syscall.ENOENT {
+			errorCode = NoSuchKey
+		}
+		return
+	}
+
+	// check server-side encryption
+	sse, dataKey, err := checkObjectSSE(w, r, xattr)
+	if err != nil {
+		log.LogErrorf("selectObjectContentHandler: check sse fail: requestId(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+
+	source := &volumeSelectSource{vol: vol, path: param.Object(), info: fileInfo, sse: sse, dataKey: dataKey}
+	stream := newSelectEventStream(w)
+	stop := make(chan struct{})
+	var progress func() *SelectStats
+	if req.RequestProgress != nil && req.RequestProgress.Enabled {
+		progress = executor.Stats
+	}
+	go stream.keepAlive(progress, stop)
+
+	start = time.Now()
+	err = executor.Run(source, stream)
+	close(stop)
+	span.AppendTrackLog("select.r", start, err)
+	if err != nil {
+		log.LogErrorf("selectObjectContentHandler: run query fail: requestID(%v) volume(%v) path(%v) expression(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), req.Expression, err)
+		// the error is responded as an error message once the event stream is started
+		if stream.Started() {
+			stream.SendError(err)
+			err = nil
+		}
+		return
+	}
+	if err = stream.SendStats(executor.Stats()); err == nil {
+		err = stream.SendEnd()
+	}
+	if err != nil {
+		log.LogWarnf("selectObjectContentHandler: send response fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		err = nil
+	}
+}
+
+// volumeSelectSource reads the object data to query from the volume.
+type volumeSelectSource struct {
+	vol     *Volume
+	path    string
+	info    *FSFileInfo
+	sse     *sseMeta
+	dataKey []byte
+}
+
+func (s *volumeSelectSource) Size() int64 {
+	return s.info.Size
+}
+
+func (s *volumeSelectSource) NewReader(offset, length int64) (io.ReadCloser, error) {
+	if offset >= s.info.Size || length <= 0 {
+		return io.NopCloser(bytes.NewReader(nil)), nil
+	}
+	if remain := s.info.Size - offset; length > remain {
+		length = remain
+	}
+	reader, writer := io.Pipe()
+	go func() {
+		var w io.Writer = writer
+		if s.sse != nil {
+			w = s.sse.decryptWriter(writer, s.dataKey, uint64(offset))
+		}
+		err := s.vol.readFile(s.info.Inode, uint64(s.info.Size), s.path, w, uint64(offset),
+			uint64(length), s.info.StorageClass)
+		writer.CloseWithError(err)
+	}()
+	return reader, nil
+}
+
+// selectEventStream writes the messages of the SelectObjectContent response in the event stream encoding.
+// The response status is sent with the first message, the errors occurred before that are responded as
+// the normal error responses.
+type selectEventStream struct {
+	w        http.ResponseWriter
+	encoder  *eventstream.Encoder
+	lock     sync.Mutex
+	started  bool
+	lastSent time.Time
+	err      error
+}
+
+func newSelectEventStream(w http.ResponseWriter) *selectEventStream {
+	return &selectEventStream{w: w, encoder: eventstream.NewEncoder(w)}
+}
+
+func (s *selectEventStream) Started() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.started
+}
+
+func (s *selectEventStream) send(headers eventstream.Headers, payload []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	if !s.started {
+		s.w.WriteHeader(http.StatusOK)
+		s.started = true
+	}
+	if s.err = s.encoder.Encode(eventstream.Message{Headers: headers, Payload: payload}); s.err != nil {
+		return s.err
+	}
+	if flusher, ok := s.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	s.lastSent = time.Now()
+	return nil
+}
+
+func selectEventHeaders(eventType, contentType string) eventstream.Headers {
+	var headers eventstream.Headers
+	headers.Set(":message-type", eventstream.StringValue("event"))
+	headers.Set(":event-type", eventstream.StringValue(eventType))
+	if contentType != "" {
+		headers.Set(":content-type", eventstream.StringValue(contentType))
+	}
+	return headers
+}
+
+func (s *selectEventStream) SendRecords(payload []byte) error {
+	return s.send(selectEventHeaders("Records", ValueContentTypeStream), payload)
+}
+
+// selectStatsMessage is the payload of the Progress and Stats messages, which contain the same details.
+type selectStatsMessage struct {
+	XMLName xml.Name
+	*SelectStats
+}
+
+func (s *selectEventStream) sendStats(eventType string, stats *SelectStats) error {
+	payload, err := MarshalXMLEntity(&selectStatsMessage{XMLName: xml.Name{Local: eventType}, SelectStats: stats})
+	if err != nil {
+		return err
+	}
+	return s.send(selectEventHeaders(eventType, "text/xml"), payload)
+}
+
+func (s *selectEventStream) SendProgress(stats *SelectStats) error {
+	return s.sendStats("Progress", stats)
+}
+
+func (s *selectEventStream) SendStats(stats *SelectStats) error {
+	return s.sendStats("Stats", stats)
+}
+
+func (s *selectEventStream) SendCont() error {
+	return s.send(selectEventHeaders("Cont", ""), nil)
+}
+
+func (s *selectEventStream) SendEnd() error {
+	return s.send(selectEventHeaders("End", ""), nil)
+}
+
+func (s *selectEventStream) SendError(err error) error {
+	ec, ok := err.(*ErrorCode)
+	if !ok {
+		ec = InternalErrorCode(err)
+	}
+	var headers eventstream.Headers
+	headers.Set(":message-type", eventstream.StringValue("error"))
+	headers.Set(":error-code", eventstream.StringValue(ec.ErrorCode))
+	headers.Set(":error-message", eventstream.StringValue(ec.ErrorMessage))
+	return s.send(headers, nil)
+}
+
+// keepAlive sends the Progress messages periodically if the progress is requested, and sends the Cont
+// messages to keep the connection alive if no message is sent for a while.
+func (s *selectEventStream) keepAlive(progress func() *SelectStats, stop <-chan struct{}) {
+	ticker := time.NewTicker(selectProgressInterval)
+	defer ticker.Stop()
+	begin := time.Now()
+	var last *SelectStats
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		if progress != nil {
+			if stats := progress(); last == nil || *stats != *last {
+				last = stats
+				if s.SendProgress(stats) != nil {
+					return
+				}
+				continue
+			}
+		}
+		s.lock.Lock()
+		lastSent := s.lastSent
+		s.lock.Unlock()
+		if lastSent.IsZero() {
+			lastSent = begin
+		}
+		if time.Since(lastSent) >= selectKeepAliveInterval && s.SendCont() != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,854 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// The reader of Parquet objects supports the flat schemas, which is what S3 Select supports, with the
+// PLAIN, RLE and dictionary encodings and the UNCOMPRESSED, SNAPPY, GZIP and ZSTD compression codecs.
+// The metadata of Parquet is serialized with the thrift compact protocol, which is decoded generically
+// into thriftStruct and read by the field ids defined in parquet.thrift.
+
+const (
+	parquetMagic         = "PAR1"
+	parquetFooterSize    = 8
+	maxParquetFooterSize = 64 << 20
+	// the page header is read with a small buffer first, which is enlarged if the header is larger
+	parquetPageHeaderReadSize = 8 << 10
+	maxParquetPageHeaderSize  = 16 << 20
+	// the approximate memory size of a decoded value
+	parquetValueSize = 16
+
+	parquetBoolean           = 0
+	parquetInt32             = 1
+	parquetInt64             = 2
+	parquetInt96             = 3
+	parquetFloat             = 4
+	parquetDouble            = 5
+	parquetByteArray         = 6
+	parquetFixedLenByteArray = 7
+
+	parquetRequired = 0
+	parquetOptional = 1
+	parquetRepeated = 2
+
+	parquetConvertedDecimal         = 5
+	parquetConvertedDate            = 6
+	parquetConvertedTimestampMillis = 9
+	parquetConvertedTimestampMicros = 10
+
+	parquetEncodingPlain          = 0
+	parquetEncodingPlainDictonary = 2
+	parquetEncodingRLE            = 3
+	parquetEncodingBitPacked      = 4
+	parquetEncodingRLEDictionary  = 8
+
+	parquetCodecUncompressed = 0
+	parquetCodecSnappy       = 1
+	parquetCodecGzip         = 2
+	parquetCodecZstd         = 6
+
+	parquetPageData       = 0
+	parquetPageDictionary = 2
+	parquetPageDataV2     = 3
+)
+
+var (
+	errInvalidParquet = errors.New("invalid parquet file")
+	errThriftInvalid  = errors.New("invalid thrift data")
+)
+
+func newParquetError(format string, args ...interface{}) *ErrorCode {
+	return NewError("ParquetParsingError", fmt.Sprintf(format, args...), 400)
+}
+
+// thriftStruct is the decoded thrift struct, the values are indexed by the field ids, and they are
+// bool, int64, float64, []byte, []interface{} or thriftStruct.
+type thriftStruct map[int16]interface{}
+
+func (s thriftStruct) int(id int16) int64 {
+	v, _ := s[id].(int64)
+	return v
+}
+
+func (s thriftStruct) has(id int16) bool {
+	_, ok := s[id]
+	return ok
+}
+
+func (s thriftStruct) str(id int16) string {
+	v, _ := s[id].([]byte)
+	return string(v)
+}
+
+func (s thriftStruct) boolean(id int16, def bool) bool {
+	if v, ok := s[id].(bool); ok {
+		return v
+	}
+	return def
+}
+
+func (s thriftStruct) child(id int16) thriftStruct {
+	v, _ := s[id].(thriftStruct)
+	return v
+}
+
+func (s thriftStruct) list(id int16) []interface{} {
+	v, _ := s[id].([]interface{})
+	return v
+}
+
+const (
+	thriftStop       = 0
+	thriftTrue       = 1
+	thriftFalse      = 2
+	thriftByte       = 3
+	thriftI16        = 4
+	thriftI32        = 5
+	thriftI64        = 6
+	thriftDouble     = 7
+	thriftBinary     = 8
+	thriftList       = 9
+	thriftSet        = 10
+	thriftMap        = 11
+	thriftStructType = 12
+
+	maxThriftDepth = 64
+)
+
+type thriftDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *thriftDecoder) readByte() (byte, error) {
+	if d.pos >= len(d.data) {
+		return 0, errThriftInvalid
+	}
+	b := d.data[d.pos]
+	d.pos++
+	return b, nil
+}
+
+func (d *thriftDecoder) readUvarint() (uint64, error) {
+	v, n := binary.Uvarint(d.data[d.pos:])
+	if n <= 0 {
+		return 0, errThriftInvalid
+	}
+	d.pos += n
+	return v, nil
+}
+
+func (d *thriftDecoder) readVarint() (int64, error) {
+	v, err := d.readUvarint()
+	return int64(v>>1) ^ -int64(v&1), err
+}
+
+func (d *thriftDecoder) readStruct(depth int) (thriftStruct, error) {
+	if depth > maxThriftDepth {
+		return nil, errThriftInvalid
+	}
+	s := make(thriftStruct)
+	var lastID int16
+	for {
+		b, err := d.readByte()
+		if err != nil {
+			return nil, err
+		}
+		typ := b & 0x0f
+		if typ == thriftStop {
+			return s, nil
+		}
+		id := lastID + int16(b>>4)
+		if b>>4 == 0 {
+			v, err := d.readVarint()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(v)
+		}
+		lastID = id
+		var value interface{}
+		switch typ {
+		case thriftTrue:
+			value = true
+		case thriftFalse:
+			value = false
+		default:
+			if value, err = d.readValue(typ, depth); err != nil {
+				return nil, err
+			}
+		}
+		s[id] = value
+	}
+}
+
+func (d *thriftDecoder) readValue(typ byte, depth int) (interface{}, error) {
+	switch typ {
+	case thriftTrue, thriftFalse:
+		// the booleans in the lists are encoded as a byte
+		b, err := d.readByte()
+		return b == thriftTrue, err
+	case thriftByte:
+		b, err := d.readByte()
+		return int64(int8(b)), err
+	case thriftI16, thriftI32, thriftI64:
+		return d.readVarint()
+	case thriftDouble:
+		if d.pos+8 > len(d.data) {
+			return nil, errThriftInvalid
+		}
+		v := math.Float64frombits(binary.LittleEndian.Uint64(d.data[d.pos:]))
+		d.pos += 8
+		return v, nil
+	case thriftBinary:
+		n, err := d.readUvarint()
+		if err != nil {
+			return nil, err
+		}
+		if n > uint64(len(d.data)-d.pos) {
+			return nil, errThriftInvalid
+		}
+		v := d.data[d.pos : d.pos+int(n)]
+		d.pos += int(n)
+		return v, nil
+	case thriftList, thriftSet:
+		b, err := d.readByte()
+		if err != nil {
+			return nil, err
+		}
+		n := uint64(b >> 4)
+		if n == 15 {
+			if n, err = d.readUvarint(); err != nil {
+				return nil, err
+			}
+		}
+		if n > uint64(len(d.data)-d.pos) {
+			return nil, errThriftInvalid
+		}
+		list := make([]interface{}, 0, n)
+		for i := uint64(0); i < n; i++ {
+			v, err := d.readValue(b&0x0f, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case thriftMap:
+		n, err := d.readUvarint()
+		if err != nil || n == 0 {
+			return nil, err
+		}
+		if n > uint64(len(d.data)-d.pos) {
+			return nil, errThriftInvalid
+		}
+		b, err := d.readByte()
+		if err != nil {
+			return nil, err
+		}
+		// the maps are not used by the parquet metadata, they are skipped
+		for i := uint64(0); i < n; i++ {
+			if _, err = d.readValue(b>>4, depth+1); err != nil {
+				return nil, err
+			}
+			if _, err = d.readValue(b&0x0f, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	case thriftStructType:
+		return d.readStruct(depth + 1)
+	}
+	return nil, errThriftInvalid
+}
+
+// parquetColumn is a leaf column of the flat schema.
+type parquetColumn struct {
+	name          string
+	typ           int64
+	typeLength    int64
+	repetition    int64
+	convertedType int64
+	scale         int64
+}
+
+// parquetRecordReader reads the rows of the Parquet object, one row group at a time. The pages of column chunks
+// are read one by one, and the sizes in the metadata are checked against the object size and the memory limit
+// before any buffer is allocated. The decoded values of the row group are limited by the memory limit.
+type parquetRecordReader struct {
+	r         io.ReaderAt
+	size      int64
+	limit     int64
+	used      int64 // the memory used by the decoded values of the current row group
+	columns   []*parquetColumn
+	rowGroups []thriftStruct
+
+	rows    [][]interface{} // values of the columns in the current row group
+	numRows int
+	row     int
+}
+
+// newParquetRecordReader reads the footer of the Parquet object. Only the row groups whose data starts
+// within [start, end] are read, end < 0 means the end of the object. The limit is the memory limit of the
+// decoded values of a row group.
+func newParquetRecordReader(r io.ReaderAt, size, start, end, limit int64) (*parquetRecordReader, error) {
+	if size < int64(len(parquetMagic)+parquetFooterSize) {
+		return nil, newParquetError("the object is too small to be a parquet file")
+	}
+	footer := make([]byte, parquetFooterSize)
+	if _, err := r.ReadAt(footer, size-parquetFooterSize); err != nil {
+		return nil, err
+	}
+	if string(footer[4:]) != parquetMagic {
+		return nil, newParquetError("the object is not a parquet file")
+	}
+	metaLen := int64(binary.LittleEndian.Uint32(footer))
+	if metaLen > maxParquetFooterSize || metaLen > limit || metaLen > size-parquetFooterSize-int64(len(parquetMagic)) {
+		return nil, newParquetError("invalid parquet footer length %d", metaLen)
+	}
+	data := make([]byte, metaLen)
+	if _, err := r.ReadAt(data, size-parquetFooterSize-metaLen); err != nil {
+		return nil, err
+	}
+	d := &thriftDecoder{data: data}
+	meta, err := d.readStruct(0)
+	if err != nil {
+		return nil, newParquetError("invalid parquet metadata: %v", err)
+	}
+
+	p := &parquetRecordReader{r: r, size: size, limit: limit}
+	schema := meta.list(2)
+	if len(schema) == 0 {
+		return nil, newParquetError("empty parquet schema")
+	}
+	for _, elem := range schema[1:] {
+		e, _ := elem.(thriftStruct)
+		if e == nil {
+			return nil, errInvalidParquet
+		}
+		if e.int(5) > 0 || e.int(3) == parquetRepeated {
+			return nil, newParquetError("nested or repeated column %q is not supported", e.str(4))
+		}
+		p.columns = append(p.columns, &parquetColumn{
+			name:          e.str(4),
+			typ:           e.int(1),
+			typeLength:    e.int(2),
+			repetition:    e.int(3),
+			convertedType: -1,
+			scale:         e.int(7),
+		})
+		if e.has(6) {
+			p.columns[len(p.columns)-1].convertedType = e.int(6)
+		}
+	}
+	if len(p.columns) == 0 {
+		return nil, newParquetError("empty parquet schema")
+	}
+	for _, item := range meta.list(4) {
+		rg, _ := item.(thriftStruct)
+		if rg == nil {
+			return nil, errInvalidParquet
+		}
+		chunks := rg.list(1)
+		if len(chunks) != len(p.columns) {
+			return nil, newParquetError("the number of column chunks does not match the schema")
+		}
+		if first, _ := chunks[0].(thriftStruct); first != nil {
+			offset := columnChunkOffset(first.child(3))
+			if offset < start || end >= 0 && offset > end {
+				continue
+			}
+		}
+		p.rowGroups = append(p.rowGroups, rg)
+	}
+	return p, nil
+}
+
+func columnChunkOffset(meta thriftStruct) int64 {
+	offset := meta.int(9)
+	if dict := meta.int(11); meta.has(11) && dict > 0 && dict < offset {
+		offset = dict
+	}
+	return offset
+}
+
+func (p *parquetRecordReader) Read() (*selectRecord, error) {
+	for p.row >= p.numRows {
+		if len(p.rowGroups) == 0 {
+			return nil, io.EOF
+		}
+		if err := p.readRowGroup(p.rowGroups[0]); err != nil {
+			return nil, err
+		}
+		p.rowGroups = p.rowGroups[1:]
+	}
+	obj := newJSONObject()
+	for i, col := range p.columns {
+		obj.set(col.name, p.rows[i][p.row])
+	}
+	p.row++
+	return &selectRecord{object: obj}, nil
+}
+
+// reserve accounts the memory to be used by the decoded values of the current row group.
+func (p *parquetRecordReader) reserve(size int64) error {
+	if size < 0 || size > p.limit-p.used {
+		return newParquetError("the row group exceeds the memory limit of %d bytes", p.limit)
+	}
+	p.used += size
+	return nil
+}
+
+func (p *parquetRecordReader) readRowGroup(rg thriftStruct) (err error) {
+	numRows := rg.int(3)
+	if numRows < 0 || numRows > math.MaxInt32 {
+		return newParquetError("invalid number of rows %d", numRows)
+	}
+	p.rows, p.used = nil, 0
+	if numRows > p.limit/parquetValueSize/int64(len(p.columns)) {
+		return newParquetError("the row group exceeds the memory limit of %d bytes", p.limit)
+	}
+	if err = p.reserve(numRows * int64(len(p.columns)) * parquetValueSize); err != nil {
+		return err
+	}
+	p.rows = make([][]interface{}, len(p.columns))
+	for i, item := range rg.list(1) {
+		chunk, _ := item.(thriftStruct)
+		meta := chunk.child(3)
+		if meta == nil {
+			return newParquetError("the column chunk of %q has no metadata", p.columns[i].name)
+		}
+		if p.rows[i], err = p.readColumnChunk(p.columns[i], meta, int(numRows)); err != nil {
+			return err
+		}
+	}
+	p.numRows = int(numRows)
+	p.row = 0
+	return nil
+}
+
+// readPageHeader reads the page header at the offset of the column chunk ending at end,
+// the size of the header is returned.
+func (p *parquetRecordReader) readPageHeader(offset, end int64) (header thriftStruct, size int64, err error) {
+	window := int64(parquetPageHeaderReadSize)
+	for {
+		if window > end-offset {
+			window = end - offset
+		}
+		buf := make([]byte, window)
+		if _, err = p.r.ReadAt(buf, offset); err != nil {
+			return
+		}
+		d := &thriftDecoder{data: buf}
+		if header, err = d.readStruct(0); err == nil {
+			return header, int64(d.pos), nil
+		}
+		// the header may be truncated by the buffer
+		if window == end-offset || window >= maxParquetPageHeaderSize {
+			return
+		}
+		window *= 2
+	}
+}
+
+func (p *parquetRecordReader) readColumnChunk(col *parquetColumn, meta thriftStruct, numRows int) ([]interface{}, error) {
+	offset, length := columnChunkOffset(meta), meta.int(7)
+	if offset < 0 || length < 0 || offset > p.size || length > p.size-offset {
+		return nil, newParquetError("invalid column chunk of %q", col.name)
+	}
+	end := offset + length
+	codec := meta.int(4)
+
+	values := make([]interface{}, 0, numRows)
+	var dict []interface{}
+	for len(values) < numRows && offset < end {
+		header, headerSize, err := p.readPageHeader(offset, end)
+		if err != nil {
+			if err == errThriftInvalid {
+				return nil, newParquetError("invalid page header of %q: %v", col.name, err)
+			}
+			return nil, err
+		}
+		offset += headerSize
+		size := header.int(3)
+		if size < 0 || size > end-offset || size > p.limit {
+			return nil, newParquetError("invalid page size of %q", col.name)
+		}
+		page := make([]byte, size)
+		if _, err = p.r.ReadAt(page, offset); err != nil {
+			return nil, err
+		}
+		offset += size
+
+		switch header.int(1) {
+		case parquetPageDictionary:
+			dh := header.child(7)
+			numValues := dh.int(1)
+			if numValues < 0 || numValues > p.limit/parquetValueSize {
+				return nil, newParquetError("invalid dictionary size of %q", col.name)
+			}
+			if err = p.reserve(header.int(2) + numValues*parquetValueSize); err != nil {
+				return nil, err
+			}
+			if page, err = decompressParquetPage(codec, page, header.int(2)); err != nil {
+				return nil, err
+			}
+			if dict, _, err = decodeParquetPlain(col, page, int(numValues)); err != nil {
+				return nil, err
+			}
+		case parquetPageData:
+			dh := header.child(5)
+			if err = p.reserve(header.int(2)); err != nil {
+				return nil, err
+			}
+			if page, err = decompressParquetPage(codec, page, header.int(2)); err != nil {
+				return nil, err
+			}
+			numValues := int(dh.int(1))
+			if numValues < 0 || numValues > numRows-len(values) {
+				return nil, newParquetError("invalid number of values of %q", col.name)
+			}
+			var defLevels []int
+			if col.repetition == parquetOptional {
+				if len(page) < 4 {
+					return nil, errInvalidParquet
+				}
+				n := int(binary.LittleEndian.Uint32(page))
+				if n > len(page)-4 {
+					return nil, errInvalidParquet
+				}
+				if defLevels, err = decodeRLEHybrid(page[4:4+n], 1, numValues); err != nil {
+					return nil, err
+				}
+				page = page[4+n:]
+			}
+			if values, err = decodeParquetPage(col, values, page, dh.int(2), defLevels, numValues, dict); err != nil {
+				return nil, err
+			}
+		case parquetPageDataV2:
+			dh := header.child(8)
+			numValues := int(dh.int(1))
+			if numValues < 0 || numValues > numRows-len(values) {
+				return nil, newParquetError("invalid number of values of %q", col.name)
+			}
+			if err = p.reserve(header.int(2)); err != nil {
+				return nil, err
+			}
+			defLen, repLen := dh.int(5), dh.int(6)
+			if defLen < 0 || repLen < 0 || defLen+repLen > int64(len(page)) {
+				return nil, errInvalidParquet
+			}
+			var defLevels []int
+			if col.repetition == parquetOptional {
+				if defLevels, err = decodeRLEHybrid(page[repLen:repLen+defLen], 1, numValues); err != nil {
+					return nil, err
+				}
+			}
+			page = page[repLen+defLen:]
+			if dh.boolean(7, true) {
+				if page, err = decompressParquetPage(codec, page, header.int(2)-defLen-repLen); err != nil {
+					return nil, err
+				}
+			}
+			if values, err = decodeParquetPage(col, values, page, dh.int(4), defLevels, numValues, dict); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(values) != numRows {
+		return nil, newParquetError("the column %q has %d values but expected %d", col.name, len(values), numRows)
+	}
+	return values, nil
+}
+
+func decompressParquetPage(codec int64, page []byte, uncompressedSize int64) ([]byte, error) {
+	if uncompressedSize < 0 || uncompressedSize > math.MaxInt32 {
+		return nil, errInvalidParquet
+	}
+	switch codec {
+	case parquetCodecUncompressed:
+		return page, nil
+	case parquetCodecSnappy:
+		// the decoded length is read from the page, which must not exceed the size in the header
+		if n, err := snappy.DecodedLen(page); err != nil || int64(n) > uncompressedSize {
+			return nil, errInvalidParquet
+		}
+		return snappy.Decode(nil, page)
+	case parquetCodecGzip:
+		r, err := gzip.NewReader(bytes.NewReader(page))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return readDecompressedPage(r, uncompressedSize)
+	case parquetCodecZstd:
+		dec, err := zstd.NewReader(bytes.NewReader(page), zstd.WithDecoderConcurrency(1), zstd.WithDecoderLowmem(true))
+		if err != nil {
+			return nil, err
+		}
+		defer dec.Close()
+		return readDecompressedPage(dec, uncompressedSize)
+	}
+	return nil, newParquetError("unsupported parquet compression codec %d", codec)
+}
+
+// readDecompressedPage reads the decompressed page, which must not exceed the size in the header.
+func readDecompressedPage(r io.Reader, uncompressedSize int64) ([]byte, error) {
+	buf := bytes.NewBuffer(make([]byte, 0, uncompressedSize))
+	if _, err := io.Copy(buf, io.LimitReader(r, uncompressedSize+1)); err != nil {
+		return nil, err
+	}
+	if int64(buf.Len()) > uncompressedSize {
+		return nil, errInvalidParquet
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeParquetPage decodes the values of the data page, nil is filled for the null values.
+func decodeParquetPage(col *parquetColumn, values []interface{}, page []byte, encoding int64,
+	defLevels []int, numValues int, dict []interface{}) ([]interface{}, error) {
+	numNonNull := numValues
+	if defLevels != nil {
+		numNonNull = 0
+		for _, level := range defLevels {
+			numNonNull += level
+		}
+	}
+	var (
+		nonNull []interface{}
+		err     error
+	)
+	switch encoding {
+	case parquetEncodingPlain:
+		if nonNull, _, err = decodeParquetPlain(col, page, numNonNull); err != nil {
+			return nil, err
+		}
+	case parquetEncodingPlainDictonary, parquetEncodingRLEDictionary:
+		if dict == nil {
+			return nil, newParquetError("the dictionary page of %q is missing", col.name)
+		}
+		if len(page) == 0 {
+			if numNonNull > 0 {
+				return nil, errInvalidParquet
+			}
+			break
+		}
+		indexes, err := decodeRLEHybrid(page[1:], int(page[0]), numNonNull)
+		if err != nil {
+			return nil, err
+		}
+		nonNull = make([]interface{}, numNonNull)
+		for i, idx := range indexes {
+			if idx >= len(dict) {
+				return nil, newParquetError("invalid dictionary index of %q", col.name)
+			}
+			nonNull[i] = dict[idx]
+		}
+	case parquetEncodingRLE:
+		if col.typ != parquetBoolean || len(page) < 4 {
+			return nil, newParquetError("unsupported RLE encoding of %q", col.name)
+		}
+		levels, err := decodeRLEHybrid(page[4:], 1, numNonNull)
+		if err != nil {
+			return nil, err
+		}
+		nonNull = make([]interface{}, numNonNull)
+		for i, level := range levels {
+			nonNull[i] = level == 1
+		}
+	default:
+		return nil, newParquetError("unsupported parquet encoding %d of %q", encoding, col.name)
+	}
+	if defLevels == nil {
+		return append(values, nonNull...), nil
+	}
+	j := 0
+	for _, level := range defLevels {
+		if level == 0 {
+			values = append(values, nil)
+			continue
+		}
+		values = append(values, nonNull[j])
+		j++
+	}
+	return values, nil
+}
+
+// decodeRLEHybrid decodes n values of the RLE/bit-packing hybrid encoding.
+func decodeRLEHybrid(data []byte, bitWidth, n int) ([]int, error) {
+	if bitWidth < 0 || bitWidth > 32 {
+		return nil, errInvalidParquet
+	}
+	values := make([]int, 0, n)
+	byteWidth := (bitWidth + 7) / 8
+	pos := 0
+	for len(values) < n {
+		header, k := binary.Uvarint(data[pos:])
+		if k <= 0 {
+			return nil, errInvalidParquet
+		}
+		pos += k
+		if header&1 == 0 {
+			count := int(header >> 1)
+			if pos+byteWidth > len(data) {
+				return nil, errInvalidParquet
+			}
+			v := 0
+			for i := 0; i < byteWidth; i++ {
+				v |= int(data[pos+i]) << (8 * i)
+			}
+			pos += byteWidth
+			for i := 0; i < count && len(values) < n; i++ {
+				values = append(values, v)
+			}
+			continue
+		}
+		groups := int(header >> 1)
+		size := groups * bitWidth
+		if groups < 0 || size < 0 || pos+size > len(data) {
+			return nil, errInvalidParquet
+		}
+		for i := 0; i < groups*8 && len(values) < n; i++ {
+			v := 0
+			for b := 0; b < bitWidth; b++ {
+				bit := i*bitWidth + b
+				if data[pos+bit/8]>>(bit%8)&1 == 1 {
+					v |= 1 << b
+				}
+			}
+			values = append(values, v)
+		}
+		pos += size
+	}
+	return values, nil
+}
+
+// decodeParquetPlain decodes n values of the PLAIN encoding, the number of bytes consumed is returned.
+func decodeParquetPlain(col *parquetColumn, data []byte, n int) ([]interface{}, int, error) {
+	values := make([]interface{}, 0, n)
+	pos := 0
+	need := func(size int) error {
+		if size < 0 || pos+size > len(data) {
+			return newParquetError("the data page of %q is truncated", col.name)
+		}
+		return nil
+	}
+	for i := 0; i < n; i++ {
+		switch col.typ {
+		case parquetBoolean:
+			if i/8 >= len(data) {
+				return nil, 0, newParquetError("the data page of %q is truncated", col.name)
+			}
+			values = append(values, data[i/8]>>(i%8)&1 == 1)
+			pos = i/8 + 1
+		case parquetInt32:
+			if err := need(4); err != nil {
+				return nil, 0, err
+			}
+			values = append(values, col.convertInt(int64(int32(binary.LittleEndian.Uint32(data[pos:])))))
+			pos += 4
+		case parquetInt64:
+			if err := need(8); err != nil {
+				return nil, 0, err
+			}
+			values = append(values, col.convertInt(int64(binary.LittleEndian.Uint64(data[pos:]))))
+			pos += 8
+		case parquetInt96:
+			if err := need(12); err != nil {
+				return nil, 0, err
+			}
+			nanos := int64(binary.LittleEndian.Uint64(data[pos:]))
+			julianDay := int64(binary.LittleEndian.Uint32(data[pos+8:]))
+			// julian day 2440588 is the unix epoch
+			t := time.Unix((julianDay-2440588)*86400, nanos).UTC()
+			values = append(values, t.Format(time.RFC3339Nano))
+			pos += 12
+		case parquetFloat:
+			if err := need(4); err != nil {
+				return nil, 0, err
+			}
+			values = append(values, float64(math.Float32frombits(binary.LittleEndian.Uint32(data[pos:]))))
+			pos += 4
+		case parquetDouble:
+			if err := need(8); err != nil {
+				return nil, 0, err
+			}
+			values = append(values, math.Float64frombits(binary.LittleEndian.Uint64(data[pos:])))
+			pos += 8
+		case parquetByteArray:
+			if err := need(4); err != nil {
+				return nil, 0, err
+			}
+			size := int(binary.LittleEndian.Uint32(data[pos:]))
+			pos += 4
+			if err := need(size); err != nil {
+				return nil, 0, err
+			}
+			values = append(values, col.convertBytes(data[pos:pos+size]))
+			pos += size
+		case parquetFixedLenByteArray:
+			size := int(col.typeLength)
+			if err := need(size); err != nil {
+				return nil, 0, err
+			}
+			values = append(values, col.convertBytes(data[pos:pos+size]))
+			pos += size
+		default:
+			return nil, 0, newParquetError("unsupported parquet type %d of %q", col.typ, col.name)
+		}
+	}
+	return values, pos, nil
+}
+
+func (col *parquetColumn) convertInt(v int64) interface{} {
+	switch col.convertedType {
+	case parquetConvertedDecimal:
+		return float64(v) / math.Pow10(int(col.scale))
+	case parquetConvertedDate:
+		return time.Unix(v*86400, 0).UTC().Format("2006-01-02")
+	case parquetConvertedTimestampMillis:
+		return time.UnixMilli(v).UTC().Format(time.RFC3339Nano)
+	case parquetConvertedTimestampMicros:
+		return time.UnixMicro(v).UTC().Format(time.RFC3339Nano)
+	}
+	return v
+}
+
+func (col *parquetColumn) convertBytes(v []byte) interface{} {
+	if col.convertedType == parquetConvertedDecimal {
+		// the unscaled value is a big-endian two's complement integer
+		i := new(big.Int).SetBytes(v)
+		if len(v) > 0 && v[0]&0x80 != 0 {
+			i.Sub(i, new(big.Int).Lsh(big.NewInt(1), uint(len(v)*8)))
+		}
+		f, _ := new(big.Float).Quo(new(big.Float).SetInt(i), new(big.Float).SetFloat64(math.Pow10(int(col.scale)))).Float64()
+		return f
+	}
+	return string(v)
+}
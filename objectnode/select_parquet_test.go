@@ -0,0 +1,496 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+)
+
+// The minimal thrift compact encoder and parquet writer to generate the test objects.
+
+type thriftField struct {
+	id    int16
+	typ   byte
+	value interface{}
+}
+
+type thriftListValue struct {
+	elemType byte
+	items    []interface{}
+}
+
+func writeThriftValue(buf *bytes.Buffer, typ byte, v interface{}) {
+	var tmp [binary.MaxVarintLen64]byte
+	switch typ {
+	case thriftI16, thriftI32, thriftI64:
+		n := v.(int64)
+		buf.Write(tmp[:binary.PutUvarint(tmp[:], uint64((n<<1)^(n>>63)))])
+	case thriftBinary:
+		b := []byte(v.(string))
+		buf.Write(tmp[:binary.PutUvarint(tmp[:], uint64(len(b)))])
+		buf.Write(b)
+	case thriftList:
+		l := v.(thriftListValue)
+		if len(l.items) < 15 {
+			buf.WriteByte(byte(len(l.items))<<4 | l.elemType)
+		} else {
+			buf.WriteByte(0xf0 | l.elemType)
+			buf.Write(tmp[:binary.PutUvarint(tmp[:], uint64(len(l.items)))])
+		}
+		for _, item := range l.items {
+			writeThriftValue(buf, l.elemType, item)
+		}
+	case thriftStructType:
+		writeThriftStruct(buf, v.([]thriftField))
+	}
+}
+
+func writeThriftStruct(buf *bytes.Buffer, fields []thriftField) {
+	var last int16
+	for _, f := range fields {
+		typ := f.typ
+		if b, ok := f.value.(bool); ok {
+			typ = thriftFalse
+			if b {
+				typ = thriftTrue
+			}
+		}
+		if delta := f.id - last; delta > 0 && delta <= 15 {
+			buf.WriteByte(byte(delta)<<4 | typ)
+		} else {
+			buf.WriteByte(typ)
+			writeThriftValue(buf, thriftI16, int64(f.id))
+		}
+		last = f.id
+		if typ != thriftTrue && typ != thriftFalse {
+			writeThriftValue(buf, typ, f.value)
+		}
+	}
+	buf.WriteByte(thriftStop)
+}
+
+func i32Field(id int16, v int64) thriftField {
+	return thriftField{id: id, typ: thriftI32, value: v}
+}
+
+func i64Field(id int16, v int64) thriftField {
+	return thriftField{id: id, typ: thriftI64, value: v}
+}
+
+// encodeBitPacked encodes the values with the bit-packed run of the RLE/bit-packing hybrid encoding.
+func encodeBitPacked(values []int, bitWidth int) []byte {
+	groups := (len(values) + 7) / 8
+	var buf bytes.Buffer
+	var tmp [binary.MaxVarintLen64]byte
+	buf.Write(tmp[:binary.PutUvarint(tmp[:], uint64(groups<<1|1))])
+	packed := make([]byte, groups*bitWidth)
+	for i, v := range values {
+		for b := 0; b < bitWidth; b++ {
+			if v>>b&1 == 1 {
+				bit := i*bitWidth + b
+				packed[bit/8] |= 1 << (bit % 8)
+			}
+		}
+	}
+	buf.Write(packed)
+	return buf.Bytes()
+}
+
+type testParquetColumn struct {
+	name          string
+	typ           int64
+	optional      bool
+	convertedType int64 // -1 if none
+	codec         int64
+	dictionary    bool
+	pageV2        bool
+}
+
+func encodePlain(col *testParquetColumn, values []interface{}) []byte {
+	var buf bytes.Buffer
+	var bits byte
+	n := 0
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+		switch col.typ {
+		case parquetBoolean:
+			if v.(bool) {
+				bits |= 1 << (n % 8)
+			}
+			if n%8 == 7 {
+				buf.WriteByte(bits)
+				bits = 0
+			}
+		case parquetInt32:
+			_ = binary.Write(&buf, binary.LittleEndian, int32(v.(int64)))
+		case parquetInt64:
+			_ = binary.Write(&buf, binary.LittleEndian, v.(int64))
+		case parquetDouble:
+			_ = binary.Write(&buf, binary.LittleEndian, math.Float64bits(v.(float64)))
+		case parquetByteArray:
+			_ = binary.Write(&buf, binary.LittleEndian, uint32(len(v.(string))))
+			buf.WriteString(v.(string))
+		}
+		n++
+	}
+	if col.typ == parquetBoolean && n%8 != 0 {
+		buf.WriteByte(bits)
+	}
+	return buf.Bytes()
+}
+
+func compressTestPage(t testing.TB, codec int64, data []byte) []byte {
+	switch codec {
+	case parquetCodecSnappy:
+		return snappy.Encode(nil, data)
+	case parquetCodecGzip:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		_, err := w.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		return buf.Bytes()
+	case parquetCodecZstd:
+		enc, err := zstd.NewWriter(nil)
+		require.NoError(t, err)
+		defer enc.Close()
+		return enc.EncodeAll(data, nil)
+	}
+	return data
+}
+
+func writeTestPage(t testing.TB, buf *bytes.Buffer, codec int64, pageType int64, headerID int16,
+	pageHeader []thriftField, levels []byte, data []byte) {
+	compressed := compressTestPage(t, codec, data)
+	writeThriftStruct(buf, []thriftField{
+		i32Field(1, pageType),
+		i32Field(2, int64(len(levels)+len(data))),
+		i32Field(3, int64(len(levels)+len(compressed))),
+		{id: headerID, typ: thriftStructType, value: pageHeader},
+	})
+	buf.Write(levels)
+	buf.Write(compressed)
+}
+
+// writeTestColumnChunk writes the column chunk of the values and returns the ColumnMetaData.
+func writeTestColumnChunk(t testing.TB, buf *bytes.Buffer, col *testParquetColumn, values []interface{}) []thriftField {
+	start := int64(buf.Len())
+	var (
+		defLevels  []int
+		numNulls   int64
+		dictOffset int64 = -1
+	)
+	for _, v := range values {
+		if v == nil {
+			defLevels = append(defLevels, 0)
+			numNulls++
+		} else {
+			defLevels = append(defLevels, 1)
+		}
+	}
+
+	encoding := int64(parquetEncodingPlain)
+	data := encodePlain(col, values)
+	if col.dictionary {
+		var (
+			dict    []interface{}
+			indexes []int
+			seen    = make(map[interface{}]int)
+		)
+		for _, v := range values {
+			if v == nil {
+				continue
+			}
+			idx, ok := seen[v]
+			if !ok {
+				idx = len(dict)
+				seen[v] = idx
+				dict = append(dict, v)
+			}
+			indexes = append(indexes, idx)
+		}
+		dictOffset = int64(buf.Len())
+		writeTestPage(t, buf, col.codec, parquetPageDictionary, 7,
+			[]thriftField{i32Field(1, int64(len(dict))), i32Field(2, parquetEncodingPlain)}, nil, encodePlain(col, dict))
+		encoding = parquetEncodingRLEDictionary
+		data = append([]byte{2}, encodeBitPacked(indexes, 2)...)
+	}
+
+	dataOffset := int64(buf.Len())
+	var levels []byte
+	if col.optional {
+		levels = encodeBitPacked(defLevels, 1)
+	}
+	if col.pageV2 {
+		writeTestPage(t, buf, col.codec, parquetPageDataV2, 8, []thriftField{
+			i32Field(1, int64(len(values))),
+			i32Field(2, numNulls),
+			i32Field(3, int64(len(values))),
+			i32Field(4, encoding),
+			i32Field(5, int64(len(levels))),
+			i32Field(6, 0),
+		}, levels, data)
+	} else {
+		if col.optional {
+			prefix := make([]byte, 4)
+			binary.LittleEndian.PutUint32(prefix, uint32(len(levels)))
+			data = append(append(prefix, levels...), data...)
+		}
+		writeTestPage(t, buf, col.codec, parquetPageData, 5, []thriftField{
+			i32Field(1, int64(len(values))),
+			i32Field(2, encoding),
+			i32Field(3, parquetEncodingRLE),
+			i32Field(4, parquetEncodingRLE),
+		}, nil, data)
+	}
+
+	meta := []thriftField{
+		i32Field(1, col.typ),
+		{id: 2, typ: thriftList, value: thriftListValue{elemType: thriftI32, items: []interface{}{encoding}}},
+		{id: 3, typ: thriftList, value: thriftListValue{elemType: thriftBinary, items: []interface{}{col.name}}},
+		i32Field(4, col.codec),
+		i64Field(5, int64(len(values))),
+		i64Field(6, int64(buf.Len())-start),
+		i64Field(7, int64(buf.Len())-start),
+		i64Field(9, dataOffset),
+	}
+	if dictOffset >= 0 {
+		meta = append(meta, i64Field(11, dictOffset))
+	}
+	return meta
+}
+
+// writeTestParquet writes the rows to a parquet file, each element of rowGroups is a row group.
+func writeTestParquet(t testing.TB, columns []*testParquetColumn, rowGroups [][][]interface{}) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(parquetMagic)
+
+	schema := []interface{}{[]thriftField{
+		{id: 4, typ: thriftBinary, value: "schema"},
+		i32Field(5, int64(len(columns))),
+	}}
+	for _, col := range columns {
+		repetition := int64(parquetRequired)
+		if col.optional {
+			repetition = parquetOptional
+		}
+		elem := []thriftField{i32Field(1, col.typ), i32Field(3, repetition), {id: 4, typ: thriftBinary, value: col.name}}
+		if col.convertedType >= 0 {
+			elem = append(elem, i32Field(6, col.convertedType))
+		}
+		schema = append(schema, elem)
+	}
+
+	var groups []interface{}
+	numRows := 0
+	for _, rows := range rowGroups {
+		var chunks []interface{}
+		for i, col := range columns {
+			values := make([]interface{}, len(rows))
+			for j, row := range rows {
+				values[j] = row[i]
+			}
+			offset := int64(buf.Len())
+			meta := writeTestColumnChunk(t, &buf, col, values)
+			chunks = append(chunks, []thriftField{
+				i64Field(2, offset),
+				{id: 3, typ: thriftStructType, value: meta},
+			})
+		}
+		groups = append(groups, []thriftField{
+			{id: 1, typ: thriftList, value: thriftListValue{elemType: thriftStructType, items: chunks}},
+			i64Field(2, 0),
+			i64Field(3, int64(len(rows))),
+		})
+		numRows += len(rows)
+	}
+
+	var footer bytes.Buffer
+	writeThriftStruct(&footer, []thriftField{
+		i32Field(1, 1),
+		{id: 2, typ: thriftList, value: thriftListValue{elemType: thriftStructType, items: schema}},
+		i64Field(3, int64(numRows)),
+		{id: 4, typ: thriftList, value: thriftListValue{elemType: thriftStructType, items: groups}},
+		{id: 6, typ: thriftBinary, value: "cubefs test"},
+	})
+	buf.Write(footer.Bytes())
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(footer.Len()))
+	buf.WriteString(parquetMagic)
+	return buf.Bytes()
+}
+
+func TestSelectParquet(t *testing.T) {
+	columns := []*testParquetColumn{
+		{name: "id", typ: parquetInt64, convertedType: -1},
+		{name: "name", typ: parquetByteArray, optional: true, convertedType: 0, codec: parquetCodecSnappy},
+		{name: "score", typ: parquetDouble, optional: true, convertedType: -1, codec: parquetCodecGzip, pageV2: true},
+		{name: "city", typ: parquetByteArray, convertedType: 0, codec: parquetCodecZstd, dictionary: true},
+		{name: "active", typ: parquetBoolean, convertedType: -1},
+		{name: "day", typ: parquetInt32, optional: true, convertedType: parquetConvertedDate},
+	}
+	data := writeTestParquet(t, columns, [][][]interface{}{
+		{
+			{int64(1), "alice", 90.5, "beijing", true, int64(19000)},
+			{int64(2), nil, 80.0, "shanghai", false, nil},
+			{int64(3), "carol", nil, "beijing", true, int64(0)},
+		},
+		{
+			{int64(4), "dave", 70.25, "shenzhen", false, int64(1)},
+		},
+	})
+
+	tests := []struct {
+		sql    string
+		output string
+		result string
+	}{
+		{"SELECT * FROM S3Object", "<JSON/>",
+			"{\"id\":1,\"name\":\"alice\",\"score\":90.5,\"city\":\"beijing\",\"active\":true,\"day\":\"2022-01-08\"}\n" +
+				"{\"id\":2,\"name\":null,\"score\":80,\"city\":\"shanghai\",\"active\":false,\"day\":null}\n" +
+				"{\"id\":3,\"name\":\"carol\",\"score\":null,\"city\":\"beijing\",\"active\":true,\"day\":\"1970-01-01\"}\n" +
+				"{\"id\":4,\"name\":\"dave\",\"score\":70.25,\"city\":\"shenzhen\",\"active\":false,\"day\":\"1970-01-02\"}\n"},
+		{"SELECT s.id, s.name FROM S3Object s WHERE s.city = 'beijing'", "<CSV/>", "1,alice\n3,carol\n"},
+		{"SELECT id FROM S3Object WHERE active AND score > 50", "<CSV/>", "1\n"},
+		{"SELECT id FROM S3Object WHERE name IS NULL", "<CSV/>", "2\n"},
+		{"SELECT COUNT(*), SUM(score), MAX(id), MIN(city) FROM S3Object", "<CSV/>", "4,240.75,4,beijing\n"},
+		{"SELECT city FROM S3Object LIMIT 2", "<CSV/>", "beijing\nshanghai\n"},
+	}
+	for _, tc := range tests {
+		result, stats, err := runSelect(t, newSelectRequest(tc.sql, "<Parquet/>", tc.output), data)
+		require.NoError(t, err, tc.sql)
+		require.Equal(t, tc.result, result, tc.sql)
+		require.True(t, stats.BytesScanned > 0)
+		require.True(t, stats.BytesProcessed > 0)
+	}
+
+	// only the row groups starting within the scan range are read
+	request := `<SelectObjectContentRequest><Expression>SELECT id FROM S3Object</Expression>
+		<ExpressionType>SQL</ExpressionType><InputSerialization><Parquet/></InputSerialization>
+		<OutputSerialization><CSV/></OutputSerialization><ScanRange><Start>5</Start></ScanRange></SelectObjectContentRequest>`
+	result, _, err := runSelect(t, request, data)
+	require.NoError(t, err)
+	require.Equal(t, "4\n", result)
+
+	// invalid parquet objects
+	for _, invalid := range [][]byte{[]byte("PAR1"), []byte("not a parquet object"), data[:len(data)-1]} {
+		_, _, err = runSelect(t, newSelectRequest("SELECT * FROM S3Object", "<Parquet/>", "<CSV/>"), invalid)
+		require.Error(t, err)
+	}
+	corrupted := append([]byte{}, data...)
+	for i := 10; i < 60; i++ {
+		corrupted[i] = 0xff
+	}
+	_, _, err = runSelect(t, newSelectRequest("SELECT * FROM S3Object", "<Parquet/>", "<CSV/>"), corrupted)
+	require.Error(t, err)
+}
+
+func TestDecodeRLEHybrid(t *testing.T) {
+	// RLE run of 5 values of 3 followed by a bit-packed run of 8 values
+	data := []byte{5 << 1, 3}
+	data = append(data, encodeBitPacked([]int{0, 1, 2, 3, 3, 2, 1, 0}, 2)...)
+	values, err := decodeRLEHybrid(data, 2, 13)
+	require.NoError(t, err)
+	require.Equal(t, []int{3, 3, 3, 3, 3, 0, 1, 2, 3, 3, 2, 1, 0}, values)
+
+	_, err = decodeRLEHybrid(data, 2, 20)
+	require.Error(t, err)
+	_, err = decodeRLEHybrid([]byte{0x80}, 1, 1)
+	require.Error(t, err)
+}
+
+func TestSelectParquetMemoryLimit(t *testing.T) {
+	columns := []*testParquetColumn{
+		{name: "id", typ: parquetInt64, convertedType: -1},
+		{name: "name", typ: parquetByteArray, convertedType: 0, codec: parquetCodecSnappy},
+	}
+	rows := make([][]interface{}, 1000)
+	for i := range rows {
+		rows[i] = []interface{}{int64(i), "name"}
+	}
+	data := writeTestParquet(t, columns, [][][]interface{}{rows})
+
+	p, err := newParquetRecordReader(bytes.NewReader(data), int64(len(data)), 0, -1, 1<<20)
+	require.NoError(t, err)
+	for i := 0; i < len(rows); i++ {
+		_, err = p.Read()
+		require.NoError(t, err)
+	}
+
+	p, err = newParquetRecordReader(bytes.NewReader(data), int64(len(data)), 0, -1, 16<<10)
+	require.NoError(t, err)
+	_, err = p.Read()
+	require.Error(t, err)
+}
+
+func FuzzThriftDecoder(f *testing.F) {
+	var buf bytes.Buffer
+	writeThriftStruct(&buf, []thriftField{
+		i32Field(1, 1),
+		i64Field(2, math.MaxInt64),
+		{id: 3, typ: thriftBinary, value: "name"},
+		{id: 4, typ: thriftList, value: thriftListValue{elemType: thriftI32, items: []interface{}{int64(1), int64(2)}}},
+		{id: 5, typ: thriftStructType, value: []thriftField{i32Field(1, 2)}},
+	})
+	f.Add(buf.Bytes())
+	f.Add([]byte{0x19, 0xfc})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		d := &thriftDecoder{data: data}
+		if _, err := d.readStruct(0); err == nil && d.pos > len(data) {
+			t.Fatalf("read %d bytes beyond the data of %d bytes", d.pos, len(data))
+		}
+	})
+}
+
+// FuzzParquetColumnChunk mutates the column chunks of a valid object, the metadata in the footer is kept
+// so that the fuzzer reaches readColumnChunk.
+func FuzzParquetColumnChunk(f *testing.F) {
+	columns := []*testParquetColumn{
+		{name: "id", typ: parquetInt64, convertedType: -1, codec: parquetCodecZstd},
+		{name: "name", typ: parquetByteArray, optional: true, convertedType: 0, codec: parquetCodecSnappy},
+		{name: "score", typ: parquetDouble, optional: true, convertedType: -1, codec: parquetCodecGzip, pageV2: true},
+		{name: "city", typ: parquetByteArray, convertedType: 0, dictionary: true},
+	}
+	data := writeTestParquet(f, columns, [][][]interface{}{
+		{
+			{int64(1), "alice", 90.5, "beijing"},
+			{int64(2), nil, nil, "shanghai"},
+		},
+	})
+	metaLen := int(binary.LittleEndian.Uint32(data[len(data)-parquetFooterSize:]))
+	footer := data[len(data)-parquetFooterSize-metaLen:]
+	f.Add(data[:len(data)-len(footer)])
+	f.Fuzz(func(t *testing.T, chunks []byte) {
+		object := append(append([]byte{}, chunks...), footer...)
+		p, err := newParquetRecordReader(bytes.NewReader(object), int64(len(object)), 0, -1, 1<<20)
+		if err != nil {
+			return
+		}
+		for {
+			if _, err = p.Read(); err != nil {
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,1467 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The SQL subset of S3 Select:
+//
+//	SELECT <* | expr [[AS] alias], ...> FROM S3Object[[*]][.path] [[AS] alias]
+//	[WHERE expr] [LIMIT n]
+//
+// The expressions support arithmetic, comparison, logical operators, LIKE, IN, BETWEEN, IS [NOT] NULL,
+// string concatenation, CAST, a few scalar functions and the aggregate functions COUNT, SUM, AVG, MIN, MAX.
+// The values of the expressions are nil (NULL), bool, int64, float64, string, *jsonObject and []interface{}.
+
+type sqlTokenKind int
+
+const (
+	sqlTokenEOF sqlTokenKind = iota
+	sqlTokenIdent
+	sqlTokenQuotedIdent
+	sqlTokenString
+	sqlTokenNumber
+	sqlTokenOp
+)
+
+type sqlToken struct {
+	kind sqlTokenKind
+	text string
+	pos  int
+}
+
+func newSelectSQLError(format string, args ...interface{}) *ErrorCode {
+	return NewError("ParseSelectFailure", fmt.Sprintf(format, args...), 400)
+}
+
+func newSelectEvalError(format string, args ...interface{}) *ErrorCode {
+	return NewError("EvaluatorInvalidArguments", fmt.Sprintf(format, args...), 400)
+}
+
+func lexSQL(sql string) ([]sqlToken, error) {
+	var tokens []sqlToken
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '\'':
+			var sb strings.Builder
+			j := i + 1
+			for {
+				if j >= len(sql) {
+					return nil, newSelectSQLError("unterminated string literal at position %d", i)
+				}
+				if sql[j] == '\'' {
+					if j+1 < len(sql) && sql[j+1] == '\'' {
+						sb.WriteByte('\'')
+						j += 2
+						continue
+					}
+					break
+				}
+				sb.WriteByte(sql[j])
+				j++
+			}
+			tokens = append(tokens, sqlToken{kind: sqlTokenString, text: sb.String(), pos: i})
+			i = j + 1
+		case c == '"':
+			j := strings.IndexByte(sql[i+1:], '"')
+			if j < 0 {
+				return nil, newSelectSQLError("unterminated quoted identifier at position %d", i)
+			}
+			tokens = append(tokens, sqlToken{kind: sqlTokenQuotedIdent, text: sql[i+1 : i+1+j], pos: i})
+			i += j + 2
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(sql) && sql[i+1] >= '0' && sql[i+1] <= '9':
+			j := i
+			for j < len(sql) && (sql[j] >= '0' && sql[j] <= '9' || sql[j] == '.') {
+				j++
+			}
+			if j < len(sql) && (sql[j] == 'e' || sql[j] == 'E') {
+				k := j + 1
+				if k < len(sql) && (sql[k] == '+' || sql[k] == '-') {
+					k++
+				}
+				if k < len(sql) && sql[k] >= '0' && sql[k] <= '9' {
+					for j = k; j < len(sql) && sql[j] >= '0' && sql[j] <= '9'; j++ {
+					}
+				}
+			}
+			tokens = append(tokens, sqlToken{kind: sqlTokenNumber, text: sql[i:j], pos: i})
+			i = j
+		case c == '_' || c < utf8.RuneSelf && unicode.IsLetter(rune(c)):
+			j := i
+			for j < len(sql) && (sql[j] == '_' || sql[j] < utf8.RuneSelf &&
+				(unicode.IsLetter(rune(sql[j])) || unicode.IsDigit(rune(sql[j])))) {
+				j++
+			}
+			tokens = append(tokens, sqlToken{kind: sqlTokenIdent, text: sql[i:j], pos: i})
+			i = j
+		default:
+			op := string(c)
+			if i+1 < len(sql) {
+				switch two := sql[i : i+2]; two {
+				case "<=", ">=", "<>", "!=", "||":
+					op = two
+				}
+			}
+			if !strings.Contains("()[],.*+-/%=<>!|", string(c)) || op == "!" || op == "|" {
+				return nil, newSelectSQLError("unexpected character %q at position %d", c, i)
+			}
+			tokens = append(tokens, sqlToken{kind: sqlTokenOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	tokens = append(tokens, sqlToken{kind: sqlTokenEOF, pos: len(sql)})
+	return tokens, nil
+}
+
+// selectQuery is the parsed SELECT statement.
+type selectQuery struct {
+	selectAll   bool
+	projections []*sqlProjection
+	fromPath    []sqlPathElem // path of the records in the JSON document, e.g. S3Object[*].items[*]
+	alias       string
+	where       sqlExpr
+	limit       int64 // -1 if no limit
+
+	columns    []*sqlColumnRef
+	aggregates []*sqlAggregate
+}
+
+type sqlProjection struct {
+	expr  sqlExpr
+	alias string
+}
+
+func (q *selectQuery) isAggregate() bool {
+	return len(q.aggregates) > 0
+}
+
+type sqlParser struct {
+	tokens []sqlToken
+	pos    int
+	query  *selectQuery
+	// aggregate functions are only allowed in the projections
+	inProjection bool
+	inAggregate  bool
+}
+
+func parseSelectQuery(sql string) (*selectQuery, error) {
+	tokens, err := lexSQL(sql)
+	if err != nil {
+		return nil, err
+	}
+	p := &sqlParser{tokens: tokens, query: &selectQuery{limit: -1}}
+	if err = p.parseSelect(); err != nil {
+		return nil, err
+	}
+	q := p.query
+	// strip the table alias from the column references, the alias itself refers to the whole record
+	for _, col := range q.columns {
+		if first := col.path[0]; !first.quoted &&
+			(q.alias != "" && strings.EqualFold(first.name, q.alias) || strings.EqualFold(first.name, "S3Object")) {
+			col.path = col.path[1:]
+		}
+	}
+	// SELECT alias FROM S3Object alias selects the whole records
+	if len(q.projections) == 1 && q.projections[0].alias == "" {
+		if col, ok := q.projections[0].expr.(*sqlColumnRef); ok && len(col.path) == 0 {
+			q.selectAll = true
+		}
+	}
+	if q.isAggregate() {
+		for _, proj := range q.projections {
+			if !hasAggregate(proj.expr) {
+				return nil, newSelectSQLError("non-aggregate projection cannot be used with aggregate functions")
+			}
+		}
+	}
+	return q, nil
+}
+
+func (p *sqlParser) peek() sqlToken {
+	return p.tokens[p.pos]
+}
+
+func (p *sqlParser) next() sqlToken {
+	t := p.tokens[p.pos]
+	if t.kind != sqlTokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *sqlParser) isKeyword(kw string) bool {
+	t := p.peek()
+	return t.kind == sqlTokenIdent && strings.EqualFold(t.text, kw)
+}
+
+func (p *sqlParser) acceptKeyword(kw string) bool {
+	if p.isKeyword(kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *sqlParser) expectKeyword(kw string) error {
+	if !p.acceptKeyword(kw) {
+		return p.unexpected(kw)
+	}
+	return nil
+}
+
+func (p *sqlParser) isOp(op string) bool {
+	t := p.peek()
+	return t.kind == sqlTokenOp && t.text == op
+}
+
+func (p *sqlParser) acceptOp(op string) bool {
+	if p.isOp(op) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *sqlParser) expectOp(op string) error {
+	if !p.acceptOp(op) {
+		return p.unexpected(op)
+	}
+	return nil
+}
+
+func (p *sqlParser) unexpected(expected string) error {
+	t := p.peek()
+	if t.kind == sqlTokenEOF {
+		return newSelectSQLError("expected %s but reached the end of the expression", expected)
+	}
+	return newSelectSQLError("expected %s but found %q at position %d", expected, t.text, t.pos)
+}
+
+var sqlReservedWords = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "LIMIT": true, "AS": true, "AND": true, "OR": true,
+	"NOT": true, "LIKE": true, "ESCAPE": true, "IN": true, "BETWEEN": true, "IS": true, "NULL": true,
+	"TRUE": true, "FALSE": true, "CAST": true, "MISSING": true,
+}
+
+func (p *sqlParser) parseSelect() (err error) {
+	q := p.query
+	if err = p.expectKeyword("SELECT"); err != nil {
+		return
+	}
+	if p.acceptOp("*") {
+		q.selectAll = true
+	} else {
+		p.inProjection = true
+		for {
+			proj := &sqlProjection{}
+			if proj.expr, err = p.parseExpr(); err != nil {
+				return
+			}
+			if p.acceptKeyword("AS") {
+				t := p.next()
+				if t.kind != sqlTokenIdent && t.kind != sqlTokenQuotedIdent {
+					return newSelectSQLError("invalid alias at position %d", t.pos)
+				}
+				proj.alias = t.text
+			} else if t := p.peek(); t.kind == sqlTokenQuotedIdent ||
+				t.kind == sqlTokenIdent && !sqlReservedWords[strings.ToUpper(t.text)] {
+				proj.alias = p.next().text
+			}
+			q.projections = append(q.projections, proj)
+			if !p.acceptOp(",") {
+				break
+			}
+		}
+		p.inProjection = false
+	}
+
+	if err = p.expectKeyword("FROM"); err != nil {
+		return
+	}
+	t := p.next()
+	if t.kind != sqlTokenIdent || !strings.EqualFold(t.text, "S3Object") {
+		return newSelectSQLError("the table name must be S3Object at position %d", t.pos)
+	}
+	for {
+		if p.acceptOp("[") {
+			if err = p.expectOp("*"); err != nil {
+				return
+			}
+			if err = p.expectOp("]"); err != nil {
+				return
+			}
+			q.fromPath = append(q.fromPath, sqlPathElem{wildcard: true})
+			continue
+		}
+		if p.acceptOp(".") {
+			t = p.next()
+			if t.kind != sqlTokenIdent && t.kind != sqlTokenQuotedIdent {
+				return newSelectSQLError("invalid path of S3Object at position %d", t.pos)
+			}
+			q.fromPath = append(q.fromPath, sqlPathElem{name: t.text, quoted: t.kind == sqlTokenQuotedIdent})
+			continue
+		}
+		break
+	}
+	if p.acceptKeyword("AS") {
+		t = p.next()
+		if t.kind != sqlTokenIdent && t.kind != sqlTokenQuotedIdent {
+			return newSelectSQLError("invalid alias at position %d", t.pos)
+		}
+		q.alias = t.text
+	} else if t = p.peek(); t.kind == sqlTokenIdent && !sqlReservedWords[strings.ToUpper(t.text)] {
+		q.alias = p.next().text
+	}
+
+	if p.acceptKeyword("WHERE") {
+		if q.where, err = p.parseExpr(); err != nil {
+			return
+		}
+		if hasAggregate(q.where) {
+			return newSelectSQLError("aggregate functions are not allowed in the WHERE clause")
+		}
+	}
+	if p.acceptKeyword("LIMIT") {
+		t = p.next()
+		if t.kind != sqlTokenNumber {
+			return newSelectSQLError("invalid LIMIT at position %d", t.pos)
+		}
+		if q.limit, err = strconv.ParseInt(t.text, 10, 64); err != nil || q.limit < 0 {
+			return newSelectSQLError("invalid LIMIT %q", t.text)
+		}
+	}
+	if t = p.peek(); t.kind != sqlTokenEOF {
+		return newSelectSQLError("unexpected %q at position %d", t.text, t.pos)
+	}
+	return nil
+}
+
+// parseExpr parses the expressions with the precedence: OR < AND < NOT < comparison < || < +- < */% < unary.
+func (p *sqlParser) parseExpr() (sqlExpr, error) {
+	return p.parseOr()
+}
+
+func (p *sqlParser) parseOr() (sqlExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &sqlBinary{op: "OR", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *sqlParser) parseAnd() (sqlExpr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &sqlBinary{op: "AND", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *sqlParser) parseNot() (sqlExpr, error) {
+	if p.acceptKeyword("NOT") {
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &sqlUnary{op: "NOT", x: x}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *sqlParser) parseComparison() (sqlExpr, error) {
+	left, err := p.parseConcat()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if t.kind == sqlTokenOp {
+			switch t.text {
+			case "=", "!=", "<>", "<", "<=", ">", ">=":
+				p.next()
+				right, err := p.parseConcat()
+				if err != nil {
+					return nil, err
+				}
+				op := t.text
+				if op == "<>" {
+					op = "!="
+				}
+				left = &sqlBinary{op: op, left: left, right: right}
+				continue
+			}
+			return left, nil
+		}
+		if p.acceptKeyword("IS") {
+			not := p.acceptKeyword("NOT")
+			if !p.acceptKeyword("NULL") && !p.acceptKeyword("MISSING") {
+				return nil, p.unexpected("NULL")
+			}
+			left = &sqlIsNull{x: left, not: not}
+			continue
+		}
+		not := false
+		if p.isKeyword("NOT") {
+			// NOT LIKE, NOT IN, NOT BETWEEN
+			if next := p.tokens[p.pos+1]; next.kind == sqlTokenIdent &&
+				(strings.EqualFold(next.text, "LIKE") || strings.EqualFold(next.text, "IN") || strings.EqualFold(next.text, "BETWEEN")) {
+				p.next()
+				not = true
+			} else {
+				return left, nil
+			}
+		}
+		switch {
+		case p.acceptKeyword("LIKE"):
+			like := &sqlLike{x: left, not: not}
+			if like.pattern, err = p.parseConcat(); err != nil {
+				return nil, err
+			}
+			if p.acceptKeyword("ESCAPE") {
+				if like.escape, err = p.parseConcat(); err != nil {
+					return nil, err
+				}
+			}
+			left = like
+		case p.acceptKeyword("IN"):
+			in := &sqlIn{x: left, not: not}
+			if err = p.expectOp("("); err != nil {
+				return nil, err
+			}
+			for {
+				item, err := p.parseExpr()
+				if err != nil {
+					return nil, err
+				}
+				in.list = append(in.list, item)
+				if !p.acceptOp(",") {
+					break
+				}
+			}
+			if err = p.expectOp(")"); err != nil {
+				return nil, err
+			}
+			left = in
+		case p.acceptKeyword("BETWEEN"):
+			between := &sqlBetween{x: left, not: not}
+			if between.low, err = p.parseConcat(); err != nil {
+				return nil, err
+			}
+			if err = p.expectKeyword("AND"); err != nil {
+				return nil, err
+			}
+			if between.high, err = p.parseConcat(); err != nil {
+				return nil, err
+			}
+			left = between
+		default:
+			return left, nil
+		}
+	}
+}
+
+func (p *sqlParser) parseConcat() (sqlExpr, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptOp("||") {
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		left = &sqlBinary{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *sqlParser) parseAdditive() (sqlExpr, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("+") || p.isOp("-") {
+		op := p.next().text
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &sqlBinary{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *sqlParser) parseMultiplicative() (sqlExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("*") || p.isOp("/") || p.isOp("%") {
+		op := p.next().text
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &sqlBinary{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *sqlParser) parseUnary() (sqlExpr, error) {
+	if p.acceptOp("-") {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &sqlUnary{op: "-", x: x}, nil
+	}
+	if p.acceptOp("+") {
+		return p.parseUnary()
+	}
+	return p.parsePrimary()
+}
+
+func (p *sqlParser) parsePrimary() (sqlExpr, error) {
+	t := p.peek()
+	switch t.kind {
+	case sqlTokenNumber:
+		p.next()
+		if i, err := strconv.ParseInt(t.text, 10, 64); err == nil {
+			return &sqlLiteral{value: i}, nil
+		}
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, newSelectSQLError("invalid number %q at position %d", t.text, t.pos)
+		}
+		return &sqlLiteral{value: f}, nil
+	case sqlTokenString:
+		p.next()
+		return &sqlLiteral{value: t.text}, nil
+	case sqlTokenOp:
+		if p.acceptOp("(") {
+			x, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if err = p.expectOp(")"); err != nil {
+				return nil, err
+			}
+			return x, nil
+		}
+		return nil, p.unexpected("expression")
+	case sqlTokenQuotedIdent:
+		return p.parseColumnRef()
+	case sqlTokenIdent:
+		upper := strings.ToUpper(t.text)
+		switch upper {
+		case "NULL", "MISSING":
+			p.next()
+			return &sqlLiteral{}, nil
+		case "TRUE", "FALSE":
+			p.next()
+			return &sqlLiteral{value: upper == "TRUE"}, nil
+		case "CAST":
+			p.next()
+			return p.parseCast()
+		}
+		if sqlReservedWords[upper] {
+			return nil, p.unexpected("expression")
+		}
+		if next := p.tokens[p.pos+1]; next.kind == sqlTokenOp && next.text == "(" {
+			return p.parseFunction()
+		}
+		return p.parseColumnRef()
+	}
+	return nil, p.unexpected("expression")
+}
+
+func (p *sqlParser) parseColumnRef() (sqlExpr, error) {
+	col := &sqlColumnRef{}
+	t := p.next()
+	col.path = append(col.path, sqlPathElem{name: t.text, quoted: t.kind == sqlTokenQuotedIdent})
+	for {
+		if p.acceptOp(".") {
+			t = p.next()
+			if t.kind != sqlTokenIdent && t.kind != sqlTokenQuotedIdent {
+				return nil, newSelectSQLError("invalid column path at position %d", t.pos)
+			}
+			col.path = append(col.path, sqlPathElem{name: t.text, quoted: t.kind == sqlTokenQuotedIdent})
+			continue
+		}
+		if p.acceptOp("[") {
+			t = p.next()
+			var elem sqlPathElem
+			switch t.kind {
+			case sqlTokenNumber:
+				i, err := strconv.Atoi(t.text)
+				if err != nil || i < 0 {
+					return nil, newSelectSQLError("invalid array index %q at position %d", t.text, t.pos)
+				}
+				elem = sqlPathElem{index: true, i: i}
+			case sqlTokenString:
+				elem = sqlPathElem{name: t.text, quoted: true}
+			default:
+				return nil, newSelectSQLError("invalid array index at position %d", t.pos)
+			}
+			if err := p.expectOp("]"); err != nil {
+				return nil, err
+			}
+			col.path = append(col.path, elem)
+			continue
+		}
+		break
+	}
+	p.query.columns = append(p.query.columns, col)
+	return col, nil
+}
+
+func (p *sqlParser) parseCast() (sqlExpr, error) {
+	if err := p.expectOp("("); err != nil {
+		return nil, err
+	}
+	x, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err = p.expectKeyword("AS"); err != nil {
+		return nil, err
+	}
+	t := p.next()
+	if t.kind != sqlTokenIdent {
+		return nil, newSelectSQLError("invalid CAST type at position %d", t.pos)
+	}
+	cast := &sqlCast{x: x}
+	switch strings.ToUpper(t.text) {
+	case "INT", "INTEGER", "BIGINT", "SMALLINT":
+		cast.typ = "INT"
+	case "FLOAT", "DOUBLE", "REAL", "DECIMAL", "NUMERIC":
+		cast.typ = "FLOAT"
+	case "STRING", "CHAR", "VARCHAR":
+		cast.typ = "STRING"
+	case "BOOL", "BOOLEAN":
+		cast.typ = "BOOL"
+	default:
+		return nil, newSelectSQLError("unsupported CAST type %q", t.text)
+	}
+	if err = p.expectOp(")"); err != nil {
+		return nil, err
+	}
+	return cast, nil
+}
+
+var sqlAggregateFuncs = map[string]bool{"COUNT": true, "SUM": true, "AVG": true, "MIN": true, "MAX": true}
+
+func (p *sqlParser) parseFunction() (sqlExpr, error) {
+	t := p.next()
+	name := strings.ToUpper(t.text)
+	p.next() // (
+	if sqlAggregateFuncs[name] {
+		if !p.inProjection || p.inAggregate {
+			return nil, newSelectSQLError("aggregate function %s is not allowed at position %d", name, t.pos)
+		}
+		agg := &sqlAggregate{fn: name}
+		if name == "COUNT" && p.acceptOp("*") {
+			agg.star = true
+		} else {
+			p.inAggregate = true
+			x, err := p.parseExpr()
+			p.inAggregate = false
+			if err != nil {
+				return nil, err
+			}
+			agg.x = x
+		}
+		if err := p.expectOp(")"); err != nil {
+			return nil, err
+		}
+		p.query.aggregates = append(p.query.aggregates, agg)
+		return agg, nil
+	}
+
+	fn := &sqlFunc{name: name}
+	switch name {
+	case "LOWER", "UPPER", "CHAR_LENGTH", "CHARACTER_LENGTH", "TRIM", "ABS":
+		fn.arity = [2]int{1, 1}
+	case "SUBSTRING":
+		fn.arity = [2]int{2, 3}
+	case "NULLIF":
+		fn.arity = [2]int{2, 2}
+	case "COALESCE":
+		fn.arity = [2]int{1, math.MaxInt32}
+	default:
+		return nil, newSelectSQLError("unsupported function %s at position %d", t.text, t.pos)
+	}
+	if !p.isOp(")") {
+		for {
+			x, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			fn.args = append(fn.args, x)
+			// SUBSTRING(x FROM start [FOR length])
+			if name == "SUBSTRING" && len(fn.args) == 1 && p.acceptKeyword("FROM") {
+				if x, err = p.parseExpr(); err != nil {
+					return nil, err
+				}
+				fn.args = append(fn.args, x)
+				if p.acceptKeyword("FOR") {
+					if x, err = p.parseExpr(); err != nil {
+						return nil, err
+					}
+					fn.args = append(fn.args, x)
+				}
+				break
+			}
+			if !p.acceptOp(",") {
+				break
+			}
+		}
+	}
+	if err := p.expectOp(")"); err != nil {
+		return nil, err
+	}
+	if len(fn.args) < fn.arity[0] || len(fn.args) > fn.arity[1] {
+		return nil, newSelectSQLError("invalid number of arguments of function %s", name)
+	}
+	return fn, nil
+}
+
+// sqlExpr is the evaluable node of the expression tree.
+type sqlExpr interface {
+	eval(rec *selectRecord) (interface{}, error)
+}
+
+type sqlLiteral struct {
+	value interface{}
+}
+
+func (e *sqlLiteral) eval(*selectRecord) (interface{}, error) {
+	return e.value, nil
+}
+
+type sqlPathElem struct {
+	name     string
+	quoted   bool
+	index    bool
+	i        int
+	wildcard bool
+}
+
+type sqlColumnRef struct {
+	path []sqlPathElem
+}
+
+// name returns the name of the projection of the column in the output.
+func (e *sqlColumnRef) name() string {
+	for i := len(e.path) - 1; i >= 0; i-- {
+		if !e.path[i].index {
+			return e.path[i].name
+		}
+	}
+	return ""
+}
+
+func (e *sqlColumnRef) eval(rec *selectRecord) (interface{}, error) {
+	if len(e.path) == 0 {
+		return rec.value(), nil
+	}
+	var (
+		v    interface{}
+		ok   bool
+		path = e.path
+	)
+	if path[0].index {
+		v = rec.value()
+	} else {
+		if v, ok = rec.get(path[0].name, path[0].quoted); !ok {
+			return nil, nil
+		}
+		path = path[1:]
+	}
+	for _, elem := range path {
+		switch x := v.(type) {
+		case *jsonObject:
+			if elem.index {
+				return nil, nil
+			}
+			if v, ok = x.get(elem.name, elem.quoted); !ok {
+				return nil, nil
+			}
+		case []interface{}:
+			if !elem.index || elem.i >= len(x) {
+				return nil, nil
+			}
+			v = x[elem.i]
+		default:
+			return nil, nil
+		}
+	}
+	return v, nil
+}
+
+type sqlUnary struct {
+	op string
+	x  sqlExpr
+}
+
+func (e *sqlUnary) eval(rec *selectRecord) (interface{}, error) {
+	v, err := e.x.eval(rec)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	switch e.op {
+	case "NOT":
+		b, ok := toBool(v)
+		if !ok {
+			return nil, newSelectEvalError("NOT requires a boolean operand but got %v", v)
+		}
+		return !b, nil
+	default: // -
+		n, ok := toNumber(v)
+		if !ok {
+			return nil, newSelectEvalError("negation requires a numeric operand but got %v", v)
+		}
+		if i, ok := n.(int64); ok {
+			return -i, nil
+		}
+		return -n.(float64), nil
+	}
+}
+
+type sqlBinary struct {
+	op          string
+	left, right sqlExpr
+}
+
+func (e *sqlBinary) eval(rec *selectRecord) (interface{}, error) {
+	switch e.op {
+	case "AND", "OR":
+		return e.evalLogical(rec)
+	}
+	l, err := e.left.eval(rec)
+	if err != nil {
+		return nil, err
+	}
+	r, err := e.right.eval(rec)
+	if err != nil {
+		return nil, err
+	}
+	if l == nil || r == nil {
+		return nil, nil
+	}
+	switch e.op {
+	case "||":
+		return formatSelectValue(l) + formatSelectValue(r), nil
+	case "+", "-", "*", "/", "%":
+		return arithmetic(e.op, l, r)
+	}
+	c, err := compareValues(l, r)
+	if err != nil {
+		return nil, err
+	}
+	switch e.op {
+	case "=":
+		return c == 0, nil
+	case "!=":
+		return c != 0, nil
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	default:
+		return c >= 0, nil
+	}
+}
+
+// evalLogical implements the three-valued logic of AND and OR.
+func (e *sqlBinary) evalLogical(rec *selectRecord) (interface{}, error) {
+	operand := func(x sqlExpr) (b bool, null bool, err error) {
+		v, err := x.eval(rec)
+		if err != nil || v == nil {
+			return false, true, err
+		}
+		if b, ok := toBool(v); ok {
+			return b, false, nil
+		}
+		return false, false, newSelectEvalError("%s requires boolean operands but got %v", e.op, v)
+	}
+	l, lnull, err := operand(e.left)
+	if err != nil {
+		return nil, err
+	}
+	// short circuit
+	if !lnull && (e.op == "AND" && !l || e.op == "OR" && l) {
+		return l, nil
+	}
+	r, rnull, err := operand(e.right)
+	if err != nil {
+		return nil, err
+	}
+	if !rnull && (e.op == "AND" && !r || e.op == "OR" && r) {
+		return r, nil
+	}
+	if lnull || rnull {
+		return nil, nil
+	}
+	return r, nil
+}
+
+type sqlLike struct {
+	x, pattern, escape sqlExpr
+	not                bool
+}
+
+func (e *sqlLike) eval(rec *selectRecord) (interface{}, error) {
+	v, err := e.x.eval(rec)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	pv, err := e.pattern.eval(rec)
+	if err != nil || pv == nil {
+		return nil, err
+	}
+	var escape rune
+	if e.escape != nil {
+		ev, err := e.escape.eval(rec)
+		if err != nil {
+			return nil, err
+		}
+		es := formatSelectValue(ev)
+		if utf8.RuneCountInString(es) != 1 {
+			return nil, newSelectEvalError("the ESCAPE of LIKE must be a single character")
+		}
+		escape, _ = utf8.DecodeRuneInString(es)
+	}
+	matched := matchLike([]rune(formatSelectValue(v)), []rune(formatSelectValue(pv)), escape)
+	return matched != e.not, nil
+}
+
+// matchLike matches the string with the LIKE pattern, '%' matches any sequence and '_' matches any character.
+func matchLike(s, pattern []rune, escape rune) bool {
+	for len(pattern) > 0 {
+		c := pattern[0]
+		switch {
+		case escape != 0 && c == escape && len(pattern) > 1:
+			if len(s) == 0 || s[0] != pattern[1] {
+				return false
+			}
+			s, pattern = s[1:], pattern[2:]
+		case c == '%':
+			for len(pattern) > 0 && pattern[0] == '%' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if matchLike(s[i:], pattern, escape) {
+					return true
+				}
+			}
+			return false
+		case c == '_':
+			if len(s) == 0 {
+				return false
+			}
+			s, pattern = s[1:], pattern[1:]
+		default:
+			if len(s) == 0 || s[0] != c {
+				return false
+			}
+			s, pattern = s[1:], pattern[1:]
+		}
+	}
+	return len(s) == 0
+}
+
+type sqlIn struct {
+	x    sqlExpr
+	list []sqlExpr
+	not  bool
+}
+
+func (e *sqlIn) eval(rec *selectRecord) (interface{}, error) {
+	v, err := e.x.eval(rec)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	hasNull := false
+	for _, item := range e.list {
+		iv, err := item.eval(rec)
+		if err != nil {
+			return nil, err
+		}
+		if iv == nil {
+			hasNull = true
+			continue
+		}
+		if c, err := compareValues(v, iv); err == nil && c == 0 {
+			return !e.not, nil
+		}
+	}
+	if hasNull {
+		return nil, nil
+	}
+	return e.not, nil
+}
+
+type sqlBetween struct {
+	x, low, high sqlExpr
+	not          bool
+}
+
+func (e *sqlBetween) eval(rec *selectRecord) (interface{}, error) {
+	var values [3]interface{}
+	for i, x := range []sqlExpr{e.x, e.low, e.high} {
+		v, err := x.eval(rec)
+		if err != nil || v == nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	c1, err := compareValues(values[0], values[1])
+	if err != nil {
+		return nil, err
+	}
+	c2, err := compareValues(values[0], values[2])
+	if err != nil {
+		return nil, err
+	}
+	return (c1 >= 0 && c2 <= 0) != e.not, nil
+}
+
+type sqlIsNull struct {
+	x   sqlExpr
+	not bool
+}
+
+func (e *sqlIsNull) eval(rec *selectRecord) (interface{}, error) {
+	v, err := e.x.eval(rec)
+	if err != nil {
+		return nil, err
+	}
+	return (v == nil) != e.not, nil
+}
+
+type sqlCast struct {
+	x   sqlExpr
+	typ string
+}
+
+func (e *sqlCast) eval(rec *selectRecord) (interface{}, error) {
+	v, err := e.x.eval(rec)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	switch e.typ {
+	case "INT":
+		n, ok := toNumber(v)
+		if !ok {
+			return nil, newSelectEvalError("cannot cast %q to INT", formatSelectValue(v))
+		}
+		if f, ok := n.(float64); ok {
+			return int64(f), nil
+		}
+		return n, nil
+	case "FLOAT":
+		n, ok := toNumber(v)
+		if !ok {
+			return nil, newSelectEvalError("cannot cast %q to FLOAT", formatSelectValue(v))
+		}
+		if i, ok := n.(int64); ok {
+			return float64(i), nil
+		}
+		return n, nil
+	case "BOOL":
+		b, ok := toBool(v)
+		if !ok {
+			return nil, newSelectEvalError("cannot cast %q to BOOL", formatSelectValue(v))
+		}
+		return b, nil
+	default:
+		return formatSelectValue(v), nil
+	}
+}
+
+type sqlFunc struct {
+	name  string
+	args  []sqlExpr
+	arity [2]int
+}
+
+func (e *sqlFunc) eval(rec *selectRecord) (interface{}, error) {
+	args := make([]interface{}, len(e.args))
+	for i, x := range e.args {
+		v, err := x.eval(rec)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	switch e.name {
+	case "COALESCE":
+		for _, v := range args {
+			if v != nil {
+				return v, nil
+			}
+		}
+		return nil, nil
+	case "NULLIF":
+		if args[0] == nil || args[1] == nil {
+			return args[0], nil
+		}
+		if c, err := compareValues(args[0], args[1]); err == nil && c == 0 {
+			return nil, nil
+		}
+		return args[0], nil
+	}
+	for _, v := range args {
+		if v == nil {
+			return nil, nil
+		}
+	}
+	switch e.name {
+	case "LOWER":
+		return strings.ToLower(formatSelectValue(args[0])), nil
+	case "UPPER":
+		return strings.ToUpper(formatSelectValue(args[0])), nil
+	case "CHAR_LENGTH", "CHARACTER_LENGTH":
+		return int64(utf8.RuneCountInString(formatSelectValue(args[0]))), nil
+	case "TRIM":
+		return strings.TrimSpace(formatSelectValue(args[0])), nil
+	case "ABS":
+		n, ok := toNumber(args[0])
+		if !ok {
+			return nil, newSelectEvalError("ABS requires a numeric argument but got %v", args[0])
+		}
+		if i, ok := n.(int64); ok {
+			if i < 0 {
+				return -i, nil
+			}
+			return i, nil
+		}
+		return math.Abs(n.(float64)), nil
+	default: // SUBSTRING, the start position is 1-based
+		s := []rune(formatSelectValue(args[0]))
+		start, ok := toInt(args[1])
+		if !ok {
+			return nil, newSelectEvalError("SUBSTRING requires an integer start position")
+		}
+		end := int64(len(s)) + 1
+		if len(args) == 3 {
+			length, ok := toInt(args[2])
+			if !ok || length < 0 {
+				return nil, newSelectEvalError("SUBSTRING requires a non-negative integer length")
+			}
+			end = start + length
+		}
+		if start < 1 {
+			start = 1
+		}
+		if end > int64(len(s))+1 {
+			end = int64(len(s)) + 1
+		}
+		if start >= end {
+			return "", nil
+		}
+		return string(s[start-1 : end-1]), nil
+	}
+}
+
+// sqlAggregate accumulates the values of all the records, and evaluates to the result.
+type sqlAggregate struct {
+	fn   string
+	x    sqlExpr
+	star bool
+
+	count int64
+	sum   interface{}
+	value interface{}
+}
+
+func (e *sqlAggregate) accumulate(rec *selectRecord) error {
+	if e.star {
+		e.count++
+		return nil
+	}
+	v, err := e.x.eval(rec)
+	if err != nil || v == nil {
+		return err
+	}
+	e.count++
+	switch e.fn {
+	case "SUM", "AVG":
+		n, ok := toNumber(v)
+		if !ok {
+			return newSelectEvalError("%s requires numeric values but got %q", e.fn, formatSelectValue(v))
+		}
+		if e.sum == nil {
+			e.sum = n
+		} else if e.sum, err = arithmetic("+", e.sum, n); err != nil {
+			return err
+		}
+	case "MIN", "MAX":
+		if n, ok := toNumber(v); ok {
+			v = n
+		}
+		if e.value == nil {
+			e.value = v
+			return nil
+		}
+		c, err := compareValues(v, e.value)
+		if err != nil {
+			return err
+		}
+		if e.fn == "MIN" && c < 0 || e.fn == "MAX" && c > 0 {
+			e.value = v
+		}
+	}
+	return nil
+}
+
+func (e *sqlAggregate) eval(*selectRecord) (interface{}, error) {
+	switch e.fn {
+	case "COUNT":
+		return e.count, nil
+	case "SUM":
+		return e.sum, nil
+	case "AVG":
+		if e.count == 0 {
+			return nil, nil
+		}
+		n, _ := toNumber(e.sum)
+		if i, ok := n.(int64); ok {
+			return float64(i) / float64(e.count), nil
+		}
+		return n.(float64) / float64(e.count), nil
+	default:
+		return e.value, nil
+	}
+}
+
+func hasAggregate(x sqlExpr) bool {
+	switch e := x.(type) {
+	case *sqlAggregate:
+		return true
+	case *sqlUnary:
+		return hasAggregate(e.x)
+	case *sqlBinary:
+		return hasAggregate(e.left) || hasAggregate(e.right)
+	case *sqlLike:
+		return hasAggregate(e.x) || hasAggregate(e.pattern) || e.escape != nil && hasAggregate(e.escape)
+	case *sqlIn:
+		for _, item := range e.list {
+			if hasAggregate(item) {
+				return true
+			}
+		}
+		return hasAggregate(e.x)
+	case *sqlBetween:
+		return hasAggregate(e.x) || hasAggregate(e.low) || hasAggregate(e.high)
+	case *sqlIsNull:
+		return hasAggregate(e.x)
+	case *sqlCast:
+		return hasAggregate(e.x)
+	case *sqlFunc:
+		for _, arg := range e.args {
+			if hasAggregate(arg) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// toNumber converts the value to int64 or float64, the strings of CSV are converted if they are numeric.
+func toNumber(v interface{}) (interface{}, bool) {
+	switch x := v.(type) {
+	case int64, float64:
+		return x, true
+	case string:
+		s := strings.TrimSpace(x)
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i, true
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+func toInt(v interface{}) (int64, bool) {
+	n, ok := toNumber(v)
+	if !ok {
+		return 0, false
+	}
+	if f, ok := n.(float64); ok {
+		return int64(f), f == math.Trunc(f)
+	}
+	return n.(int64), true
+}
+
+func toBool(v interface{}) (bool, bool) {
+	switch x := v.(type) {
+	case bool:
+		return x, true
+	case string:
+		if b, err := strconv.ParseBool(strings.TrimSpace(x)); err == nil {
+			return b, true
+		}
+	}
+	return false, false
+}
+
+func arithmetic(op string, l, r interface{}) (interface{}, error) {
+	ln, ok1 := toNumber(l)
+	rn, ok2 := toNumber(r)
+	if !ok1 || !ok2 {
+		return nil, newSelectEvalError("arithmetic %s requires numeric operands but got %q and %q",
+			op, formatSelectValue(l), formatSelectValue(r))
+	}
+	li, lint := ln.(int64)
+	ri, rint := rn.(int64)
+	if lint && rint {
+		switch op {
+		case "+":
+			return li + ri, nil
+		case "-":
+			return li - ri, nil
+		case "*":
+			return li * ri, nil
+		case "/", "%":
+			if ri == 0 {
+				return nil, newSelectEvalError("division by zero")
+			}
+			if op == "/" {
+				return li / ri, nil
+			}
+			return li % ri, nil
+		}
+	}
+	lf, rf := numberToFloat(ln), numberToFloat(rn)
+	switch op {
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	case "/":
+		if rf == 0 {
+			return nil, newSelectEvalError("division by zero")
+		}
+		return lf / rf, nil
+	default:
+		if rf == 0 {
+			return nil, newSelectEvalError("division by zero")
+		}
+		return math.Mod(lf, rf), nil
+	}
+}
+
+func numberToFloat(n interface{}) float64 {
+	if i, ok := n.(int64); ok {
+		return float64(i)
+	}
+	return n.(float64)
+}
+
+// compareValues compares two non-null values. The numbers are compared numerically, and a string is
+// converted if it is compared with a number or a boolean.
+func compareValues(l, r interface{}) (int, error) {
+	ls, lstr := l.(string)
+	rs, rstr := r.(string)
+	if lstr && rstr {
+		return strings.Compare(ls, rs), nil
+	}
+	if lb, ok := l.(bool); ok {
+		rb, ok := toBool(r)
+		if !ok {
+			return 0, newSelectEvalError("cannot compare %v with %q", lb, formatSelectValue(r))
+		}
+		return compareBool(lb, rb), nil
+	}
+	if rb, ok := r.(bool); ok {
+		lb, ok := toBool(l)
+		if !ok {
+			return 0, newSelectEvalError("cannot compare %q with %v", formatSelectValue(l), rb)
+		}
+		return compareBool(lb, rb), nil
+	}
+	ln, ok1 := toNumber(l)
+	rn, ok2 := toNumber(r)
+	if ok1 && ok2 {
+		li, lint := ln.(int64)
+		ri, rint := rn.(int64)
+		if lint && rint {
+			switch {
+			case li < ri:
+				return -1, nil
+			case li > ri:
+				return 1, nil
+			}
+			return 0, nil
+		}
+		lf, rf := numberToFloat(ln), numberToFloat(rn)
+		switch {
+		case lf < rf:
+			return -1, nil
+		case lf > rf:
+			return 1, nil
+		}
+		return 0, nil
+	}
+	if lstr || rstr {
+		return strings.Compare(formatSelectValue(l), formatSelectValue(r)), nil
+	}
+	return 0, newSelectEvalError("cannot compare %q with %q", formatSelectValue(l), formatSelectValue(r))
+}
+
+func compareBool(l, r bool) int {
+	switch {
+	case l == r:
+		return 0
+	case !l:
+		return -1
+	}
+	return 1
+}
@@ -0,0 +1,369 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/private/protocol/eventstream"
+	"github.com/stretchr/testify/require"
+)
+
+type bytesSelectSource []byte
+
+func (b bytesSelectSource) Size() int64 {
+	return int64(len(b))
+}
+
+func (b bytesSelectSource) NewReader(offset, length int64) (io.ReadCloser, error) {
+	end := offset + length
+	if end > int64(len(b)) {
+		end = int64(len(b))
+	}
+	return io.NopCloser(bytes.NewReader(b[offset:end])), nil
+}
+
+type bufferSelectOutput struct {
+	bytes.Buffer
+	messages int
+}
+
+func (b *bufferSelectOutput) SendRecords(payload []byte) error {
+	b.messages++
+	_, err := b.Write(payload)
+	return err
+}
+
+func newSelectRequest(expression, input, output string) string {
+	return fmt.Sprintf(`<SelectObjectContentRequest>
+		<Expression>%s</Expression><ExpressionType>SQL</ExpressionType>
+		<InputSerialization>%s</InputSerialization>
+		<OutputSerialization>%s</OutputSerialization>
+	</SelectObjectContentRequest>`, expression, input, output)
+}
+
+func runSelect(t *testing.T, request string, data []byte) (string, *SelectStats, error) {
+	req, err := ParseSelectRequestFromXML([]byte(request))
+	require.NoError(t, err)
+	executor, err := newSelectExecutor(req)
+	if err != nil {
+		return "", nil, err
+	}
+	output := &bufferSelectOutput{}
+	err = executor.Run(bytesSelectSource(data), output)
+	return output.String(), executor.Stats(), err
+}
+
+const selectTestCSV = "id,name,age,city\n1,alice,30,beijing\n2,bob,25,\"shang,hai\"\n3,carol,35,shenzhen\n"
+
+func TestParseSelectRequest(t *testing.T) {
+	csv, json := `<CSV><FileHeaderInfo>USE</FileHeaderInfo></CSV>`, `<JSON><Type>LINES</Type></JSON>`
+	valid := []string{
+		newSelectRequest("SELECT * FROM S3Object", csv, "<CSV/>"),
+		newSelectRequest("SELECT * FROM S3Object", json, "<JSON/>"),
+		newSelectRequest("SELECT * FROM S3Object", "<Parquet/>", "<JSON/>"),
+		newSelectRequest("SELECT * FROM S3Object", "<CompressionType>GZIP</CompressionType>"+csv, "<CSV/>"),
+	}
+	for i, request := range valid {
+		_, err := ParseSelectRequestFromXML([]byte(request))
+		require.NoError(t, err, "case %d", i)
+	}
+
+	invalid := map[string]error{
+		newSelectRequest("", csv, "<CSV/>"):                                                                       nil,
+		newSelectRequest("SELECT * FROM S3Object", csv+json, "<CSV/>"):                                            nil,
+		newSelectRequest("SELECT * FROM S3Object", csv, "<CSV/><JSON/>"):                                          nil,
+		newSelectRequest("SELECT * FROM S3Object", `<CSV><FileHeaderInfo>X</FileHeaderInfo></CSV>`, "<CSV/>"):     InvalidFileHeaderInfo,
+		newSelectRequest("SELECT * FROM S3Object", `<JSON><Type>X</Type></JSON>`, "<CSV/>"):                       InvalidJsonType,
+		newSelectRequest("SELECT * FROM S3Object", csv, `<CSV><QuoteFields>X</QuoteFields></CSV>`):                InvalidQuoteFields,
+		newSelectRequest("SELECT * FROM S3Object", "<CompressionType>LZ4</CompressionType>"+csv, "<CSV/>"):        InvalidCompressionFormat,
+		newSelectRequest("SELECT * FROM S3Object", "<CompressionType>GZIP</CompressionType><Parquet/>", "<CSV/>"): nil,
+		`<SelectObjectContentRequest><Expression>SELECT * FROM S3Object</Expression><ExpressionType>XPath</ExpressionType>` +
+			`<InputSerialization><CSV/></InputSerialization><OutputSerialization><CSV/></OutputSerialization></SelectObjectContentRequest>`: InvalidExpressionType,
+		`<SelectObjectContentRequest><Expression>SELECT * FROM S3Object</Expression><ExpressionType>SQL</ExpressionType>` +
+			`<InputSerialization><CompressionType>GZIP</CompressionType><CSV/></InputSerialization><OutputSerialization><CSV/></OutputSerialization>` +
+			`<ScanRange><Start>1</Start></ScanRange></SelectObjectContentRequest>`: UnsupportedScanRangeInput,
+		`<SelectObjectContentRequest><Expression>SELECT * FROM S3Object</Expression><ExpressionType>SQL</ExpressionType>` +
+			`<InputSerialization><CSV/></InputSerialization><OutputSerialization><CSV/></OutputSerialization>` +
+			`<ScanRange><Start>10</Start><End>1</End></ScanRange></SelectObjectContentRequest>`: InvalidScanRange,
+		`<SelectObjectContentRequest>`: MalformedXML,
+	}
+	for request, expected := range invalid {
+		_, err := ParseSelectRequestFromXML([]byte(request))
+		require.Error(t, err, request)
+		if expected != nil {
+			require.Equal(t, expected, err, request)
+		}
+	}
+}
+
+func TestParseSelectQuery(t *testing.T) {
+	valid := []string{
+		"SELECT * FROM S3Object",
+		"select s.name, s._2 AS n FROM s3object s WHERE s.age > 20 AND name LIKE 'a%' LIMIT 10",
+		"SELECT COUNT(*), SUM(CAST(age AS INT)), AVG(age), MIN(age), MAX(age) FROM S3Object",
+		"SELECT \"Name\" FROM S3Object[*].items[*] AS i WHERE i.tags[0] IN ('a', 'b') OR i.x IS NOT NULL",
+		"SELECT UPPER(name) || '-' || LOWER(city), SUBSTRING(name FROM 2 FOR 3), CHAR_LENGTH(name) FROM S3Object",
+		"SELECT * FROM S3Object WHERE age BETWEEN 1 AND 2 AND NOT (age <> 3) AND age NOT IN (4, 5) AND name NOT LIKE '%x'",
+	}
+	for _, sql := range valid {
+		_, err := parseSelectQuery(sql)
+		require.NoError(t, err, sql)
+	}
+
+	invalid := []string{
+		"",
+		"SELECT",
+		"SELECT * FROM",
+		"SELECT * FROM t",
+		"SELECT * FROM S3Object WHERE",
+		"SELECT * FROM S3Object WHERE name = 'a",
+		"SELECT * FROM S3Object LIMIT -1",
+		"SELECT * FROM S3Object LIMIT a",
+		"SELECT name, COUNT(*) FROM S3Object",
+		"SELECT * FROM S3Object WHERE COUNT(*) > 1",
+		"SELECT SUM(COUNT(*)) FROM S3Object",
+		"SELECT FOO(name) FROM S3Object",
+		"SELECT CAST(name AS DATE) FROM S3Object",
+		"SELECT * FROM S3Object WHERE name ! 'a'",
+		"SELECT * FROM S3Object extra tokens",
+	}
+	for _, sql := range invalid {
+		_, err := parseSelectQuery(sql)
+		require.Error(t, err, sql)
+	}
+}
+
+func TestEvalSelectExpression(t *testing.T) {
+	rec := &selectRecord{names: []string{"a", "b", "s", "e"}, fields: []string{"10", "2.5", "Hello", ""}}
+	tests := map[string]interface{}{
+		"a + 1":                        int64(11),
+		"a / 4":                        int64(2),
+		"a % 4":                        int64(2),
+		"b * 2":                        5.0,
+		"-a":                           int64(-10),
+		"a > 9 AND b < 3":              true,
+		"a > 9 AND NULL":               nil,
+		"a < 9 AND NULL":               false,
+		"a > 9 OR NULL":                true,
+		"NOT (a = 10)":                 false,
+		"a = '10'":                     true,
+		"s = 'Hello'":                  true,
+		"s LIKE 'H_l%'":                true,
+		"s LIKE 'h%'":                  false,
+		"'50%' LIKE '50!%' ESCAPE '!'": true,
+		"a IN (1, 10)":                 true,
+		"a NOT IN (1, 2)":              true,
+		"a IN (1, NULL)":               nil,
+		"a BETWEEN 5 AND 10":           true,
+		"a NOT BETWEEN 5 AND 10":       false,
+		"x IS NULL":                    true,
+		"e IS NOT NULL":                true,
+		"s || '!'":                     "Hello!",
+		"UPPER(s)":                     "HELLO",
+		"LOWER(s)":                     "hello",
+		"CHAR_LENGTH(s)":               int64(5),
+		"TRIM('  x ')":                 "x",
+		"SUBSTRING(s, 2, 3)":           "ell",
+		"SUBSTRING(s FROM 3)":          "llo",
+		"COALESCE(x, NULL, s)":         "Hello",
+		"NULLIF(a, 10)":                nil,
+		"ABS(0 - a)":                   int64(10),
+		"CAST(b AS INT)":               int64(2),
+		"CAST(a AS FLOAT)":             10.0,
+		"CAST(a AS STRING)":            "10",
+		"CAST('true' AS BOOL)":         true,
+		"_1":                           "10",
+		"_3":                           "Hello",
+		"\"A\"":                        nil,
+		"A":                            "10",
+	}
+	for expr, expected := range tests {
+		q, err := parseSelectQuery("SELECT " + expr + " FROM S3Object")
+		require.NoError(t, err, expr)
+		v, err := q.projections[0].expr.eval(rec)
+		require.NoError(t, err, expr)
+		require.Equal(t, expected, v, expr)
+	}
+
+	for _, expr := range []string{"a / 0", "s + 1", "CAST(s AS INT)", "NOT s"} {
+		q, err := parseSelectQuery("SELECT " + expr + " FROM S3Object")
+		require.NoError(t, err, expr)
+		_, err = q.projections[0].expr.eval(rec)
+		require.Error(t, err, expr)
+	}
+}
+
+func TestSelectCSV(t *testing.T) {
+	csvInput := `<CSV><FileHeaderInfo>USE</FileHeaderInfo></CSV>`
+	tests := []struct {
+		sql    string
+		input  string
+		output string
+		result string
+	}{
+		{"SELECT * FROM S3Object", csvInput, "<CSV/>", "1,alice,30,beijing\n2,bob,25,\"shang,hai\"\n3,carol,35,shenzhen\n"},
+		{"SELECT s.name, s.city FROM S3Object s WHERE CAST(s.age AS INT) >= 30", csvInput, "<CSV/>", "alice,beijing\ncarol,shenzhen\n"},
+		{"SELECT name FROM S3Object WHERE age > 26 LIMIT 1", csvInput, "<CSV/>", "alice\n"},
+		{"SELECT _2 FROM S3Object", `<CSV><FileHeaderInfo>IGNORE</FileHeaderInfo></CSV>`, "<CSV/>", "alice\nbob\ncarol\n"},
+		{"SELECT _1 FROM S3Object", `<CSV><FileHeaderInfo>NONE</FileHeaderInfo></CSV>`, "<CSV/>", "id\n1\n2\n3\n"},
+		{"SELECT COUNT(*), SUM(age), AVG(age), MIN(name), MAX(age) FROM S3Object", csvInput, "<CSV/>", "3,90,30,alice,35\n"},
+		{"SELECT COUNT(*) FROM S3Object WHERE city LIKE 'sh%'", csvInput, "<JSON/>", "{\"_1\":2}\n"},
+		{"SELECT id, name AS n FROM S3Object WHERE id = 2", csvInput, "<JSON/>", "{\"id\":\"2\",\"n\":\"bob\"}\n"},
+		{"SELECT * FROM S3Object WHERE id = 2", csvInput, "<JSON/>", "{\"id\":\"2\",\"name\":\"bob\",\"age\":\"25\",\"city\":\"shang,hai\"}\n"},
+		{"SELECT name, city FROM S3Object WHERE id = 1", csvInput,
+			"<CSV><QuoteFields>ALWAYS</QuoteFields><FieldDelimiter>|</FieldDelimiter><RecordDelimiter>;</RecordDelimiter></CSV>",
+			"\"alice\"|\"beijing\";"},
+	}
+	for _, tc := range tests {
+		result, _, err := runSelect(t, newSelectRequest(tc.sql, tc.input, tc.output), []byte(selectTestCSV))
+		require.NoError(t, err, tc.sql)
+		require.Equal(t, tc.result, result, tc.sql)
+	}
+
+	// custom delimiters, quote, escape and comments
+	data := "# comment\r\na|b\r\n'x\\'y'|'1\r\n2'\r\n"
+	input := `<CSV><FileHeaderInfo>USE</FileHeaderInfo><Comments>#</Comments><FieldDelimiter>|</FieldDelimiter>
+		<RecordDelimiter>&#13;&#10;</RecordDelimiter><QuoteCharacter>'</QuoteCharacter><QuoteEscapeCharacter>\</QuoteEscapeCharacter></CSV>`
+	result, _, err := runSelect(t, newSelectRequest("SELECT a, b FROM S3Object", input, "<JSON/>"), []byte(data))
+	require.NoError(t, err)
+	require.Equal(t, "{\"a\":\"x'y\",\"b\":\"1\\r\\n2\"}\n", result)
+
+	// gzip compressed object
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	_, err = gw.Write([]byte(selectTestCSV))
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+	result, stats, err := runSelect(t, newSelectRequest("SELECT name FROM S3Object",
+		"<CompressionType>GZIP</CompressionType>"+csvInput, "<CSV/>"), buf.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, "alice\nbob\ncarol\n", result)
+	require.Equal(t, int64(buf.Len()), stats.BytesScanned)
+	require.Equal(t, int64(len(selectTestCSV)), stats.BytesProcessed)
+	require.Equal(t, int64(len(result)), stats.BytesReturned)
+
+	// evaluation errors are returned
+	_, _, err = runSelect(t, newSelectRequest("SELECT name FROM S3Object WHERE name > 1 + name", csvInput, "<CSV/>"), []byte(selectTestCSV))
+	require.Error(t, err)
+}
+
+func TestSelectCSVScanRange(t *testing.T) {
+	request := func(start, end int) string {
+		return fmt.Sprintf(`<SelectObjectContentRequest><Expression>SELECT id FROM S3Object</Expression>
+			<ExpressionType>SQL</ExpressionType>
+			<InputSerialization><CSV><FileHeaderInfo>USE</FileHeaderInfo></CSV></InputSerialization>
+			<OutputSerialization><CSV/></OutputSerialization>
+			<ScanRange><Start>%d</Start><End>%d</End></ScanRange></SelectObjectContentRequest>`, start, end)
+	}
+	// the records start at the offsets 17, 37 and 60
+	tests := []struct {
+		start, end int
+		result     string
+	}{
+		{0, 16, ""},
+		{0, 17, "1\n"},
+		{17, 17, "1\n"},
+		{18, 37, "2\n"},
+		{17, 1000, "1\n2\n3\n"},
+		{38, 1000, "3\n"},
+		{61, 1000, ""},
+	}
+	for _, tc := range tests {
+		result, _, err := runSelect(t, request(tc.start, tc.end), []byte(selectTestCSV))
+		require.NoError(t, err)
+		require.Equal(t, tc.result, result, "range [%d, %d]", tc.start, tc.end)
+	}
+}
+
+func TestSelectJSON(t *testing.T) {
+	lines := `{"id":1,"name":"alice","tags":["a","b"],"addr":{"city":"beijing"}}
+{"id":2,"name":"bob","tags":["c"],"addr":{"city":"shanghai"},"score":9.5}
+
+{"id":3,"name":"carol","tags":[],"addr":null}
+`
+	document := `{"items":[{"id":1,"v":true},{"id":2,"v":false}]} {"items":[{"id":3,"v":true}]}`
+	linesInput, documentInput := `<JSON><Type>LINES</Type></JSON>`, `<JSON><Type>DOCUMENT</Type></JSON>`
+	tests := []struct {
+		sql    string
+		input  string
+		data   string
+		output string
+		result string
+	}{
+		{"SELECT s.name, s.addr.city FROM S3Object s WHERE s.id > 1", linesInput, lines, "<JSON/>",
+			"{\"name\":\"bob\",\"city\":\"shanghai\"}\n{\"name\":\"carol\",\"city\":null}\n"},
+		{"SELECT s.tags[0] AS t FROM S3Object s", linesInput, lines, "<CSV/>", "a\nc\n\n"},
+		{"SELECT * FROM S3Object s WHERE s.score IS NOT NULL", linesInput, lines, "<JSON/>",
+			"{\"id\":2,\"name\":\"bob\",\"tags\":[\"c\"],\"addr\":{\"city\":\"shanghai\"},\"score\":9.5}\n"},
+		{"SELECT s.tags FROM S3Object s LIMIT 1", linesInput, lines, "<CSV/>", "\"[\"\"a\"\",\"\"b\"\"]\"\n"},
+		{"SELECT COUNT(*), SUM(s.id), MAX(s.score) FROM S3Object s", linesInput, lines, "<CSV/>", "3,6,9.5\n"},
+		{"SELECT s.id FROM S3Object[*].items[*] s WHERE s.v = true", documentInput, document, "<CSV/>", "1\n3\n"},
+		{"SELECT s FROM S3Object[*].items[*] s WHERE s.id = 2", documentInput, document, "<JSON/>", "{\"id\":2,\"v\":false}\n"},
+		{"SELECT COUNT(*) FROM S3Object", documentInput, document, "<CSV/>", "2\n"},
+	}
+	for _, tc := range tests {
+		result, _, err := runSelect(t, newSelectRequest(tc.sql, tc.input, tc.output), []byte(tc.data))
+		require.NoError(t, err, tc.sql)
+		require.Equal(t, tc.result, result, tc.sql)
+	}
+
+	_, _, err := runSelect(t, newSelectRequest("SELECT * FROM S3Object", linesInput, "<JSON/>"), []byte("{\"a\":1}\n{\"a\":"))
+	require.Error(t, err)
+}
+
+func TestSelectEventStream(t *testing.T) {
+	w := httptest.NewRecorder()
+	stream := newSelectEventStream(w)
+	require.False(t, stream.Started())
+	require.NoError(t, stream.SendRecords([]byte("a,b\n")))
+	require.True(t, stream.Started())
+	require.NoError(t, stream.SendProgress(&SelectStats{BytesScanned: 1, BytesProcessed: 2, BytesReturned: 3}))
+	require.NoError(t, stream.SendStats(&SelectStats{BytesScanned: 4, BytesProcessed: 5, BytesReturned: 6}))
+	require.NoError(t, stream.SendCont())
+	require.NoError(t, stream.SendError(InvalidScanRange))
+	require.NoError(t, stream.SendEnd())
+	require.Equal(t, 200, w.Code)
+
+	dec := eventstream.NewDecoder(w.Body)
+	var messages []eventstream.Message
+	for {
+		msg, err := dec.Decode(nil)
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		messages = append(messages, msg)
+	}
+	require.Equal(t, 6, len(messages))
+	expected := []string{"Records", "Progress", "Stats", "Cont", "", "End"}
+	for i, msg := range messages {
+		if expected[i] == "" {
+			continue
+		}
+		require.Equal(t, "event", msg.Headers.Get(":message-type").String())
+		require.Equal(t, expected[i], msg.Headers.Get(":event-type").String())
+	}
+	require.Equal(t, "a,b\n", string(messages[0].Payload))
+	require.Contains(t, string(messages[1].Payload), "<Progress><BytesScanned>1</BytesScanned><BytesProcessed>2</BytesProcessed><BytesReturned>3</BytesReturned></Progress>")
+	require.Contains(t, string(messages[2].Payload), "<Stats><BytesScanned>4</BytesScanned>")
+	require.Equal(t, "error", messages[4].Headers.Get(":message-type").String())
+	require.Equal(t, InvalidScanRange.ErrorCode, messages[4].Headers.Get(":error-code").String())
+}
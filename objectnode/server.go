@@ -36,6 +36,7 @@ import (
 	"github.com/cubefs/cubefs/sdk/master"
 	"github.com/cubefs/cubefs/util/config"
 	"github.com/cubefs/cubefs/util/exporter"
+	"github.com/cubefs/cubefs/util/keystore"
 	"github.com/cubefs/cubefs/util/log"
 	"github.com/cubefs/cubefs/util/reloadconf"
 
@@ -93,6 +94,18 @@ const (
 	// The configuration in the example will allow ObjectNode to automatically resolve "* .object.cube.io".
 	configDomains = "domains"
 
+	// The string array configuration item is used to configure the domain names of the static website
+	// endpoints. The buckets with website configuration are served as websites by the host names of
+	// the bucket under these domains, which must be different from the domains of the api.
+	// Example:
+	//		{
+	//			"websiteDomains": [
+	//				"website.cube.io"
+	//			]
+	//		}
+	// The configuration in the example will allow ObjectNode to serve "<bucket>.website.cube.io" as website.
+	configWebsiteDomains = "websiteDomains"
+
 	disabledActions               = "disabledActions"
 	configSignatureIgnoredActions = "signatureIgnoredActions"
 
@@ -154,6 +167,146 @@ const (
 
 	// s3 QoS config refresh interval
 	s3QoSRefreshIntervalSec = "s3QoSRefreshIntervalSec"
+
+	// String type configuration item, used to configure the file of the cluster master key which wraps the
+	// data keys of objects encrypted by SSE-S3. The file contains a 256-bit key encoded in hex or base64.
+	// SSE-S3 is disabled if it is not configured.
+	// Example:
+	//		{
+	//			"sseMasterKeyFile": "/cfs/conf/sse_master.key"
+	//		}
+	configSSEMasterKeyFile = "sseMasterKeyFile"
+
+	// Int type configuration item, used to limit the memory in MB used to decode the object data of a single
+	// SelectObjectContent request, e.g. a row group of the Parquet object. The default is 256MB.
+	// Example:
+	//		{
+	//			"selectMemoryLimitMB": 512
+	//		}
+	configSelectMemoryLimitMB = "selectMemoryLimitMB"
+
+	// Map type configuration item, used to enable the bucket replication. The replicator consumes the object
+	// writes from the topic of the Kafka audit configured in kafka, which is required, and replicates the
+	// objects asynchronously. The remote S3 endpoints which can be used as the replication destinations
+	// are configured in targets, and referenced by the destination bucket ARN in the form of
+	// "arn:aws:s3:<target>::<bucket>".
+	// The access keys used by other clusters to replicate objects to this cluster are configured in
+	// peer_access_keys. For detailed parameters, see the ReplicationConfig structure.
+	// Example:
+	//		{
+	//			"replication": {
+	//				"targets": {
+	//					"backup": {
+	//						"endpoint": "http://192.168.80.140:80",
+	//						"region": "cfs_backup",
+	//						"access_key": "...",
+	//						"secret_key": "..."
+	//					}
+	//				},
+	//				"kafka": {
+	//					"brokers": "192.168.0.1:9092",
+	//					"topic": "cubefs-audit",
+	//					"group": "cubefs-replication"
+	//				},
+	//				"peer_access_keys": ["..."]
+	//			}
+	//		}
+	configReplication = "replication"
+
+	// Map type configuration item, used to enable the bucket event notifications. The events are sent to the
+	// webhook and Kafka targets, which are referenced by the queue or topic ARN in the form of
+	// "arn:aws:sqs:::<target>" or "arn:aws:sns:::<target>". The events are persisted in the queue_dir before
+	// being sent, and retried until delivered. For detailed parameters, see the NotificationConfig structure.
+	// Example:
+	//		{
+	//			"notification": {
+	//				"queue_dir": "/cfs/notification",
+	//				"queue_limit": 100000,
+	//				"webhook": {
+	//					"hook": {
+	//						"endpoint": "http://192.168.0.1:8080/events"
+	//					}
+	//				},
+	//				"kafka": {
+	//					"events": {
+	//						"brokers": "192.168.0.1:9092",
+	//						"topic": "cubefs-events"
+	//					}
+	//				}
+	//			}
+	//		}
+	configNotification = "notification"
+
+	// Map type configuration item, used to enable the server access logging of the buckets. The access
+	// records of the buckets with logging enabled are batched and written as objects into the target buckets,
+	// at most rate_limit objects per second. The batches are persisted in the queue_dir before being written,
+	// so that they are delivered after the restart. For detailed parameters, see the BucketLoggingConfig structure.
+	// Example:
+	//		{
+	//			"bucketLogging": {
+	//				"queue_dir": "/cfs/bucket_logging",
+	//				"flush_interval_sec": 300,
+	//				"max_records": 10000,
+	//				"rate_limit": 10
+	//			}
+	//		}
+	configBucketLogging = "bucketLogging"
+
+	// Map type configuration item, used to enable the request metrics by bucket and by user, which are
+	// exported to Prometheus. The number of the distinct buckets and users in the labels is limited by
+	// max_buckets and max_users, and the number of the metrics configurations of the buckets is limited
+	// by max_filters. For detailed parameters, see the BucketMetricsConfig structure.
+	// Example:
+	//		{
+	//			"bucketMetrics": {
+	//				"max_buckets": 1000,
+	//				"max_users": 1000,
+	//				"max_filters": 1000
+	//			}
+	//		}
+	configBucketMetrics = "bucketMetrics"
+
+	// Map type configuration item, used to enable the AssumeRoleWithWebIdentity of STS, which issues the
+	// temporary credentials for the tokens of the OIDC issuer. The identities are mapped to the users by
+	// the rules in order, or by the user_claim. For detailed parameters, see the WebIdentityConfig structure.
+	// Example:
+	//		{
+	//			"webIdentity": {
+	//				"issuer": "https://kubernetes.default.svc",
+	//				"client_ids": ["sts.cube.io"],
+	//				"rules": [
+	//					{
+	//						"claim": "sub",
+	//						"value": "system:serviceaccount:default:app",
+	//						"user": "app",
+	//						"policy": {
+	//							"Version": "2012-10-17",
+	//							"Statement": [{"Effect": "Allow", "Action": "s3:*", "Resource": "arn:aws:s3:::app/*"}]
+	//						}
+	//					}
+	//				]
+	//			}
+	//		}
+	configWebIdentity = "webIdentity"
+
+	// Map type configuration item, used to enable the Swift v1 API, the containers are the buckets and the
+	// accounts are the users. The tokens are issued by the TempAuth compatible auth with the keys of the
+	// users, or validated by Keystone if it is configured. For detailed parameters, see the SwiftConfig structure.
+	// Example:
+	//		{
+	//			"swift": {
+	//				"token_ttl_sec": 86400,
+	//				"max_manifest_segments": 1000,
+	//				"keystone": {
+	//					"url": "http://keystone:5000",
+	//					"username": "swift",
+	//					"password": "password",
+	//					"project": "service",
+	//					"user_field": "project_id"
+	//				}
+	//			}
+	//		}
+	configSwift = "swift"
 )
 
 // Default of configuration value
@@ -181,19 +334,26 @@ var (
 )
 
 type ObjectNode struct {
-	domains    []string
-	wildcards  Wildcards
-	listen     string
-	region     string
-	httpServer *http.Server
-	vm         *VolumeManager
-	mc         *master.MasterClient
-	userStore  UserInfoStore
+	domains        []string
+	websiteDomains []string
+	wildcards      Wildcards
+	listen         string
+	region         string
+	httpServer     *http.Server
+	vm             *VolumeManager
+	mc             *master.MasterClient
+	userStore      UserInfoStore
 	// state      uint32
 	// wg         sync.WaitGroup
 
 	localAuditHandler rpc.ProgressHandler
 	externalAudit     *ExternalAudit
+	replicator        *Replicator
+	notifier          *Notifier
+	bucketLogger      *BucketLogger
+	bucketMetrics     *BucketMetrics
+	webIdentity       *WebIdentityVerifier
+	swift             *SwiftAuthenticator
 
 	closes []func() // close other resources after http server closed
 
@@ -240,6 +400,16 @@ func (o *ObjectNode) loadConfig(cfg *config.Config) (err error) {
 	}
 	log.LogInfof("loadConfig: setup config: %v(%v)", configDomains, domains)
 
+	// parse website domain
+	websiteDomains := cfg.GetStringSlice(configWebsiteDomains)
+	for _, domain := range websiteDomains {
+		if StringListContain(domains, domain) {
+			return fmt.Errorf("website domain %v conflicts with api domain", domain)
+		}
+	}
+	o.websiteDomains = websiteDomains
+	log.LogInfof("loadConfig: setup config: %v(%v)", configWebsiteDomains, websiteDomains)
+
 	// parse master config
 	masters := cfg.GetStringSlice(configMasterAddr)
 	if len(masters) == 0 {
@@ -286,6 +456,74 @@ func (o *ObjectNode) loadConfig(cfg *config.Config) (err error) {
 		log.LogInfof("loadConfig: setup config: %v(%v)", configAuditLog, rawAuditLog)
 	}
 
+	// parse sse master key
+	if masterKeyFile := cfg.GetString(configSSEMasterKeyFile); masterKeyFile != "" {
+		if sseMasterKey, err = keystore.LoadMasterKey(masterKeyFile); err != nil {
+			err = fmt.Errorf("invalid %v configuration: %v", configSSEMasterKeyFile, err)
+			return
+		}
+		log.LogInfof("loadConfig: setup config: %v(%v)", configSSEMasterKeyFile, masterKeyFile)
+	}
+
+	// parse notification config
+	if rawNotification := cfg.GetValue(configNotification); rawNotification != nil {
+		if err = o.setNotification(rawNotification); err != nil {
+			err = fmt.Errorf("invalid %v configuration: %v", configNotification, err)
+			return
+		}
+		log.LogInfof("loadConfig: setup config: %v(%v)", configNotification, rawNotification)
+	}
+
+	// parse bucket logging config
+	if rawBucketLogging := cfg.GetValue(configBucketLogging); rawBucketLogging != nil {
+		if err = o.setBucketLogging(rawBucketLogging); err != nil {
+			err = fmt.Errorf("invalid %v configuration: %v", configBucketLogging, err)
+			return
+		}
+		log.LogInfof("loadConfig: setup config: %v(%v)", configBucketLogging, rawBucketLogging)
+	}
+
+	// parse bucket metrics config
+	if rawBucketMetrics := cfg.GetValue(configBucketMetrics); rawBucketMetrics != nil {
+		if err = o.setBucketMetrics(rawBucketMetrics); err != nil {
+			err = fmt.Errorf("invalid %v configuration: %v", configBucketMetrics, err)
+			return
+		}
+		log.LogInfof("loadConfig: setup config: %v(%v)", configBucketMetrics, rawBucketMetrics)
+	}
+
+	// parse web identity config
+	if rawWebIdentity := cfg.GetValue(configWebIdentity); rawWebIdentity != nil {
+		if err = o.setWebIdentity(rawWebIdentity); err != nil {
+			err = fmt.Errorf("invalid %v configuration: %v", configWebIdentity, err)
+			return
+		}
+		log.LogInfof("loadConfig: setup config: %v(%v)", configWebIdentity, rawWebIdentity)
+	}
+
+	// parse swift config
+	if rawSwift := cfg.GetValue(configSwift); rawSwift != nil {
+		if err = o.setSwift(rawSwift); err != nil {
+			err = fmt.Errorf("invalid %v configuration: %v", configSwift, err)
+			return
+		}
+		log.LogInfof("loadConfig: setup config: %v(%v)", configSwift, rawSwift)
+	}
+
+	if limit := cfg.GetInt64(configSelectMemoryLimitMB); limit > 0 {
+		selectMemoryLimit = limit << 20
+		log.LogInfof("loadConfig: setup config: %v(%v)", configSelectMemoryLimitMB, limit)
+	}
+
+	// parse replication config
+	if rawReplication := cfg.GetValue(configReplication); rawReplication != nil {
+		if err = o.setReplication(rawReplication); err != nil {
+			err = fmt.Errorf("invalid %v configuration: %v", configReplication, err)
+			return
+		}
+		log.LogInfof("loadConfig: setup config: %v(%v)", configReplication, rawReplication)
+	}
+
 	// parse strict config
 	strict := cfg.GetBool(configStrict)
 	log.LogInfof("loadConfig: strict: %v", strict)
@@ -374,6 +612,93 @@ func (o *ObjectNode) setAuditLog(raw interface{}) error {
 	return nil
 }
 
+func (o *ObjectNode) setReplication(raw interface{}) error {
+	var conf ReplicationConfig
+	if err := ParseJSONEntity(raw, &conf); err != nil {
+		return err
+	}
+	replicator, err := NewReplicator(conf, o.getVol)
+	if err != nil {
+		return err
+	}
+	o.replicator = replicator
+	o.closes = append(o.closes, func() { replicator.Close() })
+
+	return nil
+}
+
+func (o *ObjectNode) setNotification(raw interface{}) error {
+	var conf NotificationConfig
+	if err := ParseJSONEntity(raw, &conf); err != nil {
+		return err
+	}
+	notifier, err := NewNotifier(conf)
+	if err != nil {
+		return err
+	}
+	o.notifier = notifier
+	o.closes = append(o.closes, func() { notifier.Close() })
+
+	return nil
+}
+
+func (o *ObjectNode) setBucketLogging(raw interface{}) error {
+	var conf BucketLoggingConfig
+	if err := ParseJSONEntity(raw, &conf); err != nil {
+		return err
+	}
+	bucketLogger, err := NewBucketLogger(conf, o.getVol)
+	if err != nil {
+		return err
+	}
+	o.bucketLogger = bucketLogger
+	o.closes = append(o.closes, func() { bucketLogger.Close() })
+
+	return nil
+}
+
+func (o *ObjectNode) setBucketMetrics(raw interface{}) error {
+	var conf BucketMetricsConfig
+	if err := ParseJSONEntity(raw, &conf); err != nil {
+		return err
+	}
+	bucketMetrics, err := NewBucketMetrics(conf)
+	if err != nil {
+		return err
+	}
+	o.bucketMetrics = bucketMetrics
+
+	return nil
+}
+
+func (o *ObjectNode) setWebIdentity(raw interface{}) error {
+	var conf WebIdentityConfig
+	if err := ParseJSONEntity(raw, &conf); err != nil {
+		return err
+	}
+	verifier, err := NewWebIdentityVerifier(conf)
+	if err != nil {
+		return err
+	}
+	o.webIdentity = verifier
+
+	return nil
+}
+
+func (o *ObjectNode) setSwift(raw interface{}) error {
+	var conf SwiftConfig
+	if err := ParseJSONEntity(raw, &conf); err != nil {
+		return err
+	}
+	auth, err := NewSwiftAuthenticator(conf)
+	if err != nil {
+		return err
+	}
+	o.swift = auth
+
+	return nil
+}
+
 func handleStart(s common.Server, cfg *config.Config) (err error) {
 	o, ok := s.(*ObjectNode)
 	if !ok {
@@ -454,10 +779,23 @@ func handleShutdown(s common.Server) {
 	o.shutdown()
 }
 
-func (o *ObjectNode) startMuxRestAPI() (err error) {
+// newRouter returns the router of all the APIs served by the node.
+func (o *ObjectNode) newRouter() *mux.Router {
 	router := mux.NewRouter().SkipClean(true)
-	o.registerApiRouters(router)
-	router.Use(
+	// the requests of the Swift API are matched before the ones of S3, as the paths are matched by both
+	if o.swift != nil {
+		swiftRouter := router.NewRoute().MatcherFunc(isSwiftRequest).Subrouter()
+		o.registerSwiftRouters(swiftRouter)
+		swiftRouter.Use(
+			o.auditMiddleware,
+			o.expectMiddleware,
+			o.traceMiddleware,
+			o.swiftAuthMiddleware,
+		)
+	}
+	s3Router := router.NewRoute().Subrouter()
+	o.registerApiRouters(s3Router)
+	s3Router.Use(
 		o.auditMiddleware,
 		o.expectMiddleware,
 		o.traceMiddleware,
@@ -466,10 +804,13 @@ func (o *ObjectNode) startMuxRestAPI() (err error) {
 		o.policyCheckMiddleware,
 		o.contentMiddleware,
 	)
+	return router
+}
 
+func (o *ObjectNode) startMuxRestAPI() (err error) {
 	server := &http.Server{
 		Addr:         ":" + o.listen,
-		Handler:      router,
+		Handler:      o.newRouter(),
 		ReadTimeout:  5 * time.Minute,
 		WriteTimeout: 5 * time.Minute,
 	}
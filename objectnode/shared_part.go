@@ -0,0 +1,181 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// errPartNotShared is returned if the part can't be copied by sharing the extents of the source,
+// and the data should be copied instead.
+var errPartNotShared = errors.New("part not shared")
+
+// sharedPartETag returns the ETag of the part copied from the range [offset, offset+size) of the
+// source by sharing the extents. The data of the part is never read, so the ETag of the source is
+// reused if the whole object with a plain MD5 ETag is copied, otherwise it's derived from the ETag
+// of the source and the range.
+func sharedPartETag(srcETag string, srcSize, offset, size uint64) string {
+	if offset == 0 && size == srcSize && len(srcETag) == md5.Size*2 && !strings.Contains(srcETag, "-") {
+		return srcETag
+	}
+	sum := md5.Sum([]byte(fmt.Sprintf("%s:%d-%d", srcETag, offset, offset+size-1)))
+	return hex.EncodeToString(sum[:])
+}
+
+// SharePart adds the part of the multipart upload which refers to the extents of the source inode
+// in the range [offset, offset+size) of the same volume instead of copying the data.
+//
+// The part inode is created in the meta partition of the source, and the complete object must be
+// created in the same partition to keep the shared extents, so all the shared parts of an upload
+// must be copied from the sources of the same partition. errPartNotShared is returned if the part
+// can't be shared, e.g. the upload is encrypted or has checksums, and the caller should copy the
+// data by WritePart instead.
+func (v *Volume) SharePart(path string, multipartId string, partId uint16, srcInode, offset, size uint64,
+	etag string,
+) (fInfo *FSFileInfo, err error) {
+	var exist bool
+	defer func() {
+		log.LogInfof("Audit: SharePart: volume(%v) path(%v) multipartID(%v) partID(%v) srcInode(%v) offset(%v) size(%v) exist(%v) err(%v)",
+			v.name, path, multipartId, partId, srcInode, offset, size, exist, err)
+	}()
+
+	if !proto.IsHot(v.volType) || proto.IsStorageClassBlobStore(v.mw.GetStorageClass()) || size == 0 {
+		return nil, errPartNotShared
+	}
+	var multipartInfo *proto.MultipartInfo
+	if multipartInfo, err = v.mw.GetMultipart_ll(path, multipartId); err != nil {
+		log.LogErrorf("SharePart: meta get multipart fail: volume(%v) path(%v) multipartID(%v) err(%v)",
+			v.name, path, multipartId, err)
+		return nil, err
+	}
+	// the parts of the encrypted upload have their own IVs, and the checksums of parts are computed
+	// from the data
+	if _, ok := multipartInfo.Extend[XAttrKeyOSSSSE]; ok {
+		return nil, errPartNotShared
+	}
+	if _, ok := multipartInfo.Extend[XAttrKeyOSSChecksum]; ok {
+		return nil, errPartNotShared
+	}
+	srcMP := v.mw.GetPartitionByInodeId_ll(srcInode)
+	if srcMP == nil {
+		return nil, errPartNotShared
+	}
+	var sharedInode uint64
+	if sharedInode, err = v.sharedPartInode(path, multipartId, multipartInfo.Parts, partId); err != nil {
+		return nil, errPartNotShared
+	}
+	if sharedInode != 0 && v.mw.GetPartitionByInodeId_ll(sharedInode) != srcMP {
+		return nil, errPartNotShared
+	}
+
+	var partInodeInfo *proto.InodeInfo
+	if partInodeInfo, err = v.mw.ShareExtents_ll(srcInode, offset, size, DefaultFileMode, 0, 0, path); err != nil {
+		log.LogWarnf("SharePart: meta share extents fail: volume(%v) path(%v) multipartID(%v) partID(%v) srcInode(%v) err(%v)",
+			v.name, path, multipartId, partId, srcInode, err)
+		return nil, errPartNotShared
+	}
+	log.LogDebugf("SharePart: meta share extents: volume(%v) path(%v) multipartID(%v) partID(%v) srcInode(%v) inode(%v)",
+		v.name, path, multipartId, partId, srcInode, partInodeInfo.Inode)
+
+	var oldInode uint64
+	defer func() {
+		if err != nil {
+			log.LogWarnf("SharePart: unlink part inode: volume(%v) path(%v) multipartID(%v) partID(%v) inode(%v)",
+				v.name, path, multipartId, partId, partInodeInfo.Inode)
+			_, _ = v.mw.InodeUnlink_ll(partInodeInfo.Inode, path)
+			log.LogWarnf("SharePart: evict part inode: volume(%v) path(%v) multipartID(%v) partID(%v) inode(%v)",
+				v.name, path, multipartId, partId, partInodeInfo.Inode)
+			_ = v.mw.Evict(partInodeInfo.Inode, path)
+		}
+		if exist {
+			log.LogWarnf("SharePart: unlink old part inode: volume(%v) path(%v) multipartID(%v) partID(%v) inode(%v)",
+				v.name, path, multipartId, partId, oldInode)
+			_, _ = v.mw.InodeUnlink_ll(oldInode, path)
+			log.LogWarnf("SharePart: evict old part inode: volume(%v) path(%v) multipartID(%v) partID(%v) inode(%v)",
+				v.name, path, multipartId, partId, oldInode)
+			_ = v.mw.Evict(oldInode, path)
+		}
+	}()
+
+	attrs := map[string]string{XAttrKeyOSSSharedPart: strconv.FormatUint(srcInode, 10)}
+	if err = v.mw.BatchSetXAttr_ll(partInodeInfo.Inode, attrs); err != nil {
+		log.LogErrorf("SharePart: set part xattrs fail: volume(%v) path(%v) multipartID(%v) partID(%v) inode(%v) err(%v)",
+			v.name, path, multipartId, partId, partInodeInfo.Inode, err)
+		return nil, err
+	}
+	oldInode, exist, err = v.mw.AddMultipartPart_ll(path, multipartId, partId, size, etag, partInodeInfo)
+	if err != nil {
+		log.LogErrorf("SharePart: meta add multipart part fail: volume(%v) path(%v) multipartID(%v) partID(%v) inode(%v) size(%v) MD5(%v) err(%v)",
+			v.name, path, multipartId, partId, partInodeInfo.Inode, size, etag, err)
+		return nil, err
+	}
+
+	_, fileName := splitPath(path)
+	fInfo = &FSFileInfo{
+		Path:       fileName,
+		Size:       int64(size),
+		Mode:       os.FileMode(DefaultFileMode),
+		ModifyTime: time.Now(),
+		CreateTime: partInodeInfo.CreateTime,
+		ETag:       etag,
+		Inode:      partInodeInfo.Inode,
+	}
+	return fInfo, nil
+}
+
+// sharedPartInode returns the inode of any part sharing the extents of its source except the part
+// excluded, or 0 if there is no such part. The complete object is created in the meta partition of
+// the returned inode.
+func (v *Volume) sharedPartInode(path, multipartId string, parts []*proto.MultipartPartInfo, excluded uint16) (uint64, error) {
+	inodes := make([]uint64, 0, len(parts))
+	for _, part := range parts {
+		if part.ID != excluded {
+			inodes = append(inodes, part.Inode)
+		}
+	}
+	if len(inodes) == 0 {
+		return 0, nil
+	}
+	infos, err := v.mw.BatchGetXAttr(inodes, []string{XAttrKeyOSSSharedPart})
+	if err != nil {
+		log.LogErrorf("sharedPartInode: meta get part xattrs fail: volume(%v) path(%v) multipartID(%v) err(%v)",
+			v.name, path, multipartId, err)
+		return 0, err
+	}
+	var sharedInode uint64
+	for _, info := range infos {
+		if _, ok := info.XAttrs[XAttrKeyOSSSharedPart]; !ok {
+			continue
+		}
+		// the parts shared concurrently may be in different partitions
+		if sharedInode != 0 && v.mw.GetPartitionByInodeId_ll(info.Inode) != v.mw.GetPartitionByInodeId_ll(sharedInode) {
+			log.LogErrorf("sharedPartInode: shared parts in different partitions: volume(%v) path(%v) multipartID(%v) inodes(%v,%v)",
+				v.name, path, multipartId, sharedInode, info.Inode)
+			return 0, InvalidPart
+		}
+		sharedInode = info.Inode
+	}
+	return sharedInode, nil
+}
@@ -0,0 +1,39 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSharedPartETag(t *testing.T) {
+	etag := "41f9ede9b03b89d80f3a8460d7792ff6"
+	// the whole object with a plain MD5 ETag keeps the ETag of the source
+	require.Equal(t, etag, sharedPartETag(etag, 1024, 0, 1024))
+
+	// the ETag of a range depends on both the source and the range
+	ranged := sharedPartETag(etag, 1024, 0, 512)
+	require.Len(t, ranged, 32)
+	require.NotEqual(t, etag, ranged)
+	require.NotEqual(t, ranged, sharedPartETag(etag, 1024, 512, 512))
+	require.NotEqual(t, ranged, sharedPartETag("0f9ede9b03b89d80f3a8460d7792ff64", 1024, 0, 512))
+	require.Equal(t, ranged, sharedPartETag(etag, 1024, 0, 512))
+
+	// the ETag of a multipart object is never reused
+	multipart := etag + "-2"
+	require.NotEqual(t, multipart, sharedPartETag(multipart, 1024, 0, 1024))
+}
@@ -0,0 +1,484 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+
+	"github.com/cubefs/cubefs/proto"
+)
+
+const (
+	SSEAlgorithmAES256 = "AES256"
+	SSEAlgorithmKMS    = "aws:kms"
+
+	MaxEncryptionConfigSize = 1 << 10 // 1KB
+
+	sseTypeS3 = "S3" // data key is wrapped by the cluster master key
+	sseTypeC  = "C"  // data key is wrapped by the key provided by customer
+
+	sseKeySize = 32
+)
+
+// sseMasterKey is the cluster master key used by SSE-S3, SSE-S3 is disabled if it is not configured.
+var sseMasterKey []byte
+
+var errSSEOffsetOutOfRange = errors.New("sse: offset out of range")
+
+// ServerSideEncryptionConfiguration is the default encryption of the bucket.
+type ServerSideEncryptionConfiguration struct {
+	XMLNS   string                      `xml:"xmlns,attr,omitempty" json:"-"`
+	XMLName xml.Name                    `xml:"ServerSideEncryptionConfiguration" json:"-"`
+	Rules   []*ServerSideEncryptionRule `xml:"Rule" json:"rules"`
+}
+
+type ServerSideEncryptionRule struct {
+	ApplyServerSideEncryptionByDefault *ServerSideEncryptionByDefault `xml:"ApplyServerSideEncryptionByDefault" json:"default"`
+	BucketKeyEnabled                   bool                           `xml:"BucketKeyEnabled,omitempty" json:"bucket_key,omitempty"`
+}
+
+type ServerSideEncryptionByDefault struct {
+	SSEAlgorithm   string `xml:"SSEAlgorithm" json:"algorithm"`
+	KMSMasterKeyID string `xml:"KMSMasterKeyID,omitempty" json:"kms_key_id,omitempty"`
+}
+
+// parse ServerSideEncryptionConfiguration from xml
+func ParseEncryptionConfigFromXML(data []byte) (*ServerSideEncryptionConfiguration, error) {
+	config := &ServerSideEncryptionConfiguration{}
+	if err := xml.Unmarshal(data, config); err != nil {
+		return nil, MalformedXML
+	}
+	if len(config.Rules) != 1 || config.Rules[0].ApplyServerSideEncryptionByDefault == nil {
+		return nil, MalformedXML
+	}
+	switch config.Rules[0].ApplyServerSideEncryptionByDefault.SSEAlgorithm {
+	case SSEAlgorithmAES256:
+		if config.Rules[0].ApplyServerSideEncryptionByDefault.KMSMasterKeyID != "" {
+			return nil, InvalidArgument
+		}
+		if len(sseMasterKey) == 0 {
+			return nil, SSES3NotEnabled
+		}
+	case SSEAlgorithmKMS:
+		return nil, SSEKMSNotSupported
+	default:
+		return nil, MalformedXML
+	}
+	return config, nil
+}
+
+// defaultSSEOption returns the encryption applied to new objects without encryption headers.
+func (c *ServerSideEncryptionConfiguration) defaultSSEOption() *SSEOption {
+	if c == nil || len(c.Rules) == 0 || c.Rules[0].ApplyServerSideEncryptionByDefault == nil {
+		return nil
+	}
+	if c.Rules[0].ApplyServerSideEncryptionByDefault.SSEAlgorithm != SSEAlgorithmAES256 {
+		return nil
+	}
+	return &SSEOption{Type: sseTypeS3}
+}
+
+func storeBucketEncryption(bytes []byte, vol *Volume) (err error) {
+	return vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSEncryption, bytes)
+}
+
+func deleteBucketEncryption(vol *Volume) (err error) {
+	return vol.store.Delete(vol.name, bucketRootPath, XAttrKeyOSSEncryption)
+}
+
+// SSEOption is the server-side encryption specified by request headers.
+type SSEOption struct {
+	Type           string
+	CustomerKey    []byte
+	CustomerKeyMD5 string
+}
+
+// ParseSSEOption parses the encryption for writing a new object, which is either SSE-S3 or SSE-C.
+func ParseSSEOption(header http.Header) (opt *SSEOption, err error) {
+	if opt, err = ParseSSECustomerOption(header, false); err != nil {
+		return
+	}
+	algorithm := header.Get(XAmzServerSideEncryption)
+	if algorithm == "" {
+		return
+	}
+	if opt != nil {
+		return nil, InvalidSSEHeaders
+	}
+	switch algorithm {
+	case SSEAlgorithmAES256:
+	case SSEAlgorithmKMS:
+		return nil, SSEKMSNotSupported
+	default:
+		return nil, InvalidEncryptionAlgorithm
+	}
+	if len(sseMasterKey) == 0 {
+		return nil, SSES3NotEnabled
+	}
+	return &SSEOption{Type: sseTypeS3}, nil
+}
+
+// ParseSSECustomerOption parses the customer provided key of the object or the copy source object.
+func ParseSSECustomerOption(header http.Header, copySource bool) (*SSEOption, error) {
+	algorithmKey, keyKey, keyMD5Key := XAmzServerSideEncryptionCustomerAlgorithm,
+		XAmzServerSideEncryptionCustomerKey, XAmzServerSideEncryptionCustomerKeyMD5
+	if copySource {
+		algorithmKey, keyKey, keyMD5Key = XAmzCopySourceServerSideEncryptionCustomerAlgorithm,
+			XAmzCopySourceServerSideEncryptionCustomerKey, XAmzCopySourceServerSideEncryptionCustomerKeyMD5
+	}
+	algorithm, encodedKey, keyMD5 := header.Get(algorithmKey), header.Get(keyKey), header.Get(keyMD5Key)
+	if algorithm == "" && encodedKey == "" && keyMD5 == "" {
+		return nil, nil
+	}
+	if algorithm != SSEAlgorithmAES256 {
+		return nil, InvalidEncryptionAlgorithm
+	}
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil || len(key) != sseKeySize {
+		return nil, InvalidSSECustomerKey
+	}
+	sum := md5.Sum(key)
+	computedMD5 := base64.StdEncoding.EncodeToString(sum[:])
+	if keyMD5 != "" && keyMD5 != computedMD5 {
+		return nil, SSECustomerKeyMD5Mismatch
+	}
+	return &SSEOption{Type: sseTypeC, CustomerKey: key, CustomerKeyMD5: computedMD5}, nil
+}
+
+// keyEncryptionKey returns the key used to wrap the data key of the object.
+func (o *SSEOption) keyEncryptionKey() ([]byte, error) {
+	if o.Type == sseTypeC {
+		return o.CustomerKey, nil
+	}
+	if len(sseMasterKey) == 0 {
+		return nil, SSES3NotEnabled
+	}
+	return sseMasterKey, nil
+}
+
+func (o *SSEOption) SetResponseHeader(header http.Header) {
+	if o != nil {
+		setSSEResponseHeader(header, o.Type, o.CustomerKeyMD5)
+	}
+}
+
+func setSSEResponseHeader(header http.Header, sseType, keyMD5 string) {
+	switch sseType {
+	case sseTypeS3:
+		header.Set(XAmzServerSideEncryption, SSEAlgorithmAES256)
+	case sseTypeC:
+		header.Set(XAmzServerSideEncryptionCustomerAlgorithm, SSEAlgorithmAES256)
+		header.Set(XAmzServerSideEncryptionCustomerKeyMD5, keyMD5)
+	}
+}
+
+// sseMeta is the encryption metadata of an object, which is stored in the extend attribute "oss:sse".
+// Object data is encrypted with AES-256-CTR by a random data key, so that the ciphertext has the same
+// size as the plaintext and any range of it can be decrypted independently. The data key is wrapped
+// with AES-256-GCM by the cluster master key (SSE-S3) or the customer provided key (SSE-C).
+// Each part of a multipart object is encrypted with a random IV generated when the part is written,
+// so that the keystream is never reused even if a part is uploaded again. The IVs and sizes of parts
+// are recorded on completion to locate the part of an offset.
+type sseMeta struct {
+	Type      string     `json:"type"`
+	SealedKey []byte     `json:"key"`
+	IV        []byte     `json:"iv"`
+	KeyMD5    string     `json:"kmd5,omitempty"`
+	Parts     []*ssePart `json:"parts,omitempty"`
+}
+
+type ssePart struct {
+	Number uint16 `json:"n"`
+	Size   uint64 `json:"s"`
+	IV     []byte `json:"iv"`
+}
+
+func (p *ssePart) Encode() string {
+	data, _ := json.Marshal(p)
+	return string(data)
+}
+
+func parseSSEPart(raw []byte) (part *ssePart, err error) {
+	if len(raw) == 0 {
+		return
+	}
+	part = &ssePart{}
+	if err = json.Unmarshal(raw, part); err != nil {
+		return nil, err
+	}
+	return
+}
+
+func newSSEIV() ([]byte, error) {
+	iv := make([]byte, aes.BlockSize)
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return nil, err
+	}
+	return iv, nil
+}
+
+func newSSEMeta(opt *SSEOption) (meta *sseMeta, dataKey []byte, err error) {
+	var kek []byte
+	if kek, err = opt.keyEncryptionKey(); err != nil {
+		return
+	}
+	dataKey = make([]byte, sseKeySize)
+	if _, err = io.ReadFull(rand.Reader, dataKey); err != nil {
+		return
+	}
+	var iv []byte
+	if iv, err = newSSEIV(); err != nil {
+		return
+	}
+	meta = &sseMeta{
+		Type:   opt.Type,
+		IV:     iv,
+		KeyMD5: opt.CustomerKeyMD5,
+	}
+	if meta.SealedKey, err = sealDataKey(kek, dataKey); err != nil {
+		return nil, nil, err
+	}
+	return
+}
+
+func parseSSEMeta(raw []byte) (meta *sseMeta, err error) {
+	if len(raw) == 0 {
+		return
+	}
+	meta = &sseMeta{}
+	if err = json.Unmarshal(raw, meta); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// loadSSEMeta returns nil if the object is not encrypted.
+func loadSSEMeta(xattr *proto.XAttrInfo) (*sseMeta, error) {
+	if xattr == nil {
+		return nil, nil
+	}
+	return parseSSEMeta(xattr.Get(XAttrKeyOSSSSE))
+}
+
+func (m *sseMeta) Encode() string {
+	data, _ := json.Marshal(m)
+	return string(data)
+}
+
+func (m *sseMeta) SetResponseHeader(header http.Header) {
+	if m != nil {
+		setSSEResponseHeader(header, m.Type, m.KeyMD5)
+	}
+}
+
+// objectDataKey checks the encryption parameters of the request and returns the data key of the object,
+// nil is returned if the object is not encrypted.
+func objectDataKey(meta *sseMeta, opt *SSEOption) ([]byte, error) {
+	if meta == nil {
+		if opt != nil && opt.Type == sseTypeC {
+			return nil, SSEParametersNotApplicable
+		}
+		return nil, nil
+	}
+	var kek []byte
+	switch meta.Type {
+	case sseTypeS3:
+		if opt != nil && opt.Type == sseTypeC {
+			return nil, SSEParametersNotApplicable
+		}
+		if len(sseMasterKey) == 0 {
+			return nil, SSES3NotEnabled
+		}
+		kek = sseMasterKey
+	case sseTypeC:
+		if opt == nil || opt.Type != sseTypeC {
+			return nil, SSECustomerKeyRequired
+		}
+		if opt.CustomerKeyMD5 != meta.KeyMD5 {
+			return nil, AccessDenied
+		}
+		kek = opt.CustomerKey
+	default:
+		return nil, InternalErrorCode(errors.New("sse: unknown encryption type " + meta.Type))
+	}
+	dataKey, err := unsealDataKey(kek, meta.SealedKey)
+	if err != nil {
+		return nil, AccessDenied
+	}
+	return dataKey, nil
+}
+
+// encryptReader encrypts the data from the beginning with the IV, which is the IV of the meta for
+// the whole object, or a new IV for the part of a multipart object.
+func encryptReader(r io.Reader, dataKey, iv []byte) (io.Reader, error) {
+	stream, err := newSSEStream(dataKey, iv, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &sseReader{r: r, stream: stream}, nil
+}
+
+// decryptWriter decrypts the data starting from the offset of the object before writing it to w.
+func (m *sseMeta) decryptWriter(w io.Writer, dataKey []byte, offset uint64) io.Writer {
+	return &sseWriter{w: w, cipher: m.objectCipher(dataKey, offset)}
+}
+
+func (m *sseMeta) objectCipher(dataKey []byte, offset uint64) *sseObjectCipher {
+	return &sseObjectCipher{meta: m, dataKey: dataKey, offset: offset}
+}
+
+func sealDataKey(kek, dataKey []byte) ([]byte, error) {
+	aead, err := newKeyAEAD(kek)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, dataKey, nil), nil
+}
+
+func unsealDataKey(kek, sealed []byte) ([]byte, error) {
+	aead, err := newKeyAEAD(kek)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("sse: invalid sealed key")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, nil)
+}
+
+func newKeyAEAD(kek []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// newSSEStream returns the CTR stream positioned at the offset.
+func newSSEStream(dataKey, iv []byte, offset uint64) (cipher.Stream, error) {
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	counter := make([]byte, aes.BlockSize)
+	copy(counter, iv)
+	addCounter(counter, offset/aes.BlockSize)
+	stream := cipher.NewCTR(block, counter)
+	if skip := offset % aes.BlockSize; skip > 0 {
+		pad := make([]byte, skip)
+		stream.XORKeyStream(pad, pad)
+	}
+	return stream, nil
+}
+
+// addCounter adds n to the big-endian counter.
+func addCounter(counter []byte, n uint64) {
+	for i := len(counter) - 1; i >= 0 && n > 0; i-- {
+		sum := uint64(counter[i]) + n&0xff
+		counter[i] = byte(sum)
+		n = n>>8 + sum>>8
+	}
+}
+
+// sseObjectCipher encrypts or decrypts the object data sequentially from an offset,
+// it switches the CTR stream at the boundaries of parts.
+type sseObjectCipher struct {
+	meta       *sseMeta
+	dataKey    []byte
+	offset     uint64
+	stream     cipher.Stream
+	segmentEnd uint64
+}
+
+func (c *sseObjectCipher) XORKeyStream(dst, src []byte) (err error) {
+	for len(src) > 0 {
+		if c.stream == nil || c.offset >= c.segmentEnd {
+			if err = c.seek(); err != nil {
+				return
+			}
+		}
+		n := len(src)
+		if rest := c.segmentEnd - c.offset; uint64(n) > rest {
+			n = int(rest)
+		}
+		c.stream.XORKeyStream(dst[:n], src[:n])
+		dst, src = dst[n:], src[n:]
+		c.offset += uint64(n)
+	}
+	return
+}
+
+func (c *sseObjectCipher) seek() (err error) {
+	if len(c.meta.Parts) == 0 {
+		c.segmentEnd = math.MaxUint64
+		c.stream, err = newSSEStream(c.dataKey, c.meta.IV, c.offset)
+		return
+	}
+	var start uint64
+	for _, part := range c.meta.Parts {
+		if c.offset < start+part.Size {
+			c.segmentEnd = start + part.Size
+			c.stream, err = newSSEStream(c.dataKey, part.IV, c.offset-start)
+			return
+		}
+		start += part.Size
+	}
+	return errSSEOffsetOutOfRange
+}
+
+type sseReader struct {
+	r      io.Reader
+	stream cipher.Stream
+}
+
+func (r *sseReader) Read(p []byte) (n int, err error) {
+	n, err = r.r.Read(p)
+	if n > 0 {
+		r.stream.XORKeyStream(p[:n], p[:n])
+	}
+	return
+}
+
+type sseWriter struct {
+	w      io.Writer
+	cipher *sseObjectCipher
+	buf    []byte
+}
+
+func (w *sseWriter) Write(p []byte) (n int, err error) {
+	if cap(w.buf) < len(p) {
+		w.buf = make([]byte, len(p))
+	}
+	buf := w.buf[:len(p)]
+	if err = w.cipher.XORKeyStream(buf, p); err != nil {
+		return
+	}
+	return w.w.Write(buf)
+}
@@ -0,0 +1,193 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// Get bucket encryption
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketEncryption.html
+func (o *ObjectNode) getBucketEncryptionHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("getBucketEncryptionHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var config *ServerSideEncryptionConfiguration
+	if config, err = vol.metaLoader.loadEncryption(); err != nil {
+		log.LogErrorf("getBucketEncryptionHandler: load encryption fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if config == nil || len(config.Rules) == 0 {
+		errorCode = NoSuchEncryptionConfiguration
+		return
+	}
+	config.XMLNS = XMLNS
+
+	var data []byte
+	if data, err = MarshalXMLEntity(config); err != nil {
+		log.LogErrorf("getBucketEncryptionHandler: xml marshal fail: requestID(%v) volume(%v) config(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), config, err)
+		return
+	}
+
+	writeSuccessResponseXML(w, data)
+}
+
+// Put bucket encryption
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketEncryption.html
+func (o *ObjectNode) putBucketEncryptionHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("putBucketEncryptionHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var body []byte
+	if body, err = io.ReadAll(io.LimitReader(r.Body, MaxEncryptionConfigSize+1)); err != nil {
+		log.LogErrorf("putBucketEncryptionHandler: read request body fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if len(body) > MaxEncryptionConfigSize {
+		errorCode = EntityTooLarge
+		return
+	}
+
+	var config *ServerSideEncryptionConfiguration
+	if config, err = ParseEncryptionConfigFromXML(body); err != nil {
+		log.LogErrorf("putBucketEncryptionHandler: parse encryption config fail: requestID(%v) volume(%v) config(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), err)
+		return
+	}
+
+	if body, err = json.Marshal(config); err != nil {
+		log.LogErrorf("putBucketEncryptionHandler: json marshal encryption config fail: requestID(%v) volume(%v) config(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), config, err)
+		return
+	}
+	if err = storeBucketEncryption(body, vol); err != nil {
+		log.LogErrorf("putBucketEncryptionHandler: store encryption config fail: requestID(%v) volume(%v) config(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), err)
+		return
+	}
+	vol.metaLoader.storeEncryption(config)
+
+	log.LogInfof("Audit: put bucket encryption: requestID(%v) volume(%v) config(%v)",
+		GetRequestID(r), vol.Name(), string(body))
+}
+
+// Delete bucket encryption
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketEncryption.html
+func (o *ObjectNode) deleteBucketEncryptionHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("deleteBucketEncryptionHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	if err = deleteBucketEncryption(vol); err != nil {
+		log.LogErrorf("deleteBucketEncryptionHandler: delete encryption config fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	vol.metaLoader.storeEncryption(nil)
+
+	log.LogInfof("Audit: delete bucket encryption: requestID(%v) volume(%v)", GetRequestID(r), vol.Name())
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseWriteSSEOption parses the encryption of the object to be written, the default encryption
+// of the bucket is applied if the request does not specify one.
+func parseWriteSSEOption(header http.Header, vol *Volume) (opt *SSEOption, err error) {
+	if opt, err = ParseSSEOption(header); err != nil || opt != nil {
+		return
+	}
+	var config *ServerSideEncryptionConfiguration
+	if config, err = vol.metaLoader.loadEncryption(); err != nil {
+		return
+	}
+	return config.defaultSSEOption(), nil
+}
+
+// checkObjectSSE checks the encryption parameters of reading the object and sets the encryption headers
+// of the response, nil meta is returned if the object is not encrypted.
+func checkObjectSSE(w http.ResponseWriter, r *http.Request, xattr *proto.XAttrInfo) (meta *sseMeta, dataKey []byte, err error) {
+	var opt *SSEOption
+	if opt, err = ParseSSECustomerOption(r.Header, false); err != nil {
+		return
+	}
+	if meta, err = loadSSEMeta(xattr); err != nil {
+		return
+	}
+	if dataKey, err = objectDataKey(meta, opt); err != nil {
+		return nil, nil, err
+	}
+	meta.SetResponseHeader(w.Header())
+	return
+}
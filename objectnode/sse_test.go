@@ -0,0 +1,262 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func withSSEMasterKey(t *testing.T) {
+	key := make([]byte, sseKeySize)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	sseMasterKey = key
+	t.Cleanup(func() { sseMasterKey = nil })
+}
+
+func newCustomerKeyHeader(t *testing.T, copySource bool) (http.Header, []byte) {
+	key := make([]byte, sseKeySize)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	sum := md5.Sum(key)
+	header := make(http.Header)
+	if copySource {
+		header.Set(XAmzCopySourceServerSideEncryptionCustomerAlgorithm, SSEAlgorithmAES256)
+		header.Set(XAmzCopySourceServerSideEncryptionCustomerKey, base64.StdEncoding.EncodeToString(key))
+		header.Set(XAmzCopySourceServerSideEncryptionCustomerKeyMD5, base64.StdEncoding.EncodeToString(sum[:]))
+	} else {
+		header.Set(XAmzServerSideEncryptionCustomerAlgorithm, SSEAlgorithmAES256)
+		header.Set(XAmzServerSideEncryptionCustomerKey, base64.StdEncoding.EncodeToString(key))
+		header.Set(XAmzServerSideEncryptionCustomerKeyMD5, base64.StdEncoding.EncodeToString(sum[:]))
+	}
+	return header, key
+}
+
+func TestParseSSEOption(t *testing.T) {
+	header := make(http.Header)
+	opt, err := ParseSSEOption(header)
+	require.NoError(t, err)
+	require.Nil(t, opt)
+
+	header.Set(XAmzServerSideEncryption, SSEAlgorithmAES256)
+	_, err = ParseSSEOption(header)
+	require.Equal(t, SSES3NotEnabled, err)
+
+	withSSEMasterKey(t)
+	opt, err = ParseSSEOption(header)
+	require.NoError(t, err)
+	require.Equal(t, sseTypeS3, opt.Type)
+
+	header.Set(XAmzServerSideEncryption, SSEAlgorithmKMS)
+	_, err = ParseSSEOption(header)
+	require.Equal(t, SSEKMSNotSupported, err)
+
+	header, key := newCustomerKeyHeader(t, false)
+	opt, err = ParseSSEOption(header)
+	require.NoError(t, err)
+	require.Equal(t, sseTypeC, opt.Type)
+	require.Equal(t, key, opt.CustomerKey)
+
+	header.Set(XAmzServerSideEncryption, SSEAlgorithmAES256)
+	_, err = ParseSSEOption(header)
+	require.Equal(t, InvalidSSEHeaders, err)
+
+	header, _ = newCustomerKeyHeader(t, false)
+	header.Set(XAmzServerSideEncryptionCustomerKeyMD5, base64.StdEncoding.EncodeToString([]byte("mismatch")))
+	_, err = ParseSSEOption(header)
+	require.Equal(t, SSECustomerKeyMD5Mismatch, err)
+
+	header, _ = newCustomerKeyHeader(t, false)
+	header.Set(XAmzServerSideEncryptionCustomerKey, base64.StdEncoding.EncodeToString([]byte("short")))
+	_, err = ParseSSEOption(header)
+	require.Equal(t, InvalidSSECustomerKey, err)
+
+	header, _ = newCustomerKeyHeader(t, true)
+	opt, err = ParseSSECustomerOption(header, false)
+	require.NoError(t, err)
+	require.Nil(t, opt)
+	opt, err = ParseSSECustomerOption(header, true)
+	require.NoError(t, err)
+	require.Equal(t, sseTypeC, opt.Type)
+}
+
+func TestSSEObjectDataKey(t *testing.T) {
+	withSSEMasterKey(t)
+
+	meta, dataKey, err := newSSEMeta(&SSEOption{Type: sseTypeS3})
+	require.NoError(t, err)
+	restored, err := parseSSEMeta([]byte(meta.Encode()))
+	require.NoError(t, err)
+	key, err := objectDataKey(restored, nil)
+	require.NoError(t, err)
+	require.Equal(t, dataKey, key)
+
+	header, _ := newCustomerKeyHeader(t, false)
+	customer, err := ParseSSECustomerOption(header, false)
+	require.NoError(t, err)
+	_, err = objectDataKey(restored, customer)
+	require.Equal(t, SSEParametersNotApplicable, err)
+	_, err = objectDataKey(nil, customer)
+	require.Equal(t, SSEParametersNotApplicable, err)
+
+	meta, dataKey, err = newSSEMeta(customer)
+	require.NoError(t, err)
+	_, err = objectDataKey(meta, nil)
+	require.Equal(t, SSECustomerKeyRequired, err)
+	key, err = objectDataKey(meta, customer)
+	require.NoError(t, err)
+	require.Equal(t, dataKey, key)
+
+	header, _ = newCustomerKeyHeader(t, false)
+	other, err := ParseSSECustomerOption(header, false)
+	require.NoError(t, err)
+	_, err = objectDataKey(meta, other)
+	require.Equal(t, AccessDenied, err)
+}
+
+func TestSSEEncryptDecryptRange(t *testing.T) {
+	withSSEMasterKey(t)
+	meta, dataKey, err := newSSEMeta(&SSEOption{Type: sseTypeS3})
+	require.NoError(t, err)
+
+	plain := make([]byte, 4099)
+	_, err = rand.Read(plain)
+	require.NoError(t, err)
+	reader, err := encryptReader(bytes.NewReader(plain), dataKey, meta.IV)
+	require.NoError(t, err)
+	encrypted, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.Equal(t, len(plain), len(encrypted))
+	require.NotEqual(t, plain, encrypted)
+
+	for _, rg := range [][2]int{{0, len(plain)}, {1, 17}, {15, 33}, {16, 4096}, {4000, len(plain)}} {
+		buf := new(bytes.Buffer)
+		writer := meta.decryptWriter(buf, dataKey, uint64(rg[0]))
+		// write with small chunks to cross block boundaries
+		for off := rg[0]; off < rg[1]; off += 7 {
+			end := off + 7
+			if end > rg[1] {
+				end = rg[1]
+			}
+			_, err = writer.Write(encrypted[off:end])
+			require.NoError(t, err)
+		}
+		require.Equal(t, plain[rg[0]:rg[1]], buf.Bytes())
+	}
+}
+
+func TestSSEMultipartDecrypt(t *testing.T) {
+	withSSEMasterKey(t)
+	meta, dataKey, err := newSSEMeta(&SSEOption{Type: sseTypeS3})
+	require.NoError(t, err)
+
+	var (
+		plain     []byte
+		encrypted []byte
+	)
+	for i, size := range []int{100, 37, 260} {
+		part := make([]byte, size)
+		_, err = rand.Read(part)
+		require.NoError(t, err)
+		iv, err := newSSEIV()
+		require.NoError(t, err)
+		reader, err := encryptReader(bytes.NewReader(part), dataKey, iv)
+		require.NoError(t, err)
+		data, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		plain = append(plain, part...)
+		encrypted = append(encrypted, data...)
+		meta.Parts = append(meta.Parts, &ssePart{Number: uint16(i + 1), Size: uint64(size), IV: iv})
+	}
+
+	for _, offset := range []int{0, 50, 99, 100, 136, 137, 300} {
+		buf := new(bytes.Buffer)
+		_, err = meta.decryptWriter(buf, dataKey, uint64(offset)).Write(encrypted[offset:])
+		require.NoError(t, err)
+		require.Equal(t, plain[offset:], buf.Bytes())
+	}
+
+	_, err = meta.decryptWriter(new(bytes.Buffer), dataKey, uint64(len(plain))).Write([]byte{0})
+	require.Equal(t, errSSEOffsetOutOfRange, err)
+}
+
+func TestParseEncryptionConfig(t *testing.T) {
+	config := `<ServerSideEncryptionConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+		<Rule><ApplyServerSideEncryptionByDefault><SSEAlgorithm>AES256</SSEAlgorithm></ApplyServerSideEncryptionByDefault></Rule>
+	</ServerSideEncryptionConfiguration>`
+	_, err := ParseEncryptionConfigFromXML([]byte(config))
+	require.Equal(t, SSES3NotEnabled, err)
+
+	withSSEMasterKey(t)
+	c, err := ParseEncryptionConfigFromXML([]byte(config))
+	require.NoError(t, err)
+	require.Equal(t, sseTypeS3, c.defaultSSEOption().Type)
+
+	_, err = ParseEncryptionConfigFromXML([]byte(`<ServerSideEncryptionConfiguration>
+		<Rule><ApplyServerSideEncryptionByDefault><SSEAlgorithm>aws:kms</SSEAlgorithm></ApplyServerSideEncryptionByDefault></Rule>
+	</ServerSideEncryptionConfiguration>`))
+	require.Equal(t, SSEKMSNotSupported, err)
+
+	_, err = ParseEncryptionConfigFromXML([]byte(`<ServerSideEncryptionConfiguration></ServerSideEncryptionConfiguration>`))
+	require.Equal(t, MalformedXML, err)
+
+	var empty *ServerSideEncryptionConfiguration
+	require.Nil(t, empty.defaultSSEOption())
+}
+
+func TestAddCounter(t *testing.T) {
+	counter := []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xff, 0xff}
+	addCounter(counter, 1)
+	require.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0}, counter)
+	addCounter(counter, 0x1ff)
+	require.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 0xff}, counter)
+}
+
+func TestSSEPartRewrite(t *testing.T) {
+	withSSEMasterKey(t)
+	_, dataKey, err := newSSEMeta(&SSEOption{Type: sseTypeS3})
+	require.NoError(t, err)
+
+	// the same part uploaded twice must not be encrypted with the same keystream
+	plain := make([]byte, 64)
+	encrypted := make([][]byte, 0, 2)
+	for i := 0; i < 2; i++ {
+		iv, err := newSSEIV()
+		require.NoError(t, err)
+		part := &ssePart{Number: 1, IV: iv}
+		parsed, err := parseSSEPart([]byte(part.Encode()))
+		require.NoError(t, err)
+		require.Equal(t, part, parsed)
+
+		reader, err := encryptReader(bytes.NewReader(plain), dataKey, parsed.IV)
+		require.NoError(t, err)
+		data, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		encrypted = append(encrypted, data)
+	}
+	require.NotEqual(t, encrypted[0], encrypted[1])
+
+	part, err := parseSSEPart(nil)
+	require.NoError(t, err)
+	require.Nil(t, part)
+}
@@ -65,25 +65,51 @@ type FederatedCredentials struct {
 }
 
 func EncodeFedSessionToken(ownerAk, ownerSk, fedAk, fedSk, name, policy, expireUnix string) (token string, err error) {
-	encoding, err := NewStsEncoding(fedAk, ownerSk)
-	if err != nil {
-		return
-	}
-	toEncrypt := strings.Join([]string{
+	return encodeSessionToken(ownerAk, ownerSk, fedAk, []string{
+		fedAk,
+		fedSk,
+		name,
+		policy,
+		expireUnix,
+	})
+}
+
+// EncodeWebIdentitySessionToken encodes the session token of the web identity, the permissions of which
+// are limited by both the policy of the identity and the session policy of the request if not empty.
+func EncodeWebIdentitySessionToken(ownerAk, ownerSk, fedAk, fedSk, name, policy, sessionPolicy, expireUnix string) (token string, err error) {
+	return encodeSessionToken(ownerAk, ownerSk, fedAk, []string{
 		fedAk,
 		fedSk,
 		name,
 		policy,
 		expireUnix,
-	}, stsSep)
+		sessionPolicy,
+	})
+}
+
+func encodeSessionToken(ownerAk, ownerSk, fedAk string, fields []string) (token string, err error) {
+	encoding, err := NewStsEncoding(fedAk, ownerSk)
+	if err != nil {
+		return
+	}
+	toEncrypt := strings.Join(fields, stsSep)
 	token = base64.URLEncoding.EncodeToString([]byte(ownerAk + stsSep + encoding.Encrypt([]byte(toEncrypt))))
 	return
 }
 
 type FedDecodeResult struct {
-	FedSK    string
-	Policy   *PolicyV2
-	UserInfo *proto.UserInfo
+	FedSK         string
+	Policy        *PolicyV2
+	SessionPolicy *PolicyV2
+	UserInfo      *proto.UserInfo
+}
+
+// IsAllow checks the action against the policy, and the session policy if present.
+func (r *FedDecodeResult) IsAllow(action, bucket, key string) bool {
+	if !r.Policy.IsAllow(action, bucket, key) {
+		return false
+	}
+	return r.SessionPolicy == nil || r.SessionPolicy.IsAllow(action, bucket, key)
 }
 
 func DecodeFedSessionToken(fedAk, session string, getUserInfo func(ak string) (*proto.UserInfo, error)) (*FedDecodeResult, error) {
@@ -113,8 +139,9 @@ func DecodeFedSessionToken(fedAk, session string, getUserInfo func(ak string) (*
 		return nil, InvalidToken
 	}
 
+	// the session token of the web identity has the additional session policy
 	parts := strings.Split(string(decryptInfo), stsSep)
-	if len(parts) != 5 {
+	if len(parts) != 5 && len(parts) != 6 {
 		return nil, InvalidToken
 	}
 	fedAk1, fedSk, policyStr, expireUnixStr := parts[0], parts[1], parts[3], parts[4]
@@ -135,7 +162,14 @@ func DecodeFedSessionToken(fedAk, session string, getUserInfo func(ak string) (*
 		return nil, InvalidToken
 	}
 
-	return &FedDecodeResult{UserInfo: userInfo, FedSK: fedSk, Policy: &policy}, nil
+	result := &FedDecodeResult{UserInfo: userInfo, FedSK: fedSk, Policy: &policy}
+	if len(parts) == 6 && parts[5] != "" {
+		result.SessionPolicy = new(PolicyV2)
+		if err = json.Unmarshal([]byte(parts[5]), result.SessionPolicy); err != nil {
+			return nil, InvalidToken
+		}
+	}
+	return result, nil
 }
 
 func NewStsEncoding(block, key string) (*StsEncoding, error) {
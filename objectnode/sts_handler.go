@@ -15,16 +15,47 @@
 package objectnode
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/util"
 	"github.com/cubefs/cubefs/util/log"
 )
 
+const maxSTSFormSize = 1 << 16
+
+var regexRoleSessionName = regexp.MustCompile(`^[\w+=,.@-]{2,64}$`)
+
+// stsRequestAction returns the action of the STS request in the query or the form body, the body
+// is kept for the signature verification and the handler.
+func stsRequestAction(r *http.Request) string {
+	if action := r.URL.Query().Get(stsActionKey); action != "" {
+		return action
+	}
+	if r.Body == nil || !strings.HasPrefix(r.Header.Get(ContentType), "application/x-www-form-urlencoded") {
+		return ""
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxSTSFormSize))
+	r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(data), r.Body))
+	if err != nil {
+		return ""
+	}
+	values, err := url.ParseQuery(string(data))
+	if err != nil {
+		return ""
+	}
+	return values.Get(stsActionKey)
+}
+
 // https://docs.aws.amazon.com/zh_cn/STS/latest/APIReference/API_GetFederationToken.html
 func (o *ObjectNode) getFederationTokenHandler(w http.ResponseWriter, r *http.Request) {
 	var (
@@ -112,3 +143,140 @@ func (o *ObjectNode) getFederationTokenHandler(w http.ResponseWriter, r *http.Re
 
 	writeSuccessResponseXML(w, response)
 }
+
+// https://docs.aws.amazon.com/STS/latest/APIReference/API_AssumeRoleWithWebIdentity.html
+func (o *ObjectNode) assumeRoleWithWebIdentityHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err error
+		erc *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, erc)
+	}()
+	if o.webIdentity == nil {
+		erc = WebIdentityNotEnabled
+		return
+	}
+	// request param check
+	if token := r.Header.Get(XAmzSecurityToken); token != "" {
+		erc = AccessDeniedBySTS
+		return
+	}
+	roleArn := r.FormValue(stsRoleArnKey)
+	sessionName := r.FormValue(stsRoleSessionNameKey)
+	if !regexRoleSessionName.MatchString(sessionName) {
+		log.LogErrorf("assumeRoleWithWebIdentityHandler: session name invalid: requestID(%v) name(%v)",
+			GetRequestID(r), sessionName)
+		erc = InvalidArgument
+		return
+	}
+	sessionPolicy := r.FormValue(stsPolicyKey)
+	if sessionPolicy != "" {
+		if _, err = ParsePolicyV2Config(sessionPolicy); err != nil {
+			log.LogErrorf("assumeRoleWithWebIdentityHandler: session policy invalid: requestID(%v) policy(%v) err(%v)",
+				GetRequestID(r), sessionPolicy, err)
+			erc = &ErrorCode{
+				ErrorCode:    "MalformedPolicyDocument",
+				ErrorMessage: fmt.Sprintf("The policy document was malformed: %v.", err.Error()),
+				StatusCode:   http.StatusBadRequest,
+			}
+			return
+		}
+	}
+	durationSeconds, err := o.webIdentity.Duration(r.FormValue(stsDurationSecondsKey))
+	if err != nil {
+		log.LogErrorf("assumeRoleWithWebIdentityHandler: duration invalid: requestID(%v) duration(%v)",
+			GetRequestID(r), r.FormValue(stsDurationSecondsKey))
+		return
+	}
+
+	// verify the web identity and map it to the user
+	var identity *WebIdentity
+	if identity, err = o.webIdentity.Verify(r.FormValue(stsWebIdentityTokenKey)); err != nil {
+		log.LogErrorf("assumeRoleWithWebIdentityHandler: verify token fail: requestID(%v) err(%v)",
+			GetRequestID(r), err)
+		return
+	}
+	var (
+		uid    string
+		policy *PolicyV2
+	)
+	if uid, policy, err = o.webIdentity.MapIdentity(roleArn, identity); err != nil {
+		log.LogErrorf("assumeRoleWithWebIdentityHandler: no user mapped: requestID(%v) roleArn(%v) subject(%v)",
+			GetRequestID(r), roleArn, identity.Subject)
+		return
+	}
+	var user *proto.UserInfo
+	if user, err = o.mc.UserAPI().GetUserInfo(uid); err != nil {
+		log.LogErrorf("assumeRoleWithWebIdentityHandler: get user info fail: requestID(%v) uid(%v) err(%v)",
+			GetRequestID(r), uid, err)
+		if err == proto.ErrUserNotExists {
+			err = AccessDenied
+		}
+		return
+	}
+	// the root user is not allowed to be assumed by the web identity
+	if user.UserType == proto.UserTypeRoot {
+		log.LogErrorf("assumeRoleWithWebIdentityHandler: root user mapped: requestID(%v) uid(%v) subject(%v)",
+			GetRequestID(r), uid, identity.Subject)
+		erc = AccessDenied
+		return
+	}
+	// the credentials have all the permissions of the user if no policy is mapped
+	policyStr := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"*","Resource":"*"}]}`
+	if policy != nil {
+		var data []byte
+		if data, err = json.Marshal(policy); err != nil {
+			log.LogErrorf("assumeRoleWithWebIdentityHandler: json marshal policy fail: requestID(%v) policy(%+v) err(%v)",
+				GetRequestID(r), policy, err)
+			return
+		}
+		policyStr = string(data)
+	}
+
+	// federated ak/sk generation
+	now := time.Now().UTC()
+	expireUnixStr := fmt.Sprint(now.Unix() + durationSeconds)
+	fedAk := stsAkPrefix + util.RandomString(13, util.Numeric|util.LowerLetter|util.UpperLetter)
+	fedSk := util.RandomString(32, util.Numeric|util.LowerLetter|util.UpperLetter)
+	sessionToken, err := EncodeWebIdentitySessionToken(user.AccessKey, user.SecretKey, fedAk, fedSk, sessionName,
+		policyStr, sessionPolicy, expireUnixStr)
+	if err != nil {
+		log.LogErrorf("assumeRoleWithWebIdentityHandler: encode session token fail: requestID(%v) err(%v)",
+			GetRequestID(r), err)
+		return
+	}
+	roleName := roleArn[strings.LastIndex(roleArn, "/")+1:]
+	if roleName == "" {
+		roleName = "web-identity"
+	}
+	// response result return
+	result := AssumeRoleWithWebIdentityResponse{
+		AssumeRoleWithWebIdentityResult: &AssumeRoleWithWebIdentityResult{
+			SubjectFromWebIdentityToken: identity.Subject,
+			Audience:                    identity.Audience,
+			Provider:                    o.webIdentity.conf.Issuer,
+			AssumedRoleUser: &AssumedRoleUser{
+				Arn:           fmt.Sprintf("arn:aws:sts::%s:assumed-role/%s/%s", user.UserID, roleName, sessionName),
+				AssumedRoleId: fmt.Sprintf("%s:%s", user.UserID, sessionName),
+			},
+			Credentials: &FederatedCredentials{
+				AccessKeyId:     fedAk,
+				SecretAccessKey: fedSk,
+				SessionToken:    sessionToken,
+				Expiration:      now.Add(time.Duration(durationSeconds) * time.Second).Format(time.RFC3339),
+			},
+		},
+	}
+	result.ResponseMetadata.RequestID = GetRequestID(r)
+	response, err := MarshalXMLEntity(&result)
+	if err != nil {
+		log.LogErrorf("assumeRoleWithWebIdentityHandler: xml marshal result fail: requestID(%v) err(%v)",
+			GetRequestID(r), err)
+		return
+	}
+
+	log.LogInfof("Audit: assume role with web identity: requestID(%v) remote(%v) subject(%v) user(%v) session(%v)",
+		GetRequestID(r), getRequestIP(r), identity.Subject, user.UserID, sessionName)
+	writeSuccessResponseXML(w, response)
+}
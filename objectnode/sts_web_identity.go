@@ -0,0 +1,485 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.aws.amazon.com/STS/latest/APIReference/API_AssumeRoleWithWebIdentity.html
+// https://openid.net/specs/openid-connect-core-1_0.html#IDTokenValidation
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	stsAssumeRoleWithWebIdentity = "AssumeRoleWithWebIdentity"
+	stsRoleArnKey                = "RoleArn"
+	stsRoleSessionNameKey        = "RoleSessionName"
+	stsWebIdentityTokenKey       = "WebIdentityToken"
+
+	minWebIdentityDurationSec        = 900
+	defaultWebIdentityDurationSec    = 3600
+	defaultWebIdentityMaxDuration    = 43200
+	defaultJWKSRefreshIntervalSec    = 3600
+	defaultJWKSMinRefreshIntervalSec = 60
+	defaultIDPRequestTimeoutSec      = 10
+	webIdentityClockSkew             = time.Minute
+	maxJWKSResponseSize              = 1 << 20
+	oidcDiscoveryPath                = "/.well-known/openid-configuration"
+)
+
+// WebIdentityConfig is the configuration of the AssumeRoleWithWebIdentity, the tokens issued by the
+// OIDC issuer for the client ids are accepted. The keys to verify the tokens are fetched from the
+// jwks_url, or from the discovery document of the issuer if not specified.
+//
+// The identity in the token is mapped to a CubeFS user by the first matching rule, or by the value of
+// user_claim if no rule matches. The policy of the matching rule limits the permissions of the issued
+// credentials, and the session policy in the request limits them further.
+type WebIdentityConfig struct {
+	Issuer                 string             `json:"issuer"`
+	JWKSURL                string             `json:"jwks_url"`
+	ClientIDs              []string           `json:"client_ids"`
+	UserClaim              string             `json:"user_claim"`
+	Rules                  []*WebIdentityRule `json:"rules"`
+	MaxDurationSec         int64              `json:"max_duration_sec"`
+	JWKSRefreshIntervalSec int64              `json:"jwks_refresh_interval_sec"`
+}
+
+// WebIdentityRule maps the tokens with the claim equal to the value to the user. The claim of array
+// type matches if any of the elements is equal to the value, e.g. the "groups" claim. The rule only
+// applies to the requests with the role arn if specified.
+type WebIdentityRule struct {
+	RoleArn string    `json:"role_arn"`
+	Claim   string    `json:"claim"`
+	Value   string    `json:"value"`
+	User    string    `json:"user"`
+	Policy  *PolicyV2 `json:"policy"`
+}
+
+// FixConfig validates and fixes the configuration.
+func (c *WebIdentityConfig) FixConfig() error {
+	c.Issuer = strings.TrimSuffix(c.Issuer, "/")
+	if c.Issuer == "" {
+		return errors.New("issuer is required")
+	}
+	if len(c.ClientIDs) == 0 {
+		return errors.New("client_ids is required")
+	}
+	if c.UserClaim == "" && len(c.Rules) == 0 {
+		return errors.New("either user_claim or rules is required")
+	}
+	for _, rule := range c.Rules {
+		if rule.Claim == "" || rule.User == "" {
+			return errors.New("claim and user of rule are required")
+		}
+		if rule.Policy != nil {
+			if err := rule.Policy.Validate(); err != nil {
+				return fmt.Errorf("invalid policy of rule: %v", err)
+			}
+		}
+	}
+	if c.MaxDurationSec <= 0 {
+		c.MaxDurationSec = defaultWebIdentityMaxDuration
+	}
+	if c.MaxDurationSec < minWebIdentityDurationSec || c.MaxDurationSec > defaultWebIdentityMaxDuration {
+		return fmt.Errorf("max_duration_sec must be between %d and %d", minWebIdentityDurationSec, defaultWebIdentityMaxDuration)
+	}
+	if c.JWKSRefreshIntervalSec <= 0 {
+		c.JWKSRefreshIntervalSec = defaultJWKSRefreshIntervalSec
+	}
+	return nil
+}
+
+type AssumeRoleWithWebIdentityResponse struct {
+	XMLName                         *xml.Name                        `xml:"AssumeRoleWithWebIdentityResponse"`
+	AssumeRoleWithWebIdentityResult *AssumeRoleWithWebIdentityResult `xml:"AssumeRoleWithWebIdentityResult"`
+	ResponseMetadata                struct {
+		RequestID string `xml:"RequestId,omitempty"`
+	} `xml:"ResponseMetadata,omitempty"`
+}
+
+type AssumeRoleWithWebIdentityResult struct {
+	SubjectFromWebIdentityToken string                `xml:"SubjectFromWebIdentityToken"`
+	Audience                    string                `xml:"Audience"`
+	AssumedRoleUser             *AssumedRoleUser      `xml:"AssumedRoleUser"`
+	Credentials                 *FederatedCredentials `xml:"Credentials"`
+	Provider                    string                `xml:"Provider"`
+}
+
+type AssumedRoleUser struct {
+	Arn           string `xml:"Arn"`
+	AssumedRoleId string `xml:"AssumedRoleId"`
+}
+
+// WebIdentity is the identity in the verified token.
+type WebIdentity struct {
+	Subject  string
+	Audience string
+	Claims   map[string]interface{}
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+type webIdentityKey struct {
+	kid string
+	key crypto.PublicKey
+}
+
+// WebIdentityVerifier verifies the tokens issued by the OIDC issuer, the keys of the issuer are
+// cached and refreshed periodically, or when the key of the token is not found.
+type WebIdentityVerifier struct {
+	conf   WebIdentityConfig
+	client *http.Client
+
+	mu        sync.Mutex
+	keys      []webIdentityKey
+	fetchedAt time.Time
+}
+
+func NewWebIdentityVerifier(conf WebIdentityConfig) (*WebIdentityVerifier, error) {
+	if err := conf.FixConfig(); err != nil {
+		return nil, err
+	}
+	return &WebIdentityVerifier{
+		conf:   conf,
+		client: &http.Client{Timeout: defaultIDPRequestTimeoutSec * time.Second},
+	}, nil
+}
+
+// Verify verifies the signature and the claims of the token, and returns the identity in it.
+func (v *WebIdentityVerifier) Verify(token string) (*WebIdentity, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, InvalidIdentityToken
+	}
+	var header jwtHeader
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return nil, InvalidIdentityToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, InvalidIdentityToken
+	}
+	keys, err := v.loadKeys(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+	verified := false
+	for _, key := range keys {
+		if header.Kid != "" && key.kid != header.Kid {
+			continue
+		}
+		if verified = verifyJWTSignature(header.Alg, key.key, signed, signature); verified {
+			break
+		}
+	}
+	if !verified {
+		return nil, InvalidIdentityToken
+	}
+
+	var claims map[string]interface{}
+	if err = decodeJWTSegment(parts[1], &claims); err != nil {
+		return nil, InvalidIdentityToken
+	}
+	return v.verifyClaims(claims, time.Now())
+}
+
+func (v *WebIdentityVerifier) verifyClaims(claims map[string]interface{}, now time.Time) (*WebIdentity, error) {
+	if iss, _ := claims["iss"].(string); strings.TrimSuffix(iss, "/") != v.conf.Issuer {
+		return nil, InvalidIdentityToken
+	}
+	audience := ""
+	for _, aud := range claimValues(claims["aud"]) {
+		if StringListContain(v.conf.ClientIDs, aud) {
+			audience = aud
+			break
+		}
+	}
+	if audience == "" {
+		return nil, InvalidIdentityToken
+	}
+	exp, ok := claimTime(claims["exp"])
+	if !ok {
+		return nil, InvalidIdentityToken
+	}
+	if now.After(exp.Add(webIdentityClockSkew)) {
+		return nil, ExpiredIdentityToken
+	}
+	if nbf, ok := claimTime(claims["nbf"]); ok && now.Add(webIdentityClockSkew).Before(nbf) {
+		return nil, InvalidIdentityToken
+	}
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, InvalidIdentityToken
+	}
+	return &WebIdentity{Subject: subject, Audience: audience, Claims: claims}, nil
+}
+
+// MapIdentity maps the identity to the CubeFS user and the policy of the issued credentials.
+func (v *WebIdentityVerifier) MapIdentity(roleArn string, identity *WebIdentity) (user string, policy *PolicyV2, err error) {
+	for _, rule := range v.conf.Rules {
+		if rule.RoleArn != "" && rule.RoleArn != roleArn {
+			continue
+		}
+		if StringListContain(claimValues(identity.Claims[rule.Claim]), rule.Value) {
+			return rule.User, rule.Policy, nil
+		}
+	}
+	if v.conf.UserClaim != "" {
+		if values := claimValues(identity.Claims[v.conf.UserClaim]); len(values) == 1 && values[0] != "" {
+			return values[0], nil, nil
+		}
+	}
+	return "", nil, AccessDenied
+}
+
+// Duration returns the lifetime in seconds of the credentials requested by DurationSeconds, which is
+// one hour if it is not specified. A duration out of the allowed range is rejected.
+func (v *WebIdentityVerifier) Duration(value string) (int64, error) {
+	if value == "" {
+		return defaultWebIdentityDurationSec, nil
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < minWebIdentityDurationSec || seconds > v.conf.MaxDurationSec {
+		return 0, InvalidDurationSeconds
+	}
+	return seconds, nil
+}
+
+// loadKeys returns the cached keys of the issuer, and fetches them again if they are expired or
+// the key of the token is not found.
+func (v *WebIdentityVerifier) loadKeys(kid string) ([]webIdentityKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	refresh := time.Duration(v.conf.JWKSRefreshIntervalSec) * time.Second
+	if time.Since(v.fetchedAt) < refresh {
+		if kid == "" || findWebIdentityKey(v.keys, kid) {
+			return v.keys, nil
+		}
+		// the keys are rotated, but the fetch is limited in case of the tokens with unknown keys
+		if time.Since(v.fetchedAt) < defaultJWKSMinRefreshIntervalSec*time.Second {
+			return v.keys, nil
+		}
+	}
+	keys, err := v.fetchKeys()
+	if err != nil {
+		log.LogErrorf("WebIdentityVerifier: fetch keys fail: issuer(%v) err(%v)", v.conf.Issuer, err)
+		// the stale keys are still used if the issuer is unavailable
+		if len(v.keys) > 0 {
+			return v.keys, nil
+		}
+		return nil, IDPCommunicationError
+	}
+	v.keys, v.fetchedAt = keys, time.Now()
+	return v.keys, nil
+}
+
+func (v *WebIdentityVerifier) fetchKeys() ([]webIdentityKey, error) {
+	jwksURL := v.conf.JWKSURL
+	if jwksURL == "" {
+		var discovery struct {
+			JWKSURI string `json:"jwks_uri"`
+		}
+		if err := v.getJSON(v.conf.Issuer+oidcDiscoveryPath, &discovery); err != nil {
+			return nil, err
+		}
+		if discovery.JWKSURI == "" {
+			return nil, errors.New("jwks_uri not found in discovery document")
+		}
+		jwksURL = discovery.JWKSURI
+	}
+	var set jsonWebKeySet
+	if err := v.getJSON(jwksURL, &set); err != nil {
+		return nil, err
+	}
+	return parseJSONWebKeySet(&set), nil
+}
+
+func (v *WebIdentityVerifier) getJSON(url string, value interface{}) error {
+	resp, err := v.client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %v from %v", resp.StatusCode, url)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxJWKSResponseSize)).Decode(value)
+}
+
+// parseJSONWebKeySet parses the RSA and EC signing keys, and the other keys are ignored.
+func parseJSONWebKeySet(set *jsonWebKeySet) (keys []webIdentityKey) {
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		var key crypto.PublicKey
+		switch jwk.Kty {
+		case "RSA":
+			n, err1 := base64.RawURLEncoding.DecodeString(jwk.N)
+			e, err2 := base64.RawURLEncoding.DecodeString(jwk.E)
+			if err1 != nil || err2 != nil || len(e) == 0 || len(e) > 4 {
+				continue
+			}
+			key = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		case "EC":
+			curve := jwkCurve(jwk.Crv)
+			x, err1 := base64.RawURLEncoding.DecodeString(jwk.X)
+			y, err2 := base64.RawURLEncoding.DecodeString(jwk.Y)
+			if curve == nil || err1 != nil || err2 != nil {
+				continue
+			}
+			pub := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+			if !curve.IsOnCurve(pub.X, pub.Y) {
+				continue
+			}
+			key = pub
+		default:
+			continue
+		}
+		keys = append(keys, webIdentityKey{kid: jwk.Kid, key: key})
+	}
+	return
+}
+
+func jwkCurve(crv string) elliptic.Curve {
+	switch crv {
+	case "P-256":
+		return elliptic.P256()
+	case "P-384":
+		return elliptic.P384()
+	case "P-521":
+		return elliptic.P521()
+	}
+	return nil
+}
+
+func findWebIdentityKey(keys []webIdentityKey, kid string) bool {
+	for _, key := range keys {
+		if key.kid == kid {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyJWTSignature verifies the signature by the asymmetric algorithms, the symmetric algorithms
+// and "none" are not accepted.
+func verifyJWTSignature(alg string, key crypto.PublicKey, signed, signature []byte) bool {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "ES512":
+		hash = crypto.SHA512
+	default:
+		return false
+	}
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		return strings.HasPrefix(alg, "RS") && rsa.VerifyPKCS1v15(pub, hash, digest, signature) == nil
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if !strings.HasPrefix(alg, "ES") || len(signature) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(pub, digest, r, s)
+	}
+	return false
+}
+
+func decodeJWTSegment(segment string, value interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(value)
+}
+
+// claimValues returns the values of the claim of string, number, bool or array type.
+func claimValues(claim interface{}) (values []string) {
+	switch value := claim.(type) {
+	case string:
+		values = append(values, value)
+	case json.Number:
+		values = append(values, value.String())
+	case bool:
+		values = append(values, fmt.Sprint(value))
+	case []interface{}:
+		for _, v := range value {
+			values = append(values, claimValues(v)...)
+		}
+	}
+	return
+}
+
+func claimTime(claim interface{}) (time.Time, bool) {
+	number, ok := claim.(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	seconds, err := number.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(seconds), 0), true
+}
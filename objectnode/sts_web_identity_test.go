@@ -0,0 +1,280 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testIssuerClientID = "sts.cube.io"
+
+// testIssuer is a local stand-in of the OIDC issuer, which serves the discovery document and the keys.
+type testIssuer struct {
+	server  *httptest.Server
+	rsaKey  *rsa.PrivateKey
+	ecKey   *ecdsa.PrivateKey
+	fetches int32
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	issuer := &testIssuer{rsaKey: rsaKey, ecKey: ecKey}
+
+	encode := base64.RawURLEncoding.EncodeToString
+	mux := http.NewServeMux()
+	mux.HandleFunc(oidcDiscoveryPath, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"jwks_uri": issuer.server.URL + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&issuer.fetches, 1)
+		_ = json.NewEncoder(w).Encode(jsonWebKeySet{Keys: []jsonWebKey{
+			{Kty: "RSA", Kid: "rsa", Use: "sig", N: encode(rsaKey.N.Bytes()), E: encode(big.NewInt(int64(rsaKey.E)).Bytes())},
+			{Kty: "EC", Kid: "ec", Crv: "P-256", X: encode(ecKey.X.Bytes()), Y: encode(ecKey.Y.Bytes())},
+			{Kty: "RSA", Kid: "enc", Use: "enc", N: encode(rsaKey.N.Bytes()), E: "AQAB"},
+		}})
+	})
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+func (i *testIssuer) sign(t *testing.T, alg, kid string, claims map[string]interface{}) string {
+	header, err := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	switch alg {
+	case "RS256":
+		signature, err = rsa.SignPKCS1v15(rand.Reader, i.rsaKey, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+	case "ES256":
+		r, s, err := ecdsa.Sign(rand.Reader, i.ecKey, digest[:])
+		require.NoError(t, err)
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (i *testIssuer) claims(sub string) map[string]interface{} {
+	return map[string]interface{}{
+		"iss":    i.server.URL,
+		"aud":    []string{"other", testIssuerClientID},
+		"sub":    sub,
+		"exp":    time.Now().Add(time.Hour).Unix(),
+		"nbf":    time.Now().Unix(),
+		"groups": []string{"dev", "ops"},
+	}
+}
+
+func TestWebIdentityConfig(t *testing.T) {
+	_, err := NewWebIdentityVerifier(WebIdentityConfig{ClientIDs: []string{"a"}, UserClaim: "sub"})
+	require.Error(t, err)
+	_, err = NewWebIdentityVerifier(WebIdentityConfig{Issuer: "https://issuer", UserClaim: "sub"})
+	require.Error(t, err)
+	_, err = NewWebIdentityVerifier(WebIdentityConfig{Issuer: "https://issuer", ClientIDs: []string{"a"}})
+	require.Error(t, err)
+	_, err = NewWebIdentityVerifier(WebIdentityConfig{
+		Issuer:    "https://issuer",
+		ClientIDs: []string{"a"},
+		Rules:     []*WebIdentityRule{{Claim: "sub", Value: "a", User: "u", Policy: &PolicyV2{Version: "2000-01-01"}}},
+	})
+	require.Error(t, err)
+
+	v, err := NewWebIdentityVerifier(WebIdentityConfig{Issuer: "https://issuer/", ClientIDs: []string{"a"}, UserClaim: "sub"})
+	require.NoError(t, err)
+	require.Equal(t, "https://issuer", v.conf.Issuer)
+	require.Equal(t, int64(defaultWebIdentityMaxDuration), v.conf.MaxDurationSec)
+	require.Equal(t, int64(defaultJWKSRefreshIntervalSec), v.conf.JWKSRefreshIntervalSec)
+
+	_, err = NewWebIdentityVerifier(WebIdentityConfig{Issuer: "https://issuer", ClientIDs: []string{"a"}, UserClaim: "sub", MaxDurationSec: 600})
+	require.Error(t, err)
+	_, err = NewWebIdentityVerifier(WebIdentityConfig{Issuer: "https://issuer", ClientIDs: []string{"a"}, UserClaim: "sub", MaxDurationSec: 86400})
+	require.Error(t, err)
+}
+
+func TestWebIdentityDuration(t *testing.T) {
+	v, err := NewWebIdentityVerifier(WebIdentityConfig{Issuer: "https://issuer", ClientIDs: []string{"a"}, UserClaim: "sub", MaxDurationSec: 7200})
+	require.NoError(t, err)
+
+	seconds, err := v.Duration("")
+	require.NoError(t, err)
+	require.Equal(t, int64(defaultWebIdentityDurationSec), seconds)
+	seconds, err = v.Duration("900")
+	require.NoError(t, err)
+	require.Equal(t, int64(900), seconds)
+	seconds, err = v.Duration("7200")
+	require.NoError(t, err)
+	require.Equal(t, int64(7200), seconds)
+
+	for _, value := range []string{"899", "7201", "43200", "-1", "abc"} {
+		_, err = v.Duration(value)
+		require.Equal(t, InvalidDurationSeconds, err, value)
+	}
+}
+
+func TestWebIdentityVerify(t *testing.T) {
+	issuer := newTestIssuer(t)
+	v, err := NewWebIdentityVerifier(WebIdentityConfig{
+		Issuer:    issuer.server.URL,
+		ClientIDs: []string{testIssuerClientID},
+		UserClaim: "sub",
+	})
+	require.NoError(t, err)
+
+	identity, err := v.Verify(issuer.sign(t, "RS256", "rsa", issuer.claims("alice")))
+	require.NoError(t, err)
+	require.Equal(t, "alice", identity.Subject)
+	require.Equal(t, testIssuerClientID, identity.Audience)
+	identity, err = v.Verify(issuer.sign(t, "ES256", "ec", issuer.claims("bob")))
+	require.NoError(t, err)
+	require.Equal(t, "bob", identity.Subject)
+	// the keys are cached
+	require.Equal(t, int32(1), atomic.LoadInt32(&issuer.fetches))
+
+	// the signature by the key of other kid, or by the key for encryption
+	_, err = v.Verify(issuer.sign(t, "RS256", "ec", issuer.claims("alice")))
+	require.Equal(t, InvalidIdentityToken, err)
+	_, err = v.Verify(issuer.sign(t, "RS256", "enc", issuer.claims("alice")))
+	require.Equal(t, InvalidIdentityToken, err)
+	// the algorithm none is not accepted
+	token := issuer.sign(t, "RS256", "rsa", issuer.claims("alice"))
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","kid":"rsa"}`))
+	_, err = v.Verify(header + token[strings.Index(token, "."):])
+	require.Equal(t, InvalidIdentityToken, err)
+	// the tampered claims
+	parts := strings.Split(token, ".")
+	parts[1] = base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"root"}`))
+	_, err = v.Verify(strings.Join(parts, "."))
+	require.Equal(t, InvalidIdentityToken, err)
+	_, err = v.Verify("not.a.token")
+	require.Equal(t, InvalidIdentityToken, err)
+
+	claims := issuer.claims("alice")
+	claims["exp"] = time.Now().Add(-time.Hour).Unix()
+	_, err = v.Verify(issuer.sign(t, "RS256", "rsa", claims))
+	require.Equal(t, ExpiredIdentityToken, err)
+	claims = issuer.claims("alice")
+	claims["aud"] = "other"
+	_, err = v.Verify(issuer.sign(t, "RS256", "rsa", claims))
+	require.Equal(t, InvalidIdentityToken, err)
+	claims = issuer.claims("alice")
+	claims["iss"] = "https://other"
+	_, err = v.Verify(issuer.sign(t, "RS256", "rsa", claims))
+	require.Equal(t, InvalidIdentityToken, err)
+	claims = issuer.claims("alice")
+	claims["nbf"] = time.Now().Add(time.Hour).Unix()
+	_, err = v.Verify(issuer.sign(t, "RS256", "rsa", claims))
+	require.Equal(t, InvalidIdentityToken, err)
+
+	// the unknown kid triggers no fetch within the min refresh interval
+	_, err = v.Verify(issuer.sign(t, "RS256", "unknown", issuer.claims("alice")))
+	require.Equal(t, InvalidIdentityToken, err)
+	require.Equal(t, int32(1), atomic.LoadInt32(&issuer.fetches))
+	v.fetchedAt = time.Now().Add(-2 * defaultJWKSMinRefreshIntervalSec * time.Second)
+	_, err = v.Verify(issuer.sign(t, "RS256", "unknown", issuer.claims("alice")))
+	require.Equal(t, InvalidIdentityToken, err)
+	require.Equal(t, int32(2), atomic.LoadInt32(&issuer.fetches))
+
+	// the stale keys are used if the issuer is unavailable
+	issuer.server.Close()
+	v.fetchedAt = time.Time{}
+	_, err = v.Verify(issuer.sign(t, "RS256", "rsa", issuer.claims("alice")))
+	require.NoError(t, err)
+	v.keys = nil
+	_, err = v.Verify(issuer.sign(t, "RS256", "rsa", issuer.claims("alice")))
+	require.Equal(t, IDPCommunicationError, err)
+}
+
+func TestWebIdentityMapIdentity(t *testing.T) {
+	policy := &PolicyV2{Version: defaultPolicyVersion, Statements: []StatementV2{
+		{Effect: Allow, Action: "s3:GetObject", Resource: "arn:aws:s3:::bucket/*"},
+	}}
+	v, err := NewWebIdentityVerifier(WebIdentityConfig{
+		Issuer:    "https://issuer",
+		ClientIDs: []string{testIssuerClientID},
+		Rules: []*WebIdentityRule{
+			{RoleArn: "arn:aws:iam::cubefs:role/admin", Claim: "groups", Value: "ops", User: "admin"},
+			{Claim: "groups", Value: "dev", User: "dev", Policy: policy},
+		},
+	})
+	require.NoError(t, err)
+
+	identity := &WebIdentity{Subject: "alice", Claims: map[string]interface{}{"sub": "alice", "groups": []interface{}{"dev", "ops"}}}
+	user, mapped, err := v.MapIdentity("arn:aws:iam::cubefs:role/admin", identity)
+	require.NoError(t, err)
+	require.Equal(t, "admin", user)
+	require.Nil(t, mapped)
+	user, mapped, err = v.MapIdentity("", identity)
+	require.NoError(t, err)
+	require.Equal(t, "dev", user)
+	require.Equal(t, policy, mapped)
+
+	identity.Claims["groups"] = []interface{}{"qa"}
+	_, _, err = v.MapIdentity("", identity)
+	require.Equal(t, AccessDenied, err)
+	v.conf.UserClaim = "sub"
+	user, mapped, err = v.MapIdentity("", identity)
+	require.NoError(t, err)
+	require.Equal(t, "alice", user)
+	require.Nil(t, mapped)
+}
+
+func TestEncodeDecodeWebIdentitySessionToken(t *testing.T) {
+	fedAk := stsAkPrefix + "WebIdentity01"
+	expireUnixStr := fmt.Sprint(time.Now().Add(time.Hour).Unix())
+	policyStr := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"*","Resource":"*"}]}`
+	sessionPolicyStr := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"arn:aws:s3:::bucket/*"}]}`
+
+	token, err := EncodeWebIdentitySessionToken(testOwnerAK, testOwnerSK, fedAk, "sk", "session", policyStr, sessionPolicyStr, expireUnixStr)
+	require.NoError(t, err)
+	fed, err := DecodeFedSessionToken(fedAk, token, testGetUserInfo)
+	require.NoError(t, err)
+	require.NotNil(t, fed.SessionPolicy)
+	require.True(t, fed.IsAllow("s3:GetObject", "bucket", "key"))
+	require.False(t, fed.IsAllow("s3:PutObject", "bucket", "key"))
+
+	token, err = EncodeWebIdentitySessionToken(testOwnerAK, testOwnerSK, fedAk, "sk", "session", policyStr, "", expireUnixStr)
+	require.NoError(t, err)
+	fed, err = DecodeFedSessionToken(fedAk, token, testGetUserInfo)
+	require.NoError(t, err)
+	require.Nil(t, fed.SessionPolicy)
+	require.True(t, fed.IsAllow("s3:PutObject", "bucket", "key"))
+}
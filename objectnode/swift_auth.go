@@ -0,0 +1,390 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.openstack.org/swift/latest/overview_auth.html
+// https://docs.openstack.org/api-ref/identity/v3/#authentication-and-token-management
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	SwiftAuthTokenPrefix = "AUTH_tk"
+	SwiftAccountPrefix   = "AUTH_"
+
+	defaultSwiftTokenTTLSec          = 86400
+	defaultSwiftMaxManifestSegments  = 1000
+	defaultKeystoneCacheTTLSec       = 300
+	defaultKeystoneRequestTimeoutSec = 10
+	maxKeystoneCachedTokens          = 100000
+	maxKeystoneResponseSize          = 1 << 20
+	keystoneServiceTokenEarlyExpiry  = time.Minute
+	keystoneTokensPath               = "/v3/auth/tokens"
+
+	keystoneUserFieldProjectID   = "project_id"
+	keystoneUserFieldProjectName = "project_name"
+	keystoneUserFieldUserID      = "user_id"
+	keystoneUserFieldUserName    = "user_name"
+)
+
+// SwiftConfig is the configuration of the Swift API front-end. The TempAuth compatible tokens are issued
+// by the auth endpoint to the users with the access key and the secret key, and the tokens of Keystone
+// are accepted as well if keystone is configured.
+type SwiftConfig struct {
+	TokenTTLSec         int64           `json:"token_ttl_sec"`
+	MaxManifestSegments int             `json:"max_manifest_segments"`
+	Keystone            *KeystoneConfig `json:"keystone"`
+}
+
+// KeystoneConfig is the configuration of the validation of the Keystone v3 tokens. The tokens are
+// validated with the token of the service user, and the identity of a valid token is mapped to the
+// CubeFS user with the same id by the user_field, which is one of project_id, project_name, user_id
+// and user_name. The validated tokens are cached for cache_ttl_sec.
+type KeystoneConfig struct {
+	URL           string `json:"url"`
+	Username      string `json:"username"`
+	Password      string `json:"password"`
+	UserDomain    string `json:"user_domain"`
+	Project       string `json:"project"`
+	ProjectDomain string `json:"project_domain"`
+	UserField     string `json:"user_field"`
+	CacheTTLSec   int64  `json:"cache_ttl_sec"`
+}
+
+// FixConfig validates and fixes the configuration.
+func (c *SwiftConfig) FixConfig() error {
+	if c.TokenTTLSec <= 0 {
+		c.TokenTTLSec = defaultSwiftTokenTTLSec
+	}
+	if c.MaxManifestSegments <= 0 {
+		c.MaxManifestSegments = defaultSwiftMaxManifestSegments
+	}
+	if c.Keystone == nil {
+		return nil
+	}
+	k := c.Keystone
+	k.URL = strings.TrimSuffix(k.URL, "/")
+	if k.URL == "" || k.Username == "" || k.Password == "" || k.Project == "" {
+		return errors.New("url, username, password and project of keystone are required")
+	}
+	if k.UserDomain == "" {
+		k.UserDomain = "Default"
+	}
+	if k.ProjectDomain == "" {
+		k.ProjectDomain = "Default"
+	}
+	switch k.UserField {
+	case "":
+		k.UserField = keystoneUserFieldProjectID
+	case keystoneUserFieldProjectID, keystoneUserFieldProjectName, keystoneUserFieldUserID, keystoneUserFieldUserName:
+	default:
+		return fmt.Errorf("invalid user_field of keystone: %v", k.UserField)
+	}
+	if k.CacheTTLSec <= 0 {
+		k.CacheTTLSec = defaultKeystoneCacheTTLSec
+	}
+	return nil
+}
+
+// SwiftAuthenticator authenticates the tokens of the Swift requests.
+type SwiftAuthenticator struct {
+	conf     SwiftConfig
+	keystone *KeystoneValidator
+}
+
+func NewSwiftAuthenticator(conf SwiftConfig) (*SwiftAuthenticator, error) {
+	if err := conf.FixConfig(); err != nil {
+		return nil, err
+	}
+	auth := &SwiftAuthenticator{conf: conf}
+	if conf.Keystone != nil {
+		auth.keystone = NewKeystoneValidator(*conf.Keystone)
+	}
+	return auth, nil
+}
+
+func (a *SwiftAuthenticator) TokenTTL() time.Duration {
+	return time.Duration(a.conf.TokenTTLSec) * time.Second
+}
+
+func (a *SwiftAuthenticator) MaxManifestSegments() int {
+	return a.conf.MaxManifestSegments
+}
+
+// newSwiftToken returns the TempAuth token of the user. The token is signed with the secret key of the
+// user, so that it is verified by any object node without a shared token store, and it is revoked when
+// the secret key is changed.
+func newSwiftToken(accessKey, secretKey string, expires time.Time) string {
+	payload := accessKey + ":" + strconv.FormatInt(expires.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secretKey))
+	mac.Write([]byte(payload))
+	raw := payload + ":" + hex.EncodeToString(mac.Sum(nil))
+	return SwiftAuthTokenPrefix + base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// verifySwiftToken verifies the TempAuth token and returns the user of it.
+func verifySwiftToken(token string, now time.Time, loadUser func(accessKey string) (*proto.UserInfo, error)) (*proto.UserInfo, error) {
+	if !strings.HasPrefix(token, SwiftAuthTokenPrefix) {
+		return nil, SwiftUnauthorized
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token[len(SwiftAuthTokenPrefix):])
+	if err != nil {
+		return nil, SwiftUnauthorized
+	}
+	items := strings.Split(string(raw), ":")
+	if len(items) != 3 || items[0] == "" {
+		return nil, SwiftUnauthorized
+	}
+	expires, err := strconv.ParseInt(items[1], 10, 64)
+	if err != nil || now.Unix() > expires {
+		return nil, SwiftUnauthorized
+	}
+	userInfo, err := loadUser(items[0])
+	if err == InvalidAccessKeyId {
+		return nil, SwiftUnauthorized
+	}
+	if err != nil {
+		return nil, err
+	}
+	expected := newSwiftToken(userInfo.AccessKey, userInfo.SecretKey, time.Unix(expires, 0))
+	if !hmac.Equal([]byte(expected), []byte(token)) {
+		return nil, SwiftUnauthorized
+	}
+	return userInfo, nil
+}
+
+type keystoneTokenResponse struct {
+	Token struct {
+		ExpiresAt time.Time `json:"expires_at"`
+		User      struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"user"`
+		Project *struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"project"`
+	} `json:"token"`
+}
+
+// identity returns the identity of the token by the user field, empty is returned if the token is
+// not scoped to a project but the project is required.
+func (t *keystoneTokenResponse) identity(userField string) string {
+	switch userField {
+	case keystoneUserFieldUserID:
+		return t.Token.User.ID
+	case keystoneUserFieldUserName:
+		return t.Token.User.Name
+	}
+	if t.Token.Project == nil {
+		return ""
+	}
+	if userField == keystoneUserFieldProjectName {
+		return t.Token.Project.Name
+	}
+	return t.Token.Project.ID
+}
+
+type keystoneCachedToken struct {
+	accessKey string
+	expires   time.Time
+}
+
+// KeystoneValidator validates the Keystone v3 tokens with the token of the service user, which is
+// fetched again when it is expired or rejected. The validated tokens are cached with the access keys
+// of the users they are mapped to.
+type KeystoneValidator struct {
+	conf   KeystoneConfig
+	client *http.Client
+
+	serviceMu      sync.Mutex
+	serviceToken   string
+	serviceExpires time.Time
+
+	mu     sync.Mutex
+	tokens map[string]keystoneCachedToken
+}
+
+func NewKeystoneValidator(conf KeystoneConfig) *KeystoneValidator {
+	return &KeystoneValidator{
+		conf:   conf,
+		client: &http.Client{Timeout: defaultKeystoneRequestTimeoutSec * time.Second},
+		tokens: make(map[string]keystoneCachedToken),
+	}
+}
+
+// Validate validates the token and returns the access key of the user that the identity of the token
+// is mapped to by the resolve function.
+func (v *KeystoneValidator) Validate(token string, resolve func(identity string) (string, error)) (string, error) {
+	now := time.Now()
+	v.mu.Lock()
+	cached, ok := v.tokens[token]
+	v.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.accessKey, nil
+	}
+
+	resp, err := v.validateToken(token)
+	if err != nil {
+		return "", err
+	}
+	if !resp.Token.ExpiresAt.IsZero() && !now.Before(resp.Token.ExpiresAt) {
+		return "", SwiftUnauthorized
+	}
+	identity := resp.identity(v.conf.UserField)
+	if identity == "" {
+		return "", SwiftUnauthorized
+	}
+	accessKey, err := resolve(identity)
+	if err != nil {
+		return "", err
+	}
+
+	expires := now.Add(time.Duration(v.conf.CacheTTLSec) * time.Second)
+	if !resp.Token.ExpiresAt.IsZero() && resp.Token.ExpiresAt.Before(expires) {
+		expires = resp.Token.ExpiresAt
+	}
+	v.mu.Lock()
+	if len(v.tokens) >= maxKeystoneCachedTokens {
+		for key, value := range v.tokens {
+			if !now.Before(value.expires) {
+				delete(v.tokens, key)
+			}
+		}
+		if len(v.tokens) >= maxKeystoneCachedTokens {
+			v.tokens = make(map[string]keystoneCachedToken)
+		}
+	}
+	v.tokens[token] = keystoneCachedToken{accessKey: accessKey, expires: expires}
+	v.mu.Unlock()
+	return accessKey, nil
+}
+
+func (v *KeystoneValidator) validateToken(token string) (*keystoneTokenResponse, error) {
+	for retry := 0; ; retry++ {
+		serviceToken, err := v.loadServiceToken(retry > 0)
+		if err != nil {
+			log.LogErrorf("KeystoneValidator: load service token fail: url(%v) err(%v)", v.conf.URL, err)
+			return nil, InternalErrorCode(err)
+		}
+		req, err := http.NewRequest(http.MethodGet, v.conf.URL+keystoneTokensPath, nil)
+		if err != nil {
+			return nil, InternalErrorCode(err)
+		}
+		req.Header.Set(XAuthToken, serviceToken)
+		req.Header.Set(XSubjectToken, token)
+		resp, err := v.client.Do(req)
+		if err != nil {
+			log.LogErrorf("KeystoneValidator: validate token fail: url(%v) err(%v)", v.conf.URL, err)
+			return nil, InternalErrorCode(err)
+		}
+		switch resp.StatusCode {
+		case http.StatusOK:
+			result := &keystoneTokenResponse{}
+			err = json.NewDecoder(io.LimitReader(resp.Body, maxKeystoneResponseSize)).Decode(result)
+			resp.Body.Close()
+			if err != nil {
+				return nil, InternalErrorCode(err)
+			}
+			return result, nil
+		case http.StatusUnauthorized:
+			resp.Body.Close()
+			// the service token may be revoked, fetch it again once
+			if retry == 0 {
+				continue
+			}
+			return nil, InternalErrorCode(errors.New("keystone service token is rejected"))
+		case http.StatusNotFound, http.StatusBadRequest:
+			resp.Body.Close()
+			return nil, SwiftUnauthorized
+		default:
+			resp.Body.Close()
+			return nil, InternalErrorCode(fmt.Errorf("unexpected status %v from keystone", resp.StatusCode))
+		}
+	}
+}
+
+// loadServiceToken returns the cached token of the service user, or fetches a new one if it is
+// expired or refresh is required.
+func (v *KeystoneValidator) loadServiceToken(refresh bool) (string, error) {
+	v.serviceMu.Lock()
+	defer v.serviceMu.Unlock()
+	if !refresh && v.serviceToken != "" && time.Now().Add(keystoneServiceTokenEarlyExpiry).Before(v.serviceExpires) {
+		return v.serviceToken, nil
+	}
+
+	type name struct {
+		Name string `json:"name"`
+	}
+	body := map[string]interface{}{
+		"auth": map[string]interface{}{
+			"identity": map[string]interface{}{
+				"methods": []string{"password"},
+				"password": map[string]interface{}{
+					"user": map[string]interface{}{
+						"name":     v.conf.Username,
+						"password": v.conf.Password,
+						"domain":   name{Name: v.conf.UserDomain},
+					},
+				},
+			},
+			"scope": map[string]interface{}{
+				"project": map[string]interface{}{
+					"name":   v.conf.Project,
+					"domain": name{Name: v.conf.ProjectDomain},
+				},
+			},
+		},
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+	resp, err := v.client.Post(v.conf.URL+keystoneTokensPath, ValueContentTypeJSON, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %v from keystone", resp.StatusCode)
+	}
+	token := resp.Header.Get(XSubjectToken)
+	if token == "" {
+		return "", errors.New("service token not found in keystone response")
+	}
+	result := &keystoneTokenResponse{}
+	if err = json.NewDecoder(io.LimitReader(resp.Body, maxKeystoneResponseSize)).Decode(result); err != nil {
+		return "", err
+	}
+	v.serviceToken, v.serviceExpires = token, result.Token.ExpiresAt
+	return token, nil
+}
@@ -0,0 +1,907 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.openstack.org/api-ref/object-store/
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	swiftMaxListLimit        = 10000
+	swiftMaxMetaNameLength   = 128
+	swiftMaxMetaValueLength  = 256
+	swiftMaxMetaCount        = 90
+	swiftMaxMetaOverallSize  = 4096
+	swiftListFormatPlain     = "plain"
+	swiftListFormatJSON      = "json"
+	swiftListFormatXML       = "xml"
+	swiftLastModifiedLayout  = "2006-01-02T15:04:05.000000"
+	swiftContentTypeSuffix   = "; charset=utf-8"
+	valueSwiftTrue           = "True"
+	ParamSwiftFormat         = "format"
+	ParamSwiftEndMarker      = "end_marker"
+	ParamSwiftLimit          = "limit"
+	XCopiedFrom              = "X-Copied-From"
+	XCopiedFromLastModified  = "X-Copied-From-Last-Modified"
+	swiftErrorResponseLayout = "<html><h1>%s</h1><p>%s</p></html>"
+)
+
+// swiftErrorResponse writes the error of the Swift request, which is in plain html instead of the
+// xml of S3, and the status codes are mapped to the ones of Swift.
+func (o *ObjectNode) swiftErrorResponse(w http.ResponseWriter, r *http.Request, err error, ec *ErrorCode) {
+	if err == nil && ec == nil {
+		return
+	}
+	log.LogErrorf("swiftErrorResponse: found error: requestID(%v) err(%v) errCode(%v)", GetRequestID(r), err, ec)
+	switch err {
+	case syscall.ENOENT:
+		ec = NoSuchKey
+	case syscall.EDQUOT, syscall.ENOSPC:
+		ec = DiskQuotaExceeded
+	case syscall.EPERM:
+		ec = FileDeleteLock
+	}
+	if ec1, ok := err.(*ErrorCode); ok && ec == nil {
+		ec = ec1
+	}
+	if ec == nil {
+		ec = InternalErrorCode(err)
+	}
+	statusCode := ec.StatusCode
+	switch ec.ErrorCode {
+	case QuotaExceeded.ErrorCode, DiskQuotaExceeded.ErrorCode, EntityTooLarge.ErrorCode:
+		statusCode = http.StatusRequestEntityTooLarge
+	case InvalidAccessKeyId.ErrorCode:
+		statusCode = http.StatusUnauthorized
+	}
+
+	SetResponseStatusCode(r, strconv.Itoa(statusCode))
+	SetResponseErrorMessage(r, ec.ErrorMessage)
+	SetResponseErrorCode(r, ec.ErrorCode)
+	if r.Method == http.MethodHead {
+		w.WriteHeader(statusCode)
+		return
+	}
+	body := fmt.Sprintf(swiftErrorResponseLayout, http.StatusText(statusCode), ec.ErrorMessage)
+	w.Header().Set(ContentType, "text/html"+swiftContentTypeSuffix)
+	w.Header().Set(ContentLength, strconv.Itoa(len(body)))
+	w.WriteHeader(statusCode)
+	_, _ = w.Write([]byte(body))
+}
+
+// swiftUserInfo authenticates the token of the request and returns the user of it.
+func (o *ObjectNode) swiftUserInfo(r *http.Request) (*proto.UserInfo, error) {
+	token := r.Header.Get(XAuthToken)
+	if token == "" {
+		token = r.Header.Get(XStorageToken)
+	}
+	if strings.HasPrefix(token, SwiftAuthTokenPrefix) {
+		return verifySwiftToken(token, time.Now(), o.getUserInfoByAccessKeyV2)
+	}
+	if o.swift.keystone == nil {
+		return nil, SwiftUnauthorized
+	}
+	accessKey, err := o.swift.keystone.Validate(token, o.keystoneUserAccessKey)
+	if err != nil {
+		return nil, err
+	}
+	userInfo, err := o.getUserInfoByAccessKeyV2(accessKey)
+	if err == InvalidAccessKeyId {
+		return nil, SwiftUnauthorized
+	}
+	return userInfo, err
+}
+
+// keystoneUserAccessKey returns the access key of the user mapped from the identity of Keystone.
+func (o *ObjectNode) keystoneUserAccessKey(identity string) (string, error) {
+	userInfo, err := o.mc.UserAPI().GetUserInfo(identity)
+	if err != nil {
+		log.LogErrorf("keystoneUserAccessKey: get user info fail: identity(%v) err(%v)", identity, err)
+		if err == proto.ErrUserNotExists {
+			return "", AccessDenied
+		}
+		return "", err
+	}
+	// the root user is not allowed to be mapped from the identity of Keystone
+	if userInfo.UserType == proto.UserTypeRoot {
+		log.LogErrorf("keystoneUserAccessKey: root user mapped: identity(%v)", identity)
+		return "", AccessDenied
+	}
+	return userInfo.AccessKey, nil
+}
+
+// checkSwiftAccess checks whether the user is allowed to perform the request. The account of the request
+// must be the account of the user, and the containers are accessed by the owners or by the users authorized
+// by the volume policies, only the owners are allowed to delete the containers and update the metadata.
+func checkSwiftAccess(userInfo *proto.UserInfo, account string, param *RequestParam) error {
+	if account != SwiftAccountPrefix+userInfo.UserID {
+		return AccessDenied
+	}
+	bucket := param.Bucket()
+	if bucket == "" {
+		return nil
+	}
+	switch param.action {
+	case proto.OSSCreateBucketAction, proto.OSSCopyObjectAction:
+		// checked by the handlers, as the container to create may exist or the copy has two containers
+		return nil
+	case proto.OSSDeleteBucketAction, proto.OSSSwiftPostContainerAction:
+		if userInfo.Policy != nil && userInfo.Policy.IsOwn(bucket) {
+			return nil
+		}
+		return AccessDenied
+	case proto.OSSPutObjectAction, proto.OSSDeleteObjectAction, proto.OSSSwiftPostObjectAction:
+		if swiftContainerAllowed(userInfo.Policy, bucket, true) {
+			return nil
+		}
+		return AccessDenied
+	default:
+		if swiftContainerAllowed(userInfo.Policy, bucket, false) {
+			return nil
+		}
+		return AccessDenied
+	}
+}
+
+// swiftContainerAllowed checks whether the user is allowed to read or write the objects of the container.
+func swiftContainerAllowed(policy *proto.UserPolicy, bucket string, write bool) bool {
+	if policy == nil {
+		return false
+	}
+	if policy.IsOwn(bucket) {
+		return true
+	}
+	if write {
+		return policy.IsAuthorizedS3(bucket, PUT_OBJECT)
+	}
+	return policy.IsAuthorizedS3(bucket, GET_OBJECT)
+}
+
+func swiftStorageURL(r *http.Request, uid string) string {
+	scheme := "http"
+	if r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https") {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + swiftAPIPathPrefix + SwiftAccountPrefix + uid
+}
+
+func swiftTimestamp(t time.Time) string {
+	return fmt.Sprintf("%d.%05d", t.Unix(), t.Nanosecond()/10000)
+}
+
+// updateSwiftMetadata updates the metadata by the headers with the prefix, the metadata with the
+// empty value or with the remove prefix is removed. The names of the metadata are in lower case.
+func updateSwiftMetadata(metadata map[string]string, header http.Header, prefix, removePrefix string) map[string]string {
+	if metadata == nil {
+		metadata = make(map[string]string)
+	}
+	for name, values := range header {
+		name = http.CanonicalHeaderKey(name)
+		switch {
+		case removePrefix != "" && strings.HasPrefix(name, removePrefix):
+			delete(metadata, strings.ToLower(name[len(removePrefix):]))
+		case strings.HasPrefix(name, prefix) && len(name) > len(prefix):
+			key, value := strings.ToLower(name[len(prefix):]), strings.Join(values, ",")
+			if strings.HasPrefix(key, "oss:") {
+				continue
+			}
+			if value == "" {
+				delete(metadata, key)
+			} else {
+				metadata[key] = value
+			}
+		}
+	}
+	return metadata
+}
+
+// validateSwiftMetadata checks the metadata with the default constraints of Swift.
+func validateSwiftMetadata(metadata map[string]string) *ErrorCode {
+	invalid := func(msg string) *ErrorCode {
+		ec := InvalidArgument.Copy()
+		ec.ErrorMessage = msg
+		return ec
+	}
+	if len(metadata) > swiftMaxMetaCount {
+		return invalid(fmt.Sprintf("Too many metadata items; max %d", swiftMaxMetaCount))
+	}
+	size := 0
+	for name, value := range metadata {
+		if len(name) > swiftMaxMetaNameLength {
+			return invalid(fmt.Sprintf("Metadata name too long; max %d", swiftMaxMetaNameLength))
+		}
+		if len(value) > swiftMaxMetaValueLength {
+			return invalid(fmt.Sprintf("Metadata value longer than %d", swiftMaxMetaValueLength))
+		}
+		size += len(name) + len(value)
+	}
+	if size > swiftMaxMetaOverallSize {
+		return invalid(fmt.Sprintf("Total metadata too large; max %d", swiftMaxMetaOverallSize))
+	}
+	return nil
+}
+
+func setSwiftMetadataHeader(header http.Header, prefix string, metadata map[string]string) {
+	for name, value := range metadata {
+		header.Set(prefix+name, value)
+	}
+}
+
+// loadSwiftContainerMeta loads the metadata of the container, which is kept in the xattr of the root.
+func loadSwiftContainerMeta(vol *Volume) (metadata map[string]string, err error) {
+	var raw []byte
+	if raw, err = vol.store.Get(vol.name, bucketRootPath, XAttrKeyOSSSwiftMeta); err != nil {
+		return
+	}
+	metadata = make(map[string]string)
+	if len(raw) == 0 {
+		return
+	}
+	err = json.Unmarshal(raw, &metadata)
+	return
+}
+
+func storeSwiftContainerMeta(vol *Volume, metadata map[string]string) error {
+	if len(metadata) == 0 {
+		return vol.store.Delete(vol.name, bucketRootPath, XAttrKeyOSSSwiftMeta)
+	}
+	raw, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	return vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSSwiftMeta, raw)
+}
+
+type swiftListOption struct {
+	format    string
+	prefix    string
+	delimiter string
+	marker    string
+	endMarker string
+	limit     uint64
+}
+
+func parseSwiftListOption(r *http.Request) (*swiftListOption, *ErrorCode) {
+	query := r.URL.Query()
+	opt := &swiftListOption{
+		format:    swiftListFormatPlain,
+		prefix:    query.Get(ParamPrefix),
+		delimiter: query.Get(ParamPartDelimiter),
+		marker:    query.Get(ParamMarker),
+		endMarker: query.Get(ParamSwiftEndMarker),
+		limit:     swiftMaxListLimit,
+	}
+	accept := r.Header.Get("Accept")
+	switch format := strings.ToLower(query.Get(ParamSwiftFormat)); {
+	case format == swiftListFormatJSON || format == "" && strings.Contains(accept, ValueContentTypeJSON):
+		opt.format = swiftListFormatJSON
+	case format == swiftListFormatXML || format == "" && (strings.Contains(accept, ValueContentTypeXML) || strings.Contains(accept, "text/xml")):
+		opt.format = swiftListFormatXML
+	}
+	if limit := query.Get(ParamSwiftLimit); limit != "" {
+		value, err := strconv.ParseUint(limit, 10, 64)
+		if err != nil || value > swiftMaxListLimit {
+			return nil, InvalidArgument
+		}
+		opt.limit = value
+	}
+	return opt, nil
+}
+
+// filterNames returns the sorted names which are matched by the prefix, the marker and the end marker.
+func (opt *swiftListOption) filterNames(names []string) []string {
+	sort.Strings(names)
+	result := make([]string, 0)
+	for i, name := range names {
+		if i > 0 && name == names[i-1] {
+			continue
+		}
+		if !strings.HasPrefix(name, opt.prefix) || name <= opt.marker {
+			continue
+		}
+		if opt.endMarker != "" && name >= opt.endMarker {
+			break
+		}
+		if uint64(len(result)) >= opt.limit {
+			break
+		}
+		result = append(result, name)
+	}
+	return result
+}
+
+type swiftContainerEntry struct {
+	XMLName      xml.Name `json:"-" xml:"container"`
+	Name         string   `json:"name" xml:"name"`
+	Count        uint64   `json:"count" xml:"count"`
+	Bytes        uint64   `json:"bytes" xml:"bytes"`
+	LastModified string   `json:"last_modified" xml:"last_modified"`
+}
+
+type swiftAccountListing struct {
+	XMLName    xml.Name               `xml:"account"`
+	Name       string                 `xml:"name,attr"`
+	Containers []*swiftContainerEntry `xml:"container"`
+}
+
+type swiftObjectEntry struct {
+	XMLName      xml.Name `json:"-" xml:"object"`
+	Name         string   `json:"name" xml:"name"`
+	Hash         string   `json:"hash" xml:"hash"`
+	Bytes        int64    `json:"bytes" xml:"bytes"`
+	ContentType  string   `json:"content_type" xml:"content_type"`
+	LastModified string   `json:"last_modified" xml:"last_modified"`
+}
+
+type swiftSubdirEntry struct {
+	XMLName  xml.Name `json:"-" xml:"subdir"`
+	NameAttr string   `json:"-" xml:"name,attr"`
+	Name     string   `json:"-" xml:"name"`
+	Subdir   string   `json:"subdir" xml:"-"`
+}
+
+type swiftContainerListing struct {
+	XMLName xml.Name      `xml:"container"`
+	Name    string        `xml:"name,attr"`
+	Entries []interface{} // *swiftObjectEntry or *swiftSubdirEntry
+}
+
+// writeSwiftListing writes the listing in the format, the plain listing is the names in lines.
+func writeSwiftListing(w http.ResponseWriter, format string, names []string, listing, entries interface{}) (err error) {
+	var data []byte
+	switch format {
+	case swiftListFormatJSON:
+		if data, err = json.Marshal(entries); err != nil {
+			return
+		}
+		w.Header().Set(ContentType, ValueContentTypeJSON+swiftContentTypeSuffix)
+	case swiftListFormatXML:
+		if data, err = xml.Marshal(listing); err != nil {
+			return
+		}
+		data = append([]byte(xml.Header), data...)
+		w.Header().Set(ContentType, ValueContentTypeXML+swiftContentTypeSuffix)
+	default:
+		if len(names) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		data = []byte(strings.Join(names, "\n") + "\n")
+		w.Header().Set(ContentType, ValueContentTypeText+swiftContentTypeSuffix)
+	}
+	w.Header().Set(ContentLength, strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(data)
+	return
+}
+
+// swiftContainerNames returns the names of the containers of the account, which are the volumes owned
+// by the user and the volumes authorized to the user.
+func swiftContainerNames(userInfo *proto.UserInfo) []string {
+	if userInfo.Policy == nil {
+		return nil
+	}
+	names := append([]string{}, userInfo.Policy.OwnVols...)
+	for vol := range userInfo.Policy.AuthorizedVols {
+		names = append(names, vol)
+	}
+	return names
+}
+
+// swiftContainerStat returns the object count and the bytes used of the container. The object count is
+// the inode count of the volume, which includes the directories made for the object keys.
+func (o *ObjectNode) swiftContainerStat(name string) (count, bytes uint64) {
+	stat, err := o.mc.ClientAPI().GetVolumeStat(name)
+	if err != nil {
+		log.LogWarnf("swiftContainerStat: get volume stat fail: volume(%v) err(%v)", name, err)
+		return
+	}
+	count = stat.InodeCount
+	if count > 0 {
+		// the root directory of the volume
+		count--
+	}
+	return count, stat.UsedSize
+}
+
+// Get auth token
+// API reference: https://docs.openstack.org/swift/latest/overview_auth.html
+// Notes: the TempAuth compatible auth, X-Auth-User is the access key of the user, or in the form of
+// account:access-key, and X-Auth-Key is the secret key of the user.
+func (o *ObjectNode) swiftAuthHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.swiftErrorResponse(w, r, err, errorCode)
+	}()
+
+	user, key := r.Header.Get(XAuthUser), r.Header.Get(XAuthKey)
+	if user == "" {
+		user, key = r.Header.Get(XStorageUser), r.Header.Get(XStoragePass)
+	}
+	if i := strings.LastIndex(user, ":"); i >= 0 {
+		user = user[i+1:]
+	}
+	if user == "" || key == "" {
+		errorCode = SwiftUnauthorized
+		return
+	}
+
+	var userInfo *proto.UserInfo
+	if userInfo, err = o.getUserInfoByAccessKeyV2(user); err != nil {
+		log.LogErrorf("swiftAuthHandler: get user info fail: requestID(%v) accessKey(%v) err(%v)",
+			GetRequestID(r), user, err)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(key), []byte(userInfo.SecretKey)) != 1 {
+		log.LogErrorf("swiftAuthHandler: key not match: requestID(%v) accessKey(%v)", GetRequestID(r), user)
+		errorCode = SwiftUnauthorized
+		return
+	}
+
+	ttl := o.swift.TokenTTL()
+	token := newSwiftToken(userInfo.AccessKey, userInfo.SecretKey, time.Now().Add(ttl))
+	w.Header().Set(XAuthToken, token)
+	w.Header().Set(XStorageToken, token)
+	w.Header().Set(XAuthTokenExpires, strconv.FormatInt(int64(ttl/time.Second), 10))
+	w.Header().Set(XStorageUrl, swiftStorageURL(r, userInfo.UserID))
+	w.Header().Set(ContentLength, "0")
+	w.WriteHeader(http.StatusOK)
+
+	log.LogInfof("Audit: swift auth: requestID(%v) remote(%v) uid(%v)", GetRequestID(r), getRequestIP(r), userInfo.UserID)
+}
+
+// Show account details and list containers
+// API reference: https://docs.openstack.org/api-ref/object-store/#show-account-details-and-list-containers
+func (o *ObjectNode) swiftListContainersHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.swiftErrorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	var userInfo *proto.UserInfo
+	if userInfo, err = o.getUserInfoByAccessKeyV2(param.AccessKey()); err != nil {
+		log.LogErrorf("swiftListContainersHandler: get user info fail: requestID(%v) accessKey(%v) err(%v)",
+			GetRequestID(r), param.AccessKey(), err)
+		return
+	}
+
+	var opt *swiftListOption
+	if opt, errorCode = parseSwiftListOption(r); errorCode != nil {
+		return
+	}
+	all := swiftContainerNames(userInfo)
+	names := opt.filterNames(all)
+
+	listing := &swiftAccountListing{Name: SwiftAccountPrefix + userInfo.UserID, Containers: make([]*swiftContainerEntry, 0, len(names))}
+	if opt.format != swiftListFormatPlain {
+		for _, name := range names {
+			entry := &swiftContainerEntry{Name: name}
+			entry.Count, entry.Bytes = o.swiftContainerStat(name)
+			if vol, err1 := o.getVol(name); err1 == nil {
+				entry.LastModified = vol.CreateTime().UTC().Format(swiftLastModifiedLayout)
+			}
+			listing.Containers = append(listing.Containers, entry)
+		}
+	}
+
+	w.Header().Set(XAccountContainerCount, strconv.Itoa(len(distinctNames(all))))
+	if fresh, err1 := o.mc.UserAPI().GetUserInfo(userInfo.UserID); err1 == nil {
+		setSwiftMetadataHeader(w.Header(), XAccountMetaPrefix, fresh.AccountMetadata)
+	}
+	if err = writeSwiftListing(w, opt.format, names, listing, listing.Containers); err != nil {
+		log.LogErrorf("swiftListContainersHandler: write listing fail: requestID(%v) uid(%v) err(%v)",
+			GetRequestID(r), userInfo.UserID, err)
+		err = nil
+	}
+}
+
+// distinctNames returns the sorted names without duplicates.
+func distinctNames(names []string) []string {
+	return (&swiftListOption{limit: uint64(len(names))}).filterNames(names)
+}
+
+// Show account metadata
+// API reference: https://docs.openstack.org/api-ref/object-store/#show-account-metadata
+func (o *ObjectNode) swiftHeadAccountHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.swiftErrorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	var userInfo *proto.UserInfo
+	if userInfo, err = o.mc.UserAPI().GetUserInfo(param.Requester()); err != nil {
+		log.LogErrorf("swiftHeadAccountHandler: get user info fail: requestID(%v) accessKey(%v) err(%v)",
+			GetRequestID(r), param.AccessKey(), err)
+		return
+	}
+
+	names := distinctNames(swiftContainerNames(userInfo))
+	var objects, bytes uint64
+	for _, name := range names {
+		count, used := o.swiftContainerStat(name)
+		objects += count
+		bytes += used
+	}
+	w.Header().Set(XAccountContainerCount, strconv.Itoa(len(names)))
+	w.Header().Set(XAccountObjectCount, strconv.FormatUint(objects, 10))
+	w.Header().Set(XAccountBytesUsed, strconv.FormatUint(bytes, 10))
+	setSwiftMetadataHeader(w.Header(), XAccountMetaPrefix, userInfo.AccountMetadata)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Create, update, or delete account metadata
+// API reference: https://docs.openstack.org/api-ref/object-store/#create-update-or-delete-account-metadata
+func (o *ObjectNode) swiftPostAccountHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.swiftErrorResponse(w, r, err, errorCode)
+	}()
+
+	requester := ParseRequestParam(r).Requester()
+	var userInfo *proto.UserInfo
+	if userInfo, err = o.mc.UserAPI().GetUserInfo(requester); err != nil {
+		log.LogErrorf("swiftPostAccountHandler: get user info fail: requestID(%v) uid(%v) err(%v)",
+			GetRequestID(r), requester, err)
+		return
+	}
+
+	metadata := updateSwiftMetadata(userInfo.AccountMetadata, r.Header, XAccountMetaPrefix, XRemoveAccountMetaPrefix)
+	if errorCode = validateSwiftMetadata(metadata); errorCode != nil {
+		return
+	}
+	param := &proto.UserAccountMetadataUpdateParam{UserID: userInfo.UserID, Metadata: metadata}
+	if _, err = o.mc.UserAPI().UpdateAccountMetadata(param, ""); err != nil {
+		log.LogErrorf("swiftPostAccountHandler: update account metadata fail: requestID(%v) uid(%v) err(%v)",
+			GetRequestID(r), userInfo.UserID, err)
+		return
+	}
+
+	log.LogInfof("Audit: swift post account: requestID(%v) uid(%v) metadata(%v)", GetRequestID(r), userInfo.UserID, metadata)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Show container details and list objects
+// API reference: https://docs.openstack.org/api-ref/object-store/#show-container-details-and-list-objects
+func (o *ObjectNode) swiftListObjectsHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.swiftErrorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("swiftListObjectsHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	// QPS and Concurrency Limit
+	rateLimit := o.AcquireRateLimiter()
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+		return
+	}
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+
+	var opt *swiftListOption
+	if opt, errorCode = parseSwiftListOption(r); errorCode != nil {
+		return
+	}
+	var result *ListFilesV1Result
+	if opt.limit > 0 {
+		listOpt := &ListFilesV1Option{
+			Prefix:    opt.prefix,
+			Delimiter: opt.delimiter,
+			Marker:    opt.marker,
+			MaxKeys:   opt.limit,
+		}
+		if result, err = vol.ListFilesV1(listOpt); err != nil {
+			log.LogErrorf("swiftListObjectsHandler: list files fail: requestID(%v) volume(%v) option(%+v) err(%v)",
+				GetRequestID(r), vol.Name(), listOpt, err)
+			return
+		}
+	} else {
+		result = &ListFilesV1Result{}
+	}
+
+	// the objects and the prefixes are merged in the order of the names
+	type item struct {
+		name  string
+		entry interface{}
+	}
+	items := make([]item, 0, len(result.Files)+len(result.CommonPrefixes))
+	for _, file := range result.Files {
+		items = append(items, item{name: file.Path, entry: &swiftObjectEntry{
+			Name:         file.Path,
+			Hash:         file.ETag,
+			Bytes:        file.Size,
+			ContentType:  file.MIMEType,
+			LastModified: file.ModifyTime.UTC().Format(swiftLastModifiedLayout),
+		}})
+	}
+	for _, prefix := range result.CommonPrefixes {
+		items = append(items, item{name: prefix, entry: &swiftSubdirEntry{NameAttr: prefix, Name: prefix, Subdir: prefix}})
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].name < items[j].name })
+
+	names := make([]string, 0, len(items))
+	listing := &swiftContainerListing{Name: vol.Name(), Entries: make([]interface{}, 0, len(items))}
+	for _, it := range items {
+		if opt.endMarker != "" && it.name >= opt.endMarker {
+			break
+		}
+		names = append(names, it.name)
+		listing.Entries = append(listing.Entries, it.entry)
+	}
+
+	if errorCode = o.setSwiftContainerHeader(w, vol); errorCode != nil {
+		return
+	}
+	if err = writeSwiftListing(w, opt.format, names, listing, listing.Entries); err != nil {
+		log.LogErrorf("swiftListObjectsHandler: write listing fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		err = nil
+	}
+}
+
+// setSwiftContainerHeader sets the stats and the metadata of the container to the response.
+func (o *ObjectNode) setSwiftContainerHeader(w http.ResponseWriter, vol *Volume) *ErrorCode {
+	metadata, err := loadSwiftContainerMeta(vol)
+	if err != nil {
+		log.LogErrorf("setSwiftContainerHeader: load metadata fail: volume(%v) err(%v)", vol.Name(), err)
+		return InternalErrorCode(err)
+	}
+	count, bytes := o.swiftContainerStat(vol.Name())
+	w.Header().Set(XContainerObjectCount, strconv.FormatUint(count, 10))
+	w.Header().Set(XContainerBytesUsed, strconv.FormatUint(bytes, 10))
+	w.Header().Set(XTimestamp, swiftTimestamp(vol.CreateTime()))
+	setSwiftMetadataHeader(w.Header(), XContainerMetaPrefix, metadata)
+	return nil
+}
+
+// Show container metadata
+// API reference: https://docs.openstack.org/api-ref/object-store/#show-container-metadata
+func (o *ObjectNode) swiftHeadContainerHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.swiftErrorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("swiftHeadContainerHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+	if errorCode = o.setSwiftContainerHeader(w, vol); errorCode != nil {
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Create container
+// API reference: https://docs.openstack.org/api-ref/object-store/#create-container
+func (o *ObjectNode) swiftPutContainerHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.swiftErrorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	bucket := param.Bucket()
+	if !IsValidBucketName(bucket, DefaultMinBucketLength, DefaultMaxBucketLength) {
+		errorCode = InvalidBucketName
+		return
+	}
+
+	var userInfo *proto.UserInfo
+	if userInfo, err = o.getUserInfoByAccessKeyV2(param.AccessKey()); err != nil {
+		log.LogErrorf("swiftPutContainerHandler: get user info fail: requestID(%v) accessKey(%v) err(%v)",
+			GetRequestID(r), param.AccessKey(), err)
+		return
+	}
+
+	// QPS and Concurrency Limit
+	rateLimit := o.AcquireRateLimiter()
+	if err = rateLimit.AcquireLimitResource(userInfo.UserID, param.apiName); err != nil {
+		return
+	}
+	defer rateLimit.ReleaseLimitResource(userInfo.UserID, param.apiName)
+
+	metadata := updateSwiftMetadata(nil, r.Header, XContainerMetaPrefix, XRemoveContainerMetaPrefix)
+	if errorCode = validateSwiftMetadata(metadata); errorCode != nil {
+		return
+	}
+
+	// the existing container of the user is updated with the metadata
+	statusCode := http.StatusCreated
+	vol, _ := o.vm.VolumeWithoutBlacklist(bucket, false)
+	if vol != nil {
+		if vol.GetOwner() != userInfo.UserID {
+			errorCode = BucketNotOwnedByYou
+			return
+		}
+		var current map[string]string
+		if current, err = loadSwiftContainerMeta(vol); err != nil {
+			log.LogErrorf("swiftPutContainerHandler: load metadata fail: requestID(%v) volume(%v) err(%v)",
+				GetRequestID(r), bucket, err)
+			return
+		}
+		metadata = updateSwiftMetadata(current, r.Header, XContainerMetaPrefix, XRemoveContainerMetaPrefix)
+		if errorCode = validateSwiftMetadata(metadata); errorCode != nil {
+			return
+		}
+		statusCode = http.StatusAccepted
+	} else {
+		if o.disableCreateBucketByS3 {
+			errorCode = DisableCreateBucketByS3
+			return
+		}
+		if err = o.mc.AdminAPI().CreateDefaultVolume(bucket, userInfo.UserID); err != nil {
+			log.LogErrorf("swiftPutContainerHandler: create volume fail: requestID(%v) volume(%v) uid(%v) err(%v)",
+				GetRequestID(r), bucket, userInfo.UserID, err)
+			if err == proto.ErrDuplicateVol {
+				err = DuplicateVol
+			} else {
+				err = InternalErrorCode(err)
+			}
+			return
+		}
+		if vol, err = o.vm.VolumeWithoutBlacklist(bucket, true); err != nil {
+			log.LogErrorf("swiftPutContainerHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+				GetRequestID(r), bucket, err)
+			return
+		}
+	}
+
+	if len(metadata) > 0 || statusCode == http.StatusAccepted {
+		if err = storeSwiftContainerMeta(vol, metadata); err != nil {
+			log.LogErrorf("swiftPutContainerHandler: store metadata fail: requestID(%v) volume(%v) err(%v)",
+				GetRequestID(r), bucket, err)
+			return
+		}
+	}
+
+	log.LogInfof("Audit: swift put container: requestID(%v) remote(%v) volume(%v) uid(%v) status(%v)",
+		GetRequestID(r), getRequestIP(r), bucket, userInfo.UserID, statusCode)
+	w.WriteHeader(statusCode)
+}
+
+// Create, update, or delete container metadata
+// API reference: https://docs.openstack.org/api-ref/object-store/#create-update-or-delete-container-metadata
+func (o *ObjectNode) swiftPostContainerHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.swiftErrorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("swiftPostContainerHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var metadata map[string]string
+	if metadata, err = loadSwiftContainerMeta(vol); err != nil {
+		log.LogErrorf("swiftPostContainerHandler: load metadata fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	metadata = updateSwiftMetadata(metadata, r.Header, XContainerMetaPrefix, XRemoveContainerMetaPrefix)
+	if errorCode = validateSwiftMetadata(metadata); errorCode != nil {
+		return
+	}
+	if err = storeSwiftContainerMeta(vol, metadata); err != nil {
+		log.LogErrorf("swiftPostContainerHandler: store metadata fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+
+	log.LogInfof("Audit: swift post container: requestID(%v) volume(%v) metadata(%v)", GetRequestID(r), vol.Name(), metadata)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Delete container
+// API reference: https://docs.openstack.org/api-ref/object-store/#delete-container
+func (o *ObjectNode) swiftDeleteContainerHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.swiftErrorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	bucket := param.Bucket()
+	var vol *Volume
+	if vol, err = o.getVol(bucket); err != nil {
+		log.LogErrorf("swiftDeleteContainerHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), bucket, err)
+		return
+	}
+
+	// QPS and Concurrency Limit
+	rateLimit := o.AcquireRateLimiter()
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+		return
+	}
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+
+	if !vol.IsEmpty() {
+		errorCode = BucketNotEmpty
+		return
+	}
+
+	var authKey string
+	if authKey, err = calculateAuthKey(param.Requester()); err != nil {
+		log.LogErrorf("swiftDeleteContainerHandler: calculate authKey fail: requestID(%v) volume(%v) uid(%v) err(%v)",
+			GetRequestID(r), bucket, param.Requester(), err)
+		return
+	}
+	if err = o.mc.AdminAPI().DeleteVolume(bucket, authKey); err != nil {
+		log.LogErrorf("swiftDeleteContainerHandler: delete volume fail: requestID(%v) volume(%v) uid(%v) err(%v)",
+			GetRequestID(r), bucket, param.Requester(), err)
+		return
+	}
+	log.LogInfof("swiftDeleteContainerHandler: delete container success: requestID(%v) volume(%v) uid(%v)",
+		GetRequestID(r), bucket, param.Requester())
+
+	o.vm.Release(bucket)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,448 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.openstack.org/swift/latest/overview_large_objects.html
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	// swiftMaxManifestSize is the max size of the manifest of the static large object.
+	swiftMaxManifestSize = 2 << 20
+	// swiftMaxDLOSegments is the max number of the segments of the dynamic large object.
+	swiftMaxDLOSegments = 10000
+
+	ValueManifestPut    = "put"
+	ValueManifestGet    = "get"
+	ValueManifestDelete = "delete"
+)
+
+// swiftSLOSegmentSpec is a segment in the manifest of the request to create the static large object,
+// the etag and the size are checked if they are specified.
+type swiftSLOSegmentSpec struct {
+	Path      string  `json:"path"`
+	ETag      *string `json:"etag"`
+	SizeBytes *int64  `json:"size_bytes"`
+}
+
+// swiftSegment is a segment in the stored manifest of the static large object, and the resolved
+// segment of the dynamic large object. The name is in the form of /container/object.
+type swiftSegment struct {
+	Name         string `json:"name"`
+	Hash         string `json:"hash"`
+	Bytes        int64  `json:"bytes"`
+	ContentType  string `json:"content_type,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// swiftSLOMeta is kept in the xattr of the manifest object of the static large object, so the size
+// and the etag of the large object are returned without reading the manifest.
+type swiftSLOMeta struct {
+	Size     int64  `json:"size"`
+	ETag     string `json:"etag"`
+	Segments int    `json:"segments"`
+}
+
+func newSwiftSLOMeta(segments []*swiftSegment) *swiftSLOMeta {
+	meta := &swiftSLOMeta{Segments: len(segments)}
+	hashes := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		meta.Size += segment.Bytes
+		hashes = append(hashes, segment.Hash)
+	}
+	meta.ETag = swiftLargeObjectETag(hashes)
+	return meta
+}
+
+func parseSwiftSLOMeta(raw []byte) (*swiftSLOMeta, error) {
+	meta := &swiftSLOMeta{}
+	if err := json.Unmarshal(raw, meta); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+func (m *swiftSLOMeta) Encode() string {
+	data, _ := json.Marshal(m)
+	return string(data)
+}
+
+// swiftLargeObjectETag returns the etag of the large object, which is the md5 of the concatenated
+// etags of the segments.
+func swiftLargeObjectETag(hashes []string) string {
+	sum := md5.Sum([]byte(strings.Join(hashes, "")))
+	return hex.EncodeToString(sum[:])
+}
+
+// splitSwiftPath splits the path in the form of [/]container/object.
+func splitSwiftPath(path string) (container, object string, ok bool) {
+	path = strings.TrimPrefix(path, "/")
+	i := strings.Index(path, "/")
+	if i <= 0 || i == len(path)-1 {
+		return "", "", false
+	}
+	return path[:i], path[i+1:], true
+}
+
+func invalidSwiftManifest(format string, args ...interface{}) *ErrorCode {
+	ec := InvalidSwiftManifest.Copy()
+	ec.ErrorMessage = fmt.Sprintf(format, args...)
+	return ec
+}
+
+// parseSLOManifest parses the manifest in the request to create the static large object.
+func parseSLOManifest(raw []byte, maxSegments int) ([]*swiftSLOSegmentSpec, error) {
+	var specs []*swiftSLOSegmentSpec
+	if err := json.Unmarshal(raw, &specs); err != nil {
+		return nil, invalidSwiftManifest("Manifest must be valid JSON.")
+	}
+	if len(specs) == 0 {
+		return nil, invalidSwiftManifest("Manifest must have at least one segment.")
+	}
+	if len(specs) > maxSegments {
+		return nil, invalidSwiftManifest("Too many segments; max %d.", maxSegments)
+	}
+	for i, spec := range specs {
+		if spec == nil {
+			return nil, invalidSwiftManifest("Index %d: invalid segment.", i)
+		}
+		if _, _, ok := splitSwiftPath(spec.Path); !ok {
+			return nil, invalidSwiftManifest("Index %d: path does not refer to an object. Path must be of the form /container/object.", i)
+		}
+		if spec.SizeBytes != nil && *spec.SizeBytes < 0 {
+			return nil, invalidSwiftManifest("Index %d: invalid size_bytes.", i)
+		}
+	}
+	return specs, nil
+}
+
+// parseDLOManifest parses the value of X-Object-Manifest in the form of container/prefix, which are
+// url-encoded.
+func parseDLOManifest(value string) (container, prefix string, err error) {
+	i := strings.Index(value, "/")
+	if i <= 0 {
+		return "", "", invalidSwiftManifest("X-Object-Manifest must be in the format container/prefix.")
+	}
+	if container, err = url.PathUnescape(value[:i]); err != nil {
+		return "", "", invalidSwiftManifest("X-Object-Manifest must be url-encoded.")
+	}
+	if prefix, err = url.PathUnescape(value[i+1:]); err != nil {
+		return "", "", invalidSwiftManifest("X-Object-Manifest must be url-encoded.")
+	}
+	return container, prefix, nil
+}
+
+// forEachSegmentRange calls fn with the range of each segment which overlaps the range of the large
+// object, the offset passed to fn is relative to the segment.
+func forEachSegmentRange(sizes []int64, offset, length uint64, fn func(i int, offset, length uint64) error) error {
+	var start uint64
+	end := offset + length
+	for i, size := range sizes {
+		segEnd := start + uint64(size)
+		if size > 0 && segEnd > offset && start < end {
+			from, to := offset, end
+			if from < start {
+				from = start
+			}
+			if to > segEnd {
+				to = segEnd
+			}
+			if err := fn(i, from-start, to-from); err != nil {
+				return err
+			}
+		}
+		if segEnd >= end {
+			break
+		}
+		start = segEnd
+	}
+	return nil
+}
+
+func isSwiftLargeObject(xattr *proto.XAttrInfo) bool {
+	return xattr != nil && (len(xattr.Get(XAttrKeyOSSSwiftSLO)) > 0 || len(xattr.Get(XAttrKeyOSSSwiftDLO)) > 0)
+}
+
+// swiftPutStaticLargeObject checks the segments in the manifest of the request and creates the
+// static large object with the normalized manifest as the content.
+func (o *ObjectNode) swiftPutStaticLargeObject(r *http.Request, userInfo *proto.UserInfo, vol *Volume,
+	path string, opt *PutFileOption) (info *FSFileInfo, meta *swiftSLOMeta, err error) {
+	var raw []byte
+	if raw, err = io.ReadAll(io.LimitReader(r.Body, swiftMaxManifestSize+1)); err != nil {
+		return
+	}
+	if len(raw) > swiftMaxManifestSize {
+		return nil, nil, invalidSwiftManifest("Manifest is too large; max %d bytes.", swiftMaxManifestSize)
+	}
+	var specs []*swiftSLOSegmentSpec
+	if specs, err = parseSLOManifest(raw, o.swift.MaxManifestSegments()); err != nil {
+		return
+	}
+
+	segments := make([]*swiftSegment, 0, len(specs))
+	for i, spec := range specs {
+		container, object, _ := splitSwiftPath(spec.Path)
+		if !swiftContainerAllowed(userInfo.Policy, container, false) {
+			return nil, nil, AccessDenied
+		}
+		var segVol *Volume
+		if segVol, err = o.getVol(container); err != nil {
+			return nil, nil, invalidSwiftManifest("Index %d: container %s does not exist.", i, container)
+		}
+		segInfo, segXattr, err1 := segVol.ObjectMeta(object)
+		if err1 == syscall.ENOENT {
+			return nil, nil, invalidSwiftManifest("Index %d: segment %s does not exist.", i, spec.Path)
+		}
+		if err1 != nil {
+			return nil, nil, err1
+		}
+		if segInfo.Mode.IsDir() || isSwiftLargeObject(segXattr) {
+			return nil, nil, invalidSwiftManifest("Index %d: segment %s is not a plain object.", i, spec.Path)
+		}
+		if spec.ETag != nil && *spec.ETag != "" && strings.Trim(*spec.ETag, "\"") != segInfo.ETag {
+			return nil, nil, invalidSwiftManifest("Index %d: etag of segment %s does not match.", i, spec.Path)
+		}
+		if spec.SizeBytes != nil && *spec.SizeBytes != segInfo.Size {
+			return nil, nil, invalidSwiftManifest("Index %d: size of segment %s does not match.", i, spec.Path)
+		}
+		segments = append(segments, &swiftSegment{
+			Name:         "/" + container + "/" + object,
+			Hash:         segInfo.ETag,
+			Bytes:        segInfo.Size,
+			ContentType:  segInfo.MIMEType,
+			LastModified: segInfo.ModifyTime.UTC().Format(swiftLastModifiedLayout),
+		})
+	}
+
+	var data []byte
+	if data, err = json.Marshal(segments); err != nil {
+		return
+	}
+	meta = newSwiftSLOMeta(segments)
+	if opt.Metadata == nil {
+		opt.Metadata = make(map[string]string)
+	}
+	opt.Metadata[XAttrKeyOSSSwiftSLO] = meta.Encode()
+	if err = vol.checkBucketQuota(uint64(len(data)), 1); err != nil {
+		return
+	}
+	if info, err = vol.PutObject(path, bytes.NewReader(data), opt); err != nil {
+		return
+	}
+	return info, meta, nil
+}
+
+// swiftLargeObject is the large object with the resolved segments.
+type swiftLargeObject struct {
+	static   bool
+	manifest string // the value of X-Object-Manifest of the dynamic large object
+	size     int64
+	etag     string
+	segments []*swiftSegment
+}
+
+func (lo *swiftLargeObject) sizes() []int64 {
+	sizes := make([]int64, 0, len(lo.segments))
+	for _, segment := range lo.segments {
+		sizes = append(sizes, segment.Bytes)
+	}
+	return sizes
+}
+
+// loadSwiftLargeObject returns the large object of the manifest object, or nil if the object is not a
+// manifest. The segments of the static large object are only loaded if withSegments is true.
+func (o *ObjectNode) loadSwiftLargeObject(userInfo *proto.UserInfo, vol *Volume, info *FSFileInfo,
+	xattr *proto.XAttrInfo, withSegments bool) (lo *swiftLargeObject, err error) {
+	if xattr == nil {
+		return nil, nil
+	}
+	if raw := xattr.Get(XAttrKeyOSSSwiftSLO); len(raw) > 0 {
+		var meta *swiftSLOMeta
+		if meta, err = parseSwiftSLOMeta(raw); err != nil {
+			return
+		}
+		lo = &swiftLargeObject{static: true, size: meta.Size, etag: meta.ETag}
+		if !withSegments {
+			return
+		}
+		var data []byte
+		if data, err = o.readSwiftManifest(vol, info, xattr); err != nil {
+			return
+		}
+		if err = json.Unmarshal(data, &lo.segments); err != nil {
+			return
+		}
+		return
+	}
+	value := string(xattr.Get(XAttrKeyOSSSwiftDLO))
+	if value == "" {
+		return nil, nil
+	}
+	container, prefix, err := parseDLOManifest(value)
+	if err != nil {
+		return
+	}
+	if !swiftContainerAllowed(userInfo.Policy, container, false) {
+		return nil, AccessDenied
+	}
+	var segVol *Volume
+	if segVol, err = o.getVol(container); err != nil {
+		return
+	}
+	lo = &swiftLargeObject{manifest: value}
+	hashes := make([]string, 0)
+	marker := ""
+	for {
+		var result *ListFilesV1Result
+		opt := &ListFilesV1Option{Prefix: prefix, Marker: marker, MaxKeys: 1000, OnlyObject: true}
+		if result, err = segVol.ListFilesV1(opt); err != nil {
+			return
+		}
+		for _, file := range result.Files {
+			if len(lo.segments) >= swiftMaxDLOSegments {
+				return nil, invalidSwiftManifest("Too many segments; max %d.", swiftMaxDLOSegments)
+			}
+			lo.segments = append(lo.segments, &swiftSegment{
+				Name:  "/" + container + "/" + file.Path,
+				Bytes: file.Size,
+			})
+			lo.size += file.Size
+			hashes = append(hashes, file.ETag)
+		}
+		// the next listing excludes the marker, so it starts after the last listed segment
+		if !result.Truncated || len(result.Files) == 0 {
+			break
+		}
+		marker = result.Files[len(result.Files)-1].Path
+	}
+	lo.etag = swiftLargeObjectETag(hashes)
+	return
+}
+
+// readSwiftManifest reads the content of the manifest object of the static large object.
+func (o *ObjectNode) readSwiftManifest(vol *Volume, info *FSFileInfo, xattr *proto.XAttrInfo) ([]byte, error) {
+	if info.Size > swiftMaxManifestSize {
+		return nil, invalidSwiftManifest("Manifest is too large; max %d bytes.", swiftMaxManifestSize)
+	}
+	sse, err := loadSSEMeta(xattr)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := objectDataKey(sse, nil)
+	if err != nil {
+		return nil, err
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, info.Size))
+	var writer io.Writer = buf
+	if sse != nil {
+		writer = sse.decryptWriter(writer, dataKey, 0)
+	}
+	size := uint64(info.Size)
+	if err = vol.readFile(info.Inode, size, info.Path, writer, 0, size, info.StorageClass); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// readSwiftSegment reads the range of the segment, the segment of the static large object is checked
+// with the etag and the size in the manifest.
+func (o *ObjectNode) readSwiftSegment(w io.Writer, userInfo *proto.UserInfo, segment *swiftSegment, static bool,
+	offset, length uint64) error {
+	container, object, ok := splitSwiftPath(segment.Name)
+	if !ok {
+		return invalidSwiftManifest("Invalid segment %s.", segment.Name)
+	}
+	if !swiftContainerAllowed(userInfo.Policy, container, false) {
+		return AccessDenied
+	}
+	vol, err := o.getVol(container)
+	if err != nil {
+		return err
+	}
+	info, xattr, err := vol.ObjectMeta(object)
+	if err != nil {
+		return err
+	}
+	if static && (info.ETag != segment.Hash || info.Size != segment.Bytes) {
+		log.LogErrorf("readSwiftSegment: segment mismatch: volume(%v) path(%v) etag(%v) size(%v) manifest(%+v)",
+			vol.Name(), object, info.ETag, info.Size, segment)
+		return SwiftSegmentMismatch
+	}
+	sse, err := loadSSEMeta(xattr)
+	if err != nil {
+		return err
+	}
+	dataKey, err := objectDataKey(sse, nil)
+	if err != nil {
+		return err
+	}
+	if sse != nil {
+		w = sse.decryptWriter(w, dataKey, offset)
+	}
+	inode, storageClass := info.Inode, info.StorageClass
+	if restore := restoredCopy(xattr); restore != nil && proto.IsStorageClassBlobStore(storageClass) {
+		inode, storageClass = restore.Inode, restore.StorageClass
+	}
+	return vol.readFile(inode, uint64(info.Size), object, w, offset, length, storageClass)
+}
+
+// deleteSwiftSegments deletes the segments of the static large object, and returns the number of the
+// deleted and not found segments, and the errors of the other segments.
+func (o *ObjectNode) deleteSwiftSegments(userInfo *proto.UserInfo, segments []*swiftSegment) (deleted, notFound int, errs [][]string) {
+	for _, segment := range segments {
+		container, object, ok := splitSwiftPath(segment.Name)
+		if !ok {
+			errs = append(errs, []string{segment.Name, "400 Bad Request"})
+			continue
+		}
+		if !swiftContainerAllowed(userInfo.Policy, container, true) {
+			errs = append(errs, []string{segment.Name, "403 Forbidden"})
+			continue
+		}
+		vol, err := o.getVol(container)
+		if err != nil {
+			notFound++
+			continue
+		}
+		if _, _, err = vol.ObjectMeta(object); err == syscall.ENOENT {
+			notFound++
+			continue
+		}
+		if err == nil {
+			_, _, err = vol.DeleteObjectVersion(object, "", false)
+		}
+		if err != nil {
+			log.LogErrorf("deleteSwiftSegments: delete segment fail: volume(%v) path(%v) err(%v)", container, object, err)
+			errs = append(errs, []string{segment.Name, "500 Internal Error"})
+			continue
+		}
+		deleted++
+	}
+	return
+}
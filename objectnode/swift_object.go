@@ -0,0 +1,617 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// setSwiftObjectHeader sets the headers of the object, the size and the etag of the large object are
+// the ones of the segments.
+func setSwiftObjectHeader(header http.Header, info *FSFileInfo, lo *swiftLargeObject) {
+	header.Set(AcceptRanges, ValueAcceptRanges)
+	header.Set(LastModified, formatTimeRFC1123(info.ModifyTime))
+	header.Set(XTimestamp, swiftTimestamp(info.ModifyTime))
+	if len(info.MIMEType) > 0 {
+		header.Set(ContentType, info.MIMEType)
+	} else {
+		header.Set(ContentType, ValueContentTypeStream)
+	}
+	etag := info.ETag
+	if lo != nil {
+		// the etag of the large object is quoted as Swift does
+		etag = wrapUnescapedQuot(lo.etag)
+		if lo.static {
+			header.Set(XStaticLargeObject, valueSwiftTrue)
+		} else {
+			header.Set(XObjectManifest, lo.manifest)
+		}
+	}
+	header[ETag] = []string{etag}
+	setSwiftMetadataHeader(header, XObjectMetaPrefix, info.Metadata)
+}
+
+// Create or replace object
+// API reference: https://docs.openstack.org/api-ref/object-store/#create-or-replace-object
+func (o *ObjectNode) swiftPutObjectHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.swiftErrorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if len(param.Object()) > MaxKeyLength {
+		errorCode = KeyTooLong
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("swiftPutObjectHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+	var userInfo *proto.UserInfo
+	if userInfo, err = o.getUserInfoByAccessKeyV2(param.AccessKey()); err != nil {
+		log.LogErrorf("swiftPutObjectHandler: get user info fail: requestID(%v) accessKey(%v) err(%v)",
+			GetRequestID(r), param.AccessKey(), err)
+		return
+	}
+
+	// QPS and Concurrency Limit
+	rateLimit := o.AcquireRateLimiter()
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+		return
+	}
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+
+	metadata := updateSwiftMetadata(nil, r.Header, XObjectMetaPrefix, "")
+	if errorCode = validateSwiftMetadata(metadata); errorCode != nil {
+		return
+	}
+	manifestPut := r.URL.Query().Get(ParamMultipartManifest) == ValueManifestPut
+	if manifest := r.Header.Get(XObjectManifest); manifest != "" {
+		if manifestPut {
+			errorCode = InvalidArgument
+			return
+		}
+		if _, _, err = parseDLOManifest(manifest); err != nil {
+			return
+		}
+		metadata[XAttrKeyOSSSwiftDLO] = manifest
+	}
+
+	objectLock, err := vol.metaLoader.loadObjectLock()
+	if err != nil {
+		log.LogErrorf("swiftPutObjectHandler: load object lock fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	var sse *SSEOption
+	if sse, err = parseWriteSSEOption(r.Header, vol); err != nil {
+		log.LogErrorf("swiftPutObjectHandler: parse sse fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+	opt := &PutFileOption{
+		MIMEType:   r.Header.Get(ContentType),
+		Metadata:   metadata,
+		ObjectLock: objectLock,
+		SSE:        sse,
+	}
+
+	log.LogInfof("Audit: swift put object: requestID(%v) remote(%v) volume(%v) path(%v) manifest(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name(), param.Object(), manifestPut)
+
+	var info *FSFileInfo
+	if manifestPut {
+		var meta *swiftSLOMeta
+		if info, meta, err = o.swiftPutStaticLargeObject(r, userInfo, vol, param.Object(), opt); err != nil {
+			log.LogErrorf("swiftPutObjectHandler: put static large object fail: requestID(%v) volume(%v) path(%v) err(%v)",
+				GetRequestID(r), vol.Name(), param.Object(), err)
+			err = handlePutObjectErr(err)
+			return
+		}
+		w.Header()[ETag] = []string{wrapUnescapedQuot(meta.ETag)}
+	} else {
+		length := GetContentLength(r)
+		if length < 0 {
+			errorCode = MissingContentLength
+			return
+		}
+		if length > SinglePutLimit {
+			errorCode = EntityTooLarge
+			return
+		}
+		var reader io.Reader = r.Body
+		if length > DefaultFlowLimitSize {
+			reader = rateLimit.GetReader(vol.owner, param.apiName, r.Body)
+		}
+		if err = vol.checkBucketQuota(uint64(length), 1); err != nil {
+			return
+		}
+		if info, err = vol.PutObject(param.Object(), reader, opt); err != nil {
+			log.LogErrorf("swiftPutObjectHandler: put object fail: requestID(%v) volume(%v) path(%v) err(%v)",
+				GetRequestID(r), vol.Name(), param.Object(), err)
+			err = handlePutObjectErr(err)
+			return
+		}
+		// the object is checked with the md5 in the Etag header of the request
+		if etag := strings.Trim(r.Header.Get(ETag), "\""); etag != "" && etag != info.ETag {
+			log.LogErrorf("swiftPutObjectHandler: etag validate fail: requestID(%v) volume(%v) path(%v) requestETag(%v) serverETag(%v)",
+				GetRequestID(r), vol.Name(), param.Object(), etag, info.ETag)
+			errorCode = BadDigest.Copy()
+			errorCode.StatusCode = http.StatusUnprocessableEntity
+			return
+		}
+		w.Header()[ETag] = []string{info.ETag}
+	}
+
+	w.Header().Set(LastModified, formatTimeRFC1123(info.ModifyTime))
+	w.Header().Set(ContentLength, "0")
+	w.WriteHeader(http.StatusCreated)
+
+	o.notify(param, vol, EventObjectCreatedPut, &notificationObject{
+		Key:       param.Object(),
+		Size:      info.Size,
+		ETag:      info.ETag,
+		VersionID: info.VersionId,
+	})
+}
+
+// Get object content and metadata
+// API reference: https://docs.openstack.org/api-ref/object-store/#get-object-content-and-metadata
+func (o *ObjectNode) swiftGetObjectHandler(w http.ResponseWriter, r *http.Request) {
+	o.swiftGetObject(w, r, true)
+}
+
+// Show object metadata
+// API reference: https://docs.openstack.org/api-ref/object-store/#show-object-metadata
+func (o *ObjectNode) swiftHeadObjectHandler(w http.ResponseWriter, r *http.Request) {
+	o.swiftGetObject(w, r, false)
+}
+
+func (o *ObjectNode) swiftGetObject(w http.ResponseWriter, r *http.Request, withBody bool) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.swiftErrorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("swiftGetObject: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+	var userInfo *proto.UserInfo
+	if userInfo, err = o.getUserInfoByAccessKeyV2(param.AccessKey()); err != nil {
+		log.LogErrorf("swiftGetObject: get user info fail: requestID(%v) accessKey(%v) err(%v)",
+			GetRequestID(r), param.AccessKey(), err)
+		return
+	}
+
+	// QPS and Concurrency Limit
+	rateLimit := o.AcquireRateLimiter()
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+		return
+	}
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+
+	info, xattr, err := vol.ObjectMeta(param.Object())
+	if err != nil {
+		log.LogErrorf("swiftGetObject: get object meta fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		if err == syscall.ENOENT {
+			errorCode = NoSuchKey
+		}
+		return
+	}
+
+	// the manifest of the static large object is returned as is with multipart-manifest=get
+	manifestGet := r.URL.Query().Get(ParamMultipartManifest) == ValueManifestGet
+	var lo *swiftLargeObject
+	if !manifestGet {
+		if lo, err = o.loadSwiftLargeObject(userInfo, vol, info, xattr, withBody); err != nil {
+			log.LogErrorf("swiftGetObject: load large object fail: requestID(%v) volume(%v) path(%v) err(%v)",
+				GetRequestID(r), vol.Name(), param.Object(), err)
+			return
+		}
+	}
+
+	// the preconditions are checked with the etag and the size of the large object
+	objectInfo := *info
+	if lo != nil {
+		objectInfo.ETag, objectInfo.Size = lo.etag, lo.size
+	}
+	if errorCode = CheckConditionInHeader(r, &objectInfo); errorCode != nil {
+		return
+	}
+	setSwiftObjectHeader(w.Header(), info, lo)
+	if manifestGet && len(xattr.Get(XAttrKeyOSSSwiftSLO)) > 0 {
+		w.Header().Set(ContentType, ValueContentTypeJSON+swiftContentTypeSuffix)
+	}
+
+	// only the single range is supported, the Range header with multiple ranges is ignored
+	size := uint64(objectInfo.Size)
+	offset, length, statusCode := uint64(0), size, http.StatusOK
+	if rangeOpt := r.Header.Get(Range); rangeOpt != "" && !info.Mode.IsDir() {
+		if specs, err1 := parseByteRangeSpecs(rangeOpt); err1 == nil && len(specs) == 1 {
+			ranges := resolveByteRanges(specs, size)
+			if len(ranges) == 0 {
+				w.Header().Set(ContentRange, "bytes */"+strconv.FormatUint(size, 10))
+				errorCode = InvalidRange
+				return
+			}
+			offset, length, statusCode = ranges[0].start, ranges[0].length, http.StatusPartialContent
+			w.Header().Set(ContentRange, ranges[0].contentRange(size))
+		}
+	}
+	if info.Mode.IsDir() {
+		length = 0
+	}
+	w.Header().Set(ContentLength, strconv.FormatUint(length, 10))
+	if !withBody || length == 0 {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	var writer io.Writer = w
+	if length > DefaultFlowLimitSize {
+		writer = rateLimit.GetResponseWriter(vol.owner, param.apiName, w)
+	}
+	if lo != nil {
+		w.WriteHeader(statusCode)
+		err = forEachSegmentRange(lo.sizes(), offset, length, func(i int, offset, length uint64) error {
+			return o.readSwiftSegment(writer, userInfo, lo.segments[i], lo.static, offset, length)
+		})
+		if err != nil {
+			log.LogErrorf("swiftGetObject: read segments fail: requestID(%v) volume(%v) path(%v) offset(%v) size(%v) err(%v)",
+				GetRequestID(r), vol.Name(), param.Object(), offset, length, err)
+			// the response has been started
+			err = nil
+		}
+		return
+	}
+
+	sse, err := loadSSEMeta(xattr)
+	if err != nil {
+		return
+	}
+	var dataKey []byte
+	if dataKey, err = objectDataKey(sse, nil); err != nil {
+		return
+	}
+	if sse != nil {
+		writer = sse.decryptWriter(writer, dataKey, offset)
+	}
+	inode, storageClass := info.Inode, info.StorageClass
+	if restore := restoredCopy(xattr); restore != nil && proto.IsStorageClassBlobStore(storageClass) {
+		inode, storageClass = restore.Inode, restore.StorageClass
+	}
+	w.WriteHeader(statusCode)
+	if err = vol.readFile(inode, uint64(info.Size), param.Object(), writer, offset, length, storageClass); err != nil {
+		log.LogErrorf("swiftGetObject: read file fail: requestID(%v) volume(%v) path(%v) offset(%v) size(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), offset, length, err)
+		err = nil
+	}
+}
+
+// Create or update object metadata
+// API reference: https://docs.openstack.org/api-ref/object-store/#create-or-update-object-metadata
+// Notes: the user metadata of the object is replaced by the metadata of the request.
+func (o *ObjectNode) swiftPostObjectHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.swiftErrorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("swiftPostObjectHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+	if _, _, err = vol.ObjectMeta(param.Object()); err != nil {
+		log.LogErrorf("swiftPostObjectHandler: get object meta fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+
+	metadata := updateSwiftMetadata(nil, r.Header, XObjectMetaPrefix, "")
+	if errorCode = validateSwiftMetadata(metadata); errorCode != nil {
+		return
+	}
+	var keys []string
+	if keys, err = vol.ListXAttrs(param.Object()); err != nil {
+		log.LogErrorf("swiftPostObjectHandler: list xattrs fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+	for _, key := range keys {
+		if _, ok := metadata[key]; ok || strings.HasPrefix(key, "oss:") {
+			continue
+		}
+		if err = vol.DeleteXAttr(param.Object(), key); err != nil {
+			log.LogErrorf("swiftPostObjectHandler: delete xattr fail: requestID(%v) volume(%v) path(%v) key(%v) err(%v)",
+				GetRequestID(r), vol.Name(), param.Object(), key, err)
+			return
+		}
+	}
+	if contentType := r.Header.Get(ContentType); contentType != "" {
+		metadata[XAttrKeyOSSMIME] = contentType
+	}
+	for key, value := range metadata {
+		if err = vol.SetXAttr(param.Object(), key, []byte(value), false); err != nil {
+			log.LogErrorf("swiftPostObjectHandler: set xattr fail: requestID(%v) volume(%v) path(%v) key(%v) err(%v)",
+				GetRequestID(r), vol.Name(), param.Object(), key, err)
+			return
+		}
+	}
+
+	log.LogInfof("Audit: swift post object: requestID(%v) volume(%v) path(%v) metadata(%v)",
+		GetRequestID(r), vol.Name(), param.Object(), metadata)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// swiftBulkDeleteResult is the result of deleting the static large object with its segments.
+type swiftBulkDeleteResult struct {
+	NumberDeleted  int        `json:"Number Deleted"`
+	NumberNotFound int        `json:"Number Not Found"`
+	ResponseStatus string     `json:"Response Status"`
+	ResponseBody   string     `json:"Response Body"`
+	Errors         [][]string `json:"Errors"`
+}
+
+// Delete object
+// API reference: https://docs.openstack.org/api-ref/object-store/#delete-object
+func (o *ObjectNode) swiftDeleteObjectHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.swiftErrorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("swiftDeleteObjectHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	// QPS and Concurrency Limit
+	rateLimit := o.AcquireRateLimiter()
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+		return
+	}
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+
+	info, xattr, err := vol.ObjectMeta(param.Object())
+	if err != nil {
+		log.LogErrorf("swiftDeleteObjectHandler: get object meta fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+
+	// the segments are deleted with the manifest of the static large object by multipart-manifest=delete
+	if r.URL.Query().Get(ParamMultipartManifest) == ValueManifestDelete && len(xattr.Get(XAttrKeyOSSSwiftSLO)) > 0 {
+		var userInfo *proto.UserInfo
+		if userInfo, err = o.getUserInfoByAccessKeyV2(param.AccessKey()); err != nil {
+			return
+		}
+		var lo *swiftLargeObject
+		if lo, err = o.loadSwiftLargeObject(userInfo, vol, info, xattr, true); err != nil {
+			log.LogErrorf("swiftDeleteObjectHandler: load large object fail: requestID(%v) volume(%v) path(%v) err(%v)",
+				GetRequestID(r), vol.Name(), param.Object(), err)
+			return
+		}
+		result := &swiftBulkDeleteResult{Errors: make([][]string, 0)}
+		deleted, notFound, errs := o.deleteSwiftSegments(userInfo, lo.segments)
+		result.NumberDeleted, result.NumberNotFound = deleted, notFound
+		result.Errors = append(result.Errors, errs...)
+		if len(result.Errors) == 0 {
+			if _, _, err = vol.DeleteObjectVersion(param.Object(), "", false); err != nil {
+				log.LogErrorf("swiftDeleteObjectHandler: delete manifest fail: requestID(%v) volume(%v) path(%v) err(%v)",
+					GetRequestID(r), vol.Name(), param.Object(), err)
+				result.Errors = append(result.Errors, []string{"/" + vol.Name() + "/" + param.Object(), "500 Internal Error"})
+				err = nil
+			} else {
+				result.NumberDeleted++
+			}
+		}
+		result.ResponseStatus = "200 OK"
+		if len(result.Errors) > 0 {
+			result.ResponseStatus = "400 Bad Request"
+		}
+		var data []byte
+		if data, err = json.Marshal(result); err != nil {
+			return
+		}
+		log.LogInfof("Audit: swift delete static large object: requestID(%v) volume(%v) path(%v) result(%+v)",
+			GetRequestID(r), vol.Name(), param.Object(), result)
+		writeResponse(w, http.StatusOK, data, ValueContentTypeJSON+swiftContentTypeSuffix)
+		return
+	}
+
+	if _, _, err = vol.DeleteObjectVersion(param.Object(), "", false); err != nil {
+		log.LogErrorf("swiftDeleteObjectHandler: delete object fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+	log.LogInfof("Audit: swift delete object: requestID(%v) remote(%v) volume(%v) path(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name(), param.Object())
+	w.WriteHeader(http.StatusNoContent)
+
+	o.notify(param, vol, EventObjectRemovedDelete, &notificationObject{Key: param.Object()})
+}
+
+// Copy object
+// API reference: https://docs.openstack.org/api-ref/object-store/#copy-object
+// Notes: the object is copied by PUT with X-Copy-From, or by COPY with Destination, both are in the
+// form of container/object. The manifest of the large object is copied instead of the segments.
+func (o *ObjectNode) swiftCopyObjectHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.swiftErrorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	var (
+		srcContainer, srcObject string
+		dstContainer, dstObject string
+		ok                      bool
+	)
+	if r.Method == swiftMethodCopy {
+		srcContainer, srcObject = param.Bucket(), param.Object()
+		dstContainer, dstObject, ok = splitSwiftPath(r.Header.Get(Destination))
+	} else {
+		dstContainer, dstObject = param.Bucket(), param.Object()
+		srcContainer, srcObject, ok = splitSwiftPath(r.Header.Get(XCopyFrom))
+	}
+	if !ok {
+		errorCode = PreconditionFailed
+		return
+	}
+	if len(dstObject) > MaxKeyLength {
+		errorCode = KeyTooLong
+		return
+	}
+
+	var userInfo *proto.UserInfo
+	if userInfo, err = o.getUserInfoByAccessKeyV2(param.AccessKey()); err != nil {
+		log.LogErrorf("swiftCopyObjectHandler: get user info fail: requestID(%v) accessKey(%v) err(%v)",
+			GetRequestID(r), param.AccessKey(), err)
+		return
+	}
+	if !swiftContainerAllowed(userInfo.Policy, srcContainer, false) ||
+		!swiftContainerAllowed(userInfo.Policy, dstContainer, true) {
+		errorCode = AccessDenied
+		return
+	}
+
+	var srcVol, dstVol *Volume
+	if srcVol, err = o.getVol(srcContainer); err != nil {
+		return
+	}
+	if dstVol, err = o.getVol(dstContainer); err != nil {
+		return
+	}
+
+	// QPS and Concurrency Limit
+	rateLimit := o.AcquireRateLimiter()
+	if err = rateLimit.AcquireLimitResource(dstVol.owner, param.apiName); err != nil {
+		return
+	}
+	defer rateLimit.ReleaseLimitResource(dstVol.owner, param.apiName)
+
+	srcInfo, srcXattr, err := srcVol.ObjectMeta(srcObject)
+	if err != nil {
+		log.LogErrorf("swiftCopyObjectHandler: get source meta fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), srcContainer, srcObject, err)
+		return
+	}
+	if srcInfo.Size > MaxCopyObjectSize {
+		errorCode = EntityTooLarge
+		return
+	}
+
+	// the metadata of the source is kept unless X-Fresh-Metadata is true
+	var metadata map[string]string
+	if fresh, _ := strconv.ParseBool(r.Header.Get(XFreshMetadata)); !fresh {
+		metadata = make(map[string]string, len(srcInfo.Metadata))
+		for key, value := range srcInfo.Metadata {
+			metadata[key] = value
+		}
+	}
+	metadata = updateSwiftMetadata(metadata, r.Header, XObjectMetaPrefix, "")
+	if errorCode = validateSwiftMetadata(metadata); errorCode != nil {
+		return
+	}
+	for _, key := range []string{XAttrKeyOSSSwiftSLO, XAttrKeyOSSSwiftDLO} {
+		if value := srcXattr.Get(key); len(value) > 0 {
+			metadata[key] = string(value)
+		}
+	}
+	contentType := r.Header.Get(ContentType)
+	if contentType == "" {
+		contentType = srcInfo.MIMEType
+	}
+
+	objectLock, err := dstVol.metaLoader.loadObjectLock()
+	if err != nil {
+		return
+	}
+	var sse *SSEOption
+	if sse, err = parseWriteSSEOption(r.Header, dstVol); err != nil {
+		return
+	}
+	if err = dstVol.checkBucketQuota(uint64(srcInfo.Size), 1); err != nil {
+		return
+	}
+	opt := &PutFileOption{
+		MIMEType:   contentType,
+		Metadata:   metadata,
+		ObjectLock: objectLock,
+		SSE:        sse,
+	}
+	info, err := dstVol.CopyFile(srcVol, srcObject, "", dstObject, MetadataDirectiveReplace, opt)
+	if err != nil {
+		log.LogErrorf("swiftCopyObjectHandler: copy fail: requestID(%v) source(%v/%v) target(%v/%v) err(%v)",
+			GetRequestID(r), srcContainer, srcObject, dstContainer, dstObject, err)
+		if err == syscall.EFBIG {
+			errorCode = EntityTooLarge
+		}
+		return
+	}
+
+	log.LogInfof("Audit: swift copy object: requestID(%v) remote(%v) source(%v/%v) target(%v/%v)",
+		GetRequestID(r), getRequestIP(r), srcContainer, srcObject, dstContainer, dstObject)
+	w.Header()[ETag] = []string{info.ETag}
+	w.Header().Set(XCopiedFrom, srcContainer+"/"+srcObject)
+	w.Header().Set(XCopiedFromLastModified, formatTimeRFC1123(srcInfo.ModifyTime))
+	w.Header().Set(LastModified, formatTimeRFC1123(info.ModifyTime))
+	w.Header().Set(ContentLength, "0")
+	w.WriteHeader(http.StatusCreated)
+
+	o.notify(param, dstVol, EventObjectCreatedCopy, &notificationObject{
+		Key:       dstObject,
+		Size:      info.Size,
+		ETag:      info.ETag,
+		VersionID: info.VersionId,
+	})
+}
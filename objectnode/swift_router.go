@@ -0,0 +1,141 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/cubefs/cubefs/proto"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	swiftAuthPath          = "/auth/v1.0"
+	swiftAuthPathShort     = "/auth/v1"
+	swiftAPIPathPrefix     = "/v1/"
+	swiftAccountPath       = "/v1/{account:" + SwiftAccountPrefix + "[^/]+}"
+	swiftContainerPath     = swiftAccountPath + "/{bucket:[^/]+}"
+	swiftObjectPath        = swiftContainerPath + "/{object:.+}"
+	swiftRouteAccount      = "account"
+	swiftMethodCopy        = "COPY"
+	ParamMultipartManifest = "multipart-manifest"
+)
+
+// isSwiftRequest checks whether the request is a request of the Swift API. As the paths of Swift are
+// matched by the path-style requests of S3 as well, only the auth requests with the user and the
+// requests with the token are routed to Swift.
+func isSwiftRequest(r *http.Request, _ *mux.RouteMatch) bool {
+	path := r.URL.Path
+	if path == swiftAuthPath || path == swiftAuthPathShort {
+		return r.Header.Get(XAuthUser) != "" || r.Header.Get(XStorageUser) != ""
+	}
+	return strings.HasPrefix(path, swiftAPIPathPrefix+SwiftAccountPrefix) &&
+		(r.Header.Get(XAuthToken) != "" || r.Header.Get(XStorageToken) != "")
+}
+
+// registerSwiftRouters registers the routers of the Swift v1 API, the containers are the volumes and the
+// objects are the objects of S3. The operations share the actions of S3 except the ones only in Swift.
+// API reference: https://docs.openstack.org/api-ref/object-store/
+func (o *ObjectNode) registerSwiftRouters(router *mux.Router) {
+	// Auth
+	// API reference: https://docs.openstack.org/swift/latest/overview_auth.html
+	for _, path := range []string{swiftAuthPath, swiftAuthPathShort} {
+		router.NewRoute().Name(ActionToUniqueRouteName(proto.OSSSwiftAuthAction)).
+			Methods(http.MethodGet).
+			Path(path).
+			HandlerFunc(o.swiftAuthHandler)
+	}
+
+	// Show account details and list containers
+	router.NewRoute().Name(ActionToUniqueRouteName(proto.OSSListBucketsAction)).
+		Methods(http.MethodGet).
+		Path(swiftAccountPath).
+		HandlerFunc(o.swiftListContainersHandler)
+	// Show account metadata
+	router.NewRoute().Name(ActionToUniqueRouteName(proto.OSSSwiftHeadAccountAction)).
+		Methods(http.MethodHead).
+		Path(swiftAccountPath).
+		HandlerFunc(o.swiftHeadAccountHandler)
+	// Create, update, or delete account metadata
+	router.NewRoute().Name(ActionToUniqueRouteName(proto.OSSSwiftPostAccountAction)).
+		Methods(http.MethodPost).
+		Path(swiftAccountPath).
+		HandlerFunc(o.swiftPostAccountHandler)
+
+	// Show container details and list objects
+	router.NewRoute().Name(ActionToUniqueRouteName(proto.OSSListObjectsAction)).
+		Methods(http.MethodGet).
+		Path(swiftContainerPath).
+		HandlerFunc(o.swiftListObjectsHandler)
+	// Show container metadata
+	router.NewRoute().Name(ActionToUniqueRouteName(proto.OSSHeadBucketAction)).
+		Methods(http.MethodHead).
+		Path(swiftContainerPath).
+		HandlerFunc(o.swiftHeadContainerHandler)
+	// Create container
+	router.NewRoute().Name(ActionToUniqueRouteName(proto.OSSCreateBucketAction)).
+		Methods(http.MethodPut).
+		Path(swiftContainerPath).
+		HandlerFunc(o.swiftPutContainerHandler)
+	// Create, update, or delete container metadata
+	router.NewRoute().Name(ActionToUniqueRouteName(proto.OSSSwiftPostContainerAction)).
+		Methods(http.MethodPost).
+		Path(swiftContainerPath).
+		HandlerFunc(o.swiftPostContainerHandler)
+	// Delete container
+	router.NewRoute().Name(ActionToUniqueRouteName(proto.OSSDeleteBucketAction)).
+		Methods(http.MethodDelete).
+		Path(swiftContainerPath).
+		HandlerFunc(o.swiftDeleteContainerHandler)
+
+	// Get object content and metadata
+	router.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetObjectAction)).
+		Methods(http.MethodGet).
+		Path(swiftObjectPath).
+		HandlerFunc(o.swiftGetObjectHandler)
+	// Show object metadata
+	router.NewRoute().Name(ActionToUniqueRouteName(proto.OSSHeadObjectAction)).
+		Methods(http.MethodHead).
+		Path(swiftObjectPath).
+		HandlerFunc(o.swiftHeadObjectHandler)
+	// Copy object, by PUT with the X-Copy-From header or by COPY with the Destination header
+	router.NewRoute().Name(ActionToUniqueRouteName(proto.OSSCopyObjectAction)).
+		Methods(http.MethodPut).
+		Path(swiftObjectPath).
+		HeadersRegexp(XCopyFrom, ".+").
+		HandlerFunc(o.swiftCopyObjectHandler)
+	router.NewRoute().Name(ActionToUniqueRouteName(proto.OSSCopyObjectAction)).
+		Methods(swiftMethodCopy).
+		Path(swiftObjectPath).
+		HeadersRegexp(Destination, ".+").
+		HandlerFunc(o.swiftCopyObjectHandler)
+	// Create or replace object, and the manifest of the large object
+	router.NewRoute().Name(ActionToUniqueRouteName(proto.OSSPutObjectAction)).
+		Methods(http.MethodPut).
+		Path(swiftObjectPath).
+		HandlerFunc(o.swiftPutObjectHandler)
+	// Create or update object metadata
+	router.NewRoute().Name(ActionToUniqueRouteName(proto.OSSSwiftPostObjectAction)).
+		Methods(http.MethodPost).
+		Path(swiftObjectPath).
+		HandlerFunc(o.swiftPostObjectHandler)
+	// Delete object
+	router.NewRoute().Name(ActionToUniqueRouteName(proto.OSSDeleteObjectAction)).
+		Methods(http.MethodDelete).
+		Path(swiftObjectPath).
+		HandlerFunc(o.swiftDeleteObjectHandler)
+}
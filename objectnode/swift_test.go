@@ -0,0 +1,302 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSwiftToken(t *testing.T) {
+	now := time.Now()
+	token := newSwiftToken(testOwnerAK, testOwnerSK, now.Add(time.Hour))
+	require.True(t, strings.HasPrefix(token, SwiftAuthTokenPrefix))
+
+	loadUser := func(ak string) (*proto.UserInfo, error) {
+		if ak != testOwnerAK {
+			return nil, InvalidAccessKeyId
+		}
+		return testGetUserInfo(ak)
+	}
+	userInfo, err := verifySwiftToken(token, now, loadUser)
+	require.NoError(t, err)
+	require.Equal(t, testUser, userInfo.UserID)
+
+	// expired
+	_, err = verifySwiftToken(token, now.Add(2*time.Hour), loadUser)
+	require.Equal(t, SwiftUnauthorized, err)
+	// signed with the other secret key
+	_, err = verifySwiftToken(newSwiftToken(testOwnerAK, "other", now.Add(time.Hour)), now, loadUser)
+	require.Equal(t, SwiftUnauthorized, err)
+	// unknown access key
+	_, err = verifySwiftToken(newSwiftToken("unknown", testOwnerSK, now.Add(time.Hour)), now, loadUser)
+	require.Equal(t, SwiftUnauthorized, err)
+	// tampered
+	for _, tampered := range []string{token + "x", SwiftAuthTokenPrefix + "!!", "AUTH_tkYWJj", "token"} {
+		_, err = verifySwiftToken(tampered, now, loadUser)
+		require.Equal(t, SwiftUnauthorized, err, tampered)
+	}
+	// the error of loading the user is returned
+	_, err = verifySwiftToken(token, now, func(string) (*proto.UserInfo, error) { return nil, errors.New("master") })
+	require.EqualError(t, err, "master")
+}
+
+func TestSwiftConfig(t *testing.T) {
+	conf := SwiftConfig{}
+	require.NoError(t, conf.FixConfig())
+	require.Equal(t, int64(defaultSwiftTokenTTLSec), conf.TokenTTLSec)
+	require.Equal(t, defaultSwiftMaxManifestSegments, conf.MaxManifestSegments)
+
+	conf = SwiftConfig{Keystone: &KeystoneConfig{URL: "http://keystone/", Username: "swift", Password: "pass", Project: "service"}}
+	require.NoError(t, conf.FixConfig())
+	require.Equal(t, "http://keystone", conf.Keystone.URL)
+	require.Equal(t, keystoneUserFieldProjectID, conf.Keystone.UserField)
+	require.Equal(t, "Default", conf.Keystone.UserDomain)
+	require.Equal(t, int64(defaultKeystoneCacheTTLSec), conf.Keystone.CacheTTLSec)
+
+	conf.Keystone.UserField = "email"
+	require.Error(t, conf.FixConfig())
+	conf = SwiftConfig{Keystone: &KeystoneConfig{URL: "http://keystone"}}
+	require.Error(t, conf.FixConfig())
+}
+
+func TestUpdateSwiftMetadata(t *testing.T) {
+	header := http.Header{}
+	header.Set("X-Container-Meta-Color", "red")
+	header.Set("X-Container-Meta-Empty", "")
+	header.Set("X-Remove-Container-Meta-Size", "x")
+	header.Set("X-Container-Meta-oss:mime", "text/plain")
+	header.Set("X-Object-Meta-Other", "ignored")
+
+	metadata := updateSwiftMetadata(map[string]string{"size": "1", "empty": "v", "shape": "round"},
+		header, XContainerMetaPrefix, XRemoveContainerMetaPrefix)
+	require.Equal(t, map[string]string{"color": "red", "shape": "round"}, metadata)
+
+	require.Nil(t, validateSwiftMetadata(metadata))
+	require.NotNil(t, validateSwiftMetadata(map[string]string{strings.Repeat("n", swiftMaxMetaNameLength+1): "v"}))
+	require.NotNil(t, validateSwiftMetadata(map[string]string{"n": strings.Repeat("v", swiftMaxMetaValueLength+1)}))
+	large := make(map[string]string)
+	for i := 0; i < 20; i++ {
+		large[strings.Repeat(string(rune('a'+i)), 10)] = strings.Repeat("v", 250)
+	}
+	require.NotNil(t, validateSwiftMetadata(large))
+}
+
+func TestCheckSwiftAccess(t *testing.T) {
+	userInfo := &proto.UserInfo{UserID: "user", Policy: &proto.UserPolicy{
+		OwnVols: []string{"own"},
+		AuthorizedVols: map[string][]string{
+			"read":  {proto.BuiltinPermissionReadOnly.String()},
+			"write": {proto.BuiltinPermissionWritable.String()},
+		},
+	}}
+
+	tests := []struct {
+		account string
+		bucket  string
+		action  proto.Action
+		err     error
+	}{
+		{"AUTH_user", "", proto.OSSListBucketsAction, nil},
+		{"AUTH_other", "", proto.OSSListBucketsAction, AccessDenied},
+		{"AUTH_user", "own", proto.OSSDeleteBucketAction, nil},
+		{"AUTH_user", "read", proto.OSSDeleteBucketAction, AccessDenied},
+		{"AUTH_user", "read", proto.OSSSwiftPostContainerAction, AccessDenied},
+		{"AUTH_user", "new", proto.OSSCreateBucketAction, nil},
+		{"AUTH_user", "read", proto.OSSGetObjectAction, nil},
+		{"AUTH_user", "read", proto.OSSListObjectsAction, nil},
+		{"AUTH_user", "read", proto.OSSPutObjectAction, AccessDenied},
+		{"AUTH_user", "write", proto.OSSPutObjectAction, nil},
+		{"AUTH_user", "write", proto.OSSDeleteObjectAction, nil},
+		{"AUTH_user", "other", proto.OSSHeadObjectAction, AccessDenied},
+		{"AUTH_user", "own", proto.OSSSwiftPostObjectAction, nil},
+	}
+	for _, tc := range tests {
+		param := &RequestParam{bucket: tc.bucket, action: tc.action}
+		require.Equal(t, tc.err, checkSwiftAccess(userInfo, tc.account, param), "%+v", tc)
+	}
+}
+
+func TestSwiftListing(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://cube.io/v1/AUTH_user?format=json&limit=2&end_marker=d", nil)
+	opt, ec := parseSwiftListOption(req)
+	require.Nil(t, ec)
+	require.Equal(t, swiftListFormatJSON, opt.format)
+	require.Equal(t, []string{"a", "b"}, opt.filterNames([]string{"c", "b", "a", "b"}))
+	opt.marker = "a"
+	require.Equal(t, []string{"b", "c"}, opt.filterNames([]string{"c", "b", "a", "d", "e"}))
+	require.Equal(t, []string{"a", "b"}, distinctNames([]string{"b", "a", "b"}))
+
+	req = httptest.NewRequest(http.MethodGet, "http://cube.io/v1/AUTH_user?limit=10001", nil)
+	_, ec = parseSwiftListOption(req)
+	require.NotNil(t, ec)
+	req = httptest.NewRequest(http.MethodGet, "http://cube.io/v1/AUTH_user", nil)
+	req.Header.Set("Accept", "application/xml")
+	opt, _ = parseSwiftListOption(req)
+	require.Equal(t, swiftListFormatXML, opt.format)
+
+	listing := &swiftContainerListing{Name: "c", Entries: []interface{}{
+		&swiftObjectEntry{Name: "a", Hash: "h", Bytes: 1, ContentType: "text/plain", LastModified: "t"},
+		&swiftSubdirEntry{NameAttr: "p/", Name: "p/", Subdir: "p/"},
+	}}
+	w := httptest.NewRecorder()
+	require.NoError(t, writeSwiftListing(w, swiftListFormatXML, []string{"a", "p/"}, listing, listing.Entries))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, xml.Header+`<container name="c"><object><name>a</name><hash>h</hash><bytes>1</bytes>`+
+		`<content_type>text/plain</content_type><last_modified>t</last_modified></object>`+
+		`<subdir name="p/"><name>p/</name></subdir></container>`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	require.NoError(t, writeSwiftListing(w, swiftListFormatJSON, []string{"a", "p/"}, listing, listing.Entries))
+	require.JSONEq(t, `[{"name":"a","hash":"h","bytes":1,"content_type":"text/plain","last_modified":"t"},{"subdir":"p/"}]`,
+		w.Body.String())
+
+	w = httptest.NewRecorder()
+	require.NoError(t, writeSwiftListing(w, swiftListFormatPlain, []string{"a", "p/"}, listing, listing.Entries))
+	require.Equal(t, "a\np/\n", w.Body.String())
+	w = httptest.NewRecorder()
+	require.NoError(t, writeSwiftListing(w, swiftListFormatPlain, nil, listing, listing.Entries))
+	require.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestParseSwiftManifest(t *testing.T) {
+	specs, err := parseSLOManifest([]byte(`[{"path":"/c/a","etag":"e","size_bytes":10},{"path":"c/b/c"}]`), 2)
+	require.NoError(t, err)
+	require.Len(t, specs, 2)
+	require.Equal(t, "e", *specs[0].ETag)
+	require.Equal(t, int64(10), *specs[0].SizeBytes)
+	require.Nil(t, specs[1].ETag)
+
+	for _, raw := range []string{`{}`, `[]`, `[{"path":"/c"}]`, `[{"path":"/c/"}]`, `[{"path":"/c/a","size_bytes":-1}]`,
+		`[{"path":"/c/a"},{"path":"/c/b"},{"path":"/c/c"}]`} {
+		_, err = parseSLOManifest([]byte(raw), 2)
+		require.Error(t, err, raw)
+		require.Equal(t, InvalidSwiftManifest.ErrorCode, err.(*ErrorCode).ErrorCode, raw)
+	}
+
+	container, prefix, err := parseDLOManifest("seg%20c/a%2Fb/")
+	require.NoError(t, err)
+	require.Equal(t, "seg c", container)
+	require.Equal(t, "a/b/", prefix)
+	_, _, err = parseDLOManifest("container")
+	require.Error(t, err)
+
+	meta := newSwiftSLOMeta([]*swiftSegment{{Hash: "a", Bytes: 1}, {Hash: "b", Bytes: 2}})
+	require.Equal(t, int64(3), meta.Size)
+	require.Equal(t, swiftLargeObjectETag([]string{"a", "b"}), meta.ETag)
+	parsed, err := parseSwiftSLOMeta([]byte(meta.Encode()))
+	require.NoError(t, err)
+	require.Equal(t, meta, parsed)
+}
+
+func TestForEachSegmentRange(t *testing.T) {
+	type segmentRange struct {
+		index          int
+		offset, length uint64
+	}
+	sizes := []int64{10, 0, 5, 20}
+	tests := []struct {
+		offset, length uint64
+		expect         []segmentRange
+	}{
+		{0, 35, []segmentRange{{0, 0, 10}, {2, 0, 5}, {3, 0, 20}}},
+		{5, 7, []segmentRange{{0, 5, 5}, {2, 0, 2}}},
+		{10, 5, []segmentRange{{2, 0, 5}}},
+		{14, 2, []segmentRange{{2, 4, 1}, {3, 0, 1}}},
+		{34, 1, []segmentRange{{3, 19, 1}}},
+	}
+	for _, tc := range tests {
+		var ranges []segmentRange
+		err := forEachSegmentRange(sizes, tc.offset, tc.length, func(i int, offset, length uint64) error {
+			ranges = append(ranges, segmentRange{i, offset, length})
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, tc.expect, ranges, "%+v", tc)
+	}
+}
+
+func TestKeystoneValidator(t *testing.T) {
+	var serviceTokens, validations int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, keystoneTokensPath, r.URL.Path)
+		expires := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		if r.Method == http.MethodPost {
+			n := atomic.AddInt32(&serviceTokens, 1)
+			w.Header().Set(XSubjectToken, "service-"+string(rune('0'+n)))
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"token":{"expires_at":"` + expires + `"}}`))
+			return
+		}
+		// the first service token is revoked
+		if r.Header.Get(XAuthToken) == "service-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		atomic.AddInt32(&validations, 1)
+		switch r.Header.Get(XSubjectToken) {
+		case "valid":
+			_, _ = w.Write([]byte(`{"token":{"expires_at":"` + expires + `","user":{"id":"uid","name":"uname"},` +
+				`"project":{"id":"pid","name":"pname"}}}`))
+		case "unscoped":
+			_, _ = w.Write([]byte(`{"token":{"expires_at":"` + expires + `","user":{"id":"uid","name":"uname"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	conf := SwiftConfig{Keystone: &KeystoneConfig{URL: server.URL, Username: "swift", Password: "pass", Project: "service"}}
+	require.NoError(t, conf.FixConfig())
+	validator := NewKeystoneValidator(*conf.Keystone)
+	resolve := func(identity string) (string, error) {
+		if identity == "denied" {
+			return "", AccessDenied
+		}
+		return "ak-" + identity, nil
+	}
+
+	ak, err := validator.Validate("valid", resolve)
+	require.NoError(t, err)
+	require.Equal(t, "ak-pid", ak)
+	require.Equal(t, int32(2), atomic.LoadInt32(&serviceTokens))
+	// cached
+	ak, err = validator.Validate("valid", resolve)
+	require.NoError(t, err)
+	require.Equal(t, "ak-pid", ak)
+	require.Equal(t, int32(1), atomic.LoadInt32(&validations))
+
+	_, err = validator.Validate("invalid", resolve)
+	require.Equal(t, SwiftUnauthorized, err)
+	_, err = validator.Validate("unscoped", resolve)
+	require.Equal(t, SwiftUnauthorized, err)
+
+	resp := &keystoneTokenResponse{}
+	require.NoError(t, json.Unmarshal([]byte(`{"token":{"user":{"id":"uid","name":"uname"},"project":{"id":"pid","name":"pname"}}}`), resp))
+	require.Equal(t, "pid", resp.identity(keystoneUserFieldProjectID))
+	require.Equal(t, "pname", resp.identity(keystoneUserFieldProjectName))
+	require.Equal(t, "uid", resp.identity(keystoneUserFieldUserID))
+	require.Equal(t, "uname", resp.identity(keystoneUserFieldUserName))
+}
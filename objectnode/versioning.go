@@ -0,0 +1,1255 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	VersioningSuspended = "Suspended"
+	NullVersionId       = "null"
+
+	MaxVersioningConfigSize = 1 << 10 // 1KB
+	versionScanReadLimit    = 1000
+
+	// the separator between the encoded key and the version in the names of the version index entries
+	versionIndexSep = "\x00"
+)
+
+var errVersionScanFinished = errors.New("version scan finished")
+
+// VersioningConfiguration is the bucket versioning state.
+// Once versioning has been enabled, the bucket can only be switched between Enabled and Suspended.
+type VersioningConfiguration struct {
+	XMLNS     string   `xml:"xmlns,attr,omitempty" json:"-"`
+	XMLName   xml.Name `xml:"VersioningConfiguration" json:"-"`
+	Status    string   `xml:"Status,omitempty" json:"status,omitempty"`
+	MfaDelete string   `xml:"MfaDelete,omitempty" json:"-"`
+	Index     uint64   `xml:"-" json:"index,omitempty"`
+}
+
+func (c *VersioningConfiguration) Enabled() bool {
+	return c != nil && c.Status == Enabled
+}
+
+func (c *VersioningConfiguration) Suspended() bool {
+	return c != nil && c.Status == VersioningSuspended
+}
+
+// parse VersioningConfiguration from xml
+func ParseVersioningConfigFromXML(data []byte) (*VersioningConfiguration, error) {
+	config := &VersioningConfiguration{}
+	if err := xml.Unmarshal(data, config); err != nil {
+		return nil, MalformedXML
+	}
+	if config.Status != Enabled && config.Status != VersioningSuspended {
+		return nil, IllegalVersioningConfiguration
+	}
+	if config.MfaDelete == Enabled {
+		return nil, NewError("InvalidArgument", "MFA delete is not supported.", 400)
+	}
+	return config, nil
+}
+
+func storeBucketVersioning(bytes []byte, vol *Volume) (err error) {
+	return vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSVersioning, bytes)
+}
+
+// setBucketVersioning stores the versioning configuration of the bucket. The version index is created
+// when the versioning is configured for the first time, and kept by the later configurations.
+func (v *Volume) setBucketVersioning(config *VersioningConfiguration) (err error) {
+	var current *VersioningConfiguration
+	if current, err = v.loadBucketVersioning(); err != nil {
+		return
+	}
+	if current != nil && current.Index != 0 {
+		config.Index = current.Index
+	} else {
+		var info *proto.InodeInfo
+		if info, err = v.mw.InodeCreate_ll(0, uint32(DefaultDirMode), 0, 0, nil, make([]uint64, 0), ""); err != nil {
+			log.LogErrorf("setBucketVersioning: create version index fail: volume(%v) err(%v)", v.name, err)
+			return
+		}
+		config.Index = info.Inode
+	}
+	var data []byte
+	if data, err = json.Marshal(config); err != nil {
+		return
+	}
+	if err = storeBucketVersioning(data, v); err != nil {
+		return
+	}
+	v.metaLoader.storeVersioning(config)
+	return
+}
+
+// Version IDs are ordered by creation time, so that the newer version always has the larger ID.
+func newVersionId() string {
+	return fmt.Sprintf("%016x%08x", time.Now().UnixNano(), rand.Uint32())
+}
+
+// versionIdTime returns the creation time carried by the version ID generated by newVersionId.
+func versionIdTime(versionId string) (int64, bool) {
+	if len(versionId) != 24 {
+		return 0, false
+	}
+	nano, err := strconv.ParseUint(versionId[:16], 16, 64)
+	if err != nil || nano > math.MaxInt64 {
+		return 0, false
+	}
+	if _, err = strconv.ParseUint(versionId[16:], 16, 32); err != nil {
+		return 0, false
+	}
+	return int64(nano), true
+}
+
+// objectVersion is a noncurrent version or a delete marker of an object. The data of a noncurrent
+// version is kept in an inode without any dentry.
+//
+// The versions of the bucket are recorded in the version index, which is a directory inode without
+// any dentry linked to it like the key index. Each version is a dentry under it named by the encoded
+// object key, a zero byte, the inverted time of the version and the version ID, and points to the
+// inode of the version, or to no inode if it's a delete marker. The key is encoded by encodeIndexKey,
+// which never produces a zero byte, so that the entries are sorted in the same order as the keys
+// listed by walking the directory tree, and the versions of an object are stored continuously from
+// the newest to the oldest. A page of versions is listed by a range scan of the index, without reading
+// the versions of the other objects.
+//
+// The time of a version is taken from its version ID, so the entry is found by the version ID directly.
+// The null version, or the version whose ID is not generated by newVersionId, is ordered by its modify
+// time, and found by scanning the versions of the object.
+type objectVersion struct {
+	VersionId    string
+	Inode        uint64
+	DeleteMarker bool
+	ModifyTime   int64
+}
+
+func (ver *objectVersion) String() string {
+	return fmt.Sprintf("version(%v) inode(%v) deleteMarker(%v) modifyTime(%v)",
+		ver.VersionId, ver.Inode, ver.DeleteMarker, ver.ModifyTime)
+}
+
+// versionIndexPrefix returns the common prefix of the index entries of all versions of the object.
+func versionIndexPrefix(key string) string {
+	return encodeIndexKey(key) + versionIndexSep
+}
+
+func versionIndexName(key, versionId string, modifyTime int64) string {
+	if nano, ok := versionIdTime(versionId); ok {
+		modifyTime = nano
+	}
+	return fmt.Sprintf("%s%016x%s", versionIndexPrefix(key), uint64(math.MaxInt64-modifyTime), versionId)
+}
+
+// parseVersionIndexEntry returns the object key and the version recorded by the index entry.
+func parseVersionIndexEntry(dentry *proto.Dentry) (key string, ver *objectVersion, err error) {
+	name := dentry.Name
+	idx := strings.Index(name, versionIndexSep)
+	if idx < 0 || len(name) <= idx+17 {
+		return "", nil, errInvalidIndexEntry
+	}
+	if key, err = decodeIndexKey(name[:idx]); err != nil {
+		return
+	}
+	var inverted uint64
+	if inverted, err = strconv.ParseUint(name[idx+1:idx+17], 16, 64); err != nil || inverted > math.MaxInt64 {
+		return "", nil, errInvalidIndexEntry
+	}
+	ver = &objectVersion{
+		VersionId:    name[idx+17:],
+		Inode:        dentry.Inode,
+		DeleteMarker: dentry.Inode == 0,
+		ModifyTime:   math.MaxInt64 - int64(inverted),
+	}
+	return
+}
+
+// FSObjectVersion is a version or a delete marker of an object returned by ListObjectVersions.
+type FSObjectVersion struct {
+	Key          string
+	VersionId    string
+	IsLatest     bool
+	DeleteMarker bool
+	Inode        uint64
+	Size         int64
+	ETag         string
+	ModifyTime   time.Time
+	StorageClass uint32
+}
+
+type ListObjectVersionsOption struct {
+	Prefix          string
+	Delimiter       string
+	KeyMarker       string
+	VersionIdMarker string
+	MaxKeys         uint64
+}
+
+type ListObjectVersionsResult struct {
+	Versions            []*FSObjectVersion
+	CommonPrefixes      []string
+	Truncated           bool
+	NextKeyMarker       string
+	NextVersionIdMarker string
+}
+
+// versioningStatus returns an empty string if the versioning has never been configured for this bucket.
+func (v *Volume) versioningStatus() (status string, err error) {
+	var config *VersioningConfiguration
+	if config, err = v.metaLoader.loadVersioning(); err != nil {
+		log.LogErrorf("versioningStatus: load versioning fail: volume(%v) err(%v)", v.name, err)
+		return
+	}
+	if config != nil {
+		status = config.Status
+	}
+	return
+}
+
+// assignVersionId generates the version ID of a new object according to the versioning status.
+// The generated ID is only stored when versioning is enabled, an object without version ID is
+// treated as the null version.
+func (v *Volume) assignVersionId(xattrs map[string]string) (versionId string, err error) {
+	var status string
+	if status, err = v.versioningStatus(); err != nil {
+		return
+	}
+	switch status {
+	case Enabled:
+		versionId = newVersionId()
+		xattrs[XAttrKeyOSSVersionId] = versionId
+	case VersioningSuspended:
+		versionId = NullVersionId
+	}
+	return
+}
+
+func (v *Volume) inodeVersionId(inode uint64) (versionId string, err error) {
+	var info *proto.XAttrInfo
+	if info, err = v.mw.XAttrGet_ll(inode, XAttrKeyOSSVersionId); err != nil {
+		return
+	}
+	if versionId = string(info.Get(XAttrKeyOSSVersionId)); versionId == "" {
+		versionId = NullVersionId
+	}
+	return
+}
+
+// versionIndex returns the inode of the version index, zero if versioning has never been configured.
+func (v *Volume) versionIndex() (index uint64, err error) {
+	var config *VersioningConfiguration
+	if config, err = v.metaLoader.loadVersioning(); err != nil {
+		log.LogErrorf("versionIndex: load versioning fail: volume(%v) err(%v)", v.name, err)
+		return
+	}
+	if config != nil {
+		index = config.Index
+	}
+	return
+}
+
+// readObjectVersions reads at most limit versions of the object from the newest, starting from the entry
+// named from if it is not empty.
+func (v *Volume) readObjectVersions(index uint64, key, from string, limit uint64) (names []string, versions []*objectVersion, more bool, err error) {
+	prefix := versionIndexPrefix(key)
+	if from == "" {
+		from = prefix
+	}
+	var children []proto.Dentry
+	if children, err = v.mw.ReadDirLimit_ll(index, from, limit); err != nil {
+		log.LogErrorf("readObjectVersions: read version index fail: volume(%v) index(%v) key(%v) err(%v)",
+			v.name, index, key, err)
+		return
+	}
+	for i := range children {
+		if !strings.HasPrefix(children[i].Name, prefix) {
+			return names, versions, false, nil
+		}
+		var ver *objectVersion
+		if _, ver, err = parseVersionIndexEntry(&children[i]); err != nil {
+			log.LogErrorf("readObjectVersions: parse version fail: volume(%v) index(%v) name(%q) err(%v)",
+				v.name, index, children[i].Name, err)
+			return
+		}
+		names = append(names, children[i].Name)
+		versions = append(versions, ver)
+	}
+	return names, versions, uint64(len(children)) >= limit, nil
+}
+
+// newestObjectVersion returns the newest noncurrent version of the object, nil if it has no version.
+func (v *Volume) newestObjectVersion(key string) (ver *objectVersion, err error) {
+	var index uint64
+	if index, err = v.versionIndex(); err != nil || index == 0 {
+		return
+	}
+	var versions []*objectVersion
+	if _, versions, _, err = v.readObjectVersions(index, key, "", 1); err != nil || len(versions) == 0 {
+		return
+	}
+	return versions[0], nil
+}
+
+// getObjectVersion returns the specified noncurrent version of the object, nil if it does not exist.
+func (v *Volume) getObjectVersion(key, versionId string) (ver *objectVersion, err error) {
+	var index uint64
+	if index, err = v.versionIndex(); err != nil || index == 0 {
+		return
+	}
+	if nano, ok := versionIdTime(versionId); ok {
+		name := versionIndexName(key, versionId, nano)
+		var inode uint64
+		if inode, _, err = v.mw.Lookup_ll(index, name); err != nil {
+			if err == syscall.ENOENT {
+				return nil, nil
+			}
+			log.LogErrorf("getObjectVersion: lookup version index fail: volume(%v) index(%v) key(%v) versionId(%v) err(%v)",
+				v.name, index, key, versionId, err)
+			return
+		}
+		return &objectVersion{VersionId: versionId, Inode: inode, DeleteMarker: inode == 0, ModifyTime: nano}, nil
+	}
+	var from string
+	for {
+		var names []string
+		var versions []*objectVersion
+		var more bool
+		if names, versions, more, err = v.readObjectVersions(index, key, from, versionScanReadLimit); err != nil {
+			return
+		}
+		for i, ver := range versions {
+			if ver.VersionId == versionId && names[i] != from {
+				return ver, nil
+			}
+		}
+		if !more {
+			return nil, nil
+		}
+		from = names[len(names)-1]
+	}
+}
+
+func (v *Volume) addObjectVersion(key string, ver *objectVersion) (err error) {
+	var index uint64
+	if index, err = v.versionIndex(); err != nil {
+		return
+	}
+	if index == 0 {
+		return syscall.ENOENT
+	}
+	name := versionIndexName(key, ver.VersionId, ver.ModifyTime)
+	if err = v.mw.DentryCreate_ll(index, name, ver.Inode, DefaultFileMode, ""); err != nil {
+		log.LogErrorf("addObjectVersion: create index entry fail: volume(%v) index(%v) key(%v) %v err(%v)",
+			v.name, index, key, ver, err)
+	}
+	return
+}
+
+func (v *Volume) removeObjectVersion(key string, ver *objectVersion) (err error) {
+	var index uint64
+	if index, err = v.versionIndex(); err != nil || index == 0 {
+		return
+	}
+	name := versionIndexName(key, ver.VersionId, ver.ModifyTime)
+	if _, err = v.mw.DentryDelete_ll(index, name, ""); err != nil {
+		if err == syscall.ENOENT {
+			return nil
+		}
+		log.LogErrorf("removeObjectVersion: delete index entry fail: volume(%v) index(%v) key(%v) %v err(%v)",
+			v.name, index, key, ver, err)
+	}
+	return
+}
+
+// hasObjectVersions checks whether any noncurrent version is recorded under the directory,
+// such a directory cannot be removed even though it has no dentry.
+func (v *Volume) hasObjectVersions(dirKey string) (bool, error) {
+	index, err := v.versionIndex()
+	if err != nil || index == 0 {
+		return false, err
+	}
+	prefix := encodeIndexKey(dirKey)
+	children, err := v.mw.ReadDirLimit_ll(index, prefix, 1)
+	if err != nil {
+		return false, err
+	}
+	return len(children) > 0 && strings.HasPrefix(children[0].Name, prefix), nil
+}
+
+// releaseVersionInode removes the data of a version permanently.
+func (v *Volume) releaseVersionInode(inode uint64, fullPath string) {
+	v.releaseRestoredCopy(inode, fullPath)
+	if _, err := v.mw.InodeUnlink_ll(inode, fullPath); err != nil {
+		log.LogWarnf("releaseVersionInode: unlink inode fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, fullPath, inode, err)
+	}
+	if err := v.ec.EvictStream(inode); err != nil {
+		log.LogWarnf("releaseVersionInode: evict stream fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, fullPath, inode, err)
+	}
+	if err := v.mw.Evict(inode, fullPath); err != nil {
+		log.LogWarnf("releaseVersionInode: evict inode fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, fullPath, inode, err)
+	}
+	deleteAttrCache(inode, v.name)
+}
+
+// archiveObjectVersion keeps the inode replaced by a new object as a noncurrent version.
+// It returns false if the bucket is not versioned or the old inode is a null version
+// in a versioning-suspended bucket, and the caller should release the old inode.
+func (v *Volume) archiveObjectVersion(key string, oldInode uint64) (archived bool, err error) {
+	var status string
+	if status, err = v.versioningStatus(); err != nil || status == "" {
+		return
+	}
+	var versionId string
+	if versionId, err = v.inodeVersionId(oldInode); err != nil {
+		return
+	}
+	if versionId == NullVersionId && status == VersioningSuspended {
+		return
+	}
+	var info *proto.InodeInfo
+	if info, err = v.mw.InodeGet_ll(oldInode); err != nil {
+		return
+	}
+	err = v.addObjectVersion(key, &objectVersion{
+		VersionId:  versionId,
+		Inode:      oldInode,
+		ModifyTime: info.ModifyTime.UnixNano(),
+	})
+	if err != nil {
+		return
+	}
+	log.LogDebugf("archiveObjectVersion: volume(%v) key(%v) inode(%v) versionId(%v)",
+		v.name, key, oldInode, versionId)
+	return true, nil
+}
+
+// releaseNullVersion removes the noncurrent null version of an object after a new null version
+// has been created in a versioning-suspended bucket.
+func (v *Volume) releaseNullVersion(name, fullPath string) (err error) {
+	var status string
+	if status, err = v.versioningStatus(); err != nil || status != VersioningSuspended {
+		return
+	}
+	var ver *objectVersion
+	if ver, err = v.getObjectVersion(fullPath, NullVersionId); err != nil || ver == nil {
+		return
+	}
+	if !ver.DeleteMarker {
+		var objectLock *ObjectLockConfig
+		if objectLock, err = v.metaLoader.loadObjectLock(); err != nil {
+			return
+		}
+		// the protected version has been checked before the new version is created,
+		// it's only kept if the object lock is updated concurrently.
+		if objectLock != nil {
+			if err = isObjectLocked(v, ver.Inode, name, fullPath, false); err != nil {
+				return
+			}
+		}
+	}
+	if err = v.removeObjectVersion(fullPath, ver); err != nil {
+		return
+	}
+	if !ver.DeleteMarker {
+		v.releaseVersionInode(ver.Inode, fullPath)
+	}
+	return
+}
+
+// lookupObjectParent returns the parent directory inode and the name of the object without creating any directory.
+func (v *Volume) lookupObjectParent(path string) (parentId uint64, name string, err error) {
+	pathItems := NewPathIterator(path).ToSlice()
+	if len(pathItems) == 0 || pathItems[len(pathItems)-1].IsDirectory {
+		return 0, "", syscall.ENOENT
+	}
+	dirs := make([]string, 0, len(pathItems)-1)
+	for _, item := range pathItems[:len(pathItems)-1] {
+		dirs = append(dirs, item.Name)
+	}
+	if parentId, err = v.lookupDirectories(dirs, false); err != nil {
+		return
+	}
+	return parentId, pathItems[len(pathItems)-1].Name, nil
+}
+
+// ObjectVersionMeta returns the meta of the specified version of the object.
+// The current version is returned if versionId is empty.
+//
+// A NoSuchVersion error is returned if the version does not exist,
+// and a MethodNotAllowed error is returned if the version is a delete marker.
+func (v *Volume) ObjectVersionMeta(path, versionId string) (info *FSFileInfo, xattr *proto.XAttrInfo, err error) {
+	info, xattr, err = v.ObjectMeta(path)
+	if versionId == "" {
+		return
+	}
+	if err != nil && err != syscall.ENOENT {
+		return
+	}
+	if err == nil && (info.VersionId == versionId || info.VersionId == "" && versionId == NullVersionId) {
+		return
+	}
+	info, xattr = nil, nil
+
+	var ver *objectVersion
+	if ver, err = v.getObjectVersion(path, versionId); err != nil {
+		return
+	}
+	if ver == nil {
+		err = NoSuchVersion
+		return
+	}
+	if ver.DeleteMarker {
+		err = MethodNotAllowed
+		return
+	}
+	var inoInfo *proto.InodeInfo
+	if inoInfo, err = v.mw.InodeGet_ll(ver.Inode); err != nil {
+		log.LogErrorf("ObjectVersionMeta: get inode fail: volume(%v) path(%v) versionId(%v) inode(%v) err(%v)",
+			v.name, path, versionId, ver.Inode, err)
+		return
+	}
+	if info, xattr, err = v.objectMetaOfInode(path, os.FileMode(inoInfo.Mode), inoInfo); err != nil {
+		return
+	}
+	info.VersionId = versionId
+	return
+}
+
+// LatestDeleteMarker returns the version ID of the delete marker if it is the current version of the object.
+func (v *Volume) LatestDeleteMarker(path string) (versionId string, ok bool) {
+	if status, err := v.versioningStatus(); err != nil || status == "" {
+		return
+	}
+	parentId, name, err := v.lookupObjectParent(path)
+	if err != nil {
+		return
+	}
+	if _, _, err = v.mw.Lookup_ll(parentId, name); err != syscall.ENOENT {
+		return
+	}
+	newest, err := v.newestObjectVersion(path)
+	if err != nil || newest == nil || !newest.DeleteMarker {
+		return
+	}
+	return newest.VersionId, true
+}
+
+// DeleteObjectVersion deletes the object in a versioned bucket.
+//
+// If versionId is empty, a delete marker is created as the current version, and the current object
+// is kept as a noncurrent version. Otherwise the specified version is removed permanently, and the
+// newest noncurrent version becomes the current one if the current version is removed.
+//
+// The returned versionId is the ID of the created delete marker or the removed version,
+// and deleteMarker indicates whether it is a delete marker. The object protected by the retention in
+// GOVERNANCE mode can be deleted permanently only if bypassGovernance is set.
+func (v *Volume) DeleteObjectVersion(path, versionId string, bypassGovernance bool) (deleteMarker bool, resultVersionId string, err error) {
+	defer func() {
+		// Audit behavior
+		log.LogInfof("Audit: DeleteObjectVersion: volume(%v) path(%v) versionId(%v) deleteMarker(%v) resultVersionId(%v) err(%v)",
+			v.name, path, versionId, deleteMarker, resultVersionId, err)
+	}()
+
+	var status string
+	if status, err = v.versioningStatus(); err != nil {
+		return
+	}
+	// directories are not versioned
+	if status == "" && (versionId == "" || versionId == NullVersionId) || strings.HasSuffix(path, pathSep) {
+		err = v.deletePath(path, bypassGovernance)
+		return
+	}
+	if status == "" {
+		err = NoSuchVersion
+		return
+	}
+	if versionId == "" {
+		deleteMarker, resultVersionId, err = v.putDeleteMarker(path, status, bypassGovernance)
+	} else {
+		deleteMarker, resultVersionId, err = v.deleteVersion(path, versionId, bypassGovernance)
+	}
+	if err == nil {
+		v.syncKeyIndex(path)
+	}
+	return
+}
+
+func (v *Volume) putDeleteMarker(path, status string, bypassGovernance bool) (deleteMarker bool, versionId string, err error) {
+	var parentId uint64
+	var name string
+	if parentId, name, err = v.lookupObjectParent(path); err != nil {
+		// the object has never existed, there is nothing to hide.
+		if err == syscall.ENOENT {
+			err = nil
+		}
+		return
+	}
+
+	inode, mode, err := v.mw.Lookup_ll(parentId, name)
+	if err != nil && err != syscall.ENOENT {
+		log.LogErrorf("putDeleteMarker: lookup fail: volume(%v) path(%v) parentID(%v) name(%v) err(%v)",
+			v.name, path, parentId, name, err)
+		return
+	}
+	if err == nil && os.FileMode(mode).IsDir() {
+		return false, "", nil
+	}
+	if err == syscall.ENOENT {
+		inode, err = 0, nil
+	}
+	// the delete marker in a versioning-suspended bucket releases the null versions
+	if status == VersioningSuspended {
+		if err = v.checkNullVersionsLocked(inode, name, path, bypassGovernance); err != nil {
+			return
+		}
+	}
+	if inode != 0 {
+		if err = v.hideCurrentVersion(parentId, name, path, inode, status); err != nil {
+			return
+		}
+	}
+
+	versionId = newVersionId()
+	var replaced *objectVersion
+	if status == VersioningSuspended {
+		// the delete marker replaces the noncurrent null version
+		versionId = NullVersionId
+		if replaced, err = v.getObjectVersion(path, NullVersionId); err != nil {
+			return
+		}
+	}
+	err = v.addObjectVersion(path, &objectVersion{
+		VersionId:    versionId,
+		DeleteMarker: true,
+		ModifyTime:   time.Now().UnixNano(),
+	})
+	if err != nil {
+		return
+	}
+	if replaced != nil {
+		if err = v.removeObjectVersion(path, replaced); err != nil {
+			return
+		}
+		if !replaced.DeleteMarker {
+			v.releaseVersionInode(replaced.Inode, path)
+		}
+	}
+	return true, versionId, nil
+}
+
+// hideCurrentVersion removes the dentry of the current version before a delete marker is created,
+// the current version is kept as a noncurrent version unless it is a null version in a
+// versioning-suspended bucket.
+func (v *Volume) hideCurrentVersion(parentId uint64, name, path string, inode uint64, status string) (err error) {
+	var versionId string
+	var info *proto.InodeInfo
+	var kept *objectVersion
+	if versionId, err = v.inodeVersionId(inode); err != nil {
+		return
+	}
+	if info, err = v.mw.InodeGet_ll(inode); err != nil {
+		return
+	}
+	keep := versionId != NullVersionId || status != VersioningSuspended
+	if keep {
+		// hold one more link, so that the inode will not be released when the dentry is deleted,
+		// and record the version before the dentry is deleted, so that it is never lost.
+		if _, err = v.mw.InodeLink_ll(inode, path); err != nil {
+			log.LogErrorf("hideCurrentVersion: link inode fail: volume(%v) path(%v) inode(%v) err(%v)",
+				v.name, path, inode, err)
+			return
+		}
+		kept = &objectVersion{
+			VersionId:  versionId,
+			Inode:      inode,
+			ModifyTime: info.ModifyTime.UnixNano(),
+		}
+		if err = v.addObjectVersion(path, kept); err != nil {
+			_, _ = v.mw.InodeUnlink_ll(inode, path)
+			return
+		}
+	}
+	if _, err = v.mw.Delete_ll(parentId, name, false, path); err != nil {
+		log.LogErrorf("hideCurrentVersion: delete dentry fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, inode, err)
+		if keep {
+			_ = v.removeObjectVersion(path, kept)
+			_, _ = v.mw.InodeUnlink_ll(inode, path)
+		}
+		return
+	}
+	deleteDentryCache(parentId, name, v.name)
+	if keep {
+		return
+	}
+	v.releaseRestoredCopy(inode, path)
+	if err = v.ec.EvictStream(inode); err != nil {
+		log.LogWarnf("hideCurrentVersion: evict stream fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, inode, err)
+	}
+	if err = v.mw.Evict(inode, path); err != nil {
+		log.LogWarnf("hideCurrentVersion: evict inode fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, inode, err)
+	}
+	deleteAttrCache(inode, v.name)
+	return nil
+}
+
+func (v *Volume) deleteVersion(path, versionId string, bypassGovernance bool) (deleteMarker bool, resultVersionId string, err error) {
+	var parentId uint64
+	var name string
+	if parentId, name, err = v.lookupObjectParent(path); err != nil {
+		if err == syscall.ENOENT {
+			err = NoSuchVersion
+		}
+		return
+	}
+	objectLock, err := v.metaLoader.loadObjectLock()
+	if err != nil {
+		log.LogErrorf("deleteVersion: load volume objetLock: volume(%v) err(%v)", v.name, err)
+		return
+	}
+
+	current, mode, err := v.mw.Lookup_ll(parentId, name)
+	if err != nil && err != syscall.ENOENT {
+		log.LogErrorf("deleteVersion: lookup fail: volume(%v) path(%v) parentID(%v) name(%v) err(%v)",
+			v.name, path, parentId, name, err)
+		return
+	}
+	if err == syscall.ENOENT || os.FileMode(mode).IsDir() {
+		current = 0
+	}
+	err = nil
+
+	var currentVersionId string
+	if current != 0 {
+		if currentVersionId, err = v.inodeVersionId(current); err != nil {
+			return
+		}
+	}
+	if current != 0 && currentVersionId == versionId {
+		if objectLock != nil {
+			if err = isObjectLocked(v, current, name, path, bypassGovernance); err != nil {
+				return
+			}
+		}
+		if _, err = v.mw.Delete_ll(parentId, name, false, path); err != nil {
+			log.LogErrorf("deleteVersion: delete dentry fail: volume(%v) path(%v) inode(%v) err(%v)",
+				v.name, path, current, err)
+			return
+		}
+		deleteDentryCache(parentId, name, v.name)
+		v.releaseRestoredCopy(current, path)
+		if err = v.ec.EvictStream(current); err != nil {
+			log.LogWarnf("deleteVersion: evict stream fail: volume(%v) path(%v) inode(%v) err(%v)",
+				v.name, path, current, err)
+		}
+		if err = v.mw.Evict(current, path); err != nil {
+			log.LogWarnf("deleteVersion: evict inode fail: volume(%v) path(%v) inode(%v) err(%v)",
+				v.name, path, current, err)
+		}
+		deleteAttrCache(current, v.name)
+		current = 0
+	} else {
+		var ver *objectVersion
+		if ver, err = v.getObjectVersion(path, versionId); err != nil {
+			return
+		}
+		if ver == nil {
+			err = NoSuchVersion
+			return
+		}
+		if !ver.DeleteMarker && objectLock != nil {
+			if err = isObjectLocked(v, ver.Inode, name, path, bypassGovernance); err != nil {
+				return
+			}
+		}
+		if err = v.removeObjectVersion(path, ver); err != nil {
+			return
+		}
+		if !ver.DeleteMarker {
+			v.releaseVersionInode(ver.Inode, path)
+		}
+		deleteMarker = ver.DeleteMarker
+	}
+	if current != 0 {
+		return deleteMarker, versionId, nil
+	}
+
+	// the newest noncurrent object becomes the current version
+	var promoted *objectVersion
+	if promoted, err = v.newestObjectVersion(path); err != nil {
+		return
+	}
+	if promoted == nil || promoted.DeleteMarker {
+		return deleteMarker, versionId, nil
+	}
+	if err = v.mw.DentryCreate_ll(parentId, name, promoted.Inode, DefaultFileMode, path); err != nil {
+		if err == syscall.EEXIST {
+			// a new object has been put, the version stays noncurrent.
+			return deleteMarker, versionId, nil
+		}
+		log.LogErrorf("deleteVersion: promote version fail: volume(%v) path(%v) inode(%v) versionId(%v) err(%v)",
+			v.name, path, promoted.Inode, promoted.VersionId, err)
+		return
+	}
+	updateDentryCache(parentId, promoted.Inode, DefaultFileMode, name, v.name)
+	if err = v.removeObjectVersion(path, promoted); err != nil {
+		return
+	}
+	return deleteMarker, versionId, nil
+}
+
+// ListObjectVersions lists all versions of objects in key order, and versions of the same object
+// are listed from the newest to the oldest.
+func (v *Volume) ListObjectVersions(opt *ListObjectVersionsOption) (result *ListObjectVersionsResult, err error) {
+	result = &ListObjectVersionsResult{}
+	scanner := &versionScanner{
+		v:        v,
+		opt:      opt,
+		result:   result,
+		prefixes: make(PrefixMap),
+	}
+	if scanner.index, err = v.versionIndex(); err != nil {
+		return
+	}
+	scanner.seekStart()
+	if err = scanner.scan(rootIno, ""); err == nil {
+		// the versions left are of the objects after the last current one
+		err = scanner.drainVersions("")
+	}
+	if err != nil && err != errVersionScanFinished {
+		log.LogErrorf("ListObjectVersions: scan fail: volume(%v) option(%+v) err(%v)", v.name, opt, err)
+		return
+	}
+	if err = v.supplyObjectVersions(result.Versions); err != nil {
+		log.LogErrorf("ListObjectVersions: supply versions fail: volume(%v) option(%+v) err(%v)", v.name, opt, err)
+		return
+	}
+	if result.Truncated {
+		result.NextKeyMarker = scanner.lastKey
+		if scanner.lastVersion != nil {
+			result.NextVersionIdMarker = scanner.lastVersion.VersionId
+		}
+	}
+	return
+}
+
+// supplyObjectVersions supplements the size, etag and version ID of versions.
+func (v *Volume) supplyObjectVersions(versions []*FSObjectVersion) (err error) {
+	inodes := make([]uint64, 0, len(versions))
+	for _, ver := range versions {
+		if !ver.DeleteMarker {
+			inodes = append(inodes, ver.Inode)
+		}
+	}
+	if len(inodes) == 0 {
+		return
+	}
+
+	inodeInfos := make(map[uint64]*proto.InodeInfo, len(inodes))
+	for _, info := range v.mw.BatchInodeGet(inodes) {
+		inodeInfos[info.Inode] = info
+	}
+	xattrs, err := v.mw.BatchGetXAttr(inodes, []string{XAttrKeyOSSETag, XAttrKeyOSSETagDeprecated, XAttrKeyOSSVersionId})
+	if err != nil {
+		log.LogErrorf("supplyObjectVersions: batch get xattr fail, inodes(%v), err(%v)", inodes, err)
+		return
+	}
+	inodeXAttrs := make(map[uint64]*proto.XAttrInfo, len(xattrs))
+	for _, xattr := range xattrs {
+		inodeXAttrs[xattr.Inode] = xattr
+	}
+
+	for _, ver := range versions {
+		if ver.DeleteMarker {
+			continue
+		}
+		if info, ok := inodeInfos[ver.Inode]; ok {
+			ver.Size = int64(info.Size)
+			ver.ModifyTime = info.ModifyTime
+			ver.StorageClass = info.StorageClass
+		}
+		xattr, ok := inodeXAttrs[ver.Inode]
+		if !ok {
+			xattr = &proto.XAttrInfo{Inode: ver.Inode, XAttrs: make(map[string]string)}
+		}
+		rawETag := string(xattr.Get(XAttrKeyOSSETag))
+		if len(rawETag) == 0 {
+			rawETag = string(xattr.Get(XAttrKeyOSSETagDeprecated))
+		}
+		if len(rawETag) > 0 {
+			ver.ETag = ParseETagValue(rawETag).ETag()
+		}
+		if ver.VersionId == "" {
+			if ver.VersionId = string(xattr.Get(XAttrKeyOSSVersionId)); ver.VersionId == "" {
+				ver.VersionId = NullVersionId
+			}
+		}
+	}
+	return
+}
+
+// versionScanner walks the directories in key order for the current objects, and merges them with
+// the noncurrent versions read from the version index in the same order. Only the entries of the
+// listed page and the current objects walked through are read, no matter how many versions are kept.
+type versionScanner struct {
+	v           *Volume
+	opt         *ListObjectVersionsOption
+	result      *ListObjectVersionsResult
+	prefixes    PrefixMap
+	count       uint64
+	lastKey     string
+	lastVersion *FSObjectVersion
+
+	index uint64
+	// the entries read from the version index but not visited yet
+	entries []*versionIndexEntry
+	// the index is read from the name next time, and the entry with the name skipName has been read.
+	from     string
+	skipName string
+	drained  bool
+	// the versions of the key marker are scanned for the version ID marker, which can't be located directly
+	scanMarker bool
+}
+
+type versionIndexEntry struct {
+	name string
+	key  string
+	ver  *objectVersion
+}
+
+// encodedBefore compares the keys in the order that they are listed.
+func encodedBefore(a, b string) bool {
+	return encodeIndexKey(a) < encodeIndexKey(b)
+}
+
+// seekStart locates the first entry of the version index to list according to the prefix and the markers.
+func (s *versionScanner) seekStart() {
+	s.from = encodeIndexKey(s.opt.Prefix)
+	if s.opt.KeyMarker == "" || encodedBefore(s.opt.KeyMarker, s.opt.Prefix) {
+		return
+	}
+	switch nano, ok := versionIdTime(s.opt.VersionIdMarker); {
+	case s.opt.VersionIdMarker == "":
+		// all versions of the key marker have been listed
+		s.seekVersions(prefixSuccessor(versionIndexPrefix(s.opt.KeyMarker)))
+	case ok:
+		s.from = versionIndexName(s.opt.KeyMarker, s.opt.VersionIdMarker, nano)
+		s.skipName = s.from
+	default:
+		s.from = versionIndexPrefix(s.opt.KeyMarker)
+		s.scanMarker = true
+	}
+}
+
+// prefixSuccessor returns the smallest name after all names with the prefix, empty if there is no such name.
+func prefixSuccessor(prefix string) string {
+	b := []byte(prefix)
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] < 0xff {
+			b[i]++
+			return string(b[:i+1])
+		}
+	}
+	return ""
+}
+
+// peekVersion returns the next entry of the version index with the prefix, nil if there is no more.
+func (s *versionScanner) peekVersion() (*versionIndexEntry, error) {
+	for len(s.entries) == 0 {
+		if s.index == 0 || s.drained {
+			return nil, nil
+		}
+		children, err := s.v.mw.ReadDirLimit_ll(s.index, s.from, versionScanReadLimit)
+		if err != nil {
+			return nil, err
+		}
+		s.drained = len(children) < versionScanReadLimit
+		for i := range children {
+			if children[i].Name == s.skipName {
+				continue
+			}
+			key, ver, err := parseVersionIndexEntry(&children[i])
+			if err != nil {
+				log.LogWarnf("peekVersion: parse version fail: volume(%v) index(%v) name(%q) err(%v)",
+					s.v.name, s.index, children[i].Name, err)
+				continue
+			}
+			// the keys with the prefix are continuous in the index
+			if !strings.HasPrefix(key, s.opt.Prefix) {
+				s.drained = true
+				break
+			}
+			s.entries = append(s.entries, &versionIndexEntry{name: children[i].Name, key: key, ver: ver})
+		}
+		if len(children) > 0 {
+			s.from = children[len(children)-1].Name
+			s.skipName = s.from
+		}
+	}
+	return s.entries[0], nil
+}
+
+// seekVersions skips the entries of the version index before the name, all entries are skipped if it is empty.
+func (s *versionScanner) seekVersions(name string) {
+	if name == "" {
+		s.entries, s.drained = nil, true
+		return
+	}
+	for len(s.entries) > 0 && s.entries[0].name < name {
+		s.entries = s.entries[1:]
+	}
+	if len(s.entries) == 0 && name > s.from {
+		s.from, s.skipName = name, ""
+	}
+}
+
+// drainVersions visits the objects without current version, whose versions are before the name
+// in the version index, all versions left are visited if the name is empty.
+func (s *versionScanner) drainVersions(before string) error {
+	for {
+		entry, err := s.peekVersion()
+		if err != nil {
+			return err
+		}
+		if entry == nil || before != "" && entry.name >= before {
+			return nil
+		}
+		if err = s.visitObject(entry.key, 0); err != nil {
+			return err
+		}
+		if len(s.entries) > 0 && s.entries[0] == entry {
+			s.entries = s.entries[1:]
+		}
+	}
+}
+
+// startName returns the name from which the directory should be scanned, according to the prefix and key marker.
+func (s *versionScanner) startName(dirPath string) string {
+	var from string
+	for _, key := range []string{s.opt.Prefix, s.opt.KeyMarker} {
+		if len(key) <= len(dirPath) || !strings.HasPrefix(key, dirPath) {
+			continue
+		}
+		name := key[len(dirPath):]
+		if idx := strings.Index(name, pathSep); idx >= 0 {
+			name = name[:idx]
+		}
+		if name > from {
+			from = name
+		}
+	}
+	return from
+}
+
+func (s *versionScanner) scan(dirIno uint64, dirPath string) (err error) {
+	from := s.startName(dirPath)
+	var fetched bool
+	for {
+		var children []proto.Dentry
+		if children, err = s.v.mw.ReadDirLimit_ll(dirIno, from, versionScanReadLimit); err != nil {
+			if err == syscall.ENOENT {
+				return nil
+			}
+			return
+		}
+		for i := range children {
+			dentry := &children[i]
+			// the start point is included in the result of ReadDirLimit_ll, skip the one that has been visited.
+			if fetched && i == 0 && dentry.Name == from {
+				continue
+			}
+			key := dirPath + dentry.Name
+			if os.FileMode(dentry.Type).IsDir() {
+				if err = s.drainVersions(encodeIndexKey(key + pathSep)); err != nil {
+					return
+				}
+				if err = s.visitDir(dentry.Inode, key+pathSep); err != nil {
+					return
+				}
+				continue
+			}
+			if err = s.drainVersions(versionIndexPrefix(key)); err != nil {
+				return
+			}
+			if err = s.visitObject(key, dentry.Inode); err != nil {
+				return
+			}
+		}
+		if len(children) < versionScanReadLimit {
+			return nil
+		}
+		from = children[len(children)-1].Name
+		fetched = true
+	}
+}
+
+func (s *versionScanner) visitDir(dirIno uint64, dirKey string) error {
+	if !strings.HasPrefix(dirKey, s.opt.Prefix) && !strings.HasPrefix(s.opt.Prefix, dirKey) {
+		return nil
+	}
+	if s.opt.KeyMarker != "" && encodedBefore(dirKey, s.opt.KeyMarker) && !strings.HasPrefix(s.opt.KeyMarker, dirKey) {
+		return nil
+	}
+	if strings.HasPrefix(dirKey, s.opt.Prefix) {
+		if commonPrefix, ok := s.commonPrefix(dirKey); ok {
+			return s.emitPrefix(commonPrefix)
+		}
+	}
+	return s.scan(dirIno, dirKey)
+}
+
+// visitObject lists the current version of the object, zero if it does not exist,
+// and the noncurrent versions of it at the head of the version index.
+func (s *versionScanner) visitObject(key string, current uint64) (err error) {
+	if !strings.HasPrefix(key, s.opt.Prefix) {
+		return nil
+	}
+	if commonPrefix, ok := s.commonPrefix(key); ok {
+		return s.emitPrefix(commonPrefix)
+	}
+	if s.opt.KeyMarker != "" && encodedBefore(key, s.opt.KeyMarker) {
+		return nil
+	}
+
+	latest := current == 0
+	if key == s.opt.KeyMarker {
+		// skip the versions which have been returned by the previous request
+		if s.opt.VersionIdMarker == "" {
+			return nil
+		}
+		latest = false
+		if err = s.skipMarker(key, current); err != nil {
+			return
+		}
+	} else if current != 0 {
+		if err = s.emit(&FSObjectVersion{Key: key, Inode: current, IsLatest: true}); err != nil {
+			return
+		}
+	}
+
+	for {
+		var entry *versionIndexEntry
+		if entry, err = s.peekVersion(); err != nil {
+			return
+		}
+		if entry == nil || entry.key != key {
+			return nil
+		}
+		err = s.emit(&FSObjectVersion{
+			Key:          key,
+			VersionId:    entry.ver.VersionId,
+			IsLatest:     latest,
+			DeleteMarker: entry.ver.DeleteMarker,
+			Inode:        entry.ver.Inode,
+			ModifyTime:   time.Unix(0, entry.ver.ModifyTime),
+		})
+		if err != nil {
+			return
+		}
+		s.entries = s.entries[1:]
+		latest = false
+	}
+}
+
+// skipMarker skips the noncurrent versions of the key marker until the version ID marker. The version index
+// has been located after the marker by seekStart, unless the marker is the null version or the version
+// whose ID is not generated by newVersionId, which is found by scanning the versions of the key.
+func (s *versionScanner) skipMarker(key string, current uint64) (err error) {
+	if !s.scanMarker {
+		return
+	}
+	s.scanMarker = false
+	if current != 0 {
+		var versionId string
+		if versionId, err = s.v.inodeVersionId(current); err != nil || versionId == s.opt.VersionIdMarker {
+			return
+		}
+	}
+	for {
+		var entry *versionIndexEntry
+		if entry, err = s.peekVersion(); err != nil || entry == nil || entry.key != key {
+			return
+		}
+		s.entries = s.entries[1:]
+		if entry.ver.VersionId == s.opt.VersionIdMarker {
+			return
+		}
+	}
+}
+
+func (s *versionScanner) emit(entry *FSObjectVersion) error {
+	if s.count >= s.opt.MaxKeys {
+		s.result.Truncated = true
+		return errVersionScanFinished
+	}
+	s.result.Versions = append(s.result.Versions, entry)
+	s.count++
+	s.lastKey = entry.Key
+	s.lastVersion = entry
+	return nil
+}
+
+func (s *versionScanner) commonPrefix(key string) (string, bool) {
+	if s.opt.Delimiter == "" {
+		return "", false
+	}
+	rest := strings.TrimPrefix(key, s.opt.Prefix)
+	idx := strings.Index(rest, s.opt.Delimiter)
+	if idx < 0 {
+		return "", false
+	}
+	return s.opt.Prefix + rest[:idx] + s.opt.Delimiter, true
+}
+
+func (s *versionScanner) emitPrefix(commonPrefix string) error {
+	// the versions under the common prefix are not listed
+	s.seekVersions(prefixSuccessor(encodeIndexKey(commonPrefix)))
+	if s.prefixes.contain(commonPrefix) {
+		return nil
+	}
+	if s.opt.KeyMarker != "" && !encodedBefore(s.opt.KeyMarker, commonPrefix) {
+		return nil
+	}
+	if s.count >= s.opt.MaxKeys {
+		s.result.Truncated = true
+		return errVersionScanFinished
+	}
+	s.prefixes.AddPrefix(commonPrefix)
+	s.result.CommonPrefixes = append(s.result.CommonPrefixes, commonPrefix)
+	s.count++
+	s.lastKey = commonPrefix
+	s.lastVersion = nil
+	return nil
+}
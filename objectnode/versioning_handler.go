@@ -0,0 +1,291 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// Get bucket versioning
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketVersioning.html
+func (o *ObjectNode) getBucketVersioningHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("getBucketVersioningHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var config *VersioningConfiguration
+	if config, err = vol.metaLoader.loadVersioning(); err != nil {
+		log.LogErrorf("getBucketVersioningHandler: load versioning fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	// the bucket that has never been versioned returns an empty configuration
+	if config == nil {
+		config = &VersioningConfiguration{}
+	}
+	config.XMLNS = XMLNS
+
+	var data []byte
+	if data, err = MarshalXMLEntity(config); err != nil {
+		log.LogErrorf("getBucketVersioningHandler: xml marshal fail: requestID(%v) volume(%v) config(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), config, err)
+		return
+	}
+
+	writeSuccessResponseXML(w, data)
+}
+
+// Put bucket versioning
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketVersioning.html
+func (o *ObjectNode) putBucketVersioningHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("putBucketVersioningHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var body []byte
+	if body, err = io.ReadAll(io.LimitReader(r.Body, MaxVersioningConfigSize+1)); err != nil {
+		log.LogErrorf("putBucketVersioningHandler: read request body fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if len(body) > MaxVersioningConfigSize {
+		errorCode = EntityTooLarge
+		return
+	}
+
+	var config *VersioningConfiguration
+	if config, err = ParseVersioningConfigFromXML(body); err != nil {
+		log.LogErrorf("putBucketVersioningHandler: parse versioning config fail: requestID(%v) volume(%v) config(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), err)
+		return
+	}
+
+	// versioning cannot be suspended when object lock or replication is configured for the bucket
+	if config.Suspended() {
+		var objectLock *ObjectLockConfig
+		if objectLock, err = vol.metaLoader.loadObjectLock(); err != nil {
+			log.LogErrorf("putBucketVersioningHandler: load object lock fail: requestID(%v) volume(%v) err(%v)",
+				GetRequestID(r), vol.Name(), err)
+			return
+		}
+		if objectLock != nil && !objectLock.IsEmpty() {
+			errorCode = InvalidBucketState
+			return
+		}
+		var replication *ReplicationConfiguration
+		if replication, err = vol.metaLoader.loadReplication(); err != nil {
+			log.LogErrorf("putBucketVersioningHandler: load replication fail: requestID(%v) volume(%v) err(%v)",
+				GetRequestID(r), vol.Name(), err)
+			return
+		}
+		if replication != nil {
+			errorCode = InvalidBucketState
+			return
+		}
+	}
+
+	if err = vol.setBucketVersioning(config); err != nil {
+		log.LogErrorf("putBucketVersioningHandler: store versioning config fail: requestID(%v) volume(%v) config(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), config, err)
+		return
+	}
+
+	log.LogInfof("Audit: put bucket versioning: requestID(%v) volume(%v) status(%v)",
+		GetRequestID(r), vol.Name(), config.Status)
+}
+
+// List object versions
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_ListObjectVersions.html
+func (o *ObjectNode) listObjectVersionsHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("listObjectVersionsHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	// QPS and Concurrency Limit
+	rateLimit := o.AcquireRateLimiter()
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+		return
+	}
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+
+	// get options
+	prefix := r.URL.Query().Get(ParamPrefix)
+	delimiter := r.URL.Query().Get(ParamPartDelimiter)
+	keyMarker := r.URL.Query().Get(ParamKeyMarker)
+	versionIdMarker := r.URL.Query().Get(ParamVersionIdMarker)
+	maxKeys := r.URL.Query().Get(ParamMaxKeys)
+	encodingType := r.URL.Query().Get(ParamEncodingType)
+
+	var maxKeysInt uint64
+	if maxKeys != "" {
+		if maxKeysInt, err = strconv.ParseUint(maxKeys, 10, 16); err != nil {
+			log.LogErrorf("listObjectVersionsHandler: parse max keys fail: requestID(%v) volume(%v) maxKeys(%v) err(%v)",
+				GetRequestID(r), vol.Name(), maxKeys, err)
+			errorCode = InvalidArgument
+			return
+		}
+		if maxKeysInt > MaxKeys {
+			maxKeysInt = MaxKeys
+		}
+	} else {
+		maxKeysInt = uint64(MaxKeys)
+	}
+	if encodingType != "" && encodingType != "url" {
+		errorCode = InvalidArgument
+		return
+	}
+	if versionIdMarker != "" && keyMarker == "" {
+		errorCode = InvalidArgument
+		return
+	}
+
+	option := &ListObjectVersionsOption{
+		Prefix:          prefix,
+		Delimiter:       delimiter,
+		KeyMarker:       keyMarker,
+		VersionIdMarker: versionIdMarker,
+		MaxKeys:         maxKeysInt,
+	}
+	var result *ListObjectVersionsResult
+	if result, err = vol.ListObjectVersions(option); err != nil {
+		log.LogErrorf("listObjectVersionsHandler: list object versions fail: requestID(%v) volume(%v) option(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), option, err)
+		return
+	}
+
+	bucketOwner := NewBucketOwner(vol)
+	versions := make([]interface{}, 0, len(result.Versions))
+	for _, ver := range result.Versions {
+		if ver.DeleteMarker {
+			versions = append(versions, &DeleteMarkerEntry{
+				Key:          encodeKey(ver.Key, encodingType),
+				VersionId:    ver.VersionId,
+				IsLatest:     ver.IsLatest,
+				LastModified: formatTimeISO(ver.ModifyTime),
+				Owner:        bucketOwner,
+			})
+			continue
+		}
+		versions = append(versions, &VersionEntry{
+			Key:          encodeKey(ver.Key, encodingType),
+			VersionId:    ver.VersionId,
+			IsLatest:     ver.IsLatest,
+			LastModified: formatTimeISO(ver.ModifyTime),
+			ETag:         wrapUnescapedQuot(ver.ETag),
+			Size:         int(ver.Size),
+			StorageClass: StorageClassStandard,
+			Owner:        bucketOwner,
+		})
+	}
+	commonPrefixes := make([]*CommonPrefix, 0, len(result.CommonPrefixes))
+	for _, commonPrefix := range result.CommonPrefixes {
+		commonPrefixes = append(commonPrefixes, &CommonPrefix{Prefix: encodeKey(commonPrefix, encodingType)})
+	}
+
+	listVersionsResult := &ListVersionsResult{
+		Bucket:              param.Bucket(),
+		Prefix:              prefix,
+		KeyMarker:           keyMarker,
+		VersionIdMarker:     versionIdMarker,
+		MaxKeys:             int(maxKeysInt),
+		Delimiter:           delimiter,
+		EncodingType:        encodingType,
+		IsTruncated:         result.Truncated,
+		NextKeyMarker:       encodeKey(result.NextKeyMarker, encodingType),
+		NextVersionIdMarker: result.NextVersionIdMarker,
+		Versions:            versions,
+		CommonPrefixes:      commonPrefixes,
+	}
+	response, err := MarshalXMLEntity(listVersionsResult)
+	if err != nil {
+		log.LogErrorf("listObjectVersionsHandler: xml marshal result fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+
+	writeSuccessResponseXML(w, response)
+}
+
+// setDeleteMarkerHeader marks the response when the requested version or the current version is a delete marker.
+func setDeleteMarkerHeader(w http.ResponseWriter, vol *Volume, path, versionId string, err error) {
+	if err == MethodNotAllowed {
+		w.Header().Set(XAmzDeleteMarker, "true")
+		w.Header().Set(XAmzVersionId, versionId)
+		w.Header().Set(LastModified, formatTimeRFC1123(time.Now()))
+		return
+	}
+	if err == syscall.ENOENT && versionId == "" {
+		if markerVersionId, ok := vol.LatestDeleteMarker(path); ok {
+			w.Header().Set(XAmzDeleteMarker, "true")
+			w.Header().Set(XAmzVersionId, markerVersionId)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersioningConfig(t *testing.T) {
+	tests := []struct {
+		value       string
+		expectedErr error
+	}{
+		{
+			value: `<VersioningConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+						<Status>Enabled</Status>
+					</VersioningConfiguration>`,
+			expectedErr: nil,
+		},
+		{
+			value: `<VersioningConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+						<Status>Suspended</Status>
+					</VersioningConfiguration>`,
+			expectedErr: nil,
+		},
+		{
+			value: `<VersioningConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+						<Status>enabled</Status>
+					</VersioningConfiguration>`,
+			expectedErr: IllegalVersioningConfiguration,
+		},
+		{
+			value:       `<VersioningConfiguration></VersioningConfiguration>`,
+			expectedErr: IllegalVersioningConfiguration,
+		},
+		{
+			value:       `<VersioningConfiguration><Status>Enabled</Status>`,
+			expectedErr: MalformedXML,
+		},
+	}
+
+	for _, tc := range tests {
+		_, err := ParseVersioningConfigFromXML([]byte(tc.value))
+		require.Equal(t, tc.expectedErr, err)
+	}
+
+	_, err := ParseVersioningConfigFromXML([]byte(`<VersioningConfiguration>
+		<Status>Enabled</Status><MfaDelete>Enabled</MfaDelete></VersioningConfiguration>`))
+	require.Error(t, err)
+}
+
+func TestVersionIdTime(t *testing.T) {
+	before := time.Now().UnixNano()
+	nano, ok := versionIdTime(newVersionId())
+	require.True(t, ok)
+	require.True(t, nano >= before && nano <= time.Now().UnixNano())
+
+	for _, versionId := range []string{NullVersionId, "", "0123456789abcdef0123456", "0123456789abcdefg1234567", "ffffffffffffffff01234567"} {
+		_, ok = versionIdTime(versionId)
+		require.False(t, ok, versionId)
+	}
+}
+
+func TestVersionIndexEntry(t *testing.T) {
+	versionId := newVersionId()
+	nano, _ := versionIdTime(versionId)
+	name := versionIndexName("dir/a.txt", versionId, 0)
+	key, ver, err := parseVersionIndexEntry(&proto.Dentry{Name: name, Inode: 10})
+	require.NoError(t, err)
+	require.Equal(t, "dir/a.txt", key)
+	require.Equal(t, &objectVersion{VersionId: versionId, Inode: 10, ModifyTime: nano}, ver)
+
+	// the null version is ordered by its modify time, and the delete marker points to no inode
+	key, ver, err = parseVersionIndexEntry(&proto.Dentry{Name: versionIndexName("a", NullVersionId, 20)})
+	require.NoError(t, err)
+	require.Equal(t, "a", key)
+	require.Equal(t, &objectVersion{VersionId: NullVersionId, DeleteMarker: true, ModifyTime: 20}, ver)
+
+	for _, name := range []string{"a", "a\x00", "a\x000123", "a\x00000000000000000g" + NullVersionId, "\x01\x09\x00" + name[len("dir/a.txt")+1:]} {
+		_, _, err = parseVersionIndexEntry(&proto.Dentry{Name: name})
+		require.Error(t, err, name)
+	}
+}
+
+func TestVersionIndexOrder(t *testing.T) {
+	// the versions of an object are sorted from the newest to the oldest, and all versions
+	// of an object are sorted before the objects whose keys have it as prefix.
+	names := []string{
+		versionIndexName("a", fmt.Sprintf("%016x%08x", 30, 0), 0),
+		versionIndexName("a", NullVersionId, 20),
+		versionIndexName("a", fmt.Sprintf("%016x%08x", 10, 0), 0),
+		versionIndexName("a/b", NullVersionId, 10),
+		versionIndexName("a/b/c", NullVersionId, 10),
+		versionIndexName("a0", NullVersionId, 40),
+		versionIndexName("b", NullVersionId, 10),
+	}
+	require.True(t, sort.StringsAreSorted(names))
+	for _, name := range names[:3] {
+		require.True(t, strings.HasPrefix(name, versionIndexPrefix("a")))
+	}
+	require.True(t, names[2] < prefixSuccessor(versionIndexPrefix("a")))
+	require.True(t, names[3] >= prefixSuccessor(versionIndexPrefix("a")))
+	require.True(t, names[4] < prefixSuccessor(encodeIndexKey("a/")))
+	require.True(t, names[5] >= prefixSuccessor(encodeIndexKey("a/")))
+
+	require.Equal(t, "b", prefixSuccessor("a"))
+	require.Equal(t, "b", prefixSuccessor("a\xff\xff"))
+	require.Equal(t, "", prefixSuccessor("\xff"))
+	require.True(t, encodedBefore("a/b", "a.txt"))
+	require.False(t, encodedBefore("a.txt", "a/b"))
+}
+
+func TestMarshalListVersionsResult(t *testing.T) {
+	result := &ListVersionsResult{
+		Bucket:  "bucket",
+		MaxKeys: 1000,
+		Versions: []interface{}{
+			&DeleteMarkerEntry{Key: "a", VersionId: "2", IsLatest: true},
+			&VersionEntry{Key: "a", VersionId: "1", ETag: "\"etag\"", Size: 1, StorageClass: StorageClassStandard},
+		},
+		CommonPrefixes: []*CommonPrefix{{Prefix: "dir/"}},
+	}
+	data, err := MarshalXMLEntity(result)
+	require.NoError(t, err)
+	body := string(data)
+	require.True(t, strings.Contains(body, "<ListVersionsResult>"))
+	markerIdx := strings.Index(body, "<DeleteMarker><Key>a</Key><VersionId>2</VersionId><IsLatest>true</IsLatest>")
+	versionIdx := strings.Index(body, "<Version><Key>a</Key><VersionId>1</VersionId><IsLatest>false</IsLatest>")
+	require.True(t, markerIdx > 0)
+	require.True(t, versionIdx > markerIdx)
+	require.True(t, strings.Contains(body, "<CommonPrefixes><Prefix>dir/</Prefix></CommonPrefixes>"))
+}
@@ -0,0 +1,224 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/WebsiteHosting.html
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	MaxWebsiteConfigSize   = 128 << 10
+	MaxWebsiteRoutingRules = 50
+)
+
+type WebsiteConfiguration struct {
+	XMLName               xml.Name              `xml:"WebsiteConfiguration" json:"-"`
+	XMLNS                 string                `xml:"xmlns,attr,omitempty" json:"-"`
+	IndexDocument         *WebsiteIndexDocument `xml:"IndexDocument,omitempty" json:"index_document,omitempty"`
+	ErrorDocument         *WebsiteErrorDocument `xml:"ErrorDocument,omitempty" json:"error_document,omitempty"`
+	RedirectAllRequestsTo *WebsiteRedirectAll   `xml:"RedirectAllRequestsTo,omitempty" json:"redirect_all_requests_to,omitempty"`
+	RoutingRules          []*WebsiteRoutingRule `xml:"RoutingRules>RoutingRule,omitempty" json:"routing_rules,omitempty"`
+}
+
+type WebsiteIndexDocument struct {
+	Suffix string `xml:"Suffix" json:"suffix"`
+}
+
+type WebsiteErrorDocument struct {
+	Key string `xml:"Key" json:"key"`
+}
+
+type WebsiteRedirectAll struct {
+	HostName string `xml:"HostName" json:"host_name"`
+	Protocol string `xml:"Protocol,omitempty" json:"protocol,omitempty"`
+}
+
+type WebsiteRoutingRule struct {
+	Condition *WebsiteCondition `xml:"Condition,omitempty" json:"condition,omitempty"`
+	Redirect  *WebsiteRedirect  `xml:"Redirect" json:"redirect"`
+}
+
+type WebsiteCondition struct {
+	HttpErrorCodeReturnedEquals string `xml:"HttpErrorCodeReturnedEquals,omitempty" json:"http_error_code,omitempty"`
+	KeyPrefixEquals             string `xml:"KeyPrefixEquals,omitempty" json:"key_prefix,omitempty"`
+}
+
+type WebsiteRedirect struct {
+	HostName             string `xml:"HostName,omitempty" json:"host_name,omitempty"`
+	HttpRedirectCode     string `xml:"HttpRedirectCode,omitempty" json:"http_redirect_code,omitempty"`
+	Protocol             string `xml:"Protocol,omitempty" json:"protocol,omitempty"`
+	ReplaceKeyPrefixWith string `xml:"ReplaceKeyPrefixWith,omitempty" json:"replace_key_prefix_with,omitempty"`
+	ReplaceKeyWith       string `xml:"ReplaceKeyWith,omitempty" json:"replace_key_with,omitempty"`
+}
+
+func newWebsiteError(message string) *ErrorCode {
+	return NewError("InvalidArgument", message, http.StatusBadRequest)
+}
+
+func ParseWebsiteConfigFromXML(data []byte) (*WebsiteConfiguration, error) {
+	config := &WebsiteConfiguration{}
+	if err := xml.Unmarshal(data, config); err != nil {
+		return nil, MalformedXML
+	}
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func validWebsiteProtocol(protocol string) bool {
+	return protocol == "" || protocol == "http" || protocol == "https"
+}
+
+func (c *WebsiteConfiguration) validate() *ErrorCode {
+	if c.RedirectAllRequestsTo != nil {
+		if c.IndexDocument != nil || c.ErrorDocument != nil || len(c.RoutingRules) > 0 {
+			return newWebsiteError("RedirectAllRequestsTo cannot be provided in conjunction with other Routing Rules.")
+		}
+		if c.RedirectAllRequestsTo.HostName == "" {
+			return newWebsiteError("A host name must be provided in RedirectAllRequestsTo.")
+		}
+		if !validWebsiteProtocol(c.RedirectAllRequestsTo.Protocol) {
+			return newWebsiteError("Invalid protocol, protocol can be http or https.")
+		}
+		return nil
+	}
+	if c.IndexDocument == nil {
+		return newWebsiteError("A value for IndexDocument Suffix must be provided if RedirectAllRequestsTo is empty.")
+	}
+	if suffix := c.IndexDocument.Suffix; suffix == "" || strings.Contains(suffix, "/") {
+		return newWebsiteError("The IndexDocument Suffix is not well formed.")
+	}
+	if c.ErrorDocument != nil && c.ErrorDocument.Key == "" {
+		return newWebsiteError("The ErrorDocument Key is not well formed.")
+	}
+	if len(c.RoutingRules) > MaxWebsiteRoutingRules {
+		return newWebsiteError("The number of routing rules must not exceed the allowed limit of 50.")
+	}
+	for _, rule := range c.RoutingRules {
+		if err := rule.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *WebsiteRoutingRule) validate() *ErrorCode {
+	if r.Redirect == nil {
+		return newWebsiteError("A Redirect must be provided in the RoutingRule.")
+	}
+	if r.Condition != nil && r.Condition.HttpErrorCodeReturnedEquals != "" {
+		code, err := strconv.Atoi(r.Condition.HttpErrorCodeReturnedEquals)
+		if err != nil || code < 400 || code > 599 {
+			return newWebsiteError("The provided HTTP error code is not valid. Valid codes are 4XX or 5XX.")
+		}
+	}
+	redirect := r.Redirect
+	if redirect.ReplaceKeyPrefixWith != "" && redirect.ReplaceKeyWith != "" {
+		return newWebsiteError("You can only define ReplaceKeyPrefix or ReplaceKey but not both.")
+	}
+	if !validWebsiteProtocol(redirect.Protocol) {
+		return newWebsiteError("Invalid protocol, protocol can be http or https.")
+	}
+	if redirect.HttpRedirectCode != "" {
+		code, err := strconv.Atoi(redirect.HttpRedirectCode)
+		if err != nil || code <= 300 || code > 399 {
+			return newWebsiteError("The provided HTTP redirect code is not valid. Valid codes are 3XX except 300.")
+		}
+	}
+	return nil
+}
+
+// match checks whether the rule applies to the key and the status code of the response, the status
+// code is 0 before the object is served, then only the rules without error code condition apply.
+func (r *WebsiteRoutingRule) match(key string, code int) bool {
+	if r.Condition == nil {
+		return code == 0
+	}
+	if !strings.HasPrefix(key, r.Condition.KeyPrefixEquals) {
+		return false
+	}
+	if r.Condition.HttpErrorCodeReturnedEquals == "" {
+		return code == 0
+	}
+	return r.Condition.HttpErrorCodeReturnedEquals == strconv.Itoa(code)
+}
+
+// location returns the url which the request of the key is redirected to, and the redirect code.
+func (r *WebsiteRoutingRule) location(req *http.Request, key string) (string, int) {
+	redirect := r.Redirect
+	if redirect.ReplaceKeyWith != "" {
+		key = redirect.ReplaceKeyWith
+	} else if redirect.ReplaceKeyPrefixWith != "" {
+		prefix := ""
+		if r.Condition != nil {
+			prefix = r.Condition.KeyPrefixEquals
+		}
+		key = redirect.ReplaceKeyPrefixWith + strings.TrimPrefix(key, prefix)
+	}
+	code := http.StatusMovedPermanently
+	if redirect.HttpRedirectCode != "" {
+		code, _ = strconv.Atoi(redirect.HttpRedirectCode)
+	}
+	return websiteURL(req, redirect.Protocol, redirect.HostName, "/"+key), code
+}
+
+// routingRule returns the first routing rule which matches the key and the status code.
+func (c *WebsiteConfiguration) routingRule(key string, code int) *WebsiteRoutingRule {
+	for _, rule := range c.RoutingRules {
+		if rule.match(key, code) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// indexKey returns the key of the object to be served, the index document is served for
+// the root and the keys end with a slash.
+func (c *WebsiteConfiguration) indexKey(key string) string {
+	if key == "" || strings.HasSuffix(key, "/") {
+		return key + c.IndexDocument.Suffix
+	}
+	return key
+}
+
+// websiteURL builds the redirect location of the unescaped path, the protocol and host of the
+// request are used if they are not specified.
+func websiteURL(req *http.Request, protocol, host, path string) string {
+	if host == "" {
+		host = req.Host
+	}
+	if protocol == "" {
+		protocol = "http"
+		if req.TLS != nil {
+			protocol = "https"
+		}
+	}
+	return (&url.URL{Scheme: protocol, Host: host, Path: path}).String()
+}
+
+func storeBucketWebsite(bytes []byte, vol *Volume) (err error) {
+	return vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSWebsite, bytes)
+}
+
+func deleteBucketWebsite(vol *Volume) (err error) {
+	return vol.store.Delete(vol.name, bucketRootPath, XAttrKeyOSSWebsite)
+}
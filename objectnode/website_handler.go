@@ -0,0 +1,395 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// websiteRouteKey is the route variable of the requested key of the website endpoint, which is
+// different from the object key of the S3 API, so that the policy check is left to the handler.
+const websiteRouteKey = "key"
+
+// Get bucket website
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketWebsite.html
+func (o *ObjectNode) getBucketWebsiteHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("getBucketWebsiteHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var config *WebsiteConfiguration
+	if config, err = vol.metaLoader.loadWebsite(); err != nil {
+		log.LogErrorf("getBucketWebsiteHandler: load website fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if config == nil {
+		errorCode = NoSuchWebsiteConfiguration
+		return
+	}
+	config.XMLNS = XMLNS
+
+	var data []byte
+	if data, err = MarshalXMLEntity(config); err != nil {
+		log.LogErrorf("getBucketWebsiteHandler: xml marshal fail: requestID(%v) volume(%v) config(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), config, err)
+		return
+	}
+
+	writeSuccessResponseXML(w, data)
+}
+
+// Put bucket website
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketWebsite.html
+func (o *ObjectNode) putBucketWebsiteHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("putBucketWebsiteHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var body []byte
+	if body, err = io.ReadAll(io.LimitReader(r.Body, MaxWebsiteConfigSize+1)); err != nil {
+		log.LogErrorf("putBucketWebsiteHandler: read request body fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if len(body) > MaxWebsiteConfigSize {
+		errorCode = EntityTooLarge
+		return
+	}
+
+	var config *WebsiteConfiguration
+	if config, err = ParseWebsiteConfigFromXML(body); err != nil {
+		log.LogErrorf("putBucketWebsiteHandler: parse website config fail: requestID(%v) volume(%v) config(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), err)
+		return
+	}
+
+	if body, err = json.Marshal(config); err != nil {
+		log.LogErrorf("putBucketWebsiteHandler: json marshal website config fail: requestID(%v) volume(%v) config(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), config, err)
+		return
+	}
+	if err = storeBucketWebsite(body, vol); err != nil {
+		log.LogErrorf("putBucketWebsiteHandler: store website config fail: requestID(%v) volume(%v) config(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), err)
+		return
+	}
+	vol.metaLoader.storeWebsite(config)
+
+	log.LogInfof("Audit: put bucket website: requestID(%v) volume(%v) config(%v)",
+		GetRequestID(r), vol.Name(), string(body))
+}
+
+// Delete bucket website
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketWebsite.html
+func (o *ObjectNode) deleteBucketWebsiteHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("deleteBucketWebsiteHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	if err = deleteBucketWebsite(vol); err != nil {
+		log.LogErrorf("deleteBucketWebsiteHandler: delete website config fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	vol.metaLoader.storeWebsite(nil)
+	log.LogInfof("Audit: delete bucket website: requestID(%v) volume(%v)", GetRequestID(r), vol.Name())
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// websiteHandler serves the bucket as a static website for the requests to the website endpoint.
+// All the requests are served as anonymous requests, and each object to be served is checked
+// against the bucket policy and the object ACL, just like the anonymous GetObject.
+// Reference: https://docs.aws.amazon.com/AmazonS3/latest/userguide/WebsiteEndpoints.html
+func (o *ObjectNode) websiteHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		errorCode = MethodNotAllowed
+		return
+	}
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("websiteHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var config *WebsiteConfiguration
+	if config, err = vol.metaLoader.loadWebsite(); err != nil {
+		log.LogErrorf("websiteHandler: load website fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if config == nil {
+		errorCode = NoSuchWebsiteConfiguration
+		return
+	}
+
+	if redirect := config.RedirectAllRequestsTo; redirect != nil {
+		http.Redirect(w, r, websiteURL(r, redirect.Protocol, redirect.HostName, r.URL.Path), http.StatusMovedPermanently)
+		return
+	}
+
+	key := param.vars[websiteRouteKey]
+	if rule := config.routingRule(key, 0); rule != nil {
+		location, code := rule.location(r, key)
+		http.Redirect(w, r, location, code)
+		return
+	}
+
+	// QPS and Concurrency Limit
+	rateLimit := o.AcquireRateLimiter()
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+		return
+	}
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+
+	object, status, err := o.loadWebsiteObject(param, vol, config.indexKey(key))
+	if err != nil {
+		return
+	}
+	// the request of a folder without the trailing slash is redirected to the folder if it has the index document
+	if status == http.StatusNotFound && key != "" && !strings.HasSuffix(key, "/") {
+		var indexStatus int
+		if _, indexStatus, err = o.loadWebsiteObject(param, vol, key+"/"+config.IndexDocument.Suffix); err != nil {
+			return
+		}
+		if indexStatus == http.StatusOK {
+			http.Redirect(w, r, websiteURL(r, "", "", "/"+key+"/"), http.StatusFound)
+			return
+		}
+	}
+	if status == http.StatusOK {
+		if errorCode = CheckConditionInHeader(r, object.info); errorCode != nil {
+			return
+		}
+		err = o.writeWebsiteObject(w, r, param, vol, object, status)
+		return
+	}
+
+	if rule := config.routingRule(key, status); rule != nil {
+		location, code := rule.location(r, key)
+		http.Redirect(w, r, location, code)
+		return
+	}
+	if config.ErrorDocument != nil {
+		var errorDocument *websiteObject
+		var errorStatus int
+		if errorDocument, errorStatus, err = o.loadWebsiteObject(param, vol, config.ErrorDocument.Key); err != nil {
+			return
+		}
+		if errorStatus == http.StatusOK {
+			err = o.writeWebsiteObject(w, r, param, vol, errorDocument, status)
+			return
+		}
+	}
+	if status == http.StatusNotFound {
+		errorCode = NoSuchKey
+	} else {
+		errorCode = AccessDenied
+	}
+}
+
+type websiteObject struct {
+	key   string
+	info  *FSFileInfo
+	xattr *proto.XAttrInfo
+}
+
+// loadWebsiteObject loads the object to be served, it returns 403 if the object is not readable
+// by the anonymous users, and 404 if the object does not exist.
+func (o *ObjectNode) loadWebsiteObject(param *RequestParam, vol *Volume, key string) (*websiteObject, int, error) {
+	allowed, err := websiteReadable(param, vol, key)
+	if err != nil {
+		log.LogErrorf("loadWebsiteObject: check permission fail: requestID(%v) volume(%v) key(%v) err(%v)",
+			GetRequestID(param.r), vol.Name(), key, err)
+		return nil, 0, err
+	}
+	if !allowed {
+		return nil, http.StatusForbidden, nil
+	}
+	info, xattr, err := vol.ObjectMeta(key)
+	if err == syscall.ENOENT || (err == nil && info.Mode.IsDir()) {
+		return nil, http.StatusNotFound, nil
+	}
+	if err != nil {
+		log.LogErrorf("loadWebsiteObject: get object meta fail: requestID(%v) volume(%v) key(%v) err(%v)",
+			GetRequestID(param.r), vol.Name(), key, err)
+		return nil, 0, err
+	}
+	return &websiteObject{key: key, info: info, xattr: xattr}, http.StatusOK, nil
+}
+
+// websiteReadable checks whether the object is readable by the anonymous users according to the
+// bucket policy and the ACL of the object, as the policy check does for the anonymous GetObject.
+func websiteReadable(param *RequestParam, vol *Volume, key string) (bool, error) {
+	policy, err := vol.metaLoader.loadPolicy()
+	if err != nil {
+		return false, err
+	}
+	pab, err := vol.publicAccessBlock()
+	if err != nil {
+		return false, err
+	}
+	if policy != nil && !policy.IsEmpty() {
+		getParam := *param
+		getParam.object = key
+		getParam.action = proto.OSSGetObjectAction
+		getParam.apiName = GET_OBJECT
+		conditionCheck := map[string]string{
+			SOURCEIP: param.sourceIP,
+			REFERER:  param.r.Referer(),
+			HOST:     param.r.Host,
+			KEYNAME:  key,
+		}
+		result := policy.IsAllowed(&getParam, AnonymousUser, vol.owner, conditionCheck)
+		switch pab.checkPolicyResult(result, policy, AnonymousUser, vol.owner) {
+		case POLICY_ALLOW:
+			return true, nil
+		case POLICY_DENY:
+			return false, nil
+		default:
+			// the acl should be checked
+		}
+	}
+	acl, err := getObjectACL(vol, key, true)
+	if err != nil && err != syscall.ENOENT {
+		return false, err
+	}
+	acl = pab.effectiveACL(acl)
+	return acl != nil && acl.IsAllowed(AnonymousUser, proto.OSSGetObjectAction), nil
+}
+
+func (o *ObjectNode) writeWebsiteObject(w http.ResponseWriter, r *http.Request, param *RequestParam, vol *Volume,
+	object *websiteObject, status int,
+) (err error) {
+	info := object.info
+	sse, dataKey, err := checkObjectSSE(w, r, object.xattr)
+	if err != nil {
+		log.LogErrorf("writeWebsiteObject: check sse fail: requestID(%v) volume(%v) key(%v) err(%v)",
+			GetRequestID(r), vol.Name(), object.key, err)
+		return
+	}
+
+	w.Header().Set(LastModified, formatTimeRFC1123(info.ModifyTime))
+	if len(info.MIMEType) > 0 {
+		w.Header().Set(ContentType, info.MIMEType)
+	} else {
+		w.Header().Set(ContentType, ValueContentTypeStream)
+	}
+	if len(info.CacheControl) > 0 {
+		w.Header().Set(CacheControl, info.CacheControl)
+	}
+	if len(info.Expires) > 0 {
+		w.Header().Set(Expires, info.Expires)
+	}
+	if len(info.ETag) > 0 {
+		w.Header()[ETag] = []string{wrapUnescapedQuot(info.ETag)}
+	}
+	w.Header().Set(ContentLength, strconv.FormatInt(info.Size, 10))
+	w.WriteHeader(status)
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	size := uint64(info.Size)
+	var writer io.Writer = w
+	if size > DefaultFlowLimitSize {
+		writer = o.AcquireRateLimiter().GetResponseWriter(vol.owner, param.apiName, w)
+	}
+	if sse != nil {
+		writer = sse.decryptWriter(writer, dataKey, 0)
+	}
+	// the response header has been written, so the error is only logged
+	if readErr := vol.readFile(info.Inode, size, object.key, writer, 0, size, info.StorageClass); readErr != nil {
+		log.LogErrorf("writeWebsiteObject: read file fail: requestID(%v) volume(%v) key(%v) err(%v)",
+			GetRequestID(r), vol.Name(), object.key, readErr)
+	}
+	return
+}
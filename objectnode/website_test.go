@@ -0,0 +1,145 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWebsiteConfig(t *testing.T) {
+	tests := []struct {
+		value string
+		valid bool
+	}{
+		{
+			value: `<WebsiteConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+						<IndexDocument><Suffix>index.html</Suffix></IndexDocument>
+						<ErrorDocument><Key>error.html</Key></ErrorDocument>
+						<RoutingRules><RoutingRule>
+							<Condition><KeyPrefixEquals>docs/</KeyPrefixEquals></Condition>
+							<Redirect><ReplaceKeyPrefixWith>documents/</ReplaceKeyPrefixWith></Redirect>
+						</RoutingRule></RoutingRules>
+					</WebsiteConfiguration>`,
+			valid: true,
+		},
+		{
+			value: `<WebsiteConfiguration>
+						<RedirectAllRequestsTo><HostName>example.com</HostName><Protocol>https</Protocol></RedirectAllRequestsTo>
+					</WebsiteConfiguration>`,
+			valid: true,
+		},
+		{
+			value: `<WebsiteConfiguration></WebsiteConfiguration>`,
+		},
+		{
+			value: `<WebsiteConfiguration><IndexDocument><Suffix>a/index.html</Suffix></IndexDocument></WebsiteConfiguration>`,
+		},
+		{
+			value: `<WebsiteConfiguration>
+						<IndexDocument><Suffix>index.html</Suffix></IndexDocument>
+						<RedirectAllRequestsTo><HostName>example.com</HostName></RedirectAllRequestsTo>
+					</WebsiteConfiguration>`,
+		},
+		{
+			value: `<WebsiteConfiguration>
+						<RedirectAllRequestsTo><HostName>example.com</HostName><Protocol>ftp</Protocol></RedirectAllRequestsTo>
+					</WebsiteConfiguration>`,
+		},
+		{
+			value: `<WebsiteConfiguration>
+						<IndexDocument><Suffix>index.html</Suffix></IndexDocument>
+						<RoutingRules><RoutingRule>
+							<Condition><HttpErrorCodeReturnedEquals>200</HttpErrorCodeReturnedEquals></Condition>
+							<Redirect><HostName>example.com</HostName></Redirect>
+						</RoutingRule></RoutingRules>
+					</WebsiteConfiguration>`,
+		},
+		{
+			value: `<WebsiteConfiguration>
+						<IndexDocument><Suffix>index.html</Suffix></IndexDocument>
+						<RoutingRules><RoutingRule>
+							<Redirect><ReplaceKeyPrefixWith>a</ReplaceKeyPrefixWith><ReplaceKeyWith>b</ReplaceKeyWith></Redirect>
+						</RoutingRule></RoutingRules>
+					</WebsiteConfiguration>`,
+		},
+		{
+			value: `<WebsiteConfiguration>
+						<IndexDocument><Suffix>index.html</Suffix></IndexDocument>
+						<RoutingRules><RoutingRule>
+							<Redirect><HttpRedirectCode>300</HttpRedirectCode></Redirect>
+						</RoutingRule></RoutingRules>
+					</WebsiteConfiguration>`,
+		},
+		{
+			value: `<WebsiteConfiguration>
+						<IndexDocument><Suffix>index.html</Suffix></IndexDocument>
+						<RoutingRules><RoutingRule></RoutingRule></RoutingRules>
+					</WebsiteConfiguration>`,
+		},
+		{
+			value: `<WebsiteConfiguration>`,
+		},
+	}
+	for i, tc := range tests {
+		_, err := ParseWebsiteConfigFromXML([]byte(tc.value))
+		if tc.valid {
+			require.NoError(t, err, "case %d", i)
+		} else {
+			require.Error(t, err, "case %d", i)
+		}
+	}
+}
+
+func TestWebsiteRoutingRules(t *testing.T) {
+	config, err := ParseWebsiteConfigFromXML([]byte(`<WebsiteConfiguration>
+		<IndexDocument><Suffix>index.html</Suffix></IndexDocument>
+		<RoutingRules>
+			<RoutingRule>
+				<Condition><KeyPrefixEquals>docs/</KeyPrefixEquals></Condition>
+				<Redirect><ReplaceKeyPrefixWith>documents/</ReplaceKeyPrefixWith></Redirect>
+			</RoutingRule>
+			<RoutingRule>
+				<Condition><KeyPrefixEquals>images/</KeyPrefixEquals><HttpErrorCodeReturnedEquals>404</HttpErrorCodeReturnedEquals></Condition>
+				<Redirect><HostName>example.com</HostName><Protocol>https</Protocol><HttpRedirectCode>302</HttpRedirectCode><ReplaceKeyWith>missing.png</ReplaceKeyWith></Redirect>
+			</RoutingRule>
+		</RoutingRules>
+	</WebsiteConfiguration>`))
+	require.NoError(t, err)
+
+	require.Equal(t, "index.html", config.indexKey(""))
+	require.Equal(t, "a/index.html", config.indexKey("a/"))
+	require.Equal(t, "a", config.indexKey("a"))
+
+	req := httptest.NewRequest(http.MethodGet, "http://bucket.website.cube.io/docs/a%20b.html", nil)
+	require.Nil(t, config.routingRule("a.html", 0))
+	require.Nil(t, config.routingRule("images/a.png", 0))
+	require.Nil(t, config.routingRule("images/a.png", http.StatusForbidden))
+
+	rule := config.routingRule("docs/a b.html", 0)
+	require.NotNil(t, rule)
+	location, code := rule.location(req, "docs/a b.html")
+	require.Equal(t, "http://bucket.website.cube.io/documents/a%20b.html", location)
+	require.Equal(t, http.StatusMovedPermanently, code)
+
+	rule = config.routingRule("images/a.png", http.StatusNotFound)
+	require.NotNil(t, rule)
+	location, code = rule.location(req, "images/a.png")
+	require.Equal(t, "https://example.com/missing.png", location)
+	require.Equal(t, http.StatusFound, code)
+}
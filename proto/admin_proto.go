@@ -279,6 +279,9 @@ const (
 	UserTransferVol     = "/user/transferVol"
 	UserList            = "/user/list"
 	UsersOfVol          = "/vol/users"
+	// APIs for account settings of user
+	UserUpdatePublicAccessBlock = "/user/updatePublicAccessBlock"
+	UserUpdateAccountMetadata   = "/user/updateAccountMetadata"
 	// graphql api for header
 	HeadAuthorized  = "Authorization"
 	ParamAuthorized = "_authorization"
@@ -292,6 +295,10 @@ const (
 	QuotaGet    = "/quota/get"
 	// QuotaBatchModifyPath = "/quota/batchModifyPath"
 	QuotaListAll = "/quota/listAll"
+	// user quota
+	UserQuotaSet    = "/userQuota/set"
+	UserQuotaDelete = "/userQuota/delete"
+	UserQuotaList   = "/userQuota/list"
 	// trash
 	AdminSetTrashInterval              = "/vol/setTrashInterval"
 	AdminSetVolAccessTimeValidInterval = "/vol/setAccessTimeValidInterval"
@@ -430,6 +437,8 @@ var GApiInfo map[string]string = map[string]string{
 	"userupdate":                      UserUpdate,
 	"userupdatepolicy":                UserUpdatePolicy,
 	"userremovepolicy":                UserRemovePolicy,
+	"userupdatepublicaccessblock":     UserUpdatePublicAccessBlock,
+	"userupdateaccountmetadata":       UserUpdateAccountMetadata,
 	"userdeletevolpolicy":             UserDeleteVolPolicy,
 	"usergetinfo":                     UserGetInfo,
 	"usergetakinfo":                   UserGetAKInfo,
@@ -826,6 +835,10 @@ type QuotaHeartBeatInfos struct {
 	QuotaHbInfos []*QuotaHeartBeatInfo
 }
 
+type UserQuotaHeartBeatInfos struct {
+	UserQuotaHbInfos []*UserQuotaInfo
+}
+
 type TxInfo struct {
 	Volume     string
 	Mask       TxOpMask
@@ -856,6 +869,7 @@ type HeartBeatRequest struct {
 	RaftPartitionCanUsingDifferentPortEnabled bool
 	UidLimitToMetaNode
 	QuotaHeartBeatInfos
+	UserQuotaHeartBeatInfos
 	TxInfos
 	ForbiddenVols        []string
 	DisableAuditVols     []string
@@ -974,6 +988,7 @@ type MetaPartitionReport struct {
 	ForbidWriteOpOfProtoVer0  bool
 	UidInfo                   []*UidReportSpaceInfo
 	QuotaReportInfos          []*QuotaReportInfo
+	UserQuotaReportInfos      []*UserQuotaReportInfo
 	StatByStorageClass        []*StatOfStorageClass
 	StatByMigrateStorageClass []*StatOfStorageClass
 	LocalPeers                []Peer
@@ -1387,6 +1402,7 @@ type SimpleVolView struct {
 	EnableAutoDpMetaRepair  bool
 	AccessTimeInterval      int64
 	EnablePersistAccessTime bool
+	InlineDataThreshold     int64
 
 	// hybrid cloud
 	VolStorageClass          uint32
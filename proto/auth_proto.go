@@ -264,6 +264,9 @@ const (
 	MsgMasterUserRemovePolicyReq    MsgType = MsgMasterAPIAccessReq + 0x80500
 	MsgMasterUserDeleteVolPolicyReq MsgType = MsgMasterAPIAccessReq + 0x80600
 	MsgMasterUserTransferVolReq     MsgType = MsgMasterAPIAccessReq + 0x80700
+	// Master API user account settings
+	MsgMasterUserUpdatePublicAccessBlockReq MsgType = MsgMasterAPIAccessReq + 0x80800
+	MsgMasterUserUpdateAccountMetadataReq   MsgType = MsgMasterAPIAccessReq + 0x80900
 
 	// Master API zone management
 	MsgMasterUpdateZoneReq MsgType = MsgMasterAPIAccessReq + 0x90100
@@ -353,6 +356,9 @@ var MsgType2ResourceMap = map[MsgType]string{
 	MsgMasterUserRemovePolicyReq:    "master:userremotepolicy",
 	MsgMasterUserDeleteVolPolicyReq: "master:userdeletevolpolicy",
 	MsgMasterUserTransferVolReq:     "master:usertransfervol",
+	// Master API user account settings
+	MsgMasterUserUpdatePublicAccessBlockReq: "master:userupdatepublicaccessblock",
+	MsgMasterUserUpdateAccountMetadataReq:   "master:userupdateaccountmetadata",
 
 	// Master API zone management
 	MsgMasterUpdateZoneReq: "master:updatezone",
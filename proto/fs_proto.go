@@ -487,6 +487,17 @@ type UpdateDentryResponse struct {
 	Inode uint64 `json:"ino"` // old inode number
 }
 
+// UpdateDentryIfRequest defines the request to update a dentry only if it still points to OldIno.
+type UpdateDentryIfRequest struct {
+	VolName     string `json:"vol"`
+	PartitionID uint64 `json:"pid"`
+	ParentID    uint64 `json:"pino"`
+	Name        string `json:"name"`
+	Inode       uint64 `json:"ino"`    // new inode number
+	OldIno      uint64 `json:"oldIno"` // expected inode number
+	RequestExtend
+}
+
 type TxUpdateDentryRequest struct {
 	VolName     string           `json:"vol"`
 	PartitionID uint64           `json:"pid"`
@@ -691,6 +702,10 @@ type AppendExtentKeyWithCheckRequest struct {
 	IsCache        bool
 	StorageClass   uint32 `json:"storageClass"`
 	IsMigration    bool
+	// InlineGeneration is the generation of the inode when its inline data was read, if the extent is
+	// written from the inline data. The append fails with OpConflictExtentsErr if the inode has been
+	// changed since then.
+	InlineGeneration uint64 `json:"igen,omitempty"`
 }
 
 func (ap *AppendExtentKeyWithCheckRequest) EkString() string {
@@ -745,6 +760,8 @@ type GetExtentsResponse struct {
 	LayerInfo       []LayerInfo `json:"layer"`
 	Status          int
 	LeaseExpireTime uint64 `json:"leaseExpireTime"`
+	// Inline is true if the data of the file is kept inline in the inode instead of the extents.
+	Inline bool `json:"inline,omitempty"`
 }
 
 // TruncateRequest defines the request to truncate.
@@ -823,6 +840,22 @@ type AppendExtentKeysRequest struct {
 	StorageClass uint32      `json:"storageClass"`
 }
 
+// AppendExtentKeysIfRequest appends the extent keys and sets the extended attributes of the inode
+// only if its size and generation are still the expected ones, so that the concurrent appends at
+// the same position are serialized by the meta partition.
+type AppendExtentKeysIfRequest struct {
+	VolName      string            `json:"vol"`
+	PartitionId  uint64            `json:"pid"`
+	Inode        uint64            `json:"ino"`
+	Extents      []ExtentKey       `json:"eks"`
+	StorageClass uint32            `json:"storageClass"`
+	Size         uint64            `json:"size"` // expected size
+	Generation   uint64            `json:"gen"`  // expected generation
+	ModifyTime   int64             `json:"mt"`
+	Attrs        map[string]string `json:"attrs"`
+	FullPath     string            `json:"fullPath"`
+}
+
 type SetXAttrRequest struct {
 	VolName     string `json:"vol"`
 	PartitionId uint64 `json:"pid"`
@@ -1024,6 +1057,23 @@ type ListMasterQuotaResponse struct {
 	Quotas []*QuotaInfo
 }
 
+type SetUserQuotaRequest struct {
+	VolName     string        `json:"vol"`
+	Type        UserQuotaType `json:"type"`
+	Id          uint32        `json:"id"`
+	SoftBytes   uint64        `json:"sbyte"`
+	HardBytes   uint64        `json:"hbyte"`
+	SoftInodes  uint64        `json:"sino"`
+	HardInodes  uint64        `json:"hino"`
+	GracePeriod int64         `json:"grace"`
+}
+
+// ListUserQuotaResponse contains the quotas of the volume and the usage of the users and groups
+// without quota, whose limits are zero.
+type ListUserQuotaResponse struct {
+	Quotas []*UserQuotaInfo
+}
+
 type BatchSetMetaserverQuotaReuqest struct {
 	PartitionId uint64   `json:"pid"`
 	Inodes      []uint64 `json:"ino"`
@@ -1094,6 +1144,254 @@ type LockDirResponse struct {
 	Status uint8 `json:"status"`
 }
 
+const (
+	PosixLockRead   uint8 = 1
+	PosixLockWrite  uint8 = 2
+	PosixLockUnlock uint8 = 3
+)
+
+// DefaultPosixLockLease is the seconds the locks of a client are kept without being renewed.
+const DefaultPosixLockLease uint64 = 30
+
+// PosixLock is an advisory lock on an inode held by a lock owner of a client. The flock locks lock
+// the whole file and never conflict with the byte-range locks.
+type PosixLock struct {
+	Type     uint8  `json:"type"`
+	Flock    bool   `json:"flock"`
+	Start    uint64 `json:"start"`
+	End      uint64 `json:"end"` // inclusive, math.MaxUint64 means the end of file
+	ClientId uint64 `json:"cid"`
+	Owner    uint64 `json:"owner"`
+	Pid      uint32 `json:"pid"`
+	Expire   int64  `json:"expire"`
+}
+
+func (lk *PosixLock) String() string {
+	if lk == nil {
+		return ""
+	}
+	return fmt.Sprintf("PosixLock{type(%v) flock(%v) range[%v, %v] client(%v) owner(%v) pid(%v) expire(%v)}",
+		lk.Type, lk.Flock, lk.Start, lk.End, lk.ClientId, lk.Owner, lk.Pid, lk.Expire)
+}
+
+// SameOwner returns true if both the locks are held by the same owner of the same client.
+func (lk *PosixLock) SameOwner(other *PosixLock) bool {
+	return lk.ClientId == other.ClientId && lk.Owner == other.Owner
+}
+
+// Overlaps returns true if the locks are of the same kind and their ranges overlap.
+func (lk *PosixLock) Overlaps(other *PosixLock) bool {
+	if lk.Flock != other.Flock {
+		return false
+	}
+	return lk.Flock || (lk.Start <= other.End && other.Start <= lk.End)
+}
+
+// Conflicts returns true if the lock can not be held together with the other lock.
+func (lk *PosixLock) Conflicts(other *PosixLock) bool {
+	return !lk.SameOwner(other) && lk.Overlaps(other) && (lk.Type == PosixLockWrite || other.Type == PosixLockWrite)
+}
+
+type SetLockRequest struct {
+	VolName     string    `json:"vol"`
+	PartitionId uint64    `json:"pid"`
+	Inode       uint64    `json:"ino"`
+	Lock        PosixLock `json:"lock"`
+	Lease       uint64    `json:"lease"` // unit seconds
+	SubmitTime  time.Time `json:"submitTime"`
+}
+
+// SetLockResponse contains the conflicting lock if the lock can not be set.
+type SetLockResponse struct {
+	Conflict *PosixLock `json:"conflict"`
+	Status   uint8      `json:"status"`
+}
+
+type GetLockRequest struct {
+	VolName     string    `json:"vol"`
+	PartitionId uint64    `json:"pid"`
+	Inode       uint64    `json:"ino"`
+	Lock        PosixLock `json:"lock"`
+}
+
+// GetLockResponse contains the first lock conflicting with the requested one, it is nil if the lock can be set.
+type GetLockResponse struct {
+	Lock *PosixLock `json:"lock"`
+}
+
+// RenewLockRequest extends the expiration of all the locks held by the client on the inodes.
+type RenewLockRequest struct {
+	VolName     string    `json:"vol"`
+	PartitionId uint64    `json:"pid"`
+	ClientId    uint64    `json:"cid"`
+	Inodes      []uint64  `json:"inos"`
+	Lease       uint64    `json:"lease"` // unit seconds
+	SubmitTime  time.Time `json:"submitTime"`
+}
+
+// RenewLockResponse contains the inodes still locked by the client.
+type RenewLockResponse struct {
+	Inodes []uint64 `json:"inos"`
+}
+
+// ChangeEventType is the type of the change events published by the meta partitions.
+type ChangeEventType uint8
+
+const (
+	// ChangeEventCreate is published when a dentry is created by create, mkdir, link, symlink or rename.
+	ChangeEventCreate ChangeEventType = iota + 1
+	// ChangeEventUnlink is published when a dentry is removed by unlink, rmdir or rename.
+	ChangeEventUnlink
+	// ChangeEventRename is published when rename replaces an existing dentry with another inode,
+	// the replaced inode is kept in OldInode. A rename is published as a ChangeEventCreate or
+	// ChangeEventRename of the new name and a ChangeEventUnlink of the old name of the same inode,
+	// which are published by the partitions of the parents.
+	ChangeEventRename
+	ChangeEventSetAttr
+	ChangeEventSetXAttr
+	ChangeEventRemoveXAttr
+	// ChangeEventExtents is published when the extents of an inode are appended, truncated or migrated.
+	ChangeEventExtents
+	// ChangeEventInodeCreate is published when an inode is created, its dentry is published by
+	// the partition of the parent.
+	ChangeEventInodeCreate
+	// ChangeEventInodeLink and ChangeEventInodeUnlink are published when the link count of an
+	// inode is increased or decreased.
+	ChangeEventInodeLink
+	ChangeEventInodeUnlink
+	// ChangeEventInodeEvict is published when an inode is evicted, the inode without links is
+	// deleted then.
+	ChangeEventInodeEvict
+)
+
+func (t ChangeEventType) String() string {
+	switch t {
+	case ChangeEventCreate:
+		return "create"
+	case ChangeEventUnlink:
+		return "unlink"
+	case ChangeEventRename:
+		return "rename"
+	case ChangeEventSetAttr:
+		return "setattr"
+	case ChangeEventSetXAttr:
+		return "setxattr"
+	case ChangeEventRemoveXAttr:
+		return "removexattr"
+	case ChangeEventExtents:
+		return "extents"
+	case ChangeEventInodeCreate:
+		return "icreate"
+	case ChangeEventInodeLink:
+		return "ilink"
+	case ChangeEventInodeUnlink:
+		return "iunlink"
+	case ChangeEventInodeEvict:
+		return "evict"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(t))
+	}
+}
+
+// ChangeEvent is a change of the metadata applied by a meta partition. The events of a partition
+// are ordered by Seq, which is the raft index of the applied entry. The changes made by a
+// transaction are published when they are applied, before the transaction commits, and the reverse
+// changes are published if the transaction is rolled back. An event may be published again if the
+// request is retried.
+type ChangeEvent struct {
+	Seq         uint64          `json:"seq"`
+	PartitionId uint64          `json:"pid"`
+	Type        ChangeEventType `json:"type"`
+	Inode       uint64          `json:"ino"`
+	ParentId    uint64          `json:"pino,omitempty"`
+	Name        string          `json:"name,omitempty"`
+	OldInode    uint64          `json:"oldIno,omitempty"`
+	Keys        []string        `json:"keys,omitempty"`
+	Time        int64           `json:"time"`
+}
+
+func (e *ChangeEvent) String() string {
+	return fmt.Sprintf("ChangeEvent{seq(%v) pid(%v) type(%v) ino(%v) pino(%v) name(%v) oldIno(%v) keys(%v) time(%v)}",
+		e.Seq, e.PartitionId, e.Type, e.Inode, e.ParentId, e.Name, e.OldInode, e.Keys, e.Time)
+}
+
+type GetChangeEventsRequest struct {
+	VolName     string `json:"vol"`
+	PartitionId uint64 `json:"pid"`
+	From        uint64 `json:"from"`
+	Limit       uint32 `json:"limit"`
+}
+
+// GetChangeEventsResponse returns the events after the requested sequence number. Next is the
+// sequence number to continue from, and Start is the sequence number after which all the events
+// are retained. Expired is true and no events are returned if some events after the requested
+// sequence number are not retained any more.
+type GetChangeEventsResponse struct {
+	Events  []*ChangeEvent `json:"events"`
+	Next    uint64         `json:"next"`
+	Start   uint64         `json:"start"`
+	Expired bool           `json:"expired"`
+}
+
+// MaxInlineDataThreshold is the max size of the data which can be kept in the inode of a small file.
+const MaxInlineDataThreshold = 64 * 1024
+
+// WriteInlineRequest writes the data into the inode at the offset, the file is extended with zeros
+// if the offset is beyond its size. It fails with OpConflictExtentsErr if the file has extents or
+// the data exceeds the inline threshold of the volume.
+type WriteInlineRequest struct {
+	VolName     string    `json:"vol"`
+	PartitionId uint64    `json:"pid"`
+	Inode       uint64    `json:"ino"`
+	Offset      uint64    `json:"off"`
+	Data        []byte    `json:"data"`
+	SubmitTime  time.Time `json:"submitTime"`
+}
+
+// WriteInlineResponse contains the size and generation of the inode after the write.
+type WriteInlineResponse struct {
+	Size       uint64 `json:"sz"`
+	Generation uint64 `json:"gen"`
+	Status     uint8  `json:"status"`
+}
+
+type ReadInlineRequest struct {
+	VolName     string `json:"vol"`
+	PartitionId uint64 `json:"pid"`
+	Inode       uint64 `json:"ino"`
+	Offset      uint64 `json:"off"`
+	Size        uint32 `json:"sz"`
+}
+
+// ReadInlineResponse contains the inline data in the requested range, the size and the generation
+// of the file. The range of the file beyond the inline data reads zeros.
+type ReadInlineResponse struct {
+	Data       []byte `json:"data"`
+	Size       uint64 `json:"sz"`
+	Generation uint64 `json:"gen"`
+}
+
+// ShareExtentsRequest creates an inode in the partition of the source inode, which refers to the
+// extents of the source in the range [Offset, Offset+Size) instead of copying the data.
+type ShareExtentsRequest struct {
+	VolName     string `json:"vol"`
+	PartitionId uint64 `json:"pid"`
+	Inode       uint64 `json:"ino"`
+	Offset      uint64 `json:"off"`
+	Size        uint64 `json:"sz"`
+	Mode        uint32 `json:"mode"`
+	Uid         uint32 `json:"uid"`
+	Gid         uint32 `json:"gid"`
+	FullPath    string `json:"fullPath"`
+	// the inode allocated by the leader
+	NewInode   uint64    `json:"newIno"`
+	SubmitTime time.Time `json:"submitTime"`
+}
+
+type ShareExtentsResponse struct {
+	Info *InodeInfo `json:"info"`
+}
+
 type InodeAccessTime struct {
 	Inode      uint64    `json:"ino"`
 	AccessTime time.Time `json:"at"`
@@ -0,0 +1,236 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// The inventory configurations of the bucket are kept in the LcConfiguration of the volume, and the inventory
+// reports are generated by lcnode with the same scan tasks scheduled by master as the lifecycle rules.
+
+const (
+	InventoryMaxCounts = 1000
+
+	InventoryFormatCSV     = "CSV"
+	InventoryFormatParquet = "Parquet"
+	InventoryFormatORC     = "ORC"
+
+	InventoryFrequencyDaily  = "Daily"
+	InventoryFrequencyWeekly = "Weekly"
+
+	InventoryVersionsAll     = "All"
+	InventoryVersionsCurrent = "Current"
+
+	InventoryFieldSize                      = "Size"
+	InventoryFieldLastModifiedDate          = "LastModifiedDate"
+	InventoryFieldStorageClass              = "StorageClass"
+	InventoryFieldETag                      = "ETag"
+	InventoryFieldObjectLockRetainUntilDate = "ObjectLockRetainUntilDate"
+	InventoryFieldObjectLockMode            = "ObjectLockMode"
+	InventoryFieldObjectLockLegalHoldStatus = "ObjectLockLegalHoldStatus"
+
+	inventoryBucketARNPrefix = "arn:aws:s3:::"
+	inventoryTaskPrefix      = "inventory/"
+)
+
+var inventoryFields = []string{
+	InventoryFieldSize, InventoryFieldLastModifiedDate, InventoryFieldStorageClass, InventoryFieldETag,
+	InventoryFieldObjectLockRetainUntilDate, InventoryFieldObjectLockMode, InventoryFieldObjectLockLegalHoldStatus,
+}
+
+var (
+	InventoryErrTooManyConfigs = errors.New("Inventory configurations number should not exceed allowed limit of 1000")
+	InventoryErrMissingID      = errors.New("No inventory configuration ID in request")
+	InventoryErrInvalidID      = errors.New("Invalid inventory configuration ID")
+	InventoryErrSameID         = errors.New("Inventory configuration ID must be unique")
+	InventoryErrDestination    = errors.New("The destination bucket of the inventory must be in the form of arn:aws:s3:::bucket")
+	InventoryErrFormat         = errors.New("The format of the inventory must be CSV, ORC or Parquet")
+	InventoryErrFrequency      = errors.New("The frequency of the inventory must be Daily or Weekly")
+	InventoryErrVersions       = errors.New("The included object versions of the inventory must be All or Current")
+	InventoryErrOptionalField  = errors.New("Unsupported optional field of the inventory")
+	InventoryErrPrefix         = errors.New("Inventory prefix cannot start with '/'")
+	InventoryErrNotImplemented = errors.New("The ORC format and the inventory of all object versions are not supported")
+	regexInventoryId           = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+)
+
+type InventoryConfiguration struct {
+	XMLName                xml.Name              `json:"-" xml:"InventoryConfiguration" bson:"-"`
+	ID                     string                `json:"Id" xml:"Id" bson:"Id"`
+	IsEnabled              bool                  `json:"IsEnabled" xml:"IsEnabled" bson:"IsEnabled"`
+	Destination            *InventoryDestination `json:"Destination" xml:"Destination" bson:"Destination"`
+	Filter                 *InventoryFilter      `json:"Filter,omitempty" xml:"Filter,omitempty" bson:"Filter,omitempty"`
+	IncludedObjectVersions string                `json:"IncludedObjectVersions" xml:"IncludedObjectVersions" bson:"IncludedObjectVersions"`
+	OptionalFields         []string              `json:"OptionalFields,omitempty" xml:"OptionalFields>Field,omitempty" bson:"OptionalFields,omitempty"`
+	Schedule               *InventorySchedule    `json:"Schedule" xml:"Schedule" bson:"Schedule"`
+}
+
+type InventoryDestination struct {
+	S3BucketDestination *InventoryS3BucketDestination `json:"S3BucketDestination" xml:"S3BucketDestination" bson:"S3BucketDestination"`
+}
+
+type InventoryS3BucketDestination struct {
+	AccountId string `json:"AccountId,omitempty" xml:"AccountId,omitempty" bson:"AccountId,omitempty"`
+	Bucket    string `json:"Bucket" xml:"Bucket" bson:"Bucket"`
+	Format    string `json:"Format" xml:"Format" bson:"Format"`
+	Prefix    string `json:"Prefix,omitempty" xml:"Prefix,omitempty" bson:"Prefix,omitempty"`
+}
+
+type InventoryFilter struct {
+	Prefix string `json:"Prefix,omitempty" xml:"Prefix,omitempty" bson:"Prefix,omitempty"`
+}
+
+type InventorySchedule struct {
+	Frequency string `json:"Frequency" xml:"Frequency" bson:"Frequency"`
+}
+
+func ValidInventories(inventories []*InventoryConfiguration) error {
+	if len(inventories) > InventoryMaxCounts {
+		return InventoryErrTooManyConfigs
+	}
+	ids := make(map[string]bool)
+	for _, c := range inventories {
+		if ids[c.ID] {
+			return InventoryErrSameID
+		}
+		ids[c.ID] = true
+		if err := ValidInventory(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ValidInventory(c *InventoryConfiguration) error {
+	if len(c.ID) == 0 {
+		return InventoryErrMissingID
+	}
+	if len(c.ID) > MaxIdLength || !regexInventoryId.MatchString(c.ID) {
+		return InventoryErrInvalidID
+	}
+	if c.DestinationBucket() == "" {
+		return InventoryErrDestination
+	}
+	switch c.Destination.S3BucketDestination.Format {
+	case InventoryFormatCSV, InventoryFormatParquet:
+	case InventoryFormatORC:
+		return InventoryErrNotImplemented
+	default:
+		return InventoryErrFormat
+	}
+	if c.Schedule == nil ||
+		(c.Schedule.Frequency != InventoryFrequencyDaily && c.Schedule.Frequency != InventoryFrequencyWeekly) {
+		return InventoryErrFrequency
+	}
+	switch c.IncludedObjectVersions {
+	case InventoryVersionsCurrent:
+	case InventoryVersionsAll:
+		return InventoryErrNotImplemented
+	default:
+		return InventoryErrVersions
+	}
+	for _, field := range c.OptionalFields {
+		if !isInventoryField(field) {
+			return InventoryErrOptionalField
+		}
+	}
+	if strings.HasPrefix(c.GetPrefix(), "/") {
+		return InventoryErrPrefix
+	}
+	return nil
+}
+
+func isInventoryField(field string) bool {
+	for _, f := range inventoryFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// DestinationBucket returns the name of the destination bucket from its ARN.
+func (c *InventoryConfiguration) DestinationBucket() string {
+	if c.Destination == nil || c.Destination.S3BucketDestination == nil {
+		return ""
+	}
+	bucket := c.Destination.S3BucketDestination.Bucket
+	if !strings.HasPrefix(bucket, inventoryBucketARNPrefix) || strings.Contains(bucket, "/") {
+		return ""
+	}
+	return strings.TrimPrefix(bucket, inventoryBucketARNPrefix)
+}
+
+func (c *InventoryConfiguration) GetPrefix() string {
+	if c.Filter != nil {
+		return c.Filter.Prefix
+	}
+	return ""
+}
+
+// HasField reports whether the optional field is included in the inventory report.
+func (c *InventoryConfiguration) HasField(field string) bool {
+	for _, f := range c.OptionalFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// GenInventoryTasks generates the scan tasks of the enabled inventory configurations, the weekly
+// inventories are generated on Sundays.
+func (lcConf *LcConfiguration) GenInventoryTasks(now time.Time) []*RuleTask {
+	tasks := make([]*RuleTask, 0)
+	for _, c := range lcConf.Inventories {
+		if !c.IsEnabled {
+			log.LogDebugf("GenInventoryTasks: skip disabled inventory(%v) in volume(%v)", c.ID, lcConf.VolName)
+			continue
+		}
+		if c.Schedule.Frequency == InventoryFrequencyWeekly && now.Weekday() != time.Sunday {
+			continue
+		}
+		// the rule of the task only specifies the prefix to be scanned
+		task := &RuleTask{
+			Id:      InventoryTaskID(lcConf.VolName, c.ID),
+			VolName: lcConf.VolName,
+			Rule: &Rule{
+				ID:     inventoryTaskPrefix + c.ID,
+				Status: RuleEnabled,
+				Filter: &Filter{Prefix: c.GetPrefix()},
+			},
+			Inventory: c,
+		}
+		tasks = append(tasks, task)
+		log.LogDebugf("GenInventoryTasks: RuleTask(%v) generated from inventory(%v) in volume(%v)", *task, c.ID, lcConf.VolName)
+	}
+	return tasks
+}
+
+func InventoryTaskID(vol, id string) string {
+	return fmt.Sprintf("%s:%s%s", vol, inventoryTaskPrefix, id)
+}
+
+// IsInventoryTask reports whether the task id is of an inventory scan task.
+func IsInventoryTask(id string) bool {
+	return strings.Contains(id, ":"+inventoryTaskPrefix)
+}
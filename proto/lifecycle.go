@@ -15,6 +15,7 @@
 package proto
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"regexp"
@@ -33,6 +34,10 @@ const (
 	OpTypeDelete          = "DELETE"
 	OpTypeStorageClassHDD = "HDD"
 	OpTypeStorageClassEBS = "BLOBSTORE"
+	OpTypeRestoreExpired  = "RESTORE_EXPIRED"
+
+	// XAttrKeyOSSRestore is the xattr key of the object which keeps the ObjectRestore
+	XAttrKeyOSSRestore = "oss:restore"
 )
 
 func OpTypeToStorageType(op string) uint32 {
@@ -47,8 +52,9 @@ func OpTypeToStorageType(op string) uint32 {
 }
 
 type LcConfiguration struct {
-	VolName string
-	Rules   []*Rule
+	VolName     string
+	Rules       []*Rule
+	Inventories []*InventoryConfiguration `json:",omitempty"`
 }
 
 type Rule struct {
@@ -358,7 +364,7 @@ func (lcConf *LcConfiguration) GenEnabledRuleTasks() []*RuleTask {
 		tasks = append(tasks, task)
 		log.LogDebugf("GenEnabledRuleTasks: RuleTask(%v) generated from rule(%v) in volume(%v)", *task, r.ID, lcConf.VolName)
 	}
-	return tasks
+	return append(tasks, lcConf.GenInventoryTasks(time.Now())...)
 }
 
 // ----------------------------------------------
@@ -372,9 +378,10 @@ type LcNodeRuleTaskRequest struct {
 }
 
 type RuleTask struct {
-	Id      string
-	VolName string
-	Rule    *Rule
+	Id        string
+	VolName   string
+	Rule      *Rule
+	Inventory *InventoryConfiguration `json:",omitempty"`
 }
 
 type LcNodeRuleTaskResponse struct {
@@ -403,11 +410,38 @@ type LcNodeRuleTaskStatistics struct {
 	ExpiredMToBlobstoreNum   int64
 	ExpiredMToBlobstoreBytes int64
 	ExpiredSkipNum           int64
+	ExpiredRestoreNum        int64
+	InventoryObjectNum       int64
 
 	ErrorDeleteNum       int64
 	ErrorMToHddNum       int64
 	ErrorMToBlobstoreNum int64
 	ErrorReadDirNum      int64
+	ErrorRestoreNum      int64
+	ErrorInventoryNum    int64
+}
+
+// ObjectRestore is the temporary replica copy of the object restored from blobstore,
+// the copy is released by lcnode once it expires.
+type ObjectRestore struct {
+	Inode        uint64 `json:"inode"`
+	StorageClass uint32 `json:"storage_class"`
+	ExpiryDate   int64  `json:"expiry_date"` // unix seconds
+}
+
+func ParseObjectRestore(data []byte) (*ObjectRestore, error) {
+	restore := &ObjectRestore{}
+	if err := json.Unmarshal(data, restore); err != nil {
+		return nil, err
+	}
+	if restore.Inode == 0 {
+		return nil, errors.New("invalid inode of the restored copy")
+	}
+	return restore, nil
+}
+
+func (r *ObjectRestore) Expired(now time.Time) bool {
+	return now.Unix() >= r.ExpiryDate
 }
 
 // ----------------------------------
@@ -149,6 +149,140 @@ func (quotaInfo *QuotaInfo) IsOverQuotaBytes() (isOver bool) {
 	return
 }
 
+// UserQuotaType is the owner type of a user quota.
+type UserQuotaType uint8
+
+const (
+	UserQuotaTypeUser  UserQuotaType = 1
+	UserQuotaTypeGroup UserQuotaType = 2
+)
+
+// DefaultUserQuotaGracePeriod is the seconds the soft limits of a user quota can be exceeded by default.
+const DefaultUserQuotaGracePeriod int64 = 7 * 24 * 3600
+
+func (t UserQuotaType) String() string {
+	switch t {
+	case UserQuotaTypeUser:
+		return "user"
+	case UserQuotaTypeGroup:
+		return "group"
+	default:
+		return fmt.Sprintf("UserQuotaType(%d)", uint8(t))
+	}
+}
+
+func ParseUserQuotaType(s string) (t UserQuotaType, err error) {
+	switch s {
+	case "user", "usr", "u":
+		t = UserQuotaTypeUser
+	case "group", "grp", "g":
+		t = UserQuotaTypeGroup
+	default:
+		err = fmt.Errorf("invalid user quota type %v", s)
+	}
+	return
+}
+
+type UserQuotaKey struct {
+	Type UserQuotaType
+	Id   uint32
+}
+
+type UserQuotaUsedInfo struct {
+	UsedBytes  int64
+	UsedInodes int64
+}
+
+func (usedInfo *UserQuotaUsedInfo) Add(info *UserQuotaUsedInfo) {
+	usedInfo.UsedBytes += info.UsedBytes
+	usedInfo.UsedInodes += info.UsedInodes
+}
+
+type UserQuotaReportInfo struct {
+	Type     UserQuotaType
+	Id       uint32
+	UsedInfo UserQuotaUsedInfo
+}
+
+// UserQuotaInfo is the quota of a user or group in a volume, the limit of zero means no limit.
+// The grace end is the unix time when the exceeded soft limit begins to be enforced, it is zero
+// if the usage is not above the soft limit.
+type UserQuotaInfo struct {
+	VolName        string
+	Type           UserQuotaType
+	Id             uint32
+	CTime          int64
+	SoftBytes      uint64
+	HardBytes      uint64
+	SoftInodes     uint64
+	HardInodes     uint64
+	GracePeriod    int64
+	BytesGraceEnd  int64
+	InodesGraceEnd int64
+	UsedInfo       UserQuotaUsedInfo
+	LimitedBytes   bool
+	LimitedInodes  bool
+}
+
+func (quotaInfo *UserQuotaInfo) Key() UserQuotaKey {
+	return UserQuotaKey{Type: quotaInfo.Type, Id: quotaInfo.Id}
+}
+
+func (quotaInfo *UserQuotaInfo) HasLimit() bool {
+	return quotaInfo.SoftBytes != 0 || quotaInfo.HardBytes != 0 || quotaInfo.SoftInodes != 0 || quotaInfo.HardInodes != 0
+}
+
+// Update sets the usage aggregated from the meta partitions, starts or stops the grace periods and
+// refreshes the limited flags, it returns true if any of them is changed except the usage.
+func (quotaInfo *UserQuotaInfo) Update(usedInfo UserQuotaUsedInfo, now int64) (changed bool) {
+	quotaInfo.UsedInfo = usedInfo
+	bytesGraceEnd := userQuotaGraceEnd(usedInfo.UsedBytes, quotaInfo.SoftBytes, quotaInfo.BytesGraceEnd, quotaInfo.GracePeriod, now)
+	inodesGraceEnd := userQuotaGraceEnd(usedInfo.UsedInodes, quotaInfo.SoftInodes, quotaInfo.InodesGraceEnd, quotaInfo.GracePeriod, now)
+	if bytesGraceEnd != quotaInfo.BytesGraceEnd || inodesGraceEnd != quotaInfo.InodesGraceEnd {
+		quotaInfo.BytesGraceEnd, quotaInfo.InodesGraceEnd = bytesGraceEnd, inodesGraceEnd
+		changed = true
+	}
+
+	// limited if no more can be allocated
+	limitedBytes := quotaInfo.IsOverBytes(usedInfo.UsedBytes+1, now)
+	limitedInodes := quotaInfo.IsOverInodes(usedInfo.UsedInodes+1, now)
+	if limitedBytes != quotaInfo.LimitedBytes || limitedInodes != quotaInfo.LimitedInodes {
+		quotaInfo.LimitedBytes, quotaInfo.LimitedInodes = limitedBytes, limitedInodes
+		changed = true
+	}
+	return
+}
+
+// IsOverBytes returns true if the used bytes exceed the hard limit, or the soft limit after the grace period.
+func (quotaInfo *UserQuotaInfo) IsOverBytes(usedBytes int64, now int64) bool {
+	return isOverUserQuota(usedBytes, quotaInfo.SoftBytes, quotaInfo.HardBytes, quotaInfo.BytesGraceEnd, now)
+}
+
+// IsOverInodes returns true if the used inodes exceed the hard limit, or the soft limit after the grace period.
+func (quotaInfo *UserQuotaInfo) IsOverInodes(usedInodes int64, now int64) bool {
+	return isOverUserQuota(usedInodes, quotaInfo.SoftInodes, quotaInfo.HardInodes, quotaInfo.InodesGraceEnd, now)
+}
+
+func userQuotaGraceEnd(used int64, soft uint64, graceEnd int64, gracePeriod int64, now int64) int64 {
+	if soft == 0 || used <= 0 || uint64(used) <= soft {
+		return 0
+	}
+	if graceEnd == 0 {
+		return now + gracePeriod
+	}
+	return graceEnd
+}
+
+func isOverUserQuota(used int64, soft uint64, hard uint64, graceEnd int64, now int64) bool {
+	if used <= 0 {
+		return false
+	}
+	if hard != 0 && uint64(used) > hard {
+		return true
+	}
+	return soft != 0 && uint64(used) > soft && graceEnd != 0 && now >= graceEnd
+}
+
 type StatOfStorageClass struct {
 	StorageClass  uint32
 	InodeCount    uint64
@@ -0,0 +1,60 @@
+package proto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserQuotaInfoUpdate(t *testing.T) {
+	quotaInfo := &UserQuotaInfo{
+		Type:        UserQuotaTypeUser,
+		Id:          1000,
+		SoftBytes:   100,
+		HardBytes:   200,
+		HardInodes:  10,
+		GracePeriod: 60,
+	}
+	now := int64(1000)
+
+	require.False(t, quotaInfo.Update(UserQuotaUsedInfo{UsedBytes: 100, UsedInodes: 9}, now))
+	require.False(t, quotaInfo.LimitedBytes)
+	require.False(t, quotaInfo.IsOverBytes(101, now))
+	require.True(t, quotaInfo.IsOverBytes(201, now))
+
+	// the inodes reach the hard limit
+	require.True(t, quotaInfo.Update(UserQuotaUsedInfo{UsedBytes: 100, UsedInodes: 10}, now))
+	require.True(t, quotaInfo.LimitedInodes)
+	require.False(t, quotaInfo.IsOverInodes(10, now))
+
+	// the grace period starts after the soft limit is exceeded
+	require.True(t, quotaInfo.Update(UserQuotaUsedInfo{UsedBytes: 150, UsedInodes: 10}, now))
+	require.Equal(t, now+60, quotaInfo.BytesGraceEnd)
+	require.False(t, quotaInfo.LimitedBytes)
+	require.False(t, quotaInfo.Update(UserQuotaUsedInfo{UsedBytes: 160, UsedInodes: 10}, now+30))
+	require.Equal(t, now+60, quotaInfo.BytesGraceEnd)
+
+	// the soft limit is enforced after the grace period
+	require.True(t, quotaInfo.Update(UserQuotaUsedInfo{UsedBytes: 160, UsedInodes: 10}, now+60))
+	require.True(t, quotaInfo.LimitedBytes)
+	require.True(t, quotaInfo.IsOverBytes(161, now+60))
+
+	// the grace period is reset after the usage drops below the soft limit
+	require.True(t, quotaInfo.Update(UserQuotaUsedInfo{UsedBytes: 50, UsedInodes: 5}, now+70))
+	require.Zero(t, quotaInfo.BytesGraceEnd)
+	require.False(t, quotaInfo.LimitedBytes)
+	require.False(t, quotaInfo.LimitedInodes)
+}
+
+func TestParseUserQuotaType(t *testing.T) {
+	for _, s := range []string{"user", "usr", "u"} {
+		quotaType, err := ParseUserQuotaType(s)
+		require.NoError(t, err)
+		require.Equal(t, UserQuotaTypeUser, quotaType)
+	}
+	quotaType, err := ParseUserQuotaType(UserQuotaTypeGroup.String())
+	require.NoError(t, err)
+	require.Equal(t, UserQuotaTypeGroup, quotaType)
+	_, err = ParseUserQuotaType("other")
+	require.Error(t, err)
+}
@@ -100,6 +100,7 @@ const (
 	MinimumNlinkReadDir
 	InodeLruLimit
 	FuseServeThreads
+	EnableFileLock
 	MaxMountOption
 )
 
@@ -209,6 +210,7 @@ func InitMountOptions(opts []MountOption) {
 	opts[MinimumNlinkReadDir] = MountOption{"minimumNlinkReadDir", "the minimum Nlink value of the directory that actively triggers the ReadDir operation", "", int64(10000)}
 	opts[InodeLruLimit] = MountOption{"inodeLruLimit", "capacity for inode lru", "", int64(2000000)}
 	opts[FuseServeThreads] = MountOption{"fuseServeThreads", "Fuse Serve Threads", "", int64(0)}
+	opts[EnableFileLock] = MountOption{"enableFileLock", "Enable posix lock and flock across mounts", "", false}
 	for i := 0; i < MaxMountOption; i++ {
 		flag.StringVar(&opts[i].cmdlineValue, opts[i].keyword, "", opts[i].description)
 	}
@@ -401,4 +403,5 @@ type MountOptions struct {
 	InodeLruLimit         int64
 	FuseServeThreads      int64
 	MinReadAheadSize      int64
+	EnableFileLock        bool
 }
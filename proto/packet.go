@@ -201,6 +201,7 @@ const (
 	OpMetaGetUniqID       uint8 = 0xAC
 	OpMetaGetAppliedID    uint8 = 0xAD
 	OpMetaUpdateInodeMeta uint8 = 0xAE
+	OpMetaUpdateDentryIf  uint8 = 0xAF
 
 	// Multi version snapshot
 	OpRandomWriteAppend     uint8 = 0xB1
@@ -250,6 +251,24 @@ const (
 	OpMetaBatchSetXAttr uint8 = 0xD2
 	OpMetaGetAllXAttr   uint8 = 0xD3
 
+	// posix lock and flock
+	OpMetaSetLock   uint8 = 0xD8
+	OpMetaGetLock   uint8 = 0xD9
+	OpMetaRenewLock uint8 = 0xDA
+
+	// change feed
+	OpMetaGetChangeEvents uint8 = 0xDB
+
+	// inline data of small files
+	OpMetaWriteInline uint8 = 0xCE
+	OpMetaReadInline  uint8 = 0xCF
+
+	// share the extents of a file within a meta partition
+	OpMetaShareExtents uint8 = 0xDC
+
+	// append the extents only if the inode is unchanged
+	OpMetaAppendExtentKeysIf uint8 = 0xD4
+
 	// transaction error
 
 	OpTxInodeInfoNotExistErr  uint8 = 0xE0
@@ -550,6 +569,22 @@ func (p *Packet) GetOpMsg() (m string) {
 		m = "OpMetaReadDirLimit"
 	case OpMetaLockDir:
 		m = "OpMetaLockDir"
+	case OpMetaSetLock:
+		m = "OpMetaSetLock"
+	case OpMetaGetLock:
+		m = "OpMetaGetLock"
+	case OpMetaRenewLock:
+		m = "OpMetaRenewLock"
+	case OpMetaGetChangeEvents:
+		m = "OpMetaGetChangeEvents"
+	case OpMetaWriteInline:
+		m = "OpMetaWriteInline"
+	case OpMetaReadInline:
+		m = "OpMetaReadInline"
+	case OpMetaShareExtents:
+		m = "OpMetaShareExtents"
+	case OpMetaAppendExtentKeysIf:
+		m = "OpMetaAppendExtentKeysIf"
 	case OpMetaInodeGet:
 		m = "OpMetaInodeGet"
 	case OpMetaBatchInodeGet:
@@ -568,6 +603,8 @@ func (p *Packet) GetOpMsg() (m string) {
 		m = "OpMetaObjExtentsList"
 	case OpMetaUpdateDentry:
 		m = "OpMetaUpdateDentry"
+	case OpMetaUpdateDentryIf:
+		m = "OpMetaUpdateDentryIf"
 	case OpMetaTruncate:
 		m = "OpMetaTruncate"
 	case OpMetaLinkInode:
@@ -68,7 +68,7 @@ const (
 	OSSGetBucketPolicyAction       Action = OSSActionPrefix + "GetBucketPolicy"
 	OSSPutBucketPolicyAction       Action = OSSActionPrefix + "PutBucketPolicy"
 	OSSDeleteBucketPolicyAction    Action = OSSActionPrefix + "DeleteBucketPolicy"
-	OSSGetBucketPolicyStatusAction Action = OSSActionPrefix + "GetBucketPolicyStatus" // unsupported
+	OSSGetBucketPolicyStatusAction Action = OSSActionPrefix + "GetBucketPolicyStatus"
 
 	// Bucket ACL actions
 	OSSGetBucketAclAction Action = OSSActionPrefix + "GetBucketAcl"
@@ -124,47 +124,101 @@ const (
 	OSSDeleteBucketLifecycleConfigurationAction Action = OSSActionPrefix + "DeleteBucketLifecycleConfiguration"
 
 	// Object storage version actions
-	OSSGetBucketVersioningAction Action = OSSActionPrefix + "GetBucketVersioning" // unsupported
-	OSSPutBucketVersioningAction Action = OSSActionPrefix + "PutBucketVersioning" // unsupported
-	OSSListObjectVersionsAction  Action = OSSActionPrefix + "ListObjectVersions"  // unsupported
+	OSSGetBucketVersioningAction Action = OSSActionPrefix + "GetBucketVersioning"
+	OSSPutBucketVersioningAction Action = OSSActionPrefix + "PutBucketVersioning"
+	OSSListObjectVersionsAction  Action = OSSActionPrefix + "ListObjectVersions"
 
 	// Object legal hold actions
-	OSSGetObjectLegalHoldAction Action = OSSActionPrefix + "GetObjectLegalHold" // unsupported
-	OSSPutObjectLegalHoldAction Action = OSSActionPrefix + "PutObjectLegalHold" // unsupported
+	OSSGetObjectLegalHoldAction Action = OSSActionPrefix + "GetObjectLegalHold"
+	OSSPutObjectLegalHoldAction Action = OSSActionPrefix + "PutObjectLegalHold"
 
 	// Object retention actions
-	OSSGetObjectRetentionAction Action = OSSActionPrefix + "GetObjectRetention" // unsupported
-	OSSPutObjectRetentionAction Action = OSSActionPrefix + "PutObjectRetention" // unsupported
+	OSSGetObjectRetentionAction Action = OSSActionPrefix + "GetObjectRetention"
+	OSSPutObjectRetentionAction Action = OSSActionPrefix + "PutObjectRetention"
+
+	// Object attributes actions
+	OSSGetObjectAttributesAction Action = OSSActionPrefix + "GetObjectAttributes"
+
+	// Object append actions
+	OSSAppendObjectAction Action = OSSActionPrefix + "AppendObject"
 
 	// Bucket encryption actions
-	OSSGetBucketEncryptionAction    Action = OSSActionPrefix + "GetBucketEncryption"    // unsupported
-	OSSPutBucketEncryptionAction    Action = OSSActionPrefix + "PutBucketEncryption"    // unsupported
-	OSSDeleteBucketEncryptionAction Action = OSSActionPrefix + "DeleteBucketEncryption" // unsupported
+	OSSGetBucketEncryptionAction    Action = OSSActionPrefix + "GetBucketEncryption"
+	OSSPutBucketEncryptionAction    Action = OSSActionPrefix + "PutBucketEncryption"
+	OSSDeleteBucketEncryptionAction Action = OSSActionPrefix + "DeleteBucketEncryption"
 
 	// Bucket website actions
-	OSSGetBucketWebsiteAction    Action = OSSActionPrefix + "GetBucketWebsite"    // unsupported
-	OSSPutBucketWebsiteAction    Action = OSSActionPrefix + "PutBucketWebsite"    // unsupported
-	OSSDeleteBucketWebsiteAction Action = OSSActionPrefix + "DeleteBucketWebsite" // unsupported
+	OSSGetBucketWebsiteAction    Action = OSSActionPrefix + "GetBucketWebsite"
+	OSSPutBucketWebsiteAction    Action = OSSActionPrefix + "PutBucketWebsite"
+	OSSDeleteBucketWebsiteAction Action = OSSActionPrefix + "DeleteBucketWebsite"
+	OSSGetWebsiteObjectAction    Action = OSSActionPrefix + "GetWebsiteObject"
 
 	// Object restore actions
-	OSSRestoreObjectAction Action = OSSActionPrefix + "RestoreObject" // unsupported
+	OSSRestoreObjectAction Action = OSSActionPrefix + "RestoreObject"
+
+	// Object select actions
+	OSSSelectObjectContentAction Action = OSSActionPrefix + "SelectObjectContent"
 
 	// Public access block actions
-	OSSGetPublicAccessBlockAction    Action = OSSActionPrefix + "GetPublicAccessBlock"   // unsupported
-	OSSPutPublicAccessBlockAction    Action = OSSActionPrefix + "PutPublicAccessBlock"   // unsupported
-	OSSDeletePublicAccessBlockAction Action = OSSActionPrefix + "DeletePulicAccessBlock" // unuspported
+	OSSGetPublicAccessBlockAction    Action = OSSActionPrefix + "GetPublicAccessBlock"
+	OSSPutPublicAccessBlockAction    Action = OSSActionPrefix + "PutPublicAccessBlock"
+	OSSDeletePublicAccessBlockAction Action = OSSActionPrefix + "DeletePublicAccessBlock"
+
+	// Account public access block actions
+	OSSGetAccountPublicAccessBlockAction    Action = OSSActionPrefix + "GetAccountPublicAccessBlock"
+	OSSPutAccountPublicAccessBlockAction    Action = OSSActionPrefix + "PutAccountPublicAccessBlock"
+	OSSDeleteAccountPublicAccessBlockAction Action = OSSActionPrefix + "DeleteAccountPublicAccessBlock"
 
 	// Bucket request payment actions
 	OSSGetBucketRequestPaymentAction Action = OSSActionPrefix + "GetBucketRequestPayment" // unsupported
 	OSSPutBucketRequestPaymentAction Action = OSSActionPrefix + "PutBucketRequestPayment" // unsupported
 
 	// Bucket replication actions
-	OSSGetBucketReplicationAction    Action = OSSActionPrefix + "GetBucketReplicationAction"    // unsupported
-	OSSPutBucketReplicationAction    Action = OSSActionPrefix + "PutBucketReplicationAction"    // unsupported
-	OSSDeleteBucketReplicationAction Action = OSSActionPrefix + "DeleteBucketReplicationAction" // unsupported
+	OSSGetBucketReplicationAction    Action = OSSActionPrefix + "GetBucketReplicationAction"
+	OSSPutBucketReplicationAction    Action = OSSActionPrefix + "PutBucketReplicationAction"
+	OSSDeleteBucketReplicationAction Action = OSSActionPrefix + "DeleteBucketReplicationAction"
+
+	// Bucket notification actions
+	OSSGetBucketNotificationAction Action = OSSActionPrefix + "GetBucketNotification"
+	OSSPutBucketNotificationAction Action = OSSActionPrefix + "PutBucketNotification"
+
+	// Bucket logging actions
+	OSSGetBucketLoggingAction Action = OSSActionPrefix + "GetBucketLogging"
+	OSSPutBucketLoggingAction Action = OSSActionPrefix + "PutBucketLogging"
+
+	// Bucket inventory actions
+	OSSGetBucketInventoryConfigurationAction    Action = OSSActionPrefix + "GetBucketInventoryConfiguration"
+	OSSPutBucketInventoryConfigurationAction    Action = OSSActionPrefix + "PutBucketInventoryConfiguration"
+	OSSDeleteBucketInventoryConfigurationAction Action = OSSActionPrefix + "DeleteBucketInventoryConfiguration"
+	OSSListBucketInventoryConfigurationsAction  Action = OSSActionPrefix + "ListBucketInventoryConfigurations"
+
+	// Bucket metrics actions
+	OSSGetBucketMetricsConfigurationAction    Action = OSSActionPrefix + "GetBucketMetricsConfiguration"
+	OSSPutBucketMetricsConfigurationAction    Action = OSSActionPrefix + "PutBucketMetricsConfiguration"
+	OSSDeleteBucketMetricsConfigurationAction Action = OSSActionPrefix + "DeleteBucketMetricsConfiguration"
+	OSSListBucketMetricsConfigurationsAction  Action = OSSActionPrefix + "ListBucketMetricsConfigurations"
+
+	// Bucket quota actions
+	OSSGetBucketQuotaAction    Action = OSSActionPrefix + "GetBucketQuota"
+	OSSPutBucketQuotaAction    Action = OSSActionPrefix + "PutBucketQuota"
+	OSSDeleteBucketQuotaAction Action = OSSActionPrefix + "DeleteBucketQuota"
+
+	// Bucket key index actions
+	OSSGetBucketKeyIndexAction     Action = OSSActionPrefix + "GetBucketKeyIndex"
+	OSSPutBucketKeyIndexAction     Action = OSSActionPrefix + "PutBucketKeyIndex"
+	OSSDeleteBucketKeyIndexAction  Action = OSSActionPrefix + "DeleteBucketKeyIndex"
+	OSSRebuildBucketKeyIndexAction Action = OSSActionPrefix + "RebuildBucketKeyIndex"
+
+	// Swift actions, the other operations of the Swift API share the actions of S3
+	OSSSwiftAuthAction          Action = OSSActionPrefix + "SwiftAuth"
+	OSSSwiftHeadAccountAction   Action = OSSActionPrefix + "SwiftHeadAccount"
+	OSSSwiftPostAccountAction   Action = OSSActionPrefix + "SwiftPostAccount"
+	OSSSwiftPostContainerAction Action = OSSActionPrefix + "SwiftPostContainer"
+	OSSSwiftPostObjectAction    Action = OSSActionPrefix + "SwiftPostObject"
 
 	// STS actions
-	OSSGetFederationTokenAction Action = OSSActionPrefix + "GetFederationToken"
+	OSSGetFederationTokenAction        Action = OSSActionPrefix + "GetFederationToken"
+	OSSAssumeRoleWithWebIdentityAction Action = OSSActionPrefix + "AssumeRoleWithWebIdentity"
 
 	// constants for POSIX file system interface
 	POSIXReadAction  Action = POSIXActionPrefix + "Read"
@@ -231,6 +285,8 @@ var AllActions = []Action{
 	OSSPutObjectLegalHoldAction,
 	OSSGetObjectRetentionAction,
 	OSSPutObjectRetentionAction,
+	OSSGetObjectAttributesAction,
+	OSSAppendObjectAction,
 	OSSGetBucketEncryptionAction,
 	OSSPutBucketEncryptionAction,
 	OSSDeleteBucketEncryptionAction,
@@ -240,17 +296,47 @@ var AllActions = []Action{
 	OSSGetBucketWebsiteAction,
 	OSSPutBucketWebsiteAction,
 	OSSDeleteBucketWebsiteAction,
+	OSSGetWebsiteObjectAction,
 	OSSRestoreObjectAction,
+	OSSSelectObjectContentAction,
 	OSSGetPublicAccessBlockAction,
 	OSSPutPublicAccessBlockAction,
 	OSSDeletePublicAccessBlockAction,
+	OSSGetAccountPublicAccessBlockAction,
+	OSSPutAccountPublicAccessBlockAction,
+	OSSDeleteAccountPublicAccessBlockAction,
 	OSSGetBucketRequestPaymentAction,
 	OSSPutBucketRequestPaymentAction,
 	OSSGetBucketReplicationAction,
 	OSSPutBucketReplicationAction,
 	OSSDeleteBucketReplicationAction,
+	OSSGetBucketNotificationAction,
+	OSSPutBucketNotificationAction,
+	OSSGetBucketLoggingAction,
+	OSSPutBucketLoggingAction,
+	OSSGetBucketInventoryConfigurationAction,
+	OSSPutBucketInventoryConfigurationAction,
+	OSSDeleteBucketInventoryConfigurationAction,
+	OSSListBucketInventoryConfigurationsAction,
+	OSSGetBucketMetricsConfigurationAction,
+	OSSPutBucketMetricsConfigurationAction,
+	OSSDeleteBucketMetricsConfigurationAction,
+	OSSListBucketMetricsConfigurationsAction,
+	OSSGetBucketQuotaAction,
+	OSSPutBucketQuotaAction,
+	OSSDeleteBucketQuotaAction,
+	OSSGetBucketKeyIndexAction,
+	OSSPutBucketKeyIndexAction,
+	OSSDeleteBucketKeyIndexAction,
+	OSSRebuildBucketKeyIndexAction,
+	OSSSwiftAuthAction,
+	OSSSwiftHeadAccountAction,
+	OSSSwiftPostAccountAction,
+	OSSSwiftPostContainerAction,
+	OSSSwiftPostObjectAction,
 	OSSOptionsObjectAction,
 	OSSGetFederationTokenAction,
+	OSSAssumeRoleWithWebIdentityAction,
 
 	// POSIX file system interface actions
 	POSIXReadAction,
@@ -414,7 +500,9 @@ var builtinPermissionActionsMap = map[Permission]Actions{
 		OSSListObjectVersionsAction,
 		OSSGetObjectLegalHoldAction,
 		OSSGetObjectRetentionAction,
+		OSSGetObjectAttributesAction,
 		OSSGetBucketEncryptionAction,
+		OSSSelectObjectContentAction,
 
 		// file system interface
 		POSIXReadAction,
@@ -448,7 +536,10 @@ var builtinPermissionActionsMap = map[Permission]Actions{
 		OSSPutObjectLegalHoldAction,
 		OSSGetObjectRetentionAction,
 		OSSPutObjectRetentionAction,
+		OSSGetObjectAttributesAction,
+		OSSAppendObjectAction,
 		OSSGetBucketEncryptionAction,
+		OSSSelectObjectContentAction,
 
 		// POSIX file system interface actions
 		POSIXReadAction,
@@ -107,6 +107,22 @@ type UserInfo struct {
 	Description string       `json:"description" graphql:"description"`
 	Mu          sync.RWMutex `json:"-" graphql:"-"`
 	EMPTY       bool         // graphql need ???
+
+	// PublicAccessBlock is the account level S3 Block Public Access configuration,
+	// which applies to all the buckets owned by the user.
+	PublicAccessBlock *PublicAccessBlockConfig `json:"public_access_block,omitempty" graphql:"-"`
+
+	// AccountMetadata is the custom metadata of the Swift account of the user.
+	AccountMetadata map[string]string `json:"account_metadata,omitempty" graphql:"-"`
+}
+
+// PublicAccessBlockConfig is the S3 Block Public Access configuration.
+// Reference: https://docs.aws.amazon.com/AmazonS3/latest/userguide/access-control-block-public-access.html
+type PublicAccessBlockConfig struct {
+	BlockPublicAcls       bool `json:"block_public_acls"`
+	IgnorePublicAcls      bool `json:"ignore_public_acls"`
+	BlockPublicPolicy     bool `json:"block_public_policy"`
+	RestrictPublicBuckets bool `json:"restrict_public_buckets"`
 }
 
 func (i *UserInfo) String() string {
@@ -343,6 +359,20 @@ type UserPermUpdateParam struct {
 	Policy []string `json:"policy"`
 }
 
+// UserPublicAccessBlockUpdateParam updates the account level public access block configuration
+// of the user, the configuration is removed if Config is nil.
+type UserPublicAccessBlockUpdateParam struct {
+	UserID string                   `json:"user_id"`
+	Config *PublicAccessBlockConfig `json:"config"`
+}
+
+// UserAccountMetadataUpdateParam replaces the Swift account metadata of the user,
+// the metadata is removed if Metadata is empty.
+type UserAccountMetadataUpdateParam struct {
+	UserID   string            `json:"user_id"`
+	Metadata map[string]string `json:"metadata"`
+}
+
 func NewUserPermUpdateParam(userID, volmue string) *UserPermUpdateParam {
 	return &UserPermUpdateParam{UserID: userID, Volume: volmue, Policy: make([]string, 0)}
 }
@@ -70,6 +70,7 @@ type ExtentCache struct {
 	root    *btree.BTree
 	discard *btree.BTree
 	verSeq  uint64
+	inline  bool // the data of the file is kept inline in the inode
 }
 
 // NewExtentCache returns a new extent cache.
@@ -89,13 +90,13 @@ func (cache *ExtentCache) LogOutPut() {
 	})
 }
 
-func (cache *ExtentCache) RefreshForce(inode uint64, force bool, getExtents GetExtentsFunc, isCache bool, openForWrite, isMigration bool) error {
-	gen, size, extents, err := getExtents(inode, isCache, openForWrite, isMigration)
+func (cache *ExtentCache) RefreshForce(inode uint64, force bool, getExtents GetExtentsWithInlineFunc, isCache bool, openForWrite, isMigration bool) error {
+	gen, size, extents, inline, err := getExtents(inode, isCache, openForWrite, isMigration)
 	if err != nil {
 		return err
 	}
 	// log.LogDebugf("Local ExtentCache before update: ino(%v) gen(%v) size(%v) extents(%v)", inode, cache.gen, cache.size, cache.List())
-	cache.update(gen, size, force, extents)
+	cache.update(gen, size, force, inline, extents)
 	if log.EnableDebug() {
 		log.LogDebugf("[RefreshForce] Local ExtentCache after update: ino(%v) gen(%v) size(%v) extents(%v) openForWrite(%v) isMigration(%v)",
 			inode, cache.gen, cache.size, cache.List(), openForWrite, isMigration)
@@ -104,17 +105,17 @@ func (cache *ExtentCache) RefreshForce(inode uint64, force bool, getExtents GetE
 }
 
 // Refresh refreshes the extent cache.
-func (cache *ExtentCache) Refresh(inode uint64, getExtents GetExtentsFunc, isCache, openForWrite, isMigration bool) error {
+func (cache *ExtentCache) Refresh(inode uint64, getExtents GetExtentsWithInlineFunc, isCache, openForWrite, isMigration bool) error {
 	if cache.root.Len() > 0 {
 		return nil
 	}
 
-	gen, size, extents, err := getExtents(inode, isCache, openForWrite, isMigration)
+	gen, size, extents, inline, err := getExtents(inode, isCache, openForWrite, isMigration)
 	if err != nil {
 		return err
 	}
 
-	cache.update(gen, size, false, extents)
+	cache.update(gen, size, false, inline, extents)
 	if log.EnableDebug() {
 		log.LogDebugf("[Refresh] Local ExtentCache after update: ino(%v) gen(%v) size(%v) extents(%v) openForWrite(%v) isMigration(%v)",
 			inode, cache.gen, cache.size, cache.List(), openForWrite, isMigration)
@@ -122,7 +123,7 @@ func (cache *ExtentCache) Refresh(inode uint64, getExtents GetExtentsFunc, isCac
 	return nil
 }
 
-func (cache *ExtentCache) update(gen, size uint64, force bool, eks []proto.ExtentKey) {
+func (cache *ExtentCache) update(gen, size uint64, force, inline bool, eks []proto.ExtentKey) {
 	cache.Lock()
 	defer cache.Unlock()
 
@@ -134,6 +135,7 @@ func (cache *ExtentCache) update(gen, size uint64, force bool, eks []proto.Exten
 
 	cache.gen = gen
 	cache.size = size
+	cache.inline = inline
 	cache.root.Clear(false)
 	for _, ek := range eks {
 		extent := ek
@@ -349,6 +351,20 @@ func (cache *ExtentCache) Size() (size int, gen uint64) {
 	return int(cache.size), cache.gen
 }
 
+// Empty returns true if there is no extent in the cache.
+func (cache *ExtentCache) Empty() bool {
+	cache.RLock()
+	defer cache.RUnlock()
+	return cache.root.Len() == 0
+}
+
+// Inline returns true if the data of the file is kept inline in the inode.
+func (cache *ExtentCache) Inline() bool {
+	cache.RLock()
+	defer cache.RUnlock()
+	return cache.inline
+}
+
 // SetSize set the size of the cache.
 func (cache *ExtentCache) SetSize(size uint64, sync bool) {
 	cache.Lock()
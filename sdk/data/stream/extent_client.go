@@ -57,6 +57,7 @@ type (
 	SplitExtentKeyFunc            func(parentInode, inode uint64, key proto.ExtentKey, storageClass uint32) error
 	AppendExtentKeyFunc           func(parentInode, inode uint64, key proto.ExtentKey, discard []proto.ExtentKey, isCache bool, storageClass uint32, isMigration bool) (int, error)
 	GetExtentsFunc                func(inode uint64, isCache bool, openForWrite bool, isMigration bool) (uint64, uint64, []proto.ExtentKey, error)
+	GetExtentsWithInlineFunc      func(inode uint64, isCache bool, openForWrite bool, isMigration bool) (uint64, uint64, []proto.ExtentKey, bool, error)
 	TruncateFunc                  func(inode, size uint64, fullPath string) error
 	EvictIcacheFunc               func(inode uint64)
 	LoadBcacheFunc                func(vol, key string, buf []byte, offset uint64, size uint32) (int, error)
@@ -646,7 +647,7 @@ func (client *ExtentClient) RefreshExtentsWithCache(inode *proto.InodeInfo) erro
 	if s == nil {
 		return nil
 	}
-	s.extents.update(inode.Extents.Generation, inode.Extents.Size, false, inode.Extents.Extents)
+	s.extents.update(inode.Extents.Generation, inode.Extents.Size, false, inode.Extents.Inline, inode.Extents.Extents)
 	return nil
 }
 
@@ -575,7 +575,15 @@ func (eh *ExtentHandler) appendExtentKey() (err error) {
 			ekey := *eh.key
 			doAppend := func() (err error) {
 				discard := eh.stream.extents.Append(&ekey, true)
-				status, err = eh.stream.client.appendExtentKey(eh.stream.parentInode, eh.inode, ekey, discard, eh.stream.isCache, eh.storageClass, eh.isMigration)
+				if gen := atomic.LoadUint64(&eh.stream.inlineGen); gen > 0 {
+					// the extent is written from the inline data, each append increases the generation
+					status, err = eh.stream.client.metaWrapper.AppendInlineExtentKey(eh.inode, ekey, discard, eh.storageClass, gen)
+					if err == nil {
+						atomic.CompareAndSwapUint64(&eh.stream.inlineGen, gen, gen+1)
+					}
+				} else {
+					status, err = eh.stream.client.appendExtentKey(eh.stream.parentInode, eh.inode, ekey, discard, eh.stream.isCache, eh.storageClass, eh.isMigration)
+				}
 				if atomic.LoadInt32(&eh.stream.needUpdateVer) > 0 {
 					if errUpdateExtents := eh.stream.GetExtentsForceRefresh(); errUpdateExtents != nil {
 						log.LogErrorf("action[appendExtentKey] inode %v GetExtents err %v errUpdateExtents %v", eh.stream.inode, err, errUpdateExtents)
@@ -0,0 +1,128 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stream
+
+import (
+	"io"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/google/uuid"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// The data of a small file may be kept inline in its inode instead of extents, if the volume sets
+// an inline threshold. A file has either inline data or extents, and the metanode tells whether
+// the data is inline along with the extents, so the streamer only looks for inline data if the file
+// is known to have it. Once the file grows beyond the threshold, the inline data is written into
+// the extents first, and the metanode drops it when the extent is appended. The extents written
+// from the inline data are appended only if the inode has not been changed since the inline data
+// was read, so the inline data written by other clients meanwhile is never lost.
+
+func (s *Streamer) inlineEnabled(storageClass uint32, isMigration bool) bool {
+	return s.client.metaWrapper != nil && !isMigration && !proto.IsCold(s.client.volumeType) &&
+		!proto.IsStorageClassBlobStore(storageClass) && s.handler == nil && s.dirtylist.Len() == 0 &&
+		s.extents.Empty() && (s.client.dataWrapper.InlineDataThreshold() > 0 || s.extents.Inline())
+}
+
+// writeInline writes the data into the inode if it fits in the inline threshold, done is false if
+// the data should be written into the extents, and the inline data has been promoted then.
+func (s *Streamer) writeInline(data []byte, offset, size int, storageClass uint32) (total int, done bool, err error) {
+	if threshold := s.client.dataWrapper.InlineDataThreshold(); threshold > 0 && offset+size <= threshold {
+		newSize, gen, e := s.client.metaWrapper.WriteInline(s.inode, uint64(offset), data[:size])
+		if e == nil {
+			s.extents.update(gen, newSize, false, true, nil)
+			log.LogDebugf("writeInline: ino(%v) offset(%v) size(%v) fileSize(%v) gen(%v)", s.inode, offset, size, newSize, gen)
+			return size, true, nil
+		}
+		if e != syscall.ENOTSUP {
+			log.LogErrorf("writeInline: ino(%v) offset(%v) size(%v) err(%v)", s.inode, offset, size, e)
+			return 0, true, e
+		}
+		// the file may have been written into the extents by other clients
+		if err = s.GetExtentsForce(); err != nil {
+			return 0, true, err
+		}
+		if !s.extents.Empty() {
+			return 0, false, nil
+		}
+	}
+	if !s.extents.Inline() {
+		return 0, false, nil
+	}
+	if err = s.promoteInline(storageClass); err != nil {
+		log.LogErrorf("writeInline: promote ino(%v) err(%v)", s.inode, err)
+		return 0, true, err
+	}
+	return 0, false, nil
+}
+
+// promoteInline writes the inline data of the file into the extents, and waits until the extents
+// are appended to the inode. It fails if the inode is changed by other clients meanwhile.
+func (s *Streamer) promoteInline(storageClass uint32) (err error) {
+	inline, _, gen, err := s.client.metaWrapper.ReadInline(s.inode, 0, proto.MaxInlineDataThreshold)
+	if err != nil || len(inline) == 0 {
+		return
+	}
+	atomic.StoreUint64(&s.inlineGen, gen)
+	defer atomic.StoreUint64(&s.inlineGen, 0)
+	for _, req := range s.extents.PrepareWriteRequests(0, len(inline), inline) {
+		if _, err = s.doWriteAppend(req, false, storageClass, false); err != nil {
+			return
+		}
+	}
+	if err = s.flush(true, uuid.New().String()); err != nil {
+		return
+	}
+	log.LogDebugf("promoteInline: ino(%v) size(%v) extents(%v)", s.inode, len(inline), s.extents.List())
+	return
+}
+
+// readInline reads the inline data of the file which has no extents, the range of the file beyond
+// the inline data reads zeros.
+func (s *Streamer) readInline(data []byte, offset, size, fileSize int, storageClass uint32) (total int, err error) {
+	if offset > fileSize {
+		return
+	}
+	readSize := size
+	if offset+size > fileSize {
+		readSize = fileSize - offset
+		err = io.EOF
+	}
+	if readSize == 0 {
+		return
+	}
+	inline, _, _, e := s.client.metaWrapper.ReadInline(s.inode, uint64(offset), readSize)
+	if e != nil {
+		log.LogErrorf("readInline: ino(%v) offset(%v) size(%v) err(%v)", s.inode, offset, readSize, e)
+		return 0, e
+	}
+	// the cached extents may be stale if the file has been promoted by other clients
+	if len(inline) < readSize {
+		if e = s.GetExtentsForce(); e != nil {
+			return 0, e
+		}
+		if !s.extents.Empty() {
+			return s.read(data, offset, size, storageClass)
+		}
+	}
+	n := copy(data[:readSize], inline)
+	for i := n; i < readSize; i++ {
+		data[i] = 0
+	}
+	return readSize, err
+}
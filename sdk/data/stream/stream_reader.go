@@ -62,6 +62,7 @@ type Streamer struct {
 	pendingCache         chan bcacheKey
 	verSeq               uint64
 	needUpdateVer        int32
+	inlineGen            uint64 // generation of the inline data being promoted, accessed atomically
 	isCache              bool
 	openForWrite         bool
 	rdonly               bool
@@ -165,18 +166,31 @@ func (s *Streamer) String() string {
 // TODO should we call it RefreshExtents instead?
 func (s *Streamer) GetExtents(isMigration bool) error {
 	if s.client.disableMetaCache || !s.needBCache {
-		return s.extents.RefreshForce(s.inode, false, s.client.getExtents, s.isCache, s.openForWrite, isMigration)
+		return s.extents.RefreshForce(s.inode, false, s.getExtentsFunc(), s.isCache, s.openForWrite, isMigration)
 	}
 
-	return s.extents.Refresh(s.inode, s.client.getExtents, s.isCache, s.openForWrite, isMigration)
+	return s.extents.Refresh(s.inode, s.getExtentsFunc(), s.isCache, s.openForWrite, isMigration)
 }
 
 func (s *Streamer) GetExtentsForce() error {
-	return s.extents.RefreshForce(s.inode, false, s.client.getExtents, s.isCache, s.openForWrite, false)
+	return s.extents.RefreshForce(s.inode, false, s.getExtentsFunc(), s.isCache, s.openForWrite, false)
 }
 
 func (s *Streamer) GetExtentsForceRefresh() error {
-	return s.extents.RefreshForce(s.inode, true, s.client.getExtents, s.isCache, s.openForWrite, false)
+	return s.extents.RefreshForce(s.inode, true, s.getExtentsFunc(), s.isCache, s.openForWrite, false)
+}
+
+// getExtentsFunc returns the function to get the extents of the file, which also tells whether the
+// data of the file is inline if the client has the meta wrapper.
+func (s *Streamer) getExtentsFunc() GetExtentsWithInlineFunc {
+	if s.client.metaWrapper != nil {
+		return s.client.metaWrapper.GetExtentsWithInline
+	}
+	getExtents := s.client.getExtents
+	return func(inode uint64, isCache bool, openForWrite bool, isMigration bool) (uint64, uint64, []proto.ExtentKey, bool, error) {
+		gen, size, extents, err := getExtents(inode, isCache, openForWrite, isMigration)
+		return gen, size, extents, false, err
+	}
 }
 
 // GetExtentReader returns the extent reader.
@@ -249,6 +263,10 @@ func (s *Streamer) read(data []byte, offset int, size int, storageClass uint32)
 
 	filesize, _ := s.extents.Size()
 	log.LogDebugf("read: ino(%v) requests(%v) filesize(%v)", s.inode, requests, filesize)
+	if s.client.metaWrapper != nil && !proto.IsCold(s.client.volumeType) && !proto.IsStorageClassBlobStore(storageClass) &&
+		filesize > 0 && s.extents.Inline() && s.extents.Empty() {
+		return s.readInline(data, offset, size, filesize, storageClass)
+	}
 	for _, req := range requests {
 		log.LogDebugf("action[streamer.read] req %v", req)
 		if req.ExtentKey == nil {
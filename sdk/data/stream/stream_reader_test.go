@@ -332,7 +332,7 @@ func TestStreamerRead_WithHoles_Consistency(t *testing.T) {
 
 	s := NewStreamer(client, 1, false, false, "/test/file")
 	// Pre-fill extents
-	s.extents.RefreshForce(1, true, getExtents, false, false, false)
+	s.GetExtentsForceRefresh()
 
 	// 7. Verify Data (Data Node - RemoteCache Disabled)
 	s.client.forceRemoteCache = true
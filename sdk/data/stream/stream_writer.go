@@ -378,6 +378,13 @@ begin:
 	s.client.writeLimiter.Wait(ctx)
 	s.client.LimitManager.WriteAlloc(ctx, size)
 
+	if s.inlineEnabled(storageClass, isMigration) {
+		var done bool
+		if total, done, err = s.writeInline(data, offset, size, storageClass); done || err != nil {
+			return
+		}
+	}
+
 	requests := s.extents.PrepareWriteRequests(offset, size, data)
 	// requests contain offset
 	log.LogDebugf("Streamer write: ino(%v) prepared requests(%v) offset(%v) fileSize(%v)", s.inode, requests, offset, fileSize)
@@ -21,6 +21,7 @@ import (
 	"net"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cubefs/cubefs/proto"
@@ -93,6 +94,9 @@ type Wrapper struct {
 	HostsDelay             sync.Map
 
 	readFailedHosts map[uint64]map[string]time.Time
+
+	// the files not larger than it are kept inline in their inodes, 0 means disabled
+	inlineDataThreshold int64
 }
 
 // NewDataPartitionWrapper returns a new data partition wrapper.
@@ -173,6 +177,11 @@ func (w *Wrapper) FollowerRead() bool {
 	return w.followerRead
 }
 
+// InlineDataThreshold returns the max size of the files kept inline in their inodes, 0 means disabled.
+func (w *Wrapper) InlineDataThreshold() int {
+	return int(atomic.LoadInt64(&w.inlineDataThreshold))
+}
+
 func (w *Wrapper) SetMaximallyRead(maximallyRead bool) {
 	w.maximallyReadClientCfg = maximallyRead
 	w.maximallyRead = w.maximallyReadClientCfg || w.maximallyRead
@@ -244,6 +253,7 @@ func (w *Wrapper) GetSimpleVolView() (err error) {
 	w.dpSelectorParm = view.DpSelectorParm
 	w.volType = view.VolType
 	w.EnablePosixAcl = view.EnablePosixAcl
+	atomic.StoreInt64(&w.inlineDataThreshold, view.InlineDataThreshold)
 
 	w.UpdateUidsView(view)
 
@@ -396,6 +406,11 @@ func (w *Wrapper) updateSimpleVolView(clientInfo SimpleClientInfo) (err error) {
 		w.dpSelectorChanged = true
 		w.Lock.Unlock()
 	}
+
+	if old := atomic.SwapInt64(&w.inlineDataThreshold, view.InlineDataThreshold); old != view.InlineDataThreshold {
+		log.LogDebugf("UpdateSimpleVolView: update inlineDataThreshold from old(%v) to new(%v)",
+			old, view.InlineDataThreshold)
+	}
 	clientInfo.UpdateRemoteCacheConfig(view)
 	return nil
 }
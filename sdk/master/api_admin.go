@@ -343,6 +343,7 @@ func (api *AdminAPI) UpdateVolume(
 	request.addParam("trashInterval", strconv.FormatInt(vv.TrashInterval, 10))
 	request.addParam("accessTimeValidInterval", strconv.FormatInt(vv.AccessTimeInterval, 10))
 	request.addParam("enablePersistAccessTime", strconv.FormatBool(vv.EnablePersistAccessTime))
+	request.addParam("inlineDataThreshold", strconv.FormatInt(vv.InlineDataThreshold, 10))
 	request.addParam("volStorageClass", strconv.FormatUint(uint64(vv.VolStorageClass), 10))
 	request.addParam("forbidWriteOpOfProtoVersion0", strconv.FormatBool(vv.ForbidWriteOpOfProtoVer0))
 	request.addParam(proto.LeaderRetryTimeoutKey, strconv.FormatUint(uint64(vv.LeaderRetryTimeOut), 10))
@@ -788,6 +789,49 @@ func (api *AdminAPI) GetQuota(volName string, quotaId string) (quotaInfo *proto.
 	return quotaInfo, err
 }
 
+func (api *AdminAPI) SetUserQuota(req *proto.SetUserQuotaRequest) (err error) {
+	request := newRequest(get, proto.UserQuotaSet).Header(api.h).Param(
+		anyParam{"name", req.VolName},
+		anyParam{"type", req.Type.String()},
+		anyParam{"id", req.Id},
+		anyParam{"softBytes", req.SoftBytes},
+		anyParam{"hardBytes", req.HardBytes},
+		anyParam{"softInodes", req.SoftInodes},
+		anyParam{"hardInodes", req.HardInodes},
+		anyParam{"gracePeriod", req.GracePeriod})
+	if _, err = api.mc.serveRequest(request); err != nil {
+		log.LogErrorf("action[SetUserQuota] fail. %v", err)
+		return
+	}
+	log.LogInfof("action[SetUserQuota] success.")
+	return
+}
+
+func (api *AdminAPI) DeleteUserQuota(volName string, quotaType proto.UserQuotaType, id uint32) (err error) {
+	request := newRequest(get, proto.UserQuotaDelete).Header(api.h).Param(
+		anyParam{"name", volName},
+		anyParam{"type", quotaType.String()},
+		anyParam{"id", id})
+	if _, err = api.mc.serveRequest(request); err != nil {
+		log.LogErrorf("action[DeleteUserQuota] fail. %v", err)
+		return
+	}
+	log.LogInfo("action[DeleteUserQuota] success.")
+	return
+}
+
+func (api *AdminAPI) ListUserQuota(volName string) (quotaInfos []*proto.UserQuotaInfo, err error) {
+	resp := &proto.ListUserQuotaResponse{}
+	if err = api.mc.requestWith(resp, newRequest(get, proto.UserQuotaList).
+		Header(api.h).addParam("name", volName)); err != nil {
+		log.LogErrorf("action[ListUserQuota] fail. %v", err)
+		return
+	}
+	quotaInfos = resp.Quotas
+	log.LogInfof("action[ListUserQuota] success.")
+	return
+}
+
 func (api *AdminAPI) QueryBadDisks() (badDisks *proto.BadDiskInfos, err error) {
 	badDisks = &proto.BadDiskInfos{}
 	err = api.mc.requestWith(badDisks, newRequest(get, proto.QueryBadDisks).Header(api.h))
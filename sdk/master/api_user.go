@@ -97,6 +97,20 @@ func (api *UserAPI) UpdatePolicy(param *proto.UserPermUpdateParam, clientIDKey s
 	return
 }
 
+func (api *UserAPI) UpdatePublicAccessBlock(param *proto.UserPublicAccessBlockUpdateParam, clientIDKey string) (userInfo *proto.UserInfo, err error) {
+	userInfo = &proto.UserInfo{}
+	err = api.mc.requestWith(userInfo, newRequest(post, proto.UserUpdatePublicAccessBlock).
+		Header(api.h).Body(param).addParam("clientIDKey", clientIDKey))
+	return
+}
+
+func (api *UserAPI) UpdateAccountMetadata(param *proto.UserAccountMetadataUpdateParam, clientIDKey string) (userInfo *proto.UserInfo, err error) {
+	userInfo = &proto.UserInfo{}
+	err = api.mc.requestWith(userInfo, newRequest(post, proto.UserUpdateAccountMetadata).
+		Header(api.h).Body(param).addParam("clientIDKey", clientIDKey))
+	return
+}
+
 func (api *UserAPI) RemovePolicy(param *proto.UserPermRemoveParam, clientIDKey string) (userInfo *proto.UserInfo, err error) {
 	userInfo = &proto.UserInfo{}
 	err = api.mc.requestWith(userInfo, newRequest(post, proto.UserRemovePolicy).
@@ -1025,11 +1025,15 @@ deleteDirectly:
 }
 
 func isObjectLocked(mw *MetaWrapper, inode uint64, name string) error {
-	xattrInfo, err := mw.XAttrGet_ll(inode, "oss:lock")
+	xattrInfo, err := mw.XAttrGetAll_ll(inode)
 	if err != nil {
 		log.LogErrorf("isObjectLocked: check ObjectLock err(%v) name(%v)", err, name)
 		return err
 	}
+	if string(xattrInfo.Get("oss:legal-hold")) == "ON" {
+		log.LogWarnf("isObjectLocked: object is under legal hold, name(%v)", name)
+		return errors.New("Access Denied")
+	}
 	retainUntilDate := xattrInfo.Get("oss:lock")
 	if len(retainUntilDate) > 0 {
 		retainUntilDateInt64, err := strconv.ParseInt(string(retainUntilDate), 10, 64)
@@ -1342,7 +1346,7 @@ func (mw *MetaWrapper) rename_ll(srcParentID uint64, srcName string, dstParentID
 			return syscall.EEXIST
 		}
 
-		status, oldInode, err = mw.dupdate(dstParentMP, dstParentID, dstName, inode, dstFullPath)
+		status, oldInode, err = mw.dupdate(dstParentMP, dstParentID, dstName, inode, 0, dstFullPath)
 		if err != nil {
 			return syscall.EAGAIN
 		}
@@ -1370,7 +1374,7 @@ func (mw *MetaWrapper) rename_ll(srcParentID uint64, srcName string, dstParentID
 		if oldInode == 0 {
 			sts, inode, denVer, e = mw.ddelete(dstParentMP, dstParentID, dstName, 0, lastVerSeq, dstFullPath)
 		} else {
-			sts, denVer, e = mw.dupdate(dstParentMP, dstParentID, dstName, oldInode, dstFullPath)
+			sts, denVer, e = mw.dupdate(dstParentMP, dstParentID, dstName, oldInode, 0, dstFullPath)
 		}
 		if e == nil && sts == statusOK {
 			mw.iunlink(srcMP, inode, lastVerSeq, denVer, srcFullPath)
@@ -1484,7 +1488,40 @@ func (mw *MetaWrapper) DentryUpdate_ll(parentID uint64, name string, inode uint6
 		return
 	}
 	var status int
-	status, oldInode, err = mw.dupdate(parentMP, parentID, name, inode, fullPath)
+	status, oldInode, err = mw.dupdate(parentMP, parentID, name, inode, 0, fullPath)
+	if err != nil || status != statusOK {
+		err = statusToErrno(status)
+		return
+	}
+	return
+}
+
+// DentryUpdateIf_ll updates the dentry only if it still points to expectInode, EEXIST is
+// returned if the dentry has been pointed to another inode, ENOENT if it has been removed.
+func (mw *MetaWrapper) DentryUpdateIf_ll(parentID uint64, name string, inode, expectInode uint64, fullPath string) (oldInode uint64, err error) {
+	parentMP := mw.getPartitionByInode(parentID)
+	if parentMP == nil {
+		err = syscall.ENOENT
+		return
+	}
+	var status int
+	status, oldInode, err = mw.dupdate(parentMP, parentID, name, inode, expectInode, fullPath)
+	if err != nil || status != statusOK {
+		err = statusToErrno(status)
+		return
+	}
+	return
+}
+
+// DentryDelete_ll deletes the dentry only, the inode it points to is left untouched.
+func (mw *MetaWrapper) DentryDelete_ll(parentID uint64, name string, fullPath string) (inode uint64, err error) {
+	parentMP := mw.getPartitionByInode(parentID)
+	if parentMP == nil {
+		err = syscall.ENOENT
+		return
+	}
+	var status int
+	status, inode, _, err = mw.ddelete(parentMP, parentID, name, 0, mw.LastVerSeq, fullPath)
 	if err != nil || status != statusOK {
 		err = statusToErrno(status)
 		return
@@ -1498,7 +1535,7 @@ func (mw *MetaWrapper) SplitExtentKey(parentInode, inode uint64, ek proto.Extent
 		return syscall.ENOENT
 	}
 
-	status, err := mw.appendExtentKey(mp, inode, ek, nil, true, false, storageClass, false)
+	status, err := mw.appendExtentKey(mp, inode, ek, nil, true, false, storageClass, false, 0)
 	if err != nil || status != statusOK {
 		log.LogErrorf("SplitExtentKey: inode(%v) ek(%v) err(%v) status(%v)", inode, ek, err, status)
 		return statusToErrno(status)
@@ -1517,7 +1554,7 @@ func (mw *MetaWrapper) AppendExtentKey(parentInode, inode uint64, ek proto.Exten
 		return statusError, syscall.ENOENT
 	}
 
-	status, err := mw.appendExtentKey(mp, inode, ek, discard, false, isCache, storageClass, isMigration)
+	status, err := mw.appendExtentKey(mp, inode, ek, discard, false, isCache, storageClass, isMigration, 0)
 	if err != nil || status != statusOK {
 		log.LogErrorf("MetaWrapper AppendExtentKey: inode(%v) ek(%v) local discard(%v) err(%v) status(%v)", inode, ek, discard, err, status)
 		return status, statusToErrno(status)
@@ -1527,6 +1564,26 @@ func (mw *MetaWrapper) AppendExtentKey(parentInode, inode uint64, ek proto.Exten
 	return statusOK, nil
 }
 
+// AppendInlineExtentKey appends the extent key written from the inline data of the inode, which is read
+// at the generation gen. It fails with StatusConflictExtents if the inode has been changed since then.
+func (mw *MetaWrapper) AppendInlineExtentKey(inode uint64, ek proto.ExtentKey, discard []proto.ExtentKey,
+	storageClass uint32, gen uint64,
+) (int, error) {
+	mp := mw.getPartitionByInode(inode)
+	if mp == nil {
+		return statusError, syscall.ENOENT
+	}
+
+	status, err := mw.appendExtentKey(mp, inode, ek, discard, false, false, storageClass, false, gen)
+	if err != nil || status != statusOK {
+		log.LogErrorf("MetaWrapper AppendInlineExtentKey: inode(%v) ek(%v) discard(%v) gen(%v) err(%v) status(%v)",
+			inode, ek, discard, gen, err, status)
+		return status, statusToErrno(status)
+	}
+	log.LogDebugf("MetaWrapper AppendInlineExtentKey: ino(%v) ek(%v) discard(%v) gen(%v)", inode, ek, discard, gen)
+	return statusOK, nil
+}
+
 // AppendExtentKeys append multiple extent key into specified inode with single request.
 func (mw *MetaWrapper) AppendExtentKeys(inode uint64, eks []proto.ExtentKey, storageClass uint32) error {
 	if storageClass != proto.MediaType_SSD && storageClass != proto.MediaType_HDD {
@@ -1547,6 +1604,34 @@ func (mw *MetaWrapper) AppendExtentKeys(inode uint64, eks []proto.ExtentKey, sto
 	return nil
 }
 
+// AppendExtentKeysIf_ll appends the extent keys and sets the extended attributes of the inode in one
+// step, only if the size and generation of the inode are still the expected ones. syscall.ENOTSUP is
+// returned if the inode has been changed.
+func (mw *MetaWrapper) AppendExtentKeysIf_ll(inode, size, gen uint64, eks []proto.ExtentKey, storageClass uint32,
+	modifyTime int64, attrs map[string]string, fullPath string,
+) error {
+	mp := mw.getPartitionByInode(inode)
+	if mp == nil {
+		return syscall.ENOENT
+	}
+	req := &proto.AppendExtentKeysIfRequest{
+		Inode:        inode,
+		Extents:      eks,
+		StorageClass: storageClass,
+		Size:         size,
+		Generation:   gen,
+		ModifyTime:   modifyTime,
+		Attrs:        attrs,
+		FullPath:     fullPath,
+	}
+	status, err := mw.appendExtentKeysIf(mp, req)
+	if err != nil || status != statusOK {
+		return statusToErrno(status)
+	}
+	log.LogDebugf("AppendExtentKeysIf_ll: ino(%v) size(%v) gen(%v) extentKeys(%v)", inode, size, gen, eks)
+	return nil
+}
+
 // AppendObjExtentKeys append multiple obj extent key into specified inode with single request.
 func (mw *MetaWrapper) AppendObjExtentKeys(inode uint64, eks []proto.ObjExtentKey) error {
 	mp := mw.getPartitionByInode(inode)
@@ -1566,9 +1651,18 @@ func (mw *MetaWrapper) AppendObjExtentKeys(inode uint64, eks []proto.ObjExtentKe
 func (mw *MetaWrapper) GetExtents(inode uint64, isCache, openForWrite,
 	isMigration bool,
 ) (gen uint64, size uint64, extents []proto.ExtentKey, err error) {
+	gen, size, extents, _, err = mw.GetExtentsWithInline(inode, isCache, openForWrite, isMigration)
+	return
+}
+
+// GetExtentsWithInline is the same as GetExtents, and also returns whether the data of the file
+// is kept inline in the inode.
+func (mw *MetaWrapper) GetExtentsWithInline(inode uint64, isCache, openForWrite,
+	isMigration bool,
+) (gen uint64, size uint64, extents []proto.ExtentKey, inline bool, err error) {
 	mp := mw.getPartitionByInode(inode)
 	if mp == nil {
-		return 0, 0, nil, syscall.ENOENT
+		return 0, 0, nil, false, syscall.ENOENT
 	}
 
 	resp, err := mw.getExtents(mp, inode, isCache, openForWrite, isMigration)
@@ -1579,17 +1673,18 @@ func (mw *MetaWrapper) GetExtents(inode uint64, isCache, openForWrite,
 			}
 		}
 		log.LogErrorf("GetExtents: ino(%v) err(%v)", inode, err)
-		return 0, 0, nil, err
+		return 0, 0, nil, false, err
 	}
 	extents = resp.Extents
 	gen = resp.Generation
 	size = resp.Size
+	inline = resp.Inline
 
 	// log.LogDebugf("GetObjExtents stack[%v]", string(debug.Stack()))
 	if log.EnableDebug() {
-		log.LogDebugf("GetExtents: ino(%v) gen(%v) size(%v) extents(%v)", inode, gen, size, extents)
+		log.LogDebugf("GetExtents: ino(%v) gen(%v) size(%v) extents(%v) inline(%v)", inode, gen, size, extents, inline)
 	}
-	return gen, size, extents, nil
+	return gen, size, extents, inline, nil
 }
 
 func (mw *MetaWrapper) GetObjExtents(inode uint64) (gen uint64, size uint64, extents []proto.ExtentKey, objExtents []proto.ObjExtentKey, err error) {
@@ -0,0 +1,122 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package meta
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/cubefs/cubefs/proto"
+)
+
+// ChangeFeed reads the change events published by all the meta partitions of the volume. The
+// position of the feed is a sequence number per partition, which can be saved by Cursor and used
+// to resume the feed later. The events are kept in memory by the partitions only for a while, so
+// the feed may not be resumed from an old position, see ChangeFeedExpiredError.
+type ChangeFeed struct {
+	mw     *MetaWrapper
+	cursor map[uint64]uint64
+	mu     sync.Mutex
+}
+
+// ChangeFeedExpiredError is returned if some events after the cursor of the partitions are lost,
+// because they were dropped or the partitions have restarted. Start is the sequence number of each
+// partition from which all the events are retained, the consumer should rescan the partitions,
+// and then Seek to Start to continue the feed.
+type ChangeFeedExpiredError struct {
+	Start map[uint64]uint64
+}
+
+func (e *ChangeFeedExpiredError) Error() string {
+	return fmt.Sprintf("change feed cursor expired, start(%v)", e.Start)
+}
+
+// NewChangeFeed returns a feed starting after the cursor, the partitions not in the cursor are read
+// from the oldest events retained.
+func (mw *MetaWrapper) NewChangeFeed(cursor map[uint64]uint64) *ChangeFeed {
+	cf := &ChangeFeed{
+		mw:     mw,
+		cursor: make(map[uint64]uint64, len(cursor)),
+	}
+	for pid, seq := range cursor {
+		cf.cursor[pid] = seq
+	}
+	return cf
+}
+
+// Cursor returns the sequence numbers of the partitions the feed has read up to.
+func (cf *ChangeFeed) Cursor() map[uint64]uint64 {
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+	cursor := make(map[uint64]uint64, len(cf.cursor))
+	for pid, seq := range cf.cursor {
+		cursor[pid] = seq
+	}
+	return cursor
+}
+
+// Seek moves the feed of the partition to the sequence number.
+func (cf *ChangeFeed) Seek(pid, seq uint64) {
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+	cf.cursor[pid] = seq
+}
+
+// Next reads at most limit events from each partition and advances the feed. The events are
+// ordered by the partition, and by the sequence number in each partition, there is no order
+// between the events of different partitions. The feed is not advanced if any partition fails,
+// and a *ChangeFeedExpiredError is returned if the cursor of any partition is expired.
+func (cf *ChangeFeed) Next(limit uint32) (events []*proto.ChangeEvent, err error) {
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+
+	cf.mw.RLock()
+	partitions := make([]*MetaPartition, 0, len(cf.mw.partitions))
+	for _, mp := range cf.mw.partitions {
+		partitions = append(partitions, mp)
+	}
+	cf.mw.RUnlock()
+	sort.Slice(partitions, func(i, j int) bool {
+		return partitions[i].PartitionID < partitions[j].PartitionID
+	})
+
+	next := make(map[uint64]uint64, len(partitions))
+	var expired *ChangeFeedExpiredError
+	for _, mp := range partitions {
+		status, resp, e := cf.mw.getChangeEvents(mp, cf.cursor[mp.PartitionID], limit)
+		if e != nil || status != statusOK {
+			err = fmt.Errorf("get change events of mp(%v) failed: status(%v) err(%v)", mp.PartitionID, status, e)
+			return nil, err
+		}
+		if resp.Expired {
+			if expired == nil {
+				expired = &ChangeFeedExpiredError{Start: make(map[uint64]uint64)}
+			}
+			expired.Start[mp.PartitionID] = resp.Start
+			continue
+		}
+		next[mp.PartitionID] = resp.Next
+		events = append(events, resp.Events...)
+	}
+	if expired != nil {
+		return nil, expired
+	}
+
+	for pid, seq := range next {
+		cf.cursor[pid] = seq
+	}
+	return events, nil
+}
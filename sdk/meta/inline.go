@@ -0,0 +1,53 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package meta
+
+import (
+	"syscall"
+
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// WriteInline writes the data of a small file into its inode, and returns the size and generation
+// of the inode after the write. syscall.ENOTSUP is returned if the data can not be kept inline,
+// e.g. the file already has extents or the data exceeds the inline threshold of the volume.
+func (mw *MetaWrapper) WriteInline(ino, offset uint64, data []byte) (size, gen uint64, err error) {
+	mp := mw.getPartitionByInode(ino)
+	if mp == nil {
+		log.LogErrorf("WriteInline: no such partition, ino(%v)", ino)
+		return 0, 0, syscall.ENOENT
+	}
+	status, resp, err := mw.writeInline(mp, ino, offset, data)
+	if err != nil || status != statusOK {
+		return 0, 0, statusErrToErrno(status, err)
+	}
+	return resp.Size, resp.Generation, nil
+}
+
+// ReadInline reads at most size bytes of the inline data of the inode from the offset, and returns
+// the size and the generation of the file. The range of the file beyond the returned data up to its
+// size reads zeros.
+func (mw *MetaWrapper) ReadInline(ino, offset uint64, size int) (data []byte, fileSize, gen uint64, err error) {
+	mp := mw.getPartitionByInode(ino)
+	if mp == nil {
+		log.LogErrorf("ReadInline: no such partition, ino(%v)", ino)
+		return nil, 0, 0, syscall.ENOENT
+	}
+	status, resp, err := mw.readInline(mp, ino, offset, uint32(size))
+	if err != nil || status != statusOK {
+		return nil, 0, 0, statusErrToErrno(status, err)
+	}
+	return resp.Data, resp.Size, resp.Generation, nil
+}
@@ -0,0 +1,161 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package meta
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"syscall"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	lockRenewInterval  = time.Duration(proto.DefaultPosixLockLease) * time.Second / 3
+	lockWaitMinBackoff = 10 * time.Millisecond
+	lockWaitMaxBackoff = time.Second
+)
+
+func newLockClientId() uint64 {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return uint64(time.Now().UnixNano())
+	}
+	return binary.BigEndian.Uint64(b[:])
+}
+
+// LockClientId returns the id of the client which owns the posix locks and flocks set by it,
+// the locks expire if the client stops renewing them.
+func (mw *MetaWrapper) LockClientId() uint64 {
+	return mw.lockClientId
+}
+
+// SetLock sets or releases a posix lock or flock of the inode. If wait is false, syscall.EAGAIN is
+// returned when the lock is held by others; otherwise it waits until the lock is acquired or the
+// context is done.
+func (mw *MetaWrapper) SetLock(ctx context.Context, ino uint64, lock *proto.PosixLock, wait bool) (err error) {
+	mp := mw.getPartitionByInode(ino)
+	if mp == nil {
+		log.LogErrorf("SetLock: no such partition, ino(%v)", ino)
+		return syscall.ENOENT
+	}
+	lock.ClientId = mw.lockClientId
+	if lock.Type != proto.PosixLockUnlock {
+		mw.addLockInode(ino)
+	}
+
+	backoff := lockWaitMinBackoff
+	for {
+		status, conflict, err := mw.setLock(mp, ino, lock)
+		if err != nil {
+			return statusErrToErrno(status, err)
+		}
+		if status == statusOK {
+			return nil
+		}
+		if status != statusExist {
+			return statusToErrno(status)
+		}
+		if !wait {
+			log.LogDebugf("SetLock: ino(%v) lock(%v) conflicts with %v", ino, lock, conflict)
+			return syscall.EAGAIN
+		}
+
+		select {
+		case <-ctx.Done():
+			return syscall.EINTR
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > lockWaitMaxBackoff {
+			backoff = lockWaitMaxBackoff
+		}
+	}
+}
+
+// GetLock returns the first lock conflicting with the given one, it is nil if the lock can be set.
+func (mw *MetaWrapper) GetLock(ino uint64, lock *proto.PosixLock) (conflict *proto.PosixLock, err error) {
+	mp := mw.getPartitionByInode(ino)
+	if mp == nil {
+		log.LogErrorf("GetLock: no such partition, ino(%v)", ino)
+		return nil, syscall.ENOENT
+	}
+	lock.ClientId = mw.lockClientId
+	status, conflict, err := mw.getLock(mp, ino, lock)
+	if err != nil || status != statusOK {
+		return nil, statusErrToErrno(status, err)
+	}
+	return conflict, nil
+}
+
+func (mw *MetaWrapper) addLockInode(ino uint64) {
+	mw.lockInodesMu.Lock()
+	mw.lockInodes[ino] = time.Now()
+	mw.lockInodesMu.Unlock()
+	mw.lockRenewOnce.Do(func() {
+		go mw.renewLockTick()
+	})
+}
+
+func (mw *MetaWrapper) renewLockTick() {
+	t := time.NewTicker(lockRenewInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			mw.renewLocks()
+		case <-mw.closeCh:
+			return
+		}
+	}
+}
+
+// renewLocks renews the locks of the client, and forgets the inodes not locked any more.
+func (mw *MetaWrapper) renewLocks() {
+	start := time.Now()
+	partitions := make(map[*MetaPartition][]uint64)
+	mw.lockInodesMu.Lock()
+	for ino := range mw.lockInodes {
+		if mp := mw.getPartitionByInode(ino); mp != nil {
+			partitions[mp] = append(partitions[mp], ino)
+		}
+	}
+	mw.lockInodesMu.Unlock()
+
+	for mp, inodes := range partitions {
+		status, locked, err := mw.renewLock(mp, inodes)
+		if err != nil || status != statusOK {
+			log.LogWarnf("renewLocks: mp(%v) inodes(%v) status(%v) err(%v)", mp.PartitionID, inodes, status, err)
+			continue
+		}
+		lockedSet := make(map[uint64]struct{}, len(locked))
+		for _, ino := range locked {
+			lockedSet[ino] = struct{}{}
+		}
+		mw.lockInodesMu.Lock()
+		for _, ino := range inodes {
+			if _, ok := lockedSet[ino]; ok {
+				continue
+			}
+			// keep the inodes locked after the renewal started
+			if lockTime, ok := mw.lockInodes[ino]; ok && lockTime.Before(start) {
+				delete(mw.lockInodes, ino)
+			}
+		}
+		mw.lockInodesMu.Unlock()
+	}
+}
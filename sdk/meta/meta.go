@@ -172,6 +172,13 @@ type MetaWrapper struct {
 	uniqidRangeMutex sync.Mutex
 
 	qc *QuotaCache
+
+	// posix locks and flocks held by this client, the inodes are renewed until unlocked
+	lockClientId  uint64
+	lockInodes    map[uint64]time.Time
+	lockInodesMu  sync.Mutex
+	lockRenewOnce sync.Once
+
 	// trash
 	TrashInterval int64
 	trashPolicy   *Trash
@@ -217,6 +224,8 @@ func NewMetaWrapper(config *MetaConfig) (*MetaWrapper, error) {
 	var err error
 	mw := new(MetaWrapper)
 	mw.closeCh = make(chan struct{}, 1)
+	mw.lockClientId = newLockClientId()
+	mw.lockInodes = make(map[uint64]time.Time)
 
 	if config.Authenticate {
 		ticketMess := config.TicketMess
@@ -687,7 +687,10 @@ func (mw *MetaWrapper) txDupdate(tx *Transaction, mp *MetaPartition, parentID ui
 	return statusOK, resp.Inode, nil
 }
 
-func (mw *MetaWrapper) dupdate(mp *MetaPartition, parentID uint64, name string, newInode uint64, fullPath string) (status int, oldInode uint64, err error) {
+// dupdate updates the dentry to newInode, it's applied only if the dentry points to expectInode
+// unless expectInode is zero. The conditional update is sent by OpMetaUpdateDentryIf, which is
+// rejected by the meta nodes not supporting it rather than applied unconditionally.
+func (mw *MetaWrapper) dupdate(mp *MetaPartition, parentID uint64, name string, newInode, expectInode uint64, fullPath string) (status int, oldInode uint64, err error) {
 	bgTime := stat.BeginStat()
 	defer func() {
 		stat.EndStat("dupdate", err, bgTime, 1)
@@ -697,21 +700,36 @@ func (mw *MetaWrapper) dupdate(mp *MetaPartition, parentID uint64, name string,
 		return statusExist, 0, nil
 	}
 
-	req := &proto.UpdateDentryRequest{
-		VolName:     mw.volname,
-		PartitionID: mp.PartitionID,
-		ParentID:    parentID,
-		Name:        name,
-		Inode:       newInode,
-	}
-	req.FullPaths = []string{fullPath}
-
+	var req interface{}
 	packet := proto.NewPacketReqID()
-	packet.Opcode = proto.OpMetaUpdateDentry
 	packet.PartitionID = mp.PartitionID
+	if expectInode == 0 {
+		updateReq := &proto.UpdateDentryRequest{
+			VolName:     mw.volname,
+			PartitionID: mp.PartitionID,
+			ParentID:    parentID,
+			Name:        name,
+			Inode:       newInode,
+		}
+		updateReq.FullPaths = []string{fullPath}
+		packet.Opcode = proto.OpMetaUpdateDentry
+		req = updateReq
+	} else {
+		updateReq := &proto.UpdateDentryIfRequest{
+			VolName:     mw.volname,
+			PartitionID: mp.PartitionID,
+			ParentID:    parentID,
+			Name:        name,
+			Inode:       newInode,
+			OldIno:      expectInode,
+		}
+		updateReq.FullPaths = []string{fullPath}
+		packet.Opcode = proto.OpMetaUpdateDentryIf
+		req = updateReq
+	}
 	err = packet.MarshalData(req)
 	if err != nil {
-		log.LogErrorf("dupdate: req(%v) err(%v)", *req, err)
+		log.LogErrorf("dupdate: req(%v) err(%v)", req, err)
 		return
 	}
 
@@ -722,14 +740,14 @@ func (mw *MetaWrapper) dupdate(mp *MetaPartition, parentID uint64, name string,
 
 	packet, err = mw.sendToMetaPartitionWithTx(mp, packet)
 	if err != nil {
-		log.LogErrorf("dupdate: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		log.LogErrorf("dupdate: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, req, err)
 		return
 	}
 
 	status = parseStatus(packet.ResultCode)
 	if status != statusOK {
 		err = errors.New(packet.GetResultMsg())
-		log.LogErrorf("dupdate: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		log.LogErrorf("dupdate: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, req, packet.GetResultMsg())
 		return
 	}
 
@@ -739,7 +757,7 @@ func (mw *MetaWrapper) dupdate(mp *MetaPartition, parentID uint64, name string,
 		log.LogErrorf("dupdate: packet(%v) mp(%v) err(%v) PacketData(%v)", packet, mp, err, string(packet.Data))
 		return
 	}
-	log.LogDebugf("dupdate: packet(%v) mp(%v) req(%v) oldIno(%v)", packet, mp, *req, resp.Inode)
+	log.LogDebugf("dupdate: packet(%v) mp(%v) req(%v) oldIno(%v)", packet, mp, req, resp.Inode)
 	return statusOK, resp.Inode, nil
 }
 
@@ -1209,7 +1227,7 @@ func (mw *MetaWrapper) readDirLimit(mp *MetaPartition, parentID uint64, from str
 }
 
 func (mw *MetaWrapper) appendExtentKey(mp *MetaPartition, inode uint64, extent proto.ExtentKey,
-	discard []proto.ExtentKey, isSplit bool, isCache bool, storageClass uint32, isMigration bool,
+	discard []proto.ExtentKey, isSplit bool, isCache bool, storageClass uint32, isMigration bool, inlineGen uint64,
 ) (status int, err error) {
 	bgTime := stat.BeginStat()
 	defer func() {
@@ -1217,15 +1235,16 @@ func (mw *MetaWrapper) appendExtentKey(mp *MetaPartition, inode uint64, extent p
 	}()
 
 	req := &proto.AppendExtentKeyWithCheckRequest{
-		VolName:        mw.volname,
-		PartitionID:    mp.PartitionID,
-		Inode:          inode,
-		Extent:         extent,
-		DiscardExtents: discard,
-		IsSplit:        isSplit,
-		IsCache:        isCache,
-		StorageClass:   storageClass,
-		IsMigration:    isMigration,
+		VolName:          mw.volname,
+		PartitionID:      mp.PartitionID,
+		Inode:            inode,
+		Extent:           extent,
+		DiscardExtents:   discard,
+		IsSplit:          isSplit,
+		IsCache:          isCache,
+		StorageClass:     storageClass,
+		IsMigration:      isMigration,
+		InlineGeneration: inlineGen,
 	}
 
 	packet := proto.NewPacketReqID()
@@ -1911,6 +1930,46 @@ func (mw *MetaWrapper) removeMultipart(mp *MetaPartition, path, multipartId stri
 	return statusOK, nil
 }
 
+func (mw *MetaWrapper) appendExtentKeysIf(mp *MetaPartition, req *proto.AppendExtentKeysIfRequest) (status int, err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("appendExtentKeysIf", err, bgTime, 1)
+	}()
+
+	req.VolName = mw.volname
+	req.PartitionId = mp.PartitionID
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaAppendExtentKeysIf
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("appendExtentKeysIf: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("appendExtentKeysIf: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		err = errors.New(packet.GetResultMsg())
+		log.LogWarnf("appendExtentKeysIf: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	log.LogDebugf("appendExtentKeysIf: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+	return
+}
+
 func (mw *MetaWrapper) appendExtentKeys(mp *MetaPartition, inode uint64, extents []proto.ExtentKey, storageClass uint32) (status int, err error) {
 	bgTime := stat.BeginStat()
 	defer func() {
@@ -2822,6 +2881,285 @@ func (mw *MetaWrapper) lockDir(mp *MetaPartition, inode uint64, lease uint64, lo
 	return
 }
 
+func (mw *MetaWrapper) setLock(mp *MetaPartition, inode uint64, lock *proto.PosixLock) (status int, conflict *proto.PosixLock, err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("setLock", err, bgTime, 1)
+	}()
+
+	req := &proto.SetLockRequest{
+		VolName:     mw.volname,
+		PartitionId: mp.PartitionID,
+		Inode:       inode,
+		Lock:        *lock,
+		Lease:       proto.DefaultPosixLockLease,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaSetLock
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("setLock: marshal packet fail, err(%v)", err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("setLock: send to partition fail, packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK && status != statusExist {
+		log.LogWarnf("setLock: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	resp := new(proto.SetLockResponse)
+	if err = packet.UnmarshalData(resp); err != nil {
+		log.LogErrorf("setLock: packet(%v) mp(%v) err(%v) PacketData(%v)", packet, mp, err, string(packet.Data))
+		return
+	}
+	conflict = resp.Conflict
+	log.LogDebugf("setLock: packet(%v) mp(%v) req(%v) status(%v) conflict(%v)", packet, mp, *req, status, conflict)
+	return
+}
+
+func (mw *MetaWrapper) getLock(mp *MetaPartition, inode uint64, lock *proto.PosixLock) (status int, conflict *proto.PosixLock, err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("getLock", err, bgTime, 1)
+	}()
+
+	req := &proto.GetLockRequest{
+		VolName:     mw.volname,
+		PartitionId: mp.PartitionID,
+		Inode:       inode,
+		Lock:        *lock,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaGetLock
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("getLock: marshal packet fail, err(%v)", err)
+		return
+	}
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("getLock: send to partition fail, packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		log.LogWarnf("getLock: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	resp := new(proto.GetLockResponse)
+	if err = packet.UnmarshalData(resp); err != nil {
+		log.LogErrorf("getLock: packet(%v) mp(%v) err(%v) PacketData(%v)", packet, mp, err, string(packet.Data))
+		return
+	}
+	conflict = resp.Lock
+	return
+}
+
+func (mw *MetaWrapper) renewLock(mp *MetaPartition, inodes []uint64) (status int, locked []uint64, err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("renewLock", err, bgTime, 1)
+	}()
+
+	req := &proto.RenewLockRequest{
+		VolName:     mw.volname,
+		PartitionId: mp.PartitionID,
+		ClientId:    mw.lockClientId,
+		Inodes:      inodes,
+		Lease:       proto.DefaultPosixLockLease,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaRenewLock
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("renewLock: marshal packet fail, err(%v)", err)
+		return
+	}
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("renewLock: send to partition fail, packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		log.LogWarnf("renewLock: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	resp := new(proto.RenewLockResponse)
+	if err = packet.UnmarshalData(resp); err != nil {
+		log.LogErrorf("renewLock: packet(%v) mp(%v) err(%v) PacketData(%v)", packet, mp, err, string(packet.Data))
+		return
+	}
+	locked = resp.Inodes
+	return
+}
+
+func (mw *MetaWrapper) getChangeEvents(mp *MetaPartition, from uint64, limit uint32) (status int, resp *proto.GetChangeEventsResponse, err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("getChangeEvents", err, bgTime, 1)
+	}()
+
+	req := &proto.GetChangeEventsRequest{
+		VolName:     mw.volname,
+		PartitionId: mp.PartitionID,
+		From:        from,
+		Limit:       limit,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaGetChangeEvents
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("getChangeEvents: marshal packet fail, err(%v)", err)
+		return
+	}
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("getChangeEvents: send to partition fail, packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		log.LogWarnf("getChangeEvents: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	resp = new(proto.GetChangeEventsResponse)
+	if err = packet.UnmarshalData(resp); err != nil {
+		log.LogErrorf("getChangeEvents: packet(%v) mp(%v) err(%v) PacketData(%v)", packet, mp, err, string(packet.Data))
+		return
+	}
+	return
+}
+
+func (mw *MetaWrapper) writeInline(mp *MetaPartition, inode, offset uint64, data []byte) (status int, resp *proto.WriteInlineResponse, err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("writeInline", err, bgTime, 1)
+	}()
+
+	req := &proto.WriteInlineRequest{
+		VolName:     mw.volname,
+		PartitionId: mp.PartitionID,
+		Inode:       inode,
+		Offset:      offset,
+		Data:        data,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaWriteInline
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("writeInline: marshal packet fail, err(%v)", err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("writeInline: send to partition fail, packet(%v) mp(%v) ino(%v) offset(%v) size(%v) err(%v)",
+			packet, mp, inode, offset, len(data), err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		if status != StatusConflictExtents {
+			log.LogWarnf("writeInline: packet(%v) mp(%v) ino(%v) offset(%v) size(%v) result(%v)",
+				packet, mp, inode, offset, len(data), packet.GetResultMsg())
+		}
+		return
+	}
+
+	resp = new(proto.WriteInlineResponse)
+	if err = packet.UnmarshalData(resp); err != nil {
+		log.LogErrorf("writeInline: packet(%v) mp(%v) err(%v) PacketData(%v)", packet, mp, err, string(packet.Data))
+		return
+	}
+	return
+}
+
+func (mw *MetaWrapper) readInline(mp *MetaPartition, inode, offset uint64, size uint32) (status int, resp *proto.ReadInlineResponse, err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("readInline", err, bgTime, 1)
+	}()
+
+	req := &proto.ReadInlineRequest{
+		VolName:     mw.volname,
+		PartitionId: mp.PartitionID,
+		Inode:       inode,
+		Offset:      offset,
+		Size:        size,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaReadInline
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("readInline: marshal packet fail, err(%v)", err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("readInline: send to partition fail, packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		log.LogWarnf("readInline: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	resp = new(proto.ReadInlineResponse)
+	if err = packet.UnmarshalData(resp); err != nil {
+		log.LogErrorf("readInline: packet(%v) mp(%v) err(%v)", packet, mp, err)
+		return
+	}
+	return
+}
+
 func (mw *MetaWrapper) inodeAccessTimeGet(mp *MetaPartition, inode uint64) (status int, info *proto.InodeAccessTime, err error) {
 	bgTime := stat.BeginStat()
 	defer func() {
@@ -3046,3 +3384,52 @@ func (mw *MetaWrapper) UpdateInodeMeta(ino uint64) (err error) {
 	}
 	return err
 }
+
+func (mw *MetaWrapper) shareExtents(mp *MetaPartition, req *proto.ShareExtentsRequest) (status int, info *proto.InodeInfo, err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("shareExtents", err, bgTime, 1)
+	}()
+
+	req.VolName = mw.volname
+	req.PartitionId = mp.PartitionID
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaShareExtents
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("shareExtents: marshal packet fail, err(%v)", err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("shareExtents: send to partition fail, packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		log.LogWarnf("shareExtents: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	resp := new(proto.ShareExtentsResponse)
+	if err = packet.UnmarshalData(resp); err != nil {
+		log.LogErrorf("shareExtents: packet(%v) mp(%v) err(%v) PacketData(%v)", packet, mp, err, string(packet.Data))
+		return
+	}
+	if resp.Info == nil {
+		err = fmt.Errorf("shareExtents: info is nil, packet(%v) mp(%v) req(%v)", packet, mp, *req)
+		log.LogWarn(err)
+		return
+	}
+	log.LogDebugf("shareExtents: packet(%v) mp(%v) req(%v) info(%v)", packet, mp, *req, resp.Info)
+	return
+}
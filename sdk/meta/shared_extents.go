@@ -0,0 +1,90 @@
+// Copyright 2024 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package meta
+
+import (
+	"syscall"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// ShareExtents_ll creates an inode in the meta partition of the source inode, which refers to the
+// extents of the source in the range [offset, offset+size) instead of copying the data. The shared
+// extents are deleted after all the inodes referring to them are deleted, so the inodes which may
+// refer to them, such as the completed object of a multipart upload, must be created in the same
+// partition by InodeCreateNear_ll.
+//
+// syscall.EPERM is returned if the extents can't be shared, e.g. the volume has snapshots or the
+// range is not fully covered by the normal extents, and the data should be copied instead.
+func (mw *MetaWrapper) ShareExtents_ll(inode, offset, size uint64, mode, uid, gid uint32, fullPath string) (*proto.InodeInfo, error) {
+	mp := mw.getPartitionByInode(inode)
+	if mp == nil {
+		log.LogErrorf("ShareExtents_ll: no such partition, ino(%v)", inode)
+		return nil, syscall.ENOENT
+	}
+	req := &proto.ShareExtentsRequest{
+		Inode:    inode,
+		Offset:   offset,
+		Size:     size,
+		Mode:     mode,
+		Uid:      uid,
+		Gid:      gid,
+		FullPath: fullPath,
+	}
+	status, info, err := mw.shareExtents(mp, req)
+	if err != nil || status != statusOK {
+		return nil, statusErrToErrno(status, err)
+	}
+	return info, nil
+}
+
+// InodeCreateNear_ll creates an inode in the meta partition of the given inode.
+func (mw *MetaWrapper) InodeCreateNear_ll(inode, parentID uint64, mode, uid, gid uint32, target []byte, fullPath string) (*proto.InodeInfo, error) {
+	mp := mw.getPartitionByInode(inode)
+	if mp == nil {
+		log.LogErrorf("InodeCreateNear_ll: no such partition, ino(%v)", inode)
+		return nil, syscall.ENOENT
+	}
+	var (
+		status int
+		info   *proto.InodeInfo
+		err    error
+	)
+	if mw.EnableQuota && parentID != 0 {
+		parentMP := mw.getPartitionByInode(parentID)
+		if parentMP == nil {
+			log.LogErrorf("InodeCreateNear_ll: no parent partition, parentID(%v)", parentID)
+			return nil, syscall.ENOENT
+		}
+		quotaInfos, err := mw.getInodeQuota(parentMP, parentID)
+		if err != nil {
+			log.LogErrorf("InodeCreateNear_ll: get parent quota fail, parentID(%v) err(%v)", parentID, err)
+			return nil, syscall.ENOENT
+		}
+		var quotaIds []uint32
+		for quotaId := range quotaInfos {
+			quotaIds = append(quotaIds, quotaId)
+		}
+		status, info, err = mw.quotaIcreate(mp, mode, uid, gid, target, quotaIds, fullPath)
+	} else {
+		status, info, err = mw.icreate(mp, mode, uid, gid, target, fullPath)
+	}
+	if err != nil || status != statusOK {
+		log.LogErrorf("InodeCreateNear_ll: mp(%v) status(%v) err(%v)", mp, status, err)
+		return nil, statusErrToErrno(status, err)
+	}
+	return info, nil
+}
@@ -36,6 +36,13 @@ func NewTP(name string) (tp *TimePoint) {
 	return
 }
 
+// NewTPFrom returns the time point started at the given time, e.g. the time when the request is received.
+func NewTPFrom(name string, start time.Time) (tp *TimePoint) {
+	tp = NewTP(name)
+	tp.startTime = start
+	return
+}
+
 func (tp *TimePoint) Set() {
 	if !enabledPrometheus {
 		return
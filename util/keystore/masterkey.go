@@ -0,0 +1,38 @@
+package keystore
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// MasterKeySize is the size of the cluster master key used to wrap data keys, which is an AES-256 key.
+const MasterKeySize = 32
+
+// LoadMasterKey loads the cluster master key from file. The file contains the key encoded in hex or
+// standard base64, leading and trailing white spaces are ignored.
+func LoadMasterKey(filename string) (key []byte, err error) {
+	var data []byte
+	if data, err = os.ReadFile(filename); err != nil {
+		return
+	}
+	return ParseMasterKey(string(data))
+}
+
+// ParseMasterKey parses a master key encoded in hex or standard base64.
+func ParseMasterKey(encoded string) (key []byte, err error) {
+	encoded = strings.TrimSpace(encoded)
+	if key, err = hex.DecodeString(encoded); err != nil || len(key) != MasterKeySize {
+		if key, err = base64.StdEncoding.DecodeString(encoded); err != nil {
+			err = fmt.Errorf("invalid master key encoding")
+			return nil, err
+		}
+	}
+	if len(key) != MasterKeySize {
+		err = fmt.Errorf("invalid master key size [%d]", len(key))
+		return nil, err
+	}
+	return
+}